@@ -8,11 +8,17 @@ import (
 	"syscall"
 	"time"
 
+	admindomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminrepository "github.com/acheevo/tfa/internal/admin/repository"
 	adminservice "github.com/acheevo/tfa/internal/admin/service"
 	admintransport "github.com/acheevo/tfa/internal/admin/transport"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
 	"github.com/acheevo/tfa/internal/auth/repository"
 	authservice "github.com/acheevo/tfa/internal/auth/service"
 	authtransport "github.com/acheevo/tfa/internal/auth/transport"
+	featurerepository "github.com/acheevo/tfa/internal/features/repository"
+	featureservice "github.com/acheevo/tfa/internal/features/service"
+	featuretransport "github.com/acheevo/tfa/internal/features/transport"
 	"github.com/acheevo/tfa/internal/health/service"
 	"github.com/acheevo/tfa/internal/health/transport"
 	"github.com/acheevo/tfa/internal/http"
@@ -20,9 +26,20 @@ import (
 	infotransport "github.com/acheevo/tfa/internal/info/transport"
 	"github.com/acheevo/tfa/internal/middleware"
 	"github.com/acheevo/tfa/internal/shared/bootstrap"
+	"github.com/acheevo/tfa/internal/shared/captcha"
+	"github.com/acheevo/tfa/internal/shared/clock"
 	"github.com/acheevo/tfa/internal/shared/config"
 	"github.com/acheevo/tfa/internal/shared/database"
+	"github.com/acheevo/tfa/internal/shared/email"
+	"github.com/acheevo/tfa/internal/shared/geoip"
+	"github.com/acheevo/tfa/internal/shared/health"
+	"github.com/acheevo/tfa/internal/shared/httpclient"
 	"github.com/acheevo/tfa/internal/shared/logger"
+	"github.com/acheevo/tfa/internal/shared/monitoring"
+	"github.com/acheevo/tfa/internal/shared/monitoring/metrics"
+	"github.com/acheevo/tfa/internal/shared/scheduler"
+	"github.com/acheevo/tfa/internal/shared/storage"
+	"github.com/acheevo/tfa/internal/shared/webhook"
 	userrepository "github.com/acheevo/tfa/internal/user/repository"
 	userservice "github.com/acheevo/tfa/internal/user/service"
 	usertransport "github.com/acheevo/tfa/internal/user/transport"
@@ -37,7 +54,20 @@ func main() {
 
 	appLogger := logger.New(cfg.LogLevel, cfg.IsDevelopment())
 
-	db, err := database.New(cfg.DatabaseDSN(), cfg.IsDevelopment(), appLogger, cfg.Environment)
+	securityLogger, err := logger.NewSecurityLogger(cfg.SecurityLogSink, cfg.SecurityLogFile, cfg.GetSecurityLogEvents())
+	if err != nil {
+		appLogger.Error("failed to create security logger", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := securityLogger.Close(); err != nil {
+			appLogger.Error("failed to close security log", "error", err)
+		}
+	}()
+
+	db, err := database.NewWithMigrationLockTimeout(
+		cfg.DatabaseDSN(), cfg.IsDevelopment(), appLogger, cfg.Environment, cfg.MigrationLockTimeoutDuration(),
+	)
 	if err != nil {
 		appLogger.Error("failed to connect to database", "error", err)
 		os.Exit(1)
@@ -58,7 +88,8 @@ func main() {
 	}
 
 	// Bootstrap demo users and initial data
-	bootstrapService := bootstrap.NewService(cfg, db.DB, appLogger)
+	emailService := authservice.NewEmailService(cfg, appLogger)
+	bootstrapService := bootstrap.NewService(cfg, db.DB, appLogger, emailService)
 	if err := bootstrapService.Bootstrap(); err != nil {
 		appLogger.Error("bootstrap failed", "error", err)
 		return
@@ -69,11 +100,45 @@ func main() {
 	refreshTokenRepo := repository.NewRefreshTokenRepository(db.DB)
 	passwordResetRepo := repository.NewPasswordResetRepository(db.DB)
 	userRepo := userrepository.NewUserRepository(db.DB)
-	auditRepo := userrepository.NewAuditRepository(db.DB)
+	auditPolicy := authdomain.NewAuditPolicy(
+		cfg.GetAuditIncludeActions(), cfg.GetAuditExcludeActions(), cfg.AuditMaxMetadataBytes,
+	)
+	auditRepo := userrepository.NewAuditRepository(db.DB, auditPolicy, appLogger)
+	featureOverrideRepo := featurerepository.NewOverrideRepository(db.DB)
+	broadcastRepo := adminrepository.NewBroadcastRepository(db.DB)
+	mergeRepo := adminrepository.NewMergeRepository(db.DB)
+	alertRepo := adminrepository.NewSecurityAlertRepository(db.DB)
+	consentRepo := userrepository.NewConsentRepository(db.DB)
+	recoveryRepo := repository.NewRecoveryRequestRepository(db.DB)
+
+	systemClock := clock.New()
+
+	// In-memory metrics are the default; the registry registers the standard metric definitions
+	metricsCollector := metrics.NewInMemoryCollector(cfg, appLogger)
+	metrics.NewMetricsRegistry(metricsCollector)
+
+	// Initialize rate limiter early so its lockout state can be reported by the admin service
+	rateLimiter := middleware.NewRateLimiter(appLogger, securityLogger, systemClock, 10, time.Minute) // 10 requests per minute
 
 	// Initialize services
-	jwtService := authservice.NewJWTService(cfg)
-	emailService := authservice.NewEmailService(cfg, appLogger)
+	claimEnricher, err := authdomain.NewUserClaimEnricher(cfg.GetJWTCustomClaims())
+	if err != nil {
+		appLogger.Error("invalid JWT_CUSTOM_CLAIMS", "error", err)
+		return
+	}
+	jwtService := authservice.NewJWTService(cfg, systemClock, claimEnricher)
+
+	// Outbound clients report their circuit breakers in the health check
+	var outboundClients []*httpclient.Client
+
+	// CAPTCHA escalation is optional; without a threshold logins never ask for one
+	var captchaVerifier authservice.CaptchaVerifier
+	if cfg.LoginCaptchaThreshold > 0 {
+		captchaClient := httpclient.New(cfg, appLogger, "captcha", metricsCollector)
+		outboundClients = append(outboundClients, captchaClient)
+		captchaVerifier = captcha.NewHTTPVerifier(cfg, captchaClient)
+	}
+
 	authService := authservice.NewAuthService(
 		cfg,
 		appLogger,
@@ -82,6 +147,12 @@ func main() {
 		passwordResetRepo,
 		jwtService,
 		emailService,
+		auditRepo,
+		systemClock,
+		securityLogger,
+		consentRepo,
+		recoveryRepo,
+		captchaVerifier,
 	)
 
 	userSvc := userservice.NewUserService(
@@ -90,28 +161,107 @@ func main() {
 		userRepo,
 		auditRepo,
 		authUserRepo,
+		consentRepo,
 	)
 
+	// The shared email queue is optional; admin queue endpoints report it as unavailable without it
+	var emailQueue admindomain.EmailQueue
+	queueService, err := email.NewService(cfg, appLogger, db.DB, nil, systemClock)
+	if err != nil {
+		appLogger.Warn("email queue service unavailable", "error", err)
+	} else {
+		emailQueue = queueService
+	}
+
+	// GeoIP enrichment is optional; without it security views show raw IP addresses only
+	var ipLocator admindomain.IPLocator
+	if cfg.GeoIPServiceURL != "" {
+		geoClient := httpclient.New(cfg, appLogger, "geoip", metricsCollector)
+		outboundClients = append(outboundClients, geoClient)
+		ipLocator = geoip.NewResolver(cfg, appLogger, geoip.NewHTTPLookup(cfg, geoClient), systemClock)
+	}
+
+	// Security alerts are always stored and logged; the webhook and email recipients are optional
+	var alertNotifier admindomain.AlertNotifier
+	if cfg.SecurityAlertWebhookURL != "" {
+		webhookClient := httpclient.New(cfg, appLogger, "security_alert_webhook", metricsCollector)
+		outboundClients = append(outboundClients, webhookClient)
+		alertNotifier = webhook.NewSender(cfg.SecurityAlertWebhookURL, cfg.SecurityAlertWebhookSecret, webhookClient)
+	}
+	if recipients := cfg.GetSecurityAlertEmailRecipients(); len(recipients) > 0 {
+		alertNotifier = adminservice.NewAlertRecipients(alertNotifier, emailService, recipients)
+	}
+
+	// Exports need file storage; only local storage is implemented, and "0" for the interval turns them off
+	var exportRepo adminservice.ExportRepo
+	var exportStorage admindomain.ExportStorage
+	if cfg.AdminExportIntervalDuration() > 0 {
+		if cfg.StorageProvider != "local" {
+			appLogger.Warn("exports unavailable", "storage_provider", cfg.StorageProvider)
+		} else if localStorage, err := storage.NewLocal(cfg); err != nil {
+			appLogger.Warn("exports unavailable", "error", err)
+		} else {
+			exportRepo = adminrepository.NewExportRepository(db.DB)
+			exportStorage = localStorage
+		}
+	}
+
 	adminSvc := adminservice.NewAdminService(
 		cfg,
 		appLogger,
 		userRepo,
 		auditRepo,
+		emailService,
+		rateLimiter,
+		securityLogger,
+		emailQueue,
+		broadcastRepo,
+		authService,
+		authService,
+		mergeRepo,
+		ipLocator,
+		alertRepo,
+		alertNotifier,
+		rateLimiter,
+		recoveryRepo,
+		exportRepo,
+		exportStorage,
+		emailService,
+		systemClock,
 	)
 
-	healthService := service.NewHealthService(cfg, db, appLogger)
+	featureSvc := featureservice.NewFeatureService(
+		cfg,
+		appLogger,
+		featureOverrideRepo,
+		userRepo,
+		auditRepo,
+	)
+
+	// The detailed health report is only shown to admins, so its checkers may expose internals
+	healthReport := health.NewEnhancedHealthService(cfg, appLogger)
+	healthReport.RegisterChecker(health.NewDatabaseHealthChecker("database", db.DB))
+	healthReport.RegisterChecker(health.NewMemoryHealthChecker("memory"))
+	if queueService != nil {
+		healthReport.RegisterChecker(health.NewEmailHealthChecker("email_queue", queueService, cfg.EmailQueueAlertDepth))
+	}
+	for _, client := range outboundClients {
+		healthReport.RegisterChecker(health.NewCircuitBreakerHealthChecker(client))
+	}
+
+	healthService := service.NewHealthService(cfg, db, appLogger, outboundClients, healthReport)
 	infoSvc := infoservice.NewInfoService(cfg, db, appLogger)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(appLogger, authService)
-	rbacMiddleware := middleware.NewRBACMiddleware(appLogger, authService)
-	rateLimiter := middleware.NewRateLimiter(appLogger, 10, time.Minute) // 10 requests per minute
+	rbacMiddleware := middleware.NewRBACMiddleware(appLogger, securityLogger, authService, cfg.AuthzDecisionLog)
 
 	// Initialize handlers
 	authHandler := authtransport.NewAuthHandler(cfg, appLogger, authService)
 	userHandler := usertransport.NewUserHandler(cfg, appLogger, userSvc)
 	adminHandler := admintransport.NewAdminHandler(cfg, appLogger, adminSvc)
-	healthHandler := transport.NewHealthHandler(healthService)
+	featureHandler := featuretransport.NewFeatureHandler(cfg, appLogger, featureSvc)
+	healthHandler := transport.NewHealthHandler(cfg, healthService)
 	infoHandler := infotransport.NewInfoHandler(infoSvc)
 
 	server := http.NewServer(
@@ -122,11 +272,102 @@ func main() {
 		authHandler,
 		userHandler,
 		adminHandler,
+		featureHandler,
 		authMiddleware,
 		rbacMiddleware,
 		rateLimiter,
+		metricsCollector,
 	)
 
+	// Background jobs
+	jobScheduler := scheduler.New(appLogger)
+	jobScheduler.Register(scheduler.Job{
+		Name:     "purge_deleted_users",
+		Interval: cfg.UserPurgeIntervalDuration(),
+		Run: func(ctx context.Context) error {
+			_, err := adminSvc.PurgeDeletedUsers(ctx)
+			return err
+		},
+	})
+	if cfg.AdminDormantDemotion {
+		jobScheduler.Register(scheduler.Job{
+			Name:     "demote_dormant_admins",
+			Interval: cfg.AdminDormantCheckIntervalDuration(),
+			Run: func(ctx context.Context) error {
+				_, err := adminSvc.DemoteDormantAdmins(ctx, systemClock.Now())
+				return err
+			},
+		})
+	}
+	jobScheduler.Register(scheduler.Job{
+		Name:     "check_security_thresholds",
+		Interval: cfg.SecurityAlertIntervalDuration(),
+		Run: func(ctx context.Context) error {
+			_, err := adminSvc.CheckSecurityThresholds(ctx)
+			return err
+		},
+	})
+	if exportRepo != nil {
+		jobScheduler.Register(scheduler.Job{
+			Name:     "run_exports",
+			Interval: cfg.AdminExportIntervalDuration(),
+			Run: func(ctx context.Context) error {
+				_, err := adminSvc.RunExports(ctx)
+				return err
+			},
+		})
+	}
+	jobScheduler.Register(scheduler.Job{
+		Name:     "fail_stale_broadcasts",
+		Interval: cfg.BroadcastStaleAfterDuration(),
+		Run: func(ctx context.Context) error {
+			_, err := adminSvc.FailStaleBroadcasts(ctx)
+			return err
+		},
+	})
+	businessMetrics := monitoring.NewBusinessMetricsRecorder(metricsCollector)
+	jobScheduler.Register(scheduler.Job{
+		Name:     "measure_user_activity",
+		Interval: cfg.UserActivityMetricsIntervalDuration(),
+		Run: func(ctx context.Context) error {
+			activity, err := adminSvc.MeasureUserActivity(ctx, systemClock.Now())
+			if err != nil {
+				return err
+			}
+			businessMetrics.RecordActiveUsers(float64(activity.Active))
+			businessMetrics.RecordLoggedInUsers("1d", float64(activity.DailyActive))
+			businessMetrics.RecordLoggedInUsers("30d", float64(activity.MonthlyActive))
+			return nil
+		},
+	})
+	if queueService != nil {
+		emailMetrics := monitoring.NewEmailMetricsRecorder(metricsCollector)
+		jobScheduler.Register(scheduler.Job{
+			Name:     "retry_failed_emails",
+			Interval: cfg.EmailRetryIntervalDuration(),
+			Run: func(ctx context.Context) error {
+				retried, err := queueService.RetryFailed(ctx)
+				emailMetrics.RecordEmailsRetried(retried)
+				return err
+			},
+		})
+		jobScheduler.Register(scheduler.Job{
+			Name:     "monitor_email_queue",
+			Interval: cfg.EmailQueueMonitorIntervalDuration(),
+			Run: func(ctx context.Context) error {
+				stats, err := queueService.GetQueueStats(ctx)
+				if err != nil {
+					return err
+				}
+				emailMetrics.RecordQueueStats(stats, systemClock.Now())
+				_, err = adminSvc.CheckEmailQueue(ctx, stats)
+				return err
+			},
+		})
+	}
+	jobScheduler.Start(context.Background())
+	defer jobScheduler.Stop()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
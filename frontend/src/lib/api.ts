@@ -29,6 +29,7 @@ export interface NotificationPrefs {
   email: boolean;
   sms: boolean;
   push: boolean;
+  marketing?: boolean;
 }
 
 export interface PrivacyPrefs {
@@ -58,6 +59,34 @@ export interface RegisterRequest {
   password: string;
   first_name: string;
   last_name: string;
+  marketing_opt_in?: boolean;
+}
+
+export type ConsentType = 'terms_of_service' | 'privacy_policy' | 'marketing_emails';
+
+export interface ConsentStatus {
+  type: ConsentType;
+  granted: boolean;
+  version?: string;
+  current_version: string;
+  up_to_date: boolean;
+  recorded_at?: string;
+}
+
+export interface UserConsent {
+  id: number;
+  type: ConsentType;
+  granted: boolean;
+  version: string;
+  source: 'registration' | 'preferences' | 'api';
+  ip_address?: string;
+  user_agent?: string;
+  created_at: string;
+}
+
+export interface ConsentRecordResponse {
+  consents: ConsentStatus[];
+  history: UserConsent[];
 }
 
 export interface LoginRequest {
@@ -126,8 +155,7 @@ export interface UserListRequest {
   search?: string;
   role?: 'user' | 'admin';
   status?: 'active' | 'inactive' | 'suspended';
-  sort_by?: string;
-  sort_order?: 'asc' | 'desc';
+  sort?: string; // "field:asc" or "field:desc"
 }
 
 export interface UserListResponse {
@@ -263,6 +291,28 @@ export interface AdminAuditLogResponse {
   pagination: Pagination;
 }
 
+export interface UserAuditLogRequest {
+  page?: number;
+  page_size?: number;
+  action?: string;
+  level?: 'info' | 'warning' | 'error';
+  search?: string;
+  date_from?: string;
+  date_to?: string;
+}
+
+export interface UserAuditLogResponse {
+  logs: AuditLogEntry[];
+  pagination: Pagination;
+}
+
+export interface IPLocation {
+  city?: string;
+  region?: string;
+  country?: string;
+  country_code?: string;
+}
+
 export interface EnhancedAuditLogEntry {
   id: number;
   action: string;
@@ -270,6 +320,7 @@ export interface EnhancedAuditLogEntry {
   resource: string;
   description: string;
   ip_address: string;
+  location?: IPLocation;
   user_agent: string;
   metadata?: Record<string, any>;
   created_at: string;
@@ -289,6 +340,7 @@ export interface AuditLogEntry {
   resource: string;
   description: string;
   ip_address: string;
+  location?: IPLocation;
   user_agent: string;
   metadata?: Record<string, any>;
   created_at: string;
@@ -478,6 +530,17 @@ class ApiClient {
     });
   }
 
+  async getConsents(): Promise<ConsentRecordResponse> {
+    return this.request<ConsentRecordResponse>('/auth/me/consents');
+  }
+
+  async updateConsent(type: ConsentType, granted: boolean): Promise<ConsentRecordResponse> {
+    return this.request<ConsentRecordResponse>('/auth/me/consents', {
+      method: 'POST',
+      body: JSON.stringify({ type, granted }),
+    });
+  }
+
   async changeEmail(data: ChangeEmailRequest): Promise<MessageResponse> {
     return this.request<MessageResponse>('/user/change-email', {
       method: 'POST',
@@ -497,8 +560,7 @@ class ApiClient {
     if (params?.search) searchParams.append('search', params.search);
     if (params?.role) searchParams.append('role', params.role);
     if (params?.status) searchParams.append('status', params.status);
-    if (params?.sort_by) searchParams.append('sort_by', params.sort_by);
-    if (params?.sort_order) searchParams.append('sort_order', params.sort_order);
+    if (params?.sort) searchParams.append('sort', params.sort);
 
     const queryString = searchParams.toString();
     const endpoint = queryString ? `/admin/users?${queryString}` : '/admin/users';
@@ -531,6 +593,18 @@ class ApiClient {
     });
   }
 
+  async resendUserVerification(userId: number): Promise<MessageResponse> {
+    return this.request<MessageResponse>(`/admin/users/${userId}/resend-verification`, {
+      method: 'POST',
+    });
+  }
+
+  async sendUserPasswordReset(userId: number): Promise<MessageResponse> {
+    return this.request<MessageResponse>(`/admin/users/${userId}/send-reset`, {
+      method: 'POST',
+    });
+  }
+
   async deleteUsers(userIds: number[], data: DeleteUserRequest): Promise<MessageResponse> {
     const idsParam = userIds.join(',');
     return this.request<MessageResponse>(`/admin/users?ids=${idsParam}`, {
@@ -569,6 +643,22 @@ class ApiClient {
     return this.request<AdminAuditLogResponse>(endpoint);
   }
 
+  async getUserAuditLogs(userId: number, params?: UserAuditLogRequest): Promise<UserAuditLogResponse> {
+    const searchParams = new URLSearchParams();
+    if (params?.page) searchParams.append('page', params.page.toString());
+    if (params?.page_size) searchParams.append('page_size', params.page_size.toString());
+    if (params?.action) searchParams.append('action', params.action);
+    if (params?.level) searchParams.append('level', params.level);
+    if (params?.search) searchParams.append('search', params.search);
+    if (params?.date_from) searchParams.append('date_from', params.date_from);
+    if (params?.date_to) searchParams.append('date_to', params.date_to);
+
+    const queryString = searchParams.toString();
+    const endpoint = queryString ? `/admin/users/${userId}/audit?${queryString}` : `/admin/users/${userId}/audit`;
+
+    return this.request<UserAuditLogResponse>(endpoint);
+  }
+
   // Health check
   async healthCheck(): Promise<{ status: string; timestamp: string }> {
     return this.request<{ status: string; timestamp: string }>('/health');
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// ActivityType classifies an entry in the admin activity feed
+type ActivityType string
+
+const (
+	ActivityRegistration  ActivityType = "registration"
+	ActivityRoleChange    ActivityType = "role_change"
+	ActivityDeletion      ActivityType = "deletion"
+	ActivitySecurityAlert ActivityType = "security_alert"
+)
+
+// ActivityTypes lists every feed type; events at the same instant are ordered by their type's position
+var ActivityTypes = []ActivityType{ActivitySecurityAlert, ActivityDeletion, ActivityRoleChange, ActivityRegistration}
+
+// MaxActivityDepth bounds how far back the feed pages. Every source is read page*page_size entries
+// deep to merge a page, so deeper pages are refused rather than loading whole tables.
+const MaxActivityDepth = 1000
+
+// ActivityFeedRequest selects a page of the admin activity feed
+type ActivityFeedRequest struct {
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=20" binding:"min=1,max=100"`
+	Types    string `form:"types"` // Comma-separated ActivityTypes; empty includes every type
+}
+
+// ActivityItem is one significant event in the feed
+type ActivityItem struct {
+	Type       ActivityType `json:"type"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	Summary    string       `json:"summary"`
+	Level      string       `json:"level"`
+	ActorID    *uint        `json:"actor_id,omitempty"`
+	TargetID   *uint        `json:"target_id,omitempty"`
+	SourceID   string       `json:"source_id"` // ID of the user, audit entry or alert the event comes from
+}
+
+// ActivityFeedResponse is a page of the admin activity feed
+type ActivityFeedResponse struct {
+	Items      []*ActivityItem       `json:"items"`
+	Pagination userdomain.Pagination `json:"pagination"`
+}
+
+// ParseActivityTypes returns the comma-separated activity types, or every type when none are given
+func ParseActivityTypes(types string) ([]ActivityType, error) {
+	if strings.TrimSpace(types) == "" {
+		return ActivityTypes, nil
+	}
+
+	var selected []ActivityType
+	seen := make(map[ActivityType]bool)
+	for _, name := range strings.Split(types, ",") {
+		activityType := ActivityType(strings.TrimSpace(name))
+		if activityTypeRank(activityType) < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidActivityType, name)
+		}
+		if !seen[activityType] {
+			seen[activityType] = true
+			selected = append(selected, activityType)
+		}
+	}
+	return selected, nil
+}
+
+// MergeActivity merges the per-type feeds into one feed, newest first, and returns the requested page
+func MergeActivity(page, pageSize int, feeds ...[]*ActivityItem) []*ActivityItem {
+	var merged []*ActivityItem
+	for _, feed := range feeds {
+		merged = append(merged, feed...)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if !merged[i].OccurredAt.Equal(merged[j].OccurredAt) {
+			return merged[i].OccurredAt.After(merged[j].OccurredAt)
+		}
+		return activityTypeRank(merged[i].Type) < activityTypeRank(merged[j].Type)
+	})
+
+	start := (page - 1) * pageSize
+	if start >= len(merged) {
+		return []*ActivityItem{}
+	}
+	end := start + pageSize
+	if end > len(merged) {
+		end = len(merged)
+	}
+	return merged[start:end]
+}
+
+// RegistrationActivity describes a user signing up
+func RegistrationActivity(user *authdomain.User) *ActivityItem {
+	userID := user.ID
+	return &ActivityItem{
+		Type:       ActivityRegistration,
+		OccurredAt: user.CreatedAt,
+		Summary:    fmt.Sprintf("%s registered", user.Email),
+		Level:      string(authdomain.AuditLevelInfo),
+		TargetID:   &userID,
+		SourceID:   fmt.Sprintf("%d", user.ID),
+	}
+}
+
+// AuditActivity describes an audited role change or deletion
+func AuditActivity(activityType ActivityType, log *authdomain.AuditLog) *ActivityItem {
+	return &ActivityItem{
+		Type:       activityType,
+		OccurredAt: log.CreatedAt,
+		Summary:    log.Description,
+		Level:      string(log.Level),
+		ActorID:    log.UserID,
+		TargetID:   log.TargetID,
+		SourceID:   fmt.Sprintf("%d", log.ID),
+	}
+}
+
+// AlertActivity describes a raised security alert
+func AlertActivity(alert *authdomain.SecurityAlert) *ActivityItem {
+	item := &ActivityItem{
+		Type:       ActivitySecurityAlert,
+		OccurredAt: alert.CreatedAt,
+		Summary:    alert.Title,
+		Level:      alert.Severity,
+		SourceID:   alert.ID,
+	}
+	if alert.AdminID != 0 {
+		adminID := alert.AdminID
+		item.ActorID = &adminID
+	}
+	return item
+}
+
+// activityTypeRank returns the type's position in ActivityTypes, or -1 for an unknown type
+func activityTypeRank(activityType ActivityType) int {
+	for i, known := range ActivityTypes {
+		if known == activityType {
+			return i
+		}
+	}
+	return -1
+}
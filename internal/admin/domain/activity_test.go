@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+func TestParseActivityTypes(t *testing.T) {
+	types, err := ParseActivityTypes("")
+	assert.NoError(t, err)
+	assert.Equal(t, ActivityTypes, types)
+
+	types, err = ParseActivityTypes("registration, security_alert,registration")
+	assert.NoError(t, err)
+	assert.Equal(t, []ActivityType{ActivityRegistration, ActivitySecurityAlert}, types)
+
+	_, err = ParseActivityTypes("registration,logins")
+	assert.ErrorIs(t, err, ErrInvalidActivityType)
+}
+
+func TestMergeActivity(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	registrations := []*ActivityItem{
+		RegistrationActivity(&authdomain.User{ID: 3, Email: "c@example.com", CreatedAt: at(50)}),
+		RegistrationActivity(&authdomain.User{ID: 2, Email: "b@example.com", CreatedAt: at(30)}),
+		RegistrationActivity(&authdomain.User{ID: 1, Email: "a@example.com", CreatedAt: at(10)}),
+	}
+	deletions := []*ActivityItem{
+		AuditActivity(ActivityDeletion, &authdomain.AuditLog{ID: 8, Description: "deleted", CreatedAt: at(40)}),
+		AuditActivity(ActivityDeletion, &authdomain.AuditLog{ID: 7, Description: "deleted", CreatedAt: at(10)}),
+	}
+	alerts := []*ActivityItem{
+		AlertActivity(&authdomain.SecurityAlert{ID: "alert-1", Title: "Mass deletion", Severity: "high", CreatedAt: at(45)}),
+	}
+
+	sources := func(items []*ActivityItem) []string {
+		ids := make([]string, len(items))
+		for i, item := range items {
+			ids[i] = string(item.Type) + ":" + item.SourceID
+		}
+		return ids
+	}
+
+	// Newest first across sources; a deletion and a registration at the same instant put the deletion first
+	all := MergeActivity(1, 10, registrations, deletions, alerts)
+	assert.Equal(t, []string{
+		"registration:3", "security_alert:alert-1", "deletion:8", "registration:2", "deletion:7", "registration:1",
+	}, sources(all))
+
+	assert.Equal(t, []string{"deletion:8", "registration:2"}, sources(MergeActivity(2, 2, registrations, deletions, alerts)))
+	assert.Empty(t, MergeActivity(4, 2, registrations, deletions, alerts))
+
+	// Only the selected sources are merged
+	assert.Equal(t, []string{"security_alert:alert-1", "deletion:8", "deletion:7"}, sources(MergeActivity(1, 10, deletions, alerts)))
+}
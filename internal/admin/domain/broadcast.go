@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"time"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// BroadcastStatus represents the progress of an email broadcast
+type BroadcastStatus string
+
+const (
+	BroadcastStatusRunning   BroadcastStatus = "running"
+	BroadcastStatusCompleted BroadcastStatus = "completed"
+	BroadcastStatusFailed    BroadcastStatus = "failed"
+)
+
+// BroadcastEmailRequest represents a request to email every user matching a filter
+type BroadcastEmailRequest struct {
+	TemplateID string                 `json:"template_id" binding:"required"`
+	Variables  map[string]interface{} `json:"variables"`
+	Essential  bool                   `json:"essential"`
+	ConfirmAll bool                   `json:"confirm_all"`
+	Reason     string                 `json:"reason" binding:"required,min=1,max=255"`
+}
+
+// BroadcastJob tracks an email broadcast while its recipients are queued
+type BroadcastJob struct {
+	ID                 string          `json:"id" gorm:"primaryKey;size:36"`
+	AdminID            uint            `json:"admin_id" gorm:"not null;index"`
+	TemplateID         string          `json:"template_id" gorm:"not null"`
+	FilterSearch       string          `json:"filter_search,omitempty"`
+	FilterRole         string          `json:"filter_role,omitempty"`
+	FilterStatus       string          `json:"filter_status,omitempty"`
+	Essential          bool            `json:"essential"`
+	Reason             string          `json:"reason"`
+	Status             BroadcastStatus `json:"status" gorm:"not null;index"`
+	Matched            int             `json:"matched"`
+	Queued             int             `json:"queued"`
+	SkippedPreferences int             `json:"skipped_preferences"`
+	SkippedSuppressed  int             `json:"skipped_suppressed"`
+	Failed             int             `json:"failed"`
+	Error              string          `json:"error,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	CompletedAt        *time.Time      `json:"completed_at,omitempty"`
+}
+
+// HasBroadcastFilter reports whether a user filter narrows a broadcast below every user
+func HasBroadcastFilter(filter *userdomain.UserListRequest) bool {
+	return filter.Search != "" || filter.Role != "" || filter.Status != ""
+}
+
+// ShouldReceiveBroadcast reports whether a user has opted in to a broadcast; essential mail
+// ignores notification preferences
+func ShouldReceiveBroadcast(user *authdomain.User, essential bool) bool {
+	return essential || user.Preferences.Notifications.Email
+}
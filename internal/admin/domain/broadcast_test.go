@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+func TestHasBroadcastFilter(t *testing.T) {
+	assert.False(t, HasBroadcastFilter(&userdomain.UserListRequest{Page: 2, Sort: "email:asc"}))
+	assert.True(t, HasBroadcastFilter(&userdomain.UserListRequest{Search: "example.com"}))
+	assert.True(t, HasBroadcastFilter(&userdomain.UserListRequest{Role: authdomain.RoleUser}))
+	assert.True(t, HasBroadcastFilter(&userdomain.UserListRequest{Status: authdomain.StatusActive}))
+}
+
+func TestShouldReceiveBroadcast(t *testing.T) {
+	optedIn := &authdomain.User{}
+	optedIn.Preferences.Notifications.Email = true
+	optedOut := &authdomain.User{}
+
+	assert.True(t, ShouldReceiveBroadcast(optedIn, false))
+	assert.False(t, ShouldReceiveBroadcast(optedOut, false))
+	assert.True(t, ShouldReceiveBroadcast(optedOut, true))
+}
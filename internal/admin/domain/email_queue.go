@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// EmailQueue exposes the email queue operations available to operators
+type EmailQueue interface {
+	ProcessQueue(ctx context.Context) error
+	GetQueueStats(ctx context.Context) (*emaildomain.QueueStats, error)
+	CancelScheduled(ctx context.Context, messageID string) error
+	GetTemplate(templateID string) (*emaildomain.EmailTemplate, error)
+	ScheduleTemplate(
+		ctx context.Context,
+		templateID string,
+		to []string,
+		variables map[string]interface{},
+		metadata map[string]string,
+		scheduledAt time.Time,
+	) error
+	IsSuppressed(address string) bool
+	ListQueuedEmails(ctx context.Context, filter *emaildomain.QueuedEmailFilter) ([]*emaildomain.QueuedEmail, int, error)
+	ListDeliveryEvents(ctx context.Context, filter *emaildomain.DeliveryEventFilter) ([]*emaildomain.EmailDeliveryEvent, int, error)
+}
+
+// EmailQueueProcessResponse reports the queue state before and after a manual processing cycle
+type EmailQueueProcessResponse struct {
+	Message string                  `json:"message"`
+	Before  *emaildomain.QueueStats `json:"before"`
+	After   *emaildomain.QueueStats `json:"after"`
+}
+
+// EmailListRequest represents a request to page through queued emails
+type EmailListRequest struct {
+	Page       int                     `form:"page,default=1" binding:"min=1"`
+	PageSize   int                     `form:"page_size,default=50" binding:"min=1,max=100"`
+	Status     emaildomain.EmailStatus `form:"status" binding:"omitempty,oneof=pending sending sent failed retrying canceled"`
+	Recipient  string                  `form:"recipient"`
+	TemplateID string                  `form:"template_id"`
+	DateFrom   *time.Time              `form:"date_from" time_format:"2006-01-02"`
+	DateTo     *time.Time              `form:"date_to" time_format:"2006-01-02"`
+}
+
+// ToFilter converts the request to a queue filter
+func (r *EmailListRequest) ToFilter() *emaildomain.QueuedEmailFilter {
+	return &emaildomain.QueuedEmailFilter{
+		Status:     r.Status,
+		Recipient:  r.Recipient,
+		TemplateID: r.TemplateID,
+		DateFrom:   r.DateFrom,
+		DateTo:     r.DateTo,
+		Page:       r.Page,
+		PageSize:   r.PageSize,
+	}
+}
+
+// EmailListResponse represents a page of queued emails
+type EmailListResponse struct {
+	Emails     []*emaildomain.QueuedEmail `json:"emails"`
+	Pagination userdomain.Pagination      `json:"pagination"`
+}
+
+// EmailEventListRequest represents a request to page through delivery events
+type EmailEventListRequest struct {
+	Page     int        `form:"page,default=1" binding:"min=1"`
+	PageSize int        `form:"page_size,default=50" binding:"min=1,max=100"`
+	EmailID  string     `form:"email_id"`
+	Event    string     `form:"event" binding:"omitempty,oneof=sent delivered opened clicked bounced complained"`
+	DateFrom *time.Time `form:"date_from" time_format:"2006-01-02"`
+	DateTo   *time.Time `form:"date_to" time_format:"2006-01-02"`
+}
+
+// ToFilter converts the request to a delivery event filter
+func (r *EmailEventListRequest) ToFilter() *emaildomain.DeliveryEventFilter {
+	return &emaildomain.DeliveryEventFilter{
+		EmailID:  r.EmailID,
+		Event:    r.Event,
+		DateFrom: r.DateFrom,
+		DateTo:   r.DateTo,
+		Page:     r.Page,
+		PageSize: r.PageSize,
+	}
+}
+
+// EmailEventListResponse represents a page of delivery events
+type EmailEventListResponse struct {
+	Events     []*emaildomain.EmailDeliveryEvent `json:"events"`
+	Pagination userdomain.Pagination             `json:"pagination"`
+}
+
+// Alert types raised by the email queue monitor
+const (
+	AlertTypeEmailQueueDepth = "email_queue_depth"
+	AlertTypeEmailQueueAge   = "email_queue_age"
+)
+
+// EmailQueueAlerts returns the alerts a queue snapshot calls for: too many pending emails, or a due
+// email left waiting too long. A zero maxDepth or maxAge disables that alert.
+func EmailQueueAlerts(
+	stats *emaildomain.QueueStats,
+	maxDepth int,
+	maxAge time.Duration,
+	now time.Time,
+) []*authdomain.SecurityAlert {
+	var alerts []*authdomain.SecurityAlert
+
+	if maxDepth > 0 && stats.Pending >= int64(maxDepth) {
+		alerts = append(alerts, &authdomain.SecurityAlert{
+			ID:          uuid.New().String(),
+			Type:        AlertTypeEmailQueueDepth,
+			Severity:    "high",
+			Title:       "Email queue backing up",
+			Description: fmt.Sprintf("%d pending emails reached the alert threshold of %d", stats.Pending, maxDepth),
+			Data: map[string]interface{}{
+				"pending":   stats.Pending,
+				"threshold": maxDepth,
+			},
+			CreatedAt: now,
+		})
+	}
+
+	if maxAge > 0 && stats.OldestPendingAt != nil {
+		if waited := now.Sub(*stats.OldestPendingAt).Round(time.Second); waited >= maxAge {
+			alerts = append(alerts, &authdomain.SecurityAlert{
+				ID:       uuid.New().String(),
+				Type:     AlertTypeEmailQueueAge,
+				Severity: "high",
+				Title:    "Emails waiting too long to send",
+				Description: fmt.Sprintf("The oldest pending email has waited %s, reaching the alert threshold of %s",
+					waited, maxAge),
+				Data: map[string]interface{}{
+					"oldest_pending_at": *stats.OldestPendingAt,
+					"waited":            waited.String(),
+					"threshold":         maxAge.String(),
+				},
+				CreatedAt: now,
+			})
+		}
+	}
+
+	return alerts
+}
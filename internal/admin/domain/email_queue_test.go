@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+func TestEmailQueueAlerts(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	oldest := now.Add(-45 * time.Minute)
+
+	assert.Empty(t, EmailQueueAlerts(&emaildomain.QueueStats{Pending: 999}, 1000, 30*time.Minute, now))
+
+	alerts := EmailQueueAlerts(&emaildomain.QueueStats{Pending: 1000, OldestPendingAt: &oldest}, 1000, 30*time.Minute, now)
+	if assert.Len(t, alerts, 2) {
+		assert.Equal(t, AlertTypeEmailQueueDepth, alerts[0].Type)
+		assert.Equal(t, "1000 pending emails reached the alert threshold of 1000", alerts[0].Description)
+		assert.Equal(t, AlertTypeEmailQueueAge, alerts[1].Type)
+		assert.Equal(t, "The oldest pending email has waited 45m0s, reaching the alert threshold of 30m0s", alerts[1].Description)
+	}
+
+	// Zero disables a threshold
+	assert.Empty(t, EmailQueueAlerts(&emaildomain.QueueStats{Pending: 5000, OldestPendingAt: &oldest}, 0, 0, now))
+}
@@ -4,22 +4,78 @@ import "errors"
 
 // Admin management errors
 var (
-	ErrNotAuthorized     = errors.New("not authorized for admin operations")
-	ErrCannotManageSelf  = errors.New("cannot manage own account through admin interface")
-	ErrBulkActionFailed  = errors.New("bulk action failed")
-	ErrAuditLogNotFound  = errors.New("audit log not found")
-	ErrSystemHealthCheck = errors.New("system health check failed")
-	ErrInvalidDateRange  = errors.New("invalid date range")
-	ErrTooManyUsers      = errors.New("too many users selected for bulk action")
+	ErrNotAuthorized        = errors.New("not authorized for admin operations")
+	ErrCannotManageSelf     = errors.New("cannot manage own account through admin interface")
+	ErrBulkActionFailed     = errors.New("bulk action failed")
+	ErrAuditLogNotFound     = errors.New("audit log not found")
+	ErrSystemHealthCheck    = errors.New("system health check failed")
+	ErrInvalidDateRange     = errors.New("invalid date range")
+	ErrTooManyUsers         = errors.New("too many users selected for bulk action")
+	ErrInvalidWindow        = errors.New("invalid stats window")
+	ErrEmailQueueOffline    = errors.New("email queue is not available")
+	ErrLastAdmin            = errors.New("cannot permanently delete the last admin")
+	ErrSuperAdminRequired   = errors.New("action requires a super admin")
+	ErrLastSuperAdmin       = errors.New("cannot remove the last super admin")
+	ErrLastActiveAdmin      = errors.New("cannot remove the last active admin")
+	ErrBroadcastNoFilter    = errors.New("broadcast without a user filter requires confirm_all")
+	ErrBroadcastNotFound    = errors.New("broadcast not found")
+	ErrAccountEmailsOffline = errors.New("account emails are not available")
+	ErrMergeSameUser        = errors.New("cannot merge a user into itself")
+	ErrMergeTargetInactive  = errors.New("merge target must be an active account")
+	ErrMergeRoleConflict    = errors.New("merge source has a higher role than the target")
+	ErrOperationBusy        = errors.New("too many operations of this kind are already running")
+	ErrAlertNotFound        = errors.New("security alert not found")
+	ErrInvalidActivityType  = errors.New("invalid activity type")
+	ErrActivityPageTooDeep  = errors.New("activity feed page is too deep")
+	ErrUserNotPending       = errors.New("user is not pending approval")
+	ErrSelfInBulkAction     = errors.New("bulk action includes your own account")
+	ErrTooManyAlertStreams  = errors.New("too many security alert streams are open")
+	ErrInvalidCursor        = errors.New("invalid pagination cursor")
+	ErrCursorFilterMismatch = errors.New("pagination cursor was issued for different filters")
+	ErrSessionsOffline      = errors.New("session management is not available")
+	ErrExportsOffline       = errors.New("exports are not available")
+	ErrExportNotFound       = errors.New("export not found")
+	ErrExportUserRequired   = errors.New("evidence export requires user_id")
+	ErrExportLinkInvalid    = errors.New("export download link is invalid")
+	ErrExportLinkExpired    = errors.New("export download link has expired")
+	ErrExportAccessRevoked  = errors.New("export requester no longer has access")
 )
 
 // IsAdminError checks if the error is an admin management error
 func IsAdminError(err error) bool {
 	return err == ErrNotAuthorized ||
 		err == ErrCannotManageSelf ||
+		err == ErrSelfInBulkAction ||
+		err == ErrTooManyAlertStreams ||
+		err == ErrSessionsOffline ||
 		err == ErrBulkActionFailed ||
 		err == ErrAuditLogNotFound ||
 		err == ErrSystemHealthCheck ||
 		err == ErrInvalidDateRange ||
-		err == ErrTooManyUsers
+		err == ErrInvalidCursor ||
+		err == ErrCursorFilterMismatch ||
+		err == ErrTooManyUsers ||
+		err == ErrInvalidWindow ||
+		err == ErrEmailQueueOffline ||
+		err == ErrLastAdmin ||
+		err == ErrSuperAdminRequired ||
+		err == ErrLastSuperAdmin ||
+		err == ErrLastActiveAdmin ||
+		err == ErrBroadcastNoFilter ||
+		err == ErrBroadcastNotFound ||
+		err == ErrAccountEmailsOffline ||
+		err == ErrMergeSameUser ||
+		err == ErrMergeTargetInactive ||
+		err == ErrMergeRoleConflict ||
+		err == ErrOperationBusy ||
+		err == ErrAlertNotFound ||
+		errors.Is(err, ErrInvalidActivityType) ||
+		err == ErrActivityPageTooDeep ||
+		err == ErrUserNotPending ||
+		err == ErrExportsOffline ||
+		err == ErrExportNotFound ||
+		err == ErrExportUserRequired ||
+		err == ErrExportLinkInvalid ||
+		err == ErrExportLinkExpired ||
+		err == ErrExportAccessRevoked
 }
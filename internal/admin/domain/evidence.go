@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// EvidenceFormatVersion is bumped whenever the files in an evidence bundle change shape
+const EvidenceFormatVersion = 1
+
+// Files in a user evidence bundle. Record files hold one JSON object per line, newest first.
+const (
+	EvidenceFileManifest       = "manifest.json"
+	EvidenceFileUser           = "user.json"
+	EvidenceFileAuditLog       = "audit_log.jsonl"
+	EvidenceFileRoleChanges    = "role_changes.jsonl"
+	EvidenceFileLoginHistory   = "login_history.jsonl"
+	EvidenceFileSecurityAlerts = "security_alerts.jsonl"
+)
+
+// UserEvidence is an evidence export the admin has been authorized for, ready to be written
+type UserEvidence struct {
+	User        *authdomain.User
+	AdminID     uint
+	AdminEmail  string
+	GeneratedAt time.Time
+}
+
+// Filename names the bundle after the user and when it was generated
+func (e *UserEvidence) Filename() string {
+	return fmt.Sprintf("user-%d-evidence-%s.zip", e.User.ID, e.GeneratedAt.UTC().Format("20060102T150405Z"))
+}
+
+// EvidenceManifest describes an evidence bundle: who generated it and when, and a checksum of every
+// other file so tampering after export can be detected
+type EvidenceManifest struct {
+	FormatVersion    int            `json:"format_version"`
+	UserID           uint           `json:"user_id"`
+	UserEmail        string         `json:"user_email"`
+	GeneratedAt      time.Time      `json:"generated_at"`
+	GeneratedBy      uint           `json:"generated_by"`
+	GeneratedByEmail string         `json:"generated_by_email"`
+	AppVersion       string         `json:"app_version"`
+	Files            []EvidenceFile `json:"files"`
+}
+
+// EvidenceFile describes one file in an evidence bundle
+type EvidenceFile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Records     int    `json:"records"`
+	SHA256      string `json:"sha256"`
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// ExportType is the kind of data an export job produces
+type ExportType string
+
+const (
+	// ExportTypeUsers is every user matching a filter, one JSON object per line
+	ExportTypeUsers ExportType = "users"
+	// ExportTypeAuditLogs is every audit entry matching a filter, one JSON object per line
+	ExportTypeAuditLogs ExportType = "audit_logs"
+	// ExportTypeEvidence is a user's evidence bundle, the same zip the evidence endpoint streams
+	ExportTypeEvidence ExportType = "evidence"
+)
+
+// ExportStatus represents the progress of an export job
+type ExportStatus string
+
+const (
+	ExportStatusPending   ExportStatus = "pending"
+	ExportStatusRunning   ExportStatus = "running"
+	ExportStatusCompleted ExportStatus = "completed"
+	ExportStatusFailed    ExportStatus = "failed"
+	ExportStatusExpired   ExportStatus = "expired"
+)
+
+// CreateExportRequest represents a request to export data in the background. The filters narrow a
+// users or audit_logs export; user_id is the subject of an evidence export and the actor of an
+// audit_logs one.
+type CreateExportRequest struct {
+	Type   ExportType `json:"type" binding:"required,oneof=users audit_logs evidence"`
+	UserID *uint      `json:"user_id"`
+	Search string     `json:"search" binding:"max=255"`
+	Role   string     `json:"role" binding:"omitempty,oneof=user admin super_admin"`
+	Status string     `json:"status" binding:"omitempty,oneof=active inactive suspended pending"`
+	Action string     `json:"action" binding:"max=100"`
+	Level  string     `json:"level" binding:"omitempty,oneof=info warning error"`
+	Reason string     `json:"reason" binding:"required,min=1,max=255"`
+}
+
+// ExportJob tracks an export from request until its file expires. The file is written to storage
+// under StorageKey and is only handed out through signed download links.
+type ExportJob struct {
+	ID           string       `json:"id" gorm:"primaryKey;size:36"`
+	AdminID      uint         `json:"admin_id" gorm:"not null;index"`
+	Type         ExportType   `json:"type" gorm:"not null"`
+	UserID       *uint        `json:"user_id,omitempty"`
+	FilterSearch string       `json:"filter_search,omitempty"`
+	FilterRole   string       `json:"filter_role,omitempty"`
+	FilterStatus string       `json:"filter_status,omitempty"`
+	FilterAction string       `json:"filter_action,omitempty"`
+	FilterLevel  string       `json:"filter_level,omitempty"`
+	Reason       string       `json:"reason"`
+	Status       ExportStatus `json:"status" gorm:"not null;index"`
+	Filename     string       `json:"filename,omitempty"`
+	StorageKey   string       `json:"-"`
+	Records      int          `json:"records"`
+	Size         int64        `json:"size"`
+	Error        string       `json:"error,omitempty"`
+	IPAddress    string       `json:"-"`
+	UserAgent    string       `json:"-"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	StartedAt    *time.Time   `json:"started_at,omitempty"`
+	CompletedAt  *time.Time   `json:"completed_at,omitempty"`
+	ExpiresAt    *time.Time   `json:"expires_at,omitempty" gorm:"index"`
+
+	// DownloadURL is a signed link to the file, issued on each read of a completed export
+	DownloadURL          string     `json:"download_url,omitempty" gorm:"-"`
+	DownloadURLExpiresAt *time.Time `json:"download_url_expires_at,omitempty" gorm:"-"`
+}
+
+// ExportFilename names an export's file after its type and when it was started
+func ExportFilename(exportType ExportType, startedAt time.Time) string {
+	extension := "jsonl"
+	if exportType == ExportTypeEvidence {
+		extension = "zip"
+	}
+	return fmt.Sprintf("%s-export-%s.%s", exportType, startedAt.UTC().Format("20060102T150405Z"), extension)
+}
+
+// ExportContentType is the media type an export's file is downloaded as
+func ExportContentType(exportType ExportType) string {
+	if exportType == ExportTypeEvidence {
+		return "application/zip"
+	}
+	return "application/x-ndjson"
+}
+
+// ExportDownload is an export file opened for a signed download; the caller closes Content
+type ExportDownload struct {
+	Export  *ExportJob
+	Content io.ReadCloser
+}
+
+// ExportStorage keeps finished export files
+type ExportStorage interface {
+	GenerateKey(prefix, filename string) (string, error)
+	Put(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// ExportMailer tells the admin who requested an export that it has finished
+type ExportMailer interface {
+	SendExportReady(email, firstName, filename, downloadURL string, linkExpiresAt time.Time) error
+	SendExportFailed(email, firstName, exportID string) error
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// MergeUsersRequest asks to fold a duplicate source account into a target account
+type MergeUsersRequest struct {
+	SourceID uint   `json:"source_id" binding:"required"`
+	TargetID uint   `json:"target_id" binding:"required"`
+	Reason   string `json:"reason" binding:"required,min=1,max=255"`
+}
+
+// MergeResult counts what a merge moved from the source account to the target
+type MergeResult struct {
+	AuditEntriesMoved       int64 `json:"audit_entries_moved"`
+	FeatureOverridesMoved   int64 `json:"feature_overrides_moved"`
+	FeatureOverridesDropped int64 `json:"feature_overrides_dropped"`
+	SessionsRevoked         int64 `json:"sessions_revoked"`
+}
+
+// MergeUsersResponse describes a completed merge
+type MergeUsersResponse struct {
+	SourceID uint                     `json:"source_id"`
+	TargetID uint                     `json:"target_id"`
+	Target   *authdomain.UserResponse `json:"target"`
+	MergeResult
+}
+
+// CheckMergeable explains why a source account cannot be merged into a target, returning nil if it can.
+// The target keeps its own profile, role and status, so a merge may never quietly strip privileges
+// from the person behind the source account or land them in an account they cannot sign in to.
+func CheckMergeable(source, target *authdomain.User) error {
+	if source.ID == target.ID {
+		return ErrMergeSameUser
+	}
+	if !target.IsActive() {
+		return ErrMergeTargetInactive
+	}
+	if authdomain.RoleLevel(source.Role) > authdomain.RoleLevel(target.Role) {
+		return ErrMergeRoleConflict
+	}
+	return nil
+}
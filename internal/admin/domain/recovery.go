@@ -0,0 +1,25 @@
+package domain
+
+import (
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// RecoveryRequestListRequest represents a request for account recovery requests, oldest first
+type RecoveryRequestListRequest struct {
+	Page     int                       `form:"page,default=1" binding:"min=1"`
+	PageSize int                       `form:"page_size,default=20" binding:"min=1,max=100"`
+	Status   authdomain.RecoveryStatus `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+}
+
+// RecoveryRequestListResponse represents a page of account recovery requests
+type RecoveryRequestListResponse struct {
+	Requests   []*authdomain.RecoveryRequest `json:"requests"`
+	Pagination userdomain.Pagination         `json:"pagination"`
+}
+
+// ReviewRecoveryRequest represents an admin's decision on a recovery request. The note records how
+// the requester's identity was verified, or why the request was declined.
+type ReviewRecoveryRequest struct {
+	Note string `json:"note" binding:"required,min=10,max=1000"`
+}
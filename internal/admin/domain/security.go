@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/geoip"
+)
+
+// Login security reporting DTOs
+
+// LoginStatsRequest represents a request for brute-force login statistics
+type LoginStatsRequest struct {
+	Window string `form:"window"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1"`
+}
+
+// LoginFailureSummary aggregates the failed logins in a window: totals over every attempt, and the
+// accounts and IP addresses with the most failures
+type LoginFailureSummary struct {
+	TotalFailures  int
+	UniqueIPs      int
+	UniqueAccounts int
+	TopIPs         []LoginFailureCount
+	TopAccounts    []LoginFailureCount
+}
+
+// LoginFailureCount counts failed logins for a single IP address or account
+type LoginFailureCount struct {
+	Key         string          `json:"key"`
+	Location    *geoip.Location `json:"location,omitempty"` // Only for IP address keys
+	Failures    int             `json:"failures"`
+	LastAttempt time.Time       `json:"last_attempt"`
+}
+
+// LoginLockout is a login key that has exhausted its attempts in the current window
+type LoginLockout struct {
+	Key      string
+	Attempts int
+	ResetsAt time.Time
+}
+
+// LockoutProvider exposes the accounts and addresses the login rate limiter currently blocks
+type LockoutProvider interface {
+	CurrentLockouts() []LoginLockout
+}
+
+// LockoutEntry describes a key that is currently blocked by the login rate limiter
+type LockoutEntry struct {
+	Key      string    `json:"key"`
+	Attempts int       `json:"attempts"`
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+// AlertNotifier delivers security alerts to an external endpoint such as an incident webhook
+type AlertNotifier interface {
+	Send(ctx context.Context, event string, payload interface{}) error
+}
+
+// SecurityAlertMailer emails a security alert to a security contact
+type SecurityAlertMailer interface {
+	SendSecurityAlert(email string, alert *authdomain.SecurityAlert) error
+}
+
+// LoginLimitTightener lowers login rate limits until the given time in response to a security alert;
+// the zero time lifts it
+type LoginLimitTightener interface {
+	TightenLoginLimits(until time.Time)
+}
+
+// SessionRevoker ends a user's sessions so changes to their access take effect immediately, and
+// lists and ends single sessions for incident response
+type SessionRevoker interface {
+	RevokeUserSessions(userID uint) (int64, error)
+	ListUserSessions(userID uint) ([]*authdomain.RefreshToken, error)
+	GetSessionByID(tokenID uint) (*authdomain.RefreshToken, error)
+	RevokeSession(tokenID uint) (*authdomain.RefreshToken, error)
+}
+
+// AccountEmailSender sends account emails through the auth flows, so the tokens in them
+// only ever reach the user's inbox, or the contact address of an approved recovery request
+type AccountEmailSender interface {
+	ResendEmailVerification(userID uint) error
+	ForgotPassword(req *authdomain.ForgotPasswordRequest) error
+	SendRecoveryReset(userID uint, sendTo string) error
+}
+
+// IPLocator resolves IP addresses to approximate locations for display. It must not block,
+// so a location may be missing until a background lookup has finished.
+type IPLocator interface {
+	Locate(ip string) *geoip.Location
+}
+
+// LoginStatsResponse summarizes failed login activity over a window
+type LoginStatsResponse struct {
+	Window          string              `json:"window"`
+	Since           time.Time           `json:"since"`
+	TotalFailures   int                 `json:"total_failures"`
+	UniqueIPs       int                 `json:"unique_ips"`
+	UniqueAccounts  int                 `json:"unique_accounts"`
+	TopIPs          []LoginFailureCount `json:"top_ips"`
+	TopAccounts     []LoginFailureCount `json:"top_accounts"`
+	CurrentLockouts []LockoutEntry      `json:"current_lockouts"`
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// Security alert types raised by the threshold monitor
+const (
+	AlertTypeRoleChange      = "role_change" // raised by a high-risk role change rather than a threshold
+	AlertTypeAdminPromotions = "admin_promotion_spike"
+	AlertTypeFailedLogins    = "failed_login_spike"
+	AlertTypeMassDeletion    = "mass_deletion"
+)
+
+// Automatic responses to a breached threshold, enabled through SECURITY_ALERT_RESPONSES
+const (
+	ResponseRequireStepUp      = "require_step_up"      // every admin needs step-up auth to change roles or users
+	ResponseTightenLoginLimits = "tighten_login_limits" // login attempts per IP are halved
+)
+
+// SecurityActivity counts the audited activity the thresholds are checked against
+type SecurityActivity struct {
+	AdminPromotions int64 `json:"admin_promotions"`
+	FailedLogins    int64 `json:"failed_logins"`
+	Deletions       int64 `json:"deletions"`
+}
+
+// SecurityThresholds are the activity levels within one window that raise an alert; zero disables a threshold
+type SecurityThresholds struct {
+	AdminPromotions int
+	FailedLogins    int
+	Deletions       int
+}
+
+// ThresholdBreach is a threshold the activity reached, with the response it calls for
+type ThresholdBreach struct {
+	Type     string
+	Severity string
+	Title    string
+	Count    int64
+	Limit    int
+	Response string
+}
+
+// BreachedThresholds returns every enabled threshold the activity reached
+func BreachedThresholds(activity SecurityActivity, thresholds SecurityThresholds) []ThresholdBreach {
+	checks := []ThresholdBreach{
+		{
+			Type:     AlertTypeAdminPromotions,
+			Severity: "critical",
+			Title:    "Unusual number of admin promotions",
+			Count:    activity.AdminPromotions,
+			Limit:    thresholds.AdminPromotions,
+			Response: ResponseRequireStepUp,
+		},
+		{
+			Type:     AlertTypeFailedLogins,
+			Severity: "high",
+			Title:    "Spike in failed logins",
+			Count:    activity.FailedLogins,
+			Limit:    thresholds.FailedLogins,
+			Response: ResponseTightenLoginLimits,
+		},
+		{
+			Type:     AlertTypeMassDeletion,
+			Severity: "high",
+			Title:    "Unusual number of user deletions",
+			Count:    activity.Deletions,
+			Limit:    thresholds.Deletions,
+			Response: ResponseRequireStepUp,
+		},
+	}
+
+	var breaches []ThresholdBreach
+	for _, check := range checks {
+		if check.Limit > 0 && check.Count >= int64(check.Limit) {
+			breaches = append(breaches, check)
+		}
+	}
+	return breaches
+}
+
+// NewThresholdAlert builds the alert raised for a breach within the given window
+func NewThresholdAlert(breach ThresholdBreach, window time.Duration, now time.Time) *authdomain.SecurityAlert {
+	return &authdomain.SecurityAlert{
+		ID:       uuid.New().String(),
+		Type:     breach.Type,
+		Severity: breach.Severity,
+		Title:    breach.Title,
+		Description: fmt.Sprintf("%d in the last %s reached the alert threshold of %d",
+			breach.Count, window, breach.Limit),
+		Data: map[string]interface{}{
+			"count":     breach.Count,
+			"threshold": breach.Limit,
+			"window":    window.String(),
+		},
+		CreatedAt: now,
+	}
+}
+
+// SecurityAlertListRequest represents a request for security alerts, newest first
+type SecurityAlertListRequest struct {
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=20" binding:"min=1,max=100"`
+	Type     string `form:"type"`
+	Resolved *bool  `form:"resolved"`
+}
+
+// SecurityAlertListResponse represents a page of security alerts
+type SecurityAlertListResponse struct {
+	Alerts     []*authdomain.SecurityAlert `json:"alerts"`
+	Pagination userdomain.Pagination       `json:"pagination"`
+}
+
+// ResolveSecurityAlertRequest represents an admin closing a security alert, which also lifts its response
+type ResolveSecurityAlertRequest struct {
+	Notes string `json:"notes" binding:"required,min=1,max=1000"`
+}
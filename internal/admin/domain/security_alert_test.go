@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreachedThresholds(t *testing.T) {
+	thresholds := SecurityThresholds{AdminPromotions: 5, FailedLogins: 200, Deletions: 50}
+
+	assert.Empty(t, BreachedThresholds(SecurityActivity{AdminPromotions: 4, FailedLogins: 199, Deletions: 49}, thresholds))
+
+	breaches := BreachedThresholds(SecurityActivity{AdminPromotions: 5, FailedLogins: 10, Deletions: 80}, thresholds)
+	assert.Len(t, breaches, 2)
+	assert.Equal(t, AlertTypeAdminPromotions, breaches[0].Type)
+	assert.Equal(t, "critical", breaches[0].Severity)
+	assert.Equal(t, ResponseRequireStepUp, breaches[0].Response)
+	assert.Equal(t, AlertTypeMassDeletion, breaches[1].Type)
+	assert.Equal(t, int64(80), breaches[1].Count)
+
+	breaches = BreachedThresholds(SecurityActivity{FailedLogins: 500}, thresholds)
+	assert.Len(t, breaches, 1)
+	assert.Equal(t, ResponseTightenLoginLimits, breaches[0].Response)
+}
+
+func TestBreachedThresholdsDisabled(t *testing.T) {
+	activity := SecurityActivity{AdminPromotions: 100, FailedLogins: 10000, Deletions: 1000}
+	assert.Empty(t, BreachedThresholds(activity, SecurityThresholds{}))
+}
+
+func TestNewThresholdAlert(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	breach := ThresholdBreach{Type: AlertTypeMassDeletion, Severity: "high", Title: "Mass deletion", Count: 60, Limit: 50}
+
+	alert := NewThresholdAlert(breach, time.Hour, now)
+	assert.NotEmpty(t, alert.ID)
+	assert.Equal(t, AlertTypeMassDeletion, alert.Type)
+	assert.Equal(t, "60 in the last 1h0m0s reached the alert threshold of 50", alert.Description)
+	assert.Equal(t, int64(60), alert.Data["count"])
+	assert.Equal(t, now, alert.CreatedAt)
+	assert.Zero(t, alert.AdminID)
+	assert.Empty(t, alert.Response)
+}
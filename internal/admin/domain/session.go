@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// UserSession is one of a user's signed-in sessions as admins see it; the token itself is never shown
+type UserSession struct {
+	ID         uint      `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// ToUserSession describes the session a refresh token belongs to
+func ToUserSession(token *authdomain.RefreshToken) *UserSession {
+	return &UserSession{
+		ID:         token.ID,
+		CreatedAt:  token.CreatedAt,
+		LastUsedAt: token.LastUsed(),
+		ExpiresAt:  token.ExpiresAt,
+	}
+}
+
+// UserSessionsResponse lists a user's sessions, newest first
+type UserSessionsResponse struct {
+	UserID   uint           `json:"user_id"`
+	Sessions []*UserSession `json:"sessions"`
+}
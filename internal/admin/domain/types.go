@@ -1,17 +1,28 @@
 package domain
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	authdomain "github.com/acheevo/tfa/internal/auth/domain"
 	userdomain "github.com/acheevo/tfa/internal/user/domain"
 )
 
+// Expensive admin operations, each limited to a configurable number running at once
+const (
+	OperationBulkAction = "bulk_action"
+	OperationBroadcast  = "broadcast"
+	OperationMerge      = "merge"
+	OperationEmailQueue = "email_queue"
+)
+
 // Admin user management DTOs
 
 // UpdateUserRoleRequest represents a request to update a user's role
 type UpdateUserRoleRequest struct {
-	Role   authdomain.UserRole `json:"role" binding:"required,oneof=user admin"`
+	Role   authdomain.UserRole `json:"role" binding:"required,oneof=user admin super_admin"`
 	Reason string              `json:"reason" binding:"required,min=1,max=255"`
 }
 
@@ -21,6 +32,18 @@ type UpdateUserStatusRequest struct {
 	Reason string                `json:"reason" binding:"required,min=1,max=255"`
 }
 
+// ApproveUserRequest represents a request to approve a registration waiting for approval
+type ApproveUserRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// UpdateEmailVerificationRequest represents a request to manually verify or unverify a user's email
+type UpdateEmailVerificationRequest struct {
+	Verified   *bool  `json:"verified" binding:"required"`
+	Reason     string `json:"reason" binding:"required,min=1,max=255"`
+	NotifyUser bool   `json:"notify_user"`
+}
+
 // AdminUpdateUserRequest represents an admin request to update user information
 type AdminUpdateUserRequest struct {
 	FirstName     string                `json:"first_name" binding:"omitempty,min=1,max=50"`
@@ -36,7 +59,15 @@ type AdminUpdateUserRequest struct {
 // DeleteUserRequest represents a request to delete a user
 type DeleteUserRequest struct {
 	Reason string `json:"reason" binding:"required,min=1,max=255"`
-	Force  bool   `json:"force"` // Force delete (hard delete) vs soft delete
+	Force  *bool  `json:"force"` // Force delete (hard delete) vs soft delete; omitted uses the configured default
+}
+
+// IsHardDelete resolves the delete mode, falling back to the configured default when force is omitted
+func (r *DeleteUserRequest) IsHardDelete(defaultHard bool) bool {
+	if r.Force == nil {
+		return defaultHard
+	}
+	return *r.Force
 }
 
 // BulkUserActionRequest represents a request to perform bulk actions on users
@@ -84,6 +115,8 @@ type AdminStatsResponse struct {
 	NewUsersThisWeek int              `json:"new_users_this_week"`
 	UserGrowth       []UserGrowthData `json:"user_growth"`
 	TopCountries     []CountryData    `json:"top_countries,omitempty"`
+	// Activity is the latest user activity count, absent until the first one has run
+	Activity *UserActivity `json:"activity,omitempty"`
 }
 
 // UserGrowthData represents user growth data for charts
@@ -110,12 +143,80 @@ type AdminAuditLogRequest struct {
 	DateFrom  *time.Time             `form:"date_from" time_format:"2006-01-02"`
 	DateTo    *time.Time             `form:"date_to" time_format:"2006-01-02"`
 	IPAddress string                 `form:"ip_address"`
+	Sort      string                 `form:"sort"` // "field:asc|desc", see AuditSortFields
+	Count     userdomain.CountMode   `form:"count" binding:"omitempty,oneof=exact estimated none"`
+	Cursor    string                 `form:"cursor"` // next_cursor of the previous page; replaces page
+
+	// After is the position decoded from Cursor; the listing resumes past it
+	After *AuditLogPosition `form:"-"`
 }
 
+// CursorFilters are the filters a cursor is bound to, so it can't be replayed against another query
+func (r *AdminAuditLogRequest) CursorFilters(sort userdomain.Sort) map[string]string {
+	filters := map[string]string{
+		"action":     string(r.Action),
+		"level":      string(r.Level),
+		"resource":   r.Resource,
+		"ip_address": r.IPAddress,
+		"sort":       sort.OrderClause(),
+	}
+	if r.UserID != nil {
+		filters["user_id"] = strconv.FormatUint(uint64(*r.UserID), 10)
+	}
+	if r.TargetID != nil {
+		filters["target_id"] = strconv.FormatUint(uint64(*r.TargetID), 10)
+	}
+	if r.DateFrom != nil {
+		filters["date_from"] = r.DateFrom.Format(time.DateOnly)
+	}
+	if r.DateTo != nil {
+		filters["date_to"] = r.DateTo.Format(time.DateOnly)
+	}
+	return filters
+}
+
+// AuditLogPosition is where a cursor-paginated audit log listing resumes: past the entry with this
+// creation time and ID in the default newest-first order
+type AuditLogPosition struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"i"`
+}
+
+// Filtered reports whether the listing is narrowed by any filter, so can't use an estimated total
+func (r *AdminAuditLogRequest) Filtered() bool {
+	return r.UserID != nil || r.TargetID != nil || r.Action != "" || r.Level != "" || r.Resource != "" ||
+		r.DateFrom != nil || r.DateTo != nil || r.IPAddress != ""
+}
+
+// Sortable fields and defaults for the audit log listing
+var (
+	AuditSortFields  = []string{"created_at", "level", "action"}
+	DefaultAuditSort = userdomain.Sort{Field: "created_at", Desc: true}
+)
+
 // AdminAuditLogResponse represents the response for audit log requests
 type AdminAuditLogResponse struct {
 	Logs       []*EnhancedAuditLogEntry `json:"logs"`
 	Pagination userdomain.Pagination    `json:"pagination"`
+	NextCursor string                   `json:"next_cursor,omitempty"` // Set on newest-first listings with more entries
+}
+
+// UserAuditLogRequest represents a request for one user's audit history, covering entries where the
+// user is either the actor or the target
+type UserAuditLogRequest struct {
+	Page     int                    `form:"page,default=1" binding:"min=1"`
+	PageSize int                    `form:"page_size,default=20" binding:"min=1,max=100"`
+	Action   authdomain.AuditAction `form:"action"`
+	Level    authdomain.AuditLevel  `form:"level" binding:"omitempty,oneof=info warning error"`
+	Search   string                 `form:"search" binding:"max=100"`
+	DateFrom *time.Time             `form:"date_from" time_format:"2006-01-02"`
+	DateTo   *time.Time             `form:"date_to" time_format:"2006-01-02"`
+}
+
+// UserAuditLogResponse represents a page of a user's audit history
+type UserAuditLogResponse struct {
+	Logs       []userdomain.AuditLogEntry `json:"logs"`
+	Pagination userdomain.Pagination      `json:"pagination"`
 }
 
 // EnhancedAuditLogEntry represents an enhanced audit log entry with user details
@@ -209,17 +310,56 @@ func IsAuthorizedForUserManagement(user *authdomain.User) bool {
 
 // CanManageUser checks if an admin can manage a specific user
 func CanManageUser(admin, target *authdomain.User) bool {
+	return CheckCanManageUser(admin, target) == nil
+}
+
+// CheckCanManageUser explains why an admin cannot manage a specific user, returning nil if they can
+func CheckCanManageUser(admin, target *authdomain.User) error {
 	if !IsAuthorizedForUserManagement(admin) {
-		return false
+		return ErrNotAuthorized
 	}
 
 	// Admins cannot manage themselves through admin endpoints
 	if admin.ID == target.ID {
+		return ErrCannotManageSelf
+	}
+
+	// Super admin accounts can only be managed by another super admin
+	if target.IsSuperAdmin() && !admin.IsSuperAdmin() {
+		return ErrSuperAdminRequired
+	}
+
+	return nil
+}
+
+// RemovesActiveAdmin reports whether giving target the new role or status (empty leaves it unchanged)
+// would take away an active admin
+func RemovesActiveAdmin(target *authdomain.User, newRole authdomain.UserRole, newStatus authdomain.UserStatus) bool {
+	if !target.IsAdmin() || !target.IsActive() {
 		return false
 	}
+	demoted := newRole != "" && !authdomain.IsRoleAtLeast(newRole, authdomain.RoleAdmin)
+	deactivated := newStatus != "" && newStatus != authdomain.StatusActive
+	return demoted || deactivated
+}
 
-	// Super admin logic could be added here if needed
-	return true
+// StatusChangeWarnings lists the unusual but allowed aspects of giving target the new status,
+// returned to the admin alongside the successful change
+func StatusChangeWarnings(target *authdomain.User, newStatus authdomain.UserStatus, reason string) []string {
+	var warnings []string
+	if target.Status == newStatus {
+		warnings = append(warnings, fmt.Sprintf("user already has status %s", newStatus))
+	}
+	if len(strings.TrimSpace(reason)) < 10 {
+		warnings = append(warnings, "reason for status change is very brief")
+	}
+	if target.IsAdmin() && newStatus != authdomain.StatusActive {
+		warnings = append(warnings, "status change takes away an admin's access")
+	}
+	if target.Status == authdomain.StatusPending && newStatus == authdomain.StatusActive {
+		warnings = append(warnings, "pending registrations are usually approved through the approve endpoint, which also notifies the user")
+	}
+	return warnings
 }
 
 // ValidateBulkAction validates bulk action requests
@@ -234,3 +374,36 @@ func (r *BulkUserActionRequest) Validate() error {
 
 	return nil
 }
+
+// NewPagination builds the pagination block shared by admin list responses
+func NewPagination(page, pageSize, total int) userdomain.Pagination {
+	totalPages := (total + pageSize - 1) / pageSize
+	return userdomain.Pagination{
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+		HasPrev:    page > 1,
+	}
+}
+
+// NewListPagination builds the pagination block for a listing that may have estimated or skipped its
+// total. Whether a page follows is always known; an estimated total is kept consistent with it.
+func NewListPagination(page, pageSize int, count userdomain.PageCount) userdomain.Pagination {
+	pagination := NewPagination(page, pageSize, count.Total)
+	pagination.HasNext = count.HasNext
+	pagination.Count = count.Mode
+
+	switch count.Mode {
+	case userdomain.CountNone:
+		pagination.TotalPages = 0
+	case userdomain.CountEstimated:
+		if count.HasNext {
+			pagination.TotalPages = max(pagination.TotalPages, page+1)
+		} else {
+			pagination.TotalPages = page
+		}
+	}
+	return pagination
+}
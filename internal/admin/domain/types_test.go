@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+func TestDeleteUserRequestIsHardDelete(t *testing.T) {
+	hard, soft := true, false
+
+	omitted := &DeleteUserRequest{}
+	assert.False(t, omitted.IsHardDelete(false))
+	assert.True(t, omitted.IsHardDelete(true))
+
+	// An explicit force flag always wins over the configured default
+	assert.True(t, (&DeleteUserRequest{Force: &hard}).IsHardDelete(false))
+	assert.False(t, (&DeleteUserRequest{Force: &soft}).IsHardDelete(true))
+}
+
+func TestCheckCanManageUser(t *testing.T) {
+	active := authdomain.StatusActive
+	superAdmin := &authdomain.User{ID: 1, Role: authdomain.RoleSuperAdmin, Status: active}
+	otherSuperAdmin := &authdomain.User{ID: 2, Role: authdomain.RoleSuperAdmin, Status: active}
+	admin := &authdomain.User{ID: 3, Role: authdomain.RoleAdmin, Status: active}
+	user := &authdomain.User{ID: 4, Role: authdomain.RoleUser, Status: active}
+
+	assert.NoError(t, CheckCanManageUser(admin, user))
+	assert.NoError(t, CheckCanManageUser(superAdmin, admin))
+	assert.NoError(t, CheckCanManageUser(superAdmin, otherSuperAdmin))
+	assert.ErrorIs(t, CheckCanManageUser(admin, superAdmin), ErrSuperAdminRequired)
+	assert.ErrorIs(t, CheckCanManageUser(superAdmin, superAdmin), ErrCannotManageSelf)
+	assert.ErrorIs(t, CheckCanManageUser(user, admin), ErrNotAuthorized)
+	assert.True(t, IsAuthorizedForUserManagement(superAdmin))
+}
+
+func TestRemovesActiveAdmin(t *testing.T) {
+	admin := &authdomain.User{ID: 1, Role: authdomain.RoleAdmin, Status: authdomain.StatusActive}
+	superAdmin := &authdomain.User{ID: 2, Role: authdomain.RoleSuperAdmin, Status: authdomain.StatusActive}
+	suspendedAdmin := &authdomain.User{ID: 3, Role: authdomain.RoleAdmin, Status: authdomain.StatusSuspended}
+	user := &authdomain.User{ID: 4, Role: authdomain.RoleUser, Status: authdomain.StatusActive}
+
+	assert.True(t, RemovesActiveAdmin(admin, authdomain.RoleUser, ""))
+	assert.True(t, RemovesActiveAdmin(admin, "", authdomain.StatusSuspended))
+	assert.True(t, RemovesActiveAdmin(superAdmin, "", authdomain.StatusInactive))
+	assert.False(t, RemovesActiveAdmin(superAdmin, authdomain.RoleAdmin, ""))
+	assert.False(t, RemovesActiveAdmin(admin, "", authdomain.StatusActive))
+	assert.False(t, RemovesActiveAdmin(admin, "", ""))
+
+	// Inactive admins and regular users don't count towards the remaining admins
+	assert.False(t, RemovesActiveAdmin(suspendedAdmin, authdomain.RoleUser, ""))
+	assert.False(t, RemovesActiveAdmin(user, "", authdomain.StatusSuspended))
+}
+
+func TestStatusChangeWarnings(t *testing.T) {
+	admin := &authdomain.User{ID: 1, Role: authdomain.RoleAdmin, Status: authdomain.StatusActive}
+	user := &authdomain.User{ID: 2, Role: authdomain.RoleUser, Status: authdomain.StatusActive}
+	pending := &authdomain.User{ID: 3, Role: authdomain.RoleUser, Status: authdomain.StatusPending}
+
+	assert.Empty(t, StatusChangeWarnings(user, authdomain.StatusSuspended, "repeated spam reports"))
+	assert.Equal(t, []string{"reason for status change is very brief"},
+		StatusChangeWarnings(user, authdomain.StatusSuspended, "spam"))
+	assert.Equal(t, []string{"user already has status active"},
+		StatusChangeWarnings(user, authdomain.StatusActive, "confirmed identity"))
+	assert.Equal(t, []string{"status change takes away an admin's access"},
+		StatusChangeWarnings(admin, authdomain.StatusInactive, "left the company"))
+	assert.Len(t, StatusChangeWarnings(pending, authdomain.StatusActive, "known customer"), 1)
+}
+
+func TestNewPagination(t *testing.T) {
+	pagination := NewPagination(2, 20, 45)
+	assert.Equal(t, 3, pagination.TotalPages)
+	assert.True(t, pagination.HasNext)
+	assert.True(t, pagination.HasPrev)
+
+	pagination = NewPagination(1, 20, 0)
+	assert.Equal(t, 0, pagination.TotalPages)
+	assert.False(t, pagination.HasNext)
+	assert.False(t, pagination.HasPrev)
+}
+
+func TestNewListPagination(t *testing.T) {
+	pagination := NewListPagination(2, 20, userdomain.ExactPageCount(2, 20, 45))
+	assert.Equal(t, 45, pagination.Total)
+	assert.Equal(t, 3, pagination.TotalPages)
+	assert.True(t, pagination.HasNext)
+	assert.Equal(t, userdomain.CountExact, pagination.Count)
+
+	// Without a total, has_next comes from the extra row
+	pagination = NewListPagination(2, 20, userdomain.PageCount{Mode: userdomain.CountNone, HasNext: true})
+	assert.Equal(t, 0, pagination.Total)
+	assert.Equal(t, 0, pagination.TotalPages)
+	assert.True(t, pagination.HasNext)
+	assert.True(t, pagination.HasPrev)
+
+	pagination = NewListPagination(3, 20, userdomain.PageCount{Mode: userdomain.CountNone})
+	assert.False(t, pagination.HasNext)
+
+	// An estimate that undercounts still leaves room for the page that follows
+	pagination = NewListPagination(5, 20, userdomain.PageCount{Mode: userdomain.CountEstimated, Total: 60, HasNext: true})
+	assert.Equal(t, 60, pagination.Total)
+	assert.Equal(t, 6, pagination.TotalPages)
+	assert.True(t, pagination.HasNext)
+
+	// And one that overcounts ends at the last page actually found
+	pagination = NewListPagination(2, 20, userdomain.PageCount{Mode: userdomain.CountEstimated, Total: 100})
+	assert.Equal(t, 2, pagination.TotalPages)
+	assert.False(t, pagination.HasNext)
+}
+
+func TestAuditSortFields(t *testing.T) {
+	sort, err := userdomain.ParseSort("level:asc", AuditSortFields, DefaultAuditSort)
+	assert.NoError(t, err)
+	assert.Equal(t, "level ASC, id ASC", sort.OrderClause())
+
+	sort, err = userdomain.ParseSort("", AuditSortFields, DefaultAuditSort)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultAuditSort, sort)
+
+	_, err = userdomain.ParseSort("email:asc", AuditSortFields, DefaultAuditSort)
+	assert.Equal(t, userdomain.ErrInvalidSort, err)
+}
+
+func TestCheckMergeable(t *testing.T) {
+	active := authdomain.StatusActive
+	user := &authdomain.User{ID: 1, Role: authdomain.RoleUser, Status: active}
+	duplicate := &authdomain.User{ID: 2, Role: authdomain.RoleUser, Status: active}
+	admin := &authdomain.User{ID: 3, Role: authdomain.RoleAdmin, Status: active}
+	suspended := &authdomain.User{ID: 4, Role: authdomain.RoleUser, Status: authdomain.StatusSuspended}
+
+	assert.NoError(t, CheckMergeable(duplicate, user))
+	assert.NoError(t, CheckMergeable(user, admin))
+	assert.ErrorIs(t, CheckMergeable(user, user), ErrMergeSameUser)
+	assert.ErrorIs(t, CheckMergeable(admin, user), ErrMergeRoleConflict)
+	assert.ErrorIs(t, CheckMergeable(user, suspended), ErrMergeTargetInactive)
+	assert.NoError(t, CheckMergeable(suspended, user))
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// Login windows user activity is counted over
+const (
+	DailyActiveWindow   = 24 * time.Hour
+	MonthlyActiveWindow = 30 * 24 * time.Hour
+)
+
+// UserActivity counts the active accounts and the users who logged in within each window, as of
+// MeasuredAt. Deleted users are left out.
+type UserActivity struct {
+	Active        int64     `json:"active"`
+	DailyActive   int64     `json:"daily_active"`
+	MonthlyActive int64     `json:"monthly_active"`
+	MeasuredAt    time.Time `json:"measured_at" gorm:"-"`
+}
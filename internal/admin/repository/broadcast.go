@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+)
+
+// BroadcastRepository handles database operations for email broadcast jobs
+type BroadcastRepository struct {
+	db *gorm.DB
+}
+
+// NewBroadcastRepository creates a new broadcast repository
+func NewBroadcastRepository(db *gorm.DB) *BroadcastRepository {
+	return &BroadcastRepository{
+		db: db,
+	}
+}
+
+// Create creates a new broadcast job
+func (r *BroadcastRepository) Create(job *domain.BroadcastJob) error {
+	return r.db.Create(job).Error
+}
+
+// Update saves the progress of a broadcast job
+func (r *BroadcastRepository) Update(job *domain.BroadcastJob) error {
+	return r.db.Save(job).Error
+}
+
+// GetByID gets a broadcast job by ID
+func (r *BroadcastRepository) GetByID(id string) (*domain.BroadcastJob, error) {
+	var job domain.BroadcastJob
+	err := r.db.Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrBroadcastNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FailStale marks broadcasts still running without progress since updatedBefore as failed, as their
+// runner is assumed gone, and returns how many there were
+func (r *BroadcastRepository) FailStale(updatedBefore, now time.Time) (int64, error) {
+	result := r.db.Model(&domain.BroadcastJob{}).
+		Where("status = ? AND updated_at < ?", domain.BroadcastStatusRunning, updatedBefore).
+		Updates(map[string]interface{}{
+			"status":       domain.BroadcastStatusFailed,
+			"error":        "broadcast stopped making progress",
+			"completed_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+)
+
+// ExportRepository handles database operations for export jobs
+type ExportRepository struct {
+	db *gorm.DB
+}
+
+// NewExportRepository creates a new export repository
+func NewExportRepository(db *gorm.DB) *ExportRepository {
+	return &ExportRepository{
+		db: db,
+	}
+}
+
+// Create creates a new export job
+func (r *ExportRepository) Create(job *domain.ExportJob) error {
+	return r.db.Create(job).Error
+}
+
+// Update saves the progress of an export job
+func (r *ExportRepository) Update(job *domain.ExportJob) error {
+	return r.db.Save(job).Error
+}
+
+// GetByID gets an export job by ID
+func (r *ExportRepository) GetByID(id string) (*domain.ExportJob, error) {
+	var job domain.ExportJob
+	err := r.db.Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrExportNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ClaimNext marks the oldest pending export as running and returns it, or nil when none are waiting.
+// Rows being claimed elsewhere are skipped, so several instances can run exports side by side.
+func (r *ExportRepository) ClaimNext(now time.Time) (*domain.ExportJob, error) {
+	var job domain.ExportJob
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", domain.ExportStatusPending).
+			Order("created_at ASC").
+			First(&job).Error; err != nil {
+			return err
+		}
+
+		job.Status = domain.ExportStatusRunning
+		job.StartedAt = &now
+		return tx.Save(&job).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListExpired returns up to limit completed exports whose files are past their expiry
+func (r *ExportRepository) ListExpired(now time.Time, limit int) ([]*domain.ExportJob, error) {
+	var jobs []*domain.ExportJob
+	err := r.db.
+		Where("status = ? AND expires_at <= ?", domain.ExportStatusCompleted, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// FailStale marks exports still running since before startedBefore as failed, as their runner is
+// assumed gone, and returns how many there were
+func (r *ExportRepository) FailStale(startedBefore, now time.Time) (int64, error) {
+	result := r.db.Model(&domain.ExportJob{}).
+		Where("status = ? AND started_at < ?", domain.ExportStatusRunning, startedBefore).
+		Updates(map[string]interface{}{
+			"status":       domain.ExportStatusFailed,
+			"error":        "export did not finish in time",
+			"completed_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
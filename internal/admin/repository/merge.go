@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	featuredomain "github.com/acheevo/tfa/internal/features/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// MergeRepository handles the database side of merging duplicate user accounts
+type MergeRepository struct {
+	db *gorm.DB
+}
+
+// NewMergeRepository creates a new merge repository
+func NewMergeRepository(db *gorm.DB) *MergeRepository {
+	return &MergeRepository{
+		db: db,
+	}
+}
+
+// Merge moves the source user's audit references and feature overrides to the target, ends the
+// source's sessions and soft-deletes it, all in one transaction. Where both users override the
+// same flag, the target's override wins and the source's is dropped.
+func (r *MergeRepository) Merge(sourceID, targetID uint) (*domain.MergeResult, error) {
+	result := &domain.MergeResult{}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock both accounts so a concurrent update or merge can't interleave with this one
+		var locked []authdomain.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint{sourceID, targetID}).
+			Find(&locked).Error; err != nil {
+			return err
+		}
+		if len(locked) != 2 {
+			return userdomain.ErrUserNotFound
+		}
+
+		moved := tx.Model(&authdomain.AuditLog{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.AuditEntriesMoved += moved.RowsAffected
+
+		moved = tx.Model(&authdomain.AuditLog{}).Where("target_id = ?", sourceID).Update("target_id", targetID)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.AuditEntriesMoved += moved.RowsAffected
+
+		targetFlags := tx.Model(&featuredomain.FeatureOverride{}).Select("flag").Where("user_id = ?", targetID)
+		dropped := tx.Where("user_id = ? AND flag IN (?)", sourceID, targetFlags).Delete(&featuredomain.FeatureOverride{})
+		if dropped.Error != nil {
+			return dropped.Error
+		}
+		result.FeatureOverridesDropped = dropped.RowsAffected
+
+		moved = tx.Model(&featuredomain.FeatureOverride{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.FeatureOverridesMoved = moved.RowsAffected
+
+		revoked := tx.Where("user_id = ?", sourceID).Delete(&authdomain.RefreshToken{})
+		if revoked.Error != nil {
+			return revoked.Error
+		}
+		result.SessionsRevoked = revoked.RowsAffected
+
+		return tx.Delete(&authdomain.User{}, sourceID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// SecurityAlertRepository handles database operations for security alerts
+type SecurityAlertRepository struct {
+	db *gorm.DB
+}
+
+// NewSecurityAlertRepository creates a new security alert repository
+func NewSecurityAlertRepository(db *gorm.DB) *SecurityAlertRepository {
+	return &SecurityAlertRepository{
+		db: db,
+	}
+}
+
+// Create stores a security alert
+func (r *SecurityAlertRepository) Create(alert *authdomain.SecurityAlert) error {
+	return r.db.Create(alert).Error
+}
+
+// GetByID gets a security alert by ID
+func (r *SecurityAlertRepository) GetByID(id string) (*authdomain.SecurityAlert, error) {
+	var alert authdomain.SecurityAlert
+	err := r.db.Where("id = ?", id).First(&alert).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrAlertNotFound
+		}
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// Update saves changes to a security alert
+func (r *SecurityAlertRepository) Update(alert *authdomain.SecurityAlert) error {
+	return r.db.Save(alert).Error
+}
+
+// ExistsSince reports whether an alert of the type was raised at or after since
+func (r *SecurityAlertRepository) ExistsSince(alertType string, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&authdomain.SecurityAlert{}).
+		Where("type = ? AND created_at >= ?", alertType, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ActiveResponseUntil returns when the latest unresolved alert carrying the automatic response
+// stops enforcing it, or nil when no alert enforces it at now
+func (r *SecurityAlertRepository) ActiveResponseUntil(response string, now time.Time) (*time.Time, error) {
+	var until *time.Time
+	err := r.db.Model(&authdomain.SecurityAlert{}).
+		Select("MAX(response_until)").
+		Where("response = ? AND resolved = ? AND response_until > ?", response, false, now).
+		Row().Scan(&until)
+	return until, err
+}
+
+// ListByAdmin retrieves every security alert raised over an admin's activity, newest first
+func (r *SecurityAlertRepository) ListByAdmin(adminID uint) ([]*authdomain.SecurityAlert, error) {
+	var alerts []*authdomain.SecurityAlert
+	err := r.db.Where("admin_id = ?", adminID).Order("created_at DESC, id DESC").Find(&alerts).Error
+	return alerts, err
+}
+
+// List retrieves security alerts with filtering and pagination, newest first
+func (r *SecurityAlertRepository) List(req *domain.SecurityAlertListRequest) ([]*authdomain.SecurityAlert, int, error) {
+	query := r.db.Model(&authdomain.SecurityAlert{})
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	if req.Resolved != nil {
+		query = query.Where("resolved = ?", *req.Resolved)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	alerts := make([]*authdomain.SecurityAlert, 0, req.PageSize)
+	err := query.
+		Order("created_at DESC, id DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&alerts).Error
+	return alerts, int(total), err
+}
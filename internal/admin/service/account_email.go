@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// ResendVerificationEmail sends a fresh verification email to a user on an admin's behalf.
+// The per-account resend limit still applies, and the token only goes to the user's inbox.
+func (s *AdminService) ResendVerificationEmail(adminID, targetUserID uint, ipAddress, userAgent string) error {
+	targetUser, err := s.authorizeAccountEmail(adminID, targetUserID)
+	if err != nil {
+		return err
+	}
+
+	if targetUser.EmailVerified {
+		return authdomain.ErrEmailAlreadyVerified
+	}
+
+	if err := s.accountEmails.ResendEmailVerification(targetUserID); err != nil {
+		s.logger.Error("failed to resend verification email for admin",
+			"admin_id", adminID,
+			"target_user_id", targetUserID,
+			"error", err)
+		return err
+	}
+
+	s.auditAccountEmail(adminID, targetUserID, authdomain.AuditActionVerificationResent,
+		fmt.Sprintf("Verification email resent to %s by admin", targetUser.Email), targetUser.Email, ipAddress, userAgent)
+	return nil
+}
+
+// SendPasswordReset sends a password reset email to a user on an admin's behalf.
+// The usual per-email reset limits still apply, and the token only goes to the user's inbox.
+func (s *AdminService) SendPasswordReset(adminID, targetUserID uint, ipAddress, userAgent string) error {
+	targetUser, err := s.authorizeAccountEmail(adminID, targetUserID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.accountEmails.ForgotPassword(&authdomain.ForgotPasswordRequest{Email: targetUser.Email}); err != nil {
+		s.logger.Error("failed to send password reset for admin",
+			"admin_id", adminID,
+			"target_user_id", targetUserID,
+			"error", err)
+		return err
+	}
+
+	s.auditAccountEmail(adminID, targetUserID, authdomain.AuditActionAdminPasswordReset,
+		fmt.Sprintf("Password reset email sent to %s by admin", targetUser.Email), targetUser.Email, ipAddress, userAgent)
+	return nil
+}
+
+// authorizeAccountEmail checks the admin may manage the target user and returns the target
+func (s *AdminService) authorizeAccountEmail(adminID, targetUserID uint) (*authdomain.User, error) {
+	if s.accountEmails == nil {
+		return nil, domain.ErrAccountEmailsOffline
+	}
+
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, domain.ErrNotAuthorized
+	}
+
+	targetUser, err := s.userRepo.GetByID(targetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := domain.CheckCanManageUser(admin, targetUser); err != nil {
+		return nil, err
+	}
+
+	return targetUser, nil
+}
+
+// auditAccountEmail records which admin sent an account email to which user
+func (s *AdminService) auditAccountEmail(
+	adminID, targetUserID uint,
+	action authdomain.AuditAction,
+	description, email, ipAddress, userAgent string,
+) {
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		&targetUserID,
+		action,
+		authdomain.AuditLevelInfo,
+		"admin",
+		description,
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"email": email,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for account email",
+			"admin_id", adminID,
+			"target_user_id", targetUserID,
+			"action", action,
+			"error", err)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// GetActivityFeed returns a page of recent registrations, role changes, deletions and security
+// alerts, newest first. Each selected source is read deep enough to fill the page and the results
+// are merged in memory.
+func (s *AdminService) GetActivityFeed(adminID uint, req *domain.ActivityFeedRequest) (*domain.ActivityFeedResponse, error) {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, domain.ErrNotAuthorized
+	}
+
+	types, err := domain.ParseActivityTypes(req.Types)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := req.Page * req.PageSize
+	if depth > domain.MaxActivityDepth {
+		return nil, domain.ErrActivityPageTooDeep
+	}
+
+	feeds := make([][]*domain.ActivityItem, 0, len(types))
+	total := 0
+	for _, activityType := range types {
+		items, count, err := s.recentActivity(activityType, depth)
+		if err != nil {
+			s.logger.Error("failed to load activity", "admin_id", adminID, "type", activityType, "error", err)
+			return nil, err
+		}
+		feeds = append(feeds, items)
+		total += count
+	}
+
+	return &domain.ActivityFeedResponse{
+		Items:      domain.MergeActivity(req.Page, req.PageSize, feeds...),
+		Pagination: domain.NewPagination(req.Page, req.PageSize, total),
+	}, nil
+}
+
+// recentActivity returns up to limit of the newest events of one type and how many exist in total
+func (s *AdminService) recentActivity(activityType domain.ActivityType, limit int) ([]*domain.ActivityItem, int, error) {
+	switch activityType {
+	case domain.ActivityRegistration:
+		users, count, err := s.userRepo.List(&userdomain.UserListRequest{Page: 1, PageSize: limit, Sort: "created_at:desc"})
+		if err != nil {
+			return nil, 0, err
+		}
+		items := make([]*domain.ActivityItem, len(users))
+		for i, user := range users {
+			items[i] = domain.RegistrationActivity(user)
+		}
+		return items, count.Total, nil
+
+	case domain.ActivityRoleChange, domain.ActivityDeletion:
+		action := authdomain.AuditActionUserRoleChanged
+		if activityType == domain.ActivityDeletion {
+			action = authdomain.AuditActionUserDeleted
+		}
+		logs, count, err := s.auditRepo.List(&domain.AdminAuditLogRequest{Page: 1, PageSize: limit, Action: action})
+		if err != nil {
+			return nil, 0, err
+		}
+		items := make([]*domain.ActivityItem, len(logs))
+		for i, log := range logs {
+			items[i] = domain.AuditActivity(activityType, log)
+		}
+		return items, count.Total, nil
+
+	case domain.ActivitySecurityAlert:
+		if s.alertRepo == nil {
+			return nil, 0, nil
+		}
+		alerts, total, err := s.alertRepo.List(&domain.SecurityAlertListRequest{Page: 1, PageSize: limit})
+		if err != nil {
+			return nil, 0, err
+		}
+		items := make([]*domain.ActivityItem, len(alerts))
+		for i, alert := range alerts {
+			items[i] = domain.AlertActivity(alert)
+		}
+		return items, total, nil
+	}
+
+	return nil, 0, domain.ErrInvalidActivityType
+}
@@ -1,37 +1,113 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/acheevo/tfa/internal/admin/domain"
 	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	authservice "github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/concurrency"
 	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/cursor"
+	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
+	"github.com/acheevo/tfa/internal/shared/geoip"
+	applogger "github.com/acheevo/tfa/internal/shared/logger"
 	userdomain "github.com/acheevo/tfa/internal/user/domain"
 	"github.com/acheevo/tfa/internal/user/repository"
 )
 
 // AdminService handles admin user management operations
 type AdminService struct {
-	config    *config.Config
-	logger    *slog.Logger
-	userRepo  *repository.UserRepository
-	auditRepo *repository.AuditRepository
+	config         *config.Config
+	logger         *slog.Logger
+	userRepo       UserRepo
+	auditRepo      AuditRepo
+	emailService   *authservice.EmailService
+	lockouts       domain.LockoutProvider
+	securityLogger *applogger.SecurityLogger
+	emailQueue     domain.EmailQueue
+	broadcastRepo  BroadcastRepo
+	sessions       domain.SessionRevoker
+	accountEmails  domain.AccountEmailSender
+	mergeRepo      MergeRepo
+	ipLocator      domain.IPLocator
+	alertRepo      SecurityAlertRepo
+	alertNotifier  domain.AlertNotifier
+	loginLimits    domain.LoginLimitTightener
+	recoveryRepo   RecoveryRequestRepo
+	exportRepo     ExportRepo
+	exportStorage  domain.ExportStorage
+	exportMailer   domain.ExportMailer
+	clock          clock.Clock
+	cursors        *cursor.Codec
+	operations     *concurrency.Limiter
+	alertStream    *alertStream
+	// userActivity holds the latest user activity count, written by the metrics job and read by the dashboard
+	userActivity atomic.Pointer[domain.UserActivity]
 }
 
 // NewAdminService creates a new admin service
 func NewAdminService(
 	config *config.Config,
 	logger *slog.Logger,
-	userRepo *repository.UserRepository,
-	auditRepo *repository.AuditRepository,
+	userRepo UserRepo,
+	auditRepo AuditRepo,
+	emailService *authservice.EmailService,
+	lockouts domain.LockoutProvider,
+	securityLogger *applogger.SecurityLogger,
+	emailQueue domain.EmailQueue,
+	broadcastRepo BroadcastRepo,
+	sessions domain.SessionRevoker,
+	accountEmails domain.AccountEmailSender,
+	mergeRepo MergeRepo,
+	ipLocator domain.IPLocator,
+	alertRepo SecurityAlertRepo,
+	alertNotifier domain.AlertNotifier,
+	loginLimits domain.LoginLimitTightener,
+	recoveryRepo RecoveryRequestRepo,
+	exportRepo ExportRepo,
+	exportStorage domain.ExportStorage,
+	exportMailer domain.ExportMailer,
+	clk clock.Clock,
 ) *AdminService {
 	return &AdminService{
-		config:    config,
-		logger:    logger,
-		userRepo:  userRepo,
-		auditRepo: auditRepo,
+		config:         config,
+		logger:         logger,
+		userRepo:       userRepo,
+		auditRepo:      auditRepo,
+		emailService:   emailService,
+		lockouts:       lockouts,
+		securityLogger: securityLogger,
+		emailQueue:     emailQueue,
+		broadcastRepo:  broadcastRepo,
+		sessions:       sessions,
+		accountEmails:  accountEmails,
+		mergeRepo:      mergeRepo,
+		ipLocator:      ipLocator,
+		alertRepo:      alertRepo,
+		alertNotifier:  alertNotifier,
+		loginLimits:    loginLimits,
+		recoveryRepo:   recoveryRepo,
+		exportRepo:     exportRepo,
+		exportStorage:  exportStorage,
+		exportMailer:   exportMailer,
+		clock:          clk,
+		cursors:        cursor.NewCodec(config.JWTSecret),
+		operations: concurrency.NewLimiter(map[string]int{
+			domain.OperationBulkAction: config.AdminBulkConcurrency,
+			domain.OperationBroadcast:  config.AdminBroadcastConcurrency,
+			domain.OperationMerge:      config.AdminMergeConcurrency,
+			domain.OperationEmailQueue: config.AdminEmailQueueConcurrency,
+		}),
+		alertStream: newAlertStream(config.SecurityAlertStreamBuffer, config.SecurityAlertStreamMaxClients),
 	}
 }
 
@@ -47,8 +123,12 @@ func (s *AdminService) ListUsers(adminID uint, req *userdomain.UserListRequest)
 		return nil, domain.ErrNotAuthorized
 	}
 
+	if req.Count == "" {
+		req.Count = userdomain.CountMode(s.config.ListCountMode)
+	}
+
 	// Get users
-	users, total, err := s.userRepo.List(req)
+	users, count, err := s.userRepo.List(req)
 	if err != nil {
 		s.logger.Error("failed to list users", "admin_id", adminID, "error", err)
 		return nil, err
@@ -60,23 +140,15 @@ func (s *AdminService) ListUsers(adminID uint, req *userdomain.UserListRequest)
 		userSummaries[i] = userdomain.ToUserSummary(user)
 	}
 
-	// Build pagination
-	totalPages := (total + req.PageSize - 1) / req.PageSize
-	pagination := userdomain.Pagination{
-		Page:       req.Page,
-		PageSize:   req.PageSize,
-		Total:      total,
-		TotalPages: totalPages,
-		HasNext:    req.Page < totalPages,
-		HasPrev:    req.Page > 1,
-	}
-
 	return &userdomain.UserListResponse{
 		Users:      userSummaries,
-		Pagination: pagination,
+		Pagination: domain.NewListPagination(req.Page, req.PageSize, count),
 	}, nil
 }
 
+// auditTrailSummarySize is how many recent audit entries the user detail response includes
+const auditTrailSummarySize = 10
+
 // GetUserDetails retrieves detailed information about a user
 func (s *AdminService) GetUserDetails(adminID, targetUserID uint) (*userdomain.UserDetailResponse, error) {
 	// Check admin authorization
@@ -98,56 +170,107 @@ func (s *AdminService) GetUserDetails(adminID, targetUserID uint) (*userdomain.U
 	// Build response
 	response := userdomain.ToUserDetailResponse(targetUser)
 
-	// Get audit trail for this user
-	auditLogs, err := s.auditRepo.GetUserAuditHistory(targetUserID, 50)
+	// Get the most recent audit entries for this user; the full history is paginated separately
+	auditLogs, err := s.auditRepo.GetUserAuditHistory(targetUserID, auditTrailSummarySize)
 	if err != nil {
 		s.logger.Error("failed to get user audit history", "user_id", targetUserID, "error", err)
 		// Continue without audit trail rather than failing
 	} else {
-		response.AuditTrail = make([]userdomain.AuditLogEntry, len(auditLogs))
-		for i, log := range auditLogs {
-			response.AuditTrail[i] = userdomain.AuditLogEntry{
-				ID:          log.ID,
-				Action:      log.Action,
-				Level:       log.Level,
-				Resource:    log.Resource,
-				Description: log.Description,
-				IPAddress:   log.IPAddress,
-				UserAgent:   log.UserAgent,
-				Metadata:    log.Metadata,
-				CreatedAt:   log.CreatedAt,
-			}
-		}
+		response.AuditTrail = s.toAuditLogEntries(auditLogs)
 	}
 
 	return response, nil
 }
 
-// UpdateUserRole updates a user's role with comprehensive security validation
+// GetUserAuditLogs retrieves a page of a user's audit history, filtered by action, level, date
+// range and description
+func (s *AdminService) GetUserAuditLogs(
+	adminID, targetUserID uint,
+	req *domain.UserAuditLogRequest,
+) (*domain.UserAuditLogResponse, error) {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, domain.ErrNotAuthorized
+	}
+
+	// Validate date range
+	if req.DateFrom != nil && req.DateTo != nil && req.DateFrom.After(*req.DateTo) {
+		return nil, domain.ErrInvalidDateRange
+	}
+
+	// Make sure the user exists, so an unknown ID is a 404 rather than an empty history
+	if _, err := s.userRepo.GetByID(targetUserID); err != nil {
+		return nil, err
+	}
+
+	logs, total, err := s.auditRepo.ListUserHistory(targetUserID, req)
+	if err != nil {
+		s.logger.Error("failed to get user audit history", "admin_id", adminID, "user_id", targetUserID, "error", err)
+		return nil, err
+	}
+
+	return &domain.UserAuditLogResponse{
+		Logs:       s.toAuditLogEntries(logs),
+		Pagination: domain.NewPagination(req.Page, req.PageSize, total),
+	}, nil
+}
+
+// toAuditLogEntries converts audit logs to their response form
+func (s *AdminService) toAuditLogEntries(logs []*authdomain.AuditLog) []userdomain.AuditLogEntry {
+	entries := make([]userdomain.AuditLogEntry, len(logs))
+	for i, log := range logs {
+		entries[i] = userdomain.AuditLogEntry{
+			ID:          log.ID,
+			Action:      log.Action,
+			Level:       log.Level,
+			Resource:    log.Resource,
+			Description: log.Description,
+			IPAddress:   log.IPAddress,
+			Location:    s.locate(log.IPAddress),
+			UserAgent:   log.UserAgent,
+			Metadata:    log.Metadata,
+			CreatedAt:   log.CreatedAt,
+		}
+	}
+	return entries
+}
+
+// UpdateUserRole updates a user's role with comprehensive security validation. The returned
+// warnings describe unusual but allowed aspects of the change.
 func (s *AdminService) UpdateUserRole(
 	adminID, targetUserID uint,
 	req *domain.UpdateUserRoleRequest,
 	ipAddress, userAgent string,
-) error {
+) ([]string, error) {
 	// Check admin authorization
 	admin, err := s.userRepo.GetByID(adminID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !domain.IsAuthorizedForUserManagement(admin) {
-		return domain.ErrNotAuthorized
+		return nil, domain.ErrNotAuthorized
 	}
 
 	// Get target user
 	targetUser, err := s.userRepo.GetByID(targetUserID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Check if admin can manage this user
-	if !domain.CanManageUser(admin, targetUser) {
-		return domain.ErrCannotManageSelf
+	if err := domain.CheckCanManageUser(admin, targetUser); err != nil {
+		return nil, err
+	}
+
+	// Granting the super admin role is reserved for super admins
+	if req.Role == authdomain.RoleSuperAdmin && !admin.IsSuperAdmin() {
+		return nil, domain.ErrSuperAdminRequired
 	}
 
 	// Perform comprehensive security validation
@@ -163,6 +286,17 @@ func (s *AdminService) UpdateUserRole(
 		RequestSource: "web",
 	}
 
+	if s.config.LastAdminProtection && domain.RemovesActiveAdmin(targetUser, req.Role, "") {
+		remaining, err := s.userRepo.CountActiveAdminsExcluding([]uint{targetUserID})
+		if err != nil {
+			return nil, err
+		}
+		if remaining == 0 {
+			return nil, domain.ErrLastActiveAdmin
+		}
+		securityCheck.ActiveAdminsRemaining = &remaining
+	}
+
 	validationResult := authdomain.ValidateRoleChange(securityCheck)
 	if !validationResult.Valid {
 		s.logger.Warn("role change validation failed",
@@ -171,7 +305,7 @@ func (s *AdminService) UpdateUserRole(
 			"errors", validationResult.Errors,
 			"risk_level", validationResult.RiskLevel,
 		)
-		return fmt.Errorf("role change validation failed: %s", strings.Join(validationResult.Errors, "; "))
+		return nil, fmt.Errorf("role change validation failed: %s", strings.Join(validationResult.Errors, "; "))
 	}
 
 	// Log security warnings
@@ -185,6 +319,12 @@ func (s *AdminService) UpdateUserRole(
 		)
 	}
 
+	if targetUser.IsSuperAdmin() && req.Role != authdomain.RoleSuperAdmin {
+		if err := s.ensureSuperAdminRemains([]uint{targetUserID}); err != nil {
+			return nil, err
+		}
+	}
+
 	// Store the old role for audit
 	oldRole := targetUser.Role
 
@@ -233,7 +373,7 @@ func (s *AdminService) UpdateUserRole(
 			"target_user_id", targetUserID,
 			"error", err,
 		)
-		return err
+		return nil, err
 	}
 
 	// Create enhanced audit log with security validation details
@@ -247,11 +387,17 @@ func (s *AdminService) UpdateUserRole(
 		"risk_level":        validationResult.RiskLevel,
 	}
 
+	// Super admin grants and revocations are always recorded at warning level
+	auditLevel := authdomain.AuditLevelInfo
+	if oldRole == authdomain.RoleSuperAdmin || req.Role == authdomain.RoleSuperAdmin {
+		auditLevel = authdomain.AuditLevelWarning
+	}
+
 	if err := s.auditRepo.CreateAuditEntry(
 		&adminID,
 		&targetUserID,
 		authdomain.AuditActionUserRoleChanged,
-		authdomain.AuditLevelInfo,
+		auditLevel,
 		"admin",
 		fmt.Sprintf("Role changed from %s to %s: %s [Risk: %s]", oldRole, req.Role, req.Reason, validationResult.RiskLevel),
 		ipAddress,
@@ -265,6 +411,19 @@ func (s *AdminService) UpdateUserRole(
 		)
 	}
 
+	if oldRole != req.Role {
+		s.revokeSessionsForRoleChange(&adminID, targetUserID, oldRole, req.Role, ipAddress, userAgent)
+	}
+
+	s.securityLogger.Log(applogger.SecurityEventRoleChange, "user role changed",
+		"admin_id", adminID,
+		"target_user_id", targetUserID,
+		"old_role", oldRole,
+		"new_role", req.Role,
+		"risk_level", validationResult.RiskLevel,
+		"ip_address", ipAddress,
+	)
+
 	// Generate security alerts for high-risk changes
 	if validationResult.RiskLevel == "high" || validationResult.RiskLevel == "critical" {
 		alertData := map[string]interface{}{
@@ -281,23 +440,16 @@ func (s *AdminService) UpdateUserRole(
 		}
 
 		alert := authdomain.GenerateSecurityAlert(
-			"role_change",
+			domain.AlertTypeRoleChange,
 			validationResult.RiskLevel,
 			fmt.Sprintf("High-risk role change: %s → %s", oldRole, req.Role),
 			fmt.Sprintf("Admin %s changed role of %s from %s to %s", admin.Email, targetUser.Email, oldRole, req.Role),
 			admin,
 			alertData,
 		)
-
-		s.logger.Warn("security alert generated for role change",
-			"alert_id", alert.ID,
-			"alert_type", alert.Type,
-			"severity", alert.Severity,
-			"admin_id", adminID,
-			"target_user_id", targetUserID,
-		)
-
-		// TODO: Send alert to security monitoring system
+		if err := s.raiseSecurityAlert(context.Background(), alert); err != nil {
+			s.logger.Error("failed to store security alert for role change", "alert_id", alert.ID, "error", err)
+		}
 	}
 
 	s.logger.Info("role change completed successfully",
@@ -308,42 +460,57 @@ func (s *AdminService) UpdateUserRole(
 		"risk_level", validationResult.RiskLevel,
 	)
 
-	return nil
+	return validationResult.Warnings, nil
 }
 
-// UpdateUserStatus updates a user's status
+// UpdateUserStatus updates a user's status. The returned warnings describe unusual but allowed
+// aspects of the change.
 func (s *AdminService) UpdateUserStatus(
 	adminID, targetUserID uint,
 	req *domain.UpdateUserStatusRequest,
 	ipAddress, userAgent string,
-) error {
+) ([]string, error) {
 	// Check admin authorization
 	admin, err := s.userRepo.GetByID(adminID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !domain.IsAuthorizedForUserManagement(admin) {
-		return domain.ErrNotAuthorized
+		return nil, domain.ErrNotAuthorized
 	}
 
 	// Get target user
 	targetUser, err := s.userRepo.GetByID(targetUserID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Check if admin can manage this user
-	if !domain.CanManageUser(admin, targetUser) {
-		return domain.ErrCannotManageSelf
+	if err := domain.CheckCanManageUser(admin, targetUser); err != nil {
+		return nil, err
 	}
 
+	if targetUser.IsSuperAdmin() && req.Status != authdomain.StatusActive {
+		if err := s.ensureSuperAdminRemains([]uint{targetUserID}); err != nil {
+			return nil, err
+		}
+	}
+
+	if domain.RemovesActiveAdmin(targetUser, "", req.Status) {
+		if err := s.ensureActiveAdminRemains([]uint{targetUserID}); err != nil {
+			return nil, err
+		}
+	}
+
+	warnings := domain.StatusChangeWarnings(targetUser, req.Status, req.Reason)
+
 	// Update status
 	oldStatus := targetUser.Status
 	err = s.userRepo.UpdateUserStatus(targetUserID, req.Status)
 	if err != nil {
 		s.logger.Error("failed to update user status", "admin_id", adminID, "target_user_id", targetUserID, "error", err)
-		return err
+		return nil, err
 	}
 
 	// Create audit log
@@ -360,6 +527,7 @@ func (s *AdminService) UpdateUserStatus(
 			"old_status": oldStatus,
 			"new_status": req.Status,
 			"reason":     req.Reason,
+			"warnings":   warnings,
 		},
 	); err != nil {
 		s.logger.Error("failed to create audit log for status change",
@@ -368,10 +536,94 @@ func (s *AdminService) UpdateUserStatus(
 			"error", err)
 	}
 
+	return warnings, nil
+}
+
+// UpdateEmailVerification manually verifies or unverifies a user's email address
+func (s *AdminService) UpdateEmailVerification(
+	adminID, targetUserID uint,
+	req *domain.UpdateEmailVerificationRequest,
+	ipAddress, userAgent string,
+) error {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return domain.ErrNotAuthorized
+	}
+
+	// Get target user
+	targetUser, err := s.userRepo.GetByID(targetUserID)
+	if err != nil {
+		return err
+	}
+
+	// Check if admin can manage this user
+	if err := domain.CheckCanManageUser(admin, targetUser); err != nil {
+		return err
+	}
+
+	// Update verification flag
+	verified := *req.Verified
+	oldVerified := targetUser.EmailVerified
+	err = s.userRepo.UpdateEmailVerification(targetUserID, verified)
+	if err != nil {
+		s.logger.Error("failed to update email verification",
+			"admin_id", adminID,
+			"target_user_id", targetUserID,
+			"error", err)
+		return err
+	}
+
+	action := authdomain.AuditActionEmailVerified
+	level := authdomain.AuditLevelInfo
+	if !verified {
+		action = authdomain.AuditActionEmailUnverified
+		level = authdomain.AuditLevelWarning
+	}
+
+	// Create audit log
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		&targetUserID,
+		action,
+		level,
+		"admin",
+		fmt.Sprintf("Email verification changed from %t to %t by admin: %s", oldVerified, verified, req.Reason),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"email":        targetUser.Email,
+			"old_verified": oldVerified,
+			"new_verified": verified,
+			"reason":       req.Reason,
+			"notify_user":  req.NotifyUser,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for email verification change",
+			"admin_id", adminID,
+			"target_user_id", targetUserID,
+			"error", err)
+	}
+
+	// Notify user (don't fail the operation if email fails)
+	if req.NotifyUser && s.emailService != nil {
+		if err := s.emailService.SendEmailVerificationStatusChanged(targetUser.Email, targetUser.FirstName, verified); err != nil {
+			s.logger.Error("failed to send email verification status notification",
+				"target_user_id", targetUserID,
+				"error", err)
+		}
+	}
+
 	return nil
 }
 
-// UpdateUser updates user information (admin version)
+// UpdateUser updates user information (admin version). Role and status changes are made through
+// UpdateUserRole and UpdateUserStatus, so they are validated, audited, alerted on and end sessions
+// exactly as on their own endpoints.
 func (s *AdminService) UpdateUser(
 	adminID, targetUserID uint,
 	req *domain.AdminUpdateUserRequest,
@@ -394,13 +646,14 @@ func (s *AdminService) UpdateUser(
 	}
 
 	// Check if admin can manage this user
-	if !domain.CanManageUser(admin, targetUser) {
-		return domain.ErrCannotManageSelf
+	if err := domain.CheckCanManageUser(admin, targetUser); err != nil {
+		return err
 	}
 
 	// Check if email change is requested and if it already exists
+	req.Email = authdomain.NormalizeEmail(req.Email)
 	if req.Email != "" && req.Email != targetUser.Email {
-		exists, err := s.userRepo.CheckEmailExists(req.Email, targetUserID)
+		exists, err := s.userRepo.CheckEmailExists(req.Email, targetUserID, !s.config.UserDeletedEmailReuse)
 		if err != nil {
 			return err
 		}
@@ -409,8 +662,42 @@ func (s *AdminService) UpdateUser(
 		}
 	}
 
+	// Check the role and status together up front, so neither is applied when the pair is refused
+	demotesSuperAdmin := (req.Role != "" && req.Role != authdomain.RoleSuperAdmin) ||
+		(req.Status != "" && req.Status != authdomain.StatusActive)
+	if targetUser.IsSuperAdmin() && demotesSuperAdmin {
+		if err := s.ensureSuperAdminRemains([]uint{targetUserID}); err != nil {
+			return err
+		}
+	}
+
+	if domain.RemovesActiveAdmin(targetUser, req.Role, req.Status) {
+		if err := s.ensureActiveAdminRemains([]uint{targetUserID}); err != nil {
+			return err
+		}
+	}
+
+	if req.Role != "" && req.Role != targetUser.Role {
+		roleReq := &domain.UpdateUserRoleRequest{Role: req.Role, Reason: req.Reason}
+		if _, err := s.UpdateUserRole(adminID, targetUserID, roleReq, ipAddress, userAgent); err != nil {
+			return err
+		}
+		targetUser.Role = req.Role
+	}
+
+	if req.Status != "" && req.Status != targetUser.Status {
+		statusReq := &domain.UpdateUserStatusRequest{Status: req.Status, Reason: req.Reason}
+		if _, err := s.UpdateUserStatus(adminID, targetUserID, statusReq, ipAddress, userAgent); err != nil {
+			return err
+		}
+		targetUser.Status = req.Status
+	}
+
 	// Build changes for audit
 	changes := s.buildUserChanges(targetUser, req)
+	if changes == "" {
+		return nil
+	}
 
 	// Apply updates
 	if req.FirstName != "" {
@@ -425,12 +712,6 @@ func (s *AdminService) UpdateUser(
 			targetUser.EmailVerified = *req.EmailVerified
 		}
 	}
-	if req.Role != "" {
-		targetUser.Role = req.Role
-	}
-	if req.Status != "" {
-		targetUser.Status = req.Status
-	}
 	if req.Avatar != "" {
 		targetUser.Avatar = req.Avatar
 	}
@@ -483,6 +764,20 @@ func (s *AdminService) DeleteUsers(
 		return domain.ErrNotAuthorized
 	}
 
+	// Deleting several users at once is a bulk action. It is all-or-nothing, so listing the acting
+	// admin fails the request rather than deleting everyone else.
+	if len(userIDs) > 1 {
+		if slices.Contains(userIDs, adminID) {
+			return domain.ErrSelfInBulkAction
+		}
+
+		release, err := s.acquireOperation(domain.OperationBulkAction)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
 	// Get target users to check permissions and for audit
 	targetUsers, err := s.userRepo.GetUsersByIDs(userIDs)
 	if err != nil {
@@ -490,15 +785,38 @@ func (s *AdminService) DeleteUsers(
 	}
 
 	// Check permissions for each user
+	deletesSuperAdmin := false
+	deletesActiveAdmin := false
 	for _, targetUser := range targetUsers {
-		if !domain.CanManageUser(admin, targetUser) {
-			return domain.ErrCannotManageSelf
+		if err := domain.CheckCanManageUser(admin, targetUser); err != nil {
+			return err
+		}
+		deletesSuperAdmin = deletesSuperAdmin || targetUser.IsSuperAdmin()
+		deletesActiveAdmin = deletesActiveAdmin || (targetUser.IsAdmin() && targetUser.IsActive())
+	}
+
+	if deletesSuperAdmin {
+		if err := s.ensureSuperAdminRemains(userIDs); err != nil {
+			return err
+		}
+	}
+
+	if deletesActiveAdmin {
+		if err := s.ensureActiveAdminRemains(userIDs); err != nil {
+			return err
+		}
+	}
+
+	hardDelete := req.IsHardDelete(s.config.UserHardDeleteByDefault())
+	if hardDelete {
+		if err := s.ensureAdminRemains(admin, targetUsers, userIDs); err != nil {
+			return err
 		}
 	}
 
 	// Perform deletion
 	var deleteErr error
-	if req.Force {
+	if hardDelete {
 		deleteErr = s.userRepo.HardDelete(userIDs)
 	} else {
 		deleteErr = s.userRepo.SoftDelete(userIDs)
@@ -508,21 +826,27 @@ func (s *AdminService) DeleteUsers(
 		s.logger.Error("failed to delete users",
 			"admin_id", adminID,
 			"user_ids", userIDs,
-			"force", req.Force,
+			"force", hardDelete,
 			"error", deleteErr)
 		return deleteErr
 	}
 
 	// Create audit logs for each deleted user
 	deleteType := "soft"
-	if req.Force {
+	if hardDelete {
 		deleteType = "hard"
 	}
 
 	for _, targetUser := range targetUsers {
+		// A permanently deleted user can no longer be referenced; the email is kept in metadata
+		targetID := &targetUser.ID
+		if hardDelete {
+			targetID = nil
+		}
+
 		if err := s.auditRepo.CreateAuditEntry(
 			&adminID,
-			&targetUser.ID,
+			targetID,
 			authdomain.AuditActionUserDeleted,
 			authdomain.AuditLevelWarning,
 			"admin",
@@ -545,6 +869,145 @@ func (s *AdminService) DeleteUsers(
 	return nil
 }
 
+// ensureAdminRemains rejects a permanent deletion that would leave no admin account. Permanently
+// deleting an admin account is reserved for super admins.
+func (s *AdminService) ensureAdminRemains(admin *authdomain.User, targetUsers []*authdomain.User, userIDs []uint) error {
+	deletesAdmin := false
+	for _, targetUser := range targetUsers {
+		if targetUser.IsAdmin() {
+			deletesAdmin = true
+			break
+		}
+	}
+	if !deletesAdmin {
+		return nil
+	}
+	if !admin.IsSuperAdmin() {
+		return domain.ErrSuperAdminRequired
+	}
+
+	remaining, err := s.userRepo.CountAdminsExcluding(userIDs)
+	if err != nil {
+		return err
+	}
+	if remaining == 0 {
+		return domain.ErrLastAdmin
+	}
+	return nil
+}
+
+// ensureSuperAdminRemains rejects a change that would leave no active super admin, so the
+// break-glass account can never be removed by accident
+func (s *AdminService) ensureSuperAdminRemains(userIDs []uint) error {
+	remaining, err := s.userRepo.CountActiveSuperAdminsExcluding(userIDs)
+	if err != nil {
+		return err
+	}
+	if remaining == 0 {
+		return domain.ErrLastSuperAdmin
+	}
+	return nil
+}
+
+// ensureActiveAdminRemains rejects a change that would leave no active admin to run the admin
+// endpoints, unless LAST_ADMIN_PROTECTION is turned off
+func (s *AdminService) ensureActiveAdminRemains(userIDs []uint) error {
+	if !s.config.LastAdminProtection {
+		return nil
+	}
+
+	remaining, err := s.userRepo.CountActiveAdminsExcluding(userIDs)
+	if err != nil {
+		return err
+	}
+	if remaining == 0 {
+		return domain.ErrLastActiveAdmin
+	}
+	return nil
+}
+
+// acquireOperation takes a slot for an expensive operation, failing fast when the configured number
+// are already running on this instance. The returned func frees the slot.
+func (s *AdminService) acquireOperation(operation string) (func(), error) {
+	release, ok := s.operations.TryAcquire(operation)
+	if !ok {
+		s.logger.Warn("admin operation rejected, concurrency limit reached",
+			"operation", operation,
+			"running", s.operations.Running(operation),
+		)
+		return nil, domain.ErrOperationBusy
+	}
+	return release, nil
+}
+
+// purgeBatchSize bounds how many soft-deleted users a single purge run removes
+const purgeBatchSize = 500
+
+// PurgeDeletedUsers permanently deletes users that were soft-deleted longer ago than the retention
+// period. Admins are kept while no other admin account remains. It returns the number purged.
+func (s *AdminService) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	retention := s.config.UserDeletedRetentionDuration()
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	users, err := s.userRepo.GetSoftDeletedBefore(s.clock.Now().Add(-retention), purgeBatchSize)
+	if err != nil {
+		s.logger.Error("failed to get soft-deleted users", "error", err)
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, nil
+	}
+
+	adminsRemain, err := s.userRepo.CountAdminsExcluding(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	purge := make([]*authdomain.User, 0, len(users))
+	userIDs := make([]uint, 0, len(users))
+	for _, user := range users {
+		if user.IsAdmin() && adminsRemain == 0 {
+			s.logger.Warn("skipping purge of deleted admin: no other admin remains", "user_id", user.ID)
+			continue
+		}
+		purge = append(purge, user)
+		userIDs = append(userIDs, user.ID)
+	}
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	if err := s.userRepo.HardDelete(userIDs); err != nil {
+		s.logger.Error("failed to purge soft-deleted users", "user_ids", userIDs, "error", err)
+		return 0, err
+	}
+
+	for _, user := range purge {
+		if err := s.auditRepo.CreateAuditEntry(
+			nil,
+			nil,
+			authdomain.AuditActionUserDeleted,
+			authdomain.AuditLevelWarning,
+			"system",
+			fmt.Sprintf("User %s permanently deleted after retention period", user.Email),
+			"",
+			"",
+			map[string]interface{}{
+				"delete_type": "purge",
+				"user_email":  user.Email,
+				"deleted_at":  user.DeletedAt.Time,
+			},
+		); err != nil {
+			s.logger.Error("failed to create audit log for user purge", "user_id", user.ID, "error", err)
+		}
+	}
+
+	s.logger.Info("purged soft-deleted users", "count", len(userIDs), "retention", retention)
+	return len(userIDs), nil
+}
+
 // BulkUpdateUsers performs bulk operations on multiple users
 func (s *AdminService) BulkUpdateUsers(
 	adminID uint,
@@ -571,6 +1034,17 @@ func (s *AdminService) BulkUpdateUsers(
 		return nil, domain.ErrTooManyUsers
 	}
 
+	includesSelf := slices.Contains(req.UserIDs, adminID)
+	if includesSelf && s.config.BulkSelfAction != "skip" {
+		return nil, domain.ErrSelfInBulkAction
+	}
+
+	release, err := s.acquireOperation(domain.OperationBulkAction)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Get target users
 	targetUsers, err := s.userRepo.GetUsersByIDs(req.UserIDs)
 	if err != nil {
@@ -582,6 +1056,9 @@ func (s *AdminService) BulkUpdateUsers(
 		Results:        make([]domain.BulkActionItemResult, 0, len(req.UserIDs)),
 	}
 
+	// Active admins already removed earlier in this batch count towards the last-admin check
+	var removedAdmins []uint
+
 	// Process each user
 	for _, userID := range req.UserIDs {
 		itemResult := domain.BulkActionItemResult{
@@ -589,6 +1066,14 @@ func (s *AdminService) BulkUpdateUsers(
 			Success: false,
 		}
 
+		// Admins can't suspend, demote or delete themselves by including their own ID
+		if includesSelf && userID == adminID {
+			itemResult.Error = "cannot act on your own account"
+			result.Results = append(result.Results, itemResult)
+			result.Failed++
+			continue
+		}
+
 		// Find user in fetched users
 		var targetUser *authdomain.User
 		for _, user := range targetUsers {
@@ -613,6 +1098,23 @@ func (s *AdminService) BulkUpdateUsers(
 			continue
 		}
 
+		// Super admin accounts go through the single-user endpoints and their last-super-admin checks
+		if targetUser.IsSuperAdmin() {
+			itemResult.Error = "super admin accounts must be changed individually"
+			result.Results = append(result.Results, itemResult)
+			result.Failed++
+			continue
+		}
+
+		if removesActiveAdmin(targetUser, req) {
+			if err := s.ensureActiveAdminRemains(append(removedAdmins, userID)); err != nil {
+				itemResult.Error = err.Error()
+				result.Results = append(result.Results, itemResult)
+				result.Failed++
+				continue
+			}
+		}
+
 		// Perform action
 		var actionErr error
 		var actionDescription string
@@ -650,7 +1152,21 @@ func (s *AdminService) BulkUpdateUsers(
 			itemResult.Success = true
 			result.Successful++
 
+			if removesActiveAdmin(targetUser, req) {
+				removedAdmins = append(removedAdmins, userID)
+			}
+
 			// Create audit log
+			auditMetadata := map[string]interface{}{
+				"bulk_action":    req.Action,
+				"reason":         req.Reason,
+				"target_email":   targetUser.Email,
+				"target_user_id": userID,
+			}
+			if req.Action == domain.BulkActionRoleChange {
+				auditMetadata["old_role"] = targetUser.Role
+				auditMetadata["new_role"] = *req.Role
+			}
 			if err := s.auditRepo.CreateAuditEntry(
 				&adminID,
 				&userID,
@@ -660,18 +1176,17 @@ func (s *AdminService) BulkUpdateUsers(
 				fmt.Sprintf("Bulk operation: %s. Reason: %s", actionDescription, req.Reason),
 				ipAddress,
 				userAgent,
-				map[string]interface{}{
-					"bulk_action":    req.Action,
-					"reason":         req.Reason,
-					"target_email":   targetUser.Email,
-					"target_user_id": userID,
-				},
+				auditMetadata,
 			); err != nil {
 				s.logger.Error("failed to create audit log for bulk operation",
 					"admin_id", adminID,
 					"target_user_id", userID,
 					"error", err)
 			}
+
+			if req.Action == domain.BulkActionRoleChange && targetUser.Role != *req.Role {
+				s.revokeSessionsForRoleChange(&adminID, userID, targetUser.Role, *req.Role, ipAddress, userAgent)
+			}
 		}
 
 		result.Results = append(result.Results, itemResult)
@@ -680,6 +1195,21 @@ func (s *AdminService) BulkUpdateUsers(
 	return result, nil
 }
 
+// removesActiveAdmin reports whether a bulk action takes away the target's active admin access
+func removesActiveAdmin(target *authdomain.User, req *domain.BulkUserActionRequest) bool {
+	switch req.Action {
+	case domain.BulkActionDelete:
+		return target.IsAdmin() && target.IsActive()
+	case domain.BulkActionDeactivate:
+		return domain.RemovesActiveAdmin(target, "", authdomain.StatusInactive)
+	case domain.BulkActionSuspend:
+		return domain.RemovesActiveAdmin(target, "", authdomain.StatusSuspended)
+	case domain.BulkActionRoleChange:
+		return req.Role != nil && domain.RemovesActiveAdmin(target, *req.Role, "")
+	}
+	return false
+}
+
 // GetAdminStats retrieves admin dashboard statistics
 func (s *AdminService) GetAdminStats(adminID uint) (*domain.AdminStatsResponse, error) {
 	// Check admin authorization
@@ -725,9 +1255,24 @@ func (s *AdminService) GetAdminStats(adminID uint) (*domain.AdminStatsResponse,
 		NewUsersToday:    int(stats.NewUsersToday),
 		NewUsersThisWeek: int(stats.NewUsersThisWeek),
 		UserGrowth:       userGrowth,
+		Activity:         s.userActivity.Load(),
 	}, nil
 }
 
+// MeasureUserActivity counts the active accounts and the users who logged in over the last day and
+// 30 days, and keeps the result for the dashboard so it doesn't have to run the count itself
+func (s *AdminService) MeasureUserActivity(ctx context.Context, now time.Time) (*domain.UserActivity, error) {
+	activity, err := s.userRepo.CountUserActivity(now.Add(-domain.DailyActiveWindow), now.Add(-domain.MonthlyActiveWindow))
+	if err != nil {
+		s.logger.Error("failed to count user activity", "error", err)
+		return nil, err
+	}
+
+	activity.MeasuredAt = now
+	s.userActivity.Store(activity)
+	return activity, nil
+}
+
 // GetAuditLogs retrieves audit logs with filtering
 func (s *AdminService) GetAuditLogs(
 	adminID uint,
@@ -748,39 +1293,318 @@ func (s *AdminService) GetAuditLogs(
 		return nil, domain.ErrInvalidDateRange
 	}
 
+	if req.Count == "" {
+		req.Count = userdomain.CountMode(s.config.ListCountMode)
+	}
+
+	// Cursors are only issued for the default newest-first order, which is bound into them
+	sort, err := userdomain.ParseSort(req.Sort, domain.AuditSortFields, domain.DefaultAuditSort)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := cursor.Fingerprint(req.CursorFilters(sort))
+	if req.Cursor != "" {
+		var position domain.AuditLogPosition
+		if err := s.cursors.Decode(req.Cursor, fingerprint, &position); err != nil {
+			if errors.Is(err, cursor.ErrFilterMismatch) {
+				return nil, domain.ErrCursorFilterMismatch
+			}
+			return nil, domain.ErrInvalidCursor
+		}
+		req.After = &position
+	}
+
 	// Get audit logs
-	logs, total, err := s.auditRepo.List(req)
+	logs, count, err := s.auditRepo.List(req)
 	if err != nil {
 		s.logger.Error("failed to get audit logs", "admin_id", adminID, "error", err)
 		return nil, err
 	}
 
+	var nextCursor string
+	if sort == domain.DefaultAuditSort && count.HasNext && len(logs) > 0 {
+		last := logs[len(logs)-1]
+		nextCursor, err = s.cursors.Encode(domain.AuditLogPosition{CreatedAt: last.CreatedAt, ID: last.ID}, fingerprint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Convert to enhanced format
 	enhancedLogs := make([]*domain.EnhancedAuditLogEntry, len(logs))
 	for i, log := range logs {
 		enhancedLogs[i] = domain.ToEnhancedAuditLogEntry(log)
-	}
-
-	// Build pagination
-	totalPages := (total + req.PageSize - 1) / req.PageSize
-	pagination := userdomain.Pagination{
-		Page:       req.Page,
-		PageSize:   req.PageSize,
-		Total:      total,
-		TotalPages: totalPages,
-		HasNext:    req.Page < totalPages,
-		HasPrev:    req.Page > 1,
+		enhancedLogs[i].Location = s.locate(log.IPAddress)
 	}
 
 	return &domain.AdminAuditLogResponse{
 		Logs:       enhancedLogs,
-		Pagination: pagination,
+		Pagination: domain.NewListPagination(req.Page, req.PageSize, count),
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// ListQueuedEmails returns a page of queued emails filtered by status, recipient, template and date
+func (s *AdminService) ListQueuedEmails(
+	ctx context.Context,
+	adminID uint,
+	req *domain.EmailListRequest,
+) (*domain.EmailListResponse, error) {
+	if err := s.authorizeEmailQueue(adminID); err != nil {
+		return nil, err
+	}
+
+	if req.DateFrom != nil && req.DateTo != nil && req.DateFrom.After(*req.DateTo) {
+		return nil, domain.ErrInvalidDateRange
+	}
+
+	emails, total, err := s.emailQueue.ListQueuedEmails(ctx, req.ToFilter())
+	if err != nil {
+		s.logger.Error("failed to list queued emails", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+
+	return &domain.EmailListResponse{
+		Emails:     emails,
+		Pagination: domain.NewPagination(req.Page, req.PageSize, total),
+	}, nil
+}
+
+// ListEmailEvents returns a page of delivery events filtered by email, event type and date
+func (s *AdminService) ListEmailEvents(
+	ctx context.Context,
+	adminID uint,
+	req *domain.EmailEventListRequest,
+) (*domain.EmailEventListResponse, error) {
+	if err := s.authorizeEmailQueue(adminID); err != nil {
+		return nil, err
+	}
+
+	if req.DateFrom != nil && req.DateTo != nil && req.DateFrom.After(*req.DateTo) {
+		return nil, domain.ErrInvalidDateRange
+	}
+
+	events, total, err := s.emailQueue.ListDeliveryEvents(ctx, req.ToFilter())
+	if err != nil {
+		s.logger.Error("failed to list email delivery events", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+
+	return &domain.EmailEventListResponse{
+		Events:     events,
+		Pagination: domain.NewPagination(req.Page, req.PageSize, total),
 	}, nil
 }
 
+// authorizeEmailQueue checks that the admin may use the email queue and that it is available
+func (s *AdminService) authorizeEmailQueue(adminID uint) error {
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return domain.ErrNotAuthorized
+	}
+
+	if s.emailQueue == nil {
+		return domain.ErrEmailQueueOffline
+	}
+
+	return nil
+}
+
 // Helper methods
 
-// buildUserChanges builds a human-readable string of user changes
+// GetLoginStats summarizes failed logins by IP and account over a window, along with current lockouts
+func (s *AdminService) GetLoginStats(adminID uint, req *domain.LoginStatsRequest) (*domain.LoginStatsResponse, error) {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, domain.ErrNotAuthorized
+	}
+
+	window := s.config.SecurityStatsWindowDuration()
+	if req.Window != "" {
+		window, err = time.ParseDuration(req.Window)
+		if err != nil || window <= 0 || window > s.config.SecurityStatsMaxWindowDuration() {
+			return nil, domain.ErrInvalidWindow
+		}
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	limit = min(limit, s.config.SecurityStatsLimit())
+
+	since := time.Now().Add(-window)
+	summary, err := s.auditRepo.SummarizeFailedLogins(since, limit)
+	if err != nil {
+		s.logger.Error("failed to summarize failed logins", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+
+	stats := &domain.LoginStatsResponse{
+		Window:          window.String(),
+		Since:           since,
+		TotalFailures:   summary.TotalFailures,
+		UniqueIPs:       summary.UniqueIPs,
+		UniqueAccounts:  summary.UniqueAccounts,
+		TopIPs:          summary.TopIPs,
+		TopAccounts:     summary.TopAccounts,
+		CurrentLockouts: []domain.LockoutEntry{},
+	}
+	for i := range stats.TopIPs {
+		stats.TopIPs[i].Location = s.locate(stats.TopIPs[i].Key)
+	}
+
+	if s.lockouts != nil {
+		for _, lockout := range s.lockouts.CurrentLockouts() {
+			if len(stats.CurrentLockouts) == limit {
+				break
+			}
+			stats.CurrentLockouts = append(stats.CurrentLockouts, domain.LockoutEntry{
+				Key:      lockout.Key,
+				Attempts: lockout.Attempts,
+				ResetsAt: lockout.ResetsAt,
+			})
+		}
+	}
+
+	return stats, nil
+}
+
+// GetEmailQueueStats returns the number of queued emails in each delivery state
+func (s *AdminService) GetEmailQueueStats(ctx context.Context, adminID uint) (*emaildomain.QueueStats, error) {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, domain.ErrNotAuthorized
+	}
+
+	if s.emailQueue == nil {
+		return nil, domain.ErrEmailQueueOffline
+	}
+
+	stats, err := s.emailQueue.GetQueueStats(ctx)
+	if err != nil {
+		s.logger.Error("failed to get email queue stats", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// ProcessEmailQueue runs one email queue processing cycle on demand
+func (s *AdminService) ProcessEmailQueue(
+	ctx context.Context,
+	adminID uint,
+	ipAddress, userAgent string,
+) (*domain.EmailQueueProcessResponse, error) {
+	before, err := s.GetEmailQueueStats(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := s.acquireOperation(domain.OperationEmailQueue)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := s.emailQueue.ProcessQueue(ctx); err != nil {
+		s.logger.Error("failed to process email queue", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+
+	after, err := s.emailQueue.GetQueueStats(ctx)
+	if err != nil {
+		s.logger.Error("failed to get email queue stats", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		nil,
+		authdomain.AuditActionEmailQueueRun,
+		authdomain.AuditLevelInfo,
+		"admin",
+		"Email queue processed manually",
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"pending_before": before.Pending,
+			"pending_after":  after.Pending,
+			"sent_before":    before.Sent,
+			"sent_after":     after.Sent,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for email queue processing", "error", err)
+	}
+
+	s.logger.Info("email queue processed manually",
+		"admin_id", adminID,
+		"pending_before", before.Pending,
+		"pending_after", after.Pending,
+	)
+
+	return &domain.EmailQueueProcessResponse{
+		Message: "email queue processed",
+		Before:  before,
+		After:   after,
+	}, nil
+}
+
+// CancelScheduledEmail cancels a scheduled email before it is sent
+func (s *AdminService) CancelScheduledEmail(ctx context.Context, adminID uint, messageID, ipAddress, userAgent string) error {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return domain.ErrNotAuthorized
+	}
+
+	if s.emailQueue == nil {
+		return domain.ErrEmailQueueOffline
+	}
+
+	if err := s.emailQueue.CancelScheduled(ctx, messageID); err != nil {
+		return err
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		nil,
+		authdomain.AuditActionEmailCanceled,
+		authdomain.AuditLevelInfo,
+		"admin",
+		fmt.Sprintf("Scheduled email %s canceled", messageID),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"message_id": messageID,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for email cancellation", "error", err)
+	}
+
+	s.logger.Info("scheduled email canceled", "admin_id", adminID, "message_id", messageID)
+	return nil
+}
+
+// buildUserChanges builds a human-readable string of the profile changes, empty when there are none
 func (s *AdminService) buildUserChanges(current *authdomain.User, req *domain.AdminUpdateUserRequest) string {
 	var changes []string
 
@@ -796,14 +1620,6 @@ func (s *AdminService) buildUserChanges(current *authdomain.User, req *domain.Ad
 		changes = append(changes, fmt.Sprintf("email: '%s' -> '%s'", current.Email, req.Email))
 	}
 
-	if req.Role != "" && current.Role != req.Role {
-		changes = append(changes, fmt.Sprintf("role: '%s' -> '%s'", current.Role, req.Role))
-	}
-
-	if req.Status != "" && current.Status != req.Status {
-		changes = append(changes, fmt.Sprintf("status: '%s' -> '%s'", current.Status, req.Status))
-	}
-
 	if req.EmailVerified != nil && current.EmailVerified != *req.EmailVerified {
 		changes = append(changes, fmt.Sprintf("email verified: %t -> %t", current.EmailVerified, *req.EmailVerified))
 	}
@@ -813,12 +1629,62 @@ func (s *AdminService) buildUserChanges(current *authdomain.User, req *domain.Ad
 	}
 
 	if len(changes) == 0 {
-		return "no changes"
+		return ""
 	}
 
 	return fmt.Sprintf("[%s]", strings.Join(changes, ", "))
 }
 
+// locate returns the cached approximate location of an IP address, if GeoIP enrichment is on
+func (s *AdminService) locate(ip string) *geoip.Location {
+	if s.ipLocator == nil {
+		return nil
+	}
+	return s.ipLocator.Locate(ip)
+}
+
+// revokeSessionsForRoleChange ends the target's sessions after a role change when configured, so
+// access tokens carrying the old role stop working immediately. adminID is nil for changes the
+// system made itself.
+func (s *AdminService) revokeSessionsForRoleChange(
+	adminID *uint,
+	targetUserID uint,
+	oldRole, newRole authdomain.UserRole,
+	ipAddress, userAgent string,
+) {
+	if !s.config.RoleChangeRevokesSessions || s.sessions == nil {
+		return
+	}
+
+	revoked, err := s.sessions.RevokeUserSessions(targetUserID)
+	if err != nil {
+		s.logger.Error("failed to revoke sessions after role change",
+			"target_user_id", targetUserID,
+			"error", err,
+		)
+		return
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		adminID,
+		&targetUserID,
+		authdomain.AuditActionSessionsRevoked,
+		authdomain.AuditLevelInfo,
+		"admin",
+		fmt.Sprintf("Revoked %d session(s) after role change from %s to %s", revoked, oldRole, newRole),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"reason":           "role_change",
+			"sessions_revoked": revoked,
+			"old_role":         oldRole,
+			"new_role":         newRole,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for session revocation", "target_user_id", targetUserID, "error", err)
+	}
+}
+
 // getAuditActionForBulkAction maps bulk actions to audit actions
 func (s *AdminService) getAuditActionForBulkAction(action domain.BulkActionType) authdomain.AuditAction {
 	switch action {
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+const (
+	superAdminID uint = iota + 1
+	adminID
+	userID
+	suspendedAdminID
+	pendingUserID
+)
+
+func newTestAdminService(cfg *config.Config) (*AdminService, *fakeUserRepo, *fakeAuditRepo) {
+	users := newFakeUserRepo(
+		&authdomain.User{ID: superAdminID, Email: "root@example.com", Role: authdomain.RoleSuperAdmin, Status: authdomain.StatusActive},
+		&authdomain.User{ID: adminID, Email: "admin@example.com", Role: authdomain.RoleAdmin, Status: authdomain.StatusActive},
+		&authdomain.User{ID: userID, Email: "user@example.com", Role: authdomain.RoleUser, Status: authdomain.StatusActive},
+		&authdomain.User{ID: suspendedAdminID, Email: "former@example.com", Role: authdomain.RoleAdmin, Status: authdomain.StatusSuspended},
+		&authdomain.User{ID: pendingUserID, Email: "pending@example.com", Role: authdomain.RoleUser, Status: authdomain.StatusPending},
+	)
+	audit := &fakeAuditRepo{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	s := NewAdminService(cfg, logger, users, audit, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, clock.New())
+	return s, users, audit
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		actorID uint
+		target  uint
+		role    authdomain.UserRole
+		wantErr error
+	}{
+		{"admin promotes user", adminID, userID, authdomain.RoleAdmin, nil},
+		{"super admin grants super admin", superAdminID, adminID, authdomain.RoleSuperAdmin, nil},
+		{"admin cannot grant super admin", adminID, userID, authdomain.RoleSuperAdmin, domain.ErrSuperAdminRequired},
+		{"admin cannot manage super admin", adminID, superAdminID, authdomain.RoleUser, domain.ErrSuperAdminRequired},
+		{"admin cannot change own role", adminID, adminID, authdomain.RoleUser, domain.ErrCannotManageSelf},
+		{"regular user is not authorized", userID, pendingUserID, authdomain.RoleAdmin, domain.ErrNotAuthorized},
+		{"suspended admin is not authorized", suspendedAdminID, userID, authdomain.RoleAdmin, domain.ErrNotAuthorized},
+		{"unknown target", adminID, 99, authdomain.RoleAdmin, authdomain.ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, users, audit := newTestAdminService(&config.Config{LastAdminProtection: true})
+			before := map[uint]authdomain.UserRole{}
+			for id, user := range users.users {
+				before[id] = user.Role
+			}
+
+			_, err := s.UpdateUserRole(tt.actorID, tt.target, &domain.UpdateUserRoleRequest{
+				Role:   tt.role,
+				Reason: "quarterly access review",
+			}, "127.0.0.1", "test")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, audit.actions)
+				for id, user := range users.users {
+					assert.Equal(t, before[id], user.Role)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.role, users.users[tt.target].Role)
+			assert.Equal(t, []authdomain.AuditAction{authdomain.AuditActionUserRoleChanged}, audit.actions)
+		})
+	}
+}
+
+func TestUpdateUserNormalizesEmail(t *testing.T) {
+	s, users, _ := newTestAdminService(&config.Config{})
+
+	// Another user's email is taken however it is cased or padded
+	err := s.UpdateUser(adminID, pendingUserID, &domain.AdminUpdateUserRequest{
+		Email: " User@Example.COM ", Reason: "support ticket",
+	}, "127.0.0.1", "test")
+	assert.ErrorIs(t, err, userdomain.ErrEmailAlreadyExists)
+	assert.Equal(t, "pending@example.com", users.users[pendingUserID].Email)
+
+	err = s.UpdateUser(adminID, pendingUserID, &domain.AdminUpdateUserRequest{
+		Email: " Renamed@Example.com ", Reason: "support ticket",
+	}, "127.0.0.1", "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed@example.com", users.users[pendingUserID].Email)
+}
+
+func TestUpdateUserChangesRoleAndStatusLikeTheirOwnEndpoints(t *testing.T) {
+	t.Run("a demotion is a role change that ends sessions", func(t *testing.T) {
+		s, users, audit := newTestAdminService(&config.Config{RoleChangeRevokesSessions: true})
+		sessions := newFakeSessions(&authdomain.RefreshToken{ID: 1, UserID: adminID, Token: "laptop"})
+		s.sessions = sessions
+
+		err := s.UpdateUser(superAdminID, adminID, &domain.AdminUpdateUserRequest{
+			Role: authdomain.RoleUser, FirstName: "Former", Reason: "left the team",
+		}, "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, authdomain.RoleUser, users.users[adminID].Role)
+		assert.Equal(t, "Former", users.users[adminID].FirstName)
+		assert.Empty(t, sessions.tokens)
+		assert.Equal(t, []authdomain.AuditAction{
+			authdomain.AuditActionUserRoleChanged,
+			authdomain.AuditActionSessionsRevoked,
+			authdomain.AuditActionUserUpdated,
+		}, audit.actions)
+	})
+
+	t.Run("a status change is audited as one", func(t *testing.T) {
+		s, users, audit := newTestAdminService(&config.Config{})
+
+		err := s.UpdateUser(adminID, userID, &domain.AdminUpdateUserRequest{
+			Status: authdomain.StatusSuspended, Reason: "abuse report",
+		}, "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, authdomain.StatusSuspended, users.users[userID].Status)
+		assert.Equal(t, []authdomain.AuditAction{authdomain.AuditActionUserStatusChanged}, audit.actions)
+	})
+
+	t.Run("a refused role change applies nothing", func(t *testing.T) {
+		s, users, audit := newTestAdminService(&config.Config{})
+
+		err := s.UpdateUser(adminID, superAdminID, &domain.AdminUpdateUserRequest{
+			Role: authdomain.RoleUser, FirstName: "Root", Reason: "takeover",
+		}, "127.0.0.1", "test")
+
+		assert.ErrorIs(t, err, domain.ErrSuperAdminRequired)
+		assert.Equal(t, authdomain.RoleSuperAdmin, users.users[superAdminID].Role)
+		assert.Empty(t, users.users[superAdminID].FirstName)
+		assert.Empty(t, audit.actions)
+	})
+}
+
+func TestUpdateUserStatus(t *testing.T) {
+	t.Run("suspends user", func(t *testing.T) {
+		s, users, audit := newTestAdminService(&config.Config{})
+
+		_, err := s.UpdateUserStatus(adminID, userID, &domain.UpdateUserStatusRequest{
+			Status: authdomain.StatusSuspended,
+			Reason: "abuse report",
+		}, "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, authdomain.StatusSuspended, users.users[userID].Status)
+		assert.Equal(t, []authdomain.AuditAction{authdomain.AuditActionUserStatusChanged}, audit.actions)
+	})
+
+	t.Run("admin cannot suspend super admin", func(t *testing.T) {
+		s, users, audit := newTestAdminService(&config.Config{})
+
+		_, err := s.UpdateUserStatus(adminID, superAdminID, &domain.UpdateUserStatusRequest{
+			Status: authdomain.StatusSuspended,
+			Reason: "abuse report",
+		}, "127.0.0.1", "test")
+
+		assert.ErrorIs(t, err, domain.ErrSuperAdminRequired)
+		assert.Equal(t, authdomain.StatusActive, users.users[superAdminID].Status)
+		assert.Empty(t, audit.actions)
+	})
+}
+
+func TestApproveUser(t *testing.T) {
+	t.Run("activates pending user", func(t *testing.T) {
+		s, users, audit := newTestAdminService(&config.Config{})
+
+		err := s.ApproveUser(adminID, pendingUserID, &domain.ApproveUserRequest{}, "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, authdomain.StatusActive, users.users[pendingUserID].Status)
+		assert.Equal(t, []authdomain.AuditAction{authdomain.AuditActionUserApproved}, audit.actions)
+	})
+
+	t.Run("rejects user that is not pending", func(t *testing.T) {
+		s, _, audit := newTestAdminService(&config.Config{})
+
+		err := s.ApproveUser(adminID, userID, &domain.ApproveUserRequest{}, "127.0.0.1", "test")
+
+		assert.ErrorIs(t, err, domain.ErrUserNotPending)
+		assert.Empty(t, audit.actions)
+	})
+}
+
+func TestDeleteUsersIncludingSelf(t *testing.T) {
+	s, users, audit := newTestAdminService(&config.Config{})
+
+	err := s.DeleteUsers(adminID, &domain.DeleteUserRequest{Reason: "cleanup"}, []uint{userID, adminID}, "127.0.0.1", "test")
+
+	assert.ErrorIs(t, err, domain.ErrSelfInBulkAction)
+	assert.False(t, users.users[userID].DeletedAt.Valid)
+	assert.False(t, users.users[adminID].DeletedAt.Valid)
+	assert.Empty(t, audit.actions)
+}
+
+func TestBulkUpdateUsersIncludingSelf(t *testing.T) {
+	req := &domain.BulkUserActionRequest{
+		UserIDs: []uint{userID, adminID},
+		Action:  domain.BulkActionDelete,
+		Reason:  "cleanup",
+	}
+
+	t.Run("rejects the request by default", func(t *testing.T) {
+		s, users, audit := newTestAdminService(&config.Config{})
+
+		result, err := s.BulkUpdateUsers(adminID, req, "127.0.0.1", "test")
+
+		assert.ErrorIs(t, err, domain.ErrSelfInBulkAction)
+		assert.Nil(t, result)
+		assert.False(t, users.users[userID].DeletedAt.Valid)
+		assert.Empty(t, audit.actions)
+	})
+
+	t.Run("skips the admin's own account", func(t *testing.T) {
+		s, users, _ := newTestAdminService(&config.Config{BulkSelfAction: "skip"})
+
+		result, err := s.BulkUpdateUsers(adminID, req, "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Successful)
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, domain.BulkActionItemResult{UserID: adminID, Error: "cannot act on your own account"}, result.Results[1])
+		assert.True(t, users.users[userID].DeletedAt.Valid)
+		assert.False(t, users.users[adminID].DeletedAt.Valid)
+	})
+}
+
+func TestMeasureUserActivity(t *testing.T) {
+	s, users, _ := newTestAdminService(&config.Config{})
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	loggedIn := func(id uint, ago time.Duration) {
+		at := now.Add(-ago)
+		users.users[id].LastLoginAt = &at
+	}
+
+	loggedIn(superAdminID, time.Hour)
+	loggedIn(adminID, 24*time.Hour) // exactly a day ago still counts as daily
+	loggedIn(userID, 3*24*time.Hour)
+	loggedIn(suspendedAdminID, 31*24*time.Hour)
+	users.users[10] = &authdomain.User{ID: 10, Status: authdomain.StatusActive,
+		DeletedAt: gorm.DeletedAt{Time: now, Valid: true}, LastLoginAt: &now}
+
+	// The dashboard has nothing until the first count
+	stats, err := s.GetAdminStats(superAdminID)
+	assert.NoError(t, err)
+	assert.Nil(t, stats.Activity)
+
+	activity, err := s.MeasureUserActivity(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.UserActivity{Active: 3, DailyActive: 2, MonthlyActive: 3, MeasuredAt: now}, activity)
+
+	stats, err = s.GetAdminStats(superAdminID)
+	assert.NoError(t, err)
+	assert.Equal(t, activity, stats.Activity)
+}
+
+func TestGetLoginStats(t *testing.T) {
+	resetsAt := time.Date(2024, 1, 1, 12, 15, 0, 0, time.UTC)
+	newService := func(cfg *config.Config) (*AdminService, *fakeAuditRepo) {
+		s, _, audit := newTestAdminService(cfg)
+		audit.failures = domain.LoginFailureSummary{
+			TotalFailures:  9,
+			UniqueIPs:      3,
+			UniqueAccounts: 4,
+			TopIPs:         []domain.LoginFailureCount{{Key: "10.0.0.1", Failures: 5}},
+			TopAccounts:    []domain.LoginFailureCount{{Key: "victim@example.com", Failures: 6}},
+		}
+		s.lockouts = &fakeLockouts{lockouts: []domain.LoginLockout{
+			{Key: "victim@example.com", Attempts: 5, ResetsAt: resetsAt},
+			{Key: "10.0.0.1", Attempts: 5, ResetsAt: resetsAt.Add(-time.Minute)},
+		}}
+		return s, audit
+	}
+
+	t.Run("reports the aggregated failures and lockouts", func(t *testing.T) {
+		s, audit := newService(&config.Config{SecurityStatsMaxLimit: 100})
+
+		stats, err := s.GetLoginStats(adminID, &domain.LoginStatsRequest{Window: "1h"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 10, audit.summaryLimit)
+		assert.Equal(t, "1h0m0s", stats.Window)
+		assert.Equal(t, 9, stats.TotalFailures)
+		assert.Equal(t, 4, stats.UniqueAccounts)
+		assert.Equal(t, "10.0.0.1", stats.TopIPs[0].Key)
+		assert.Equal(t, []domain.LockoutEntry{
+			{Key: "victim@example.com", Attempts: 5, ResetsAt: resetsAt},
+			{Key: "10.0.0.1", Attempts: 5, ResetsAt: resetsAt.Add(-time.Minute)},
+		}, stats.CurrentLockouts)
+	})
+
+	t.Run("caps the limit", func(t *testing.T) {
+		s, audit := newService(&config.Config{SecurityStatsMaxLimit: 1})
+
+		stats, err := s.GetLoginStats(adminID, &domain.LoginStatsRequest{Limit: 50})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 1, audit.summaryLimit)
+		assert.Len(t, stats.CurrentLockouts, 1)
+	})
+
+	t.Run("an unset cap falls back to the default", func(t *testing.T) {
+		s, audit := newService(&config.Config{})
+
+		_, err := s.GetLoginStats(adminID, &domain.LoginStatsRequest{Limit: 1000})
+		assert.NoError(t, err)
+		assert.Equal(t, 100, audit.summaryLimit)
+	})
+
+	t.Run("requires an admin", func(t *testing.T) {
+		s, _ := newService(&config.Config{})
+
+		_, err := s.GetLoginStats(userID, &domain.LoginStatsRequest{})
+		assert.ErrorIs(t, err, domain.ErrNotAuthorized)
+	})
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	t.Run("off by default", func(t *testing.T) {
+		s, users, _ := newTestAdminService(&config.Config{UserDeletedRetention: "0"})
+		users.users[userID].DeletedAt = gorm.DeletedAt{Time: time.Now().AddDate(-1, 0, 0), Valid: true}
+
+		purged, err := s.PurgeDeletedUsers(context.Background())
+		assert.NoError(t, err)
+		assert.Zero(t, purged)
+		assert.Contains(t, users.users, userID)
+	})
+
+	t.Run("purges users deleted longer ago than the retention", func(t *testing.T) {
+		s, users, audit := newTestAdminService(&config.Config{UserDeletedRetention: "720h"})
+		clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+		s.clock = clk
+		users.users[userID].DeletedAt = gorm.DeletedAt{Time: clk.Now(), Valid: true}
+
+		purged, err := s.PurgeDeletedUsers(context.Background())
+		assert.NoError(t, err)
+		assert.Zero(t, purged)
+
+		clk.Advance(721 * time.Hour)
+		purged, err = s.PurgeDeletedUsers(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		assert.NotContains(t, users.users, userID)
+		assert.NotEmpty(t, audit.actions)
+	})
+}
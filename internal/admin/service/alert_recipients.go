@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	authservice "github.com/acheevo/tfa/internal/auth/service"
+)
+
+var (
+	_ domain.AlertNotifier       = (*AlertRecipients)(nil)
+	_ domain.SecurityAlertMailer = (*authservice.EmailService)(nil)
+)
+
+// AlertRecipients delivers security alerts to everyone who should be told about them: the alert
+// webhook and each security contact's inbox. Either may be left unset.
+type AlertRecipients struct {
+	webhook domain.AlertNotifier
+	mailer  domain.SecurityAlertMailer
+	emails  []string
+}
+
+// NewAlertRecipients creates an AlertNotifier for the webhook and email addresses. The addresses
+// should already be deduplicated, as SECURITY_ALERT_EMAIL_RECIPIENTS is by the config.
+func NewAlertRecipients(webhook domain.AlertNotifier, mailer domain.SecurityAlertMailer, emails []string) *AlertRecipients {
+	return &AlertRecipients{
+		webhook: webhook,
+		mailer:  mailer,
+		emails:  emails,
+	}
+}
+
+// Send delivers an alert to every recipient once. A recipient that can't be reached doesn't stop the
+// others from being told; the failures are returned together.
+func (r *AlertRecipients) Send(ctx context.Context, event string, payload interface{}) error {
+	var errs []error
+	if r.webhook != nil {
+		if err := r.webhook.Send(ctx, event, payload); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+
+	// Only alerts can be rendered as an email
+	alert, ok := payload.(*authdomain.SecurityAlert)
+	if ok && r.mailer != nil {
+		for _, email := range r.emails {
+			if err := r.mailer.SendSecurityAlert(email, alert); err != nil {
+				errs = append(errs, fmt.Errorf("email %s: %w", email, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
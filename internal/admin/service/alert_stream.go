@@ -0,0 +1,132 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// defaultAlertStreamBuffer is used when SECURITY_ALERT_STREAM_BUFFER is unset
+const defaultAlertStreamBuffer = 16
+
+// alertStream fans security alerts raised on this instance out to the admins streaming them. It is
+// in-process, so a client only sees alerts raised by the replica it is connected to.
+type alertStream struct {
+	buffer     int
+	maxClients int
+
+	mu            sync.Mutex
+	subscriptions map[*AlertSubscription]struct{}
+}
+
+// newAlertStream creates a stream giving each client a buffer of alerts; maxClients of zero is unlimited
+func newAlertStream(buffer, maxClients int) *alertStream {
+	if buffer < 1 {
+		buffer = defaultAlertStreamBuffer
+	}
+
+	return &alertStream{
+		buffer:        buffer,
+		maxClients:    maxClients,
+		subscriptions: make(map[*AlertSubscription]struct{}),
+	}
+}
+
+// AlertSubscription receives the alerts raised while it is open. Close must be called once the
+// client has gone.
+type AlertSubscription struct {
+	stream *alertStream
+	alerts chan *authdomain.SecurityAlert
+	// lagged is set, under the stream's lock, when the client fell too far behind and was dropped
+	lagged bool
+	closed bool
+}
+
+// Alerts delivers each alert as it is raised. It is closed when the client falls a full buffer behind,
+// as the alerts it missed are gone; it can reconnect and catch up from the alert list.
+func (sub *AlertSubscription) Alerts() <-chan *authdomain.SecurityAlert {
+	return sub.alerts
+}
+
+// Lagged reports whether the subscription was dropped for falling behind
+func (sub *AlertSubscription) Lagged() bool {
+	sub.stream.mu.Lock()
+	defer sub.stream.mu.Unlock()
+	return sub.lagged
+}
+
+// Close stops delivery; it is safe to call more than once
+func (sub *AlertSubscription) Close() {
+	sub.stream.mu.Lock()
+	defer sub.stream.mu.Unlock()
+	sub.stream.remove(sub)
+}
+
+// subscribe opens a subscription, refusing it once the client cap is reached
+func (st *alertStream) subscribe() (*AlertSubscription, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.maxClients > 0 && len(st.subscriptions) >= st.maxClients {
+		return nil, domain.ErrTooManyAlertStreams
+	}
+
+	sub := &AlertSubscription{
+		stream: st,
+		alerts: make(chan *authdomain.SecurityAlert, st.buffer),
+	}
+	st.subscriptions[sub] = struct{}{}
+	return sub, nil
+}
+
+// publish hands the alert to every subscription without blocking. One whose buffer is full is
+// dropped rather than letting a slow client hold up the caller raising the alert.
+func (st *alertStream) publish(alert *authdomain.SecurityAlert) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for sub := range st.subscriptions {
+		delivered := *alert
+		select {
+		case sub.alerts <- &delivered:
+		default:
+			sub.lagged = true
+			st.remove(sub)
+		}
+	}
+}
+
+// remove closes a subscription's channel once; the caller holds the lock
+func (st *alertStream) remove(sub *AlertSubscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(st.subscriptions, sub)
+	close(sub.alerts)
+}
+
+// SubscribeSecurityAlerts opens a live stream of the security alerts raised from now on
+func (s *AdminService) SubscribeSecurityAlerts(adminID uint) (*AlertSubscription, error) {
+	if err := s.AuthorizeSecurityAlertStream(adminID); err != nil {
+		return nil, err
+	}
+
+	return s.alertStream.subscribe()
+}
+
+// AuthorizeSecurityAlertStream checks the admin may still watch the alert stream. An open stream
+// calls it again periodically, so an admin who is demoted or suspended stops receiving alerts.
+func (s *AdminService) AuthorizeSecurityAlertStream(adminID uint) error {
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return domain.ErrNotAuthorized
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+func TestSecurityAlertStreamDeliversRaisedAlerts(t *testing.T) {
+	cfg := &config.Config{EmailQueueAlertDepth: 100, EmailQueueAlertAge: "0", SecurityAlertWindow: "1h"}
+	s, _, _ := newTestAdminService(cfg)
+	s.alertRepo = &fakeAlertRepo{}
+
+	subscription, err := s.SubscribeSecurityAlerts(adminID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer subscription.Close()
+
+	raised, err := s.CheckEmailQueue(context.Background(), &emaildomain.QueueStats{Pending: 150})
+	if !assert.NoError(t, err) || !assert.Len(t, raised, 1) {
+		return
+	}
+
+	select {
+	case alert := <-subscription.Alerts():
+		assert.Equal(t, domain.AlertTypeEmailQueueDepth, alert.Type)
+		assert.Equal(t, raised[0].ID, alert.ID)
+	case <-time.After(time.Second):
+		t.Fatal("alert was not delivered to the stream")
+	}
+
+	// Once closed, nothing more is delivered
+	subscription.Close()
+	subscription.Close()
+	_, open := <-subscription.Alerts()
+	assert.False(t, open)
+	assert.False(t, subscription.Lagged())
+}
+
+func TestSecurityAlertStreamRequiresAdmin(t *testing.T) {
+	s, _, _ := newTestAdminService(&config.Config{})
+
+	_, err := s.SubscribeSecurityAlerts(userID)
+	assert.ErrorIs(t, err, domain.ErrNotAuthorized)
+
+	_, err = s.SubscribeSecurityAlerts(suspendedAdminID)
+	assert.ErrorIs(t, err, domain.ErrNotAuthorized)
+
+	assert.NoError(t, s.AuthorizeSecurityAlertStream(adminID))
+	assert.ErrorIs(t, s.AuthorizeSecurityAlertStream(suspendedAdminID), domain.ErrNotAuthorized)
+}
+
+func TestSecurityAlertStreamBackpressure(t *testing.T) {
+	stream := newAlertStream(2, 0)
+	slow, err := stream.subscribe()
+	assert.NoError(t, err)
+	fast, err := stream.subscribe()
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		stream.publish(&authdomain.SecurityAlert{ID: "alert"})
+		<-fast.Alerts()
+	}
+
+	// The slow client is dropped after its buffer, without holding up the fast one
+	assert.True(t, slow.Lagged())
+	assert.False(t, fast.Lagged())
+	received := 0
+	for range slow.Alerts() {
+		received++
+	}
+	assert.Equal(t, 2, received)
+
+	stream.publish(&authdomain.SecurityAlert{ID: "later"})
+	assert.Equal(t, "later", (<-fast.Alerts()).ID)
+	slow.Close()
+}
+
+func TestSecurityAlertStreamClientCap(t *testing.T) {
+	stream := newAlertStream(1, 1)
+
+	first, err := stream.subscribe()
+	assert.NoError(t, err)
+	_, err = stream.subscribe()
+	assert.ErrorIs(t, err, domain.ErrTooManyAlertStreams)
+
+	// A closed stream frees its place
+	first.Close()
+	second, err := stream.subscribe()
+	assert.NoError(t, err)
+	second.Close()
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// ApproveUser activates a self-registered user waiting for approval and emails them that
+// they can now sign in
+func (s *AdminService) ApproveUser(
+	adminID, targetUserID uint,
+	req *domain.ApproveUserRequest,
+	ipAddress, userAgent string,
+) error {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return domain.ErrNotAuthorized
+	}
+
+	// Get target user
+	targetUser, err := s.userRepo.GetByID(targetUserID)
+	if err != nil {
+		return err
+	}
+
+	// Check if admin can manage this user
+	if err := domain.CheckCanManageUser(admin, targetUser); err != nil {
+		return err
+	}
+
+	if targetUser.Status != authdomain.StatusPending {
+		return domain.ErrUserNotPending
+	}
+
+	activated, err := s.userRepo.ActivatePending(targetUserID)
+	if err != nil {
+		s.logger.Error("failed to approve user", "admin_id", adminID, "target_user_id", targetUserID, "error", err)
+		return err
+	}
+	if !activated {
+		return domain.ErrUserNotPending
+	}
+
+	description := fmt.Sprintf("Registration of %s approved by admin", targetUser.Email)
+	if req.Reason != "" {
+		description += ": " + req.Reason
+	}
+
+	// Create audit log
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		&targetUserID,
+		authdomain.AuditActionUserApproved,
+		authdomain.AuditLevelInfo,
+		"admin",
+		description,
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"email":      targetUser.Email,
+			"old_status": targetUser.Status,
+			"new_status": authdomain.StatusActive,
+			"role":       targetUser.Role,
+			"reason":     req.Reason,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for user approval",
+			"admin_id", adminID,
+			"target_user_id", targetUserID,
+			"error", err)
+	}
+
+	// Notify user (don't fail the approval if email fails)
+	if s.emailService != nil {
+		if err := s.emailService.SendAccountApproved(targetUser.Email, targetUser.FirstName); err != nil {
+			s.logger.Error("failed to send account approval email",
+				"target_user_id", targetUserID,
+				"error", err)
+		}
+	}
+
+	return nil
+}
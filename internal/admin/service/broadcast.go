@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// broadcastVariables are filled in per recipient and never need to be supplied by the admin
+var broadcastVariables = map[string]bool{
+	"user_name": true,
+	"app_name":  true,
+}
+
+// StartBroadcast validates an email broadcast and starts queueing it to every user matching the filter
+func (s *AdminService) StartBroadcast(
+	ctx context.Context,
+	adminID uint,
+	filter *userdomain.UserListRequest,
+	req *domain.BroadcastEmailRequest,
+	ipAddress, userAgent string,
+) (*domain.BroadcastJob, error) {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, domain.ErrNotAuthorized
+	}
+
+	if s.emailQueue == nil || s.broadcastRepo == nil {
+		return nil, domain.ErrEmailQueueOffline
+	}
+
+	// A broadcast exists only to send email, so don't report one as queued when nothing will go out
+	if !s.config.EmailEnabled {
+		return nil, authdomain.ErrEmailDisabled
+	}
+
+	if !domain.HasBroadcastFilter(filter) && !req.ConfirmAll {
+		return nil, domain.ErrBroadcastNoFilter
+	}
+
+	template, err := s.emailQueue.GetTemplate(req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	for _, variable := range template.Variables {
+		if _, ok := req.Variables[variable]; !ok && !broadcastVariables[variable] {
+			return nil, emaildomain.ErrTemplateMissingVariables
+		}
+	}
+
+	job := &domain.BroadcastJob{
+		ID:           uuid.New().String(),
+		AdminID:      adminID,
+		TemplateID:   req.TemplateID,
+		FilterSearch: filter.Search,
+		FilterRole:   string(filter.Role),
+		FilterStatus: string(filter.Status),
+		Essential:    req.Essential,
+		Reason:       req.Reason,
+		Status:       domain.BroadcastStatusRunning,
+	}
+	// The slot is held until the background run finishes, not just for this request
+	release, err := s.acquireOperation(domain.OperationBroadcast)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.broadcastRepo.Create(job); err != nil {
+		release()
+		s.logger.Error("failed to create broadcast job", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		nil,
+		authdomain.AuditActionEmailBroadcast,
+		authdomain.AuditLevelWarning,
+		"admin",
+		fmt.Sprintf("Email broadcast started with template %s", req.TemplateID),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"broadcast_id":  job.ID,
+			"template_id":   req.TemplateID,
+			"filter_search": filter.Search,
+			"filter_role":   filter.Role,
+			"filter_status": filter.Status,
+			"essential":     req.Essential,
+			"confirm_all":   req.ConfirmAll,
+			"reason":        req.Reason,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for email broadcast", "error", err)
+	}
+
+	s.logger.Info("email broadcast started",
+		"broadcast_id", job.ID,
+		"admin_id", adminID,
+		"template_id", req.TemplateID,
+	)
+
+	// Queueing continues after the request returns, so it must not inherit the request cancellation
+	started := *job
+	runCtx, runFilter := context.WithoutCancel(ctx), *filter
+	go func() {
+		defer release()
+		// A panic here would take the whole process down and leave the job running forever
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Error("email broadcast panicked", "broadcast_id", job.ID, "panic", r)
+				s.finishBroadcast(job, fmt.Errorf("broadcast stopped unexpectedly"))
+			}
+		}()
+		s.runBroadcast(runCtx, job, runFilter, req.Variables)
+	}()
+
+	return &started, nil
+}
+
+// GetBroadcast returns the progress of an email broadcast
+func (s *AdminService) GetBroadcast(adminID uint, broadcastID string) (*domain.BroadcastJob, error) {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, domain.ErrNotAuthorized
+	}
+
+	if s.broadcastRepo == nil {
+		return nil, domain.ErrEmailQueueOffline
+	}
+
+	return s.broadcastRepo.GetByID(broadcastID)
+}
+
+// runBroadcast pages through the matching users and schedules one personalized email per recipient.
+// Each batch holds one minute of the provider's rate limit and is scheduled a minute after the last.
+func (s *AdminService) runBroadcast(
+	ctx context.Context,
+	job *domain.BroadcastJob,
+	filter userdomain.UserListRequest,
+	variables map[string]interface{},
+) {
+	batchSize := s.config.EmailProviderRateLimit()
+	start := s.clock.Now()
+	batch := 0
+	inBatch := 0
+
+	// Page in a stable order so users created mid-broadcast do not shift later pages
+	filter.Page = 1
+	filter.PageSize = 100
+	filter.Sort = "created_at:asc"
+	// The job reports how many users matched, so the total is always counted
+	filter.Count = userdomain.CountExact
+
+	for {
+		users, count, err := s.userRepo.List(&filter)
+		if err != nil {
+			s.finishBroadcast(job, err)
+			return
+		}
+		job.Matched = count.Total
+
+		for _, user := range users {
+			if s.emailQueue.IsSuppressed(user.Email) {
+				job.SkippedSuppressed++
+				continue
+			}
+			if !domain.ShouldReceiveBroadcast(user, job.Essential) {
+				job.SkippedPreferences++
+				continue
+			}
+
+			if inBatch == batchSize {
+				batch++
+				inBatch = 0
+			}
+			inBatch++
+
+			recipientVariables := map[string]interface{}{
+				"user_name": user.FirstName,
+				"app_name":  s.config.AppName,
+			}
+			for key, value := range variables {
+				recipientVariables[key] = value
+			}
+
+			// Essential broadcasts go out right away; others may wait for the recipient's send window
+			metadata := map[string]string{
+				"broadcast_id":                        job.ID,
+				emaildomain.MetadataRecipientTimezone: user.Preferences.Timezone,
+			}
+			if job.Essential {
+				metadata[emaildomain.MetadataTransactional] = "true"
+			}
+
+			scheduledAt := start.Add(time.Duration(batch) * time.Minute)
+			if err := s.emailQueue.ScheduleTemplate(
+				ctx,
+				job.TemplateID,
+				[]string{user.Email},
+				recipientVariables,
+				metadata,
+				scheduledAt,
+			); err != nil {
+				s.logger.Error("failed to queue broadcast email", "broadcast_id", job.ID, "user_id", user.ID, "error", err)
+				job.Failed++
+				continue
+			}
+			job.Queued++
+		}
+
+		if err := s.broadcastRepo.Update(job); err != nil {
+			s.logger.Error("failed to update broadcast job", "broadcast_id", job.ID, "error", err)
+		}
+
+		if !count.HasNext {
+			break
+		}
+		filter.Page++
+	}
+
+	s.finishBroadcast(job, nil)
+}
+
+// finishBroadcast records the final state of a broadcast job
+func (s *AdminService) finishBroadcast(job *domain.BroadcastJob, err error) {
+	now := s.clock.Now()
+	job.CompletedAt = &now
+	job.Status = domain.BroadcastStatusCompleted
+	if err != nil {
+		job.Status = domain.BroadcastStatusFailed
+		job.Error = err.Error()
+		s.logger.Error("email broadcast failed", "broadcast_id", job.ID, "error", err)
+	}
+
+	if err := s.broadcastRepo.Update(job); err != nil {
+		s.logger.Error("failed to update broadcast job", "broadcast_id", job.ID, "error", err)
+		return
+	}
+
+	s.logger.Info("email broadcast finished",
+		"broadcast_id", job.ID,
+		"status", job.Status,
+		"queued", job.Queued,
+		"skipped_preferences", job.SkippedPreferences,
+		"skipped_suppressed", job.SkippedSuppressed,
+		"failed", job.Failed,
+	)
+}
+
+// FailStaleBroadcasts is run by the background scheduler. Broadcasts run on the instance that started
+// them and save their progress after every page, so one that hasn't saved for the stale period was
+// left behind by an instance that went away; it is marked failed. It returns how many there were.
+func (s *AdminService) FailStaleBroadcasts(ctx context.Context) (int64, error) {
+	if s.broadcastRepo == nil {
+		return 0, nil
+	}
+
+	now := s.clock.Now()
+	stale, err := s.broadcastRepo.FailStale(now.Add(-s.config.BroadcastStaleAfterDuration()), now)
+	if err != nil {
+		return 0, err
+	}
+	if stale > 0 {
+		s.logger.Warn("failed broadcasts left running", "count", stale)
+	}
+	return stale, nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+func TestBroadcastPanicFailsJob(t *testing.T) {
+	s, _, _ := newTestAdminService(&config.Config{EmailEnabled: true})
+	broadcasts := newFakeBroadcastRepo()
+	s.broadcastRepo = broadcasts
+	s.emailQueue = &fakeEmailQueue{panicOnSend: true}
+
+	job, err := s.StartBroadcast(
+		context.Background(),
+		adminID,
+		&userdomain.UserListRequest{Role: "user"},
+		&domain.BroadcastEmailRequest{TemplateID: "announcement", Reason: "launch"},
+		"127.0.0.1",
+		"test-agent",
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Eventually(t, func() bool {
+		stored, err := broadcasts.GetByID(job.ID)
+		return err == nil && stored.Status == domain.BroadcastStatusFailed
+	}, time.Second, 10*time.Millisecond)
+
+	stored, _ := broadcasts.GetByID(job.ID)
+	assert.Equal(t, "broadcast stopped unexpectedly", stored.Error)
+	assert.NotNil(t, stored.CompletedAt)
+}
+
+func TestFailStaleBroadcasts(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s, _, _ := newTestAdminService(&config.Config{BroadcastStaleAfter: "15m"})
+	s.clock = clock.NewMock(now)
+	broadcasts := newFakeBroadcastRepo()
+	s.broadcastRepo = broadcasts
+
+	seed := []*domain.BroadcastJob{
+		{ID: "abandoned", Status: domain.BroadcastStatusRunning, UpdatedAt: now.Add(-time.Hour)},
+		{ID: "progressing", Status: domain.BroadcastStatusRunning, UpdatedAt: now.Add(-time.Minute)},
+		{ID: "finished", Status: domain.BroadcastStatusCompleted, UpdatedAt: now.Add(-time.Hour)},
+	}
+	for _, job := range seed {
+		assert.NoError(t, broadcasts.Create(job))
+	}
+
+	stale, err := s.FailStaleBroadcasts(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(1), stale)
+
+	for id, want := range map[string]domain.BroadcastStatus{
+		"abandoned":   domain.BroadcastStatusFailed,
+		"progressing": domain.BroadcastStatusRunning,
+		"finished":    domain.BroadcastStatusCompleted,
+	} {
+		job, err := broadcasts.GetByID(id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, want, job.Status, id)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	applogger "github.com/acheevo/tfa/internal/shared/logger"
+)
+
+// DemoteDormantAdmins demotes to user every admin with no audited admin activity, and no admin grant,
+// within ADMIN_DORMANT_PERIOD, so a dormant privileged account has to be granted its role again before
+// it can be used. Allowlisted admins are spared, and so is the most recently active one when demoting
+// them all would leave no active admin. It returns the admins demoted.
+func (s *AdminService) DemoteDormantAdmins(ctx context.Context, now time.Time) ([]*authdomain.User, error) {
+	if !s.config.AdminDormantDemotion {
+		return nil, nil
+	}
+
+	period := s.config.AdminDormantPeriodDuration()
+	cutoff := now.Add(-period)
+
+	admins, err := s.userRepo.ListByRole(authdomain.RoleAdmin)
+	if err != nil {
+		s.logger.Error("failed to list admins", "error", err)
+		return nil, err
+	}
+
+	adminIDs := make([]uint, len(admins))
+	for i, admin := range admins {
+		adminIDs[i] = admin.ID
+	}
+	lastActivity, err := s.auditRepo.LastAdminActivity(adminIDs)
+	if err != nil {
+		s.logger.Error("failed to get admin activity", "error", err)
+		return nil, err
+	}
+
+	allowlist := s.config.GetAdminDormantAllowlist()
+	var dormant []*authdomain.User
+	var dormantIDs []uint
+	lastActive := make(map[uint]time.Time)
+	for _, admin := range admins {
+		// An admin who has never used admin functions is counted from when the account was created
+		active := admin.CreatedAt
+		if at, ok := lastActivity[admin.ID]; ok && at.After(active) {
+			active = at
+		}
+		if !active.Before(cutoff) || slices.Contains(allowlist, authdomain.NormalizeEmail(admin.Email)) {
+			continue
+		}
+
+		dormant = append(dormant, admin)
+		dormantIDs = append(dormantIDs, admin.ID)
+		lastActive[admin.ID] = active
+	}
+	if len(dormant) == 0 {
+		return nil, nil
+	}
+
+	remaining, err := s.userRepo.CountActiveAdminsExcluding(dormantIDs)
+	if err != nil {
+		return nil, err
+	}
+	if remaining == 0 {
+		dormant = spareMostRecentlyActive(dormant, lastActive)
+	}
+
+	demoted := make([]*authdomain.User, 0, len(dormant))
+	for _, admin := range dormant {
+		if err := s.demoteDormantAdmin(admin, lastActive[admin.ID], period); err != nil {
+			return demoted, err
+		}
+		demoted = append(demoted, admin)
+	}
+
+	s.logger.Info("demoted dormant admins", "count", len(demoted), "period", period)
+	return demoted, nil
+}
+
+// spareMostRecentlyActive drops the active admin who used admin functions most recently, so demoting
+// the rest leaves one active admin
+func spareMostRecentlyActive(dormant []*authdomain.User, lastActive map[uint]time.Time) []*authdomain.User {
+	spare := -1
+	for i, admin := range dormant {
+		if admin.IsActive() && (spare < 0 || lastActive[admin.ID].After(lastActive[dormant[spare].ID])) {
+			spare = i
+		}
+	}
+	if spare < 0 {
+		return dormant
+	}
+	return slices.Delete(slices.Clone(dormant), spare, spare+1)
+}
+
+// demoteDormantAdmin makes an admin a regular user, records why and tells them how to get access back
+func (s *AdminService) demoteDormantAdmin(admin *authdomain.User, lastActive time.Time, period time.Duration) error {
+	oldRole := admin.Role
+	if err := s.userRepo.UpdateUserRole(admin.ID, authdomain.RoleUser); err != nil {
+		s.logger.Error("failed to demote dormant admin", "user_id", admin.ID, "error", err)
+		return err
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		nil,
+		&admin.ID,
+		authdomain.AuditActionUserRoleChanged,
+		authdomain.AuditLevelWarning,
+		"system",
+		fmt.Sprintf("Role changed from %s to %s: no admin activity since %s", oldRole, authdomain.RoleUser,
+			lastActive.UTC().Format(time.RFC3339)),
+		"",
+		"",
+		map[string]interface{}{
+			"old_role":            oldRole,
+			"new_role":            authdomain.RoleUser,
+			"reason":              "dormant_admin",
+			"last_admin_activity": lastActive,
+			"dormant_period":      period.String(),
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for dormant admin demotion", "user_id", admin.ID, "error", err)
+	}
+
+	s.revokeSessionsForRoleChange(nil, admin.ID, oldRole, authdomain.RoleUser, "", "")
+
+	s.securityLogger.Log(applogger.SecurityEventRoleChange, "dormant admin demoted",
+		"target_user_id", admin.ID,
+		"old_role", oldRole,
+		"new_role", authdomain.RoleUser,
+		"last_admin_activity", lastActive,
+	)
+
+	if s.emailService != nil {
+		if err := s.emailService.SendAdminAccessRemoved(admin.Email, admin.FirstName, period); err != nil {
+			s.logger.Error("failed to notify demoted admin", "user_id", admin.ID, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+const dormantAdminID uint = 10
+
+func TestDemoteDormantAdmins(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+	adminAccess := func(adminID uint, at time.Time) *authdomain.AuditLog {
+		log := auditLog(adminID, 0, authdomain.AuditActionAdminAccess)
+		log.Resource = "admin"
+		log.CreatedAt = at
+		return log
+	}
+
+	newService := func(cfg *config.Config) (*AdminService, *fakeUserRepo, *fakeAuditRepo) {
+		cfg.AdminDormantDemotion = true
+		cfg.AdminDormantPeriod = "720h"
+		s, users, audit := newTestAdminService(cfg)
+		users.users[dormantAdminID] = &authdomain.User{
+			ID:        dormantAdminID,
+			Email:     "Dormant@example.com",
+			Role:      authdomain.RoleAdmin,
+			Status:    authdomain.StatusActive,
+			CreatedAt: daysAgo(400),
+		}
+		audit.logs = []*authdomain.AuditLog{
+			adminAccess(adminID, daysAgo(1)),
+			adminAccess(dormantAdminID, daysAgo(40)),
+		}
+		return s, users, audit
+	}
+	demotedIDs := func(demoted []*authdomain.User) []uint {
+		ids := make([]uint, len(demoted))
+		for i, user := range demoted {
+			ids[i] = user.ID
+		}
+		return ids
+	}
+
+	t.Run("dormant admin is demoted and active one spared", func(t *testing.T) {
+		s, users, audit := newService(&config.Config{})
+
+		demoted, err := s.DemoteDormantAdmins(context.Background(), now)
+
+		assert.NoError(t, err)
+		// The suspended admin has never used admin functions at all
+		assert.Equal(t, []uint{suspendedAdminID, dormantAdminID}, demotedIDs(demoted))
+		assert.Equal(t, authdomain.RoleUser, users.users[dormantAdminID].Role)
+		assert.Equal(t, authdomain.RoleAdmin, users.users[adminID].Role)
+		assert.Equal(t, authdomain.RoleSuperAdmin, users.users[superAdminID].Role, "super admins are never demoted")
+		assert.Equal(t, []authdomain.AuditAction{authdomain.AuditActionUserRoleChanged, authdomain.AuditActionUserRoleChanged},
+			audit.actions)
+	})
+
+	t.Run("recent admin grant counts as activity", func(t *testing.T) {
+		s, users, audit := newService(&config.Config{})
+		grant := auditLog(superAdminID, dormantAdminID, authdomain.AuditActionUserRoleChanged)
+		grant.Metadata = map[string]interface{}{"new_role": "admin"}
+		grant.CreatedAt = daysAgo(2)
+		audit.logs = append(audit.logs, grant)
+
+		demoted, err := s.DemoteDormantAdmins(context.Background(), now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{suspendedAdminID}, demotedIDs(demoted))
+		assert.Equal(t, authdomain.RoleAdmin, users.users[dormantAdminID].Role)
+	})
+
+	t.Run("allowlisted admins are spared", func(t *testing.T) {
+		s, users, _ := newService(&config.Config{AdminDormantAllowlist: "dormant@example.com, former@example.com"})
+
+		demoted, err := s.DemoteDormantAdmins(context.Background(), now)
+
+		assert.NoError(t, err)
+		assert.Empty(t, demoted)
+		assert.Equal(t, authdomain.RoleAdmin, users.users[dormantAdminID].Role)
+	})
+
+	t.Run("the most recently active admin is kept when none would remain", func(t *testing.T) {
+		s, users, audit := newService(&config.Config{})
+		users.users[superAdminID].Status = authdomain.StatusSuspended
+		audit.logs = []*authdomain.AuditLog{
+			adminAccess(adminID, daysAgo(50)),
+			adminAccess(dormantAdminID, daysAgo(40)),
+		}
+
+		demoted, err := s.DemoteDormantAdmins(context.Background(), now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{adminID, suspendedAdminID}, demotedIDs(demoted))
+		assert.Equal(t, authdomain.RoleAdmin, users.users[dormantAdminID].Role)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s, users, _ := newService(&config.Config{})
+		s.config.AdminDormantDemotion = false
+
+		demoted, err := s.DemoteDormantAdmins(context.Background(), now)
+
+		assert.NoError(t, err)
+		assert.Empty(t, demoted)
+		assert.Equal(t, authdomain.RoleAdmin, users.users[dormantAdminID].Role)
+	})
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/buildinfo"
+)
+
+// evidencePageSize is how many audit entries an evidence export reads at a time
+const evidencePageSize = 500
+
+// evidenceFile is one record file in an evidence bundle and how to fill it
+type evidenceFile struct {
+	name        string
+	description string
+	write       func(enc *json.Encoder) (int, error)
+}
+
+// PrepareUserEvidence authorizes a super admin to export the evidence bundle for a user. It runs
+// before anything is streamed, so refusals can still be answered with an error status.
+func (s *AdminService) PrepareUserEvidence(adminID, targetUserID uint) (*domain.UserEvidence, error) {
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !admin.IsSuperAdmin() {
+		return nil, domain.ErrSuperAdminRequired
+	}
+
+	user, err := s.userRepo.GetByID(targetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.UserEvidence{
+		User:        user,
+		AdminID:     admin.ID,
+		AdminEmail:  admin.Email,
+		GeneratedAt: time.Now().UTC(),
+	}, nil
+}
+
+// WriteUserEvidence streams the evidence bundle to w as a zip: the user's record, audit trail, role
+// changes, login history and security alerts, followed by a manifest with a checksum of each. Records
+// are read a page at a time and compressed as they go, so a long history is never held in memory.
+// The export is audited whether or not it completed, since part of it may already have been sent.
+func (s *AdminService) WriteUserEvidence(evidence *domain.UserEvidence, w io.Writer, ipAddress, userAgent string) error {
+	manifest := &domain.EvidenceManifest{
+		FormatVersion:    domain.EvidenceFormatVersion,
+		UserID:           evidence.User.ID,
+		UserEmail:        evidence.User.Email,
+		GeneratedAt:      evidence.GeneratedAt,
+		GeneratedBy:      evidence.AdminID,
+		GeneratedByEmail: evidence.AdminEmail,
+		AppVersion:       buildinfo.Version,
+		Files:            []domain.EvidenceFile{},
+	}
+
+	bundle := zip.NewWriter(w)
+	err := s.writeEvidenceFiles(bundle, evidence, manifest)
+	if err == nil {
+		err = writeEvidenceManifest(bundle, evidence, manifest)
+	}
+	if err == nil {
+		err = bundle.Close()
+	}
+
+	if err != nil {
+		s.logger.Error("failed to write user evidence bundle",
+			"admin_id", evidence.AdminID,
+			"user_id", evidence.User.ID,
+			"error", err)
+	}
+	s.auditEvidenceExport(evidence, manifest, err, ipAddress, userAgent)
+	return err
+}
+
+// writeEvidenceFiles writes each record file, adding it to the manifest once complete
+func (s *AdminService) writeEvidenceFiles(bundle *zip.Writer, evidence *domain.UserEvidence, manifest *domain.EvidenceManifest) error {
+	userID := evidence.User.ID
+	files := []evidenceFile{
+		{
+			name:        domain.EvidenceFileUser,
+			description: "The user's account record at export time",
+			write: func(enc *json.Encoder) (int, error) {
+				return 1, enc.Encode(evidence.User)
+			},
+		},
+		{
+			name:        domain.EvidenceFileAuditLog,
+			description: "Every audit entry the user performed or was the target of",
+			write: func(enc *json.Encoder) (int, error) {
+				return s.writeAuditHistory(enc, userID, nil, nil)
+			},
+		},
+		{
+			name:        domain.EvidenceFileRoleChanges,
+			description: "Changes made to the user's role",
+			write: func(enc *json.Encoder) (int, error) {
+				return s.writeAuditHistory(enc, userID,
+					[]authdomain.AuditAction{authdomain.AuditActionUserRoleChanged},
+					func(log *authdomain.AuditLog) bool { return log.TargetID != nil && *log.TargetID == userID })
+			},
+		},
+		{
+			name:        domain.EvidenceFileLoginHistory,
+			description: "The user's successful and failed logins and logouts",
+			write: func(enc *json.Encoder) (int, error) {
+				return s.writeAuditHistory(enc, userID, []authdomain.AuditAction{
+					authdomain.AuditActionLoginSuccess,
+					authdomain.AuditActionLoginFailed,
+					authdomain.AuditActionLogout,
+				}, nil)
+			},
+		},
+		{
+			name:        domain.EvidenceFileSecurityAlerts,
+			description: "Security alerts raised over the user's admin activity",
+			write: func(enc *json.Encoder) (int, error) {
+				return s.writeSecurityAlerts(enc, userID)
+			},
+		},
+	}
+
+	for _, file := range files {
+		entry, err := createEvidenceEntry(bundle, file.name, evidence.GeneratedAt)
+		if err != nil {
+			return err
+		}
+
+		hash := sha256.New()
+		records, err := file.write(json.NewEncoder(io.MultiWriter(entry, hash)))
+		if err != nil {
+			return fmt.Errorf("write %s: %w", file.name, err)
+		}
+
+		manifest.Files = append(manifest.Files, domain.EvidenceFile{
+			Name:        file.name,
+			Description: file.description,
+			Records:     records,
+			SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		})
+	}
+	return nil
+}
+
+// writeAuditHistory writes the user's audit entries, one per line, limited to the given actions when
+// there are any and to the entries keep accepts when set. It returns how many were written.
+func (s *AdminService) writeAuditHistory(
+	enc *json.Encoder,
+	userID uint,
+	actions []authdomain.AuditAction,
+	keep func(*authdomain.AuditLog) bool,
+) (int, error) {
+	if len(actions) == 0 {
+		actions = []authdomain.AuditAction{""}
+	}
+
+	written := 0
+	for _, action := range actions {
+		req := &domain.UserAuditLogRequest{Page: 1, PageSize: evidencePageSize, Action: action}
+		for {
+			logs, _, err := s.auditRepo.ListUserHistory(userID, req)
+			if err != nil {
+				return written, err
+			}
+
+			for _, log := range logs {
+				if keep != nil && !keep(log) {
+					continue
+				}
+				if err := enc.Encode(log); err != nil {
+					return written, err
+				}
+				written++
+			}
+
+			if len(logs) < evidencePageSize {
+				break
+			}
+			req.Page++
+		}
+	}
+	return written, nil
+}
+
+// writeSecurityAlerts writes the alerts raised over the user's admin activity, one per line
+func (s *AdminService) writeSecurityAlerts(enc *json.Encoder, userID uint) (int, error) {
+	if s.alertRepo == nil {
+		return 0, nil
+	}
+
+	alerts, err := s.alertRepo.ListByAdmin(userID)
+	if err != nil {
+		return 0, err
+	}
+	for i, alert := range alerts {
+		if err := enc.Encode(alert); err != nil {
+			return i, err
+		}
+	}
+	return len(alerts), nil
+}
+
+// writeEvidenceManifest writes the manifest last, once every file's checksum is known
+func writeEvidenceManifest(bundle *zip.Writer, evidence *domain.UserEvidence, manifest *domain.EvidenceManifest) error {
+	entry, err := createEvidenceEntry(bundle, domain.EvidenceFileManifest, evidence.GeneratedAt)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(entry)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return fmt.Errorf("write %s: %w", domain.EvidenceFileManifest, err)
+	}
+	return nil
+}
+
+// createEvidenceEntry starts a compressed file in the bundle, dated when the bundle was generated
+func createEvidenceEntry(bundle *zip.Writer, name string, generatedAt time.Time) (io.Writer, error) {
+	entry, err := bundle.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: generatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %w", name, err)
+	}
+	return entry, nil
+}
+
+// auditEvidenceExport records that an admin exported a user's evidence and what the bundle held
+func (s *AdminService) auditEvidenceExport(
+	evidence *domain.UserEvidence,
+	manifest *domain.EvidenceManifest,
+	exportErr error,
+	ipAddress, userAgent string,
+) {
+	records := make(map[string]interface{}, len(manifest.Files))
+	for _, file := range manifest.Files {
+		records[file.Name] = file.Records
+	}
+
+	description := fmt.Sprintf("Evidence bundle for %s exported by admin", evidence.User.Email)
+	metadata := map[string]interface{}{
+		"generated_at": evidence.GeneratedAt,
+		"records":      records,
+		"complete":     exportErr == nil,
+	}
+	if exportErr != nil {
+		description = fmt.Sprintf("Evidence bundle export for %s failed part way", evidence.User.Email)
+		metadata["error"] = exportErr.Error()
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&evidence.AdminID,
+		&evidence.User.ID,
+		authdomain.AuditActionEvidenceExported,
+		authdomain.AuditLevelWarning,
+		"admin",
+		description,
+		ipAddress,
+		userAgent,
+		metadata,
+	); err != nil {
+		s.logger.Error("failed to create audit log for evidence export",
+			"admin_id", evidence.AdminID,
+			"user_id", evidence.User.ID,
+			"error", err)
+	}
+}
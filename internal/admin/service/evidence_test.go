@@ -0,0 +1,136 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func TestPrepareUserEvidence(t *testing.T) {
+	s, _, _ := newTestAdminService(&config.Config{})
+
+	_, err := s.PrepareUserEvidence(adminID, userID)
+	assert.ErrorIs(t, err, domain.ErrSuperAdminRequired)
+
+	_, err = s.PrepareUserEvidence(superAdminID, 99)
+	assert.ErrorIs(t, err, authdomain.ErrUserNotFound)
+
+	evidence, err := s.PrepareUserEvidence(superAdminID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, evidence.User.ID)
+	assert.Equal(t, "root@example.com", evidence.AdminEmail)
+}
+
+func TestWriteUserEvidence(t *testing.T) {
+	s, _, audit := newTestAdminService(&config.Config{})
+	alerts := &fakeAlertRepo{}
+	s.alertRepo = alerts
+
+	// More entries than fit in one page, so the audit trail is read across pages
+	for i := 0; i < evidencePageSize+10; i++ {
+		audit.logs = append(audit.logs, auditLog(userID, 0, authdomain.AuditActionPreferencesUpdated))
+	}
+	audit.logs = append(audit.logs,
+		auditLog(userID, 0, authdomain.AuditActionLoginSuccess),
+		auditLog(0, userID, authdomain.AuditActionLoginFailed),
+		auditLog(adminID, userID, authdomain.AuditActionUserRoleChanged),
+		auditLog(userID, pendingUserID, authdomain.AuditActionUserRoleChanged), // made by the user, not to them
+		auditLog(adminID, pendingUserID, authdomain.AuditActionLoginSuccess),   // someone else's
+	)
+	_ = alerts.Create(&authdomain.SecurityAlert{ID: "mine", AdminID: userID})
+	_ = alerts.Create(&authdomain.SecurityAlert{ID: "theirs", AdminID: adminID})
+
+	evidence, err := s.PrepareUserEvidence(superAdminID, userID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, s.WriteUserEvidence(evidence, &buf, "127.0.0.1", "test")) {
+		return
+	}
+
+	bundle, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	files := map[string][]byte{}
+	var names []string
+	for _, file := range bundle.File {
+		names = append(names, file.Name)
+		reader, err := file.Open()
+		if !assert.NoError(t, err, file.Name) {
+			return
+		}
+		files[file.Name], _ = io.ReadAll(reader)
+		reader.Close()
+	}
+	assert.Equal(t, []string{
+		domain.EvidenceFileUser,
+		domain.EvidenceFileAuditLog,
+		domain.EvidenceFileRoleChanges,
+		domain.EvidenceFileLoginHistory,
+		domain.EvidenceFileSecurityAlerts,
+		domain.EvidenceFileManifest,
+	}, names)
+
+	var user authdomain.User
+	assert.NoError(t, json.Unmarshal(files[domain.EvidenceFileUser], &user))
+	assert.Equal(t, "user@example.com", user.Email)
+
+	assert.Equal(t, evidencePageSize+14, countLines(files[domain.EvidenceFileAuditLog]))
+	assert.Equal(t, 1, countLines(files[domain.EvidenceFileRoleChanges]))
+	assert.Equal(t, 2, countLines(files[domain.EvidenceFileLoginHistory]))
+	assert.Equal(t, 1, countLines(files[domain.EvidenceFileSecurityAlerts]))
+
+	var manifest domain.EvidenceManifest
+	if !assert.NoError(t, json.Unmarshal(files[domain.EvidenceFileManifest], &manifest)) {
+		return
+	}
+	assert.Equal(t, domain.EvidenceFormatVersion, manifest.FormatVersion)
+	assert.Equal(t, userID, manifest.UserID)
+	assert.Equal(t, superAdminID, manifest.GeneratedBy)
+	if !assert.Len(t, manifest.Files, 5) {
+		return
+	}
+	for _, file := range manifest.Files {
+		sum := sha256.Sum256(files[file.Name])
+		assert.Equal(t, hex.EncodeToString(sum[:]), file.SHA256, file.Name)
+	}
+	assert.Equal(t, 1, manifest.Files[2].Records)
+
+	// The export is audited
+	assert.Contains(t, audit.actions, authdomain.AuditActionEvidenceExported)
+}
+
+func auditLog(actorID, targetID uint, action authdomain.AuditAction) *authdomain.AuditLog {
+	log := &authdomain.AuditLog{Action: action}
+	if actorID != 0 {
+		log.UserID = &actorID
+	}
+	if targetID != 0 {
+		log.TargetID = &targetID
+	}
+	return log
+}
+
+func countLines(data []byte) int {
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lines++
+	}
+	return lines
+}
@@ -0,0 +1,512 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	authservice "github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/shared/storage"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+var (
+	_ domain.ExportStorage = (*storage.Local)(nil)
+	_ domain.ExportMailer  = (*authservice.EmailService)(nil)
+)
+
+const (
+	// exportPageSize is how many records a users or audit_logs export reads at a time
+	exportPageSize = 500
+	// exportExpiryBatch is how many expired export files are deleted per run
+	exportExpiryBatch = 100
+)
+
+// CreateExport queues an export for the background runner and returns it as pending. An export
+// discloses as much as an evidence bundle, so only super admins may request one.
+func (s *AdminService) CreateExport(
+	adminID uint,
+	req *domain.CreateExportRequest,
+	ipAddress, userAgent string,
+) (*domain.ExportJob, error) {
+	if err := s.authorizeExports(adminID); err != nil {
+		return nil, err
+	}
+
+	if req.Type == domain.ExportTypeEvidence {
+		if req.UserID == nil {
+			return nil, domain.ErrExportUserRequired
+		}
+		if _, err := s.userRepo.GetByID(*req.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	job := &domain.ExportJob{
+		ID:           uuid.New().String(),
+		AdminID:      adminID,
+		Type:         req.Type,
+		UserID:       req.UserID,
+		FilterSearch: strings.TrimSpace(req.Search),
+		FilterRole:   req.Role,
+		FilterStatus: req.Status,
+		FilterAction: req.Action,
+		FilterLevel:  req.Level,
+		Reason:       req.Reason,
+		Status:       domain.ExportStatusPending,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	}
+	if err := s.exportRepo.Create(job); err != nil {
+		s.logger.Error("failed to create export job", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		req.UserID,
+		authdomain.AuditActionExportRequested,
+		authdomain.AuditLevelWarning,
+		"admin",
+		fmt.Sprintf("Export of %s requested", req.Type),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"export_id":     job.ID,
+			"type":          req.Type,
+			"filter_search": job.FilterSearch,
+			"filter_role":   job.FilterRole,
+			"filter_status": job.FilterStatus,
+			"filter_action": job.FilterAction,
+			"filter_level":  job.FilterLevel,
+			"reason":        req.Reason,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for export request", "error", err)
+	}
+
+	s.logger.Info("export requested", "export_id", job.ID, "admin_id", adminID, "type", req.Type)
+	return job, nil
+}
+
+// GetExport returns the progress of one of the admin's own exports. A completed export comes with a
+// freshly signed download link, so polling again renews a link that has run out.
+func (s *AdminService) GetExport(adminID uint, exportID string) (*domain.ExportJob, error) {
+	if err := s.authorizeExports(adminID); err != nil {
+		return nil, err
+	}
+
+	job, err := s.exportRepo.GetByID(exportID)
+	if err != nil {
+		return nil, err
+	}
+	// Another admin's export is reported as missing rather than forbidden
+	if job.AdminID != adminID {
+		return nil, domain.ErrExportNotFound
+	}
+
+	if job.Status == domain.ExportStatusCompleted {
+		s.signExportDownload(job, s.clock.Now())
+	}
+	return job, nil
+}
+
+// OpenExportDownload checks a signed download link and opens the export's file. The signature stands
+// in for the admin's session, so the link works straight from the notification email, but only while
+// that admin is still an active super admin. Every download is audited against the admin who requested
+// the export.
+func (s *AdminService) OpenExportDownload(
+	exportID string,
+	expires int64,
+	signature string,
+	ipAddress, userAgent string,
+) (*domain.ExportDownload, error) {
+	if s.exportRepo == nil || s.exportStorage == nil {
+		return nil, domain.ErrExportsOffline
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decoded, s.exportSignature(exportID, expires)) {
+		return nil, domain.ErrExportLinkInvalid
+	}
+
+	now := s.clock.Now()
+	if now.Unix() >= expires {
+		return nil, domain.ErrExportLinkExpired
+	}
+
+	job, err := s.exportRepo.GetByID(exportID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == domain.ExportStatusExpired || (job.ExpiresAt != nil && !now.Before(*job.ExpiresAt)) {
+		return nil, domain.ErrExportLinkExpired
+	}
+	if job.Status != domain.ExportStatusCompleted {
+		return nil, domain.ErrExportNotFound
+	}
+
+	// The link outlives the session it stands in for, so it stops working once its requester could no
+	// longer request the export
+	requester, err := s.userRepo.GetByID(job.AdminID)
+	if errors.Is(err, authdomain.ErrUserNotFound) {
+		return nil, domain.ErrExportAccessRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !requester.IsSuperAdmin() || !requester.IsActive() {
+		return nil, domain.ErrExportAccessRevoked
+	}
+
+	content, err := s.exportStorage.Open(job.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, domain.ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&job.AdminID,
+		job.UserID,
+		authdomain.AuditActionExportDownloaded,
+		authdomain.AuditLevelWarning,
+		"admin",
+		fmt.Sprintf("Export of %s downloaded", job.Type),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"export_id": job.ID,
+			"type":      job.Type,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for export download", "error", err)
+	}
+
+	s.logger.Info("export downloaded", "export_id", job.ID, "admin_id", job.AdminID, "type", job.Type)
+	return &domain.ExportDownload{Export: job, Content: content}, nil
+}
+
+// RunExports is run by the background scheduler. It deletes the files of expired exports, fails
+// exports left running by an instance that went away, then runs pending exports one at a time until
+// none are left. It returns how many it ran.
+func (s *AdminService) RunExports(ctx context.Context) (int, error) {
+	if s.exportRepo == nil || s.exportStorage == nil {
+		return 0, nil
+	}
+
+	now := s.clock.Now()
+	if err := s.expireExports(now); err != nil {
+		return 0, err
+	}
+
+	// A live runner fails its own export at the timeout, so allow it as long again before taking over
+	timeout := s.config.AdminExportTimeoutDuration()
+	stale, err := s.exportRepo.FailStale(now.Add(-2*timeout), now)
+	if err != nil {
+		return 0, err
+	}
+	if stale > 0 {
+		s.logger.Warn("failed exports left running", "count", stale)
+	}
+
+	ran := 0
+	for ctx.Err() == nil {
+		job, err := s.exportRepo.ClaimNext(s.clock.Now())
+		if err != nil {
+			return ran, err
+		}
+		if job == nil {
+			break
+		}
+
+		s.runExport(ctx, job, timeout)
+		ran++
+	}
+	return ran, nil
+}
+
+// authorizeExports checks the admin may use exports and that exports are available
+func (s *AdminService) authorizeExports(adminID uint) error {
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return err
+	}
+
+	if !admin.IsSuperAdmin() {
+		return domain.ErrSuperAdminRequired
+	}
+
+	if s.exportRepo == nil || s.exportStorage == nil {
+		return domain.ErrExportsOffline
+	}
+	return nil
+}
+
+// runExport writes a claimed export to storage and records how it ended
+func (s *AdminService) runExport(ctx context.Context, job *domain.ExportJob, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	startedAt := s.clock.Now()
+	if job.StartedAt != nil {
+		startedAt = *job.StartedAt
+	}
+	job.Filename = domain.ExportFilename(job.Type, startedAt)
+
+	key, err := s.exportStorage.GenerateKey(fmt.Sprintf("exports/%d", job.AdminID), job.Filename)
+	if err == nil {
+		err = s.storeExport(ctx, job, key)
+	}
+	s.finishExport(job, key, err)
+}
+
+// storeExport streams the export into storage through a pipe, so a large export is never held in memory
+func (s *AdminService) storeExport(ctx context.Context, job *domain.ExportJob, key string) error {
+	reader, writer := io.Pipe()
+	counter := &countingWriter{w: writer}
+
+	written := make(chan error, 1)
+	go func() {
+		records, err := s.writeExport(ctx, job, counter)
+		job.Records = records
+		// A failed write reaches storage through the pipe, so the partial file is discarded
+		writer.CloseWithError(err)
+		written <- err
+	}()
+
+	putErr := s.exportStorage.Put(key, reader)
+	// Unblock the writer if storage stopped reading early
+	reader.Close()
+	if err := <-written; err != nil {
+		return err
+	}
+	if putErr != nil {
+		return fmt.Errorf("store export: %w", putErr)
+	}
+
+	job.Size = counter.n
+	return nil
+}
+
+// writeExport writes the export's records to w and returns how many lines were written
+func (s *AdminService) writeExport(ctx context.Context, job *domain.ExportJob, w io.Writer) (int, error) {
+	switch job.Type {
+	case domain.ExportTypeUsers:
+		return s.writeUserExport(ctx, job, json.NewEncoder(w))
+	case domain.ExportTypeAuditLogs:
+		return s.writeAuditLogExport(ctx, job, json.NewEncoder(w))
+	case domain.ExportTypeEvidence:
+		// The requester's access is checked again, as it may have changed while the export waited
+		evidence, err := s.PrepareUserEvidence(job.AdminID, *job.UserID)
+		if err != nil {
+			return 0, err
+		}
+		return 0, s.WriteUserEvidence(evidence, w, job.IPAddress, job.UserAgent)
+	}
+	return 0, fmt.Errorf("unknown export type %q", job.Type)
+}
+
+// writeUserExport writes the users matching the export's filter, one per line, oldest first
+func (s *AdminService) writeUserExport(ctx context.Context, job *domain.ExportJob, enc *json.Encoder) (int, error) {
+	// Page in a stable order so users created mid-export do not shift later pages
+	filter := userdomain.UserListRequest{
+		Page:     1,
+		PageSize: exportPageSize,
+		Search:   job.FilterSearch,
+		Role:     authdomain.UserRole(job.FilterRole),
+		Status:   authdomain.UserStatus(job.FilterStatus),
+		Sort:     "created_at:asc",
+		Count:    userdomain.CountNone,
+	}
+
+	written := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		users, count, err := s.userRepo.List(&filter)
+		if err != nil {
+			return written, err
+		}
+		for _, user := range users {
+			if err := enc.Encode(user); err != nil {
+				return written, err
+			}
+			written++
+		}
+
+		if !count.HasNext {
+			return written, nil
+		}
+		filter.Page++
+	}
+}
+
+// writeAuditLogExport writes the audit entries matching the export's filter, one per line, oldest first
+func (s *AdminService) writeAuditLogExport(ctx context.Context, job *domain.ExportJob, enc *json.Encoder) (int, error) {
+	req := domain.AdminAuditLogRequest{
+		Page:     1,
+		PageSize: exportPageSize,
+		UserID:   job.UserID,
+		Action:   authdomain.AuditAction(job.FilterAction),
+		Level:    authdomain.AuditLevel(job.FilterLevel),
+		Sort:     "created_at:asc",
+		Count:    userdomain.CountNone,
+	}
+
+	written := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		logs, count, err := s.auditRepo.List(&req)
+		if err != nil {
+			return written, err
+		}
+		for _, log := range logs {
+			if err := enc.Encode(log); err != nil {
+				return written, err
+			}
+			written++
+		}
+
+		if !count.HasNext {
+			return written, nil
+		}
+		req.Page++
+	}
+}
+
+// finishExport records the final state of an export and tells the requester
+func (s *AdminService) finishExport(job *domain.ExportJob, key string, err error) {
+	now := s.clock.Now()
+	job.CompletedAt = &now
+	if err != nil {
+		job.Status = domain.ExportStatusFailed
+		job.Error = err.Error()
+		s.logger.Error("export failed", "export_id", job.ID, "type", job.Type, "error", err)
+	} else {
+		expiresAt := now.Add(s.config.AdminExportRetentionDuration())
+		job.Status = domain.ExportStatusCompleted
+		job.StorageKey = key
+		job.ExpiresAt = &expiresAt
+	}
+
+	if err := s.exportRepo.Update(job); err != nil {
+		s.logger.Error("failed to update export job", "export_id", job.ID, "error", err)
+		return
+	}
+
+	s.logger.Info("export finished",
+		"export_id", job.ID,
+		"status", job.Status,
+		"records", job.Records,
+		"size", job.Size,
+	)
+	s.notifyExportFinished(job, now)
+}
+
+// notifyExportFinished emails the requester a download link, or that their export failed
+func (s *AdminService) notifyExportFinished(job *domain.ExportJob, now time.Time) {
+	if s.exportMailer == nil {
+		return
+	}
+
+	admin, err := s.userRepo.GetByID(job.AdminID)
+	if err != nil {
+		s.logger.Error("failed to load export requester", "export_id", job.ID, "admin_id", job.AdminID, "error", err)
+		return
+	}
+
+	if job.Status == domain.ExportStatusCompleted {
+		s.signExportDownload(job, now)
+		err = s.exportMailer.SendExportReady(admin.Email, admin.FirstName, job.Filename, job.DownloadURL, *job.DownloadURLExpiresAt)
+	} else {
+		err = s.exportMailer.SendExportFailed(admin.Email, admin.FirstName, job.ID)
+	}
+	if err != nil {
+		s.logger.Error("failed to notify export requester", "export_id", job.ID, "admin_id", job.AdminID, "error", err)
+	}
+}
+
+// expireExports deletes the files of exports past their retention and marks them expired
+func (s *AdminService) expireExports(now time.Time) error {
+	jobs, err := s.exportRepo.ListExpired(now, exportExpiryBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := s.exportStorage.Delete(job.StorageKey); err != nil {
+			s.logger.Error("failed to delete expired export", "export_id", job.ID, "error", err)
+			continue
+		}
+
+		job.Status = domain.ExportStatusExpired
+		job.StorageKey = ""
+		if err := s.exportRepo.Update(job); err != nil {
+			s.logger.Error("failed to update export job", "export_id", job.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// signExportDownload sets a download link on a completed export, valid for ADMIN_EXPORT_LINK_TTL
+// and never past the export's own expiry
+func (s *AdminService) signExportDownload(job *domain.ExportJob, now time.Time) {
+	linkExpiresAt := now.Add(s.config.AdminExportLinkTTLDuration())
+	if job.ExpiresAt != nil && job.ExpiresAt.Before(linkExpiresAt) {
+		linkExpiresAt = *job.ExpiresAt
+	}
+	expires := linkExpiresAt.Unix()
+
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expires, 10)},
+		"signature": {base64.RawURLEncoding.EncodeToString(s.exportSignature(job.ID, expires))},
+	}
+	job.DownloadURL = fmt.Sprintf("%s/api/exports/%s/download?%s",
+		strings.TrimRight(s.config.BackendURL, "/"), url.PathEscape(job.ID), query.Encode())
+
+	linkExpiresAt = time.Unix(expires, 0).UTC()
+	job.DownloadURLExpiresAt = &linkExpiresAt
+}
+
+// exportSignature signs an export ID and link expiry
+func (s *AdminService) exportSignature(exportID string, expires int64) []byte {
+	// Derive a key of its own so a download signature can never stand in for another use of the secret
+	key := hmac.New(sha256.New, []byte(s.config.JWTSecret))
+	key.Write([]byte("export-download"))
+
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	fmt.Fprintf(mac, "%s:%d", exportID, expires)
+	return mac.Sum(nil)
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/storage"
+)
+
+// newTestExportService returns an admin service with exports stored under a temporary directory
+func newTestExportService(t *testing.T) (*AdminService, *fakeUserRepo, *fakeAuditRepo, *fakeExportRepo, *fakeExportMailer) {
+	cfg := &config.Config{
+		JWTSecret:        "test-secret-that-is-long-enough-for-signing",
+		BackendURL:       "https://api.example.com",
+		LocalStoragePath: t.TempDir(),
+	}
+	files, err := storage.NewLocal(cfg)
+	if err != nil {
+		t.Fatalf("create storage: %v", err)
+	}
+
+	s, users, audit := newTestAdminService(cfg)
+	exports := newFakeExportRepo()
+	mailer := &fakeExportMailer{}
+	s.exportRepo = exports
+	s.exportStorage = files
+	s.exportMailer = mailer
+	s.clock = clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	return s, users, audit, exports, mailer
+}
+
+// downloadExport opens a signed download URL as the download endpoint would
+func downloadExport(s *AdminService, downloadURL string) ([]byte, error) {
+	parsed, err := url.Parse(downloadURL)
+	if err != nil {
+		return nil, err
+	}
+	expires, _ := strconv.ParseInt(parsed.Query().Get("expires"), 10, 64)
+	exportID := path.Base(path.Dir(parsed.Path))
+
+	download, err := s.OpenExportDownload(exportID, expires, parsed.Query().Get("signature"), "127.0.0.1", "test")
+	if err != nil {
+		return nil, err
+	}
+	defer download.Content.Close()
+	return io.ReadAll(download.Content)
+}
+
+func TestCreateExport(t *testing.T) {
+	usersExport := &domain.CreateExportRequest{Type: domain.ExportTypeUsers, Reason: "compliance request"}
+
+	t.Run("requires a super admin", func(t *testing.T) {
+		s, _, audit, _, _ := newTestExportService(t)
+
+		_, err := s.CreateExport(adminID, usersExport, "127.0.0.1", "test")
+		assert.ErrorIs(t, err, domain.ErrSuperAdminRequired)
+		assert.Empty(t, audit.actions)
+	})
+
+	t.Run("offline without storage", func(t *testing.T) {
+		s, _, _ := newTestAdminService(&config.Config{})
+
+		_, err := s.CreateExport(superAdminID, usersExport, "127.0.0.1", "test")
+		assert.ErrorIs(t, err, domain.ErrExportsOffline)
+	})
+
+	t.Run("evidence needs a user", func(t *testing.T) {
+		s, _, _, _, _ := newTestExportService(t)
+
+		_, err := s.CreateExport(superAdminID, &domain.CreateExportRequest{
+			Type: domain.ExportTypeEvidence, Reason: "legal hold",
+		}, "127.0.0.1", "test")
+		assert.ErrorIs(t, err, domain.ErrExportUserRequired)
+
+		missing := uint(99)
+		_, err = s.CreateExport(superAdminID, &domain.CreateExportRequest{
+			Type: domain.ExportTypeEvidence, UserID: &missing, Reason: "legal hold",
+		}, "127.0.0.1", "test")
+		assert.ErrorIs(t, err, authdomain.ErrUserNotFound)
+	})
+
+	t.Run("queues the export", func(t *testing.T) {
+		s, _, audit, exports, _ := newTestExportService(t)
+
+		job, err := s.CreateExport(superAdminID, usersExport, "127.0.0.1", "test")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, domain.ExportStatusPending, job.Status)
+		assert.Equal(t, superAdminID, job.AdminID)
+		assert.Contains(t, exports.jobs, job.ID)
+		assert.Equal(t, []authdomain.AuditAction{authdomain.AuditActionExportRequested}, audit.actions)
+	})
+}
+
+func TestExportCompletes(t *testing.T) {
+	s, _, audit, _, mailer := newTestExportService(t)
+
+	job, err := s.CreateExport(superAdminID, &domain.CreateExportRequest{
+		Type: domain.ExportTypeUsers, Reason: "compliance request",
+	}, "127.0.0.1", "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Polling before the runner picks it up shows it pending, without a link
+	polled, err := s.GetExport(superAdminID, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ExportStatusPending, polled.Status)
+	assert.Empty(t, polled.DownloadURL)
+
+	ran, err := s.RunExports(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ran)
+
+	polled, err = s.GetExport(superAdminID, job.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, domain.ExportStatusCompleted, polled.Status)
+	assert.Equal(t, 5, polled.Records)
+	assert.NotNil(t, polled.ExpiresAt)
+	assert.Contains(t, polled.DownloadURL, "https://api.example.com/api/exports/"+job.ID+"/download?")
+
+	// The requester is emailed a working link
+	assert.Equal(t, []string{"root@example.com " + polled.Filename}, mailer.ready)
+	content, err := downloadExport(s, mailer.downloadURL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(len(content)), polled.Size)
+	assert.Equal(t, 5, bytes.Count(content, []byte("\n")))
+	assert.Contains(t, audit.actions, authdomain.AuditActionExportDownloaded)
+
+	// Nothing is left to run
+	ran, err = s.RunExports(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, ran)
+}
+
+func TestExportDownloadLinks(t *testing.T) {
+	s, _, _, exports, _ := newTestExportService(t)
+
+	job, err := s.CreateExport(superAdminID, &domain.CreateExportRequest{
+		Type: domain.ExportTypeUsers, Reason: "compliance request",
+	}, "127.0.0.1", "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = s.RunExports(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	polled, err := s.GetExport(superAdminID, job.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// A link can't be altered or reused past its expiry
+	expires := polled.DownloadURLExpiresAt.Unix()
+	_, err = s.OpenExportDownload(job.ID, expires, "bm90LWEtc2lnbmF0dXJl", "127.0.0.1", "test")
+	assert.ErrorIs(t, err, domain.ErrExportLinkInvalid)
+	_, err = s.OpenExportDownload(job.ID, expires+60, parsedSignature(polled.DownloadURL), "127.0.0.1", "test")
+	assert.ErrorIs(t, err, domain.ErrExportLinkInvalid)
+
+	s.clock.(*clock.Mock).Advance(s.config.AdminExportLinkTTLDuration())
+	_, err = downloadExport(s, polled.DownloadURL)
+	assert.ErrorIs(t, err, domain.ErrExportLinkExpired)
+
+	past := s.clock.Now().Add(-time.Minute)
+
+	// Once the export expires its file is deleted and fresh links stop working
+	stored := exports.jobs[job.ID]
+	stored.ExpiresAt = &past
+	_, err = s.RunExports(context.Background())
+	assert.NoError(t, err)
+
+	expired, err := s.GetExport(superAdminID, job.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, domain.ExportStatusExpired, expired.Status)
+	assert.Empty(t, expired.DownloadURL)
+	_, err = s.exportStorage.Open(stored.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestExportDownloadNeedsActiveSuperAdmin(t *testing.T) {
+	s, users, _, _, _ := newTestExportService(t)
+
+	job, err := s.CreateExport(superAdminID, &domain.CreateExportRequest{
+		Type: domain.ExportTypeUsers, Reason: "compliance request",
+	}, "127.0.0.1", "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = s.RunExports(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	polled, err := s.GetExport(superAdminID, job.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	requester := users.users[superAdminID]
+	_, err = downloadExport(s, polled.DownloadURL)
+	assert.NoError(t, err)
+
+	// A link already handed out stops working once the requester loses the access it stands in for
+	requester.Role = authdomain.RoleAdmin
+	_, err = downloadExport(s, polled.DownloadURL)
+	assert.ErrorIs(t, err, domain.ErrExportAccessRevoked)
+
+	requester.Role = authdomain.RoleSuperAdmin
+	requester.Status = authdomain.StatusSuspended
+	_, err = downloadExport(s, polled.DownloadURL)
+	assert.ErrorIs(t, err, domain.ErrExportAccessRevoked)
+
+	delete(users.users, superAdminID)
+	_, err = downloadExport(s, polled.DownloadURL)
+	assert.ErrorIs(t, err, domain.ErrExportAccessRevoked)
+}
+
+func TestExportFails(t *testing.T) {
+	s, users, _, _, mailer := newTestExportService(t)
+
+	subject := userID
+	job, err := s.CreateExport(superAdminID, &domain.CreateExportRequest{
+		Type: domain.ExportTypeEvidence, UserID: &subject, Reason: "legal hold",
+	}, "127.0.0.1", "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The subject is purged while the export waits
+	delete(users.users, userID)
+
+	_, err = s.RunExports(context.Background())
+	assert.NoError(t, err)
+
+	polled, err := s.GetExport(superAdminID, job.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, domain.ExportStatusFailed, polled.Status)
+	assert.NotEmpty(t, polled.Error)
+	assert.Empty(t, polled.DownloadURL)
+	assert.Equal(t, []string{"root@example.com " + job.ID}, mailer.failed)
+	assert.Empty(t, mailer.ready)
+}
+
+func TestGetExportOnlyForRequester(t *testing.T) {
+	s, users, _, _, _ := newTestExportService(t)
+	const otherSuperAdminID uint = 50
+	users.users[otherSuperAdminID] = &authdomain.User{
+		ID: otherSuperAdminID, Email: "other@example.com", Role: authdomain.RoleSuperAdmin, Status: authdomain.StatusActive,
+	}
+
+	job, err := s.CreateExport(superAdminID, &domain.CreateExportRequest{
+		Type: domain.ExportTypeUsers, Reason: "compliance request",
+	}, "127.0.0.1", "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = s.GetExport(otherSuperAdminID, job.ID)
+	assert.ErrorIs(t, err, domain.ErrExportNotFound)
+}
+
+// parsedSignature returns the signature of a download URL
+func parsedSignature(downloadURL string) string {
+	parsed, _ := url.Parse(downloadURL)
+	return parsed.Query().Get("signature")
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// MergeUsers folds a duplicate source account into a target account. Only super admins may merge,
+// which also puts the request behind step-up auth. The target keeps its own profile, role and status;
+// the source's history and feature overrides move over and the source is soft-deleted.
+func (s *AdminService) MergeUsers(
+	adminID uint,
+	req *domain.MergeUsersRequest,
+	ipAddress, userAgent string,
+) (*domain.MergeUsersResponse, error) {
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !admin.IsSuperAdmin() {
+		return nil, domain.ErrSuperAdminRequired
+	}
+
+	source, err := s.userRepo.GetByID(req.SourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.userRepo.GetByID(req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range []*authdomain.User{source, target} {
+		if err := domain.CheckCanManageUser(admin, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := domain.CheckMergeable(source, target); err != nil {
+		return nil, err
+	}
+
+	release, err := s.acquireOperation(domain.OperationMerge)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result, err := s.mergeRepo.Merge(source.ID, target.ID)
+	if err != nil {
+		s.logger.Error("failed to merge users",
+			"admin_id", adminID,
+			"source_id", source.ID,
+			"target_id", target.ID,
+			"error", err)
+		return nil, err
+	}
+
+	// Refresh tokens went with the transaction; access tokens already issued have to be denylisted too
+	if s.sessions != nil {
+		if _, err := s.sessions.RevokeUserSessions(source.ID); err != nil {
+			s.logger.Error("failed to revoke merged user's access tokens", "source_id", source.ID, "error", err)
+		}
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		&target.ID,
+		authdomain.AuditActionUsersMerged,
+		authdomain.AuditLevelWarning,
+		"admin",
+		fmt.Sprintf("User %d (%s) merged into user %d (%s) by admin: %s", source.ID, source.Email, target.ID, target.Email, req.Reason),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"source_id":                 source.ID,
+			"source_email":              source.Email,
+			"source_role":               source.Role,
+			"target_email":              target.Email,
+			"audit_entries_moved":       result.AuditEntriesMoved,
+			"feature_overrides_moved":   result.FeatureOverridesMoved,
+			"feature_overrides_dropped": result.FeatureOverridesDropped,
+			"sessions_revoked":          result.SessionsRevoked,
+			"reason":                    req.Reason,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for user merge",
+			"admin_id", adminID,
+			"source_id", source.ID,
+			"target_id", target.ID,
+			"error", err)
+	}
+
+	s.logger.Warn("users merged",
+		"admin_id", adminID,
+		"source_id", source.ID,
+		"target_id", target.ID)
+
+	return &domain.MergeUsersResponse{
+		SourceID:    source.ID,
+		TargetID:    target.ID,
+		Target:      target.ToResponse(),
+		MergeResult: *result,
+	}, nil
+}
@@ -0,0 +1,675 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+	"github.com/acheevo/tfa/internal/user/repository"
+)
+
+// fakeUserRepo keeps users in memory, keyed by ID
+type fakeUserRepo struct {
+	users map[uint]*authdomain.User
+}
+
+func newFakeUserRepo(users ...*authdomain.User) *fakeUserRepo {
+	repo := &fakeUserRepo{users: map[uint]*authdomain.User{}}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepo) GetByID(id uint) (*authdomain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, authdomain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepo) Update(user *authdomain.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepo) List(req *userdomain.UserListRequest) ([]*authdomain.User, userdomain.PageCount, error) {
+	users := make([]*authdomain.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	return users, userdomain.ExactPageCount(req.Page, req.PageSize, len(users)), nil
+}
+
+func (r *fakeUserRepo) CheckEmailExists(email string, excludeUserID uint, includeDeleted bool) (bool, error) {
+	email = authdomain.NormalizeEmail(email)
+	for _, user := range r.users {
+		if user.Email == email && user.ID != excludeUserID && (includeDeleted || !user.DeletedAt.Valid) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeUserRepo) GetUsersByIDs(ids []uint) ([]*authdomain.User, error) {
+	var users []*authdomain.User
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepo) UpdateUserRole(userID uint, role authdomain.UserRole) error {
+	user, err := r.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	user.Role = role
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateEmailVerification(userID uint, verified bool) error {
+	user, err := r.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	user.EmailVerified = verified
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateUserStatus(userID uint, status authdomain.UserStatus) error {
+	user, err := r.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	user.Status = status
+	return nil
+}
+
+func (r *fakeUserRepo) ActivatePending(userID uint) (bool, error) {
+	user, err := r.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	if user.Status != authdomain.StatusPending {
+		return false, nil
+	}
+	user.Status = authdomain.StatusActive
+	return true, nil
+}
+
+func (r *fakeUserRepo) SoftDelete(userIDs []uint) error {
+	now := time.Now()
+	for _, id := range userIDs {
+		if user, ok := r.users[id]; ok {
+			user.DeletedAt.Time = now
+			user.DeletedAt.Valid = true
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) HardDelete(userIDs []uint) error {
+	for _, id := range userIDs {
+		delete(r.users, id)
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) GetSoftDeletedBefore(cutoff time.Time, limit int) ([]*authdomain.User, error) {
+	var users []*authdomain.User
+	for _, user := range r.users {
+		if user.DeletedAt.Valid && user.DeletedAt.Time.Before(cutoff) && len(users) < limit {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepo) ListByRole(role authdomain.UserRole) ([]*authdomain.User, error) {
+	var users []*authdomain.User
+	for _, user := range r.users {
+		if user.Role == role && !user.DeletedAt.Valid {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b *authdomain.User) int { return cmp.Compare(a.ID, b.ID) })
+	return users, nil
+}
+
+func (r *fakeUserRepo) CountAdminsExcluding(userIDs []uint) (int64, error) {
+	return r.count(userIDs, func(user *authdomain.User) bool { return user.IsAdmin() }), nil
+}
+
+func (r *fakeUserRepo) CountActiveAdminsExcluding(userIDs []uint) (int64, error) {
+	return r.count(userIDs, func(user *authdomain.User) bool {
+		return user.IsAdmin() && user.Status == authdomain.StatusActive
+	}), nil
+}
+
+func (r *fakeUserRepo) CountActiveSuperAdminsExcluding(userIDs []uint) (int64, error) {
+	return r.count(userIDs, func(user *authdomain.User) bool {
+		return user.IsSuperAdmin() && user.Status == authdomain.StatusActive
+	}), nil
+}
+
+func (r *fakeUserRepo) GetAdminStats() (*repository.AdminStats, error) {
+	return &repository.AdminStats{}, nil
+}
+
+func (r *fakeUserRepo) GetUserGrowthData(days int) ([]repository.UserGrowthDataPoint, error) {
+	return nil, nil
+}
+
+func (r *fakeUserRepo) CountUserActivity(dailySince, monthlySince time.Time) (*domain.UserActivity, error) {
+	activity := &domain.UserActivity{}
+	for _, user := range r.users {
+		if user.DeletedAt.Valid {
+			continue
+		}
+		if user.Status == authdomain.StatusActive {
+			activity.Active++
+		}
+		if user.LastLoginAt != nil && !user.LastLoginAt.Before(dailySince) {
+			activity.DailyActive++
+		}
+		if user.LastLoginAt != nil && !user.LastLoginAt.Before(monthlySince) {
+			activity.MonthlyActive++
+		}
+	}
+	return activity, nil
+}
+
+// count returns how many users outside userIDs match
+func (r *fakeUserRepo) count(userIDs []uint, match func(*authdomain.User) bool) int64 {
+	excluded := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		excluded[id] = true
+	}
+
+	var count int64
+	for _, user := range r.users {
+		if !excluded[user.ID] && !user.DeletedAt.Valid && match(user) {
+			count++
+		}
+	}
+	return count
+}
+
+// fakeAuditRepo records the actions of the audit entries written, answers history queries from logs
+// and reports activity to the security thresholds and failed logins to the login stats
+type fakeAuditRepo struct {
+	actions  []authdomain.AuditAction
+	logs     []*authdomain.AuditLog
+	activity domain.SecurityActivity
+	failures domain.LoginFailureSummary
+	// summaryLimit is the limit the failed logins were last summarized with
+	summaryLimit int
+}
+
+func (r *fakeAuditRepo) CreateAuditEntry(
+	userID *uint,
+	targetID *uint,
+	action authdomain.AuditAction,
+	level authdomain.AuditLevel,
+	resource string,
+	description string,
+	ipAddress string,
+	userAgent string,
+	metadata map[string]interface{},
+) error {
+	r.actions = append(r.actions, action)
+	return nil
+}
+
+func (r *fakeAuditRepo) List(req *domain.AdminAuditLogRequest) ([]*authdomain.AuditLog, userdomain.PageCount, error) {
+	return nil, userdomain.ExactPageCount(req.Page, req.PageSize, 0), nil
+}
+
+func (r *fakeAuditRepo) GetUserAuditHistory(userID uint, limit int) ([]*authdomain.AuditLog, error) {
+	return nil, nil
+}
+
+func (r *fakeAuditRepo) ListUserHistory(
+	userID uint,
+	req *domain.UserAuditLogRequest,
+) ([]*authdomain.AuditLog, int, error) {
+	var matching []*authdomain.AuditLog
+	for _, log := range r.logs {
+		involved := (log.UserID != nil && *log.UserID == userID) || (log.TargetID != nil && *log.TargetID == userID)
+		if involved && (req.Action == "" || log.Action == req.Action) {
+			matching = append(matching, log)
+		}
+	}
+
+	start := min((req.Page-1)*req.PageSize, len(matching))
+	end := min(start+req.PageSize, len(matching))
+	return matching[start:end], len(matching), nil
+}
+
+func (r *fakeAuditRepo) SummarizeFailedLogins(since time.Time, limit int) (*domain.LoginFailureSummary, error) {
+	r.summaryLimit = limit
+	summary := r.failures
+	return &summary, nil
+}
+
+func (r *fakeAuditRepo) GetSecurityActivity(since time.Time) (*domain.SecurityActivity, error) {
+	activity := r.activity
+	return &activity, nil
+}
+
+func (r *fakeAuditRepo) LastAdminActivity(userIDs []uint) (map[uint]time.Time, error) {
+	last := make(map[uint]time.Time)
+	for _, log := range r.logs {
+		var userID *uint
+		switch {
+		case log.Resource == "admin":
+			userID = log.UserID
+		case log.Action == authdomain.AuditActionUserRoleChanged && log.Metadata["new_role"] == string(authdomain.RoleAdmin):
+			userID = log.TargetID
+		}
+		if userID != nil && slices.Contains(userIDs, *userID) && log.CreatedAt.After(last[*userID]) {
+			last[*userID] = log.CreatedAt
+		}
+	}
+	return last, nil
+}
+
+// fakeRecoveryRepo keeps recovery requests in memory, keyed by ID
+type fakeRecoveryRepo struct {
+	requests map[string]*authdomain.RecoveryRequest
+}
+
+func newFakeRecoveryRepo(requests ...*authdomain.RecoveryRequest) *fakeRecoveryRepo {
+	repo := &fakeRecoveryRepo{requests: map[string]*authdomain.RecoveryRequest{}}
+	for _, request := range requests {
+		repo.requests[request.ID] = request
+	}
+	return repo
+}
+
+func (r *fakeRecoveryRepo) GetByID(id string) (*authdomain.RecoveryRequest, error) {
+	request, ok := r.requests[id]
+	if !ok {
+		return nil, authdomain.ErrRecoveryNotFound
+	}
+	// Return a copy, as the database would, so review updates only land through Review
+	stored := *request
+	return &stored, nil
+}
+
+func (r *fakeRecoveryRepo) Review(
+	id string,
+	status authdomain.RecoveryStatus,
+	reviewerID uint,
+	note string,
+	at time.Time,
+) (bool, error) {
+	request, ok := r.requests[id]
+	if !ok || request.Status != authdomain.RecoveryStatusPending {
+		return false, nil
+	}
+	request.Status = status
+	request.ReviewedBy = &reviewerID
+	request.ReviewNote = note
+	request.ReviewedAt = &at
+	return true, nil
+}
+
+func (r *fakeRecoveryRepo) Reopen(id string, status authdomain.RecoveryStatus) (bool, error) {
+	request, ok := r.requests[id]
+	if !ok || request.Status != status {
+		return false, nil
+	}
+	request.Status = authdomain.RecoveryStatusPending
+	request.ReviewedBy = nil
+	request.ReviewNote = ""
+	request.ReviewedAt = nil
+	return true, nil
+}
+
+func (r *fakeRecoveryRepo) List(
+	status authdomain.RecoveryStatus,
+	page, pageSize int,
+) ([]*authdomain.RecoveryRequest, int, error) {
+	var requests []*authdomain.RecoveryRequest
+	for _, request := range r.requests {
+		if status == "" || request.Status == status {
+			requests = append(requests, request)
+		}
+	}
+	return requests, len(requests), nil
+}
+
+// fakeAccountEmails records the recovery resets sent, as "<user ID> <address>"; with sendErr set
+// sending fails
+type fakeAccountEmails struct {
+	recoveryResets []string
+	sendErr        error
+}
+
+func (f *fakeAccountEmails) ResendEmailVerification(userID uint) error { return nil }
+
+func (f *fakeAccountEmails) ForgotPassword(req *authdomain.ForgotPasswordRequest) error { return nil }
+
+func (f *fakeAccountEmails) SendRecoveryReset(userID uint, sendTo string) error {
+	if f.sendErr != nil {
+		return f.sendErr
+	}
+	f.recoveryResets = append(f.recoveryResets, fmt.Sprintf("%d %s", userID, sendTo))
+	return nil
+}
+
+// fakeSessions keeps users' sessions in memory, keyed by refresh token ID
+type fakeSessions struct {
+	tokens map[uint]*authdomain.RefreshToken
+}
+
+func newFakeSessions(tokens ...*authdomain.RefreshToken) *fakeSessions {
+	sessions := &fakeSessions{tokens: map[uint]*authdomain.RefreshToken{}}
+	for _, token := range tokens {
+		sessions.tokens[token.ID] = token
+	}
+	return sessions
+}
+
+func (f *fakeSessions) RevokeUserSessions(userID uint) (int64, error) {
+	var revoked int64
+	for id, token := range f.tokens {
+		if token.UserID == userID {
+			delete(f.tokens, id)
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (f *fakeSessions) ListUserSessions(userID uint) ([]*authdomain.RefreshToken, error) {
+	var tokens []*authdomain.RefreshToken
+	for _, token := range f.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (f *fakeSessions) GetSessionByID(tokenID uint) (*authdomain.RefreshToken, error) {
+	token, ok := f.tokens[tokenID]
+	if !ok {
+		return nil, authdomain.ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (f *fakeSessions) RevokeSession(tokenID uint) (*authdomain.RefreshToken, error) {
+	token, err := f.GetSessionByID(tokenID)
+	if err != nil {
+		return nil, err
+	}
+	delete(f.tokens, tokenID)
+	return token, nil
+}
+
+// fakeAlertRepo keeps security alerts in memory, in the order they were raised
+type fakeAlertRepo struct {
+	alerts []*authdomain.SecurityAlert
+}
+
+func (r *fakeAlertRepo) Create(alert *authdomain.SecurityAlert) error {
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func (r *fakeAlertRepo) GetByID(id string) (*authdomain.SecurityAlert, error) {
+	for _, alert := range r.alerts {
+		if alert.ID == id {
+			return alert, nil
+		}
+	}
+	return nil, domain.ErrAlertNotFound
+}
+
+func (r *fakeAlertRepo) Update(alert *authdomain.SecurityAlert) error { return nil }
+
+func (r *fakeAlertRepo) ExistsSince(alertType string, since time.Time) (bool, error) {
+	for _, alert := range r.alerts {
+		if alert.Type == alertType && !alert.CreatedAt.Before(since) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeAlertRepo) ActiveResponseUntil(response string, now time.Time) (*time.Time, error) {
+	return nil, nil
+}
+
+func (r *fakeAlertRepo) List(req *domain.SecurityAlertListRequest) ([]*authdomain.SecurityAlert, int, error) {
+	return r.alerts, len(r.alerts), nil
+}
+
+func (r *fakeAlertRepo) ListByAdmin(adminID uint) ([]*authdomain.SecurityAlert, error) {
+	var alerts []*authdomain.SecurityAlert
+	for _, alert := range r.alerts {
+		if alert.AdminID == adminID {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, nil
+}
+
+// fakeAlertDeliveries records every security alert delivered, as "<recipient> <alert type>". Alerts
+// are delivered in the background, so it is safe for concurrent use.
+type fakeAlertDeliveries struct {
+	mu         sync.Mutex
+	deliveries []string
+}
+
+func (f *fakeAlertDeliveries) record(recipient, alertType string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deliveries = append(f.deliveries, recipient+" "+alertType)
+}
+
+func (f *fakeAlertDeliveries) delivered() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.deliveries...)
+}
+
+// Send records a webhook delivery
+func (f *fakeAlertDeliveries) Send(ctx context.Context, event string, payload interface{}) error {
+	f.record("webhook", payload.(*authdomain.SecurityAlert).Type)
+	return nil
+}
+
+// SendSecurityAlert records an email delivery
+func (f *fakeAlertDeliveries) SendSecurityAlert(email string, alert *authdomain.SecurityAlert) error {
+	f.record(email, alert.Type)
+	return nil
+}
+
+// fakeExportRepo keeps export jobs in memory, keyed by ID. Jobs are copied in and out, as rows would be.
+type fakeExportRepo struct {
+	mu   sync.Mutex
+	jobs map[string]*domain.ExportJob
+}
+
+func newFakeExportRepo() *fakeExportRepo {
+	return &fakeExportRepo{jobs: map[string]*domain.ExportJob{}}
+}
+
+func (r *fakeExportRepo) Create(job *domain.ExportJob) error {
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+	return r.Update(job)
+}
+
+func (r *fakeExportRepo) Update(job *domain.ExportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *job
+	stored.DownloadURL, stored.DownloadURLExpiresAt = "", nil
+	r.jobs[job.ID] = &stored
+	return nil
+}
+
+func (r *fakeExportRepo) GetByID(id string) (*domain.ExportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, domain.ErrExportNotFound
+	}
+	found := *job
+	return &found, nil
+}
+
+func (r *fakeExportRepo) ClaimNext(now time.Time) (*domain.ExportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var next *domain.ExportJob
+	for _, job := range r.jobs {
+		if job.Status == domain.ExportStatusPending && (next == nil || job.CreatedAt.Before(next.CreatedAt)) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	next.Status = domain.ExportStatusRunning
+	next.StartedAt = &now
+	claimed := *next
+	return &claimed, nil
+}
+
+func (r *fakeExportRepo) ListExpired(now time.Time, limit int) ([]*domain.ExportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var expired []*domain.ExportJob
+	for _, job := range r.jobs {
+		if job.Status == domain.ExportStatusCompleted && !job.ExpiresAt.After(now) && len(expired) < limit {
+			found := *job
+			expired = append(expired, &found)
+		}
+	}
+	return expired, nil
+}
+
+func (r *fakeExportRepo) FailStale(startedBefore, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var failed int64
+	for _, job := range r.jobs {
+		if job.Status == domain.ExportStatusRunning && job.StartedAt.Before(startedBefore) {
+			job.Status = domain.ExportStatusFailed
+			job.CompletedAt = &now
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// fakeExportMailer records the export notifications sent, as "<email> <filename or export ID>"
+type fakeExportMailer struct {
+	ready       []string
+	failed      []string
+	downloadURL string
+}
+
+func (f *fakeExportMailer) SendExportReady(email, firstName, filename, downloadURL string, linkExpiresAt time.Time) error {
+	f.ready = append(f.ready, email+" "+filename)
+	f.downloadURL = downloadURL
+	return nil
+}
+
+func (f *fakeExportMailer) SendExportFailed(email, firstName, exportID string) error {
+	f.failed = append(f.failed, email+" "+exportID)
+	return nil
+}
+
+// fakeLockouts reports a fixed set of login lockouts
+type fakeLockouts struct {
+	lockouts []domain.LoginLockout
+}
+
+func (f *fakeLockouts) CurrentLockouts() []domain.LoginLockout {
+	return f.lockouts
+}
+
+// fakeBroadcastRepo keeps broadcast jobs in memory, keyed by ID
+type fakeBroadcastRepo struct {
+	mu   sync.Mutex
+	jobs map[string]*domain.BroadcastJob
+}
+
+func newFakeBroadcastRepo() *fakeBroadcastRepo {
+	return &fakeBroadcastRepo{jobs: map[string]*domain.BroadcastJob{}}
+}
+
+func (r *fakeBroadcastRepo) Create(job *domain.BroadcastJob) error {
+	return r.Update(job)
+}
+
+func (r *fakeBroadcastRepo) Update(job *domain.BroadcastJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *job
+	r.jobs[job.ID] = &stored
+	return nil
+}
+
+func (r *fakeBroadcastRepo) GetByID(id string) (*domain.BroadcastJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, domain.ErrBroadcastNotFound
+	}
+	found := *job
+	return &found, nil
+}
+
+func (r *fakeBroadcastRepo) FailStale(updatedBefore, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var failed int64
+	for _, job := range r.jobs {
+		if job.Status == domain.BroadcastStatusRunning && job.UpdatedAt.Before(updatedBefore) {
+			job.Status = domain.BroadcastStatusFailed
+			job.CompletedAt = &now
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// fakeEmailQueue serves a template without variables; suppression checks panic when panicOnSend is set.
+// Methods the tests don't use are left to the embedded nil interface.
+type fakeEmailQueue struct {
+	domain.EmailQueue
+	panicOnSend bool
+}
+
+func (f *fakeEmailQueue) GetTemplate(templateID string) (*emaildomain.EmailTemplate, error) {
+	return &emaildomain.EmailTemplate{ID: templateID}, nil
+}
+
+func (f *fakeEmailQueue) IsSuppressed(address string) bool {
+	if f.panicOnSend {
+		panic("queue unavailable")
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// ListRecoveryRequests retrieves a page of account recovery requests, oldest first
+func (s *AdminService) ListRecoveryRequests(
+	adminID uint,
+	req *domain.RecoveryRequestListRequest,
+) (*domain.RecoveryRequestListResponse, error) {
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, domain.ErrNotAuthorized
+	}
+
+	if s.recoveryRepo == nil {
+		return &domain.RecoveryRequestListResponse{
+			Requests:   []*authdomain.RecoveryRequest{},
+			Pagination: domain.NewPagination(req.Page, req.PageSize, 0),
+		}, nil
+	}
+
+	requests, total, err := s.recoveryRepo.List(req.Status, req.Page, req.PageSize)
+	if err != nil {
+		s.logger.Error("failed to list recovery requests", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+
+	return &domain.RecoveryRequestListResponse{
+		Requests:   requests,
+		Pagination: domain.NewPagination(req.Page, req.PageSize, total),
+	}, nil
+}
+
+// ApproveRecoveryRequest grants a pending recovery request once the admin has verified the
+// requester's identity. A password reset link for the account goes to the request's contact email,
+// and the account's sessions end, since whoever holds them may be the reason for the request. The
+// request is claimed before the link is sent, so a concurrent decision can't race an unaudited link
+// out; if sending fails the request goes back to pending.
+func (s *AdminService) ApproveRecoveryRequest(
+	adminID uint,
+	requestID string,
+	req *domain.ReviewRecoveryRequest,
+	ipAddress, userAgent string,
+) (*authdomain.RecoveryRequest, error) {
+	if s.accountEmails == nil {
+		return nil, domain.ErrAccountEmailsOffline
+	}
+
+	recovery, targetUser, err := s.authorizeRecoveryReview(adminID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reviewRecoveryRequest(recovery, authdomain.RecoveryStatusApproved, adminID, req.Note); err != nil {
+		return nil, err
+	}
+
+	if err := s.accountEmails.SendRecoveryReset(targetUser.ID, recovery.ContactEmail); err != nil {
+		s.logger.Error("failed to send recovery reset",
+			"admin_id", adminID,
+			"request_id", requestID,
+			"error", err)
+		if _, reopenErr := s.recoveryRepo.Reopen(recovery.ID, authdomain.RecoveryStatusApproved); reopenErr != nil {
+			s.logger.Error("failed to reopen recovery request after failed send",
+				"admin_id", adminID,
+				"request_id", requestID,
+				"error", reopenErr)
+		}
+		return nil, err
+	}
+
+	if s.sessions != nil {
+		if _, err := s.sessions.RevokeUserSessions(targetUser.ID); err != nil {
+			s.logger.Error("failed to revoke sessions after account recovery", "target_user_id", targetUser.ID, "error", err)
+		}
+	}
+
+	s.auditRecoveryReview(adminID, recovery, authdomain.AuditActionRecoveryApproved,
+		fmt.Sprintf("Account recovery for %s approved by admin, reset link sent to %s", recovery.Email, recovery.ContactEmail),
+		ipAddress, userAgent)
+	return recovery, nil
+}
+
+// RejectRecoveryRequest declines a pending recovery request
+func (s *AdminService) RejectRecoveryRequest(
+	adminID uint,
+	requestID string,
+	req *domain.ReviewRecoveryRequest,
+	ipAddress, userAgent string,
+) (*authdomain.RecoveryRequest, error) {
+	recovery, _, err := s.authorizeRecoveryReview(adminID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reviewRecoveryRequest(recovery, authdomain.RecoveryStatusRejected, adminID, req.Note); err != nil {
+		return nil, err
+	}
+
+	s.auditRecoveryReview(adminID, recovery, authdomain.AuditActionRecoveryRejected,
+		fmt.Sprintf("Account recovery for %s rejected by admin", recovery.Email), ipAddress, userAgent)
+	return recovery, nil
+}
+
+// authorizeRecoveryReview checks the admin may manage the account a pending request targets and
+// returns the request and the account
+func (s *AdminService) authorizeRecoveryReview(
+	adminID uint,
+	requestID string,
+) (*authdomain.RecoveryRequest, *authdomain.User, error) {
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, nil, domain.ErrNotAuthorized
+	}
+
+	if s.recoveryRepo == nil {
+		return nil, nil, authdomain.ErrRecoveryNotFound
+	}
+
+	recovery, err := s.recoveryRepo.GetByID(requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if recovery.Status != authdomain.RecoveryStatusPending {
+		return nil, nil, authdomain.ErrRecoveryNotPending
+	}
+
+	targetUser, err := s.userRepo.GetByID(recovery.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Admins can't recover their own account or one that outranks them
+	if err := domain.CheckCanManageUser(admin, targetUser); err != nil {
+		return nil, nil, err
+	}
+
+	return recovery, targetUser, nil
+}
+
+// reviewRecoveryRequest records the decision, failing when another admin decided the request first
+func (s *AdminService) reviewRecoveryRequest(
+	recovery *authdomain.RecoveryRequest,
+	status authdomain.RecoveryStatus,
+	adminID uint,
+	note string,
+) error {
+	now := time.Now()
+	reviewed, err := s.recoveryRepo.Review(recovery.ID, status, adminID, note, now)
+	if err != nil {
+		s.logger.Error("failed to review recovery request", "admin_id", adminID, "request_id", recovery.ID, "error", err)
+		return err
+	}
+	if !reviewed {
+		return authdomain.ErrRecoveryNotPending
+	}
+
+	recovery.Status = status
+	recovery.ReviewedBy = &adminID
+	recovery.ReviewedAt = &now
+	recovery.ReviewNote = note
+	return nil
+}
+
+// auditRecoveryReview records an admin's decision on a recovery request
+func (s *AdminService) auditRecoveryReview(
+	adminID uint,
+	recovery *authdomain.RecoveryRequest,
+	action authdomain.AuditAction,
+	description, ipAddress, userAgent string,
+) {
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		&recovery.UserID,
+		action,
+		authdomain.AuditLevelWarning,
+		"admin",
+		description,
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"request_id":    recovery.ID,
+			"email":         recovery.Email,
+			"contact_email": recovery.ContactEmail,
+			"note":          recovery.ReviewNote,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for account recovery review",
+			"admin_id", adminID,
+			"request_id", recovery.ID,
+			"error", err)
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func newTestRecoveryService(
+	requests ...*authdomain.RecoveryRequest,
+) (*AdminService, *fakeRecoveryRepo, *fakeAccountEmails, *fakeAuditRepo) {
+	s, _, audit := newTestAdminService(&config.Config{})
+	recoveries := newFakeRecoveryRepo(requests...)
+	emails := &fakeAccountEmails{}
+	s.recoveryRepo = recoveries
+	s.accountEmails = emails
+	return s, recoveries, emails, audit
+}
+
+func pendingRecovery(id string, target uint) *authdomain.RecoveryRequest {
+	return &authdomain.RecoveryRequest{
+		ID:           id,
+		UserID:       target,
+		ContactEmail: "contact@example.com",
+		Status:       authdomain.RecoveryStatusPending,
+	}
+}
+
+func TestApproveRecoveryRequest(t *testing.T) {
+	review := &domain.ReviewRecoveryRequest{Note: "verified on a video call"}
+
+	t.Run("sends the reset link to the contact email", func(t *testing.T) {
+		s, recoveries, emails, audit := newTestRecoveryService(pendingRecovery("r1", userID))
+
+		recovery, err := s.ApproveRecoveryRequest(adminID, "r1", review, "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, authdomain.RecoveryStatusApproved, recovery.Status)
+		assert.Equal(t, authdomain.RecoveryStatusApproved, recoveries.requests["r1"].Status)
+		assert.Equal(t, "verified on a video call", recoveries.requests["r1"].ReviewNote)
+		assert.Equal(t, []string{"3 contact@example.com"}, emails.recoveryResets)
+		assert.Equal(t, []authdomain.AuditAction{authdomain.AuditActionRecoveryApproved}, audit.actions)
+	})
+
+	tests := []struct {
+		name    string
+		actorID uint
+		request *authdomain.RecoveryRequest
+		wantErr error
+	}{
+		{"already reviewed", adminID, &authdomain.RecoveryRequest{
+			ID: "r1", UserID: userID, Status: authdomain.RecoveryStatusRejected,
+		}, authdomain.ErrRecoveryNotPending},
+		{"own account", adminID, pendingRecovery("r1", adminID), domain.ErrCannotManageSelf},
+		{"super admin account", adminID, pendingRecovery("r1", superAdminID), domain.ErrSuperAdminRequired},
+		{"not an admin", userID, pendingRecovery("r1", pendingUserID), domain.ErrNotAuthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, emails, audit := newTestRecoveryService(tt.request)
+
+			_, err := s.ApproveRecoveryRequest(tt.actorID, "r1", review, "127.0.0.1", "test")
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Empty(t, emails.recoveryResets)
+			assert.Empty(t, audit.actions)
+		})
+	}
+
+	t.Run("failed send reopens the request", func(t *testing.T) {
+		s, recoveries, emails, audit := newTestRecoveryService(pendingRecovery("r1", userID))
+		emails.sendErr = errors.New("smtp unavailable")
+
+		_, err := s.ApproveRecoveryRequest(adminID, "r1", review, "127.0.0.1", "test")
+
+		assert.Error(t, err)
+		assert.Equal(t, authdomain.RecoveryStatusPending, recoveries.requests["r1"].Status)
+		assert.Nil(t, recoveries.requests["r1"].ReviewedBy)
+		assert.Empty(t, audit.actions)
+	})
+
+	t.Run("request decided concurrently sends nothing", func(t *testing.T) {
+		s, recoveries, emails, audit := newTestRecoveryService(pendingRecovery("r1", userID))
+		// Another admin rejects the request between the check and the claim
+		s.recoveryRepo = &racingRecoveryRepo{fakeRecoveryRepo: recoveries}
+
+		_, err := s.ApproveRecoveryRequest(adminID, "r1", review, "127.0.0.1", "test")
+
+		assert.ErrorIs(t, err, authdomain.ErrRecoveryNotPending)
+		assert.Empty(t, emails.recoveryResets)
+		assert.Empty(t, audit.actions)
+	})
+
+	t.Run("unknown request", func(t *testing.T) {
+		s, _, _, _ := newTestRecoveryService()
+
+		_, err := s.ApproveRecoveryRequest(adminID, "missing", review, "127.0.0.1", "test")
+
+		assert.ErrorIs(t, err, authdomain.ErrRecoveryNotFound)
+	})
+}
+
+func TestRejectRecoveryRequest(t *testing.T) {
+	s, recoveries, emails, audit := newTestRecoveryService(pendingRecovery("r1", userID))
+
+	recovery, err := s.RejectRecoveryRequest(adminID, "r1", &domain.ReviewRecoveryRequest{Note: "could not verify identity"},
+		"127.0.0.1", "test")
+
+	assert.NoError(t, err)
+	assert.Equal(t, authdomain.RecoveryStatusRejected, recovery.Status)
+	assert.Equal(t, authdomain.RecoveryStatusRejected, recoveries.requests["r1"].Status)
+	assert.Empty(t, emails.recoveryResets)
+	assert.Equal(t, []authdomain.AuditAction{authdomain.AuditActionRecoveryRejected}, audit.actions)
+}
+
+// racingRecoveryRepo rejects each request just before it is reviewed, as a concurrent admin would
+type racingRecoveryRepo struct {
+	*fakeRecoveryRepo
+}
+
+func (r *racingRecoveryRepo) Review(
+	id string,
+	status authdomain.RecoveryStatus,
+	reviewerID uint,
+	note string,
+	at time.Time,
+) (bool, error) {
+	_, _ = r.fakeRecoveryRepo.Review(id, authdomain.RecoveryStatusRejected, reviewerID+1, "", at)
+	return r.fakeRecoveryRepo.Review(id, status, reviewerID, note, at)
+}
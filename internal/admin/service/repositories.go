@@ -0,0 +1,109 @@
+package service
+
+import (
+	"time"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	adminrepository "github.com/acheevo/tfa/internal/admin/repository"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	authrepository "github.com/acheevo/tfa/internal/auth/repository"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+	"github.com/acheevo/tfa/internal/user/repository"
+)
+
+// UserRepo is the user storage the admin service needs
+type UserRepo interface {
+	GetByID(id uint) (*authdomain.User, error)
+	Update(user *authdomain.User) error
+	List(req *userdomain.UserListRequest) ([]*authdomain.User, userdomain.PageCount, error)
+	CheckEmailExists(email string, excludeUserID uint, includeDeleted bool) (bool, error)
+	GetUsersByIDs(ids []uint) ([]*authdomain.User, error)
+	UpdateUserRole(userID uint, role authdomain.UserRole) error
+	UpdateEmailVerification(userID uint, verified bool) error
+	UpdateUserStatus(userID uint, status authdomain.UserStatus) error
+	ActivatePending(userID uint) (bool, error)
+	SoftDelete(userIDs []uint) error
+	HardDelete(userIDs []uint) error
+	GetSoftDeletedBefore(cutoff time.Time, limit int) ([]*authdomain.User, error)
+	ListByRole(role authdomain.UserRole) ([]*authdomain.User, error)
+	CountAdminsExcluding(userIDs []uint) (int64, error)
+	CountActiveAdminsExcluding(userIDs []uint) (int64, error)
+	CountActiveSuperAdminsExcluding(userIDs []uint) (int64, error)
+	GetAdminStats() (*repository.AdminStats, error)
+	GetUserGrowthData(days int) ([]repository.UserGrowthDataPoint, error)
+	CountUserActivity(dailySince, monthlySince time.Time) (*domain.UserActivity, error)
+}
+
+// AuditRepo records audit log entries and answers the admin views over them
+type AuditRepo interface {
+	CreateAuditEntry(
+		userID *uint,
+		targetID *uint,
+		action authdomain.AuditAction,
+		level authdomain.AuditLevel,
+		resource string,
+		description string,
+		ipAddress string,
+		userAgent string,
+		metadata map[string]interface{},
+	) error
+	List(req *domain.AdminAuditLogRequest) ([]*authdomain.AuditLog, userdomain.PageCount, error)
+	GetUserAuditHistory(userID uint, limit int) ([]*authdomain.AuditLog, error)
+	ListUserHistory(userID uint, req *domain.UserAuditLogRequest) ([]*authdomain.AuditLog, int, error)
+	SummarizeFailedLogins(since time.Time, limit int) (*domain.LoginFailureSummary, error)
+	GetSecurityActivity(since time.Time) (*domain.SecurityActivity, error)
+	LastAdminActivity(userIDs []uint) (map[uint]time.Time, error)
+}
+
+// BroadcastRepo stores the progress of broadcast jobs
+type BroadcastRepo interface {
+	Create(job *domain.BroadcastJob) error
+	Update(job *domain.BroadcastJob) error
+	GetByID(id string) (*domain.BroadcastJob, error)
+	FailStale(updatedBefore, now time.Time) (int64, error)
+}
+
+// MergeRepo moves one account's data into another
+type MergeRepo interface {
+	Merge(sourceID, targetID uint) (*domain.MergeResult, error)
+}
+
+// SecurityAlertRepo stores raised security alerts
+type SecurityAlertRepo interface {
+	Create(alert *authdomain.SecurityAlert) error
+	GetByID(id string) (*authdomain.SecurityAlert, error)
+	Update(alert *authdomain.SecurityAlert) error
+	ExistsSince(alertType string, since time.Time) (bool, error)
+	ActiveResponseUntil(response string, now time.Time) (*time.Time, error)
+	List(req *domain.SecurityAlertListRequest) ([]*authdomain.SecurityAlert, int, error)
+	ListByAdmin(adminID uint) ([]*authdomain.SecurityAlert, error)
+}
+
+// RecoveryRequestRepo answers the admin review of account recovery requests
+type RecoveryRequestRepo interface {
+	GetByID(id string) (*authdomain.RecoveryRequest, error)
+	Review(id string, status authdomain.RecoveryStatus, reviewerID uint, note string, at time.Time) (bool, error)
+	Reopen(id string, status authdomain.RecoveryStatus) (bool, error)
+	List(status authdomain.RecoveryStatus, page, pageSize int) ([]*authdomain.RecoveryRequest, int, error)
+}
+
+// ExportRepo stores export jobs and hands pending ones to the background runner
+type ExportRepo interface {
+	Create(job *domain.ExportJob) error
+	Update(job *domain.ExportJob) error
+	GetByID(id string) (*domain.ExportJob, error)
+	ClaimNext(now time.Time) (*domain.ExportJob, error)
+	ListExpired(now time.Time, limit int) ([]*domain.ExportJob, error)
+	FailStale(startedBefore, now time.Time) (int64, error)
+}
+
+// The repositories used in production satisfy the interfaces
+var (
+	_ UserRepo            = (*repository.UserRepository)(nil)
+	_ AuditRepo           = (*repository.AuditRepository)(nil)
+	_ BroadcastRepo       = (*adminrepository.BroadcastRepository)(nil)
+	_ MergeRepo           = (*adminrepository.MergeRepository)(nil)
+	_ SecurityAlertRepo   = (*adminrepository.SecurityAlertRepository)(nil)
+	_ RecoveryRequestRepo = (*authrepository.RecoveryRequestRepository)(nil)
+	_ ExportRepo          = (*adminrepository.ExportRepository)(nil)
+)
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
+	applogger "github.com/acheevo/tfa/internal/shared/logger"
+)
+
+// securityAlertEvent is the webhook event every security alert is delivered as
+const securityAlertEvent = "security_alert"
+
+// CheckSecurityThresholds compares the audited activity of the alert window with the configured
+// thresholds. A breach raises one alert per type and window, so a sustained breach isn't reported on
+// every run, and applies its automatic response when that response is enabled. It returns the alerts
+// raised by this run.
+func (s *AdminService) CheckSecurityThresholds(ctx context.Context) ([]*authdomain.SecurityAlert, error) {
+	if s.alertRepo == nil {
+		return nil, nil
+	}
+
+	window := s.config.SecurityAlertWindowDuration()
+	now := s.clock.Now()
+	since := now.Add(-window)
+
+	activity, err := s.auditRepo.GetSecurityActivity(since)
+	if err != nil {
+		return nil, err
+	}
+
+	thresholds := domain.SecurityThresholds{
+		AdminPromotions: s.config.SecurityAlertAdminPromotions,
+		FailedLogins:    s.config.SecurityAlertFailedLogins,
+		Deletions:       s.config.SecurityAlertDeletions,
+	}
+
+	var raised []*authdomain.SecurityAlert
+	for _, breach := range domain.BreachedThresholds(*activity, thresholds) {
+		exists, err := s.alertRepo.ExistsSince(breach.Type, since)
+		if err != nil {
+			return raised, err
+		}
+		if exists {
+			continue
+		}
+
+		alert := domain.NewThresholdAlert(breach, window, now)
+		if s.responseAvailable(breach.Response) {
+			until := now.Add(s.config.SecurityAlertResponseTTLDuration())
+			alert.Response = breach.Response
+			alert.ResponseUntil = &until
+		}
+
+		if err := s.raiseSecurityAlert(ctx, alert); err != nil {
+			return raised, err
+		}
+		raised = append(raised, alert)
+	}
+
+	// Every instance runs the check, so each one picks up responses raised elsewhere or lifted early
+	if err := s.syncLoginLimits(now); err != nil {
+		return raised, err
+	}
+
+	return raised, nil
+}
+
+// CheckEmailQueue raises an alert when a queue snapshot shows emails backing up: the pending emails
+// reached EMAIL_QUEUE_ALERT_DEPTH or the oldest has waited EMAIL_QUEUE_ALERT_AGE. Like the security
+// thresholds, each kind is raised at most once per alert window. It returns the alerts raised by this run.
+func (s *AdminService) CheckEmailQueue(ctx context.Context, stats *emaildomain.QueueStats) ([]*authdomain.SecurityAlert, error) {
+	if s.alertRepo == nil || stats == nil {
+		return nil, nil
+	}
+
+	now := s.clock.Now()
+	since := now.Add(-s.config.SecurityAlertWindowDuration())
+
+	var raised []*authdomain.SecurityAlert
+	for _, alert := range domain.EmailQueueAlerts(stats, s.config.EmailQueueAlertDepth, s.config.EmailQueueAlertAgeDuration(), now) {
+		exists, err := s.alertRepo.ExistsSince(alert.Type, since)
+		if err != nil {
+			return raised, err
+		}
+		if exists {
+			continue
+		}
+
+		if err := s.raiseSecurityAlert(ctx, alert); err != nil {
+			return raised, err
+		}
+		raised = append(raised, alert)
+	}
+
+	return raised, nil
+}
+
+// RequiresStepUpForChanges reports whether an unresolved alert currently requires step-up
+// authentication from every admin changing users
+func (s *AdminService) RequiresStepUpForChanges() (bool, error) {
+	if s.alertRepo == nil {
+		return false, nil
+	}
+
+	until, err := s.alertRepo.ActiveResponseUntil(domain.ResponseRequireStepUp, s.clock.Now())
+	return until != nil, err
+}
+
+// ListSecurityAlerts retrieves a page of security alerts, newest first
+func (s *AdminService) ListSecurityAlerts(
+	adminID uint,
+	req *domain.SecurityAlertListRequest,
+) (*domain.SecurityAlertListResponse, error) {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, domain.ErrNotAuthorized
+	}
+
+	if s.alertRepo == nil {
+		return &domain.SecurityAlertListResponse{
+			Alerts:     []*authdomain.SecurityAlert{},
+			Pagination: domain.NewPagination(req.Page, req.PageSize, 0),
+		}, nil
+	}
+
+	alerts, total, err := s.alertRepo.List(req)
+	if err != nil {
+		s.logger.Error("failed to list security alerts", "admin_id", adminID, "error", err)
+		return nil, err
+	}
+
+	return &domain.SecurityAlertListResponse{
+		Alerts:     alerts,
+		Pagination: domain.NewPagination(req.Page, req.PageSize, total),
+	}, nil
+}
+
+// ResolveSecurityAlert closes an alert. Any automatic response it carries is lifted straight away.
+func (s *AdminService) ResolveSecurityAlert(
+	adminID uint,
+	alertID string,
+	req *domain.ResolveSecurityAlertRequest,
+	ipAddress, userAgent string,
+) (*authdomain.SecurityAlert, error) {
+	// Check admin authorization
+	admin, err := s.userRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsAuthorizedForUserManagement(admin) {
+		return nil, domain.ErrNotAuthorized
+	}
+
+	if s.alertRepo == nil {
+		return nil, domain.ErrAlertNotFound
+	}
+
+	alert, err := s.alertRepo.GetByID(alertID)
+	if err != nil {
+		return nil, err
+	}
+	if alert.Resolved {
+		return alert, nil
+	}
+
+	now := s.clock.Now()
+	alert.Resolved = true
+	alert.ResolvedAt = &now
+	alert.ResolvedBy = &adminID
+	alert.Notes = req.Notes
+	if err := s.alertRepo.Update(alert); err != nil {
+		s.logger.Error("failed to resolve security alert", "admin_id", adminID, "alert_id", alertID, "error", err)
+		return nil, err
+	}
+
+	if alert.Response == domain.ResponseTightenLoginLimits {
+		if err := s.syncLoginLimits(now); err != nil {
+			s.logger.Error("failed to restore login limits", "alert_id", alertID, "error", err)
+		}
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		nil,
+		authdomain.AuditActionAlertResolved,
+		authdomain.AuditLevelWarning,
+		"admin",
+		fmt.Sprintf("Security alert resolved: %s", alert.Title),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"alert_id":   alert.ID,
+			"alert_type": alert.Type,
+			"response":   alert.Response,
+			"notes":      req.Notes,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for security alert resolution", "alert_id", alertID, "error", err)
+	}
+
+	return alert, nil
+}
+
+// raiseSecurityAlert stores an alert, writes it to the security log and delivers it to the alert
+// recipients when its type is one they are sent. Delivery happens in the background and a failed
+// delivery is only logged. Once stored, the alert is also published to admins streaming alerts.
+func (s *AdminService) raiseSecurityAlert(ctx context.Context, alert *authdomain.SecurityAlert) error {
+	s.logger.Warn("security alert raised",
+		"alert_id", alert.ID,
+		"alert_type", alert.Type,
+		"severity", alert.Severity,
+		"response", alert.Response,
+	)
+
+	s.securityLogger.Log(applogger.SecurityEventAlert, alert.Title,
+		"alert_id", alert.ID,
+		"alert_type", alert.Type,
+		"severity", alert.Severity,
+		"admin_id", alert.AdminID,
+		"response", alert.Response,
+		"data", alert.Data,
+	)
+
+	if s.alertNotifier != nil && s.config.SecurityAlertNotifies(alert.Type) {
+		delivered := *alert
+		go func() {
+			if err := s.alertNotifier.Send(context.WithoutCancel(ctx), securityAlertEvent, &delivered); err != nil {
+				s.logger.Error("failed to deliver security alert", "alert_id", delivered.ID, "error", err)
+			}
+		}()
+	}
+
+	if s.alertRepo != nil {
+		if err := s.alertRepo.Create(alert); err != nil {
+			return err
+		}
+	}
+
+	// Admins watching live see every alert once it is stored, whatever is sent out
+	s.alertStream.publish(alert)
+	return nil
+}
+
+// responseAvailable reports whether an automatic response is enabled and can be applied here
+func (s *AdminService) responseAvailable(response string) bool {
+	if !s.config.SecurityAlertResponseEnabled(response) {
+		return false
+	}
+	return response != domain.ResponseTightenLoginLimits || s.loginLimits != nil
+}
+
+// syncLoginLimits brings the login rate limiter in line with the unresolved alerts tightening it
+func (s *AdminService) syncLoginLimits(now time.Time) error {
+	if s.loginLimits == nil || s.alertRepo == nil {
+		return nil
+	}
+
+	until, err := s.alertRepo.ActiveResponseUntil(domain.ResponseTightenLoginLimits, now)
+	if err != nil {
+		return err
+	}
+	if until == nil {
+		s.loginLimits.TightenLoginLimits(time.Time{})
+		return nil
+	}
+	s.loginLimits.TightenLoginLimits(*until)
+	return nil
+}
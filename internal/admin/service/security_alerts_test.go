@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+func TestCheckEmailQueue(t *testing.T) {
+	cfg := &config.Config{EmailQueueAlertDepth: 100, EmailQueueAlertAge: "30m", SecurityAlertWindow: "1h"}
+	longWait := time.Now().Add(-45 * time.Minute)
+	shortWait := time.Now().Add(-time.Minute)
+
+	t.Run("healthy queue raises nothing", func(t *testing.T) {
+		s, _, _ := newTestAdminService(cfg)
+		alerts := &fakeAlertRepo{}
+		s.alertRepo = alerts
+
+		raised, err := s.CheckEmailQueue(context.Background(), &emaildomain.QueueStats{Pending: 99, OldestPendingAt: &shortWait})
+
+		assert.NoError(t, err)
+		assert.Empty(t, raised)
+		assert.Empty(t, alerts.alerts)
+	})
+
+	t.Run("depth and age breaches each raise an alert once per window", func(t *testing.T) {
+		s, _, _ := newTestAdminService(cfg)
+		alerts := &fakeAlertRepo{}
+		s.alertRepo = alerts
+		stats := &emaildomain.QueueStats{Pending: 100, OldestPendingAt: &longWait}
+
+		raised, err := s.CheckEmailQueue(context.Background(), stats)
+		assert.NoError(t, err)
+		if assert.Len(t, raised, 2) {
+			assert.Equal(t, domain.AlertTypeEmailQueueDepth, raised[0].Type)
+			assert.Equal(t, domain.AlertTypeEmailQueueAge, raised[1].Type)
+		}
+		assert.Len(t, alerts.alerts, 2)
+
+		raised, err = s.CheckEmailQueue(context.Background(), stats)
+		assert.NoError(t, err)
+		assert.Empty(t, raised)
+		assert.Len(t, alerts.alerts, 2)
+	})
+
+	t.Run("ages are measured on the service clock", func(t *testing.T) {
+		s, _, _ := newTestAdminService(cfg)
+		s.alertRepo = &fakeAlertRepo{}
+		clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+		s.clock = clk
+		queuedAt := clk.Now().Add(-time.Minute)
+
+		raised, err := s.CheckEmailQueue(context.Background(), &emaildomain.QueueStats{Pending: 1, OldestPendingAt: &queuedAt})
+		assert.NoError(t, err)
+		assert.Empty(t, raised)
+
+		clk.Advance(30 * time.Minute)
+		raised, err = s.CheckEmailQueue(context.Background(), &emaildomain.QueueStats{Pending: 1, OldestPendingAt: &queuedAt})
+		assert.NoError(t, err)
+		assert.Len(t, raised, 1)
+	})
+
+	t.Run("disabled thresholds", func(t *testing.T) {
+		s, _, _ := newTestAdminService(&config.Config{EmailQueueAlertAge: "0", SecurityAlertWindow: "1h"})
+		s.alertRepo = &fakeAlertRepo{}
+
+		raised, err := s.CheckEmailQueue(context.Background(), &emaildomain.QueueStats{Pending: 100000, OldestPendingAt: &longWait})
+
+		assert.NoError(t, err)
+		assert.Empty(t, raised)
+	})
+}
+
+func TestSecurityAlertNotifications(t *testing.T) {
+	cfg := &config.Config{
+		SecurityAlertWindow:          "1h",
+		SecurityAlertDeletions:       50,
+		SecurityAlertFailedLogins:    200,
+		SecurityAlertEmailRecipients: "security@example.com, Oncall@example.com, oncall@example.com",
+		SecurityAlertNotifyTypes:     "mass_deletion, role_change",
+	}
+	s, _, audit := newTestAdminService(cfg)
+	s.alertRepo = &fakeAlertRepo{}
+	deliveries := &fakeAlertDeliveries{}
+	s.alertNotifier = NewAlertRecipients(deliveries, deliveries, cfg.GetSecurityAlertEmailRecipients())
+
+	// Both thresholds are breached, but only mass deletions are sent out
+	audit.activity = domain.SecurityActivity{Deletions: 60, FailedLogins: 250}
+	raised, err := s.CheckSecurityThresholds(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, raised, 2)
+
+	// A sustained breach isn't raised, or sent, again
+	raised, err = s.CheckSecurityThresholds(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, raised)
+
+	// Granting the admin role is a high-risk role change
+	_, err = s.UpdateUserRole(superAdminID, userID, &domain.UpdateUserRoleRequest{
+		Role:   authdomain.RoleAdmin,
+		Reason: "Joining the support rota",
+	}, "127.0.0.1", "test")
+	assert.NoError(t, err)
+
+	want := []string{
+		"webhook mass_deletion",
+		"security@example.com mass_deletion",
+		"oncall@example.com mass_deletion",
+		"webhook role_change",
+		"security@example.com role_change",
+		"oncall@example.com role_change",
+	}
+	assert.Eventually(t, func() bool { return len(deliveries.delivered()) >= len(want) }, time.Second, time.Millisecond)
+	assert.ElementsMatch(t, want, deliveries.delivered())
+}
+
+func TestSecurityAlertTypesCanBeNotified(t *testing.T) {
+	// SECURITY_ALERT_NOTIFY_TYPES is validated against this list, so every raised type must be on it
+	raised := []string{
+		domain.AlertTypeRoleChange,
+		domain.AlertTypeAdminPromotions,
+		domain.AlertTypeFailedLogins,
+		domain.AlertTypeMassDeletion,
+		domain.AlertTypeEmailQueueDepth,
+		domain.AlertTypeEmailQueueAge,
+	}
+	assert.ElementsMatch(t, raised, config.SecurityAlertTypes)
+}
+
+func TestAlertRecipientsContinuesPastFailures(t *testing.T) {
+	deliveries := &fakeAlertDeliveries{}
+	recipients := NewAlertRecipients(failingNotifier{}, deliveries, []string{"security@example.com", "oncall@example.com"})
+
+	err := recipients.Send(context.Background(), securityAlertEvent, &authdomain.SecurityAlert{Type: domain.AlertTypeMassDeletion})
+
+	assert.ErrorContains(t, err, "webhook")
+	assert.Equal(t, []string{"security@example.com mass_deletion", "oncall@example.com mass_deletion"}, deliveries.delivered())
+}
+
+// failingNotifier is a webhook that can't be reached
+type failingNotifier struct{}
+
+func (failingNotifier) Send(ctx context.Context, event string, payload interface{}) error {
+	return errors.New("connection refused")
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func newTestSessionService() (*AdminService, *fakeSessions, *fakeAuditRepo) {
+	s, _, audit := newTestAdminService(&config.Config{})
+	sessions := newFakeSessions(
+		&authdomain.RefreshToken{ID: 1, UserID: userID, Token: "laptop"},
+		&authdomain.RefreshToken{ID: 2, UserID: userID, Token: "phone"},
+		&authdomain.RefreshToken{ID: 3, UserID: userID, Token: "tablet"},
+		&authdomain.RefreshToken{ID: 4, UserID: superAdminID, Token: "root"},
+	)
+	s.sessions = sessions
+	return s, sessions, audit
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Run("ends only that session", func(t *testing.T) {
+		s, sessions, audit := newTestSessionService()
+
+		err := s.RevokeSession(adminID, 2, "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.NotContains(t, sessions.tokens, uint(2))
+		assert.Contains(t, sessions.tokens, uint(1))
+		assert.Contains(t, sessions.tokens, uint(3))
+		assert.Equal(t, []authdomain.AuditAction{authdomain.AuditActionSessionsRevoked}, audit.actions)
+	})
+
+	tests := []struct {
+		name    string
+		actorID uint
+		tokenID uint
+		wantErr error
+	}{
+		{"super admin session", adminID, 4, domain.ErrSuperAdminRequired},
+		{"not an admin", userID, 1, domain.ErrNotAuthorized},
+		{"unknown session", adminID, 99, authdomain.ErrTokenNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, sessions, audit := newTestSessionService()
+
+			err := s.RevokeSession(tt.actorID, tt.tokenID, "127.0.0.1", "test")
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Len(t, sessions.tokens, 4)
+			assert.Empty(t, audit.actions)
+		})
+	}
+
+	t.Run("sessions offline", func(t *testing.T) {
+		s, _, _ := newTestAdminService(&config.Config{})
+
+		assert.ErrorIs(t, s.RevokeSession(adminID, 1, "127.0.0.1", "test"), domain.ErrSessionsOffline)
+	})
+}
+
+func TestListUserSessions(t *testing.T) {
+	s, _, _ := newTestSessionService()
+
+	response, err := s.ListUserSessions(adminID, userID)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, userID, response.UserID)
+	assert.Len(t, response.Sessions, 3)
+
+	_, err = s.ListUserSessions(adminID, superAdminID)
+	assert.ErrorIs(t, err, domain.ErrSuperAdminRequired)
+}
@@ -1,20 +1,34 @@
 package transport
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/acheevo/tfa/internal/admin/domain"
 	"github.com/acheevo/tfa/internal/admin/service"
 	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/middleware"
 	"github.com/acheevo/tfa/internal/shared/config"
+	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
+	apperrors "github.com/acheevo/tfa/internal/shared/errors"
 	userdomain "github.com/acheevo/tfa/internal/user/domain"
 )
 
+// Server-sent event names on the security alert stream
+const (
+	securityAlertEvent        = "security_alert"
+	securityAlertLaggedEvent  = "lagged"
+	securityAlertExpiredEvent = "expired"
+	securityAlertRevokedEvent = "revoked"
+)
+
 // AdminHandler handles HTTP requests for admin user management
 type AdminHandler struct {
 	config       *config.Config
@@ -77,6 +91,65 @@ func (h *AdminHandler) GetUserDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetUserAuditLogs handles GET /api/admin/users/:id/audit
+func (h *AdminHandler) GetUserAuditLogs(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	targetUserID, err := h.getTargetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	var req domain.UserAuditLogRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.adminService.GetUserAuditLogs(adminID, targetUserID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ExportUserEvidence handles GET /api/admin/users/:id/evidence, streaming the user's evidence bundle
+// as a zip. Once streaming starts the status is sent, so a failure part way only cuts the download short.
+func (h *AdminHandler) ExportUserEvidence(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	targetUserID, err := h.getTargetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	evidence, err := h.adminService.PrepareUserEvidence(adminID, targetUserID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, evidence.Filename()))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	// The service logs and audits a failed export; the cut-off zip tells the client
+	_ = h.adminService.WriteUserEvidence(evidence, c.Writer, c.ClientIP(), c.GetHeader("User-Agent"))
+}
+
 // UpdateUserRole handles PUT /api/admin/users/:id/role
 func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
 	adminID := h.getUserID(c)
@@ -100,13 +173,13 @@ func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
 	ipAddress := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
 
-	err = h.adminService.UpdateUserRole(adminID, targetUserID, &req, ipAddress, userAgent)
+	warnings, err := h.adminService.UpdateUserRole(adminID, targetUserID, &req, ipAddress, userAgent)
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: "user role updated successfully"})
+	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: "user role updated successfully", Warnings: warnings})
 }
 
 // UpdateUserStatus handles PUT /api/admin/users/:id/status
@@ -132,13 +205,193 @@ func (h *AdminHandler) UpdateUserStatus(c *gin.Context) {
 	ipAddress := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
 
-	err = h.adminService.UpdateUserStatus(adminID, targetUserID, &req, ipAddress, userAgent)
+	warnings, err := h.adminService.UpdateUserStatus(adminID, targetUserID, &req, ipAddress, userAgent)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: "user status updated successfully", Warnings: warnings})
+}
+
+// ApproveUser handles POST /api/admin/users/:id/approve
+func (h *AdminHandler) ApproveUser(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	targetUserID, err := h.getTargetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	// The body is optional; an approval needs no reason
+	var req domain.ApproveUserRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.handleValidationError(c, err)
+			return
+		}
+	}
+
+	if err := h.adminService.ApproveUser(adminID, targetUserID, &req, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: "user approved successfully"})
+}
+
+// UpdateEmailVerification handles POST /api/admin/users/:id/email-verification
+func (h *AdminHandler) UpdateEmailVerification(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	targetUserID, err := h.getTargetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	var req domain.UpdateEmailVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	ipAddress := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	err = h.adminService.UpdateEmailVerification(adminID, targetUserID, &req, ipAddress, userAgent)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	message := "user email marked as verified"
+	if !*req.Verified {
+		message = "user email marked as unverified"
+	}
+
+	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: message})
+}
+
+// ResendVerificationEmail handles POST /api/admin/users/:id/resend-verification
+func (h *AdminHandler) ResendVerificationEmail(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	targetUserID, err := h.getTargetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	if err := h.adminService.ResendVerificationEmail(adminID, targetUserID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: "verification email sent to user"})
+}
+
+// SendPasswordReset handles POST /api/admin/users/:id/send-reset
+func (h *AdminHandler) SendPasswordReset(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	targetUserID, err := h.getTargetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	if err := h.adminService.SendPasswordReset(adminID, targetUserID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: "password reset email sent to user"})
+}
+
+// ListUserSessions handles GET /api/admin/users/:id/sessions
+func (h *AdminHandler) ListUserSessions(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	targetUserID, err := h.getTargetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	response, err := h.adminService.ListUserSessions(adminID, targetUserID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSession handles DELETE /api/admin/sessions/:id
+func (h *AdminHandler) RevokeSession(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid session ID"})
+		return
+	}
+
+	if err := h.adminService.RevokeSession(adminID, uint(sessionID), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: "session revoked"})
+}
+
+// MergeUsers handles POST /api/admin/users/merge
+func (h *AdminHandler) MergeUsers(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.MergeUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.adminService.MergeUsers(adminID, &req, c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: "user status updated successfully"})
+	c.JSON(http.StatusOK, response)
 }
 
 // UpdateUser handles PUT /api/admin/users/:id
@@ -278,6 +531,474 @@ func (h *AdminHandler) GetAuditLogs(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetLoginStats handles GET /api/admin/security/login-stats
+func (h *AdminHandler) GetLoginStats(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.LoginStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.adminService.GetLoginStats(adminID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetEmailQueueStats handles GET /api/admin/email/queue
+func (h *AdminHandler) GetEmailQueueStats(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	stats, err := h.adminService.GetEmailQueueStats(c.Request.Context(), adminID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ProcessEmailQueue handles POST /api/admin/email/process
+func (h *AdminHandler) ProcessEmailQueue(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	response, err := h.adminService.ProcessEmailQueue(c.Request.Context(), adminID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CancelScheduledEmail handles DELETE /api/admin/email/scheduled/:message_id
+func (h *AdminHandler) CancelScheduledEmail(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	messageID := c.Param("message_id")
+	err := h.adminService.CancelScheduledEmail(c.Request.Context(), adminID, messageID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: "scheduled email canceled"})
+}
+
+// ListQueuedEmails handles GET /api/admin/email/messages
+func (h *AdminHandler) ListQueuedEmails(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.EmailListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.adminService.ListQueuedEmails(c.Request.Context(), adminID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListEmailEvents handles GET /api/admin/email/events
+func (h *AdminHandler) ListEmailEvents(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.EmailEventListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.adminService.ListEmailEvents(c.Request.Context(), adminID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// StartBroadcast handles POST /api/admin/email/broadcast
+func (h *AdminHandler) StartBroadcast(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var filter userdomain.UserListRequest
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	var req domain.BroadcastEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	job, err := h.adminService.StartBroadcast(c.Request.Context(), adminID, &filter, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetBroadcast handles GET /api/admin/email/broadcast/:id
+func (h *AdminHandler) GetBroadcast(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	job, err := h.adminService.GetBroadcast(adminID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// CreateExport handles POST /api/admin/exports, queueing an export to run in the background
+func (h *AdminHandler) CreateExport(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.CreateExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	job, err := h.adminService.CreateExport(adminID, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetExport handles GET /api/admin/exports/:id
+func (h *AdminHandler) GetExport(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	job, err := h.adminService.GetExport(adminID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadExport handles GET /api/exports/:id/download. It needs no session; the signed link is
+// checked instead, so it can be opened straight from the notification email.
+func (h *AdminHandler) DownloadExport(c *gin.Context) {
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		h.handleError(c, domain.ErrExportLinkInvalid)
+		return
+	}
+
+	download, err := h.adminService.OpenExportDownload(
+		c.Param("id"),
+		expires,
+		c.Query("signature"),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	defer download.Content.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, download.Export.Filename))
+	c.Header("Cache-Control", "no-store")
+	c.DataFromReader(http.StatusOK, download.Export.Size, domain.ExportContentType(download.Export.Type), download.Content, nil)
+}
+
+// ListSecurityAlerts handles GET /api/admin/security/alerts
+func (h *AdminHandler) ListSecurityAlerts(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.SecurityAlertListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.adminService.ListSecurityAlerts(adminID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// StreamSecurityAlerts handles GET /api/admin/alerts/stream, pushing each security alert raised on
+// this instance to the admin as a server-sent event until they disconnect, their access token
+// expires or they lose admin access
+func (h *AdminHandler) StreamSecurityAlerts(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	value, _ := c.Get("jwt_claims")
+	claims, ok := value.(*authdomain.JWTClaims)
+	if !ok || claims.ExpiresAt == nil {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	subscription, err := h.adminService.SubscribeSecurityAlerts(adminID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	defer subscription.Close()
+
+	// The stream outlives the server's write timeout, which would otherwise cut it off
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("failed to lift write deadline for alert stream", "admin_id", adminID, "error", err)
+	}
+
+	streamAlerts(c, subscription.Alerts(), h.config.SecurityAlertStreamHeartbeatDuration(), claims.ExpiresAt.Time,
+		func() error { return h.adminService.AuthorizeSecurityAlertStream(adminID) })
+}
+
+// streamAlerts writes alerts as server-sent events, with a comment line every heartbeat while idle,
+// until the client disconnects or alerts is closed. A closed channel means the client fell too far
+// behind, so it is told to reconnect and catch up from the alert list. The stream also ends once
+// expiresAt passes, or when authorize, checked every heartbeat, fails; the client is told which so
+// it can refresh its token and reconnect.
+func streamAlerts(
+	c *gin.Context,
+	alerts <-chan *authdomain.SecurityAlert,
+	heartbeat time.Duration,
+	expiresAt time.Time,
+	authorize func() error,
+) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	expiry := time.NewTimer(time.Until(expiresAt))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-expiry.C:
+			c.SSEvent(securityAlertExpiredEvent, gin.H{"message": "access token expired, refresh and reconnect"})
+			c.Writer.Flush()
+			return
+		case <-ticker.C:
+			if err := authorize(); err != nil {
+				c.SSEvent(securityAlertRevokedEvent, gin.H{"message": "admin access revoked"})
+				c.Writer.Flush()
+				return
+			}
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+		case alert, ok := <-alerts:
+			if !ok {
+				c.SSEvent(securityAlertLaggedEvent, gin.H{"message": "alert stream fell behind, reconnect and list missed alerts"})
+				c.Writer.Flush()
+				return
+			}
+			c.SSEvent(securityAlertEvent, alert)
+		}
+		c.Writer.Flush()
+	}
+}
+
+// GetActivityFeed handles GET /api/admin/activity
+func (h *AdminHandler) GetActivityFeed(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.ActivityFeedRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.adminService.GetActivityFeed(adminID, &req)
+	if errors.Is(err, domain.ErrInvalidActivityType) {
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+			Error:   "invalid activity type",
+			Details: map[string]string{"types": "expected a comma-separated list of registration, role_change, deletion, security_alert"},
+		})
+		return
+	}
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResolveSecurityAlert handles POST /api/admin/security/alerts/:id/resolve
+func (h *AdminHandler) ResolveSecurityAlert(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.ResolveSecurityAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	ipAddress := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	alert, err := h.adminService.ResolveSecurityAlert(adminID, c.Param("id"), &req, ipAddress, userAgent)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// ListRecoveryRequests handles GET /api/admin/recovery-requests
+func (h *AdminHandler) ListRecoveryRequests(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.RecoveryRequestListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.adminService.ListRecoveryRequests(adminID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ApproveRecoveryRequest handles POST /api/admin/recovery-requests/:id/approve
+func (h *AdminHandler) ApproveRecoveryRequest(c *gin.Context) {
+	h.reviewRecoveryRequest(c, h.adminService.ApproveRecoveryRequest)
+}
+
+// RejectRecoveryRequest handles POST /api/admin/recovery-requests/:id/reject
+func (h *AdminHandler) RejectRecoveryRequest(c *gin.Context) {
+	h.reviewRecoveryRequest(c, h.adminService.RejectRecoveryRequest)
+}
+
+func (h *AdminHandler) reviewRecoveryRequest(
+	c *gin.Context,
+	review func(uint, string, *domain.ReviewRecoveryRequest, string, string) (*authdomain.RecoveryRequest, error),
+) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.ReviewRecoveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	recovery, err := review(adminID, c.Param("id"), &req, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recovery)
+}
+
+// StepUpRequiredForChanges reports whether a security alert currently requires step-up authentication
+// for user changes. It is checked by middleware, so a lookup failure is logged and doesn't block.
+func (h *AdminHandler) StepUpRequiredForChanges() bool {
+	required, err := h.adminService.RequiresStepUpForChanges()
+	if err != nil {
+		h.logger.Error("failed to check security alert responses", "error", err)
+		return false
+	}
+	return required
+}
+
 // RegisterRoutes registers all admin routes
 func (h *AdminHandler) RegisterRoutes(router *gin.RouterGroup) {
 	admin := router.Group("/admin")
@@ -285,16 +1006,40 @@ func (h *AdminHandler) RegisterRoutes(router *gin.RouterGroup) {
 		// User management
 		admin.GET("/users", h.ListUsers)
 		admin.GET("/users/:id", h.GetUserDetails)
+		admin.GET("/users/:id/audit", h.GetUserAuditLogs)
 		admin.PUT("/users/:id", h.UpdateUser)
 		admin.PUT("/users/:id/role", h.UpdateUserRole)
 		admin.PUT("/users/:id/status", h.UpdateUserStatus)
+		admin.POST("/users/:id/email-verification", h.UpdateEmailVerification)
+		admin.POST("/users/:id/resend-verification", h.ResendVerificationEmail)
+		admin.POST("/users/:id/send-reset", h.SendPasswordReset)
+		admin.GET("/users/:id/sessions", h.ListUserSessions)
+		admin.DELETE("/sessions/:id", h.RevokeSession)
 		admin.DELETE("/users", h.DeleteUsers)
 		admin.POST("/users/bulk", h.BulkUpdateUsers)
+		admin.POST("/users/merge", h.MergeUsers)
 
 		// Admin dashboard
 		admin.GET("/stats", h.GetStats)
 		admin.GET("/audit-logs", h.GetAuditLogs)
+		admin.GET("/security/login-stats", h.GetLoginStats)
+		admin.GET("/security/alerts", h.ListSecurityAlerts)
+		admin.POST("/security/alerts/:id/resolve", h.ResolveSecurityAlert)
+
+		// Email queue operations
+		admin.GET("/email/queue", h.GetEmailQueueStats)
+		admin.GET("/email/messages", h.ListQueuedEmails)
+		admin.GET("/email/events", h.ListEmailEvents)
+		admin.POST("/email/process", h.ProcessEmailQueue)
+		admin.DELETE("/email/scheduled/:message_id", h.CancelScheduledEmail)
+		admin.POST("/email/broadcast", h.StartBroadcast)
+		admin.GET("/email/broadcast/:id", h.GetBroadcast)
+
+		// Exports
+		admin.POST("/exports", h.CreateExport)
+		admin.GET("/exports/:id", h.GetExport)
 	}
+	router.GET("/exports/:id/download", h.DownloadExport)
 }
 
 // Helper methods
@@ -344,34 +1089,166 @@ func (h *AdminHandler) parseUserIDs(idsStr string) ([]uint, error) {
 func (h *AdminHandler) handleError(c *gin.Context, err error) {
 	switch err {
 	case domain.ErrNotAuthorized:
-		c.JSON(http.StatusForbidden, authdomain.ErrorResponse{Error: "not authorized for admin operations"})
+		c.JSON(http.StatusForbidden, middleware.CodedError(c, apperrors.CodePermissionDenied, authdomain.ErrorResponse{
+			Error: "not authorized for admin operations",
+		}))
 	case domain.ErrCannotManageSelf:
 		c.JSON(http.StatusForbidden, authdomain.ErrorResponse{Error: "cannot manage own account through admin interface"})
+	case domain.ErrSelfInBulkAction:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+			Error:   "bulk action includes your own account, remove it from the selection",
+			Details: map[string]string{"reason": "self_included"},
+		})
 	case domain.ErrBulkActionFailed:
 		c.JSON(http.StatusInternalServerError, authdomain.ErrorResponse{Error: "bulk action failed"})
 	case domain.ErrAuditLogNotFound:
 		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "audit log not found"})
 	case domain.ErrInvalidDateRange:
 		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid date range"})
+	case domain.ErrInvalidCursor:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+			Error:   "invalid cursor",
+			Details: map[string]string{"reason": "invalid_cursor"},
+		})
+	case domain.ErrCursorFilterMismatch:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+			Error:   "cursor was issued for different filters, start again without it",
+			Details: map[string]string{"reason": "cursor_filter_mismatch"},
+		})
 	case domain.ErrTooManyUsers:
 		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "too many users selected for bulk action"})
+	case domain.ErrInvalidWindow:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid stats window"})
+	case domain.ErrLastAdmin:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{Error: "cannot permanently delete the last admin"})
+	case domain.ErrSuperAdminRequired:
+		c.JSON(http.StatusForbidden, authdomain.ErrorResponse{Error: "action requires a super admin"})
+	case domain.ErrLastSuperAdmin:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{Error: "cannot remove the last super admin"})
+	case domain.ErrLastActiveAdmin:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{Error: "cannot remove the last active admin"})
+	case domain.ErrEmailQueueOffline:
+		c.JSON(http.StatusServiceUnavailable, authdomain.ErrorResponse{Error: "email queue is not available"})
+	case domain.ErrBroadcastNoFilter:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+			Error:   "broadcast requires confirmation",
+			Details: map[string]string{"reason": "no user filter was given; set confirm_all to email every user"},
+		})
+	case domain.ErrTooManyAlertStreams:
+		c.JSON(http.StatusServiceUnavailable, authdomain.ErrorResponse{Error: "too many security alert streams are open"})
+	case domain.ErrAlertNotFound:
+		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "security alert not found"})
+	case domain.ErrActivityPageTooDeep:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+			Error:   "activity feed page is too deep",
+			Details: map[string]string{"reason": fmt.Sprintf("page * page_size may not exceed %d", domain.MaxActivityDepth)},
+		})
+	case domain.ErrUserNotPending:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{Error: "user is not pending approval"})
+	case domain.ErrBroadcastNotFound:
+		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "broadcast not found"})
+	case domain.ErrExportsOffline:
+		c.JSON(http.StatusServiceUnavailable, authdomain.ErrorResponse{Error: "exports are not available"})
+	case domain.ErrExportNotFound:
+		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "export not found"})
+	case domain.ErrExportUserRequired:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+			Error:   "evidence export requires user_id",
+			Details: map[string]string{"user_id": "required for evidence exports"},
+		})
+	case domain.ErrExportLinkInvalid:
+		c.JSON(http.StatusForbidden, authdomain.ErrorResponse{Error: "export download link is invalid"})
+	case domain.ErrExportAccessRevoked:
+		c.JSON(http.StatusForbidden, authdomain.ErrorResponse{
+			Error:   "export requester no longer has access",
+			Details: map[string]string{"reason": "only an active super admin's exports can be downloaded"},
+		})
+	case domain.ErrExportLinkExpired:
+		c.JSON(http.StatusGone, authdomain.ErrorResponse{
+			Error:   "export download link has expired",
+			Details: map[string]string{"reason": "fetch the export again for a new link"},
+		})
+	case domain.ErrMergeSameUser:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "cannot merge a user into itself"})
+	case domain.ErrMergeTargetInactive:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{
+			Error:   "merge target must be an active account",
+			Details: map[string]string{"reason": "reactivate the target, or merge the other way round"},
+		})
+	case domain.ErrMergeRoleConflict:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{
+			Error:   "merge source has a higher role than the target",
+			Details: map[string]string{"reason": "align the roles first so the merge doesn't change anyone's access"},
+		})
+	case domain.ErrOperationBusy:
+		retryAfter := int(h.config.AdminOperationRetryAfterDuration().Seconds())
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, authdomain.ErrorResponse{
+			Error:   "too many operations of this kind are already running",
+			Details: map[string]string{"retry_after": strconv.Itoa(retryAfter)},
+		})
+	case authdomain.ErrRecoveryNotFound:
+		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "recovery request not found"})
+	case authdomain.ErrRecoveryNotPending:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{Error: "recovery request has already been reviewed"})
+	case domain.ErrAccountEmailsOffline:
+		c.JSON(http.StatusServiceUnavailable, authdomain.ErrorResponse{Error: "account emails are not available"})
+	case domain.ErrSessionsOffline:
+		c.JSON(http.StatusServiceUnavailable, authdomain.ErrorResponse{Error: "session management is not available"})
+	case authdomain.ErrTokenNotFound:
+		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "session not found"})
+	case authdomain.ErrEmailAlreadyVerified:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{Error: "email already verified"})
+	case authdomain.ErrEmailDisabled:
+		c.JSON(http.StatusServiceUnavailable, authdomain.ErrorResponse{
+			Error:   "email delivery is disabled",
+			Details: map[string]string{"reason": "email_disabled"},
+		})
+	case authdomain.ErrTooManyVerifyResends:
+		c.JSON(http.StatusTooManyRequests, authdomain.ErrorResponse{
+			Error: "too many verification emails sent to this user, please wait before sending another",
+		})
+	case authdomain.ErrPasswordResetDisabled:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{
+			Error:   "password reset is disabled on this deployment",
+			Details: map[string]string{"reason": "password_reset_disabled"},
+		})
+	case authdomain.ErrTooManyResetRequests:
+		c.JSON(http.StatusTooManyRequests, authdomain.ErrorResponse{Error: "too many password reset requests for this user, please try again later"})
+	case emaildomain.ErrTemplateNotFound:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "email template not found"})
+	case emaildomain.ErrTemplateMissingVariables:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "required template variables are missing"})
+	case emaildomain.ErrEmailNotFound:
+		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "email not found"})
+	case emaildomain.ErrEmailNotCancellable:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{Error: "email can no longer be canceled"})
+	case userdomain.ErrInvalidSort:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+			Error:   "invalid sort",
+			Details: map[string]string{"sort": "expected field:asc or field:desc with a sortable field"},
+		})
 	case userdomain.ErrUserNotFound:
-		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "user not found"})
+		c.JSON(http.StatusNotFound, middleware.CodedError(c, apperrors.CodeUserNotFound, authdomain.ErrorResponse{Error: "user not found"}))
 	case userdomain.ErrEmailAlreadyExists:
-		c.JSON(http.StatusConflict, authdomain.ErrorResponse{Error: "email already exists"})
+		c.JSON(http.StatusConflict, middleware.CodedError(c, apperrors.CodeEmailAlreadyExists, authdomain.ErrorResponse{
+			Error: "email already exists",
+		}))
 	default:
 		h.logger.Error("unhandled admin service error", "error", err)
-		c.JSON(http.StatusInternalServerError, authdomain.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, middleware.CodedError(c, apperrors.CodeInternalError, authdomain.ErrorResponse{
+			Error: "internal server error",
+		}))
 	}
 }
 
 // handleValidationError handles validation errors from request binding
 func (h *AdminHandler) handleValidationError(c *gin.Context, err error) {
 	h.logger.Error("validation error", "error", err)
-	c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+	c.JSON(http.StatusBadRequest, middleware.CodedError(c, apperrors.CodeValidationFailed, authdomain.ErrorResponse{
 		Error:   "validation failed",
 		Details: extractValidationErrors(err),
-	})
+	}))
 }
 
 // extractValidationErrors extracts field-specific validation errors
@@ -0,0 +1,288 @@
+package transport
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/admin/domain"
+	"github.com/acheevo/tfa/internal/admin/service"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// readEvent reads the next server-sent event or comment, without its trailing blank line
+func readEvent(reader *bufio.Reader) ([]string, error) {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return lines, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines, nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestStreamAlertsDeliversToConnectedClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	alerts := make(chan *authdomain.SecurityAlert, 1)
+
+	router := gin.New()
+	router.GET("/stream", func(c *gin.Context) {
+		streamAlerts(c, alerts, 50*time.Millisecond, time.Now().Add(time.Hour), func() error { return nil })
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+
+	// An idle stream is kept alive
+	event, err := readEvent(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{": heartbeat"}, event)
+
+	alerts <- &authdomain.SecurityAlert{ID: "alert-1", Type: "mass_deletion", Severity: "high"}
+	for {
+		event, err = readEvent(reader)
+		if !assert.NoError(t, err) || len(event) == 0 || event[0] != ": heartbeat" {
+			break
+		}
+	}
+	if !assert.Len(t, event, 2) {
+		return
+	}
+	assert.Equal(t, "event:"+securityAlertEvent, event[0])
+	var alert authdomain.SecurityAlert
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(event[1], "data:")), &alert))
+	assert.Equal(t, "alert-1", alert.ID)
+	assert.Equal(t, "mass_deletion", alert.Type)
+
+	// A client that fell behind is told so and the stream ends
+	close(alerts)
+	for {
+		event, err = readEvent(reader)
+		if !assert.NoError(t, err) || len(event) == 0 || event[0] != ": heartbeat" {
+			break
+		}
+	}
+	assert.Equal(t, "event:"+securityAlertLaggedEvent, event[0])
+	_, err = readEvent(reader)
+	assert.Error(t, err)
+}
+
+func TestStreamAlertsEndsWhenAccessEnds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		authorize func() error
+		event     string
+	}{
+		{
+			name:      "access token expires",
+			expiresAt: time.Now().Add(120 * time.Millisecond),
+			authorize: func() error { return nil },
+			event:     securityAlertExpiredEvent,
+		},
+		{
+			name:      "admin loses access",
+			expiresAt: time.Now().Add(time.Hour),
+			authorize: func() error { return errors.New("not authorized") },
+			event:     securityAlertRevokedEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/stream", func(c *gin.Context) {
+				streamAlerts(c, make(chan *authdomain.SecurityAlert), 50*time.Millisecond, tt.expiresAt, tt.authorize)
+			})
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/stream")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+			reader := bufio.NewReader(resp.Body)
+
+			var event []string
+			for {
+				event, err = readEvent(reader)
+				if !assert.NoError(t, err) || len(event) == 0 || event[0] != ": heartbeat" {
+					break
+				}
+			}
+			if !assert.NotEmpty(t, event) {
+				return
+			}
+			assert.Equal(t, "event:"+tt.event, event[0])
+			_, err = readEvent(reader)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// fakeUserRepo looks up the requesting admin; the audit listing uses no other user operation
+type fakeUserRepo struct {
+	service.UserRepo
+	users map[uint]*authdomain.User
+}
+
+func (r *fakeUserRepo) GetByID(id uint) (*authdomain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, userdomain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// fakeAuditRepo lists audit entries newest first, filtered by action, by page or past a cursor position
+type fakeAuditRepo struct {
+	service.AuditRepo
+	logs []*authdomain.AuditLog
+}
+
+func (r *fakeAuditRepo) List(req *domain.AdminAuditLogRequest) ([]*authdomain.AuditLog, userdomain.PageCount, error) {
+	var matched []*authdomain.AuditLog
+	for _, log := range r.logs {
+		if req.Action != "" && log.Action != req.Action {
+			continue
+		}
+		if req.After != nil && !log.CreatedAt.Before(req.After.CreatedAt) &&
+			!(log.CreatedAt.Equal(req.After.CreatedAt) && log.ID < req.After.ID) {
+			continue
+		}
+		matched = append(matched, log)
+	}
+
+	if req.After != nil {
+		page, hasNext := userdomain.TrimPage(matched, req.PageSize)
+		return page, userdomain.PageCount{Mode: userdomain.CountNone, HasNext: hasNext}, nil
+	}
+	start := min((req.Page-1)*req.PageSize, len(matched))
+	end := min(start+req.PageSize, len(matched))
+	return matched[start:end], userdomain.ExactPageCount(req.Page, req.PageSize, len(matched)), nil
+}
+
+func TestGetAuditLogsCursorPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Entries 3 and 4 share a timestamp, so the cursor must break the tie by ID
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	audit := &fakeAuditRepo{}
+	for _, entry := range []struct {
+		id  uint
+		age time.Duration
+	}{{6, 0}, {5, time.Minute}, {4, 2 * time.Minute}, {3, 2 * time.Minute}, {2, 3 * time.Minute}, {1, 4 * time.Minute}} {
+		action := authdomain.AuditActionLoginSuccess
+		if entry.id == 5 {
+			action = authdomain.AuditActionLoginFailed
+		}
+		audit.logs = append(audit.logs, &authdomain.AuditLog{ID: entry.id, Action: action, CreatedAt: now.Add(-entry.age)})
+	}
+	users := &fakeUserRepo{users: map[uint]*authdomain.User{
+		1: {ID: 1, Role: authdomain.RoleAdmin, Status: authdomain.StatusActive},
+	}}
+
+	cfg := &config.Config{JWTSecret: "test-secret-that-is-long-enough-for-signing", ListCountMode: "exact"}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	adminService := service.NewAdminService(cfg, logger, users, audit,
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, clock.New())
+	h := NewAdminHandler(cfg, logger, adminService)
+
+	router := gin.New()
+	router.GET("/audit-logs", func(c *gin.Context) { c.Set("user_id", uint(1)) }, h.GetAuditLogs)
+
+	list := func(query url.Values) (*httptest.ResponseRecorder, *domain.AdminAuditLogResponse) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit-logs?"+query.Encode(), nil))
+		var response domain.AdminAuditLogResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w, &response
+	}
+	errorReason := func(w *httptest.ResponseRecorder) string {
+		var response authdomain.ErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Details["reason"]
+	}
+
+	t.Run("walks every page once", func(t *testing.T) {
+		var ids []uint
+		query := url.Values{"action": {"login_success"}, "page_size": {"2"}}
+		for pages := 0; pages < 5; pages++ {
+			w, response := list(query)
+			if !assert.Equal(t, http.StatusOK, w.Code) {
+				return
+			}
+			for _, log := range response.Logs {
+				ids = append(ids, log.ID)
+			}
+			if response.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", response.NextCursor)
+		}
+		assert.Equal(t, []uint{6, 4, 3, 2, 1}, ids)
+	})
+
+	t.Run("cursor bound to its filters", func(t *testing.T) {
+		_, first := list(url.Values{"action": {"login_success"}, "page_size": {"2"}})
+		if !assert.NotEmpty(t, first.NextCursor) {
+			return
+		}
+
+		w, _ := list(url.Values{"action": {"login_failed"}, "page_size": {"2"}, "cursor": {first.NextCursor}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "cursor_filter_mismatch", errorReason(w))
+
+		w, _ = list(url.Values{"action": {"login_success"}, "sort": {"level:asc"}, "cursor": {first.NextCursor}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "cursor_filter_mismatch", errorReason(w))
+	})
+
+	t.Run("altered cursor", func(t *testing.T) {
+		_, first := list(url.Values{"page_size": {"2"}})
+		if !assert.NotEmpty(t, first.NextCursor) {
+			return
+		}
+
+		w, _ := list(url.Values{"page_size": {"2"}, "cursor": {"x" + first.NextCursor}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid_cursor", errorReason(w))
+	})
+
+	t.Run("no cursor for other sorts", func(t *testing.T) {
+		w, response := list(url.Values{"page_size": {"2"}, "sort": {"level:asc"}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, response.Pagination.HasNext)
+		assert.Empty(t, response.NextCursor)
+	})
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const (
+	// MetadataTruncatedKey marks audit metadata that was cut down to fit the size limit
+	MetadataTruncatedKey = "metadata_truncated"
+	// MetadataOriginalBytesKey records the serialized size of truncated metadata before truncation
+	MetadataOriginalBytesKey = "metadata_original_bytes"
+	// MetadataOmittedKey lists the nested fields dropped from truncated metadata
+	MetadataOmittedKey = "metadata_omitted"
+
+	// maxTruncatedStringLen caps string values kept in truncated metadata
+	maxTruncatedStringLen = 256
+)
+
+// essentialMetadataKeys are the fields kept when even the scalar metadata is too large
+var essentialMetadataKeys = []string{
+	"email", "target_email", "old_role", "new_role", "old_status", "new_status",
+	"reason", "risk_level", "status", "delete_type", "source_id", "target_id",
+}
+
+// FitMetadata returns metadata that serializes within the policy's size limit, together with the
+// original serialized size and whether it was truncated. Oversized metadata keeps its scalar values,
+// with long strings shortened, and drops nested values; if that is still too large only the essential
+// keys remain. Truncated metadata is marked so readers know fields are missing.
+func (p *AuditPolicy) FitMetadata(metadata map[string]interface{}) (map[string]interface{}, int, bool) {
+	if p == nil || p.maxMetadataBytes <= 0 || len(metadata) == 0 {
+		return metadata, 0, false
+	}
+
+	size := metadataSize(metadata)
+	if size <= p.maxMetadataBytes {
+		return metadata, size, false
+	}
+
+	scalars := make(map[string]interface{}, len(metadata))
+	var omitted []string
+	for key, value := range metadata {
+		if fitted, ok := truncatedScalar(value); ok {
+			scalars[key] = fitted
+		} else {
+			omitted = append(omitted, key)
+		}
+	}
+	sort.Strings(omitted)
+
+	fitted := markTruncated(scalars, size)
+	fitted[MetadataOmittedKey] = omitted
+	if metadataSize(fitted) <= p.maxMetadataBytes {
+		return fitted, size, true
+	}
+
+	essential := make(map[string]interface{}, len(essentialMetadataKeys))
+	for _, key := range essentialMetadataKeys {
+		if value, ok := scalars[key]; ok {
+			essential[key] = value
+		}
+	}
+	fitted = markTruncated(essential, size)
+	if metadataSize(fitted) <= p.maxMetadataBytes {
+		return fitted, size, true
+	}
+
+	return markTruncated(map[string]interface{}{}, size), size, true
+}
+
+// markTruncated adds the truncation markers to metadata
+func markTruncated(metadata map[string]interface{}, originalSize int) map[string]interface{} {
+	metadata[MetadataTruncatedKey] = true
+	metadata[MetadataOriginalBytesKey] = originalSize
+	return metadata
+}
+
+// truncatedScalar returns a scalar value, shortening long strings, or false for nested values
+func truncatedScalar(value interface{}) (interface{}, bool) {
+	if value == nil {
+		return nil, true
+	}
+	if _, ok := value.(time.Time); ok {
+		return value, true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		if s := v.String(); len(s) > maxTruncatedStringLen {
+			return s[:maxTruncatedStringLen] + "...", true
+		}
+		return value, true
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return value, true
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, true
+		}
+		return truncatedScalar(v.Elem().Interface())
+	default:
+		return nil, false
+	}
+}
+
+// metadataSize returns the serialized size of metadata, or 0 if it cannot be serialized
+func metadataSize(metadata map[string]interface{}) int {
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return 0
+	}
+	return len(encoded)
+}
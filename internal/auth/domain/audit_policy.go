@@ -0,0 +1,82 @@
+package domain
+
+import "strings"
+
+// securityAuditActions are always recorded, regardless of audit policy, to avoid compliance gaps
+var securityAuditActions = map[AuditAction]bool{
+	AuditActionLoginSuccess:       true,
+	AuditActionLoginFailed:        true,
+	AuditActionUserRoleChanged:    true,
+	AuditActionUserStatusChanged:  true,
+	AuditActionUserDeleted:        true,
+	AuditActionPasswordChanged:    true,
+	AuditActionPasswordCreated:    true,
+	AuditActionPasswordResetReq:   true,
+	AuditActionPasswordResetUsed:  true,
+	AuditActionEmailUnverified:    true,
+	AuditActionSessionsRevoked:    true,
+	AuditActionAdminPasswordReset: true,
+	AuditActionUsersMerged:        true,
+	AuditActionUserApproved:       true,
+	AuditActionSuperAdminAction:   true,
+	AuditActionAdminAccess:        true,
+	AuditActionStepUpVerified:     true,
+	AuditActionStepUpFailed:       true,
+	AuditActionAlertResolved:      true,
+	AuditActionRecoveryRequested:  true,
+	AuditActionRecoveryApproved:   true,
+	AuditActionRecoveryRejected:   true,
+	AuditActionBackupEmailChanged: true,
+	AuditActionEvidenceExported:   true,
+	AuditActionExportRequested:    true,
+	AuditActionExportDownloaded:   true,
+}
+
+// IsSecurityAuditAction reports whether an action is security relevant and cannot be excluded
+func IsSecurityAuditAction(action AuditAction) bool {
+	return securityAuditActions[action]
+}
+
+// AuditPolicy decides which audit actions are persisted. With no include list every action is
+// recorded; the exclude list then removes actions. Security actions and error-level entries are
+// always recorded. Metadata larger than maxMetadataBytes is cut down before storage.
+type AuditPolicy struct {
+	include          map[AuditAction]bool
+	exclude          map[AuditAction]bool
+	maxMetadataBytes int
+}
+
+// NewAuditPolicy creates an audit policy from include and exclude action lists and the largest
+// serialized metadata size to store (0 stores metadata of any size)
+func NewAuditPolicy(include, exclude []string, maxMetadataBytes int) *AuditPolicy {
+	return &AuditPolicy{
+		include:          toActionSet(include),
+		exclude:          toActionSet(exclude),
+		maxMetadataBytes: maxMetadataBytes,
+	}
+}
+
+// ShouldRecord reports whether an audit entry with the given action and level should be persisted
+func (p *AuditPolicy) ShouldRecord(action AuditAction, level AuditLevel) bool {
+	if p == nil || IsSecurityAuditAction(action) || level == AuditLevelError {
+		return true
+	}
+
+	if len(p.include) > 0 && !p.include[action] {
+		return false
+	}
+
+	return !p.exclude[action]
+}
+
+// toActionSet converts a list of action names to a set, ignoring blanks
+func toActionSet(actions []string) map[AuditAction]bool {
+	set := make(map[AuditAction]bool, len(actions))
+	for _, action := range actions {
+		action = strings.TrimSpace(action)
+		if action != "" {
+			set[AuditAction(action)] = true
+		}
+	}
+	return set
+}
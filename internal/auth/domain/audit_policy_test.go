@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditPolicyDefaultRecordsEverything(t *testing.T) {
+	policy := NewAuditPolicy(nil, nil, 0)
+
+	assert.True(t, policy.ShouldRecord(AuditActionPreferencesUpdated, AuditLevelInfo))
+	assert.True(t, policy.ShouldRecord(AuditActionUserUpdated, AuditLevelInfo))
+
+	var nilPolicy *AuditPolicy
+	assert.True(t, nilPolicy.ShouldRecord(AuditActionPreferencesUpdated, AuditLevelInfo))
+}
+
+func TestAuditPolicyExclude(t *testing.T) {
+	policy := NewAuditPolicy(nil, []string{"preferences_updated", " user_updated ", "login_failed"}, 0)
+
+	assert.False(t, policy.ShouldRecord(AuditActionPreferencesUpdated, AuditLevelInfo))
+	assert.False(t, policy.ShouldRecord(AuditActionUserUpdated, AuditLevelInfo))
+	assert.True(t, policy.ShouldRecord(AuditActionEmailVerified, AuditLevelInfo))
+
+	// Security actions cannot be excluded
+	assert.True(t, policy.ShouldRecord(AuditActionLoginFailed, AuditLevelWarning))
+
+	// Error-level entries are always kept
+	assert.True(t, policy.ShouldRecord(AuditActionUserUpdated, AuditLevelError))
+}
+
+func TestAuditPolicyInclude(t *testing.T) {
+	policy := NewAuditPolicy([]string{"user_created"}, nil, 0)
+
+	assert.True(t, policy.ShouldRecord(AuditActionUserCreated, AuditLevelInfo))
+	assert.False(t, policy.ShouldRecord(AuditActionPreferencesUpdated, AuditLevelInfo))
+
+	// Security actions are recorded even when not in the include list
+	assert.True(t, policy.ShouldRecord(AuditActionUserRoleChanged, AuditLevelWarning))
+	assert.True(t, policy.ShouldRecord(AuditActionPasswordResetUsed, AuditLevelInfo))
+}
+
+func TestAuditPolicySuperAdminActionsAlwaysRecorded(t *testing.T) {
+	actions := []string{"super_admin_action", "step_up_verified", "step_up_failed"}
+
+	excluded := NewAuditPolicy(nil, actions, 0)
+	included := NewAuditPolicy([]string{"user_created"}, nil, 0)
+	for _, action := range actions {
+		assert.True(t, excluded.ShouldRecord(AuditAction(action), AuditLevelWarning), action)
+		assert.True(t, included.ShouldRecord(AuditAction(action), AuditLevelWarning), action)
+	}
+}
+
+func TestAuditPolicyRecoveryActionsAlwaysRecorded(t *testing.T) {
+	policy := NewAuditPolicy(nil, []string{
+		"account_recovery_requested", "account_recovery_approved", "account_recovery_rejected", "backup_email_changed",
+	}, 0)
+
+	assert.True(t, policy.ShouldRecord(AuditActionRecoveryRequested, AuditLevelInfo))
+	assert.True(t, policy.ShouldRecord(AuditActionRecoveryApproved, AuditLevelWarning))
+	assert.True(t, policy.ShouldRecord(AuditActionRecoveryRejected, AuditLevelWarning))
+	assert.True(t, policy.ShouldRecord(AuditActionBackupEmailChanged, AuditLevelWarning))
+}
+
+func TestAuditPolicyExportActionsAlwaysRecorded(t *testing.T) {
+	policy := NewAuditPolicy([]string{"user_created"}, []string{
+		"user_evidence_exported", "data_export_requested", "data_export_downloaded",
+	}, 0)
+
+	assert.True(t, policy.ShouldRecord(AuditActionEvidenceExported, AuditLevelWarning))
+	assert.True(t, policy.ShouldRecord(AuditActionExportRequested, AuditLevelWarning))
+	assert.True(t, policy.ShouldRecord(AuditActionExportDownloaded, AuditLevelWarning))
+}
+
+func TestAuditPolicyPasswordCreatedAlwaysRecorded(t *testing.T) {
+	policy := NewAuditPolicy([]string{"user_created"}, []string{"password_created"}, 0)
+
+	assert.True(t, policy.ShouldRecord(AuditActionPasswordCreated, AuditLevelInfo))
+}
+
+func TestAuditPolicyAlertResolvedAlwaysRecorded(t *testing.T) {
+	policy := NewAuditPolicy([]string{"user_created"}, []string{"security_alert_resolved"}, 0)
+
+	assert.True(t, policy.ShouldRecord(AuditActionAlertResolved, AuditLevelInfo))
+}
+
+func TestAuditPolicyAdminAccessAlwaysRecorded(t *testing.T) {
+	policy := NewAuditPolicy([]string{"user_created"}, []string{"admin_access"}, 0)
+
+	assert.True(t, policy.ShouldRecord(AuditActionAdminAccess, AuditLevelInfo))
+	assert.True(t, policy.ShouldRecord(AuditActionAdminAccess, AuditLevelWarning))
+}
+
+func TestAuditPolicyFitMetadata(t *testing.T) {
+	policy := NewAuditPolicy(nil, nil, 512)
+
+	small := map[string]interface{}{"reason": "promotion", "old_role": RoleUser}
+	fitted, _, truncated := policy.FitMetadata(small)
+	assert.False(t, truncated)
+	assert.Equal(t, small, fitted)
+
+	// A role change carries whole validation results; those are dropped but the key fields survive
+	oversized := map[string]interface{}{
+		"old_role":          RoleUser,
+		"new_role":          RoleAdmin,
+		"reason":            strings.Repeat("r", 300),
+		"validation_result": map[string]interface{}{"warnings": []string{strings.Repeat("w", 1000)}},
+		"audit_entry":       &RoleChangeAuditEntry{Reason: strings.Repeat("a", 1000)},
+	}
+	fitted, size, truncated := policy.FitMetadata(oversized)
+	assert.True(t, truncated)
+	assert.Greater(t, size, 512)
+	assert.Equal(t, RoleUser, fitted["old_role"])
+	assert.Equal(t, RoleAdmin, fitted["new_role"])
+	assert.Len(t, fitted["reason"], 259)
+	assert.Equal(t, []string{"audit_entry", "validation_result"}, fitted[MetadataOmittedKey])
+	assert.Equal(t, true, fitted[MetadataTruncatedKey])
+	assert.Equal(t, size, fitted[MetadataOriginalBytesKey])
+	assert.LessOrEqual(t, metadataSize(fitted), 512)
+
+	// When even the scalars are too large only the essential keys are kept
+	wide := map[string]interface{}{"email": "user@example.com", "new_status": StatusSuspended}
+	for i := 0; i < 50; i++ {
+		wide[fmt.Sprintf("field_%d", i)] = "some value"
+	}
+	fitted, _, truncated = policy.FitMetadata(wide)
+	assert.True(t, truncated)
+	assert.Equal(t, "user@example.com", fitted["email"])
+	assert.Equal(t, StatusSuspended, fitted["new_status"])
+	assert.NotContains(t, fitted, "field_0")
+	assert.LessOrEqual(t, metadataSize(fitted), 512)
+
+	// No limit, or no policy, keeps metadata as it is
+	fitted, _, truncated = NewAuditPolicy(nil, nil, 0).FitMetadata(oversized)
+	assert.False(t, truncated)
+	assert.Equal(t, oversized, fitted)
+	var nilPolicy *AuditPolicy
+	_, _, truncated = nilPolicy.FitMetadata(oversized)
+	assert.False(t, truncated)
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// customPreferencePrefix selects a key of the user's custom preferences as a claim, e.g. "custom.plan"
+const customPreferencePrefix = "custom."
+
+// ClaimEnricher returns the extra claims embedded in a user's access token
+type ClaimEnricher func(user *User) (map[string]any, error)
+
+// userClaimSources are the user attributes that may be configured as custom claims
+var userClaimSources = map[string]func(user *User) any{
+	"first_name":     func(u *User) any { return u.FirstName },
+	"last_name":      func(u *User) any { return u.LastName },
+	"email_verified": func(u *User) any { return u.EmailVerified },
+	"status":         func(u *User) any { return u.Status },
+	"language":       func(u *User) any { return u.Preferences.Language },
+	"timezone":       func(u *User) any { return u.Preferences.Timezone },
+	"theme":          func(u *User) any { return u.Preferences.Theme },
+}
+
+// sensitiveClaimFragments mark claim names that must never be copied into a token
+var sensitiveClaimFragments = []string{"password", "passwd", "secret", "token", "hash", "apikey", "api_key", "private"}
+
+// NewUserClaimEnricher creates an enricher copying the named user attributes into access tokens.
+// Names are user attributes (see userClaimSources) or "custom.<key>" for a custom preference; unknown
+// or sensitive names are rejected. With no names it returns nil, meaning no custom claims.
+func NewUserClaimEnricher(names []string) (ClaimEnricher, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	for _, name := range names {
+		if isSensitiveClaim(name) {
+			return nil, fmt.Errorf("%w: %s", ErrSensitiveClaim, name)
+		}
+		if _, ok := userClaimSources[name]; !ok && !isCustomPreferenceClaim(name) {
+			return nil, fmt.Errorf("unknown custom claim %q", name)
+		}
+	}
+
+	return func(user *User) (map[string]any, error) {
+		claims := make(map[string]any, len(names))
+		for _, name := range names {
+			if source, ok := userClaimSources[name]; ok {
+				claims[name] = source(user)
+				continue
+			}
+			key := strings.TrimPrefix(name, customPreferencePrefix)
+			if value, ok := user.Preferences.Custom[key]; ok {
+				claims[key] = value
+			}
+		}
+		return claims, nil
+	}, nil
+}
+
+// ValidateCustomClaims checks that custom claims hold no sensitive data, at any depth, and that
+// their encoded size stays within maxBytes
+func ValidateCustomClaims(user *User, claims map[string]any, maxBytes int) error {
+	if err := checkClaimValues(user, claims); err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(claims)
+	if err != nil {
+		return fmt.Errorf("failed to encode custom claims: %w", err)
+	}
+	if len(encoded) > maxBytes {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrCustomClaimsTooLarge, len(encoded), maxBytes)
+	}
+	return nil
+}
+
+// checkClaimValues walks nested claim maps rejecting sensitive names and the user's secrets
+func checkClaimValues(user *User, claims map[string]any) error {
+	for name, value := range claims {
+		if isSensitiveClaim(name) {
+			return fmt.Errorf("%w: %s", ErrSensitiveClaim, name)
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			if err := checkClaimValues(user, v); err != nil {
+				return err
+			}
+		case string:
+			if v != "" && (v == user.PasswordHash || v == user.PreviousPasswordHash || v == user.EmailVerifyToken) {
+				return fmt.Errorf("%w: %s holds a credential", ErrSensitiveClaim, name)
+			}
+		}
+	}
+	return nil
+}
+
+// isSensitiveClaim reports whether a claim name looks like it carries a credential
+func isSensitiveClaim(name string) bool {
+	name = strings.ToLower(name)
+	for _, fragment := range sensitiveClaimFragments {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCustomPreferenceClaim reports whether a claim name selects a custom preference key
+func isCustomPreferenceClaim(name string) bool {
+	return strings.HasPrefix(name, customPreferencePrefix) && len(name) > len(customPreferencePrefix)
+}
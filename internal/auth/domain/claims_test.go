@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUserClaimEnricher(t *testing.T) {
+	enricher, err := NewUserClaimEnricher(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, enricher)
+
+	_, err = NewUserClaimEnricher([]string{"first_name", "favorite_color"})
+	assert.Error(t, err)
+
+	_, err = NewUserClaimEnricher([]string{"custom.api_key"})
+	assert.ErrorIs(t, err, ErrSensitiveClaim)
+
+	enricher, err = NewUserClaimEnricher([]string{"status", "language", "custom.plan", "custom.org"})
+	assert.NoError(t, err)
+
+	claims, err := enricher(&User{
+		Status:      StatusActive,
+		Preferences: UserPreferences{Language: "en", Custom: map[string]any{"plan": "pro", "theme_color": "red"}},
+	})
+	assert.NoError(t, err)
+	// Missing custom preferences are left out
+	assert.Equal(t, map[string]any{"status": StatusActive, "language": "en", "plan": "pro"}, claims)
+}
+
+func TestValidateCustomClaims(t *testing.T) {
+	user := &User{PasswordHash: "$2a$10$abcdef", EmailVerifyToken: "verify-token"}
+
+	assert.NoError(t, ValidateCustomClaims(user, map[string]any{"plan": "pro", "flags": map[string]any{"beta": true}}, 1024))
+	assert.NoError(t, ValidateCustomClaims(user, nil, 1024))
+
+	assert.ErrorIs(t, ValidateCustomClaims(user, map[string]any{"Session_Token": "x"}, 1024), ErrSensitiveClaim)
+	assert.ErrorIs(t, ValidateCustomClaims(user, map[string]any{"org": map[string]any{"secret": "x"}}, 1024), ErrSensitiveClaim)
+	assert.ErrorIs(t, ValidateCustomClaims(user, map[string]any{"note": "$2a$10$abcdef"}, 1024), ErrSensitiveClaim)
+	assert.ErrorIs(t, ValidateCustomClaims(user, map[string]any{"note": "verify-token"}, 1024), ErrSensitiveClaim)
+
+	assert.ErrorIs(t, ValidateCustomClaims(user, map[string]any{"bio": strings.Repeat("a", 100)}, 64), ErrCustomClaimsTooLarge)
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// DeviceIDHeader lets clients name the device they sign in from, so a new login from the same
+// device can replace that device's session instead of adding another one
+const DeviceIDHeader = "X-Device-ID"
+
+// DeviceKey identifies the device a session was created from: the client's device id when it sent
+// one, otherwise a fingerprint of the IP address and user agent. Both are hashed so the stored key
+// has a fixed length. It is empty when there is nothing to identify the device by.
+func DeviceKey(deviceID, ipAddress, userAgent string) string {
+	if id := strings.TrimSpace(deviceID); id != "" {
+		return "device:" + hashHex(id)
+	}
+	if ipAddress == "" && userAgent == "" {
+		return ""
+	}
+	return "fingerprint:" + hashHex(ipAddress+"\n"+userAgent)
+}
+
+func hashHex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
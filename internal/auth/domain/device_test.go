@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceKey(t *testing.T) {
+	withID := DeviceKey("laptop-1", "203.0.113.7", "Firefox")
+	assert.True(t, strings.HasPrefix(withID, "device:"))
+	assert.Equal(t, withID, DeviceKey(" laptop-1 ", "198.51.100.2", "Chrome"), "the device id wins over IP and user agent")
+	assert.NotEqual(t, withID, DeviceKey("laptop-2", "203.0.113.7", "Firefox"))
+
+	fingerprint := DeviceKey("", "203.0.113.7", "Firefox")
+	assert.True(t, strings.HasPrefix(fingerprint, "fingerprint:"))
+	assert.Equal(t, fingerprint, DeviceKey("  ", "203.0.113.7", "Firefox"))
+	assert.NotEqual(t, fingerprint, DeviceKey("", "203.0.113.7", "Chrome"))
+	assert.NotEqual(t, fingerprint, DeviceKey("", "198.51.100.2", "Firefox"))
+
+	assert.Empty(t, DeviceKey("", "", ""))
+	assert.LessOrEqual(t, len(DeviceKey(strings.Repeat("x", 1000), "", "")), 80)
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed disposable_domains.txt
+var disposableDomainList string
+
+// disposableDomains is the embedded list of disposable email providers
+var disposableDomains = parseDomainList(disposableDomainList)
+
+// EmailDomainPolicy decides which email domains may be used to sign up. Entries match the domain
+// and its subdomains; a "*.example.com" entry matches only subdomains of example.com.
+type EmailDomainPolicy struct {
+	Allowed         []string
+	Blocked         []string
+	BlockDisposable bool
+}
+
+// Check returns an error if the email's domain is blocked, disposable (when blocked), or missing
+// from a non-empty allowlist
+func (p *EmailDomainPolicy) Check(email string) error {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ErrInvalidEmail
+	}
+	emailDomain := strings.TrimSuffix(NormalizeEmail(email[at+1:]), ".")
+
+	if matchesAnyDomain(emailDomain, p.Blocked) {
+		return ErrEmailDomainBlocked
+	}
+	if p.BlockDisposable && IsDisposableDomain(emailDomain) {
+		return ErrDisposableEmail
+	}
+	if len(p.Allowed) > 0 && !matchesAnyDomain(emailDomain, p.Allowed) {
+		return ErrEmailDomainNotAllowed
+	}
+	return nil
+}
+
+// IsDisposableDomain reports whether a domain belongs to a known disposable email provider
+func IsDisposableDomain(emailDomain string) bool {
+	return matchesAnyDomain(strings.ToLower(emailDomain), disposableDomains)
+}
+
+// matchesAnyDomain reports whether the domain matches any of the patterns
+func matchesAnyDomain(emailDomain string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchesDomain(emailDomain, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDomain matches a domain against "example.com" (the domain and its subdomains)
+// or "*.example.com" (subdomains only)
+func matchesDomain(emailDomain, pattern string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(emailDomain, "."+suffix)
+	}
+	return emailDomain == pattern || strings.HasSuffix(emailDomain, "."+pattern)
+}
+
+// parseDomainList reads one domain per line, skipping blank lines and # comments
+func parseDomainList(list string) []string {
+	var domains []string
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	return domains
+}
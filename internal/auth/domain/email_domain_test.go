@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailDomainPolicyAllowlist(t *testing.T) {
+	policy := &EmailDomainPolicy{Allowed: []string{"acme.com", "*.partner.io"}}
+
+	assert.NoError(t, policy.Check("jane@acme.com"))
+	assert.NoError(t, policy.Check("Jane@ACME.com"))
+	assert.NoError(t, policy.Check("jane@eu.acme.com"))
+	assert.NoError(t, policy.Check("jane@mail.partner.io"))
+	assert.Equal(t, ErrEmailDomainNotAllowed, policy.Check("jane@partner.io"))
+	assert.Equal(t, ErrEmailDomainNotAllowed, policy.Check("jane@gmail.com"))
+	assert.Equal(t, ErrEmailDomainNotAllowed, policy.Check("jane@notacme.com"))
+}
+
+func TestEmailDomainPolicyBlocklist(t *testing.T) {
+	policy := &EmailDomainPolicy{Blocked: []string{"competitor.com"}}
+
+	assert.NoError(t, policy.Check("jane@gmail.com"))
+	assert.Equal(t, ErrEmailDomainBlocked, policy.Check("jane@competitor.com"))
+	assert.Equal(t, ErrEmailDomainBlocked, policy.Check("jane@sales.competitor.com"))
+
+	// The blocklist wins over the allowlist
+	policy.Allowed = []string{"competitor.com"}
+	assert.Equal(t, ErrEmailDomainBlocked, policy.Check("jane@competitor.com"))
+}
+
+func TestEmailDomainPolicyDisposable(t *testing.T) {
+	policy := &EmailDomainPolicy{}
+	assert.NoError(t, policy.Check("jane@mailinator.com"))
+
+	policy.BlockDisposable = true
+	assert.Equal(t, ErrDisposableEmail, policy.Check("jane@mailinator.com"))
+	assert.Equal(t, ErrDisposableEmail, policy.Check("jane@YOPMAIL.com"))
+	assert.NoError(t, policy.Check("jane@gmail.com"))
+
+	assert.True(t, IsDisposableDomain("guerrillamail.com"))
+	assert.False(t, IsDisposableDomain("acme.com"))
+}
+
+func TestEmailDomainPolicyEmpty(t *testing.T) {
+	policy := &EmailDomainPolicy{}
+	assert.NoError(t, policy.Check("jane@anything.dev"))
+	assert.Equal(t, ErrInvalidEmail, policy.Check("not-an-email"))
+}
@@ -9,8 +9,11 @@ var (
 	ErrUserAlreadyExists       = errors.New("user already exists")
 	ErrEmailNotVerified        = errors.New("email not verified")
 	ErrUserInactive            = errors.New("user account is inactive")
+	ErrUserSuspended           = errors.New("user account is suspended")
+	ErrUserPendingApproval     = errors.New("user account is pending approval")
 	ErrInvalidToken            = errors.New("invalid token")
 	ErrTokenExpired            = errors.New("token expired")
+	ErrSessionMaxAgeExceeded   = errors.New("session has reached its maximum age")
 	ErrTokenNotFound           = errors.New("token not found")
 	ErrTokenAlreadyUsed        = errors.New("token already used")
 	ErrPasswordsDoNotMatch     = errors.New("passwords do not match")
@@ -20,6 +23,30 @@ var (
 	ErrPasswordResetFailed     = errors.New("password reset failed")
 	ErrUnauthorized            = errors.New("unauthorized")
 	ErrForbidden               = errors.New("forbidden")
+	ErrStepUpRequired          = errors.New("step-up authentication required")
+	ErrEmailAlreadyVerified    = errors.New("email already verified")
+	ErrTooManyResetRequests    = errors.New("too many password reset requests, please try again later")
+	ErrSensitiveClaim          = errors.New("custom claim would embed sensitive data")
+	ErrCustomClaimsTooLarge    = errors.New("custom claims are too large")
+	ErrTooManyVerifyResends    = errors.New("too many verification emails requested, please wait before trying again")
+	ErrEmailDisabled           = errors.New("email delivery is disabled")
+	ErrEmailDomainNotAllowed   = errors.New("email domain is not allowed")
+	ErrEmailDomainBlocked      = errors.New("email domain is blocked")
+	ErrDisposableEmail         = errors.New("disposable email addresses are not allowed")
+	ErrRecoveryUnavailable     = errors.New("account recovery method is not available")
+	ErrRecoveryNotFound        = errors.New("recovery request not found")
+	ErrRecoveryNotPending      = errors.New("recovery request has already been reviewed")
+	ErrBackupEmailIsPrimary    = errors.New("backup email must differ from the account email")
+	ErrPasswordChangeRequired  = errors.New("password change required")
+	ErrCaptchaRequired         = errors.New("captcha required")
+	ErrCaptchaInvalid          = errors.New("captcha token is invalid")
+	ErrCaptchaUnavailable      = errors.New("captcha verification is unavailable")
+	ErrNoPasswordToReset       = errors.New("account has no password to reset")
+	ErrPasswordConvertRequired = errors.New("adding a password to this account must be confirmed")
+	ErrPasswordResetDisabled   = errors.New("password reset is disabled")
+	ErrUsernameInvalid         = errors.New("invalid username")
+	ErrUsernameReserved        = errors.New("username is reserved")
+	ErrUsernameTaken           = errors.New("username is already taken")
 )
 
 // IsValidationError checks if the error is a validation error
@@ -28,7 +55,22 @@ func IsValidationError(err error) bool {
 		err == ErrUserAlreadyExists ||
 		err == ErrPasswordsDoNotMatch ||
 		err == ErrWeakPassword ||
-		err == ErrInvalidEmail
+		err == ErrInvalidEmail ||
+		IsEmailDomainError(err)
+}
+
+// IsEmailDomainError checks if the error is a rejection by the email domain policy
+func IsEmailDomainError(err error) bool {
+	return err == ErrEmailDomainNotAllowed ||
+		err == ErrEmailDomainBlocked ||
+		err == ErrDisposableEmail
+}
+
+// IsUsernameError checks if the error is a rejection of a chosen username
+func IsUsernameError(err error) bool {
+	return errors.Is(err, ErrUsernameInvalid) ||
+		err == ErrUsernameReserved ||
+		err == ErrUsernameTaken
 }
 
 // IsAuthError checks if the error is an authentication error
@@ -36,6 +78,8 @@ func IsAuthError(err error) bool {
 	return err == ErrInvalidCredentials ||
 		err == ErrEmailNotVerified ||
 		err == ErrUserInactive ||
+		err == ErrUserSuspended ||
+		err == ErrUserPendingApproval ||
 		err == ErrUnauthorized ||
 		err == ErrForbidden
 }
@@ -44,6 +88,7 @@ func IsAuthError(err error) bool {
 func IsTokenError(err error) bool {
 	return err == ErrInvalidToken ||
 		err == ErrTokenExpired ||
+		err == ErrSessionMaxAgeExceeded ||
 		err == ErrTokenNotFound ||
 		err == ErrTokenAlreadyUsed
 }
@@ -16,12 +16,13 @@ type Action string
 
 // Define system resources
 const (
-	ResourceUser    Resource = "user"
-	ResourceAdmin   Resource = "admin"
-	ResourceProfile Resource = "profile"
-	ResourceAuth    Resource = "auth"
-	ResourceAudit   Resource = "audit"
-	ResourceSystem  Resource = "system"
+	ResourceUser     Resource = "user"
+	ResourceAdmin    Resource = "admin"
+	ResourceProfile  Resource = "profile"
+	ResourceAuth     Resource = "auth"
+	ResourceAudit    Resource = "audit"
+	ResourceSystem   Resource = "system"
+	ResourceSecurity Resource = "security"
 )
 
 // Define actions
@@ -67,6 +68,10 @@ const (
 	PermissionSystemRead   Permission = "system:read"
 	PermissionSystemWrite  Permission = "system:write"
 	PermissionSystemManage Permission = "system:manage"
+
+	// Security permissions
+	PermissionSecurityRead   Permission = "security:read"
+	PermissionSecurityManage Permission = "security:manage"
 )
 
 // RolePermissions defines permissions for each role
@@ -98,9 +103,54 @@ var RolePermissions = map[UserRole][]Permission{
 		PermissionAuditRead,
 		PermissionAuditWrite,
 		PermissionSystemRead,
+		PermissionSecurityRead,
+	},
+	RoleSuperAdmin: {
+		// Super admins have all admin permissions
+		PermissionProfileRead,
+		PermissionProfileUpdate,
+		PermissionAuthRead,
+		PermissionAuthWrite,
+		PermissionUserRead,
+		PermissionUserWrite,
+		PermissionUserCreate,
+		PermissionUserUpdate,
+		PermissionUserDelete,
+		PermissionUserManage,
+		PermissionAdminRead,
+		PermissionAdminWrite,
+		PermissionAdminManage,
+		PermissionAuditRead,
+		PermissionAuditWrite,
+		PermissionSystemRead,
+		PermissionSecurityRead,
+		// Plus the break-glass permissions no other role holds
+		PermissionAuthManage,
+		PermissionAuditManage,
+		PermissionSystemWrite,
+		PermissionSystemManage,
+		PermissionSecurityManage,
 	},
 }
 
+// roleLevels ranks roles from least to most privileged
+var roleLevels = map[UserRole]int{
+	RoleUser:       1,
+	RoleAdmin:      2,
+	RoleSuperAdmin: 3,
+}
+
+// RoleLevel returns the rank of a role in the hierarchy, or 0 for an unknown role
+func RoleLevel(role UserRole) int {
+	return roleLevels[role]
+}
+
+// IsRoleAtLeast checks if role is known and ranks at or above minimum
+func IsRoleAtLeast(role, minimum UserRole) bool {
+	level := RoleLevel(role)
+	return level > 0 && level >= RoleLevel(minimum)
+}
+
 // PermissionCheck represents a permission check request
 type PermissionCheck struct {
 	UserID     uint                   `json:"user_id"`
@@ -166,8 +216,11 @@ func CanManageUser(adminRole UserRole, adminID uint, targetRole UserRole, target
 		return false
 	}
 
-	// For now, any admin can manage any user
-	// Future enhancement: role hierarchy (super admin > admin > user)
+	// Only super admins can manage super admins
+	if targetRole == RoleSuperAdmin && adminRole != RoleSuperAdmin {
+		return false
+	}
+
 	return true
 }
 
@@ -190,9 +243,11 @@ func IsValidRole(role UserRole) bool {
 func GetHigherRoles(role UserRole) []UserRole {
 	switch role {
 	case RoleUser:
-		return []UserRole{RoleAdmin}
+		return []UserRole{RoleAdmin, RoleSuperAdmin}
 	case RoleAdmin:
-		return []UserRole{} // No higher role currently
+		return []UserRole{RoleSuperAdmin}
+	case RoleSuperAdmin:
+		return []UserRole{} // No higher role
 	default:
 		return []UserRole{}
 	}
@@ -201,6 +256,8 @@ func GetHigherRoles(role UserRole) []UserRole {
 // GetLowerRoles returns roles that are lower than the given role
 func GetLowerRoles(role UserRole) []UserRole {
 	switch role {
+	case RoleSuperAdmin:
+		return []UserRole{RoleAdmin, RoleUser}
 	case RoleAdmin:
 		return []UserRole{RoleUser}
 	case RoleUser:
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Security validation and escalation prevention for RBAC
@@ -28,6 +30,8 @@ type RoleChangeSecurityCheck struct {
 	UserAgent     string   `json:"user_agent"`
 	SessionID     string   `json:"session_id,omitempty"`
 	RequestSource string   `json:"request_source"` // "web", "api", "cli", etc.
+	// ActiveAdminsRemaining is the number of other active admins, or nil when it wasn't checked
+	ActiveAdminsRemaining *int64 `json:"active_admins_remaining,omitempty"`
 }
 
 // SecurityValidationResult represents the result of security validation
@@ -130,15 +134,38 @@ func ValidateRoleChange(check *RoleChangeSecurityCheck) *SecurityValidationResul
 		}
 	}
 
+	// 9. Only super admins can grant or revoke the super admin role
+	if check.NewRole == RoleSuperAdmin || check.TargetRole == RoleSuperAdmin {
+		result.RiskLevel = RiskLevelCritical
+		result.RequiresSecondaryAuth = true
+		result.AuditFlags = append(result.AuditFlags, "super_admin_role_change")
+
+		if check.AdminRole != RoleSuperAdmin {
+			result.Valid = false
+			result.Errors = append(result.Errors, "only super admins can grant or revoke the super admin role")
+			result.AuditFlags = append(result.AuditFlags, "unauthorized_super_admin_change")
+		}
+	}
+
+	// 10. Never demote the last active admin
+	if check.ActiveAdminsRemaining != nil && *check.ActiveAdminsRemaining == 0 &&
+		IsRoleAtLeast(check.TargetRole, RoleAdmin) && !IsRoleAtLeast(check.NewRole, RoleAdmin) {
+		result.Valid = false
+		result.Errors = append(result.Errors, "cannot demote the last active admin")
+		result.RiskLevel = RiskLevelCritical
+		result.AuditFlags = append(result.AuditFlags, "last_admin_demotion_attempt")
+	}
+
 	return result
 }
 
 // isValidRoleTransition checks if a role transition is allowed
 func isValidRoleTransition(from, to UserRole) bool {
-	// Define allowed transitions
+	// Define allowed transitions; super admin is only reachable from admin, one step at a time
 	allowedTransitions := map[UserRole][]UserRole{
-		RoleUser:  {RoleAdmin},
-		RoleAdmin: {RoleUser},
+		RoleUser:       {RoleAdmin},
+		RoleAdmin:      {RoleUser, RoleSuperAdmin},
+		RoleSuperAdmin: {RoleAdmin},
 	}
 
 	validTransitions, exists := allowedTransitions[from]
@@ -157,16 +184,10 @@ func isValidRoleTransition(from, to UserRole) bool {
 
 // isPrivilegeEscalation checks if the role change is a privilege escalation
 func isPrivilegeEscalation(from, to UserRole) bool {
-	// Define role hierarchy (higher number = more privileges)
-	roleHierarchy := map[UserRole]int{
-		RoleUser:  1,
-		RoleAdmin: 2,
-	}
-
-	fromLevel, fromExists := roleHierarchy[from]
-	toLevel, toExists := roleHierarchy[to]
+	fromLevel := RoleLevel(from)
+	toLevel := RoleLevel(to)
 
-	if !fromExists || !toExists {
+	if fromLevel == 0 || toLevel == 0 {
 		return true // Unknown role is considered escalation
 	}
 
@@ -261,20 +282,25 @@ func DefaultAlertThresholds() AlertThresholds {
 	}
 }
 
-// SecurityAlert represents a security alert
+// SecurityAlert represents a security alert. Alerts raised by the threshold monitor have no single
+// admin, and may carry an automatic response that stays in force until ResponseUntil or until the
+// alert is resolved.
 type SecurityAlert struct {
-	ID          string                 `json:"id"`
-	Type        string                 `json:"type"`
-	Severity    string                 `json:"severity"` // "low", "medium", "high", "critical"
-	Title       string                 `json:"title"`
-	Description string                 `json:"description"`
-	AdminID     uint                   `json:"admin_id"`
-	AdminEmail  string                 `json:"admin_email"`
-	Data        map[string]interface{} `json:"data"`
-	CreatedAt   time.Time              `json:"created_at"`
-	Resolved    bool                   `json:"resolved"`
-	ResolvedAt  *time.Time             `json:"resolved_at,omitempty"`
-	Notes       string                 `json:"notes,omitempty"`
+	ID            string                 `json:"id" gorm:"primaryKey;size:64"`
+	Type          string                 `json:"type" gorm:"not null;index"`
+	Severity      string                 `json:"severity" gorm:"not null"` // "low", "medium", "high", "critical"
+	Title         string                 `json:"title" gorm:"not null"`
+	Description   string                 `json:"description"`
+	AdminID       uint                   `json:"admin_id,omitempty" gorm:"index"`
+	AdminEmail    string                 `json:"admin_email,omitempty"`
+	Data          map[string]interface{} `json:"data" gorm:"type:jsonb;serializer:json"`
+	Response      string                 `json:"response,omitempty"`
+	ResponseUntil *time.Time             `json:"response_until,omitempty"`
+	CreatedAt     time.Time              `json:"created_at" gorm:"index"`
+	Resolved      bool                   `json:"resolved" gorm:"not null;default:false"`
+	ResolvedAt    *time.Time             `json:"resolved_at,omitempty"`
+	ResolvedBy    *uint                  `json:"resolved_by,omitempty"`
+	Notes         string                 `json:"notes,omitempty"`
 }
 
 // GenerateSecurityAlert creates a security alert for suspicious activity
@@ -284,7 +310,7 @@ func GenerateSecurityAlert(
 	data map[string]interface{},
 ) *SecurityAlert {
 	return &SecurityAlert{
-		ID:          fmt.Sprintf("%d_%s_%d", adminUser.ID, alertType, time.Now().Unix()),
+		ID:          uuid.New().String(),
 		Type:        alertType,
 		Severity:    severity,
 		Title:       title,
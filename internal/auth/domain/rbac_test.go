@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleHierarchy(t *testing.T) {
+	assert.True(t, IsRoleHigherThan(RoleSuperAdmin, RoleAdmin))
+	assert.True(t, IsRoleHigherThan(RoleSuperAdmin, RoleUser))
+	assert.True(t, IsRoleHigherThan(RoleAdmin, RoleUser))
+	assert.False(t, IsRoleHigherThan(RoleAdmin, RoleSuperAdmin))
+	assert.False(t, IsRoleHigherThan(RoleAdmin, RoleAdmin))
+
+	assert.True(t, IsRoleAtLeast(RoleSuperAdmin, RoleAdmin))
+	assert.True(t, IsRoleAtLeast(RoleAdmin, RoleAdmin))
+	assert.False(t, IsRoleAtLeast(RoleUser, RoleAdmin))
+	assert.False(t, IsRoleAtLeast(UserRole("owner"), RoleUser))
+
+	assert.ElementsMatch(t, []UserRole{RoleAdmin, RoleUser}, GetLowerRoles(RoleSuperAdmin))
+	assert.Empty(t, GetHigherRoles(RoleSuperAdmin))
+}
+
+func TestSuperAdminPermissions(t *testing.T) {
+	// Super admins keep every admin permission
+	for _, permission := range GetRolePermissions(RoleAdmin) {
+		assert.True(t, HasPermission(RoleSuperAdmin, permission), "super admin lacks %s", permission)
+	}
+
+	for _, permission := range []Permission{PermissionSystemManage, PermissionSecurityManage, PermissionAuditManage} {
+		assert.True(t, HasPermission(RoleSuperAdmin, permission))
+		assert.False(t, HasPermission(RoleAdmin, permission))
+	}
+}
+
+func TestCanManageUserSuperAdminTarget(t *testing.T) {
+	assert.False(t, CanManageUser(RoleAdmin, 1, RoleSuperAdmin, 2))
+	assert.True(t, CanManageUser(RoleSuperAdmin, 1, RoleSuperAdmin, 2))
+	assert.True(t, CanManageUser(RoleSuperAdmin, 1, RoleAdmin, 2))
+	assert.False(t, CanManageUser(RoleSuperAdmin, 1, RoleSuperAdmin, 1))
+}
+
+func TestRoleTransitions(t *testing.T) {
+	assert.True(t, isValidRoleTransition(RoleAdmin, RoleSuperAdmin))
+	assert.True(t, isValidRoleTransition(RoleSuperAdmin, RoleAdmin))
+	// Super admin is only reachable from admin
+	assert.False(t, isValidRoleTransition(RoleUser, RoleSuperAdmin))
+	assert.False(t, isValidRoleTransition(RoleSuperAdmin, RoleUser))
+
+	assert.True(t, isPrivilegeEscalation(RoleAdmin, RoleSuperAdmin))
+	assert.False(t, isPrivilegeEscalation(RoleSuperAdmin, RoleAdmin))
+	assert.True(t, isPrivilegeEscalation(RoleUser, UserRole("owner")))
+}
+
+func TestValidateRoleChangeSuperAdmin(t *testing.T) {
+	check := func(adminRole, targetRole, newRole UserRole) *SecurityValidationResult {
+		return ValidateRoleChange(&RoleChangeSecurityCheck{
+			AdminID:    1,
+			AdminRole:  adminRole,
+			TargetID:   2,
+			TargetRole: targetRole,
+			NewRole:    newRole,
+			Reason:     "promote on-call administrator",
+			IPAddress:  "127.0.0.1",
+			UserAgent:  "test",
+		})
+	}
+
+	granted := check(RoleSuperAdmin, RoleAdmin, RoleSuperAdmin)
+	assert.True(t, granted.Valid, granted.Errors)
+	assert.Equal(t, RiskLevelCritical, granted.RiskLevel)
+	assert.True(t, granted.RequiresSecondaryAuth)
+	assert.Contains(t, granted.AuditFlags, "super_admin_role_change")
+
+	byAdmin := check(RoleAdmin, RoleAdmin, RoleSuperAdmin)
+	assert.False(t, byAdmin.Valid)
+	assert.Contains(t, byAdmin.AuditFlags, "unauthorized_super_admin_change")
+
+	revokedByAdmin := check(RoleAdmin, RoleSuperAdmin, RoleAdmin)
+	assert.False(t, revokedByAdmin.Valid)
+
+	revoked := check(RoleSuperAdmin, RoleSuperAdmin, RoleAdmin)
+	assert.True(t, revoked.Valid, revoked.Errors)
+}
+
+func TestValidateRoleChangeLastActiveAdmin(t *testing.T) {
+	check := func(newRole UserRole, remaining *int64) *SecurityValidationResult {
+		return ValidateRoleChange(&RoleChangeSecurityCheck{
+			AdminID:               1,
+			AdminRole:             RoleSuperAdmin,
+			TargetID:              2,
+			TargetRole:            RoleAdmin,
+			NewRole:               newRole,
+			Reason:                "role cleanup",
+			IPAddress:             "127.0.0.1",
+			UserAgent:             "test",
+			ActiveAdminsRemaining: remaining,
+		})
+	}
+	none, one := int64(0), int64(1)
+
+	last := check(RoleUser, &none)
+	assert.False(t, last.Valid)
+	assert.Contains(t, last.AuditFlags, "last_admin_demotion_attempt")
+
+	assert.True(t, check(RoleUser, &one).Valid)
+	assert.True(t, check(RoleUser, nil).Valid)
+	assert.True(t, check(RoleSuperAdmin, &none).Valid)
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// Account recovery methods, enabled with ACCOUNT_RECOVERY_METHODS
+const (
+	RecoveryMethodBackupEmail   = "backup_email"
+	RecoveryMethodAdminApproval = "admin_approval"
+)
+
+// RecoveryStatus is where an admin-approved recovery request is in its review
+type RecoveryStatus string
+
+const (
+	RecoveryStatusPending  RecoveryStatus = "pending"
+	RecoveryStatusApproved RecoveryStatus = "approved"
+	RecoveryStatusRejected RecoveryStatus = "rejected"
+)
+
+// RecoveryRequest asks an admin to restore access to an account whose owner can no longer reach its
+// email. Once the admin has verified the requester's identity out of band, approving the request
+// sends a password reset link for the account to ContactEmail.
+type RecoveryRequest struct {
+	ID           string         `json:"id" gorm:"primaryKey;size:64"`
+	UserID       uint           `json:"user_id" gorm:"not null;index"`
+	Email        string         `json:"email" gorm:"not null"`
+	ContactEmail string         `json:"contact_email" gorm:"not null"`
+	Reason       string         `json:"reason" gorm:"type:text"`
+	Status       RecoveryStatus `json:"status" gorm:"not null;default:'pending';index"`
+	IPAddress    string         `json:"ip_address"`
+	UserAgent    string         `json:"user_agent"`
+	ReviewedBy   *uint          `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time     `json:"reviewed_at,omitempty"`
+	ReviewNote   string         `json:"review_note,omitempty" gorm:"type:text"`
+	CreatedAt    time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// AccountRecoveryRequest represents a request to regain access to an account. Admin approval needs a
+// contact address to send the reset link to and a reason the reviewing admin can check.
+type AccountRecoveryRequest struct {
+	Email        string `json:"email" binding:"required,email"`
+	Method       string `json:"method" binding:"required,oneof=backup_email admin_approval"`
+	ContactEmail string `json:"contact_email" binding:"required_if=Method admin_approval,omitempty,email"`
+	Reason       string `json:"reason" binding:"required_if=Method admin_approval,max=1000"`
+}
+
+// BackupEmailRequest sets the backup email that receives recovery links, or removes it when empty. The
+// current password is required, so a stolen session alone can't redirect account recovery.
+type BackupEmailRequest struct {
+	BackupEmail string `json:"backup_email" binding:"omitempty,email"`
+	Password    string `json:"password" binding:"required"`
+}
+
+// ConfirmBackupEmailRequest confirms a new backup email with the token from the link mailed to it
+type ConfirmBackupEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// BackupEmailResponse reports the confirmed backup email and the address waiting for confirmation, if any
+type BackupEmailResponse struct {
+	BackupEmail        string `json:"backup_email"`
+	PendingBackupEmail string `json:"pending_backup_email,omitempty"`
+}
@@ -1,9 +1,12 @@
 package domain
 
 import (
+	"crypto/subtle"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -16,6 +19,9 @@ type UserRole string
 const (
 	RoleUser  UserRole = "user"
 	RoleAdmin UserRole = "admin"
+	// RoleSuperAdmin is the break-glass role above admin. It is kept for the few actions too
+	// dangerous for everyday admins, and every change made with it requires step-up auth.
+	RoleSuperAdmin UserRole = "super_admin"
 )
 
 // UserStatus represents the status of a user
@@ -25,6 +31,8 @@ const (
 	StatusActive    UserStatus = "active"
 	StatusInactive  UserStatus = "inactive"
 	StatusSuspended UserStatus = "suspended"
+	// StatusPending marks a self-registered account waiting for an admin to approve it
+	StatusPending UserStatus = "pending"
 )
 
 // UserPreferences represents user preferences stored as JSONB
@@ -35,6 +43,7 @@ type UserPreferences struct {
 	Notifications NotificationPrefs `json:"notifications,omitempty"` // notification preferences
 	Privacy       PrivacyPrefs      `json:"privacy,omitempty"`       // privacy preferences
 	Custom        map[string]any    `json:"custom,omitempty"`        // custom application-specific preferences
+	BackupEmail   string            `json:"backup_email,omitempty"`  // confirmed; receives account recovery links
 }
 
 // Value implements the driver.Valuer interface for database storage
@@ -69,9 +78,10 @@ func (p *UserPreferences) Scan(value interface{}) error {
 
 // NotificationPrefs represents notification preferences
 type NotificationPrefs struct {
-	Email bool `json:"email"`
-	SMS   bool `json:"sms"`
-	Push  bool `json:"push"`
+	Email     bool `json:"email"`
+	SMS       bool `json:"sms"`
+	Push      bool `json:"push"`
+	Marketing bool `json:"marketing"` // opt-in to marketing emails, recorded as a consent
 }
 
 // PrivacyPrefs represents privacy preferences
@@ -83,12 +93,17 @@ type PrivacyPrefs struct {
 // User represents a user in the system
 type User struct {
 	ID               uint            `json:"id" gorm:"primarykey"`
-	Email            string          `json:"email" gorm:"uniqueIndex;not null"`
+	Email            string          `json:"email" gorm:"not null"`
+	Username         *string         `json:"username,omitempty" gorm:"uniqueIndex;size:64"` // optional, stored normalized
 	PasswordHash     string          `json:"-" gorm:"not null"`
 	FirstName        string          `json:"first_name" gorm:"not null"`
 	LastName         string          `json:"last_name" gorm:"not null"`
 	EmailVerified    bool            `json:"email_verified" gorm:"default:false"`
 	EmailVerifyToken string          `json:"-" gorm:"index"`
+	VerifyNudgedAt   *time.Time      `json:"-"`                  // last login-time verification reminder email
+	VerifyResends    int             `json:"-" gorm:"default:0"` // verification resends in the current window
+	VerifyResendFrom *time.Time      `json:"-"`                  // start of the verification resend window
+	ResetEmailSentAt *time.Time      `json:"-"`                  // last password reset email, for its minimum interval
 	Role             UserRole        `json:"role" gorm:"default:'user';not null"`
 	Status           UserStatus      `json:"status" gorm:"default:'active';not null"`
 	Preferences      UserPreferences `json:"preferences" gorm:"type:jsonb;default:'{}'"`
@@ -98,24 +113,116 @@ type User struct {
 	UpdatedAt        time.Time       `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt  `json:"-" gorm:"index"`
 
+	// The replaced password still signs in until PreviousPasswordUntil, when PASSWORD_LOGIN_GRACE is set
+	PreviousPasswordHash  string     `json:"-"`
+	PreviousPasswordUntil *time.Time `json:"-"`
+
+	// A new backup email only receives recovery links once the confirmation link mailed to it is opened
+	PendingBackupEmail   string     `json:"-"`
+	BackupEmailToken     string     `json:"-" gorm:"index"`
+	BackupEmailExpiresAt *time.Time `json:"-"`
+
+	// Set on a bootstrap admin created with BOOTSTRAP_ADMIN_SETUP_REQUIRED; cleared by the first password change
+	PasswordChangeRequired bool `json:"password_change_required" gorm:"default:false"`
+
 	// Relationships
 	RefreshTokens []RefreshToken `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
+// SetPassword replaces the password hash. With a grace, the replaced hash still signs in until it
+// has passed; without one any earlier grace ends too.
+func (u *User) SetPassword(hash string, grace time.Duration, now time.Time) {
+	u.PreviousPasswordHash = ""
+	u.PreviousPasswordUntil = nil
+	if grace > 0 {
+		until := now.Add(grace)
+		u.PreviousPasswordHash = u.PasswordHash
+		u.PreviousPasswordUntil = &until
+	}
+	u.PasswordHash = hash
+	u.PasswordChangeRequired = false
+}
+
+// GetUsername returns the account's username, empty when it has none
+func (u *User) GetUsername() string {
+	if u.Username == nil {
+		return ""
+	}
+	return *u.Username
+}
+
+// HasPassword reports whether the account has a local password to sign in with
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != ""
+}
+
+// InPasswordGrace reports whether the replaced password may still be used to sign in
+func (u *User) InPasswordGrace(now time.Time) bool {
+	return u.PreviousPasswordHash != "" && u.PreviousPasswordUntil != nil && now.Before(*u.PreviousPasswordUntil)
+}
+
 // IsActive checks if the user is active
 func (u *User) IsActive() bool {
 	return u.Status == StatusActive
 }
 
-// IsAdmin checks if the user has admin role
+// StatusError returns the sign-in error for the user's status, or nil if the user is active
+func (u *User) StatusError() error {
+	switch u.Status {
+	case StatusActive:
+		return nil
+	case StatusSuspended:
+		return ErrUserSuspended
+	case StatusPending:
+		return ErrUserPendingApproval
+	default:
+		return ErrUserInactive
+	}
+}
+
+// NeedsVerificationNudge checks if an unverified user is due a verification reminder email
+func (u *User) NeedsVerificationNudge(now time.Time, interval time.Duration) bool {
+	if u.EmailVerified {
+		return false
+	}
+	return u.VerifyNudgedAt == nil || now.Sub(*u.VerifyNudgedAt) >= interval
+}
+
+// VerificationResendsInWindow returns how many verification resends the user has requested in the
+// window ending at now, or 0 once the window has passed
+func (u *User) VerificationResendsInWindow(now time.Time, window time.Duration) int {
+	if u.VerifyResendFrom == nil || now.Sub(*u.VerifyResendFrom) >= window {
+		return 0
+	}
+	return u.VerifyResends
+}
+
+// IsAdmin checks if the user has admin rights, which super admins also hold
 func (u *User) IsAdmin() bool {
-	return u.Role == RoleAdmin
+	return IsRoleAtLeast(u.Role, RoleAdmin)
+}
+
+// IsSuperAdmin checks if the user has the super admin role
+func (u *User) IsSuperAdmin() bool {
+	return u.Role == RoleSuperAdmin
+}
+
+// BeforeSave normalizes the email so direct creates and saves can't store a mixed-case duplicate
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	u.Email = NormalizeEmail(u.Email)
+	return nil
+}
+
+// NormalizeEmail trims and lowercases an email address; emails are unique case-insensitively
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 // UserResponse represents the user data returned to the client
 type UserResponse struct {
 	ID            uint            `json:"id"`
 	Email         string          `json:"email"`
+	Username      string          `json:"username,omitempty"`
 	FirstName     string          `json:"first_name"`
 	LastName      string          `json:"last_name"`
 	EmailVerified bool            `json:"email_verified"`
@@ -126,6 +233,21 @@ type UserResponse struct {
 	LastLoginAt   *time.Time      `json:"last_login_at"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
+
+	PasswordChangeRequired bool `json:"password_change_required,omitempty"`
+}
+
+// SetupError returns the step an account still has to complete before it can be used, or nil. An
+// account created with a required password change verifies its email first, then changes the password.
+func (r *UserResponse) SetupError() error {
+	switch {
+	case !r.PasswordChangeRequired:
+		return nil
+	case !r.EmailVerified:
+		return ErrEmailNotVerified
+	default:
+		return ErrPasswordChangeRequired
+	}
 }
 
 // ToResponse converts User to UserResponse
@@ -133,6 +255,7 @@ func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
 		ID:            u.ID,
 		Email:         u.Email,
+		Username:      u.GetUsername(),
 		FirstName:     u.FirstName,
 		LastName:      u.LastName,
 		EmailVerified: u.EmailVerified,
@@ -143,26 +266,74 @@ func (u *User) ToResponse() *UserResponse {
 		LastLoginAt:   u.LastLoginAt,
 		CreatedAt:     u.CreatedAt,
 		UpdatedAt:     u.UpdatedAt,
+
+		PasswordChangeRequired: u.PasswordChangeRequired,
 	}
 }
 
 // RefreshToken represents a refresh token for JWT authentication
 type RefreshToken struct {
-	ID        uint           `json:"id" gorm:"primarykey"`
-	UserID    uint           `json:"user_id" gorm:"not null;index"`
-	Token     string         `json:"-" gorm:"uniqueIndex;not null"`
-	ExpiresAt time.Time      `json:"expires_at" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID          uint           `json:"id" gorm:"primarykey"`
+	UserID      uint           `json:"user_id" gorm:"not null;index"`
+	Token       string         `json:"-" gorm:"uniqueIndex;not null"`
+	DeviceKey   string         `json:"-" gorm:"size:80;index"` // See DeviceKey; empty for tokens issued before it was recorded
+	ExpiresAt   time.Time      `json:"expires_at" gorm:"not null"`
+	LastUsedAt  *time.Time     `json:"-"`                      // When a sign-in or rotation issued it; see RotationDue
+	SessionID   string         `json:"-" gorm:"size:36;index"` // Kept across rotations and carried by the session's access tokens
+	StartedAt   *time.Time     `json:"-"`                      // When the user signed in to start the session; see SessionStart
+	RefreshedAt *time.Time     `json:"-"`                      // When it last refreshed the session without being rotated; see LastUsed
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
 	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
+// SessionStart returns when the token's session began, which rotation carries over to the new token.
+// Tokens issued before the start was recorded count from their own creation.
+func (rt *RefreshToken) SessionStart() time.Time {
+	if rt.StartedAt != nil {
+		return *rt.StartedAt
+	}
+	return rt.CreatedAt
+}
+
+// LastUsed returns when the token was last used: its latest refresh, or else when it was issued
+func (rt *RefreshToken) LastUsed() time.Time {
+	if rt.RefreshedAt != nil {
+		return *rt.RefreshedAt
+	}
+	if rt.LastUsedAt != nil {
+		return *rt.LastUsedAt
+	}
+	return rt.CreatedAt
+}
+
+// SessionExpiredAt reports whether the token's session is at least maxAge old at the given time; a
+// maxAge of zero never expires it
+func (rt *RefreshToken) SessionExpiredAt(now time.Time, maxAge time.Duration) bool {
+	return maxAge > 0 && !now.Before(rt.SessionStart().Add(maxAge))
+}
+
 // IsExpired checks if the refresh token is expired
 func (rt *RefreshToken) IsExpired() bool {
-	return time.Now().After(rt.ExpiresAt)
+	return rt.IsExpiredAt(time.Now())
+}
+
+// IsExpiredAt checks if the refresh token is expired at the given time
+func (rt *RefreshToken) IsExpiredAt(now time.Time) bool {
+	return now.After(rt.ExpiresAt)
+}
+
+// RotationDue reports whether a refresh may replace the token, at most once per interval. Tokens
+// issued before LastUsedAt was recorded count from when they were created.
+func (rt *RefreshToken) RotationDue(now time.Time, interval time.Duration) bool {
+	lastUsed := rt.CreatedAt
+	if rt.LastUsedAt != nil {
+		lastUsed = *rt.LastUsedAt
+	}
+	return now.Sub(lastUsed) >= interval
 }
 
 // PasswordReset represents a password reset request
@@ -179,7 +350,19 @@ type PasswordReset struct {
 
 // IsExpired checks if the password reset token is expired
 func (pr *PasswordReset) IsExpired() bool {
-	return time.Now().After(pr.ExpiresAt)
+	return pr.IsExpiredAt(time.Now())
+}
+
+// IsExpiredAt checks if the password reset token is expired at the given time
+func (pr *PasswordReset) IsExpiredAt(now time.Time) bool {
+	return now.After(pr.ExpiresAt)
+}
+
+// TokenMatches compares a stored link token with the one presented in constant time. Lookups find
+// the token through an index; this confirms the match without leaking how much of it agreed, and
+// guards against a database collation that compares case-insensitively.
+func TokenMatches(stored, presented string) bool {
+	return presented != "" && subtle.ConstantTimeCompare([]byte(stored), []byte(presented)) == 1
 }
 
 // AuditAction represents the type of audit action
@@ -193,12 +376,37 @@ const (
 	AuditActionUserRoleChanged    AuditAction = "user_role_changed"
 	AuditActionPasswordChanged    AuditAction = "password_changed"
 	AuditActionEmailVerified      AuditAction = "email_verified"
+	AuditActionEmailUnverified    AuditAction = "email_unverified"
 	AuditActionLoginSuccess       AuditAction = "login_success"
 	AuditActionLoginFailed        AuditAction = "login_failed"
 	AuditActionLogout             AuditAction = "logout"
 	AuditActionPasswordResetReq   AuditAction = "password_reset_requested"
 	AuditActionPasswordResetUsed  AuditAction = "password_reset_used"
 	AuditActionPreferencesUpdated AuditAction = "preferences_updated"
+	AuditActionFeatureOverrideSet AuditAction = "feature_override_set"
+	AuditActionFeatureOverrideDel AuditAction = "feature_override_deleted"
+	AuditActionSessionsRevoked    AuditAction = "sessions_revoked"
+	AuditActionEmailQueueRun      AuditAction = "email_queue_processed"
+	AuditActionEmailCanceled      AuditAction = "scheduled_email_canceled"
+	AuditActionEmailBroadcast     AuditAction = "email_broadcast_started"
+	AuditActionStepUpVerified     AuditAction = "step_up_verified"
+	AuditActionStepUpFailed       AuditAction = "step_up_failed"
+	AuditActionSuperAdminAction   AuditAction = "super_admin_action"
+	AuditActionVerificationResent AuditAction = "verification_email_resent"
+	AuditActionAdminPasswordReset AuditAction = "admin_password_reset_sent"
+	AuditActionUsersMerged        AuditAction = "users_merged"
+	AuditActionConsentUpdated     AuditAction = "consent_updated"
+	AuditActionAlertResolved      AuditAction = "security_alert_resolved"
+	AuditActionAdminAccess        AuditAction = "admin_access"
+	AuditActionUserApproved       AuditAction = "user_approved"
+	AuditActionRecoveryRequested  AuditAction = "account_recovery_requested"
+	AuditActionRecoveryApproved   AuditAction = "account_recovery_approved"
+	AuditActionRecoveryRejected   AuditAction = "account_recovery_rejected"
+	AuditActionBackupEmailChanged AuditAction = "backup_email_changed"
+	AuditActionEvidenceExported   AuditAction = "user_evidence_exported"
+	AuditActionExportRequested    AuditAction = "data_export_requested"
+	AuditActionExportDownloaded   AuditAction = "data_export_downloaded"
+	AuditActionPasswordCreated    AuditAction = "password_created"
 )
 
 // AuditLevel represents the severity level of the audit event
@@ -215,6 +423,8 @@ type AuditLog struct {
 	ID          uint                   `json:"id" gorm:"primarykey"`
 	UserID      *uint                  `json:"user_id" gorm:"index"`
 	TargetID    *uint                  `json:"target_id" gorm:"index"`
+	UserEmail   string                 `json:"user_email,omitempty"`   // Snapshot kept once the user is permanently deleted
+	TargetEmail string                 `json:"target_email,omitempty"` // Snapshot kept once the target is permanently deleted
 	Action      AuditAction            `json:"action" gorm:"not null;index"`
 	Level       AuditLevel             `json:"level" gorm:"default:'info';not null"`
 	Resource    string                 `json:"resource" gorm:"not null"` // e.g., "user", "admin", "auth"
@@ -229,20 +439,39 @@ type AuditLog struct {
 	Target *User `json:"target,omitempty" gorm:"foreignKey:TargetID"`
 }
 
+// AdminAccess describes one admin API call for the access audit log
+type AdminAccess struct {
+	UserID    uint
+	TargetID  *uint // The user the call is about, for /users/:id routes
+	Method    string
+	Route     string // Route pattern, e.g. /api/admin/users/:id
+	Path      string
+	Query     string
+	Status    int
+	IPAddress string
+	UserAgent string
+}
+
 // Authentication DTOs
 
 // RegisterRequest represents a user registration request
 type RegisterRequest struct {
-	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=8"`
-	FirstName string `json:"first_name" binding:"required,min=1"`
-	LastName  string `json:"last_name" binding:"required,min=1"`
+	Email          string `json:"email" binding:"required,email"`
+	Password       string `json:"password" binding:"required,min=8"`
+	FirstName      string `json:"first_name" binding:"required,min=1"`
+	LastName       string `json:"last_name" binding:"required,min=1"`
+	Username       string `json:"username"` // Optional; checked against the username policy
+	MarketingOptIn bool   `json:"marketing_opt_in"`
+	DeviceID       string `json:"-"` // From the X-Device-ID header
 }
 
 // LoginRequest represents a user login request
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	DeviceID string `json:"-"` // From the X-Device-ID header
+	// CaptchaToken is the solved CAPTCHA, needed once logins from the client or for the account are escalated
+	CaptchaToken string `json:"captcha_token,omitempty"`
 }
 
 // RefreshTokenRequest represents a token refresh request
@@ -255,18 +484,37 @@ type ForgotPasswordRequest struct {
 	Email string `json:"email" binding:"required,email"`
 }
 
+// EmailAvailabilityRequest represents a check whether an email can still be registered
+type EmailAvailabilityRequest struct {
+	Email string `form:"email" binding:"required,email"`
+}
+
+// EmailAvailabilityResponse reports whether an email can still be registered
+type EmailAvailabilityResponse struct {
+	Available bool `json:"available"`
+}
+
 // ResetPasswordRequest represents a password reset request
 type ResetPasswordRequest struct {
 	Token           string `json:"token" binding:"required"`
 	Password        string `json:"password" binding:"required,min=8"`
-	ConfirmPassword string `json:"confirm_password" binding:"required"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
+
+	// ConvertToPassword confirms adding a password to an account that has none, when
+	// PASSWORD_RESET_PASSWORDLESS is "confirm"
+	ConvertToPassword bool `json:"convert_to_password"`
 }
 
 // ChangePasswordRequest represents a password change request
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
 	NewPassword     string `json:"new_password" binding:"required,min=8"`
-	ConfirmPassword string `json:"confirm_password" binding:"required"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=NewPassword"`
+}
+
+// StepUpRequest re-confirms the caller's password before a sensitive action
+type StepUpRequest struct {
+	Password string `json:"password" binding:"required"`
 }
 
 // EmailVerificationRequest represents an email verification request
@@ -276,35 +524,166 @@ type EmailVerificationRequest struct {
 
 // AuthResponse represents the response after successful authentication
 type AuthResponse struct {
-	User         *UserResponse `json:"user"`
-	AccessToken  string        `json:"access_token"`
-	RefreshToken string        `json:"refresh_token"`
-	ExpiresIn    int64         `json:"expires_in"` // seconds
+	User                 *UserResponse         `json:"user"`
+	AccessToken          string                `json:"access_token"`
+	RefreshToken         string                `json:"refresh_token"`
+	ExpiresIn            int64                 `json:"expires_in"` // seconds
+	VerificationReminder *VerificationReminder `json:"verification_reminder,omitempty"`
+	// PendingApproval is set instead of tokens when a new registration must be approved by an admin
+	PendingApproval bool `json:"pending_approval,omitempty"`
+}
+
+// VerificationReminder nudges an unverified user to verify their email after login
+type VerificationReminder struct {
+	Message    string `json:"message"`
+	EmailSent  bool   `json:"email_sent"`
+	ResendPath string `json:"resend_path"`
+}
+
+// ChangePasswordResponse represents the response after a password change. A fresh token
+// pair is only issued when the current session is kept.
+type ChangePasswordResponse struct {
+	Message         string `json:"message"`
+	SessionsRevoked int64  `json:"sessions_revoked"`
+	AccessToken     string `json:"access_token,omitempty"`
+	RefreshToken    string `json:"refresh_token,omitempty"`
+	ExpiresIn       int64  `json:"expires_in,omitempty"` // seconds
+}
+
+// StepUpResponse carries a short-lived token proving the caller recently re-entered their
+// password. It is sent in the X-Step-Up-Token header with the sensitive request.
+type StepUpResponse struct {
+	StepUpToken string    `json:"step_up_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	ExpiresIn   int64     `json:"expires_in"` // seconds
+}
+
+// SessionResponse describes how long the caller's current session remains usable, so clients can
+// refresh ahead of expiry. Refresh token fields are omitted when no live refresh token was presented.
+type SessionResponse struct {
+	Valid                 bool       `json:"valid"`
+	AccessTokenExpiresAt  time.Time  `json:"access_token_expires_at"`
+	AccessTokenExpiresIn  int64      `json:"access_token_expires_in"` // seconds
+	RefreshTokenValid     bool       `json:"refresh_token_valid"`
+	RefreshTokenExpiresAt *time.Time `json:"refresh_token_expires_at,omitempty"`
+	RefreshTokenExpiresIn *int64     `json:"refresh_token_expires_in,omitempty"` // seconds
+}
+
+// NewSessionResponse builds the session view from validated access token claims and the
+// stored refresh token, if any
+func NewSessionResponse(claims *JWTClaims, refreshToken *RefreshToken, now time.Time) *SessionResponse {
+	response := &SessionResponse{Valid: true}
+	if claims.ExpiresAt != nil {
+		response.AccessTokenExpiresAt = claims.ExpiresAt.Time
+		response.AccessTokenExpiresIn = secondsUntil(claims.ExpiresAt.Time, now)
+	}
+
+	if refreshToken != nil && !refreshToken.IsExpiredAt(now) {
+		expiresAt := refreshToken.ExpiresAt
+		expiresIn := secondsUntil(expiresAt, now)
+		response.RefreshTokenValid = true
+		response.RefreshTokenExpiresAt = &expiresAt
+		response.RefreshTokenExpiresIn = &expiresIn
+	}
+
+	return response
+}
+
+// secondsUntil returns the whole seconds from now until t, never negative
+func secondsUntil(t, now time.Time) int64 {
+	if !t.After(now) {
+		return 0
+	}
+	return int64(t.Sub(now) / time.Second)
 }
 
 // MessageResponse represents a simple message response
 type MessageResponse struct {
 	Message string `json:"message"`
+	// Warnings flag unusual but allowed aspects of a successful change
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   string            `json:"error"`
 	Details map[string]string `json:"details,omitempty"`
+	// Code is the machine-readable error code, when the error has one, and Message its text in the
+	// request's language
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 // JWT Claims
 
+// IntrospectionRequest is an RFC 7662 token introspection request, sent as a form or as JSON
+type IntrospectionRequest struct {
+	Token         string `form:"token" json:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint" json:"token_type_hint"`
+}
+
+// BatchIntrospectionRequest introspects several tokens in one call
+type BatchIntrospectionRequest struct {
+	Tokens []string `json:"tokens" binding:"required,min=1,max=100"`
+}
+
+// IntrospectionResponse is an RFC 7662 introspection response. Inactive tokens carry nothing but
+// active=false, so callers learn nothing about why a token was refused.
+type IntrospectionResponse struct {
+	Active    bool           `json:"active"`
+	Subject   string         `json:"sub,omitempty"`
+	Username  string         `json:"username,omitempty"`
+	TokenType string         `json:"token_type,omitempty"`
+	Role      UserRole       `json:"role,omitempty"`
+	ExpiresAt int64          `json:"exp,omitempty"`
+	IssuedAt  int64          `json:"iat,omitempty"`
+	NotBefore int64          `json:"nbf,omitempty"`
+	Ext       map[string]any `json:"ext,omitempty"`
+}
+
+// BatchIntrospectionResponse holds one introspection result per requested token, in request order
+type BatchIntrospectionResponse struct {
+	Results []*IntrospectionResponse `json:"results"`
+}
+
 // JWTClaims represents the claims in a JWT token
 // Implements jwt.Claims interface
 type JWTClaims struct {
 	UserID    uint     `json:"user_id"`
 	Email     string   `json:"email"`
 	Role      UserRole `json:"role"`       // User role for authorization
-	TokenType string   `json:"token_type"` // "access" or "refresh"
+	TokenType string   `json:"token_type"` // "access", "refresh" or "step_up"
+	// Custom holds the configured claims for downstream services, kept apart from the standard claims
+	Custom map[string]any `json:"ext,omitempty"`
+	// SessionID names the sign-in an access token belongs to, so the session can be revoked on its own
+	SessionID string `json:"sid,omitempty"`
+	// AuthTime is when the user signed in to start the session, however many refreshes ago
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Introspection describes the token the claims came from as an active RFC 7662 introspection response
+func (c *JWTClaims) Introspection() *IntrospectionResponse {
+	response := &IntrospectionResponse{
+		Active:    true,
+		Subject:   strconv.FormatUint(uint64(c.UserID), 10),
+		Username:  c.Email,
+		TokenType: "access_token",
+		Role:      c.Role,
+		Ext:       c.Custom,
+	}
+	if c.ExpiresAt != nil {
+		response.ExpiresAt = c.ExpiresAt.Unix()
+	}
+	if c.IssuedAt != nil {
+		response.IssuedAt = c.IssuedAt.Unix()
+	}
+	if c.NotBefore != nil {
+		response.NotBefore = c.NotBefore.Unix()
+	}
+	return response
+}
+
 // Valid validates the JWT claims
 func (c *JWTClaims) Valid() error {
 	// Check expiration using the new jwt library
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeedsVerificationNudge(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	interval := 24 * time.Hour
+
+	verified := &User{EmailVerified: true}
+	assert.False(t, verified.NeedsVerificationNudge(now, interval))
+
+	neverNudged := &User{EmailVerified: false}
+	assert.True(t, neverNudged.NeedsVerificationNudge(now, interval))
+
+	recent := now.Add(-time.Hour)
+	recentlyNudged := &User{EmailVerified: false, VerifyNudgedAt: &recent}
+	assert.False(t, recentlyNudged.NeedsVerificationNudge(now, interval))
+
+	old := now.Add(-25 * time.Hour)
+	nudgedLongAgo := &User{EmailVerified: false, VerifyNudgedAt: &old}
+	assert.True(t, nudgedLongAgo.NeedsVerificationNudge(now, interval))
+}
+
+func TestStatusError(t *testing.T) {
+	assert.NoError(t, (&User{Status: StatusActive}).StatusError())
+	assert.Equal(t, ErrUserInactive, (&User{Status: StatusInactive}).StatusError())
+	assert.Equal(t, ErrUserSuspended, (&User{Status: StatusSuspended}).StatusError())
+	assert.Equal(t, ErrUserPendingApproval, (&User{Status: StatusPending}).StatusError())
+
+	// Unknown statuses are treated as inactive rather than allowed in
+	assert.Equal(t, ErrUserInactive, (&User{Status: UserStatus("archived")}).StatusError())
+}
+
+func TestSetPassword(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	user := &User{PasswordHash: "first"}
+	user.SetPassword("second", time.Hour, now)
+	assert.Equal(t, "second", user.PasswordHash)
+	assert.Equal(t, "first", user.PreviousPasswordHash)
+	assert.True(t, user.InPasswordGrace(now.Add(59*time.Minute)))
+	assert.False(t, user.InPasswordGrace(now.Add(time.Hour)))
+
+	// Without a grace, a change also ends the one still running
+	user.SetPassword("third", 0, now)
+	assert.Equal(t, "third", user.PasswordHash)
+	assert.Empty(t, user.PreviousPasswordHash)
+	assert.False(t, user.InPasswordGrace(now))
+
+	// Any new password satisfies a required change
+	user.PasswordChangeRequired = true
+	user.SetPassword("fourth", 0, now)
+	assert.False(t, user.PasswordChangeRequired)
+}
+
+func TestSetupError(t *testing.T) {
+	assert.NoError(t, (&UserResponse{EmailVerified: true}).SetupError())
+	assert.NoError(t, (&UserResponse{EmailVerified: false}).SetupError(), "unverified alone is left to the route policy")
+	assert.Equal(t, ErrEmailNotVerified, (&UserResponse{PasswordChangeRequired: true}).SetupError())
+	assert.Equal(t, ErrPasswordChangeRequired, (&UserResponse{PasswordChangeRequired: true, EmailVerified: true}).SetupError())
+}
+
+func TestNewSessionResponse(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	claims := func(expiresIn time.Duration) *JWTClaims {
+		return &JWTClaims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+		}}
+	}
+
+	t.Run("Fresh", func(t *testing.T) {
+		refresh := &RefreshToken{UserID: 1, ExpiresAt: now.Add(7 * 24 * time.Hour)}
+		session := NewSessionResponse(claims(15*time.Minute), refresh, now)
+
+		assert.True(t, session.Valid)
+		assert.Equal(t, int64(900), session.AccessTokenExpiresIn)
+		assert.True(t, session.RefreshTokenValid)
+		if assert.NotNil(t, session.RefreshTokenExpiresIn) {
+			assert.Equal(t, int64(7*24*60*60), *session.RefreshTokenExpiresIn)
+		}
+	})
+
+	t.Run("NearExpiry", func(t *testing.T) {
+		refresh := &RefreshToken{UserID: 1, ExpiresAt: now.Add(time.Hour)}
+		session := NewSessionResponse(claims(1500*time.Millisecond), refresh, now)
+
+		// Partial seconds round down so clients never refresh too late
+		assert.Equal(t, int64(1), session.AccessTokenExpiresIn)
+		assert.True(t, session.AccessTokenExpiresAt.Equal(now.Add(time.Second)))
+	})
+
+	t.Run("RefreshExpired", func(t *testing.T) {
+		refresh := &RefreshToken{UserID: 1, ExpiresAt: now.Add(-time.Minute)}
+		session := NewSessionResponse(claims(10*time.Minute), refresh, now)
+
+		assert.True(t, session.Valid)
+		assert.False(t, session.RefreshTokenValid)
+		assert.Nil(t, session.RefreshTokenExpiresAt)
+		assert.Nil(t, session.RefreshTokenExpiresIn)
+	})
+
+	t.Run("NoRefreshToken", func(t *testing.T) {
+		session := NewSessionResponse(claims(10*time.Minute), nil, now)
+		assert.False(t, session.RefreshTokenValid)
+	})
+}
+
+func TestUserIsAdmin(t *testing.T) {
+	assert.True(t, (&User{Role: RoleSuperAdmin}).IsAdmin())
+	assert.True(t, (&User{Role: RoleSuperAdmin}).IsSuperAdmin())
+	assert.True(t, (&User{Role: RoleAdmin}).IsAdmin())
+	assert.False(t, (&User{Role: RoleAdmin}).IsSuperAdmin())
+	assert.False(t, (&User{Role: RoleUser}).IsAdmin())
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "user@x.com", NormalizeEmail("User@X.com"))
+	assert.Equal(t, "user@x.com", NormalizeEmail("  user@x.com "))
+
+	user := &User{Email: " User@x.com"}
+	assert.NoError(t, user.BeforeSave(nil))
+	assert.Equal(t, "user@x.com", user.Email)
+}
+
+func TestTokenMatches(t *testing.T) {
+	assert.True(t, TokenMatches("a1b2c3", "a1b2c3"))
+	assert.False(t, TokenMatches("a1b2c3", "A1B2C3"))
+	assert.False(t, TokenMatches("a1b2c3", "a1b2c"))
+	assert.False(t, TokenMatches("", ""))
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Username length limits used when the configuration leaves them unset
+const (
+	DefaultUsernameMinLength = 3
+	DefaultUsernameMaxLength = 30
+)
+
+// UsernamePolicy decides which usernames may be taken. Usernames are compared case-insensitively, and
+// a reserved name also matches when written with separators, so "Ad_Min" can't pose as "admin".
+type UsernamePolicy struct {
+	MinLength int
+	MaxLength int
+	Reserved  []string
+}
+
+// NormalizeUsername returns the form a username is stored and compared in
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// Check returns an error wrapping ErrUsernameInvalid when the username breaks the format rules, or
+// ErrUsernameReserved when it is reserved. The username is normalized first.
+func (p *UsernamePolicy) Check(username string) error {
+	username = NormalizeUsername(username)
+
+	minLength, maxLength := p.MinLength, p.MaxLength
+	if minLength <= 0 {
+		minLength = DefaultUsernameMinLength
+	}
+	if maxLength <= 0 {
+		maxLength = DefaultUsernameMaxLength
+	}
+	if len(username) < minLength || len(username) > maxLength {
+		return fmt.Errorf("%w: must be %d to %d characters long", ErrUsernameInvalid, minLength, maxLength)
+	}
+
+	for i, r := range username {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case isUsernameSeparator(r):
+			if i == 0 || i == len(username)-1 {
+				return fmt.Errorf("%w: must start and end with a letter or digit", ErrUsernameInvalid)
+			}
+			if isUsernameSeparator(rune(username[i-1])) {
+				return fmt.Errorf("%w: must not contain consecutive '.', '_' or '-'", ErrUsernameInvalid)
+			}
+		default:
+			return fmt.Errorf("%w: may only contain letters, digits, '.', '_' and '-'", ErrUsernameInvalid)
+		}
+	}
+
+	stripped := stripUsernameSeparators(username)
+	for _, reserved := range p.Reserved {
+		if stripped == stripUsernameSeparators(NormalizeUsername(reserved)) {
+			return ErrUsernameReserved
+		}
+	}
+	return nil
+}
+
+func isUsernameSeparator(r rune) bool {
+	return r == '.' || r == '_' || r == '-'
+}
+
+// stripUsernameSeparators drops the separators from a username, leaving its letters and digits
+func stripUsernameSeparators(username string) string {
+	return strings.Map(func(r rune) rune {
+		if isUsernameSeparator(r) {
+			return -1
+		}
+		return r
+	}, username)
+}
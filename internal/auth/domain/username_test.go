@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsernamePolicyFormat(t *testing.T) {
+	policy := &UsernamePolicy{MinLength: 3, MaxLength: 10}
+
+	for _, username := range []string{"jane", "Jane", "jane.doe", "jane_doe", "jane-doe", "j4ne", "abc"} {
+		assert.NoError(t, policy.Check(username), username)
+	}
+	for _, username := range []string{"", "ab", "abcdefghijk", "jane doe", "jane+doe", ".jane", "jane.", "jane__doe", "jöhn"} {
+		assert.ErrorIs(t, policy.Check(username), ErrUsernameInvalid, username)
+	}
+
+	// Unset limits fall back to the defaults
+	policy = &UsernamePolicy{}
+	assert.ErrorIs(t, policy.Check("ab"), ErrUsernameInvalid)
+	assert.NoError(t, policy.Check("abcdefghijklmnopqrstuvwxyz0123"))
+	assert.ErrorIs(t, policy.Check("abcdefghijklmnopqrstuvwxyz01234"), ErrUsernameInvalid)
+}
+
+func TestUsernamePolicyReserved(t *testing.T) {
+	policy := &UsernamePolicy{Reserved: []string{"admin", "Help-Desk"}}
+
+	for _, username := range []string{"admin", "ADMIN", "Ad.Min", "a_d_m_i_n", "helpdesk", "help.desk"} {
+		assert.Equal(t, ErrUsernameReserved, policy.Check(username), username)
+	}
+	assert.NoError(t, policy.Check("admiral"))
+	assert.NoError(t, policy.Check("jane-admin"))
+}
+
+func TestIsUsernameError(t *testing.T) {
+	policy := &UsernamePolicy{}
+
+	assert.True(t, IsUsernameError(policy.Check("a")))
+	assert.True(t, IsUsernameError(ErrUsernameReserved))
+	assert.True(t, IsUsernameError(ErrUsernameTaken))
+	assert.False(t, IsUsernameError(ErrInvalidEmail))
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// RecoveryRequestRepository handles database operations for admin-approved account recovery requests
+type RecoveryRequestRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryRequestRepository creates a new recovery request repository
+func NewRecoveryRequestRepository(db *gorm.DB) *RecoveryRequestRepository {
+	return &RecoveryRequestRepository{
+		db: db,
+	}
+}
+
+// Create stores a recovery request
+func (r *RecoveryRequestRepository) Create(request *domain.RecoveryRequest) error {
+	return r.db.Create(request).Error
+}
+
+// GetByID gets a recovery request by ID
+func (r *RecoveryRequestRepository) GetByID(id string) (*domain.RecoveryRequest, error) {
+	var request domain.RecoveryRequest
+	err := r.db.Where("id = ?", id).First(&request).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrRecoveryNotFound
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// HasPending reports whether the user already has a recovery request waiting for review
+func (r *RecoveryRequestRepository) HasPending(userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.RecoveryRequest{}).
+		Where("user_id = ? AND status = ?", userID, domain.RecoveryStatusPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Review records an admin's decision on a pending request. The status check and update happen in one
+// statement, so two admins can't both decide the same request; it reports whether the request was pending.
+func (r *RecoveryRequestRepository) Review(
+	id string,
+	status domain.RecoveryStatus,
+	reviewerID uint,
+	note string,
+	at time.Time,
+) (bool, error) {
+	result := r.db.Model(&domain.RecoveryRequest{}).
+		Where("id = ? AND status = ?", id, domain.RecoveryStatusPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": reviewerID,
+			"review_note": note,
+			"reviewed_at": at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Reopen puts a reviewed request back in the queue, clearing the decision. It only touches a request
+// with the given status, and reports whether it did.
+func (r *RecoveryRequestRepository) Reopen(id string, status domain.RecoveryStatus) (bool, error) {
+	result := r.db.Model(&domain.RecoveryRequest{}).
+		Where("id = ? AND status = ?", id, status).
+		Updates(map[string]interface{}{
+			"status":      domain.RecoveryStatusPending,
+			"reviewed_by": nil,
+			"review_note": "",
+			"reviewed_at": nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// List retrieves recovery requests, optionally with one status, oldest first so the queue is worked in order
+func (r *RecoveryRequestRepository) List(status domain.RecoveryStatus, page, pageSize int) ([]*domain.RecoveryRequest, int, error) {
+	query := r.db.Model(&domain.RecoveryRequest{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	requests := make([]*domain.RecoveryRequest, 0, pageSize)
+	err := query.
+		Order("created_at ASC, id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&requests).Error
+	return requests, int(total), err
+}
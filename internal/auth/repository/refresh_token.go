@@ -38,6 +38,19 @@ func (r *RefreshTokenRepository) GetByToken(token string) (*domain.RefreshToken,
 	return &refreshToken, nil
 }
 
+// GetByID gets a refresh token by its ID
+func (r *RefreshTokenRepository) GetByID(id uint) (*domain.RefreshToken, error) {
+	var refreshToken domain.RefreshToken
+	err := r.db.First(&refreshToken, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrTokenNotFound
+		}
+		return nil, err
+	}
+	return &refreshToken, nil
+}
+
 // GetByUserID gets all refresh tokens for a user
 func (r *RefreshTokenRepository) GetByUserID(userID uint) ([]*domain.RefreshToken, error) {
 	var tokens []*domain.RefreshToken
@@ -55,6 +68,24 @@ func (r *RefreshTokenRepository) DeleteByUserID(userID uint) error {
 	return r.db.Where("user_id = ?", userID).Delete(&domain.RefreshToken{}).Error
 }
 
+// DeleteByUserIDExcept deletes all refresh tokens for a user except keepToken, returning
+// the number of tokens deleted. An empty keepToken deletes every token.
+func (r *RefreshTokenRepository) DeleteByUserIDExcept(userID uint, keepToken string) (int64, error) {
+	query := r.db.Where("user_id = ?", userID)
+	if keepToken != "" {
+		query = query.Where("token <> ?", keepToken)
+	}
+	result := query.Delete(&domain.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteByDevice deletes a user's refresh tokens issued to the device with deviceKey, returning
+// the number of tokens deleted
+func (r *RefreshTokenRepository) DeleteByDevice(userID uint, deviceKey string) (int64, error) {
+	result := r.db.Where("user_id = ? AND device_key = ?", userID, deviceKey).Delete(&domain.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
+
 // DeleteExpired deletes all expired refresh tokens
 func (r *RefreshTokenRepository) DeleteExpired() error {
 	return r.db.Where("expires_at < ?", time.Now()).Delete(&domain.RefreshToken{}).Error
@@ -65,6 +96,11 @@ func (r *RefreshTokenRepository) Update(token *domain.RefreshToken) error {
 	return r.db.Save(token).Error
 }
 
+// MarkRefreshed records when a refresh token was last used to refresh its session
+func (r *RefreshTokenRepository) MarkRefreshed(id uint, at time.Time) error {
+	return r.db.Model(&domain.RefreshToken{}).Where("id = ?", id).UpdateColumn("refreshed_at", at).Error
+}
+
 // GetActiveTokensCount returns the count of active tokens for a user
 func (r *RefreshTokenRepository) GetActiveTokensCount(userID uint) (int64, error) {
 	var count int64
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryRevocationStore keeps access token revocations in this process only, so other replicas keep
+// accepting the revoked tokens until they expire
+type MemoryRevocationStore struct {
+	mu       sync.Mutex
+	users    map[uint]revocation
+	sessions map[string]revocation
+}
+
+type revocation struct {
+	at        time.Time
+	expiresAt time.Time
+}
+
+// NewMemoryRevocationStore creates a new in-process revocation store
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		users:    make(map[uint]revocation),
+		sessions: make(map[string]revocation),
+	}
+}
+
+// RevokeUser records that a user's access tokens issued by at are revoked, for ttl
+func (s *MemoryRevocationStore) RevokeUser(userID uint, at time.Time, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(at)
+	s.users[userID] = revocation{at: at, expiresAt: at.Add(ttl)}
+	return nil
+}
+
+// RevokeSession records that a session's access tokens issued by at are revoked, for ttl
+func (s *MemoryRevocationStore) RevokeSession(sessionID string, at time.Time, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(at)
+	s.sessions[sessionID] = revocation{at: at, expiresAt: at.Add(ttl)}
+	return nil
+}
+
+// RevokedAt returns when a user's and a session's access tokens were last revoked; the zero time
+// when they weren't. An empty session ID is never revoked.
+func (s *MemoryRevocationStore) RevokedAt(userID uint, sessionID string) (user, session time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user = s.users[userID].at
+	if sessionID != "" {
+		session = s.sessions[sessionID].at
+	}
+	return user, session, nil
+}
+
+// prune drops revocations whose tokens have all expired; the caller holds the lock
+func (s *MemoryRevocationStore) prune(now time.Time) {
+	for id, r := range s.users {
+		if now.After(r.expiresAt) {
+			delete(s.users, id)
+		}
+	}
+	for id, r := range s.sessions {
+		if now.After(r.expiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// RedisRevocationStore keeps access token revocations in Redis, so every replica rejects a revoked
+// token. Each revocation is a key holding the revocation time that Redis expires with the tokens it
+// covers. Every key shares one hash tag, so the store also works on a Redis Cluster.
+type RedisRevocationStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisRevocationStore creates a new Redis-backed revocation store whose keys start with prefix
+func NewRedisRevocationStore(client redis.UniversalClient, prefix string) *RedisRevocationStore {
+	return &RedisRevocationStore{client: client, prefix: "{" + prefix + "}"}
+}
+
+func (s *RedisRevocationStore) userKey(userID uint) string {
+	return s.prefix + ":user:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+func (s *RedisRevocationStore) sessionKey(sessionID string) string {
+	return s.prefix + ":session:" + sessionID
+}
+
+// RevokeUser records that a user's access tokens issued by at are revoked, for ttl
+func (s *RedisRevocationStore) RevokeUser(userID uint, at time.Time, ttl time.Duration) error {
+	return s.client.Set(context.Background(), s.userKey(userID), at.UnixNano(), ttl).Err()
+}
+
+// RevokeSession records that a session's access tokens issued by at are revoked, for ttl
+func (s *RedisRevocationStore) RevokeSession(sessionID string, at time.Time, ttl time.Duration) error {
+	return s.client.Set(context.Background(), s.sessionKey(sessionID), at.UnixNano(), ttl).Err()
+}
+
+// RevokedAt returns when a user's and a session's access tokens were last revoked; the zero time
+// when they weren't. An empty session ID is never revoked.
+func (s *RedisRevocationStore) RevokedAt(userID uint, sessionID string) (user, session time.Time, err error) {
+	keys := []string{s.userKey(userID)}
+	if sessionID != "" {
+		keys = append(keys, s.sessionKey(sessionID))
+	}
+	values, err := s.client.MGet(context.Background(), keys...).Result()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	times := make([]time.Time, 2)
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		nanos, err := strconv.ParseInt(fmt.Sprint(value), 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid revocation time: %w", err)
+		}
+		times[i] = time.Unix(0, nanos)
+	}
+	return times[0], times[1], nil
+}
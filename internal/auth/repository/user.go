@@ -41,7 +41,7 @@ func (r *UserRepository) GetByID(id uint) (*domain.User, error) {
 // GetByEmail gets a user by email
 func (r *UserRepository) GetByEmail(email string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.Where("LOWER(email) = ?", domain.NormalizeEmail(email)).First(&user).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, domain.ErrUserNotFound
@@ -64,6 +64,19 @@ func (r *UserRepository) GetByEmailVerifyToken(token string) (*domain.User, erro
 	return &user, nil
 }
 
+// GetByBackupEmailToken gets a user by the token confirming their new backup email
+func (r *UserRepository) GetByBackupEmailToken(token string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.Where("backup_email_token = ?", token).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 // Update updates a user
 func (r *UserRepository) Update(user *domain.User) error {
 	return r.db.Save(user).Error
@@ -75,15 +88,56 @@ func (r *UserRepository) UpdateLastLogin(userID uint) error {
 	return r.db.Model(&domain.User{}).Where("id = ?", userID).Update("last_login_at", &now).Error
 }
 
+// UpdateEmailVerifyToken sets a user's email verification token
+func (r *UserRepository) UpdateEmailVerifyToken(userID uint, token string) error {
+	return r.db.Model(&domain.User{}).Where("id = ?", userID).Update("email_verify_token", token).Error
+}
+
+// UpdateVerifyNudgedAt records when a verification reminder was last sent to a user
+func (r *UserRepository) UpdateVerifyNudgedAt(userID uint, at time.Time) error {
+	return r.db.Model(&domain.User{}).Where("id = ?", userID).Update("verify_nudged_at", &at).Error
+}
+
+// UpdateResetEmailSentAt records when a password reset email was last sent to a user
+func (r *UserRepository) UpdateResetEmailSentAt(userID uint, at time.Time) error {
+	return r.db.Model(&domain.User{}).Where("id = ?", userID).Update("reset_email_sent_at", &at).Error
+}
+
+// UpdateVerifyResends records a user's verification resend count and the start of its window
+func (r *UserRepository) UpdateVerifyResends(userID uint, windowStart time.Time, count int) error {
+	return r.db.Model(&domain.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"verify_resends":     count,
+		"verify_resend_from": &windowStart,
+	}).Error
+}
+
 // Delete soft deletes a user
 func (r *UserRepository) Delete(id uint) error {
 	return r.db.Delete(&domain.User{}, id).Error
 }
 
-// ExistsByEmail checks if a user exists by email
-func (r *UserRepository) ExistsByEmail(email string) (bool, error) {
+// ExistsByEmail checks if a user exists by email, counting soft-deleted users when includeDeleted is set
+func (r *UserRepository) ExistsByEmail(email string, includeDeleted bool) (bool, error) {
+	query := r.db
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+
+	var count int64
+	err := query.Model(&domain.User{}).Where("LOWER(email) = ?", domain.NormalizeEmail(email)).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ExistsByUsername checks whether a username is taken, compared normalized. Deleted accounts count,
+// as the unique index keeps their usernames too.
+func (r *UserRepository) ExistsByUsername(username string) (bool, error) {
 	var count int64
-	err := r.db.Model(&domain.User{}).Where("email = ?", email).Count(&count).Error
+	err := r.db.Unscoped().Model(&domain.User{}).
+		Where("username = ?", domain.NormalizeUsername(username)).
+		Count(&count).Error
 	if err != nil {
 		return false, err
 	}
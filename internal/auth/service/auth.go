@@ -1,38 +1,59 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/acheevo/tfa/internal/auth/domain"
-	"github.com/acheevo/tfa/internal/auth/repository"
+	"github.com/acheevo/tfa/internal/shared/captcha"
+	"github.com/acheevo/tfa/internal/shared/clock"
 	"github.com/acheevo/tfa/internal/shared/config"
+	applogger "github.com/acheevo/tfa/internal/shared/logger"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
 )
 
 // AuthService handles authentication operations
 type AuthService struct {
 	config            *config.Config
 	logger            *slog.Logger
-	userRepo          *repository.UserRepository
-	refreshTokenRepo  *repository.RefreshTokenRepository
-	passwordResetRepo *repository.PasswordResetRepository
+	userRepo          UserRepo
+	refreshTokenRepo  RefreshTokenRepo
+	passwordResetRepo PasswordResetRepo
 	jwtService        *JWTService
 	emailService      *EmailService
+	auditRepo         AuditRepo
+	clock             clock.Clock
+	securityLogger    *applogger.SecurityLogger
+	consentRepo       ConsentRepo
+	recoveryRepo      RecoveryRequestRepo
+	captcha           CaptchaVerifier
+	loginFailures     *loginFailures
 }
 
 // NewAuthService creates a new authentication service
 func NewAuthService(
 	config *config.Config,
 	logger *slog.Logger,
-	userRepo *repository.UserRepository,
-	refreshTokenRepo *repository.RefreshTokenRepository,
-	passwordResetRepo *repository.PasswordResetRepository,
+	userRepo UserRepo,
+	refreshTokenRepo RefreshTokenRepo,
+	passwordResetRepo PasswordResetRepo,
 	jwtService *JWTService,
 	emailService *EmailService,
+	auditRepo AuditRepo,
+	clk clock.Clock,
+	securityLogger *applogger.SecurityLogger,
+	consentRepo ConsentRepo,
+	recoveryRepo RecoveryRequestRepo,
+	captchaVerifier CaptchaVerifier,
 ) *AuthService {
 	return &AuthService{
 		config:            config,
@@ -42,13 +63,26 @@ func NewAuthService(
 		passwordResetRepo: passwordResetRepo,
 		jwtService:        jwtService,
 		emailService:      emailService,
+		auditRepo:         auditRepo,
+		clock:             clk,
+		securityLogger:    securityLogger,
+		consentRepo:       consentRepo,
+		recoveryRepo:      recoveryRepo,
+		captcha:           captchaVerifier,
+		loginFailures:     newLoginFailures(clk, config.LoginCaptchaWindowDuration()),
 	}
 }
 
-// Register registers a new user
-func (s *AuthService) Register(req *domain.RegisterRequest) (*domain.AuthResponse, error) {
+// Register registers a new user. Signing up accepts the current terms of service and privacy
+// policy, which is recorded together with the marketing choice.
+func (s *AuthService) Register(req *domain.RegisterRequest, ipAddress, userAgent string) (*domain.AuthResponse, error) {
+	if err := s.emailDomainPolicy().Check(req.Email); err != nil {
+		s.logger.Info("registration rejected by email domain policy", "email", req.Email, "error", err)
+		return nil, err
+	}
+
 	// Check if user already exists
-	exists, err := s.userRepo.ExistsByEmail(req.Email)
+	exists, err := s.userRepo.ExistsByEmail(req.Email, !s.config.UserDeletedEmailReuse)
 	if err != nil {
 		s.logger.Error("failed to check if user exists", "email", req.Email, "error", err)
 		return nil, fmt.Errorf("failed to check user existence: %w", err)
@@ -57,6 +91,15 @@ func (s *AuthService) Register(req *domain.RegisterRequest) (*domain.AuthRespons
 		return nil, domain.ErrUserAlreadyExists
 	}
 
+	var username *string
+	if req.Username != "" {
+		normalized, err := s.checkUsername(req.Username)
+		if err != nil {
+			return nil, err
+		}
+		username = &normalized
+	}
+
 	// Validate password strength
 	if err := s.validatePassword(req.Password); err != nil {
 		return nil, err
@@ -78,34 +121,49 @@ func (s *AuthService) Register(req *domain.RegisterRequest) (*domain.AuthRespons
 
 	// Create user
 	user := &domain.User{
-		Email:            strings.ToLower(strings.TrimSpace(req.Email)),
+		Email:            domain.NormalizeEmail(req.Email),
+		Username:         username,
 		PasswordHash:     passwordHash,
 		FirstName:        strings.TrimSpace(req.FirstName),
 		LastName:         strings.TrimSpace(req.LastName),
 		EmailVerified:    false,
 		EmailVerifyToken: emailVerifyToken,
-		Status:           domain.StatusActive,
+		Role:             s.registrationRole(),
+		Status:           s.registrationStatus(),
 	}
+	user.Preferences.Notifications.Marketing = req.MarketingOptIn
 
 	if err := s.userRepo.Create(user); err != nil {
 		s.logger.Error("failed to create user", "email", req.Email, "error", err)
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	s.recordRegistrationConsents(user.ID, req.MarketingOptIn, ipAddress, userAgent)
+
 	// Send email verification email
 	if err := s.emailService.SendEmailVerification(user.Email, emailVerifyToken, user.FirstName); err != nil {
 		s.logger.Error("failed to send email verification", "email", user.Email, "error", err)
 		// Don't fail registration if email fails to send
 	}
 
-	// Generate tokens
-	accessToken, err := s.jwtService.GenerateAccessToken(user)
+	// Accounts that start out inactive get no tokens until an admin lets them in
+	if !user.IsActive() {
+		s.logger.Info("user registered pending approval", "user_id", user.ID, "email", user.Email, "status", user.Status)
+		return &domain.AuthResponse{
+			User:            user.ToResponse(),
+			PendingApproval: user.Status == domain.StatusPending,
+		}, nil
+	}
+
+	// Generate tokens for a new session
+	sessionID, startedAt := uuid.NewString(), s.clock.Now()
+	accessToken, err := s.jwtService.GenerateSessionAccessToken(user, sessionID, startedAt)
 	if err != nil {
 		s.logger.Error("failed to generate access token", "user_id", user.ID, "error", err)
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, err := s.createRefreshToken(user.ID)
+	refreshToken, err := s.createRefreshToken(user.ID, domain.DeviceKey(req.DeviceID, ipAddress, userAgent), sessionID, startedAt)
 	if err != nil {
 		s.logger.Error("failed to create refresh token", "user_id", user.ID, "error", err)
 		return nil, fmt.Errorf("failed to create refresh token: %w", err)
@@ -121,27 +179,114 @@ func (s *AuthService) Register(req *domain.RegisterRequest) (*domain.AuthRespons
 	}, nil
 }
 
+// registrationRole returns the configured role for self-registered users
+func (s *AuthService) registrationRole() domain.UserRole {
+	if s.config.RegistrationDefaultRole == "" {
+		return domain.RoleUser
+	}
+	return domain.UserRole(s.config.RegistrationDefaultRole)
+}
+
+// registrationStatus returns the configured status for self-registered users
+func (s *AuthService) registrationStatus() domain.UserStatus {
+	if s.config.RegistrationDefaultStatus == "" {
+		return domain.StatusActive
+	}
+	return domain.UserStatus(s.config.RegistrationDefaultStatus)
+}
+
+// recordRegistrationConsents records the consents given by signing up
+func (s *AuthService) recordRegistrationConsents(userID uint, marketingOptIn bool, ipAddress, userAgent string) {
+	if s.consentRepo == nil {
+		return
+	}
+
+	policy := userdomain.ConsentPolicy{TermsVersion: s.config.TermsVersion, PrivacyVersion: s.config.PrivacyPolicyVersion}
+	decisions := map[userdomain.ConsentType]bool{
+		userdomain.ConsentTermsOfService:  true,
+		userdomain.ConsentPrivacyPolicy:   true,
+		userdomain.ConsentMarketingEmails: marketingOptIn,
+	}
+
+	consents := make([]*userdomain.UserConsent, 0, len(userdomain.ConsentTypes))
+	for _, consentType := range userdomain.ConsentTypes {
+		consents = append(consents, &userdomain.UserConsent{
+			UserID:    userID,
+			Type:      consentType,
+			Granted:   decisions[consentType],
+			Version:   policy.Version(consentType),
+			Source:    userdomain.ConsentSourceRegistration,
+			IPAddress: ipAddress,
+			UserAgent: userAgent,
+		})
+	}
+
+	if err := s.consentRepo.Create(consents...); err != nil {
+		s.logger.Error("failed to record registration consents", "user_id", userID, "error", err)
+		// Don't fail registration once the account exists
+	}
+}
+
 // Login authenticates a user and returns tokens
-func (s *AuthService) Login(req *domain.LoginRequest) (*domain.AuthResponse, error) {
+func (s *AuthService) Login(req *domain.LoginRequest, ipAddress, userAgent string) (*domain.AuthResponse, error) {
+	email := domain.NormalizeEmail(req.Email)
+	ipKey, accountKey := loginFailureKeys(email, ipAddress)
+
+	if err := s.checkLoginCaptcha(req.CaptchaToken, ipAddress, ipKey, accountKey); err != nil {
+		if err == domain.ErrCaptchaInvalid {
+			s.recordLoginAttempt(nil, email, false, "captcha_invalid", ipAddress, userAgent)
+		}
+		return nil, err
+	}
+
 	// Get user by email
-	user, err := s.userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(req.Email)))
+	user, err := s.userRepo.GetByEmail(email)
 	if err != nil {
 		if err == domain.ErrUserNotFound {
+			s.loginFailures.record(ipKey, accountKey)
+			s.recordLoginAttempt(nil, email, false, "unknown_account", ipAddress, userAgent)
 			return nil, domain.ErrInvalidCredentials
 		}
 		s.logger.Error("failed to get user by email", "email", req.Email, "error", err)
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Check if user is active
-	if !user.IsActive() {
-		return nil, domain.ErrUserInactive
+	// Verify password, falling back to the replaced one while its grace lasts
+	graceReason := ""
+	if err := s.verifyPassword(req.Password, user.PasswordHash); err != nil {
+		if !s.previousPasswordMatches(user, req.Password) {
+			s.loginFailures.record(ipKey, accountKey)
+			s.recordLoginAttempt(&user.ID, email, false, "invalid_password", ipAddress, userAgent)
+			return nil, domain.ErrInvalidCredentials
+		}
+		graceReason = "previous_password_grace"
 	}
 
-	// Verify password
-	if err := s.verifyPassword(req.Password, user.PasswordHash); err != nil {
-		return nil, domain.ErrInvalidCredentials
+	// Only reveal the account status once the caller has proven they know the password
+	if err := user.StatusError(); err != nil {
+		s.recordLoginAttempt(&user.ID, email, false, string(user.Status)+"_account", ipAddress, userAgent)
+		return nil, err
+	}
+
+	// Without tokens the user can't call the resend endpoint, so a blocked login resends the
+	// verification email itself, throttled like the login reminder
+	if s.config.RequireVerifiedEmailForLogin && !user.EmailVerified {
+		s.recordLoginAttempt(&user.ID, email, false, "email_not_verified", ipAddress, userAgent)
+		s.sendVerificationNudge(user)
+		return nil, domain.ErrEmailNotVerified
+	}
+
+	s.recordLoginAttempt(&user.ID, email, true, graceReason, ipAddress, userAgent)
+	if graceReason != "" {
+		if err := s.emailService.SendPreviousPasswordUsed(
+			user.Email, user.FirstName, ipAddress, *user.PreviousPasswordUntil,
+		); err != nil {
+			s.logger.Error("failed to send previous password notice", "user_id", user.ID, "error", err)
+			// Don't fail login if this fails
+		}
 	}
+	// The account's owner is back; failures from the IP address keep counting
+	s.loginFailures.reset(accountKey)
 
 	// Update last login time
 	if err := s.userRepo.UpdateLastLogin(user.ID); err != nil {
@@ -149,14 +294,15 @@ func (s *AuthService) Login(req *domain.LoginRequest) (*domain.AuthResponse, err
 		// Don't fail login if this fails
 	}
 
-	// Generate tokens
-	accessToken, err := s.jwtService.GenerateAccessToken(user)
+	// Generate tokens for a new session
+	sessionID, startedAt := uuid.NewString(), s.clock.Now()
+	accessToken, err := s.jwtService.GenerateSessionAccessToken(user, sessionID, startedAt)
 	if err != nil {
 		s.logger.Error("failed to generate access token", "user_id", user.ID, "error", err)
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, err := s.createRefreshToken(user.ID)
+	refreshToken, err := s.createRefreshToken(user.ID, domain.DeviceKey(req.DeviceID, ipAddress, userAgent), sessionID, startedAt)
 	if err != nil {
 		s.logger.Error("failed to create refresh token", "user_id", user.ID, "error", err)
 		return nil, fmt.Errorf("failed to create refresh token: %w", err)
@@ -165,13 +311,143 @@ func (s *AuthService) Login(req *domain.LoginRequest) (*domain.AuthResponse, err
 	s.logger.Info("user logged in successfully", "user_id", user.ID, "email", user.Email)
 
 	return &domain.AuthResponse{
-		User:         user.ToResponse(),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int64(s.jwtService.GetAccessTokenDuration().Seconds()),
+		User:                 user.ToResponse(),
+		AccessToken:          accessToken,
+		RefreshToken:         refreshToken,
+		ExpiresIn:            int64(s.jwtService.GetAccessTokenDuration().Seconds()),
+		VerificationReminder: s.verificationReminder(user),
 	}, nil
 }
 
+// checkLoginCaptcha asks for a CAPTCHA once the client's IP address or the account has reached
+// LOGIN_CAPTCHA_THRESHOLD failed logins in the window, and verifies the token the login carries
+func (s *AuthService) checkLoginCaptcha(token, ipAddress string, failureKeys ...string) error {
+	if s.config.LoginCaptchaThreshold <= 0 || s.captcha == nil {
+		return nil
+	}
+	if s.loginFailures.highest(failureKeys...) < s.config.LoginCaptchaThreshold {
+		return nil
+	}
+	if token == "" {
+		return domain.ErrCaptchaRequired
+	}
+
+	if err := s.captcha.Verify(context.Background(), token, ipAddress); err != nil {
+		if errors.Is(err, captcha.ErrInvalid) {
+			return domain.ErrCaptchaInvalid
+		}
+		s.logger.Error("failed to verify login captcha", "ip_address", ipAddress, "error", err)
+		return domain.ErrCaptchaUnavailable
+	}
+	return nil
+}
+
+// previousPasswordMatches reports whether password is the user's replaced password and its login
+// grace hasn't run out
+func (s *AuthService) previousPasswordMatches(user *domain.User, password string) bool {
+	if !user.InPasswordGrace(s.clock.Now()) {
+		return false
+	}
+	return s.verifyPassword(password, user.PreviousPasswordHash) == nil
+}
+
+// verificationReminder builds the login-time reminder for unverified users, re-sending the
+// verification email at most once per configured interval. It never fails the login.
+func (s *AuthService) verificationReminder(user *domain.User) *domain.VerificationReminder {
+	if !s.config.LoginVerificationNudge || user.EmailVerified {
+		return nil
+	}
+
+	return &domain.VerificationReminder{
+		Message:    "Your email address is not verified. Please check your inbox for a verification link.",
+		EmailSent:  s.sendVerificationNudge(user),
+		ResendPath: "/api/auth/resend-verification",
+	}
+}
+
+// sendVerificationNudge re-sends the verification email if the user hasn't been reminded within
+// the configured interval, and reports whether an email went out
+func (s *AuthService) sendVerificationNudge(user *domain.User) bool {
+	now := s.clock.Now()
+	if !user.NeedsVerificationNudge(now, s.config.LoginVerificationNudgeIntervalDuration()) {
+		return false
+	}
+
+	if user.EmailVerifyToken == "" {
+		token, err := s.jwtService.GenerateRandomToken()
+		if err != nil {
+			s.logger.Error("failed to generate email verification token", "user_id", user.ID, "error", err)
+			return false
+		}
+		if err := s.userRepo.UpdateEmailVerifyToken(user.ID, token); err != nil {
+			s.logger.Error("failed to update user email verification token", "user_id", user.ID, "error", err)
+			return false
+		}
+		user.EmailVerifyToken = token
+	}
+
+	// Record the nudge first so a failing mail server doesn't trigger a resend on every login
+	if err := s.userRepo.UpdateVerifyNudgedAt(user.ID, now); err != nil {
+		s.logger.Error("failed to record verification reminder", "user_id", user.ID, "error", err)
+		return false
+	}
+
+	if err := s.emailService.SendVerificationReminder(user.Email, user.EmailVerifyToken, user.FirstName); err != nil {
+		s.logger.Error("failed to send verification reminder", "user_id", user.ID, "error", err)
+		return false
+	}
+
+	s.logger.Info("verification reminder sent on login", "user_id", user.ID)
+	return true
+}
+
+// recordLoginAttempt writes a login audit entry. A successful login with a reason, such as one with
+// a replaced password, is recorded at warning level. Failures are logged, never returned, so auditing
+// problems cannot block a login.
+func (s *AuthService) recordLoginAttempt(userID *uint, email string, success bool, reason, ipAddress, userAgent string) {
+	action := domain.AuditActionLoginSuccess
+	level := domain.AuditLevelInfo
+	description := fmt.Sprintf("Successful login for %s", email)
+	if success && reason != "" {
+		level = domain.AuditLevelWarning
+		description = fmt.Sprintf("Successful login for %s: %s", email, reason)
+	}
+	if !success {
+		action = domain.AuditActionLoginFailed
+		level = domain.AuditLevelWarning
+		description = fmt.Sprintf("Failed login for %s: %s", email, reason)
+	}
+
+	metadata := map[string]interface{}{
+		"email": email,
+	}
+	if reason != "" {
+		metadata["reason"] = reason
+	}
+
+	if success {
+		s.securityLogger.Log(applogger.SecurityEventLoginSuccess, description,
+			"user_id", *userID, "email", email, "reason", reason, "ip_address", ipAddress, "user_agent", userAgent)
+	} else {
+		s.securityLogger.Log(applogger.SecurityEventLoginFailure, description,
+			"user_id", userID, "email", email, "reason", reason, "ip_address", ipAddress, "user_agent", userAgent)
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		userID,
+		userID,
+		action,
+		level,
+		"auth",
+		description,
+		ipAddress,
+		userAgent,
+		metadata,
+	); err != nil {
+		s.logger.Error("failed to create audit log for login attempt", "email", email, "error", err)
+	}
+}
+
 // RefreshToken refreshes an access token using a refresh token
 func (s *AuthService) RefreshToken(req *domain.RefreshTokenRequest) (*domain.AuthResponse, error) {
 	// Get refresh token from database
@@ -181,12 +457,19 @@ func (s *AuthService) RefreshToken(req *domain.RefreshTokenRequest) (*domain.Aut
 	}
 
 	// Check if token is expired
-	if refreshToken.IsExpired() {
+	if refreshToken.IsExpiredAt(s.clock.Now()) {
 		// Clean up expired token
 		_ = s.refreshTokenRepo.Delete(refreshToken.Token)
 		return nil, domain.ErrTokenExpired
 	}
 
+	// However often it was refreshed, a session past its maximum age needs a fresh sign-in
+	if refreshToken.SessionExpiredAt(s.clock.Now(), s.config.SessionMaxAgeDuration()) {
+		_ = s.refreshTokenRepo.Delete(refreshToken.Token)
+		s.logger.Info("session reached its maximum age", "user_id", refreshToken.UserID, "session_id", refreshToken.SessionID)
+		return nil, domain.ErrSessionMaxAgeExceeded
+	}
+
 	// Get user
 	user, err := s.userRepo.GetByID(refreshToken.UserID)
 	if err != nil {
@@ -194,27 +477,55 @@ func (s *AuthService) RefreshToken(req *domain.RefreshTokenRequest) (*domain.Aut
 	}
 
 	// Check if user is active
-	if !user.IsActive() {
-		return nil, domain.ErrUserInactive
+	if err := user.StatusError(); err != nil {
+		return nil, err
 	}
 
 	// Generate new access token
-	accessToken, err := s.jwtService.GenerateAccessToken(user)
+	accessToken, err := s.jwtService.GenerateSessionAccessToken(user, refreshToken.SessionID, refreshToken.SessionStart())
 	if err != nil {
 		s.logger.Error("failed to generate access token", "user_id", user.ID, "error", err)
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	s.logger.Info("token refreshed successfully", "user_id", user.ID)
+	// Return the same refresh token, unless rotation is on and the token has been held long enough
+	newRefreshToken := refreshToken.Token
+	rotated := s.config.RefreshTokenRotation &&
+		refreshToken.RotationDue(s.clock.Now(), s.config.RefreshTokenRotationIntervalDuration())
+	if rotated {
+		newRefreshToken, err = s.rotateRefreshToken(refreshToken)
+		if err != nil {
+			s.logger.Error("failed to rotate refresh token", "user_id", user.ID, "error", err)
+			return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+		}
+	} else if err := s.refreshTokenRepo.MarkRefreshed(refreshToken.ID, s.clock.Now()); err != nil {
+		// Only the session's last-used time is lost, so the refresh still succeeds
+		s.logger.Warn("failed to record refresh token use", "user_id", user.ID, "error", err)
+	}
+
+	s.logger.Info("token refreshed successfully", "user_id", user.ID, "rotated", rotated)
 
 	return &domain.AuthResponse{
 		User:         user.ToResponse(),
 		AccessToken:  accessToken,
-		RefreshToken: refreshToken.Token, // Return the same refresh token
-		ExpiresIn:    int64(s.jwtService.GetAccessTokenDuration().Seconds()),
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(s.jwtService.SessionAccessTokenExpiresAt(refreshToken.SessionStart()).Sub(s.clock.Now()).Seconds()),
 	}, nil
 }
 
+// rotateRefreshToken replaces a refresh token with a new one for the same device. The new token is
+// stored before the old one is deleted, so a failure never leaves the session without a token.
+func (s *AuthService) rotateRefreshToken(current *domain.RefreshToken) (string, error) {
+	token, err := s.createRefreshToken(current.UserID, current.DeviceKey, current.SessionID, current.SessionStart())
+	if err != nil {
+		return "", err
+	}
+	if err := s.refreshTokenRepo.Delete(current.Token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
 // Logout invalidates a refresh token
 func (s *AuthService) Logout(refreshToken string) error {
 	if err := s.refreshTokenRepo.Delete(refreshToken); err != nil {
@@ -237,11 +548,80 @@ func (s *AuthService) LogoutAll(userID uint) error {
 	return nil
 }
 
+// RevokeUserSessions ends every session of a user immediately, deleting their refresh tokens and
+// denylisting access tokens that have not expired yet. It returns the number of sessions revoked.
+func (s *AuthService) RevokeUserSessions(userID uint) (int64, error) {
+	revoked, err := s.refreshTokenRepo.DeleteByUserIDExcept(userID, "")
+	if err != nil {
+		s.logger.Error("failed to revoke user sessions", "user_id", userID, "error", err)
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := s.jwtService.RevokeUserAccessTokens(userID); err != nil {
+		s.logger.Error("failed to revoke access tokens", "user_id", userID, "error", err)
+		return revoked, err
+	}
+
+	s.logger.Info("user sessions revoked", "user_id", userID, "sessions_revoked", revoked)
+	return revoked, nil
+}
+
+// ListUserSessions returns a user's unexpired sessions, one per refresh token, newest first
+func (s *AuthService) ListUserSessions(userID uint) ([]*domain.RefreshToken, error) {
+	tokens, err := s.refreshTokenRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	now := s.clock.Now()
+	sessions := make([]*domain.RefreshToken, 0, len(tokens))
+	for _, token := range tokens {
+		if !token.IsExpiredAt(now) {
+			sessions = append(sessions, token)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID > sessions[j].ID })
+	return sessions, nil
+}
+
+// GetSessionByID returns the session with the refresh token ID
+func (s *AuthService) GetSessionByID(tokenID uint) (*domain.RefreshToken, error) {
+	return s.refreshTokenRepo.GetByID(tokenID)
+}
+
+// RevokeSession ends one session immediately, deleting its refresh token and denylisting the access
+// tokens it was issued. The user's other sessions carry on. A session from before access tokens named
+// their session can't be told apart, so all of the user's access tokens are denylisted instead; the
+// other sessions recover by refreshing.
+func (s *AuthService) RevokeSession(tokenID uint) (*domain.RefreshToken, error) {
+	token, err := s.refreshTokenRepo.GetByID(tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshTokenRepo.Delete(token.Token); err != nil {
+		s.logger.Error("failed to revoke session", "user_id", token.UserID, "token_id", tokenID, "error", err)
+		return nil, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if token.SessionID != "" {
+		err = s.jwtService.RevokeSessionAccessTokens(token.SessionID)
+	} else {
+		err = s.jwtService.RevokeUserAccessTokens(token.UserID)
+	}
+	if err != nil {
+		s.logger.Error("failed to revoke access tokens", "user_id", token.UserID, "token_id", tokenID, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("session revoked", "user_id", token.UserID, "token_id", tokenID)
+	return token, nil
+}
+
 // VerifyEmail verifies a user's email address
 func (s *AuthService) VerifyEmail(req *domain.EmailVerificationRequest) error {
 	// Get user by email verification token
 	user, err := s.userRepo.GetByEmailVerifyToken(req.Token)
-	if err != nil {
+	if err != nil || !domain.TokenMatches(user.EmailVerifyToken, req.Token) {
 		return domain.ErrInvalidToken
 	}
 
@@ -264,9 +644,20 @@ func (s *AuthService) VerifyEmail(req *domain.EmailVerificationRequest) error {
 	return nil
 }
 
-// ForgotPassword initiates password reset process
+// ForgotPassword initiates password reset process. Resets can only be delivered by email, so
+// it fails with ErrEmailDisabled when email is turned off, before looking the user up. A request
+// within PASSWORD_RESET_EMAIL_INTERVAL of the last reset email to the account sends nothing. It fails
+// with ErrPasswordResetDisabled while the reset endpoint is disabled, as when an admin sends a reset.
 func (s *AuthService) ForgotPassword(req *domain.ForgotPasswordRequest) error {
-	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if s.config.AuthDisableResetPassword {
+		return domain.ErrPasswordResetDisabled
+	}
+	if !s.emailService.Enabled() {
+		s.logger.Warn("password reset requested while email is disabled, set EMAIL_ENABLED to allow resets")
+		return domain.ErrEmailDisabled
+	}
+
+	email := domain.NormalizeEmail(req.Email)
 
 	// Check if user exists
 	user, err := s.userRepo.GetByEmail(email)
@@ -280,15 +671,54 @@ func (s *AuthService) ForgotPassword(req *domain.ForgotPasswordRequest) error {
 		return fmt.Errorf("failed to process password reset request: %w", err)
 	}
 
+	// Requests too soon after the last reset email are dropped silently, so the address can't be flooded
+	now := s.clock.Now()
+	if user.ResetEmailSentAt != nil && now.Before(user.ResetEmailSentAt.Add(s.config.PasswordResetEmailIntervalDuration())) {
+		s.logger.Info("password reset email suppressed, requested too soon after the last one", "user_id", user.ID)
+		return nil
+	}
+	if err := s.userRepo.UpdateResetEmailSentAt(user.ID, now); err != nil {
+		s.logger.Error("failed to record password reset email", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to process password reset request: %w", err)
+	}
+
+	// An account without a password gets no reset link when the policy refuses. The request is answered
+	// the same as any other, so it doesn't tell which accounts have a password.
+	if !user.HasPassword() && s.config.PasswordlessResetPolicy() == config.PasswordlessResetRefuse {
+		if err := s.emailService.SendPasswordResetUnavailable(user.Email, user.FirstName); err != nil {
+			s.logger.Error("failed to send password reset unavailable email", "email", user.Email, "error", err)
+			return fmt.Errorf("failed to send password reset email: %w", err)
+		}
+		s.logger.Info("password reset refused for account without a password", "user_id", user.ID)
+		return nil
+	}
+
+	if err := s.issuePasswordReset(user, user.Email); err != nil {
+		return err
+	}
+
+	s.logger.Info("password reset requested", "email", email)
+	return nil
+}
+
+// issuePasswordReset creates a reset token for the user's account and mails the reset link to sendTo,
+// which is the account's own email except during account recovery. At most three unused tokens may
+// be outstanding per account, and none are issued while the reset endpoint is disabled.
+func (s *AuthService) issuePasswordReset(user *domain.User, sendTo string) error {
+	// A link to a switched off reset endpoint would be of no use
+	if s.config.AuthDisableResetPassword {
+		return domain.ErrPasswordResetDisabled
+	}
+
 	// Check rate limiting - don't allow too many reset requests
-	count, err := s.passwordResetRepo.GetValidTokensCount(email)
+	count, err := s.passwordResetRepo.GetValidTokensCount(user.Email)
 	if err != nil {
-		s.logger.Error("failed to get valid tokens count", "email", email, "error", err)
+		s.logger.Error("failed to get valid tokens count", "email", user.Email, "error", err)
 		return fmt.Errorf("failed to process password reset request: %w", err)
 	}
 	if count >= 3 {
-		s.logger.Warn("too many password reset requests", "email", email, "count", count)
-		return fmt.Errorf("too many password reset requests, please try again later")
+		s.logger.Warn("too many password reset requests", "email", user.Email, "count", count)
+		return domain.ErrTooManyResetRequests
 	}
 
 	// Generate reset token
@@ -300,29 +730,31 @@ func (s *AuthService) ForgotPassword(req *domain.ForgotPasswordRequest) error {
 
 	// Create password reset record
 	reset := &domain.PasswordReset{
-		Email:     email,
+		Email:     user.Email,
 		Token:     token,
-		ExpiresAt: time.Now().Add(24 * time.Hour), // 24 hours expiry
+		ExpiresAt: s.clock.Now().Add(24 * time.Hour), // 24 hours expiry
 		Used:      false,
 	}
 
 	if err := s.passwordResetRepo.Create(reset); err != nil {
-		s.logger.Error("failed to create password reset", "email", email, "error", err)
+		s.logger.Error("failed to create password reset", "email", user.Email, "error", err)
 		return fmt.Errorf("failed to create password reset: %w", err)
 	}
 
 	// Send password reset email
-	if err := s.emailService.SendPasswordReset(email, token, user.FirstName); err != nil {
-		s.logger.Error("failed to send password reset email", "email", email, "error", err)
+	if err := s.emailService.SendPasswordReset(sendTo, token, user.FirstName); err != nil {
+		s.logger.Error("failed to send password reset email", "email", sendTo, "error", err)
 		return fmt.Errorf("failed to send password reset email: %w", err)
 	}
 
-	s.logger.Info("password reset requested", "email", email)
 	return nil
 }
 
-// ResetPassword resets a user's password using a reset token
-func (s *AuthService) ResetPassword(req *domain.ResetPasswordRequest) error {
+// ResetPassword resets a user's password using a reset token and revokes the user's sessions. The
+// session identified by currentRefreshToken is kept when the configuration allows it. An account
+// without a password only gets one as PASSWORD_RESET_PASSWORDLESS allows: never, when the request
+// confirms the conversion, or always.
+func (s *AuthService) ResetPassword(req *domain.ResetPasswordRequest, currentRefreshToken, ipAddress, userAgent string) error {
 	// Validate passwords match
 	if req.Password != req.ConfirmPassword {
 		return domain.ErrPasswordsDoNotMatch
@@ -335,12 +767,12 @@ func (s *AuthService) ResetPassword(req *domain.ResetPasswordRequest) error {
 
 	// Get password reset token
 	reset, err := s.passwordResetRepo.GetByToken(req.Token)
-	if err != nil {
+	if err != nil || !domain.TokenMatches(reset.Token, req.Token) {
 		return domain.ErrInvalidToken
 	}
 
 	// Check if token is expired or used
-	if reset.IsExpired() {
+	if reset.IsExpiredAt(s.clock.Now()) {
 		return domain.ErrTokenExpired
 	}
 	if reset.Used {
@@ -353,6 +785,19 @@ func (s *AuthService) ResetPassword(req *domain.ResetPasswordRequest) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	// The token stays unused when refused, so the reset can be retried with the confirmation
+	converting := !user.HasPassword()
+	if converting {
+		switch s.config.PasswordlessResetPolicy() {
+		case config.PasswordlessResetRefuse:
+			return domain.ErrNoPasswordToReset
+		case config.PasswordlessResetConfirm:
+			if !req.ConvertToPassword {
+				return domain.ErrPasswordConvertRequired
+			}
+		}
+	}
+
 	// Hash new password
 	passwordHash, err := s.hashPassword(req.Password)
 	if err != nil {
@@ -360,8 +805,13 @@ func (s *AuthService) ResetPassword(req *domain.ResetPasswordRequest) error {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	// Update user password
-	user.PasswordHash = passwordHash
+	// Update user password. A bootstrap admin's default password was never meant to stay usable, so it
+	// gets no grace.
+	grace := s.config.PasswordLoginGraceDuration()
+	if user.PasswordChangeRequired {
+		grace = 0
+	}
+	user.SetPassword(passwordHash, grace, s.clock.Now())
 	if err := s.userRepo.Update(user); err != nil {
 		s.logger.Error("failed to update user password", "user_id", user.ID, "error", err)
 		return fmt.Errorf("failed to update password: %w", err)
@@ -373,57 +823,331 @@ func (s *AuthService) ResetPassword(req *domain.ResetPasswordRequest) error {
 		// Don't fail if this fails
 	}
 
-	// Invalidate all refresh tokens to force re-login
-	if err := s.refreshTokenRepo.DeleteByUserID(user.ID); err != nil {
+	if converting {
+		s.recordPasswordCreated(user, ipAddress, userAgent)
+	}
+
+	// Invalidate refresh tokens to force re-login, optionally sparing the session that performed the reset
+	keepToken := ""
+	if s.config.PasswordResetKeepsCurrentSession() && s.ownsRefreshToken(user.ID, currentRefreshToken) {
+		keepToken = currentRefreshToken
+	}
+	revoked, err := s.refreshTokenRepo.DeleteByUserIDExcept(user.ID, keepToken)
+	if err != nil {
 		s.logger.Error("failed to invalidate refresh tokens", "user_id", user.ID, "error", err)
 		// Don't fail if this fails
+	} else {
+		s.recordSessionRevocation(user.ID, revoked, keepToken != "", "password_reset", ipAddress, userAgent)
+	}
+	// Access tokens issued before the reset stop working too; a kept session recovers by refreshing
+	if err := s.jwtService.RevokeUserAccessTokens(user.ID); err != nil {
+		s.logger.Error("failed to revoke access tokens", "user_id", user.ID, "error", err)
+		// Don't fail if this fails
 	}
 
 	s.logger.Info("password reset successfully", "user_id", user.ID, "email", user.Email)
 	return nil
 }
 
-// ChangePassword changes a user's password
-func (s *AuthService) ChangePassword(userID uint, req *domain.ChangePasswordRequest) error {
+// ChangePassword changes a user's password and revokes the user's other sessions. The current
+// session, identified by currentRefreshToken, is kept with a fresh token pair unless the
+// configuration revokes every session.
+func (s *AuthService) ChangePassword(
+	userID uint,
+	req *domain.ChangePasswordRequest,
+	currentRefreshToken, ipAddress, userAgent string,
+) (*domain.ChangePasswordResponse, error) {
 	// Validate passwords match
 	if req.NewPassword != req.ConfirmPassword {
-		return domain.ErrPasswordsDoNotMatch
+		return nil, domain.ErrPasswordsDoNotMatch
 	}
 
 	// Validate password strength
 	if err := s.validatePassword(req.NewPassword); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Get user
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Verify current password
 	if err := s.verifyPassword(req.CurrentPassword, user.PasswordHash); err != nil {
-		return domain.ErrInvalidCredentials
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	// A bootstrap admin proves they own the email before replacing the default password
+	if user.PasswordChangeRequired && !user.EmailVerified {
+		return nil, domain.ErrEmailNotVerified
 	}
 
 	// Hash new password
 	passwordHash, err := s.hashPassword(req.NewPassword)
 	if err != nil {
 		s.logger.Error("failed to hash password", "error", err)
-		return fmt.Errorf("failed to hash password: %w", err)
+		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	// Update user password
-	user.PasswordHash = passwordHash
+	// Update user password. A change locks out anyone holding the old password, so it gets no grace.
+	user.SetPassword(passwordHash, 0, s.clock.Now())
 	if err := s.userRepo.Update(user); err != nil {
 		s.logger.Error("failed to update user password", "user_id", user.ID, "error", err)
-		return fmt.Errorf("failed to update password: %w", err)
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	// Revoke every other session so a changed password also locks out anyone holding a stolen token
+	revokeAll := s.config.PasswordChangeRevokesAllSessions()
+	keepToken := ""
+	if !revokeAll && s.ownsRefreshToken(user.ID, currentRefreshToken) {
+		keepToken = currentRefreshToken
+	}
+
+	revoked, err := s.refreshTokenRepo.DeleteByUserIDExcept(user.ID, keepToken)
+	if err != nil {
+		s.logger.Error("failed to revoke sessions after password change", "user_id", user.ID, "error", err)
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	s.recordSessionRevocation(user.ID, revoked, keepToken != "", "password_change", ipAddress, userAgent)
+
+	response := &domain.ChangePasswordResponse{
+		Message:         "password changed successfully",
+		SessionsRevoked: revoked,
+	}
+
+	if !revokeAll {
+		// Rotate the current session's tokens so the pre-change refresh token is no longer valid;
+		// the new token stays tied to the same device. Entering the current password counts as
+		// signing in again, so the session's age starts over.
+		deviceKey, sessionID, startedAt := "", uuid.NewString(), s.clock.Now()
+		if keepToken != "" {
+			if current, err := s.refreshTokenRepo.GetByToken(keepToken); err == nil {
+				deviceKey = current.DeviceKey
+				if current.SessionID != "" {
+					sessionID = current.SessionID
+				}
+			}
+			if err := s.refreshTokenRepo.Delete(keepToken); err != nil {
+				s.logger.Error("failed to rotate current refresh token", "user_id", user.ID, "error", err)
+				return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+			}
+		}
+
+		accessToken, err := s.jwtService.GenerateSessionAccessToken(user, sessionID, startedAt)
+		if err != nil {
+			s.logger.Error("failed to generate access token", "user_id", user.ID, "error", err)
+			return nil, fmt.Errorf("failed to generate access token: %w", err)
+		}
+
+		refreshToken, err := s.createRefreshToken(user.ID, deviceKey, sessionID, startedAt)
+		if err != nil {
+			s.logger.Error("failed to create refresh token", "user_id", user.ID, "error", err)
+			return nil, fmt.Errorf("failed to create refresh token: %w", err)
+		}
+
+		response.AccessToken = accessToken
+		response.RefreshToken = refreshToken
+		response.ExpiresIn = int64(s.jwtService.GetAccessTokenDuration().Seconds())
+	}
+
+	s.logger.Info("password changed successfully", "user_id", user.ID, "sessions_revoked", revoked)
+	return response, nil
+}
+
+// GetSession reports the remaining lifetime of the caller's session. The access token part comes
+// straight from the validated claims; the refresh token is only looked up when one is presented.
+func (s *AuthService) GetSession(claims *domain.JWTClaims, refreshToken string) *domain.SessionResponse {
+	var stored *domain.RefreshToken
+	if refreshToken != "" {
+		token, err := s.refreshTokenRepo.GetByToken(refreshToken)
+		if err == nil && token.UserID == claims.UserID {
+			stored = token
+		}
+	}
+
+	return domain.NewSessionResponse(claims, stored, s.clock.Now())
+}
+
+// StepUp re-verifies the user's password and issues a short-lived step-up token for sensitive actions
+func (s *AuthService) StepUp(userID uint, req *domain.StepUpRequest, ipAddress, userAgent string) (*domain.StepUpResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := user.StatusError(); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyPassword(req.Password, user.PasswordHash); err != nil {
+		s.recordStepUp(user, domain.AuditActionStepUpFailed, "Step-up authentication failed", ipAddress, userAgent)
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	token, expiresAt, err := s.jwtService.GenerateStepUpToken(user)
+	if err != nil {
+		s.logger.Error("failed to generate step-up token", "user_id", user.ID, "error", err)
+		return nil, fmt.Errorf("failed to generate step-up token: %w", err)
+	}
+
+	s.recordStepUp(user, domain.AuditActionStepUpVerified, "Step-up authentication succeeded", ipAddress, userAgent)
+
+	return &domain.StepUpResponse{
+		StepUpToken: token,
+		ExpiresAt:   expiresAt,
+		ExpiresIn:   int64(expiresAt.Sub(s.clock.Now()).Seconds()),
+	}, nil
+}
+
+// VerifyStepUp checks that token is a valid, unexpired step-up token issued to the user
+func (s *AuthService) VerifyStepUp(userID uint, token string) error {
+	if token == "" {
+		return domain.ErrStepUpRequired
 	}
 
-	s.logger.Info("password changed successfully", "user_id", user.ID)
+	claims, err := s.jwtService.ValidateStepUpToken(token)
+	if err != nil || claims.UserID != userID {
+		return domain.ErrStepUpRequired
+	}
 	return nil
 }
 
+// RecordSuperAdminAction audits a change made by a super admin. These entries are always
+// written at warning level so break-glass use stands out in the audit log.
+func (s *AuthService) RecordSuperAdminAction(userID uint, method, path string, status int, ipAddress, userAgent string) {
+	if err := s.auditRepo.CreateAuditEntry(
+		&userID,
+		nil,
+		domain.AuditActionSuperAdminAction,
+		domain.AuditLevelWarning,
+		"admin",
+		fmt.Sprintf("Super admin action: %s %s (%d)", method, path, status),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"method": method,
+			"path":   path,
+			"status": status,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for super admin action", "user_id", userID, "error", err)
+	}
+}
+
+// RecordAdminAccess writes the baseline access audit entry for an admin API call. Calls that were
+// refused authorization are recorded at warning level so probing stands out.
+func (s *AuthService) RecordAdminAccess(access *domain.AdminAccess) {
+	level := domain.AuditLevelInfo
+	if access.Status == http.StatusUnauthorized || access.Status == http.StatusForbidden {
+		level = domain.AuditLevelWarning
+	}
+
+	metadata := map[string]interface{}{
+		"method": access.Method,
+		"route":  access.Route,
+		"path":   access.Path,
+		"status": access.Status,
+	}
+	if access.Query != "" {
+		metadata["query"] = access.Query
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&access.UserID,
+		access.TargetID,
+		domain.AuditActionAdminAccess,
+		level,
+		"admin",
+		fmt.Sprintf("Admin access: %s %s (%d)", access.Method, access.Path, access.Status),
+		access.IPAddress,
+		access.UserAgent,
+		metadata,
+	); err != nil {
+		s.logger.Error("failed to create audit log for admin access", "user_id", access.UserID, "path", access.Path, "error", err)
+	}
+}
+
+// recordStepUp writes an audit entry for a step-up attempt
+func (s *AuthService) recordStepUp(user *domain.User, action domain.AuditAction, description, ipAddress, userAgent string) {
+	level := domain.AuditLevelInfo
+	if action == domain.AuditActionStepUpFailed || user.IsSuperAdmin() {
+		level = domain.AuditLevelWarning
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&user.ID,
+		&user.ID,
+		action,
+		level,
+		"auth",
+		description,
+		ipAddress,
+		userAgent,
+		map[string]interface{}{"role": user.Role},
+	); err != nil {
+		s.logger.Error("failed to create audit log for step-up", "user_id", user.ID, "error", err)
+	}
+}
+
+// ownsRefreshToken reports whether token is an unexpired refresh token belonging to the user
+func (s *AuthService) ownsRefreshToken(userID uint, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	refreshToken, err := s.refreshTokenRepo.GetByToken(token)
+	if err != nil {
+		return false
+	}
+
+	return refreshToken.UserID == userID && !refreshToken.IsExpiredAt(s.clock.Now())
+}
+
+// recordSessionRevocation writes an audit entry for sessions revoked by a password change or reset
+func (s *AuthService) recordSessionRevocation(userID uint, revoked int64, keptCurrent bool, reason, ipAddress, userAgent string) {
+	metadata := map[string]interface{}{
+		"reason":           reason,
+		"sessions_revoked": revoked,
+		"kept_current":     keptCurrent,
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&userID,
+		&userID,
+		domain.AuditActionSessionsRevoked,
+		domain.AuditLevelInfo,
+		"auth",
+		fmt.Sprintf("Revoked %d session(s) after %s", revoked, strings.ReplaceAll(reason, "_", " ")),
+		ipAddress,
+		userAgent,
+		metadata,
+	); err != nil {
+		s.logger.Error("failed to create audit log for session revocation", "user_id", userID, "error", err)
+	}
+}
+
+// recordPasswordCreated audits a password reset that gave a password to an account that had none
+func (s *AuthService) recordPasswordCreated(user *domain.User, ipAddress, userAgent string) {
+	metadata := map[string]interface{}{
+		"policy": s.config.PasswordlessResetPolicy(),
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&user.ID,
+		&user.ID,
+		domain.AuditActionPasswordCreated,
+		domain.AuditLevelWarning,
+		"auth",
+		"Password added to an account without one through password reset",
+		ipAddress,
+		userAgent,
+		metadata,
+	); err != nil {
+		s.logger.Error("failed to create audit log for password creation", "user_id", user.ID, "error", err)
+	}
+}
+
 // GetUserProfile gets a user's profile
 func (s *AuthService) GetUserProfile(userID uint) (*domain.UserResponse, error) {
 	user, err := s.userRepo.GetByID(userID)
@@ -439,6 +1163,32 @@ func (s *AuthService) ValidateAccessToken(tokenString string) (*domain.JWTClaims
 	return s.jwtService.ValidateAccessToken(tokenString)
 }
 
+// IsEmailAvailable reports whether no account uses the email, compared case-insensitively. Soft-deleted
+// accounts hold on to their email unless USER_DELETED_EMAIL_REUSE is set.
+func (s *AuthService) IsEmailAvailable(email string) (bool, error) {
+	exists, err := s.userRepo.ExistsByEmail(domain.NormalizeEmail(email), !s.config.UserDeletedEmailReuse)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email availability: %w", err)
+	}
+	return !exists, nil
+}
+
+// IntrospectToken describes an access token for another service, following RFC 7662. Malformed,
+// expired and revoked tokens are all just inactive, as are tokens of users who have since been
+// deleted or are no longer active.
+func (s *AuthService) IntrospectToken(token string) *domain.IntrospectionResponse {
+	claims, err := s.jwtService.ValidateAccessToken(token)
+	if err != nil {
+		return &domain.IntrospectionResponse{Active: false}
+	}
+
+	user, err := s.userRepo.GetByID(claims.UserID)
+	if err != nil || !user.IsActive() {
+		return &domain.IntrospectionResponse{Active: false}
+	}
+	return claims.Introspection()
+}
+
 // ResendEmailVerification resends email verification email
 func (s *AuthService) ResendEmailVerification(userID uint) error {
 	user, err := s.userRepo.GetByID(userID)
@@ -447,7 +1197,17 @@ func (s *AuthService) ResendEmailVerification(userID uint) error {
 	}
 
 	if user.EmailVerified {
-		return fmt.Errorf("email already verified")
+		return domain.ErrEmailAlreadyVerified
+	}
+
+	// An explicit resend is pointless if nothing will be sent
+	if !s.emailService.Enabled() {
+		return domain.ErrEmailDisabled
+	}
+
+	// Cap resends per account so the endpoint can't be used to flood the address
+	if err := s.recordVerificationResend(user); err != nil {
+		return err
 	}
 
 	// Generate new verification token if empty
@@ -476,18 +1236,95 @@ func (s *AuthService) ResendEmailVerification(userID uint) error {
 
 // Helper methods
 
-func (s *AuthService) createRefreshToken(userID uint) (string, error) {
+// recordVerificationResend counts a verification resend against the user's window, refusing it once
+// the configured cap is reached. The resend is recorded before sending, so failed sends count too.
+func (s *AuthService) recordVerificationResend(user *domain.User) error {
+	now := s.clock.Now()
+	window := s.config.VerificationResendWindowDuration()
+	sent := user.VerificationResendsInWindow(now, window)
+
+	if s.config.VerificationResendMax > 0 && sent >= s.config.VerificationResendMax {
+		s.logger.Warn("too many verification resends", "user_id", user.ID, "count", sent)
+		return domain.ErrTooManyVerifyResends
+	}
+
+	windowStart := now
+	if sent > 0 {
+		windowStart = *user.VerifyResendFrom
+	}
+	if err := s.userRepo.UpdateVerifyResends(user.ID, windowStart, sent+1); err != nil {
+		s.logger.Error("failed to record verification resend", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to record verification resend: %w", err)
+	}
+	user.VerifyResends = sent + 1
+	user.VerifyResendFrom = &windowStart
+	return nil
+}
+
+// emailDomainPolicy builds the registration email domain policy from config
+func (s *AuthService) emailDomainPolicy() *domain.EmailDomainPolicy {
+	return &domain.EmailDomainPolicy{
+		Allowed:         s.config.GetRegistrationAllowedDomains(),
+		Blocked:         s.config.GetRegistrationBlockedDomains(),
+		BlockDisposable: s.config.RegistrationBlockDisposable,
+	}
+}
+
+// checkUsername checks a chosen username against the username policy and the taken usernames, and
+// returns it normalized
+func (s *AuthService) checkUsername(username string) (string, error) {
+	policy := &domain.UsernamePolicy{
+		MinLength: s.config.UsernameMinLength,
+		MaxLength: s.config.UsernameMaxLength,
+		Reserved:  s.config.GetUsernameReserved(),
+	}
+	if err := policy.Check(username); err != nil {
+		s.logger.Info("username rejected by username policy", "username", username, "error", err)
+		return "", err
+	}
+
+	username = domain.NormalizeUsername(username)
+	taken, err := s.userRepo.ExistsByUsername(username)
+	if err != nil {
+		s.logger.Error("failed to check if username exists", "username", username, "error", err)
+		return "", fmt.Errorf("failed to check username: %w", err)
+	}
+	if taken {
+		return "", domain.ErrUsernameTaken
+	}
+	return username, nil
+}
+
+// createRefreshToken issues a refresh token for the device with deviceKey, in the session its access
+// tokens name, which the user signed in to at startedAt. With per-device tokens enabled it replaces
+// the tokens that device already holds, so signing in again does not add a session.
+func (s *AuthService) createRefreshToken(userID uint, deviceKey, sessionID string, startedAt time.Time) (string, error) {
 	// Generate refresh token
 	tokenStr, err := s.jwtService.GenerateRefreshToken()
 	if err != nil {
 		return "", err
 	}
 
+	if s.config.RefreshTokenPerDevice && deviceKey != "" {
+		replaced, err := s.refreshTokenRepo.DeleteByDevice(userID, deviceKey)
+		if err != nil {
+			return "", err
+		}
+		if replaced > 0 {
+			s.logger.Info("replaced refresh token for device", "user_id", userID, "replaced", replaced)
+		}
+	}
+
 	// Create refresh token record
+	now := s.clock.Now()
 	refreshToken := &domain.RefreshToken{
-		UserID:    userID,
-		Token:     tokenStr,
-		ExpiresAt: time.Now().Add(s.jwtService.GetRefreshTokenDuration()),
+		UserID:     userID,
+		Token:      tokenStr,
+		DeviceKey:  deviceKey,
+		ExpiresAt:  now.Add(s.jwtService.GetRefreshTokenDuration()),
+		LastUsedAt: &now,
+		SessionID:  sessionID,
+		StartedAt:  &startedAt,
 	}
 
 	if err := s.refreshTokenRepo.Create(refreshToken); err != nil {
@@ -0,0 +1,909 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+type authTestRepos struct {
+	users         *fakeUserRepo
+	refreshTokens *fakeRefreshTokenRepo
+	audit         *fakeAuditRepo
+	consents      *fakeConsentRepo
+	resets        *fakePasswordResetRepo
+	recoveries    *fakeRecoveryRepo
+	clock         *clock.Mock
+	jwt           *JWTService
+}
+
+func newTestAuthService(cfg *config.Config, users ...*domain.User) (*AuthService, *authTestRepos) {
+	cfg.JWTSecret = "test-jwt-secret-key-for-testing-only-and-this-is-long-enough"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	repos := &authTestRepos{
+		users:         newFakeUserRepo(users...),
+		refreshTokens: newFakeRefreshTokenRepo(),
+		audit:         &fakeAuditRepo{},
+		consents:      &fakeConsentRepo{},
+		resets:        newFakePasswordResetRepo(),
+		recoveries:    &fakeRecoveryRepo{},
+		clock:         clk,
+		jwt:           NewJWTService(cfg, clk, nil),
+	}
+	s := NewAuthService(
+		cfg,
+		logger,
+		repos.users,
+		repos.refreshTokens,
+		repos.resets,
+		repos.jwt,
+		NewEmailService(cfg, logger),
+		repos.audit,
+		clk,
+		nil,
+		repos.consents,
+		repos.recoveries,
+		nil,
+	)
+	return s, repos
+}
+
+func testUser(t *testing.T, email string, status domain.UserStatus) *domain.User {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	return &domain.User{
+		Email:         email,
+		PasswordHash:  string(hash),
+		FirstName:     "Test",
+		Role:          domain.RoleUser,
+		Status:        status,
+		EmailVerified: true,
+	}
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  error
+	}{
+		{"active user", "active@example.com", "password123", nil},
+		{"email is normalized", "  Active@Example.com ", "password123", nil},
+		{"wrong password", "active@example.com", "wrong-password", domain.ErrInvalidCredentials},
+		{"unknown account", "nobody@example.com", "password123", domain.ErrInvalidCredentials},
+		{"suspended user", "suspended@example.com", "password123", domain.ErrUserSuspended},
+		{"inactive user", "inactive@example.com", "password123", domain.ErrUserInactive},
+		{"pending user", "pending@example.com", "password123", domain.ErrUserPendingApproval},
+		{"wrong password hides status", "suspended@example.com", "wrong-password", domain.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repos := newTestAuthService(&config.Config{},
+				testUser(t, "active@example.com", domain.StatusActive),
+				testUser(t, "suspended@example.com", domain.StatusSuspended),
+				testUser(t, "inactive@example.com", domain.StatusInactive),
+				testUser(t, "pending@example.com", domain.StatusPending),
+			)
+
+			response, err := s.Login(&domain.LoginRequest{Email: tt.email, Password: tt.password}, "127.0.0.1", "test")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, response)
+				assert.Empty(t, repos.refreshTokens.tokens)
+				assert.Equal(t, []domain.AuditAction{domain.AuditActionLoginFailed}, repos.audit.actions)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, response.AccessToken)
+			assert.Contains(t, repos.refreshTokens.tokens, response.RefreshToken)
+			assert.Equal(t, []domain.AuditAction{domain.AuditActionLoginSuccess}, repos.audit.actions)
+		})
+	}
+}
+
+func TestLoginRequiresVerifiedEmail(t *testing.T) {
+	unverified := testUser(t, "new@example.com", domain.StatusActive)
+	unverified.EmailVerified = false
+	s, repos := newTestAuthService(&config.Config{RequireVerifiedEmailForLogin: true}, unverified)
+
+	_, err := s.Login(&domain.LoginRequest{Email: "new@example.com", Password: "password123"}, "127.0.0.1", "test")
+
+	assert.ErrorIs(t, err, domain.ErrEmailNotVerified)
+	assert.Empty(t, repos.refreshTokens.tokens)
+}
+
+func TestPreviousPasswordLoginGrace(t *testing.T) {
+	login := func(s *AuthService, password string) error {
+		_, err := s.Login(&domain.LoginRequest{Email: "user@example.com", Password: password}, "127.0.0.1", "test")
+		return err
+	}
+	resetPassword := func(t *testing.T, s *AuthService, repos *authTestRepos) {
+		assert.NoError(t, repos.resets.Create(&domain.PasswordReset{
+			Email: "user@example.com", Token: "reset-token", ExpiresAt: repos.clock.Now().Add(time.Hour),
+		}))
+		assert.NoError(t, s.ResetPassword(&domain.ResetPasswordRequest{
+			Token: "reset-token", Password: "new-password456", ConfirmPassword: "new-password456",
+		}, "", "127.0.0.1", "test"))
+		repos.audit.actions, repos.audit.levels = nil, nil
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{PasswordLoginGrace: "0"}, testUser(t, "user@example.com", domain.StatusActive))
+		resetPassword(t, s, repos)
+
+		assert.ErrorIs(t, login(s, "password123"), domain.ErrInvalidCredentials)
+		assert.NoError(t, login(s, "new-password456"))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{PasswordLoginGrace: "1h"}, testUser(t, "user@example.com", domain.StatusActive))
+		resetPassword(t, s, repos)
+
+		// The old password signs in, audited at warning level, alongside the new one
+		assert.NoError(t, login(s, "password123"))
+		assert.NoError(t, login(s, "new-password456"))
+		assert.Equal(t, []domain.AuditAction{domain.AuditActionLoginSuccess, domain.AuditActionLoginSuccess}, repos.audit.actions)
+		assert.Equal(t, []domain.AuditLevel{domain.AuditLevelWarning, domain.AuditLevelInfo}, repos.audit.levels)
+
+		repos.clock.Advance(time.Hour)
+		assert.ErrorIs(t, login(s, "password123"), domain.ErrInvalidCredentials)
+		assert.NoError(t, login(s, "new-password456"))
+	})
+
+	t.Run("grace does not skip the status check", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{PasswordLoginGrace: "1h"}, testUser(t, "user@example.com", domain.StatusActive))
+		resetPassword(t, s, repos)
+		user, _ := repos.users.GetByEmail("user@example.com")
+		user.Status = domain.StatusSuspended
+
+		assert.ErrorIs(t, login(s, "password123"), domain.ErrUserSuspended)
+	})
+
+	t.Run("not after a password change", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{PasswordLoginGrace: "1h"}, testUser(t, "user@example.com", domain.StatusActive))
+		user, _ := repos.users.GetByEmail("user@example.com")
+		_, err := s.ChangePassword(user.ID, &domain.ChangePasswordRequest{
+			CurrentPassword: "password123",
+			NewPassword:     "new-password456",
+			ConfirmPassword: "new-password456",
+		}, "", "127.0.0.1", "test")
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, login(s, "password123"), domain.ErrInvalidCredentials)
+	})
+
+	t.Run("not for a bootstrap admin's default password", func(t *testing.T) {
+		admin := testUser(t, "user@example.com", domain.StatusActive)
+		admin.PasswordChangeRequired = true
+		s, repos := newTestAuthService(&config.Config{PasswordLoginGrace: "1h"}, admin)
+		resetPassword(t, s, repos)
+
+		assert.ErrorIs(t, login(s, "password123"), domain.ErrInvalidCredentials)
+		assert.NoError(t, login(s, "new-password456"))
+	})
+}
+
+func TestChangePasswordCompletesAdminSetup(t *testing.T) {
+	admin := testUser(t, "admin@example.com", domain.StatusActive)
+	admin.Role = domain.RoleAdmin
+	admin.EmailVerified = false
+	admin.EmailVerifyToken = "setup-token"
+	admin.PasswordChangeRequired = true
+	s, repos := newTestAuthService(&config.Config{PasswordChangeSessions: "revoke_all"}, admin)
+	user, _ := repos.users.GetByEmail("admin@example.com")
+
+	changePassword := func() error {
+		_, err := s.ChangePassword(user.ID, &domain.ChangePasswordRequest{
+			CurrentPassword: "password123",
+			NewPassword:     "new-password456",
+			ConfirmPassword: "new-password456",
+		}, "", "127.0.0.1", "test")
+		return err
+	}
+
+	// The email is verified first, so the default password can't be replaced by whoever guesses it
+	assert.ErrorIs(t, changePassword(), domain.ErrEmailNotVerified)
+	assert.True(t, user.PasswordChangeRequired)
+
+	assert.NoError(t, s.VerifyEmail(&domain.EmailVerificationRequest{Token: "setup-token"}))
+	assert.ErrorIs(t, user.ToResponse().SetupError(), domain.ErrPasswordChangeRequired)
+
+	assert.NoError(t, changePassword())
+	assert.False(t, user.PasswordChangeRequired)
+	assert.NoError(t, user.ToResponse().SetupError())
+}
+
+func TestPasswordResetWithoutPassword(t *testing.T) {
+	// setup returns a service holding an account without a password, with a reset token for it
+	setup := func(t *testing.T, policy string) (*AuthService, *authTestRepos, *domain.User) {
+		passwordless := testUser(t, "sso@example.com", domain.StatusActive)
+		passwordless.PasswordHash = ""
+		s, repos := newTestAuthService(&config.Config{EmailEnabled: true, PasswordResetPasswordless: policy}, passwordless)
+		assert.NoError(t, repos.resets.Create(&domain.PasswordReset{
+			Email: passwordless.Email, Token: "reset-token", ExpiresAt: repos.clock.Now().Add(time.Hour),
+		}))
+		return s, repos, passwordless
+	}
+	reset := func(s *AuthService, convert bool) error {
+		return s.ResetPassword(&domain.ResetPasswordRequest{
+			Token: "reset-token", Password: "new-password456", ConfirmPassword: "new-password456", ConvertToPassword: convert,
+		}, "", "127.0.0.1", "test")
+	}
+
+	t.Run("refuse", func(t *testing.T) {
+		s, repos, user := setup(t, config.PasswordlessResetRefuse)
+
+		// The request succeeds as for any account, but no reset link is issued
+		assert.NoError(t, s.ForgotPassword(&domain.ForgotPasswordRequest{Email: user.Email}))
+		assert.Len(t, repos.resets.resets, 1)
+
+		assert.ErrorIs(t, reset(s, true), domain.ErrNoPasswordToReset)
+		assert.False(t, user.HasPassword())
+		assert.False(t, repos.resets.resets["reset-token"].Used)
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		s, repos, user := setup(t, config.PasswordlessResetConfirm)
+
+		assert.NoError(t, s.ForgotPassword(&domain.ForgotPasswordRequest{Email: user.Email}))
+		assert.Len(t, repos.resets.resets, 2)
+
+		// The token survives a reset that didn't confirm, so it can be retried with the confirmation
+		assert.ErrorIs(t, reset(s, false), domain.ErrPasswordConvertRequired)
+		assert.False(t, user.HasPassword())
+		assert.False(t, repos.resets.resets["reset-token"].Used)
+
+		assert.NoError(t, reset(s, true))
+		assert.True(t, user.HasPassword())
+		assert.Contains(t, repos.audit.actions, domain.AuditActionPasswordCreated)
+	})
+
+	t.Run("allow", func(t *testing.T) {
+		s, repos, user := setup(t, config.PasswordlessResetAllow)
+
+		assert.NoError(t, reset(s, false))
+		assert.True(t, user.HasPassword())
+		assert.Contains(t, repos.audit.actions, domain.AuditActionPasswordCreated)
+	})
+
+	t.Run("accounts with a password are unaffected", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{EmailEnabled: true}, testUser(t, "user@example.com", domain.StatusActive))
+		assert.NoError(t, repos.resets.Create(&domain.PasswordReset{
+			Email: "user@example.com", Token: "reset-token", ExpiresAt: repos.clock.Now().Add(time.Hour),
+		}))
+
+		assert.NoError(t, reset(s, false))
+		assert.NotContains(t, repos.audit.actions, domain.AuditActionPasswordCreated)
+	})
+}
+
+func TestForgotPasswordEmailInterval(t *testing.T) {
+	forgot := func(t *testing.T, s *AuthService) {
+		assert.NoError(t, s.ForgotPassword(&domain.ForgotPasswordRequest{Email: "user@example.com"}))
+	}
+
+	t.Run("rapid requests send one email", func(t *testing.T) {
+		s, repos := newTestAuthService(
+			&config.Config{EmailEnabled: true, PasswordResetEmailInterval: "60s"},
+			testUser(t, "user@example.com", domain.StatusActive),
+		)
+
+		forgot(t, s)
+		forgot(t, s)
+		repos.clock.Advance(59 * time.Second)
+		forgot(t, s)
+		assert.Len(t, repos.resets.resets, 1)
+
+		// Spaced requests each get an email; suppressed ones don't push the interval back
+		repos.clock.Advance(time.Second)
+		forgot(t, s)
+		assert.Len(t, repos.resets.resets, 2)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s, repos := newTestAuthService(
+			&config.Config{EmailEnabled: true, PasswordResetEmailInterval: "0"},
+			testUser(t, "user@example.com", domain.StatusActive),
+		)
+
+		forgot(t, s)
+		forgot(t, s)
+		assert.Len(t, repos.resets.resets, 2)
+	})
+
+	t.Run("per address", func(t *testing.T) {
+		s, repos := newTestAuthService(
+			&config.Config{EmailEnabled: true, PasswordResetEmailInterval: "60s"},
+			testUser(t, "user@example.com", domain.StatusActive),
+			testUser(t, "other@example.com", domain.StatusActive),
+		)
+
+		forgot(t, s)
+		assert.NoError(t, s.ForgotPassword(&domain.ForgotPasswordRequest{Email: "other@example.com"}))
+		assert.Len(t, repos.resets.resets, 2)
+	})
+}
+
+func TestForgotPasswordWhenResetDisabled(t *testing.T) {
+	// Admins can still ask for a reset when the public endpoints are off; no dead link is sent
+	s, repos := newTestAuthService(
+		&config.Config{EmailEnabled: true, AuthDisableForgotPassword: true, AuthDisableResetPassword: true},
+		testUser(t, "user@example.com", domain.StatusActive),
+	)
+
+	err := s.ForgotPassword(&domain.ForgotPasswordRequest{Email: "user@example.com"})
+	assert.ErrorIs(t, err, domain.ErrPasswordResetDisabled)
+	assert.Empty(t, repos.resets.resets)
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	login := func(t *testing.T, s *AuthService) string {
+		response, err := s.Login(&domain.LoginRequest{Email: "user@example.com", Password: "password123"}, "127.0.0.1", "test")
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		return response.RefreshToken
+	}
+	refresh := func(t *testing.T, s *AuthService, token string) string {
+		response, err := s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: token})
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		assert.NotEmpty(t, response.AccessToken)
+		return response.RefreshToken
+	}
+
+	t.Run("rapid refreshes keep the token", func(t *testing.T) {
+		cfg := &config.Config{RefreshTokenRotation: true, RefreshTokenRotationInterval: "1m"}
+		s, repos := newTestAuthService(cfg, testUser(t, "user@example.com", domain.StatusActive))
+		token := login(t, s)
+
+		for i := 0; i < 5; i++ {
+			repos.clock.Advance(10 * time.Second)
+			assert.Equal(t, token, refresh(t, s, token))
+		}
+		assert.Len(t, repos.refreshTokens.tokens, 1)
+
+		// Each refresh is recorded as a use, without holding off rotation
+		assert.Equal(t, repos.clock.Now(), repos.refreshTokens.tokens[token].LastUsed())
+		repos.clock.Advance(10 * time.Second)
+		assert.NotEqual(t, token, refresh(t, s, token))
+	})
+
+	t.Run("spaced refreshes rotate", func(t *testing.T) {
+		cfg := &config.Config{RefreshTokenRotation: true, RefreshTokenRotationInterval: "1m"}
+		s, repos := newTestAuthService(cfg, testUser(t, "user@example.com", domain.StatusActive))
+		token := login(t, s)
+
+		repos.clock.Advance(time.Minute)
+		rotated := refresh(t, s, token)
+		assert.NotEqual(t, token, rotated)
+		assert.NotContains(t, repos.refreshTokens.tokens, token, "the replaced token no longer refreshes")
+		assert.Contains(t, repos.refreshTokens.tokens, rotated)
+
+		_, err := s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: token})
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+
+		// The interval starts over from the rotation
+		repos.clock.Advance(30 * time.Second)
+		assert.Equal(t, rotated, refresh(t, s, rotated))
+		repos.clock.Advance(30 * time.Second)
+		assert.NotEqual(t, rotated, refresh(t, s, rotated))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{}, testUser(t, "user@example.com", domain.StatusActive))
+		token := login(t, s)
+
+		repos.clock.Advance(time.Hour)
+		assert.Equal(t, token, refresh(t, s, token))
+	})
+}
+
+func TestSessionMaxAge(t *testing.T) {
+	login := func(t *testing.T, s *AuthService) string {
+		response, err := s.Login(&domain.LoginRequest{Email: "user@example.com", Password: "password123"}, "127.0.0.1", "test")
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		return response.RefreshToken
+	}
+
+	t.Run("refresh succeeds within the limit", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{SessionMaxAge: "12h"}, testUser(t, "user@example.com", domain.StatusActive))
+		signedInAt := repos.clock.Now()
+		token := login(t, s)
+
+		repos.clock.Advance(12*time.Hour - time.Second)
+		response, err := s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: token})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// Access tokens carry the sign-in time, not the refresh time
+		claims, err := repos.jwt.ValidateAccessToken(response.AccessToken)
+		if !assert.NoError(t, err) || !assert.NotNil(t, claims.AuthTime) {
+			return
+		}
+		assert.Equal(t, signedInAt.Unix(), claims.AuthTime.Unix())
+
+		// The access token expires with the session rather than outliving it
+		assert.Equal(t, signedInAt.Add(12*time.Hour).Unix(), claims.ExpiresAt.Unix())
+		assert.Equal(t, int64(1), response.ExpiresIn)
+	})
+
+	t.Run("refresh fails beyond the limit", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{SessionMaxAge: "12h"}, testUser(t, "user@example.com", domain.StatusActive))
+		token := login(t, s)
+
+		repos.clock.Advance(12 * time.Hour)
+		_, err := s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: token})
+		assert.ErrorIs(t, err, domain.ErrSessionMaxAgeExceeded)
+		assert.Empty(t, repos.refreshTokens.tokens, "the expired session's token is removed")
+
+		// Signing in again starts a new session
+		_, err = s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: login(t, s)})
+		assert.NoError(t, err)
+	})
+
+	t.Run("rotation keeps the session start", func(t *testing.T) {
+		cfg := &config.Config{SessionMaxAge: "12h", RefreshTokenRotation: true, RefreshTokenRotationInterval: "1m"}
+		s, repos := newTestAuthService(cfg, testUser(t, "user@example.com", domain.StatusActive))
+		token := login(t, s)
+
+		for i := 0; i < 11; i++ {
+			repos.clock.Advance(time.Hour)
+			response, err := s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: token})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NotEqual(t, token, response.RefreshToken)
+			token = response.RefreshToken
+		}
+
+		repos.clock.Advance(time.Hour)
+		_, err := s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: token})
+		assert.ErrorIs(t, err, domain.ErrSessionMaxAgeExceeded)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{}, testUser(t, "user@example.com", domain.StatusActive))
+		token := login(t, s)
+
+		repos.clock.Advance(6 * 24 * time.Hour)
+		_, err := s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: token})
+		assert.NoError(t, err)
+	})
+}
+
+func TestLoginCaptchaEscalation(t *testing.T) {
+	newService := func(t *testing.T) (*AuthService, *authTestRepos, *fakeCaptcha) {
+		cfg := &config.Config{LoginCaptchaThreshold: 3, LoginCaptchaWindow: "15m"}
+		s, repos := newTestAuthService(cfg, testUser(t, "user@example.com", domain.StatusActive))
+		verifier := &fakeCaptcha{}
+		s.captcha = verifier
+		return s, repos, verifier
+	}
+	login := func(s *AuthService, password, ipAddress, token string) error {
+		_, err := s.Login(&domain.LoginRequest{
+			Email: "user@example.com", Password: password, CaptchaToken: token,
+		}, ipAddress, "test")
+		return err
+	}
+	fail := func(t *testing.T, s *AuthService, times int, ipAddress string) {
+		for i := 0; i < times; i++ {
+			assert.ErrorIs(t, login(s, "wrong-password", ipAddress, ""), domain.ErrInvalidCredentials)
+		}
+	}
+
+	t.Run("not asked for below the threshold", func(t *testing.T) {
+		s, _, verifier := newService(t)
+		fail(t, s, 2, "10.0.0.1")
+
+		assert.NoError(t, login(s, "password123", "10.0.0.1", ""))
+		assert.Empty(t, verifier.verified)
+	})
+
+	t.Run("required and verified once the threshold is reached", func(t *testing.T) {
+		s, _, verifier := newService(t)
+		fail(t, s, 3, "10.0.0.1")
+
+		// Even the right password needs the CAPTCHA now
+		assert.ErrorIs(t, login(s, "password123", "10.0.0.1", ""), domain.ErrCaptchaRequired)
+		assert.ErrorIs(t, login(s, "password123", "10.0.0.1", "guessed"), domain.ErrCaptchaInvalid)
+		assert.NoError(t, login(s, "password123", "10.0.0.1", "solved"))
+		assert.Equal(t, []string{"guessed", "solved"}, verifier.verified)
+
+		// The IP address stays escalated; the account's owner signing in from elsewhere doesn't
+		assert.ErrorIs(t, login(s, "password123", "10.0.0.1", ""), domain.ErrCaptchaRequired)
+		assert.NoError(t, login(s, "password123", "10.0.0.2", ""))
+	})
+
+	t.Run("failures against one account from many addresses", func(t *testing.T) {
+		s, _, _ := newService(t)
+		fail(t, s, 1, "10.0.0.1")
+		fail(t, s, 1, "10.0.0.2")
+		fail(t, s, 1, "10.0.0.3")
+
+		assert.ErrorIs(t, login(s, "password123", "10.0.0.4", ""), domain.ErrCaptchaRequired)
+	})
+
+	t.Run("failures expire with the window", func(t *testing.T) {
+		s, repos, _ := newService(t)
+		fail(t, s, 3, "10.0.0.1")
+
+		repos.clock.Advance(15 * time.Minute)
+		assert.NoError(t, login(s, "password123", "10.0.0.1", ""))
+	})
+
+	t.Run("verifier outage", func(t *testing.T) {
+		s, _, verifier := newService(t)
+		verifier.err = errors.New("connection refused")
+		fail(t, s, 3, "10.0.0.1")
+
+		assert.ErrorIs(t, login(s, "password123", "10.0.0.1", "solved"), domain.ErrCaptchaUnavailable)
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	login := func(t *testing.T, s *AuthService, userAgent string) *domain.AuthResponse {
+		response, err := s.Login(&domain.LoginRequest{Email: "user@example.com", Password: "password123"}, "127.0.0.1", userAgent)
+		if err != nil {
+			t.Fatalf("failed to log in: %v", err)
+		}
+		return response
+	}
+
+	t.Run("other sessions carry on", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{}, testUser(t, "user@example.com", domain.StatusActive))
+		laptop := login(t, s, "laptop")
+		phone := login(t, s, "phone")
+
+		revoked, err := s.RevokeSession(repos.refreshTokens.tokens[phone.RefreshToken].ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, phone.RefreshToken, revoked.Token)
+		_, err = repos.jwt.ValidateAccessToken(phone.AccessToken)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+		_, err = s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: phone.RefreshToken})
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+
+		_, err = repos.jwt.ValidateAccessToken(laptop.AccessToken)
+		assert.NoError(t, err)
+		repos.clock.Advance(time.Second)
+		_, err = s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: laptop.RefreshToken})
+		assert.NoError(t, err)
+
+		sessions, err := s.ListUserSessions(revoked.UserID)
+		assert.NoError(t, err)
+		assert.Len(t, sessions, 1)
+	})
+
+	t.Run("session from before session IDs", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{}, testUser(t, "user@example.com", domain.StatusActive))
+		laptop := login(t, s, "laptop")
+		phone := login(t, s, "phone")
+		repos.refreshTokens.tokens[phone.RefreshToken].SessionID = ""
+
+		_, err := s.RevokeSession(repos.refreshTokens.tokens[phone.RefreshToken].ID)
+		assert.NoError(t, err)
+
+		// Every access token is denylisted, but the other session recovers by refreshing
+		_, err = repos.jwt.ValidateAccessToken(laptop.AccessToken)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+		repos.clock.Advance(time.Second)
+		refreshed, err := s.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: laptop.RefreshToken})
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = repos.jwt.ValidateAccessToken(refreshed.AccessToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		s, _ := newTestAuthService(&config.Config{}, testUser(t, "user@example.com", domain.StatusActive))
+
+		_, err := s.RevokeSession(99)
+		assert.ErrorIs(t, err, domain.ErrTokenNotFound)
+	})
+}
+
+func TestRegister(t *testing.T) {
+	t.Run("active by default", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{})
+
+		response, err := s.Register(&domain.RegisterRequest{
+			Email: "New@Example.com", Password: "password123", FirstName: "New",
+		}, "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "new@example.com", response.User.Email)
+		assert.NotEmpty(t, response.AccessToken)
+		assert.False(t, response.PendingApproval)
+		assert.Len(t, repos.users.users, 1)
+	})
+
+	t.Run("email trimmed and lowercased", func(t *testing.T) {
+		s, _ := newTestAuthService(&config.Config{}, testUser(t, "user@x.com", domain.StatusActive))
+
+		_, err := s.Register(&domain.RegisterRequest{
+			Email: " User@X.com ", Password: "password123", FirstName: "New",
+		}, "127.0.0.1", "test")
+		assert.ErrorIs(t, err, domain.ErrUserAlreadyExists)
+
+		response, err := s.Register(&domain.RegisterRequest{
+			Email: " Other@X.com ", Password: "password123", FirstName: "New",
+		}, "127.0.0.1", "test")
+		assert.NoError(t, err)
+		assert.Equal(t, "other@x.com", response.User.Email)
+	})
+
+	t.Run("pending approval", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{RegistrationDefaultStatus: string(domain.StatusPending)})
+
+		response, err := s.Register(&domain.RegisterRequest{
+			Email: "new@example.com", Password: "password123", FirstName: "New",
+		}, "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.Empty(t, response.AccessToken)
+		assert.True(t, response.PendingApproval)
+		assert.Empty(t, repos.refreshTokens.tokens)
+	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		s, _ := newTestAuthService(&config.Config{}, testUser(t, "taken@example.com", domain.StatusActive))
+
+		_, err := s.Register(&domain.RegisterRequest{
+			Email: "taken@example.com", Password: "password123", FirstName: "New",
+		}, "127.0.0.1", "test")
+
+		assert.ErrorIs(t, err, domain.ErrUserAlreadyExists)
+	})
+
+	t.Run("soft-deleted user's email is kept by default", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{}, deletedTestUser(t, "gone@example.com"))
+
+		_, err := s.Register(&domain.RegisterRequest{
+			Email: "Gone@Example.com", Password: "password123", FirstName: "New",
+		}, "127.0.0.1", "test")
+
+		assert.ErrorIs(t, err, domain.ErrUserAlreadyExists)
+		assert.Len(t, repos.users.users, 1)
+	})
+
+	t.Run("soft-deleted user's email reused when enabled", func(t *testing.T) {
+		deleted := deletedTestUser(t, "gone@example.com")
+		s, repos := newTestAuthService(&config.Config{UserDeletedEmailReuse: true}, deleted)
+
+		response, err := s.Register(&domain.RegisterRequest{
+			Email: "gone@example.com", Password: "password123", FirstName: "New",
+		}, "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, deleted.ID, response.User.ID)
+		assert.Len(t, repos.users.users, 2)
+	})
+
+	t.Run("weak password", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{})
+
+		_, err := s.Register(&domain.RegisterRequest{
+			Email: "new@example.com", Password: "short", FirstName: "New",
+		}, "127.0.0.1", "test")
+
+		assert.Error(t, err)
+		assert.Empty(t, repos.users.users)
+	})
+}
+
+func TestRegisterUsername(t *testing.T) {
+	register := func(s *AuthService, email, username string) (*domain.AuthResponse, error) {
+		return s.Register(&domain.RegisterRequest{
+			Email: email, Password: "password123", FirstName: "New", Username: username,
+		}, "127.0.0.1", "test")
+	}
+
+	t.Run("optional", func(t *testing.T) {
+		s, _ := newTestAuthService(&config.Config{})
+
+		response, err := register(s, "new@example.com", "")
+		assert.NoError(t, err)
+		assert.Empty(t, response.User.Username)
+	})
+
+	t.Run("stored normalized", func(t *testing.T) {
+		s, _ := newTestAuthService(&config.Config{})
+
+		response, err := register(s, "new@example.com", " Jane.Doe ")
+		assert.NoError(t, err)
+		assert.Equal(t, "jane.doe", response.User.Username)
+	})
+
+	t.Run("reserved names", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{UsernameReserved: "admin,support"})
+
+		for _, username := range []string{"admin", "Support", "ad_min", "s.u.p.p.o.r.t"} {
+			_, err := register(s, "new@example.com", username)
+			assert.ErrorIs(t, err, domain.ErrUsernameReserved, username)
+		}
+		assert.Empty(t, repos.users.users)
+	})
+
+	t.Run("bad formats", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{UsernameMinLength: 3, UsernameMaxLength: 12})
+
+		for _, username := range []string{"ab", "much-too-long-name", "jane doe", "jane@doe", "_jane", "jane-", "ja..ne", "jänе"} {
+			_, err := register(s, "new@example.com", username)
+			assert.ErrorIs(t, err, domain.ErrUsernameInvalid, username)
+		}
+		assert.Empty(t, repos.users.users)
+	})
+
+	t.Run("case-insensitive collisions", func(t *testing.T) {
+		s, repos := newTestAuthService(&config.Config{})
+
+		_, err := register(s, "first@example.com", "JaneDoe")
+		assert.NoError(t, err)
+
+		_, err = register(s, "second@example.com", "janedoe")
+		assert.ErrorIs(t, err, domain.ErrUsernameTaken)
+		_, err = register(s, "second@example.com", "JANEDOE")
+		assert.ErrorIs(t, err, domain.ErrUsernameTaken)
+		assert.Len(t, repos.users.users, 1)
+	})
+}
+
+func deletedTestUser(t *testing.T, email string) *domain.User {
+	user := testUser(t, email, domain.StatusActive)
+	user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return user
+}
+
+func TestResendEmailVerificationIsThrottled(t *testing.T) {
+	unverified := testUser(t, "new@example.com", domain.StatusActive)
+	unverified.EmailVerified = false
+	s, repos := newTestAuthService(&config.Config{
+		EmailEnabled:             true,
+		VerificationResendMax:    3,
+		VerificationResendWindow: "1h",
+	}, unverified)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, s.ResendEmailVerification(unverified.ID), "resend %d", i+1)
+	}
+	assert.ErrorIs(t, s.ResendEmailVerification(unverified.ID), domain.ErrTooManyVerifyResends)
+
+	// The window starts at the first resend, not the latest one
+	repos.clock.Advance(59 * time.Minute)
+	assert.ErrorIs(t, s.ResendEmailVerification(unverified.ID), domain.ErrTooManyVerifyResends)
+
+	repos.clock.Advance(time.Minute)
+	assert.NoError(t, s.ResendEmailVerification(unverified.ID))
+	assert.Equal(t, 1, unverified.VerifyResends)
+}
+
+func TestIsEmailAvailable(t *testing.T) {
+	s, _ := newTestAuthService(&config.Config{},
+		testUser(t, "taken@example.com", domain.StatusActive), deletedTestUser(t, "gone@example.com"))
+
+	tests := []struct {
+		email     string
+		available bool
+	}{
+		{"new@example.com", true},
+		{"taken@example.com", false},
+		{"  Taken@Example.COM ", false},
+		{"gone@example.com", false},
+	}
+
+	for _, tt := range tests {
+		available, err := s.IsEmailAvailable(tt.email)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.available, available, tt.email)
+	}
+}
+
+func TestIntrospectToken(t *testing.T) {
+	user := testUser(t, "active@example.com", domain.StatusActive)
+	other := testUser(t, "other@example.com", domain.StatusActive)
+	s, repos := newTestAuthService(&config.Config{JWTAccessTokenDuration: "15m"}, user, other)
+
+	token, err := repos.jwt.GenerateAccessToken(user)
+	assert.NoError(t, err)
+
+	active := s.IntrospectToken(token)
+	assert.True(t, active.Active)
+	assert.Equal(t, "1", active.Subject)
+	assert.Equal(t, "active@example.com", active.Username)
+	assert.Equal(t, "access_token", active.TokenType)
+	assert.Equal(t, domain.RoleUser, active.Role)
+	assert.Equal(t, repos.clock.Now().Add(15*time.Minute).Unix(), active.ExpiresAt)
+
+	inactive := &domain.IntrospectionResponse{Active: false}
+	assert.Equal(t, inactive, s.IntrospectToken("not-a-token"))
+
+	// Revoking a user's tokens is honored, without affecting other users
+	otherToken, err := repos.jwt.GenerateAccessToken(other)
+	assert.NoError(t, err)
+	assert.NoError(t, repos.jwt.RevokeUserAccessTokens(user.ID))
+	assert.Equal(t, inactive, s.IntrospectToken(token))
+	assert.True(t, s.IntrospectToken(otherToken).Active)
+
+	repos.clock.Advance(16 * time.Minute)
+	assert.Equal(t, inactive, s.IntrospectToken(otherToken))
+}
+
+func TestIntrospectTokenUserState(t *testing.T) {
+	inactive := &domain.IntrospectionResponse{Active: false}
+
+	t.Run("suspended user", func(t *testing.T) {
+		user := testUser(t, "user@example.com", domain.StatusActive)
+		s, repos := newTestAuthService(&config.Config{}, user)
+		token, err := repos.jwt.GenerateAccessToken(user)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, s.IntrospectToken(token).Active)
+
+		user.Status = domain.StatusSuspended
+		assert.Equal(t, inactive, s.IntrospectToken(token))
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		user := testUser(t, "user@example.com", domain.StatusActive)
+		s, repos := newTestAuthService(&config.Config{}, user)
+		token, err := repos.jwt.GenerateAccessToken(user)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		delete(repos.users.users, user.ID)
+		assert.Equal(t, inactive, s.IntrospectToken(token))
+	})
+
+	t.Run("password reset", func(t *testing.T) {
+		user := testUser(t, "user@example.com", domain.StatusActive)
+		s, repos := newTestAuthService(&config.Config{}, user)
+		token, err := repos.jwt.GenerateAccessToken(user)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, repos.resets.Create(&domain.PasswordReset{
+			Email: user.Email, Token: "reset-token", ExpiresAt: repos.clock.Now().Add(time.Hour),
+		}))
+
+		err = s.ResetPassword(&domain.ResetPasswordRequest{
+			Token: "reset-token", Password: "new-password-456", ConfirmPassword: "new-password-456",
+		}, "", "127.0.0.1", "test")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, inactive, s.IntrospectToken(token))
+
+		// Tokens issued after the reset are unaffected
+		repos.clock.Advance(time.Second)
+		fresh, err := repos.jwt.GenerateAccessToken(user)
+		if assert.NoError(t, err) {
+			assert.True(t, s.IntrospectToken(fresh).Active)
+		}
+	})
+}
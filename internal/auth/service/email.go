@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"html/template"
 	"log/slog"
+	"time"
 
 	"gopkg.in/gomail.v2"
 
+	"github.com/acheevo/tfa/internal/auth/domain"
 	"github.com/acheevo/tfa/internal/shared/config"
 )
 
-// EmailService handles email sending operations
+// EmailService handles email sending operations. When EMAIL_ENABLED is off every send is
+// skipped with an info log, so flows that merely notify the user keep working in development.
 type EmailService struct {
 	config *config.Config
 	logger *slog.Logger
@@ -32,14 +35,31 @@ func NewEmailService(config *config.Config, logger *slog.Logger) *EmailService {
 	}
 }
 
+// Enabled reports whether email delivery is turned on
+func (e *EmailService) Enabled() bool {
+	return e.config.EmailEnabled
+}
+
+// skip reports whether a send should be skipped, logging why
+func (e *EmailService) skip(kind, email string) bool {
+	if !e.config.EmailEnabled {
+		e.logger.Info("email disabled, skipping "+kind, "email", email)
+		return true
+	}
+	if e.dialer == nil {
+		e.logger.Warn("email service not configured, skipping "+kind, "email", email)
+		return true
+	}
+	return false
+}
+
 // SendEmailVerification sends an email verification email
 func (e *EmailService) SendEmailVerification(email, token, firstName string) error {
-	if e.dialer == nil {
-		e.logger.Warn("email service not configured, skipping email verification", "email", email)
+	if e.skip("email verification", email) {
 		return nil
 	}
 
-	verificationURL := fmt.Sprintf("%s/verify-email?token=%s", e.config.FrontendURL, token)
+	verificationURL := e.config.EmailVerifyURL(token)
 
 	subject := "Verify your email address"
 	htmlBody, err := e.renderEmailVerificationTemplate(firstName, verificationURL)
@@ -60,14 +80,71 @@ Best regards,
 	return e.sendEmail(email, subject, htmlBody, textBody)
 }
 
+// SendVerificationReminder sends a reminder to verify the email address after an unverified login
+func (e *EmailService) SendVerificationReminder(email, token, firstName string) error {
+	if e.skip("verification reminder", email) {
+		return nil
+	}
+
+	verificationURL := e.config.EmailVerifyURL(token)
+
+	subject := "Reminder: verify your email address"
+	htmlBody, err := e.renderEmailVerificationTemplate(firstName, verificationURL)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+You recently signed in, but your email address is still unverified.
+Verify it now by clicking the link below:
+%s
+
+If you didn't sign in, please change your password.
+
+Best regards,
+%s Team`, firstName, verificationURL, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendAdminSetup asks an admin created at bootstrap to verify their email, after which they must
+// change the bootstrap password before the account can be used
+func (e *EmailService) SendAdminSetup(email, token, firstName string) error {
+	if e.skip("admin setup email", email) {
+		return nil
+	}
+
+	verificationURL := e.config.EmailVerifyURL(token)
+
+	subject := "Finish setting up your admin account"
+	htmlBody, err := e.renderEmailVerificationTemplate(firstName, verificationURL)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+An admin account was created for this address. Verify your email address by clicking the link below:
+%s
+
+Then sign in with the password you were given and change it. The account can't be used until both are done.
+
+If you weren't expecting this, please contact your administrator.
+
+Best regards,
+%s Team`, firstName, verificationURL, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
 // SendPasswordReset sends a password reset email
 func (e *EmailService) SendPasswordReset(email, token, firstName string) error {
-	if e.dialer == nil {
-		e.logger.Warn("email service not configured, skipping password reset email", "email", email)
+	if e.skip("password reset email", email) {
 		return nil
 	}
 
-	resetURL := fmt.Sprintf("%s/reset-password?token=%s", e.config.FrontendURL, token)
+	resetURL := e.config.PasswordResetURL(token)
 
 	subject := "Reset your password"
 	htmlBody, err := e.renderPasswordResetTemplate(firstName, resetURL)
@@ -90,8 +167,7 @@ Best regards,
 
 // SendWelcomeEmail sends a welcome email to new users
 func (e *EmailService) SendWelcomeEmail(email, firstName string) error {
-	if e.dialer == nil {
-		e.logger.Warn("email service not configured, skipping welcome email", "email", email)
+	if e.skip("welcome email", email) {
 		return nil
 	}
 
@@ -113,12 +189,335 @@ Best regards,
 	return e.sendEmail(email, subject, htmlBody, textBody)
 }
 
+// SendEmailVerificationStatusChanged notifies a user that an administrator changed their email verification status
+func (e *EmailService) SendEmailVerificationStatusChanged(email, firstName string, verified bool) error {
+	if e.skip("verification status email", email) {
+		return nil
+	}
+
+	subject := "Your email address has been verified"
+	statusText := "Your email address has been verified by an administrator."
+	if !verified {
+		subject = "Your email address verification has been revoked"
+		statusText = "An administrator has revoked the verification of your email address. " +
+			"Please verify your email address again to regain full access."
+	}
+
+	htmlBody, err := e.renderEmailVerificationStatusTemplate(firstName, subject, statusText)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+%s
+
+If you have any questions, please contact our support team.
+
+Best regards,
+%s Team`, firstName, statusText, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendAccountApproved tells a user that an administrator approved their registration
+func (e *EmailService) SendAccountApproved(email, firstName string) error {
+	if e.skip("account approval email", email) {
+		return nil
+	}
+
+	subject := "Your account has been approved"
+	statusText := fmt.Sprintf("An administrator has approved your account. You can now sign in at %s.", e.config.FrontendURL)
+
+	htmlBody, err := e.renderEmailVerificationStatusTemplate(firstName, subject, statusText)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+%s
+
+If you have any questions, please contact our support team.
+
+Best regards,
+%s Team`, firstName, statusText, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendRecoveryRequested warns the account's own address that someone asked to recover the account, so
+// an owner who still has access can report a request they didn't make
+func (e *EmailService) SendRecoveryRequested(email, firstName string) error {
+	if e.skip("account recovery notice", email) {
+		return nil
+	}
+
+	subject := "Account recovery was requested"
+	statusText := "Someone asked to recover access to your account. If this wasn't you, " +
+		"sign in and change your password, and contact our support team."
+
+	htmlBody, err := e.renderEmailVerificationStatusTemplate(firstName, subject, statusText)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+%s
+
+Best regards,
+%s Team`, firstName, statusText, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendBackupEmailConfirmation asks a new backup email to confirm it should receive the account's
+// recovery links, by opening the link
+func (e *EmailService) SendBackupEmailConfirmation(email, token, firstName string) error {
+	if e.skip("backup email confirmation", email) {
+		return nil
+	}
+
+	confirmURL := e.config.BackupEmailURL(token)
+
+	subject := "Confirm your backup email"
+	statusText := fmt.Sprintf("This address was added as the backup email of your account, to receive account "+
+		"recovery links. Confirm it at %s within 24 hours. If you didn't ask for this, you can safely ignore this email.",
+		confirmURL)
+
+	htmlBody, err := e.renderEmailVerificationStatusTemplate(firstName, subject, statusText)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+%s
+
+Best regards,
+%s Team`, firstName, statusText, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendBackupEmailChanged tells the account's own address that its backup email was requested, confirmed or,
+// when backupEmail is empty, removed, so an owner can react to a change they didn't make
+func (e *EmailService) SendBackupEmailChanged(email, firstName, backupEmail string, confirmed bool) error {
+	if e.skip("backup email change notice", email) {
+		return nil
+	}
+
+	subject := "Your backup email was changed"
+	var statusText string
+	switch {
+	case backupEmail == "":
+		subject = "Your backup email was removed"
+		statusText = "The backup email of your account was removed, so account recovery links can no longer be sent to it."
+	case confirmed:
+		statusText = fmt.Sprintf("%s is now the backup email of your account and can receive account recovery links.",
+			backupEmail)
+	default:
+		subject = "A backup email was added to your account"
+		statusText = fmt.Sprintf("%s was added as the backup email of your account. Once the link sent to it is "+
+			"opened it can receive account recovery links.", backupEmail)
+	}
+	statusText += " If this wasn't you, sign in, change your password and check your backup email."
+
+	htmlBody, err := e.renderEmailVerificationStatusTemplate(firstName, subject, statusText)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+%s
+
+Best regards,
+%s Team`, firstName, statusText, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendPreviousPasswordUsed tells the account's owner that their replaced password was used to sign in
+// during its grace, so an owner who reset it because it leaked can react
+func (e *EmailService) SendPreviousPasswordUsed(email, firstName, ipAddress string, graceUntil time.Time) error {
+	if e.skip("previous password notice", email) {
+		return nil
+	}
+
+	subject := "Your previous password was used to sign in"
+	statusText := fmt.Sprintf("Someone signed in to your account from %s with the password you replaced. It keeps "+
+		"working until %s. If this wasn't you, reset your password again and contact our support team.",
+		ipAddress, graceUntil.UTC().Format("January 2, 2006 15:04 MST"))
+
+	htmlBody, err := e.renderEmailVerificationStatusTemplate(firstName, subject, statusText)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+%s
+
+Best regards,
+%s Team`, firstName, statusText, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendPasswordResetUnavailable answers a password reset requested for an account that has no password,
+// telling the owner how they sign in instead
+func (e *EmailService) SendPasswordResetUnavailable(email, firstName string) error {
+	if e.skip("password reset unavailable notice", email) {
+		return nil
+	}
+
+	subject := "Password reset requested"
+	statusText := "Someone asked to reset the password of your account, but your account signs in without " +
+		"a password, so there is none to reset. Sign in the way you usually do. If this wasn't you, you can ignore this email."
+
+	htmlBody, err := e.renderEmailVerificationStatusTemplate(firstName, subject, statusText)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+%s
+
+Best regards,
+%s Team`, firstName, statusText, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendAdminAccessRemoved tells an admin their admin role was removed because they hadn't used it
+// within the dormancy period, and that another admin can grant it again
+func (e *EmailService) SendAdminAccessRemoved(email, firstName string, dormantFor time.Duration) error {
+	if e.skip("admin access removal email", email) {
+		return nil
+	}
+
+	period := dormantFor.String()
+	if days := int(dormantFor.Hours() / 24); days > 1 {
+		period = fmt.Sprintf("%d days", days)
+	}
+
+	subject := "Your admin access has been removed"
+	statusText := fmt.Sprintf("Your account hadn't used its admin access in %s, so it has been changed to a regular account. "+
+		"You can still sign in. If you still need admin access, ask another administrator to grant it again.", period)
+
+	htmlBody, err := e.renderEmailVerificationStatusTemplate(firstName, subject, statusText)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+%s
+
+If you have any questions, please contact our support team.
+
+Best regards,
+%s Team`, firstName, statusText, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendSecurityAlert pages a security contact about a raised security alert
+func (e *EmailService) SendSecurityAlert(email string, alert *domain.SecurityAlert) error {
+	if e.skip("security alert email", email) {
+		return nil
+	}
+
+	subject := fmt.Sprintf("Security alert: %s", alert.Title)
+	raisedAt := alert.CreatedAt.UTC().Format(time.RFC1123)
+
+	htmlBody, err := e.renderSecurityAlertTemplate(alert, raisedAt)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Security alert: %s
+
+%s
+
+Type: %s
+Severity: %s
+Raised at: %s
+Alert ID: %s
+
+Review and resolve the alert in the admin console.
+
+%s Team`, alert.Title, alert.Description, alert.Type, alert.Severity, raisedAt, alert.ID, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendExportReady tells an admin their export has finished, with a signed link to download it
+func (e *EmailService) SendExportReady(email, firstName, filename, downloadURL string, linkExpiresAt time.Time) error {
+	if e.skip("export ready email", email) {
+		return nil
+	}
+
+	subject := "Your export is ready"
+	statusText := fmt.Sprintf("Your export %s has finished. Download it at %s. The link expires at %s; "+
+		"check the export in the admin console for a new one.",
+		filename, downloadURL, linkExpiresAt.UTC().Format(time.RFC1123))
+
+	htmlBody, err := e.renderEmailVerificationStatusTemplate(firstName, subject, statusText)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+%s
+
+Best regards,
+%s Team`, firstName, statusText, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
+// SendExportFailed tells an admin their export could not be completed
+func (e *EmailService) SendExportFailed(email, firstName, exportID string) error {
+	if e.skip("export failed email", email) {
+		return nil
+	}
+
+	subject := "Your export failed"
+	statusText := fmt.Sprintf("Your export could not be completed. See export %s in the admin console "+
+		"for the reason, and request it again.", exportID)
+
+	htmlBody, err := e.renderEmailVerificationStatusTemplate(firstName, subject, statusText)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`Hi %s,
+
+%s
+
+Best regards,
+%s Team`, firstName, statusText, e.config.EmailFromName)
+
+	return e.sendEmail(email, subject, htmlBody, textBody)
+}
+
 // sendEmail sends an email with both HTML and text content
 func (e *EmailService) sendEmail(to, subject, htmlBody, textBody string) error {
 	m := gomail.NewMessage()
 	m.SetHeader("From", m.FormatAddress(e.config.EmailFrom, e.config.EmailFromName))
 	m.SetHeader("To", to)
+	if prefix := e.config.EmailSubjectPrefixForEnvironment(); prefix != "" {
+		subject = prefix + " " + subject
+	}
 	m.SetHeader("Subject", subject)
+	if e.config.Environment != "" {
+		m.SetHeader("X-Environment", e.config.Environment)
+	}
 	m.SetBody("text/plain", textBody)
 	m.AddAlternative("text/html", htmlBody)
 
@@ -304,3 +703,127 @@ func (e *EmailService) renderWelcomeTemplate(firstName string) (string, error) {
 
 	return buf.String(), nil
 }
+
+// renderEmailVerificationStatusTemplate renders the email verification status change template
+func (e *EmailService) renderEmailVerificationStatusTemplate(firstName, title, statusText string) (string, error) {
+	tmpl := `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; margin-bottom: 30px; }
+        .footer { margin-top: 30px; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>{{.Title}}</h1>
+        </div>
+        <p>Hi {{.FirstName}},</p>
+        <p>{{.StatusText}}</p>
+        <p>If you have any questions, please contact our support team.</p>
+        <div class="footer">
+            <p>Best regards,<br>{{.AppName}} Team</p>
+        </div>
+    </div>
+</body>
+</html>`
+
+	t, err := template.New("email_verification_status").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		FirstName  string
+		Title      string
+		StatusText string
+		AppName    string
+	}{
+		FirstName:  firstName,
+		Title:      title,
+		StatusText: statusText,
+		AppName:    e.config.EmailFromName,
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// renderSecurityAlertTemplate renders the security alert page sent to security contacts
+func (e *EmailService) renderSecurityAlertTemplate(alert *domain.SecurityAlert, raisedAt string) (string, error) {
+	tmpl := `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Security alert</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; margin-bottom: 30px; }
+        .severity { display: inline-block; padding: 4px 12px; background-color: #dc3545; color: white;
+                    border-radius: 4px; text-transform: uppercase; font-size: 12px; }
+        .details td { padding: 4px 12px 4px 0; vertical-align: top; }
+        .footer { margin-top: 30px; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>{{.Title}}</h1>
+            <span class="severity">{{.Severity}}</span>
+        </div>
+        <p>{{.Description}}</p>
+        <table class="details">
+            <tr><td><strong>Type</strong></td><td>{{.Type}}</td></tr>
+            <tr><td><strong>Raised at</strong></td><td>{{.RaisedAt}}</td></tr>
+            <tr><td><strong>Alert ID</strong></td><td>{{.ID}}</td></tr>
+        </table>
+        <p>Review and resolve the alert in the admin console.</p>
+        <div class="footer">
+            <p>{{.AppName}} Team</p>
+        </div>
+    </div>
+</body>
+</html>`
+
+	t, err := template.New("security_alert").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		ID          string
+		Type        string
+		Severity    string
+		Title       string
+		Description string
+		RaisedAt    string
+		AppName     string
+	}{
+		ID:          alert.ID,
+		Type:        alert.Type,
+		Severity:    alert.Severity,
+		Title:       alert.Title,
+		Description: alert.Description,
+		RaisedAt:    raisedAt,
+		AppName:     e.config.EmailFromName,
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func TestEmailServiceDisabledSkipsSending(t *testing.T) {
+	// Credentials point at a closed port, so any real send attempt would fail
+	cfg := &config.Config{
+		SMTPHost:     "127.0.0.1",
+		SMTPPort:     1,
+		SMTPUsername: "user",
+		SMTPPassword: "secret",
+		EmailFrom:    "noreply@example.com",
+	}
+	emailService := NewEmailService(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	assert.False(t, emailService.Enabled())
+	assert.NoError(t, emailService.SendEmailVerification("user@example.com", "token", "User"))
+	assert.NoError(t, emailService.SendPasswordReset("user@example.com", "token", "User"))
+	assert.NoError(t, emailService.SendWelcomeEmail("user@example.com", "User"))
+	assert.NoError(t, emailService.SendSecurityAlert("security@example.com", &domain.SecurityAlert{Title: "Spike in failed logins"}))
+
+	cfg.EmailEnabled = true
+	assert.True(t, emailService.Enabled())
+	assert.Error(t, emailService.SendPasswordReset("user@example.com", "token", "User"))
+}
+
+func TestRenderSecurityAlertTemplate(t *testing.T) {
+	emailService := NewEmailService(&config.Config{EmailFromName: "Acme"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	alert := &domain.SecurityAlert{
+		ID:          "alert-1",
+		Type:        "mass_deletion",
+		Severity:    "high",
+		Title:       "Mass deletion",
+		Description: "<b>40</b> users deleted",
+		CreatedAt:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	body, err := emailService.renderSecurityAlertTemplate(alert, alert.CreatedAt.Format(time.RFC1123))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Contains(t, body, "<h1>Mass deletion</h1>")
+	assert.Contains(t, body, "mass_deletion")
+	assert.Contains(t, body, "alert-1")
+	assert.Contains(t, body, "Wed, 01 May 2024 12:00:00 UTC")
+	assert.Contains(t, body, "&lt;b&gt;40&lt;/b&gt; users deleted")
+	assert.NotContains(t, body, "Hi there")
+}
@@ -2,50 +2,124 @@ package service
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 
 	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/auth/repository"
+	"github.com/acheevo/tfa/internal/shared/clock"
 	"github.com/acheevo/tfa/internal/shared/config"
 )
 
 // JWTService handles JWT token operations
 type JWTService struct {
-	config *config.Config
+	config   *config.Config
+	clock    clock.Clock
+	enricher domain.ClaimEnricher
+
+	// revocations holds when each user's and session's outstanding access tokens were revoked
+	revocations RevocationStore
 }
 
-// NewJWTService creates a new JWT service
-func NewJWTService(config *config.Config) *JWTService {
+// NewJWTService creates a new JWT service. The enricher, when not nil, supplies the custom claims
+// added to access tokens.
+func NewJWTService(config *config.Config, clk clock.Clock, enricher domain.ClaimEnricher) *JWTService {
 	return &JWTService{
-		config: config,
+		config:      config,
+		clock:       clk,
+		enricher:    enricher,
+		revocations: newRevocationStore(config),
+	}
+}
+
+// newRevocationStore creates the store TOKEN_REVOCATION_BACKEND selects: Redis, or by default this
+// process's memory. A Redis URL that can't be parsed gives a store that always fails, so access
+// tokens are rejected rather than revocations silently ignored.
+func newRevocationStore(cfg *config.Config) RevocationStore {
+	if cfg.TokenRevocationBackend == "redis" {
+		options, err := redis.ParseURL(cfg.TokenRevocationRedisURL)
+		if err != nil {
+			return failingRevocationStore{err: fmt.Errorf("invalid TOKEN_REVOCATION_REDIS_URL: %w", err)}
+		}
+		return repository.NewRedisRevocationStore(redis.NewClient(options), cfg.TokenRevocationRedisPrefix)
 	}
+	return repository.NewMemoryRevocationStore()
 }
 
-// GenerateAccessToken generates a new access token for the user
+// failingRevocationStore is a RevocationStore that can't be reached
+type failingRevocationStore struct{ err error }
+
+func (s failingRevocationStore) RevokeUser(uint, time.Time, time.Duration) error      { return s.err }
+func (s failingRevocationStore) RevokeSession(string, time.Time, time.Duration) error { return s.err }
+func (s failingRevocationStore) RevokedAt(uint, string) (time.Time, time.Time, error) {
+	return time.Time{}, time.Time{}, s.err
+}
+
+// GenerateAccessToken generates a new access token for the user, outside any session
 func (j *JWTService) GenerateAccessToken(user *domain.User) (string, error) {
-	now := time.Now()
-	expiresAt := now.Add(j.config.JWTAccessTokenDurationParsed())
+	return j.GenerateSessionAccessToken(user, "", time.Time{})
+}
+
+// GenerateSessionAccessToken generates a new access token for the user's session, so revoking the
+// session revokes the token too. authTime is when the user signed in to start the session and goes
+// out as the auth_time claim; a zero time leaves the claim out.
+func (j *JWTService) GenerateSessionAccessToken(user *domain.User, sessionID string, authTime time.Time) (string, error) {
+	now := j.clock.Now()
+	expiresAt := j.SessionAccessTokenExpiresAt(authTime)
 
 	claims := &domain.JWTClaims{
 		UserID:    user.ID,
 		Email:     user.Email,
 		Role:      user.Role, // Include role in JWT claims for stateless authorization
 		TokenType: "access",
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+	if !authTime.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(authTime)
+	}
+
+	if j.enricher != nil {
+		custom, err := j.enricher(user)
+		if err != nil {
+			return "", fmt.Errorf("failed to build custom claims: %w", err)
+		}
+		// Refuse to issue the token rather than leak a credential or bloat every request
+		if err := domain.ValidateCustomClaims(user, custom, j.config.JWTCustomClaimsLimit()); err != nil {
+			return "", err
+		}
+		if len(custom) > 0 {
+			claims.Custom = custom
+		}
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(j.config.JWTSecret))
 }
 
+// SessionAccessTokenExpiresAt returns when an access token issued now for a session started at
+// authTime expires. With SESSION_MAX_AGE set it never outlives the session, so a refresh just
+// before the session ends can't extend access past it.
+func (j *JWTService) SessionAccessTokenExpiresAt(authTime time.Time) time.Time {
+	expiresAt := j.clock.Now().Add(j.config.JWTAccessTokenDurationParsed())
+	if maxAge := j.config.SessionMaxAgeDuration(); maxAge > 0 && !authTime.IsZero() {
+		if sessionEnd := authTime.Add(maxAge); sessionEnd.Before(expiresAt) {
+			return sessionEnd
+		}
+	}
+	return expiresAt
+}
+
 // GenerateRefreshToken generates a new refresh token
 func (j *JWTService) GenerateRefreshToken() (string, error) {
 	// Generate a random UUID for the refresh token
@@ -61,7 +135,7 @@ func (j *JWTService) ValidateAccessToken(tokenString string) (*domain.JWTClaims,
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(j.config.JWTSecret), nil
-	})
+	}, jwt.WithTimeFunc(j.clock.Now), jwt.WithLeeway(j.config.JWTLeewayDuration()))
 	if err != nil {
 		return nil, domain.ErrInvalidToken
 	}
@@ -76,15 +150,120 @@ func (j *JWTService) ValidateAccessToken(tokenString string) (*domain.JWTClaims,
 		return nil, domain.ErrInvalidToken
 	}
 
+	if j.isRevoked(claims) {
+		return nil, domain.ErrInvalidToken
+	}
+
 	return claims, nil
 }
 
-// GenerateRandomToken generates a random token for email verification and password reset
+// RevokeUserAccessTokens denylists every access token issued to a user so far. The entry is kept
+// until the longest-lived of those tokens would have expired anyway.
+func (j *JWTService) RevokeUserAccessTokens(userID uint) error {
+	if err := j.revocations.RevokeUser(userID, j.clock.Now(), j.revocationTTL()); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeSessionAccessTokens denylists the access tokens issued to one session so far, leaving the
+// user's other sessions alone
+func (j *JWTService) RevokeSessionAccessTokens(sessionID string) error {
+	if err := j.revocations.RevokeSession(sessionID, j.clock.Now(), j.revocationTTL()); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	return nil
+}
+
+// revocationTTL is how long a revocation is kept: until every token it covers has expired, leeway
+// included
+func (j *JWTService) revocationTTL() time.Duration {
+	return j.config.JWTAccessTokenDurationParsed() + j.config.JWTLeewayDuration()
+}
+
+// isRevoked reports whether an access token was issued before its user's or its session's tokens
+// were revoked. Issue times only have second precision, so tokens from the second of the revocation
+// are revoked too. A token whose revocations can't be looked up counts as revoked.
+func (j *JWTService) isRevoked(claims *domain.JWTClaims) bool {
+	userRevokedAt, sessionRevokedAt, err := j.revocations.RevokedAt(claims.UserID, claims.SessionID)
+	if err != nil {
+		return true
+	}
+
+	return (!userRevokedAt.IsZero() && issuedBy(claims, userRevokedAt)) ||
+		(!sessionRevokedAt.IsZero() && issuedBy(claims, sessionRevokedAt))
+}
+
+// issuedBy reports whether a token was issued by the time of a revocation; one without an issue
+// time always was
+func issuedBy(claims *domain.JWTClaims, revokedAt time.Time) bool {
+	return claims.IssuedAt == nil || !claims.IssuedAt.After(revokedAt.Truncate(time.Second))
+}
+
+// GenerateStepUpToken generates a short-lived token proving the user just re-entered their password
+func (j *JWTService) GenerateStepUpToken(user *domain.User) (string, time.Time, error) {
+	now := j.clock.Now()
+	expiresAt := now.Add(j.config.StepUpTokenTTLDuration())
+
+	claims := &domain.JWTClaims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenType: "step_up",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(j.config.JWTSecret))
+	return signed, expiresAt, err
+}
+
+// ValidateStepUpToken validates a step-up token and returns the claims
+func (j *JWTService) ValidateStepUpToken(tokenString string) (*domain.JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &domain.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(j.config.JWTSecret), nil
+	}, jwt.WithTimeFunc(j.clock.Now), jwt.WithLeeway(j.config.JWTLeewayDuration()))
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*domain.JWTClaims)
+	if !ok || !token.Valid || claims.TokenType != "step_up" {
+		return nil, domain.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// GenerateRandomToken generates a random token for email verification and password reset, with
+// LINK_TOKEN_BYTES of entropy in the LINK_TOKEN_ENCODING alphabet
 func (j *JWTService) GenerateRandomToken() (string, error) {
-	bytes := make([]byte, 32)
+	return NewLinkToken(j.config)
+}
+
+// NewLinkToken generates an emailed link token the way GenerateRandomToken does, for callers outside
+// the auth service
+func NewLinkToken(cfg *config.Config) (string, error) {
+	return generateLinkToken(cfg.LinkTokenByteLength(), cfg.LinkTokenEncoding)
+}
+
+// generateLinkToken reads size bytes from crypto/rand and encodes them as lowercase hex or, for
+// "base64url", unpadded base64url. Both alphabets are safe in URLs without escaping.
+func generateLinkToken(size int, encoding string) (string, error) {
+	bytes := make([]byte, size)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
+	if encoding == "base64url" {
+		return base64.RawURLEncoding.EncodeToString(bytes), nil
+	}
 	return hex.EncodeToString(bytes), nil
 }
 
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func TestAccessTokenExpiry(t *testing.T) {
+	cfg := &config.Config{
+		JWTSecret:              "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		JWTAccessTokenDuration: "15m",
+	}
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	jwtService := NewJWTService(cfg, clk, nil)
+
+	token, err := jwtService.GenerateAccessToken(&domain.User{ID: 1, Email: "user@example.com", Role: domain.RoleUser})
+	assert.NoError(t, err)
+
+	claims, err := jwtService.ValidateAccessToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), claims.UserID)
+	assert.True(t, clk.Now().Add(15*time.Minute).Equal(claims.ExpiresAt.Time))
+
+	clk.Advance(14 * time.Minute)
+	_, err = jwtService.ValidateAccessToken(token)
+	assert.NoError(t, err)
+
+	clk.Advance(2 * time.Minute)
+	_, err = jwtService.ValidateAccessToken(token)
+	assert.ErrorIs(t, err, domain.ErrInvalidToken)
+}
+
+func TestRefreshTokenExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	token := &domain.RefreshToken{ExpiresAt: now.Add(time.Hour)}
+
+	assert.False(t, token.IsExpiredAt(now))
+	assert.True(t, token.IsExpiredAt(now.Add(time.Hour+time.Second)))
+}
+
+func TestStepUpToken(t *testing.T) {
+	cfg := &config.Config{
+		JWTSecret:              "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		JWTAccessTokenDuration: "15m",
+		StepUpTokenTTL:         "5m",
+	}
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	jwtService := NewJWTService(cfg, clk, nil)
+	user := &domain.User{ID: 1, Email: "root@example.com", Role: domain.RoleSuperAdmin}
+
+	token, expiresAt, err := jwtService.GenerateStepUpToken(user)
+	assert.NoError(t, err)
+	assert.True(t, clk.Now().Add(5*time.Minute).Equal(expiresAt))
+
+	claims, err := jwtService.ValidateStepUpToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), claims.UserID)
+
+	// Step-up and access tokens are not interchangeable
+	_, err = jwtService.ValidateAccessToken(token)
+	assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	accessToken, err := jwtService.GenerateAccessToken(user)
+	assert.NoError(t, err)
+	_, err = jwtService.ValidateStepUpToken(accessToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidToken)
+
+	clk.Advance(6 * time.Minute)
+	_, err = jwtService.ValidateStepUpToken(token)
+	assert.ErrorIs(t, err, domain.ErrInvalidToken)
+}
+
+func TestRevokeUserAccessTokens(t *testing.T) {
+	cfg := &config.Config{
+		JWTSecret:              "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		JWTAccessTokenDuration: "15m",
+	}
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	jwtService := NewJWTService(cfg, clk, nil)
+
+	user := &domain.User{ID: 1, Email: "user@example.com", Role: domain.RoleAdmin}
+	other := &domain.User{ID: 2, Email: "other@example.com", Role: domain.RoleAdmin}
+	revoked, _ := jwtService.GenerateAccessToken(user)
+	untouched, _ := jwtService.GenerateAccessToken(other)
+
+	clk.Advance(time.Minute)
+	assert.NoError(t, jwtService.RevokeUserAccessTokens(user.ID))
+
+	_, err := jwtService.ValidateAccessToken(revoked)
+	assert.ErrorIs(t, err, domain.ErrInvalidToken)
+
+	_, err = jwtService.ValidateAccessToken(untouched)
+	assert.NoError(t, err)
+
+	// Tokens issued after the revocation are accepted
+	clk.Advance(time.Second)
+	fresh, _ := jwtService.GenerateAccessToken(user)
+	_, err = jwtService.ValidateAccessToken(fresh)
+	assert.NoError(t, err)
+}
+
+func TestRedisRevocationsAreShared(t *testing.T) {
+	server := miniredis.RunT(t)
+	cfg := &config.Config{
+		JWTSecret:                  "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		JWTAccessTokenDuration:     "15m",
+		TokenRevocationBackend:     "redis",
+		TokenRevocationRedisURL:    "redis://" + server.Addr() + "/0",
+		TokenRevocationRedisPrefix: "test_revocations",
+	}
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	replica := NewJWTService(cfg, clk, nil)
+	other := NewJWTService(cfg, clk, nil)
+
+	user := &domain.User{ID: 1, Email: "user@example.com", Role: domain.RoleAdmin}
+	userToken, _ := replica.GenerateAccessToken(user)
+	sessionToken, _ := replica.GenerateSessionAccessToken(user, "session-1", time.Time{})
+	otherSession, _ := replica.GenerateSessionAccessToken(&domain.User{ID: 2, Email: "other@example.com"}, "session-2", time.Time{})
+
+	clk.Advance(time.Minute)
+	if !assert.NoError(t, replica.RevokeUserAccessTokens(user.ID)) {
+		return
+	}
+	assert.NoError(t, replica.RevokeSessionAccessTokens("session-2"))
+
+	// Another replica rejects the revoked tokens too
+	_, err := other.ValidateAccessToken(userToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	_, err = other.ValidateAccessToken(sessionToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	_, err = other.ValidateAccessToken(otherSession)
+	assert.ErrorIs(t, err, domain.ErrInvalidToken)
+
+	// Revocations expire with the tokens they cover
+	assert.Equal(t, 15*time.Minute, server.TTL("{test_revocations}:user:1"))
+
+	clk.Advance(time.Second)
+	fresh, _ := replica.GenerateAccessToken(user)
+	_, err = other.ValidateAccessToken(fresh)
+	assert.NoError(t, err)
+
+	// Without Redis a token can't be checked, so it is rejected
+	server.Close()
+	_, err = other.ValidateAccessToken(fresh)
+	assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	assert.Error(t, replica.RevokeUserAccessTokens(user.ID))
+}
+
+func TestAccessTokenLeeway(t *testing.T) {
+	cfg := &config.Config{
+		JWTSecret:              "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		JWTAccessTokenDuration: "15m",
+		JWTLeeway:              "30s",
+	}
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	jwtService := NewJWTService(cfg, clk, nil)
+
+	token, err := jwtService.GenerateAccessToken(&domain.User{ID: 1, Email: "user@example.com", Role: domain.RoleUser})
+	assert.NoError(t, err)
+
+	// A server whose clock runs slightly ahead still accepts the token within the leeway
+	clk.Advance(15*time.Minute + 20*time.Second)
+	_, err = jwtService.ValidateAccessToken(token)
+	assert.NoError(t, err)
+
+	clk.Advance(20 * time.Second)
+	_, err = jwtService.ValidateAccessToken(token)
+	assert.ErrorIs(t, err, domain.ErrInvalidToken)
+}
+
+func TestAccessTokenCustomClaims(t *testing.T) {
+	cfg := &config.Config{
+		JWTSecret:               "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		JWTAccessTokenDuration:  "15m",
+		JWTCustomClaimsMaxBytes: 64,
+	}
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	enricher, err := domain.NewUserClaimEnricher([]string{"language", "custom.plan"})
+	assert.NoError(t, err)
+	jwtService := NewJWTService(cfg, clk, enricher)
+
+	user := &domain.User{
+		ID:          1,
+		Email:       "user@example.com",
+		Role:        domain.RoleUser,
+		Preferences: domain.UserPreferences{Language: "en", Custom: map[string]any{"plan": "pro"}},
+	}
+	token, err := jwtService.GenerateAccessToken(user)
+	assert.NoError(t, err)
+
+	claims, err := jwtService.ValidateAccessToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"language": "en", "plan": "pro"}, claims.Custom)
+
+	// Claims over the size limit refuse the token instead of truncating it
+	user.Preferences.Custom["plan"] = strings.Repeat("p", 64)
+	_, err = jwtService.GenerateAccessToken(user)
+	assert.ErrorIs(t, err, domain.ErrCustomClaimsTooLarge)
+
+	// Without an enricher there are no custom claims
+	token, err = NewJWTService(cfg, clk, nil).GenerateAccessToken(user)
+	assert.NoError(t, err)
+	claims, err = jwtService.ValidateAccessToken(token)
+	assert.NoError(t, err)
+	assert.Nil(t, claims.Custom)
+}
+
+func TestGenerateRandomToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *config.Config
+		length   int
+		alphabet string
+	}{
+		{"default", &config.Config{}, 64, "0123456789abcdef"},
+		{"hex", &config.Config{LinkTokenBytes: 16, LinkTokenEncoding: "hex"}, 32, "0123456789abcdef"},
+		{"base64url", &config.Config{LinkTokenBytes: 48, LinkTokenEncoding: "base64url"}, 64,
+			"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"},
+		{"below the minimum falls back to 32 bytes", &config.Config{LinkTokenBytes: 8}, 64, "0123456789abcdef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtService := NewJWTService(tt.cfg, clock.New(), nil)
+
+			seen := make(map[string]bool)
+			for i := 0; i < 1000; i++ {
+				token, err := jwtService.GenerateRandomToken()
+				assert.NoError(t, err)
+				assert.Len(t, token, tt.length)
+				assert.Empty(t, strings.Trim(token, tt.alphabet), "token %q has characters outside the alphabet", token)
+				assert.Equal(t, token, url.QueryEscape(token), "token must embed in a link unescaped")
+				assert.False(t, seen[token], "duplicate token %q", token)
+				seen[token] = true
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+)
+
+// loginFailures counts recent failed logins per client IP and per account, to tell when logins should
+// be escalated to a CAPTCHA. It is in-process, so each instance counts the failures it saw.
+type loginFailures struct {
+	clock  clock.Clock
+	window time.Duration
+
+	mu     sync.Mutex
+	counts map[string]*failureCount
+}
+
+// failureCount is the failures of one key since the window it counts in started
+type failureCount struct {
+	count   int
+	resetAt time.Time
+}
+
+func newLoginFailures(clk clock.Clock, window time.Duration) *loginFailures {
+	return &loginFailures{
+		clock:  clk,
+		window: window,
+		counts: make(map[string]*failureCount),
+	}
+}
+
+// loginFailureKeys are the keys a login's failures are counted under, for the client and the account
+func loginFailureKeys(email, ipAddress string) (string, string) {
+	return "ip:" + ipAddress, "account:" + email
+}
+
+// highest returns the most failures counted for any of the keys in the current window
+func (f *loginFailures) highest(keys ...string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.clock.Now()
+	highest := 0
+	for _, key := range keys {
+		if c, ok := f.counts[key]; ok && now.Before(c.resetAt) {
+			highest = max(highest, c.count)
+		}
+	}
+	return highest
+}
+
+// record counts a failed login against each of the keys
+func (f *loginFailures) record(keys ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.clock.Now()
+	f.prune(now)
+	for _, key := range keys {
+		c, ok := f.counts[key]
+		if !ok {
+			c = &failureCount{resetAt: now.Add(f.window)}
+			f.counts[key] = c
+		}
+		c.count++
+	}
+}
+
+// reset forgets the failures of a key, as after a successful login to the account
+func (f *loginFailures) reset(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.counts, key)
+}
+
+// prune drops counts whose window has passed; the caller holds the lock
+func (f *loginFailures) prune(now time.Time) {
+	for key, c := range f.counts {
+		if !now.Before(c.resetAt) {
+			delete(f.counts, key)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/captcha"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// fakeUserRepo keeps users in memory, keyed by ID
+type fakeUserRepo struct {
+	users  map[uint]*domain.User
+	nextID uint
+}
+
+func newFakeUserRepo(users ...*domain.User) *fakeUserRepo {
+	repo := &fakeUserRepo{users: map[uint]*domain.User{}}
+	for _, user := range users {
+		_ = repo.Create(user)
+	}
+	return repo
+}
+
+func (r *fakeUserRepo) Create(user *domain.User) error {
+	if user.ID == 0 {
+		r.nextID++
+		user.ID = r.nextID
+	} else if user.ID > r.nextID {
+		r.nextID = user.ID
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepo) GetByID(id uint) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepo) GetByEmail(email string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email == email && !user.DeletedAt.Valid {
+			return user, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) GetByEmailVerifyToken(token string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.EmailVerifyToken == token {
+			return user, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) GetByBackupEmailToken(token string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.BackupEmailToken == token {
+			return user, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) Update(user *domain.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateLastLogin(userID uint) error {
+	user, err := r.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	user.LastLoginAt = &now
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateEmailVerifyToken(userID uint, token string) error {
+	user, err := r.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	user.EmailVerifyToken = token
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateVerifyNudgedAt(userID uint, at time.Time) error {
+	user, err := r.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	user.VerifyNudgedAt = &at
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateResetEmailSentAt(userID uint, at time.Time) error {
+	user, err := r.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	user.ResetEmailSentAt = &at
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateVerifyResends(userID uint, windowStart time.Time, count int) error {
+	user, err := r.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	user.VerifyResends = count
+	user.VerifyResendFrom = &windowStart
+	return nil
+}
+
+func (r *fakeUserRepo) ExistsByEmail(email string, includeDeleted bool) (bool, error) {
+	email = domain.NormalizeEmail(email)
+	for _, user := range r.users {
+		if user.Email == email && (includeDeleted || !user.DeletedAt.Valid) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeUserRepo) ExistsByUsername(username string) (bool, error) {
+	username = domain.NormalizeUsername(username)
+	for _, user := range r.users {
+		if user.GetUsername() == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeRefreshTokenRepo keeps refresh tokens in memory, keyed by token
+type fakeRefreshTokenRepo struct {
+	tokens map[string]*domain.RefreshToken
+	nextID uint
+}
+
+func newFakeRefreshTokenRepo() *fakeRefreshTokenRepo {
+	return &fakeRefreshTokenRepo{tokens: map[string]*domain.RefreshToken{}}
+}
+
+func (r *fakeRefreshTokenRepo) Create(token *domain.RefreshToken) error {
+	r.nextID++
+	token.ID = r.nextID
+	r.tokens[token.Token] = token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByToken(token string) (*domain.RefreshToken, error) {
+	refreshToken, ok := r.tokens[token]
+	if !ok {
+		return nil, domain.ErrTokenNotFound
+	}
+	return refreshToken, nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByID(id uint) (*domain.RefreshToken, error) {
+	for _, refreshToken := range r.tokens {
+		if refreshToken.ID == id {
+			return refreshToken, nil
+		}
+	}
+	return nil, domain.ErrTokenNotFound
+}
+
+func (r *fakeRefreshTokenRepo) GetByUserID(userID uint) ([]*domain.RefreshToken, error) {
+	var tokens []*domain.RefreshToken
+	for _, refreshToken := range r.tokens {
+		if refreshToken.UserID == userID {
+			tokens = append(tokens, refreshToken)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *fakeRefreshTokenRepo) Delete(token string) error {
+	delete(r.tokens, token)
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) DeleteByUserID(userID uint) error {
+	_, err := r.DeleteByUserIDExcept(userID, "")
+	return err
+}
+
+func (r *fakeRefreshTokenRepo) DeleteByUserIDExcept(userID uint, keepToken string) (int64, error) {
+	var deleted int64
+	for key, token := range r.tokens {
+		if token.UserID == userID && key != keepToken {
+			delete(r.tokens, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *fakeRefreshTokenRepo) DeleteByDevice(userID uint, deviceKey string) (int64, error) {
+	var deleted int64
+	for key, token := range r.tokens {
+		if token.UserID == userID && token.DeviceKey == deviceKey {
+			delete(r.tokens, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *fakeRefreshTokenRepo) DeleteExpired() error {
+	for key, token := range r.tokens {
+		if time.Now().After(token.ExpiresAt) {
+			delete(r.tokens, key)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) DeleteOldestTokensForUser(userID uint, keepCount int) error {
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) MarkRefreshed(id uint, at time.Time) error {
+	for _, token := range r.tokens {
+		if token.ID == id {
+			token.RefreshedAt = &at
+		}
+	}
+	return nil
+}
+
+// fakePasswordResetRepo keeps password reset tokens in memory, keyed by token
+type fakePasswordResetRepo struct {
+	resets map[string]*domain.PasswordReset
+}
+
+func newFakePasswordResetRepo() *fakePasswordResetRepo {
+	return &fakePasswordResetRepo{resets: map[string]*domain.PasswordReset{}}
+}
+
+func (r *fakePasswordResetRepo) Create(reset *domain.PasswordReset) error {
+	r.resets[reset.Token] = reset
+	return nil
+}
+
+func (r *fakePasswordResetRepo) GetByToken(token string) (*domain.PasswordReset, error) {
+	reset, ok := r.resets[token]
+	if !ok {
+		return nil, domain.ErrTokenNotFound
+	}
+	return reset, nil
+}
+
+func (r *fakePasswordResetRepo) MarkAsUsed(token string) error {
+	reset, err := r.GetByToken(token)
+	if err != nil {
+		return err
+	}
+	reset.Used = true
+	return nil
+}
+
+func (r *fakePasswordResetRepo) DeleteExpired() error { return nil }
+
+func (r *fakePasswordResetRepo) DeleteUsed() error { return nil }
+
+func (r *fakePasswordResetRepo) GetValidTokensCount(email string) (int64, error) {
+	var count int64
+	for _, reset := range r.resets {
+		if reset.Email == email && !reset.Used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// fakeAuditRepo records the actions of the audit entries written
+type fakeAuditRepo struct {
+	actions []domain.AuditAction
+	levels  []domain.AuditLevel
+}
+
+func (r *fakeAuditRepo) CreateAuditEntry(
+	userID *uint,
+	targetID *uint,
+	action domain.AuditAction,
+	level domain.AuditLevel,
+	resource string,
+	description string,
+	ipAddress string,
+	userAgent string,
+	metadata map[string]interface{},
+) error {
+	r.actions = append(r.actions, action)
+	r.levels = append(r.levels, level)
+	return nil
+}
+
+// fakeConsentRepo keeps the consents given
+type fakeConsentRepo struct {
+	consents []*userdomain.UserConsent
+}
+
+func (r *fakeConsentRepo) Create(consents ...*userdomain.UserConsent) error {
+	r.consents = append(r.consents, consents...)
+	return nil
+}
+
+// fakeCaptcha accepts the token "solved", or fails every check with err when it is set
+type fakeCaptcha struct {
+	err      error
+	verified []string
+}
+
+func (f *fakeCaptcha) Verify(ctx context.Context, token, remoteIP string) error {
+	f.verified = append(f.verified, token)
+	if f.err != nil {
+		return f.err
+	}
+	if token != "solved" {
+		return captcha.ErrInvalid
+	}
+	return nil
+}
+
+// fakeRecoveryRepo keeps the recovery requests queued for review
+type fakeRecoveryRepo struct {
+	requests []*domain.RecoveryRequest
+}
+
+func (r *fakeRecoveryRepo) Create(request *domain.RecoveryRequest) error {
+	r.requests = append(r.requests, request)
+	return nil
+}
+
+func (r *fakeRecoveryRepo) HasPending(userID uint) (bool, error) {
+	for _, request := range r.requests {
+		if request.UserID == userID && request.Status == domain.RecoveryStatusPending {
+			return true, nil
+		}
+	}
+	return false, nil
+}
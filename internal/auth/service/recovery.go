@@ -0,0 +1,292 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// RequestAccountRecovery starts recovering an account whose owner has lost access to its email. A
+// backup email request mails a reset link to the backup email saved in the account's preferences; an
+// admin approval request is queued for an admin to review. Like ForgotPassword it succeeds without
+// doing anything when there is nothing to recover, so it doesn't reveal which accounts exist.
+func (s *AuthService) RequestAccountRecovery(req *domain.AccountRecoveryRequest, ipAddress, userAgent string) error {
+	if !s.config.AccountRecoveryMethodEnabled(req.Method) {
+		return domain.ErrRecoveryUnavailable
+	}
+
+	// Both methods end with a reset link in someone's inbox
+	if !s.emailService.Enabled() {
+		s.logger.Warn("account recovery requested while email is disabled, set EMAIL_ENABLED to allow recovery")
+		return domain.ErrEmailDisabled
+	}
+
+	email := domain.NormalizeEmail(req.Email)
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			s.logger.Info("account recovery requested for non-existent email", "email", email, "method", req.Method)
+			return nil
+		}
+		s.logger.Error("failed to get user by email", "email", email, "error", err)
+		return fmt.Errorf("failed to process account recovery request: %w", err)
+	}
+
+	if req.Method == domain.RecoveryMethodBackupEmail {
+		err = s.recoverWithBackupEmail(user, ipAddress, userAgent)
+	} else {
+		err = s.queueRecoveryRequest(user, req, ipAddress, userAgent)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Tell the owner, in case they still read the account's inbox and didn't ask for this
+	if err := s.emailService.SendRecoveryRequested(user.Email, user.FirstName); err != nil {
+		s.logger.Error("failed to send account recovery notice", "user_id", user.ID, "error", err)
+	}
+
+	return nil
+}
+
+// SendRecoveryReset mails a password reset link for the user's account to sendTo. Admins call it
+// through an approved recovery request once they have verified the requester's identity.
+func (s *AuthService) SendRecoveryReset(userID uint, sendTo string) error {
+	if !s.emailService.Enabled() {
+		return domain.ErrEmailDisabled
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.issuePasswordReset(user, sendTo)
+}
+
+// recoverWithBackupEmail mails a reset link to the user's backup email, when they confirmed one
+func (s *AuthService) recoverWithBackupEmail(user *domain.User, ipAddress, userAgent string) error {
+	backupEmail := user.Preferences.BackupEmail
+	if backupEmail == "" {
+		s.logger.Info("backup email recovery requested for an account without a backup email", "user_id", user.ID)
+		return nil
+	}
+
+	if err := s.issuePasswordReset(user, backupEmail); err != nil {
+		return err
+	}
+
+	s.recordRecoveryRequest(user, fmt.Sprintf("Account recovery link sent to backup email for %s", user.Email),
+		ipAddress, userAgent, map[string]interface{}{
+			"method":       domain.RecoveryMethodBackupEmail,
+			"backup_email": backupEmail,
+		})
+	return nil
+}
+
+// backupEmailConfirmWindow is how long the link confirming a new backup email stays valid
+const backupEmailConfirmWindow = 24 * time.Hour
+
+// SetBackupEmail changes the backup email that receives account recovery links, or removes it when the
+// request's address is empty. It needs the current password, and a new address only takes over once the
+// confirmation link mailed to it is opened, so a stolen session can't redirect recovery to another inbox.
+// The account's own address is told about the change either way.
+func (s *AuthService) SetBackupEmail(
+	userID uint,
+	req *domain.BackupEmailRequest,
+	ipAddress, userAgent string,
+) (*domain.BackupEmailResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.verifyPassword(req.Password, user.PasswordHash); err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	backupEmail := domain.NormalizeEmail(req.BackupEmail)
+	if backupEmail != "" && backupEmail == user.Email {
+		return nil, domain.ErrBackupEmailIsPrimary
+	}
+
+	// A newer request replaces any address still waiting for confirmation
+	user.PendingBackupEmail = ""
+	user.BackupEmailToken = ""
+	user.BackupEmailExpiresAt = nil
+
+	description := fmt.Sprintf("Backup email removed for %s", user.Email)
+	if backupEmail == "" {
+		user.Preferences.BackupEmail = ""
+	} else {
+		// The new address can only be confirmed through the link mailed to it
+		if !s.emailService.Enabled() {
+			return nil, domain.ErrEmailDisabled
+		}
+
+		token, err := s.jwtService.GenerateRandomToken()
+		if err != nil {
+			s.logger.Error("failed to generate backup email token", "user_id", user.ID, "error", err)
+			return nil, fmt.Errorf("failed to generate backup email token: %w", err)
+		}
+
+		expiresAt := s.clock.Now().Add(backupEmailConfirmWindow)
+		user.PendingBackupEmail = backupEmail
+		user.BackupEmailToken = token
+		user.BackupEmailExpiresAt = &expiresAt
+		description = fmt.Sprintf("Backup email confirmation sent to %s for %s", backupEmail, user.Email)
+	}
+
+	if err := s.userRepo.Update(user); err != nil {
+		s.logger.Error("failed to update backup email", "user_id", user.ID, "error", err)
+		return nil, fmt.Errorf("failed to update backup email: %w", err)
+	}
+
+	if backupEmail != "" {
+		if err := s.emailService.SendBackupEmailConfirmation(backupEmail, user.BackupEmailToken, user.FirstName); err != nil {
+			s.logger.Error("failed to send backup email confirmation", "user_id", user.ID, "error", err)
+			return nil, fmt.Errorf("failed to send backup email confirmation: %w", err)
+		}
+	}
+
+	s.recordBackupEmailChange(user, description, ipAddress, userAgent, map[string]interface{}{
+		"backup_email": backupEmail,
+		"confirmed":    false,
+	})
+
+	// Tell the owner, in case it wasn't them
+	if err := s.emailService.SendBackupEmailChanged(user.Email, user.FirstName, backupEmail, false); err != nil {
+		s.logger.Error("failed to send backup email change notice", "user_id", user.ID, "error", err)
+	}
+
+	return &domain.BackupEmailResponse{
+		BackupEmail:        user.Preferences.BackupEmail,
+		PendingBackupEmail: user.PendingBackupEmail,
+	}, nil
+}
+
+// ConfirmBackupEmail makes the address waiting for confirmation the account's backup email, using the
+// token from the link mailed to it
+func (s *AuthService) ConfirmBackupEmail(req *domain.ConfirmBackupEmailRequest, ipAddress, userAgent string) error {
+	user, err := s.userRepo.GetByBackupEmailToken(req.Token)
+	if err != nil || !domain.TokenMatches(user.BackupEmailToken, req.Token) {
+		return domain.ErrInvalidToken
+	}
+	if user.BackupEmailExpiresAt == nil || !s.clock.Now().Before(*user.BackupEmailExpiresAt) {
+		return domain.ErrTokenExpired
+	}
+
+	backupEmail := user.PendingBackupEmail
+	user.Preferences.BackupEmail = backupEmail
+	user.PendingBackupEmail = ""
+	user.BackupEmailToken = ""
+	user.BackupEmailExpiresAt = nil
+
+	if err := s.userRepo.Update(user); err != nil {
+		s.logger.Error("failed to confirm backup email", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to confirm backup email: %w", err)
+	}
+
+	s.recordBackupEmailChange(user, fmt.Sprintf("Backup email %s confirmed for %s", backupEmail, user.Email),
+		ipAddress, userAgent, map[string]interface{}{
+			"backup_email": backupEmail,
+			"confirmed":    true,
+		})
+
+	if err := s.emailService.SendBackupEmailChanged(user.Email, user.FirstName, backupEmail, true); err != nil {
+		s.logger.Error("failed to send backup email change notice", "user_id", user.ID, "error", err)
+	}
+
+	return nil
+}
+
+// recordBackupEmailChange audits a change to the address account recovery sends links to
+func (s *AuthService) recordBackupEmailChange(
+	user *domain.User,
+	description, ipAddress, userAgent string,
+	metadata map[string]interface{},
+) {
+	if err := s.auditRepo.CreateAuditEntry(
+		&user.ID,
+		&user.ID,
+		domain.AuditActionBackupEmailChanged,
+		domain.AuditLevelWarning,
+		"auth",
+		description,
+		ipAddress,
+		userAgent,
+		metadata,
+	); err != nil {
+		s.logger.Error("failed to create audit log for backup email change", "user_id", user.ID, "error", err)
+	}
+}
+
+// queueRecoveryRequest queues the request for an admin to review. An account has at most one
+// pending request, so repeated submissions don't flood the queue.
+func (s *AuthService) queueRecoveryRequest(
+	user *domain.User,
+	req *domain.AccountRecoveryRequest,
+	ipAddress, userAgent string,
+) error {
+	if s.recoveryRepo == nil {
+		return domain.ErrRecoveryUnavailable
+	}
+
+	pending, err := s.recoveryRepo.HasPending(user.ID)
+	if err != nil {
+		s.logger.Error("failed to check pending recovery requests", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to process account recovery request: %w", err)
+	}
+	if pending {
+		s.logger.Info("account recovery already pending review", "user_id", user.ID)
+		return nil
+	}
+
+	request := &domain.RecoveryRequest{
+		ID:           uuid.New().String(),
+		UserID:       user.ID,
+		Email:        user.Email,
+		ContactEmail: domain.NormalizeEmail(req.ContactEmail),
+		Reason:       req.Reason,
+		Status:       domain.RecoveryStatusPending,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		CreatedAt:    s.clock.Now(),
+	}
+	if err := s.recoveryRepo.Create(request); err != nil {
+		s.logger.Error("failed to create recovery request", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to create recovery request: %w", err)
+	}
+
+	s.recordRecoveryRequest(user, fmt.Sprintf("Account recovery requested for %s, awaiting admin approval", user.Email),
+		ipAddress, userAgent, map[string]interface{}{
+			"method":        domain.RecoveryMethodAdminApproval,
+			"request_id":    request.ID,
+			"contact_email": request.ContactEmail,
+		})
+	return nil
+}
+
+// recordRecoveryRequest audits a recovery request against the account it targets
+func (s *AuthService) recordRecoveryRequest(
+	user *domain.User,
+	description, ipAddress, userAgent string,
+	metadata map[string]interface{},
+) {
+	if err := s.auditRepo.CreateAuditEntry(
+		nil,
+		&user.ID,
+		domain.AuditActionRecoveryRequested,
+		domain.AuditLevelWarning,
+		"auth",
+		description,
+		ipAddress,
+		userAgent,
+		metadata,
+	); err != nil {
+		s.logger.Error("failed to create audit log for account recovery", "user_id", user.ID, "error", err)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func recoveryConfig(methods string) *config.Config {
+	return &config.Config{EmailEnabled: true, AccountRecoveryMethods: methods}
+}
+
+func TestRequestAccountRecoveryWithBackupEmail(t *testing.T) {
+	request := &domain.AccountRecoveryRequest{Email: "Lost@Example.com", Method: domain.RecoveryMethodBackupEmail}
+
+	t.Run("issues a reset for the account", func(t *testing.T) {
+		user := testUser(t, "lost@example.com", domain.StatusActive)
+		user.Preferences.BackupEmail = "backup@example.org"
+		s, repos := newTestAuthService(recoveryConfig("backup_email"), user)
+
+		assert.NoError(t, s.RequestAccountRecovery(request, "127.0.0.1", "test"))
+
+		// The reset is for the account itself, however the link reached its owner
+		count, _ := repos.resets.GetValidTokensCount("lost@example.com")
+		assert.Equal(t, int64(1), count)
+		assert.Equal(t, []domain.AuditAction{domain.AuditActionRecoveryRequested}, repos.audit.actions)
+	})
+
+	t.Run("does nothing without a backup email", func(t *testing.T) {
+		s, repos := newTestAuthService(recoveryConfig("backup_email"), testUser(t, "lost@example.com", domain.StatusActive))
+
+		assert.NoError(t, s.RequestAccountRecovery(request, "127.0.0.1", "test"))
+
+		assert.Empty(t, repos.resets.resets)
+		assert.Empty(t, repos.audit.actions)
+	})
+
+	t.Run("does not reveal unknown accounts", func(t *testing.T) {
+		s, repos := newTestAuthService(recoveryConfig("backup_email"))
+
+		assert.NoError(t, s.RequestAccountRecovery(request, "127.0.0.1", "test"))
+		assert.Empty(t, repos.resets.resets)
+	})
+
+	t.Run("method must be enabled", func(t *testing.T) {
+		user := testUser(t, "lost@example.com", domain.StatusActive)
+		user.Preferences.BackupEmail = "backup@example.org"
+		s, repos := newTestAuthService(recoveryConfig("admin_approval"), user)
+
+		assert.ErrorIs(t, s.RequestAccountRecovery(request, "127.0.0.1", "test"), domain.ErrRecoveryUnavailable)
+		assert.Empty(t, repos.resets.resets)
+	})
+}
+
+func TestRequestAccountRecoveryWithAdminApproval(t *testing.T) {
+	user := testUser(t, "lost@example.com", domain.StatusActive)
+	s, repos := newTestAuthService(recoveryConfig("backup_email, admin_approval"), user)
+	request := &domain.AccountRecoveryRequest{
+		Email:        "lost@example.com",
+		Method:       domain.RecoveryMethodAdminApproval,
+		ContactEmail: " New@Example.org ",
+		Reason:       "I lost access to my old work inbox",
+	}
+
+	assert.NoError(t, s.RequestAccountRecovery(request, "127.0.0.1", "test"))
+
+	if assert.Len(t, repos.recoveries.requests, 1) {
+		queued := repos.recoveries.requests[0]
+		assert.Equal(t, user.ID, queued.UserID)
+		assert.Equal(t, "new@example.org", queued.ContactEmail)
+		assert.Equal(t, domain.RecoveryStatusPending, queued.Status)
+		assert.NotEmpty(t, queued.ID)
+	}
+	// Nothing is sent until an admin approves
+	assert.Empty(t, repos.resets.resets)
+	assert.Equal(t, []domain.AuditAction{domain.AuditActionRecoveryRequested}, repos.audit.actions)
+
+	// A second request while one is pending is not queued again
+	assert.NoError(t, s.RequestAccountRecovery(request, "127.0.0.1", "test"))
+	assert.Len(t, repos.recoveries.requests, 1)
+
+	assert.NoError(t, s.SendRecoveryReset(user.ID, "new@example.org"))
+	count, _ := repos.resets.GetValidTokensCount("lost@example.com")
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRequestAccountRecoveryNeedsEmail(t *testing.T) {
+	s, _ := newTestAuthService(&config.Config{AccountRecoveryMethods: "backup_email"})
+
+	err := s.RequestAccountRecovery(&domain.AccountRecoveryRequest{
+		Email: "lost@example.com", Method: domain.RecoveryMethodBackupEmail,
+	}, "127.0.0.1", "test")
+
+	assert.ErrorIs(t, err, domain.ErrEmailDisabled)
+}
+
+func TestSetBackupEmail(t *testing.T) {
+	setup := func(t *testing.T, cfg *config.Config) (*AuthService, *authTestRepos, *domain.User) {
+		user := testUser(t, "owner@example.com", domain.StatusActive)
+		user.Preferences.BackupEmail = "old@example.org"
+		s, repos := newTestAuthService(cfg, user)
+		return s, repos, user
+	}
+
+	t.Run("needs the current password", func(t *testing.T) {
+		s, repos, user := setup(t, recoveryConfig("backup_email"))
+
+		_, err := s.SetBackupEmail(user.ID, &domain.BackupEmailRequest{
+			BackupEmail: "attacker@example.net", Password: "wrong-password",
+		}, "127.0.0.1", "test")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		assert.Empty(t, user.PendingBackupEmail)
+		assert.Empty(t, repos.audit.actions)
+	})
+
+	t.Run("a new address only takes over once confirmed", func(t *testing.T) {
+		s, repos, user := setup(t, recoveryConfig("backup_email"))
+
+		response, err := s.SetBackupEmail(user.ID, &domain.BackupEmailRequest{
+			BackupEmail: " New@Example.org ", Password: "password123",
+		}, "127.0.0.1", "test")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "old@example.org", response.BackupEmail)
+		assert.Equal(t, "new@example.org", response.PendingBackupEmail)
+		assert.Equal(t, "old@example.org", user.Preferences.BackupEmail)
+		assert.NotEmpty(t, user.BackupEmailToken)
+
+		// Recovery keeps using the confirmed address meanwhile
+		request := &domain.AccountRecoveryRequest{Email: "owner@example.com", Method: domain.RecoveryMethodBackupEmail}
+		assert.NoError(t, s.RequestAccountRecovery(request, "127.0.0.1", "test"))
+
+		token := user.BackupEmailToken
+		assert.ErrorIs(t, s.ConfirmBackupEmail(&domain.ConfirmBackupEmailRequest{Token: "other"}, "", ""),
+			domain.ErrInvalidToken)
+		assert.NoError(t, s.ConfirmBackupEmail(&domain.ConfirmBackupEmailRequest{Token: token}, "", ""))
+		assert.Equal(t, "new@example.org", user.Preferences.BackupEmail)
+		assert.Empty(t, user.PendingBackupEmail)
+		assert.Empty(t, user.BackupEmailToken)
+
+		// The link works once
+		assert.ErrorIs(t, s.ConfirmBackupEmail(&domain.ConfirmBackupEmailRequest{Token: token}, "", ""),
+			domain.ErrInvalidToken)
+		assert.Equal(t, []domain.AuditAction{
+			domain.AuditActionBackupEmailChanged,
+			domain.AuditActionRecoveryRequested,
+			domain.AuditActionBackupEmailChanged,
+		}, repos.audit.actions)
+	})
+
+	t.Run("the confirmation link expires", func(t *testing.T) {
+		s, repos, user := setup(t, recoveryConfig("backup_email"))
+
+		_, err := s.SetBackupEmail(user.ID, &domain.BackupEmailRequest{
+			BackupEmail: "new@example.org", Password: "password123",
+		}, "127.0.0.1", "test")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		repos.clock.Advance(25 * time.Hour)
+		err = s.ConfirmBackupEmail(&domain.ConfirmBackupEmailRequest{Token: user.BackupEmailToken}, "", "")
+		assert.ErrorIs(t, err, domain.ErrTokenExpired)
+		assert.Equal(t, "old@example.org", user.Preferences.BackupEmail)
+	})
+
+	t.Run("an empty address removes the backup email", func(t *testing.T) {
+		s, repos, user := setup(t, recoveryConfig("backup_email"))
+
+		response, err := s.SetBackupEmail(user.ID, &domain.BackupEmailRequest{Password: "password123"}, "127.0.0.1", "test")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Empty(t, response.BackupEmail)
+		assert.Empty(t, user.Preferences.BackupEmail)
+		assert.Equal(t, []domain.AuditAction{domain.AuditActionBackupEmailChanged}, repos.audit.actions)
+	})
+
+	t.Run("rejects the account's own email", func(t *testing.T) {
+		s, _, user := setup(t, recoveryConfig("backup_email"))
+
+		_, err := s.SetBackupEmail(user.ID, &domain.BackupEmailRequest{
+			BackupEmail: "Owner@Example.com", Password: "password123",
+		}, "127.0.0.1", "test")
+		assert.ErrorIs(t, err, domain.ErrBackupEmailIsPrimary)
+	})
+
+	t.Run("a new address needs email to be confirmed", func(t *testing.T) {
+		s, _, user := setup(t, &config.Config{})
+
+		_, err := s.SetBackupEmail(user.ID, &domain.BackupEmailRequest{
+			BackupEmail: "new@example.org", Password: "password123",
+		}, "127.0.0.1", "test")
+		assert.ErrorIs(t, err, domain.ErrEmailDisabled)
+		assert.Equal(t, "old@example.org", user.Preferences.BackupEmail)
+	})
+}
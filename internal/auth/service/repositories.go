@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/auth/repository"
+	"github.com/acheevo/tfa/internal/shared/captcha"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+	userrepo "github.com/acheevo/tfa/internal/user/repository"
+)
+
+// UserRepo is the user storage the auth service needs
+type UserRepo interface {
+	Create(user *domain.User) error
+	GetByID(id uint) (*domain.User, error)
+	GetByEmail(email string) (*domain.User, error)
+	GetByEmailVerifyToken(token string) (*domain.User, error)
+	GetByBackupEmailToken(token string) (*domain.User, error)
+	Update(user *domain.User) error
+	UpdateLastLogin(userID uint) error
+	UpdateEmailVerifyToken(userID uint, token string) error
+	UpdateVerifyNudgedAt(userID uint, at time.Time) error
+	UpdateResetEmailSentAt(userID uint, at time.Time) error
+	UpdateVerifyResends(userID uint, windowStart time.Time, count int) error
+	ExistsByEmail(email string, includeDeleted bool) (bool, error)
+	ExistsByUsername(username string) (bool, error)
+}
+
+// RevocationStore records when access tokens were revoked, so they are rejected until they expire
+type RevocationStore interface {
+	RevokeUser(userID uint, at time.Time, ttl time.Duration) error
+	RevokeSession(sessionID string, at time.Time, ttl time.Duration) error
+	RevokedAt(userID uint, sessionID string) (user, session time.Time, err error)
+}
+
+// RefreshTokenRepo stores refresh tokens, one per signed-in device
+type RefreshTokenRepo interface {
+	Create(token *domain.RefreshToken) error
+	GetByToken(token string) (*domain.RefreshToken, error)
+	GetByID(id uint) (*domain.RefreshToken, error)
+	GetByUserID(userID uint) ([]*domain.RefreshToken, error)
+	Delete(token string) error
+	DeleteByUserID(userID uint) error
+	DeleteByUserIDExcept(userID uint, keepToken string) (int64, error)
+	DeleteByDevice(userID uint, deviceKey string) (int64, error)
+	DeleteExpired() error
+	DeleteOldestTokensForUser(userID uint, keepCount int) error
+	MarkRefreshed(id uint, at time.Time) error
+}
+
+// PasswordResetRepo stores password reset tokens
+type PasswordResetRepo interface {
+	Create(reset *domain.PasswordReset) error
+	GetByToken(token string) (*domain.PasswordReset, error)
+	MarkAsUsed(token string) error
+	DeleteExpired() error
+	DeleteUsed() error
+	GetValidTokensCount(email string) (int64, error)
+}
+
+// AuditRepo records audit log entries
+type AuditRepo interface {
+	CreateAuditEntry(
+		userID *uint,
+		targetID *uint,
+		action domain.AuditAction,
+		level domain.AuditLevel,
+		resource string,
+		description string,
+		ipAddress string,
+		userAgent string,
+		metadata map[string]interface{},
+	) error
+}
+
+// ConsentRepo records the consents a user gives at registration
+type ConsentRepo interface {
+	Create(consents ...*userdomain.UserConsent) error
+}
+
+// RecoveryRequestRepo queues account recovery requests for admin review
+type RecoveryRequestRepo interface {
+	Create(request *domain.RecoveryRequest) error
+	HasPending(userID uint) (bool, error)
+}
+
+// CaptchaVerifier checks the CAPTCHA tokens logins carry once they are escalated, returning
+// captcha.ErrInvalid for a token the service rejected
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// The repositories used in production satisfy the interfaces
+var (
+	_ UserRepo            = (*repository.UserRepository)(nil)
+	_ RefreshTokenRepo    = (*repository.RefreshTokenRepository)(nil)
+	_ PasswordResetRepo   = (*repository.PasswordResetRepository)(nil)
+	_ AuditRepo           = (*userrepo.AuditRepository)(nil)
+	_ ConsentRepo         = (*userrepo.ConsentRepository)(nil)
+	_ RecoveryRequestRepo = (*repository.RecoveryRequestRepository)(nil)
+	_ CaptchaVerifier     = (*captcha.HTTPVerifier)(nil)
+)
@@ -1,15 +1,21 @@
 package transport
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 
 	"github.com/acheevo/tfa/internal/auth/domain"
 	"github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/middleware"
 	"github.com/acheevo/tfa/internal/shared/config"
+	apperrors "github.com/acheevo/tfa/internal/shared/errors"
 )
 
 // AuthHandler handles HTTP requests for authentication
@@ -36,14 +42,18 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.Register(&req)
+	req.DeviceID = c.GetHeader(domain.DeviceIDHeader)
+	response, err := h.authService.Register(&req, c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
 		h.handleAuthError(c, err)
 		return
 	}
 
-	// Set HTTP-only cookies for tokens
-	h.setAuthCookies(c, response.AccessToken, response.RefreshToken)
+	// Set HTTP-only cookies for tokens; registrations awaiting approval get none
+	if response.AccessToken != "" {
+		h.setAuthCookies(c, response.AccessToken, response.RefreshToken)
+		h.rotateCSRFToken(c)
+	}
 
 	c.JSON(http.StatusCreated, response)
 }
@@ -56,7 +66,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.Login(&req)
+	req.DeviceID = c.GetHeader(domain.DeviceIDHeader)
+	response, err := h.authService.Login(&req, c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
 		h.handleAuthError(c, err)
 		return
@@ -64,6 +75,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	// Set HTTP-only cookies for tokens
 	h.setAuthCookies(c, response.AccessToken, response.RefreshToken)
+	h.rotateCSRFToken(c)
 
 	c.JSON(http.StatusOK, response)
 }
@@ -97,6 +109,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 
 	// Set HTTP-only cookies for tokens
 	h.setAuthCookies(c, response.AccessToken, response.RefreshToken)
+	h.rotateCSRFToken(c)
 
 	c.JSON(http.StatusOK, response)
 }
@@ -170,6 +183,11 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	}
 
 	if err := h.authService.ForgotPassword(&req); err != nil {
+		// Email being off is a configuration problem, not something about this user, so it's safe to say
+		if err == domain.ErrEmailDisabled {
+			h.respondEmailDisabled(c, "password reset")
+			return
+		}
 		h.logger.Error("forgot password error", "error", err)
 		// Don't reveal specific errors for security
 		c.JSON(http.StatusOK, domain.MessageResponse{
@@ -183,6 +201,36 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	})
 }
 
+// RequestAccountRecovery handles POST /api/auth/recovery-request. Whatever happened to the account,
+// the response is the same, so it can't be used to find out which accounts exist.
+func (h *AuthHandler) RequestAccountRecovery(c *gin.Context) {
+	var req domain.AccountRecoveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	err := h.authService.RequestAccountRecovery(&req, c.ClientIP(), c.GetHeader("User-Agent"))
+	switch err {
+	case nil:
+	case domain.ErrRecoveryUnavailable:
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{
+			Error:   "account recovery method is not available",
+			Details: map[string]string{"method": req.Method},
+		})
+		return
+	case domain.ErrEmailDisabled:
+		h.respondEmailDisabled(c, "account recovery")
+		return
+	default:
+		h.logger.Error("account recovery error", "method", req.Method, "error", err)
+	}
+
+	c.JSON(http.StatusOK, domain.MessageResponse{
+		Message: "if the account can be recovered this way, you will receive an email with the next steps",
+	})
+}
+
 // ResetPassword handles password reset
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	var req domain.ResetPasswordRequest
@@ -191,7 +239,10 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.ResetPassword(&req); err != nil {
+	// The browser performing the reset may still hold a session, identified by its refresh token cookie
+	currentRefreshToken, _ := c.Cookie("refresh_token")
+
+	if err := h.authService.ResetPassword(&req, currentRefreshToken, c.ClientIP(), c.Request.UserAgent()); err != nil {
 		h.handleAuthError(c, err)
 		return
 	}
@@ -219,12 +270,102 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.ChangePassword(uid, &req); err != nil {
+	// The current session is identified by its refresh token cookie, if present
+	currentRefreshToken, _ := c.Cookie("refresh_token")
+
+	response, err := h.authService.ChangePassword(uid, &req, currentRefreshToken, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		h.handleAuthError(c, err)
+		return
+	}
+
+	if response.RefreshToken != "" {
+		h.setAuthCookies(c, response.AccessToken, response.RefreshToken)
+		h.rotateCSRFToken(c)
+	} else {
+		h.clearAuthCookies(c)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetBackupEmail handles PUT /api/auth/backup-email. A new address is mailed a confirmation link and
+// receives recovery links only once it is opened; an empty address removes the backup email.
+func (h *AuthHandler) SetBackupEmail(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	var req domain.BackupEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.authService.SetBackupEmail(uid, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if err == domain.ErrEmailDisabled {
+			h.respondEmailDisabled(c, "backup email confirmation")
+			return
+		}
 		h.handleAuthError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.MessageResponse{Message: "password changed successfully"})
+	c.JSON(http.StatusOK, response)
+}
+
+// ConfirmBackupEmail handles POST /api/auth/confirm-backup-email with the token from the confirmation link
+func (h *AuthHandler) ConfirmBackupEmail(c *gin.Context) {
+	var req domain.ConfirmBackupEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	if err := h.authService.ConfirmBackupEmail(&req, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		h.handleAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.MessageResponse{Message: "backup email confirmed"})
+}
+
+// StepUp re-confirms the caller's password and returns a short-lived step-up token
+func (h *AuthHandler) StepUp(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	var req domain.StepUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.authService.StepUp(uid, &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		h.handleAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // GetProfile handles getting user profile
@@ -266,6 +407,17 @@ func (h *AuthHandler) ResendEmailVerification(c *gin.Context) {
 	}
 
 	if err := h.authService.ResendEmailVerification(uid); err != nil {
+		if err == domain.ErrEmailDisabled {
+			h.respondEmailDisabled(c, "email verification")
+			return
+		}
+		if err == domain.ErrTooManyVerifyResends {
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse{
+				Error:   "verification email was sent recently, please wait before requesting another",
+				Details: map[string]string{"reason": "verification_resend_limited"},
+			})
+			return
+		}
 		h.logger.Error("failed to resend email verification", "user_id", uid, "error", err)
 		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: err.Error()})
 		return
@@ -274,6 +426,60 @@ func (h *AuthHandler) ResendEmailVerification(c *gin.Context) {
 	c.JSON(http.StatusOK, domain.MessageResponse{Message: "verification email sent"})
 }
 
+// CheckEmailAvailable handles GET /api/auth/email-available, telling the signup form whether an
+// email is taken. It answers only when enabled, since it reveals which addresses are registered.
+func (h *AuthHandler) CheckEmailAvailable(c *gin.Context) {
+	if !h.config.EmailAvailabilityCheck {
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Error: "email availability check is disabled"})
+		return
+	}
+
+	var req domain.EmailAvailabilityRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	available, err := h.authService.IsEmailAvailable(req.Email)
+	if err != nil {
+		h.logger.Error("failed to check email availability", "error", err)
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Error: "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.EmailAvailabilityResponse{Available: available})
+}
+
+// Introspect handles POST /api/auth/introspect, telling a gateway whether an access token is active
+// (RFC 7662). Callers authenticate with a client key, checked by the route's middleware.
+func (h *AuthHandler) Introspect(c *gin.Context) {
+	var req domain.IntrospectionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, h.authService.IntrospectToken(req.Token))
+}
+
+// IntrospectBatch handles POST /api/auth/introspect/batch, introspecting up to 100 tokens at once
+func (h *AuthHandler) IntrospectBatch(c *gin.Context) {
+	var req domain.BatchIntrospectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	results := make([]*domain.IntrospectionResponse, len(req.Tokens))
+	for i, token := range req.Tokens {
+		results[i] = h.authService.IntrospectToken(token)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, domain.BatchIntrospectionResponse{Results: results})
+}
+
 // CheckAuth handles checking authentication status
 func (h *AuthHandler) CheckAuth(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -301,9 +507,29 @@ func (h *AuthHandler) CheckAuth(c *gin.Context) {
 	})
 }
 
+// GetSession returns the remaining lifetime of the current access and refresh tokens
+func (h *AuthHandler) GetSession(c *gin.Context) {
+	value, exists := c.Get("jwt_claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	claims, ok := value.(*domain.JWTClaims)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Error: "invalid token claims"})
+		return
+	}
+
+	refreshToken, _ := c.Cookie("refresh_token")
+	c.JSON(http.StatusOK, h.authService.GetSession(claims, refreshToken))
+}
+
 // Helper methods
 
 func (h *AuthHandler) setAuthCookies(c *gin.Context, accessToken, refreshToken string) {
+	secure := h.secureCookies(c)
+
 	// Set access token cookie (shorter expiry)
 	c.SetCookie(
 		"access_token",
@@ -311,8 +537,8 @@ func (h *AuthHandler) setAuthCookies(c *gin.Context, accessToken, refreshToken s
 		int(h.config.JWTAccessTokenDurationParsed().Seconds()),
 		"/",
 		"",
-		!h.config.IsDevelopment(), // secure in production
-		true,                      // httpOnly
+		secure,
+		true, // httpOnly
 	)
 
 	// Set refresh token cookie (longer expiry)
@@ -322,56 +548,219 @@ func (h *AuthHandler) setAuthCookies(c *gin.Context, accessToken, refreshToken s
 		int(h.config.JWTRefreshTokenDurationParsed().Seconds()),
 		"/",
 		"",
-		!h.config.IsDevelopment(), // secure in production
-		true,                      // httpOnly
+		secure,
+		true, // httpOnly
 	)
 }
 
+// clearAuthCookies ends the browser session, including its CSRF token, which is always cleared so
+// one left from before CSRF_PROTECTION was switched off can't carry over either
 func (h *AuthHandler) clearAuthCookies(c *gin.Context) {
-	c.SetCookie("access_token", "", -1, "/", "", !h.config.IsDevelopment(), true)
-	c.SetCookie("refresh_token", "", -1, "/", "", !h.config.IsDevelopment(), true)
+	secure := h.secureCookies(c)
+	c.SetCookie("access_token", "", -1, "/", "", secure, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", secure, true)
+	middleware.ClearCSRFToken(c, secure)
+}
+
+// rotateCSRFToken issues a fresh CSRF token for a new session when CSRF_PROTECTION is on, replacing
+// any earlier one. The cookie is HTTP-only, so the token is also sent in the X-CSRF-Token header.
+func (h *AuthHandler) rotateCSRFToken(c *gin.Context) {
+	if !h.config.IsFeatureEnabled("csrf_protection") {
+		return
+	}
+
+	c.Header(middleware.CSRFHeader, middleware.GenerateCSRFToken(c, h.secureCookies(c)))
+}
+
+// secureCookies decides the Secure flag for this request. X-Forwarded-Proto is trusted as-is: it can
+// only add the flag, and a browser that spoofs it just stops sending the cookie over plain HTTP.
+func (h *AuthHandler) secureCookies(c *gin.Context) bool {
+	requestHTTPS := c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+	return h.config.SecureCookies(requestHTTPS)
 }
 
 func (h *AuthHandler) handleValidationError(c *gin.Context, err error) {
 	h.logger.Warn("validation error", "error", err)
-	c.JSON(http.StatusBadRequest, domain.ErrorResponse{
-		Error: "validation failed",
-		Details: map[string]string{
-			"message": err.Error(),
-		},
+	c.JSON(http.StatusBadRequest, middleware.CodedError(c, apperrors.CodeValidationFailed, domain.ErrorResponse{
+		Error:   "validation failed",
+		Details: validationDetails(err),
+	}))
+}
+
+// validationDetails describes a binding error: the full message, plus one entry per invalid field
+// keyed by the field's JSON name
+func validationDetails(err error) map[string]string {
+	details := map[string]string{"message": err.Error()}
+
+	var fieldErrors validator.ValidationErrors
+	if errors.As(err, &fieldErrors) {
+		for _, fieldError := range fieldErrors {
+			details[jsonFieldName(fieldError.Field())] = fieldErrorMessage(fieldError)
+		}
+	}
+	return details
+}
+
+// fieldErrorMessage explains a failed validation tag in words
+func fieldErrorMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters", fieldError.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fieldError.Param())
+	case "eqfield":
+		return "must match " + jsonFieldName(fieldError.Param())
+	default:
+		return "failed " + fieldError.Tag() + " validation"
+	}
+}
+
+// jsonFieldName converts a request struct field name to the snake_case name it has in JSON
+func jsonFieldName(field string) string {
+	var name strings.Builder
+	for i, r := range field {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				name.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		name.WriteRune(r)
+	}
+	return name.String()
+}
+
+// respondEmailDisabled reports that a flow needing email can't run because email is turned off
+func (h *AuthHandler) respondEmailDisabled(c *gin.Context, flow string) {
+	c.JSON(http.StatusServiceUnavailable, domain.ErrorResponse{
+		Error:   flow + " is unavailable because email delivery is disabled",
+		Details: map[string]string{"reason": "email_disabled"},
 	})
 }
 
 func (h *AuthHandler) handleAuthError(c *gin.Context, err error) {
+	// Username rejections name the rule that failed, so they are reported on the field as they are
+	if domain.IsUsernameError(err) {
+		status := http.StatusBadRequest
+		if err == domain.ErrUsernameTaken {
+			status = http.StatusConflict
+		}
+		c.JSON(status, middleware.CodedError(c, apperrors.CodeValidationFailed, domain.ErrorResponse{
+			Error:   "validation failed",
+			Details: map[string]string{"username": err.Error()},
+		}))
+		return
+	}
+
 	switch err {
 	case domain.ErrInvalidCredentials:
-		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: "invalid credentials"})
+		c.JSON(http.StatusUnauthorized, middleware.CodedError(c, apperrors.CodeInvalidCredentials, domain.ErrorResponse{
+			Error: "invalid credentials",
+		}))
 	case domain.ErrUserAlreadyExists:
-		c.JSON(http.StatusConflict, domain.ErrorResponse{Error: "user already exists"})
+		c.JSON(http.StatusConflict, middleware.CodedError(c, apperrors.CodeUserAlreadyExists, domain.ErrorResponse{
+			Error: "user already exists",
+		}))
+	case domain.ErrBackupEmailIsPrimary:
+		c.JSON(http.StatusBadRequest, middleware.CodedError(c, apperrors.CodeValidationFailed, domain.ErrorResponse{
+			Error:   "validation failed",
+			Details: map[string]string{"backup_email": err.Error()},
+		}))
+	case domain.ErrEmailDomainNotAllowed, domain.ErrEmailDomainBlocked, domain.ErrDisposableEmail:
+		c.JSON(http.StatusBadRequest, middleware.CodedError(c, apperrors.CodeValidationFailed, domain.ErrorResponse{
+			Error:   "validation failed",
+			Details: map[string]string{"email": err.Error()},
+		}))
 	case domain.ErrEmailNotVerified:
-		c.JSON(http.StatusForbidden, domain.ErrorResponse{Error: "email not verified"})
+		c.JSON(http.StatusForbidden, middleware.CodedError(c, apperrors.CodeEmailNotVerified, domain.ErrorResponse{
+			Error: "email not verified",
+			Details: map[string]string{
+				"reason": "email_not_verified",
+				"hint":   "check your inbox for the verification link; signing in again resends it once the reminder interval has passed",
+			},
+		}))
 	case domain.ErrUserInactive:
-		c.JSON(http.StatusForbidden, domain.ErrorResponse{Error: "user account is inactive"})
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{
+			Error:   "account is deactivated, reactivate it to sign in",
+			Details: map[string]string{"reason": "account_inactive"},
+		})
+	case domain.ErrUserSuspended:
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{
+			Error:   "account is suspended, please contact support",
+			Details: map[string]string{"reason": "account_suspended"},
+		})
+	case domain.ErrUserPendingApproval:
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{
+			Error:   "account is waiting for an administrator to approve it",
+			Details: map[string]string{"reason": "account_pending_approval"},
+		})
+	case domain.ErrCaptchaRequired:
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
+			Error:   "captcha required, solve the challenge and sign in again with its token",
+			Details: map[string]string{"reason": "captcha_required"},
+		})
+	case domain.ErrCaptchaInvalid:
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
+			Error:   "captcha was not accepted, please try again",
+			Details: map[string]string{"reason": "captcha_invalid"},
+		})
+	case domain.ErrCaptchaUnavailable:
+		c.JSON(http.StatusServiceUnavailable, domain.ErrorResponse{Error: "captcha verification is unavailable, please try again later"})
 	case domain.ErrInvalidToken, domain.ErrTokenNotFound:
-		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: "invalid token"})
+		c.JSON(http.StatusUnauthorized, middleware.CodedError(c, apperrors.CodeTokenInvalid, domain.ErrorResponse{Error: "invalid token"}))
 	case domain.ErrTokenExpired:
-		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: "token expired"})
+		c.JSON(http.StatusUnauthorized, middleware.CodedError(c, apperrors.CodeTokenExpired, domain.ErrorResponse{Error: "token expired"}))
+	case domain.ErrSessionMaxAgeExceeded:
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
+			Error:   "session has expired, please sign in again",
+			Details: map[string]string{"reason": "session_expired"},
+		})
 	case domain.ErrTokenAlreadyUsed:
 		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: "token already used"})
 	case domain.ErrPasswordsDoNotMatch:
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: "passwords do not match"})
+		// Same shape as the binding error for a mismatched confirmation
+		c.JSON(http.StatusBadRequest, middleware.CodedError(c, apperrors.CodeValidationFailed, domain.ErrorResponse{
+			Error:   "validation failed",
+			Details: map[string]string{"message": err.Error(), "confirm_password": "must match the new password"},
+		}))
 	case domain.ErrWeakPassword:
 		c.JSON(http.StatusBadRequest, domain.ErrorResponse{Error: "password is too weak"})
+	case domain.ErrNoPasswordToReset:
+		c.JSON(http.StatusConflict, domain.ErrorResponse{
+			Error:   "this account signs in without a password, so there is no password to reset",
+			Details: map[string]string{"reason": "no_password"},
+		})
+	case domain.ErrPasswordConvertRequired:
+		c.JSON(http.StatusConflict, domain.ErrorResponse{
+			Error: "this account signs in without a password",
+			Details: map[string]string{
+				"reason": "convert_to_password_required",
+				"hint":   "reset again with convert_to_password set to true to add a password to this account",
+			},
+		})
 	case domain.ErrUnauthorized:
-		c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: "unauthorized"})
+		c.JSON(http.StatusUnauthorized, middleware.CodedError(c, apperrors.CodeUnauthorized, domain.ErrorResponse{Error: "unauthorized"}))
 	case domain.ErrForbidden:
-		c.JSON(http.StatusForbidden, domain.ErrorResponse{Error: "forbidden"})
+		c.JSON(http.StatusForbidden, middleware.CodedError(c, apperrors.CodeForbidden, domain.ErrorResponse{Error: "forbidden"}))
+	case domain.ErrStepUpRequired:
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{
+			Error:   "step-up authentication required",
+			Details: map[string]string{"reason": "step_up_required"},
+		})
 	default:
 		if strings.Contains(err.Error(), "too many") {
-			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse{Error: err.Error()})
+			c.JSON(http.StatusTooManyRequests, middleware.CodedError(c, apperrors.CodeRateLimitExceeded, domain.ErrorResponse{
+				Error: err.Error(),
+			}))
 		} else {
 			h.logger.Error("auth service error", "error", err)
-			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{Error: "internal server error"})
+			c.JSON(http.StatusInternalServerError, middleware.CodedError(c, apperrors.CodeInternalError, domain.ErrorResponse{
+				Error: "internal server error",
+			}))
 		}
 	}
 }
@@ -387,7 +776,10 @@ func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
 		auth.POST("/verify-email", h.VerifyEmail)
 		auth.POST("/forgot-password", h.ForgotPassword)
 		auth.POST("/reset-password", h.ResetPassword)
-		auth.GET("/check", h.CheckAuth) // This will require auth middleware
+		auth.POST("/confirm-backup-email", h.ConfirmBackupEmail)
+		auth.GET("/email-available", h.CheckEmailAvailable)
+		auth.GET("/check", h.CheckAuth)    // This will require auth middleware
+		auth.GET("/session", h.GetSession) // This will require auth middleware
 	}
 
 	// Protected routes (require authentication middleware)
@@ -395,6 +787,8 @@ func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
 	{
 		protected.POST("/logout-all", h.LogoutAll)
 		protected.POST("/change-password", h.ChangePassword)
+		protected.PUT("/backup-email", h.SetBackupEmail)
+		protected.POST("/step-up", h.StepUp)
 		protected.GET("/profile", h.GetProfile)
 		protected.POST("/resend-verification", h.ResendEmailVerification)
 	}
@@ -0,0 +1,302 @@
+package transport
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/middleware"
+	"github.com/acheevo/tfa/internal/shared/config"
+	apperrors "github.com/acheevo/tfa/internal/shared/errors"
+)
+
+func TestHandleAuthErrorAccountStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewAuthHandler(&config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+
+	tests := []struct {
+		err    error
+		code   int
+		reason string
+	}{
+		{domain.ErrUserInactive, http.StatusForbidden, "account_inactive"},
+		{domain.ErrUserSuspended, http.StatusForbidden, "account_suspended"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/login", nil)
+			h.handleAuthError(c, tt.err)
+
+			assert.Equal(t, tt.code, w.Code)
+
+			var response domain.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.reason, response.Details["reason"])
+			assert.NotEmpty(t, response.Error)
+		})
+	}
+}
+
+func TestHandleAuthErrorIsLocalized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewAuthHandler(&config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+
+	respond := func(preferred, acceptLanguage string) (*httptest.ResponseRecorder, domain.ErrorResponse) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/login", nil)
+		c.Request.Header.Set("Accept-Language", acceptLanguage)
+		if preferred != "" {
+			c.Set(apperrors.LanguageContextKey, preferred)
+		}
+		h.handleAuthError(c, domain.ErrInvalidCredentials)
+
+		var response domain.ErrorResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	// The error stays in English for existing clients; the code and localized message come alongside
+	w, response := respond("", "es-ES,es;q=0.9")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "invalid credentials", response.Error)
+	assert.Equal(t, string(apperrors.CodeInvalidCredentials), response.Code)
+	assert.Equal(t, "Correo electrónico o contraseña incorrectos", response.Message)
+	assert.Equal(t, "es", w.Header().Get("Content-Language"))
+
+	// The user's saved preference wins over the browser header
+	w, response = respond("fr", "es")
+	assert.Equal(t, "Adresse e-mail ou mot de passe incorrect", response.Message)
+	assert.Equal(t, "fr", w.Header().Get("Content-Language"))
+
+	w, response = respond("", "ja")
+	assert.Equal(t, "Invalid credentials", response.Message)
+	assert.Equal(t, "en", w.Header().Get("Content-Language"))
+}
+
+func TestPasswordConfirmationMismatchIsFieldError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Binding fails before the service is reached, so no service is needed
+	h := NewAuthHandler(&config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+
+	router := gin.New()
+	router.POST("/reset-password", h.ResetPassword)
+	router.POST("/change-password", func(c *gin.Context) { c.Set("user_id", uint(1)) }, h.ChangePassword)
+
+	tests := []struct {
+		path    string
+		body    string
+		message string
+	}{
+		{
+			path:    "/reset-password",
+			body:    `{"token":"t","password":"NewPassword123","confirm_password":"OtherPassword123"}`,
+			message: "must match password",
+		},
+		{
+			path:    "/change-password",
+			body:    `{"current_password":"password","new_password":"NewPassword123","confirm_password":"OtherPassword123"}`,
+			message: "must match new_password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response domain.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "validation failed", response.Error)
+			assert.Equal(t, tt.message, response.Details["confirm_password"])
+			assert.NotEmpty(t, response.Details["message"])
+		})
+	}
+}
+
+func TestValidationDetailsNamesFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewAuthHandler(&config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+
+	router := gin.New()
+	router.POST("/reset-password", h.ResetPassword)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reset-password", strings.NewReader(`{"password":"short"}`)))
+
+	var response domain.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "is required", response.Details["token"])
+	assert.Equal(t, "must be at least 8 characters", response.Details["password"])
+	assert.Equal(t, "is required", response.Details["confirm_password"])
+}
+
+func TestCheckEmailAvailableRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name    string
+		enabled bool
+		query   string
+		code    int
+	}{
+		{"disabled", false, "?email=new@example.com", http.StatusNotFound},
+		{"missing email", true, "", http.StatusBadRequest},
+		{"invalid email", true, "?email=not-an-email", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Requests are refused before the service is reached, so no service is needed
+			h := NewAuthHandler(&config.Config{EmailAvailabilityCheck: tt.enabled}, logger, nil)
+			router := gin.New()
+			router.GET("/email-available", h.CheckEmailAvailable)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/email-available"+tt.query, nil))
+
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
+
+func TestAuthCookiesSecureFlag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		cookieSecure string
+		proto        string
+		want         bool
+	}{
+		{"plain http", "", "", false},
+		{"behind https proxy", "", "https", true},
+		{"proxy header is case-insensitive", "", "HTTPS", true},
+		{"forced on", "true", "", true},
+		{"forced off behind https proxy", "false", "https", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Environment: "development", CookieSecure: tt.cookieSecure}
+			h := NewAuthHandler(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/login", nil)
+			if tt.proto != "" {
+				c.Request.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			h.setAuthCookies(c, "access", "refresh")
+
+			cookies := w.Result().Cookies()
+			assert.Len(t, cookies, 2)
+			for _, cookie := range cookies {
+				assert.Equal(t, tt.want, cookie.Secure, cookie.Name)
+				assert.True(t, cookie.HttpOnly, cookie.Name)
+			}
+		})
+	}
+}
+
+func TestLogoutClearsCSRFCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Without a refresh token cookie, logout only clears cookies, so no service is needed
+	h := NewAuthHandler(&config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+
+	router := gin.New()
+	router.POST("/logout", h.Logout)
+
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.CSRFCookieName, Value: "stale"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	cleared := map[string]bool{}
+	for _, cookie := range w.Result().Cookies() {
+		cleared[cookie.Name] = cookie.MaxAge < 0 && cookie.Value == ""
+	}
+	assert.Equal(t, map[string]bool{"access_token": true, "refresh_token": true, middleware.CSRFCookieName: true}, cleared)
+}
+
+func TestRotateCSRFTokenOnNewSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	issue := func(cfg *config.Config) *httptest.ResponseRecorder {
+		h := NewAuthHandler(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/login", nil)
+		c.Request.AddCookie(&http.Cookie{Name: middleware.CSRFCookieName, Value: "stale"})
+		h.rotateCSRFToken(c)
+		return w
+	}
+
+	enabled := &config.Config{FeatureFlags: config.FeatureFlags{CSRFProtection: true}}
+	first := issue(enabled)
+	cookies := first.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.Equal(t, middleware.CSRFCookieName, cookies[0].Name)
+	assert.NotEqual(t, "stale", cookies[0].Value)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, cookies[0].Value, first.Header().Get(middleware.CSRFHeader))
+
+	// Each session gets its own token
+	second := issue(enabled)
+	assert.NotEqual(t, first.Header().Get(middleware.CSRFHeader), second.Header().Get(middleware.CSRFHeader))
+
+	// Nothing is issued while CSRF protection is off
+	disabled := issue(&config.Config{})
+	assert.Empty(t, disabled.Result().Cookies())
+	assert.Empty(t, disabled.Header().Get(middleware.CSRFHeader))
+}
+
+func TestAccountRecoveryRequestValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Binding fails before the service is reached, so no service is needed
+	h := NewAuthHandler(&config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+
+	router := gin.New()
+	router.POST("/recovery-request", h.RequestAccountRecovery)
+
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"unknown method", `{"email":"lost@example.com","method":"sms"}`, "method"},
+		{"admin approval needs a contact", `{"email":"lost@example.com","method":"admin_approval","reason":"lost inbox"}`, "contact_email"},
+		{"admin approval needs a reason", `{"email":"lost@example.com","method":"admin_approval","contact_email":"new@example.org"}`, "reason"},
+		{"contact must be an email", `{"email":"lost@example.com","method":"admin_approval","contact_email":"nope","reason":"x"}`, "contact_email"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/recovery-request", strings.NewReader(tt.body)))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response domain.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Contains(t, response.Details, tt.field)
+		})
+	}
+}
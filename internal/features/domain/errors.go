@@ -0,0 +1,9 @@
+package domain
+
+import "errors"
+
+var (
+	ErrUnknownFlag           = errors.New("unknown feature flag")
+	ErrInvalidOverrideTarget = errors.New("override must target exactly one of user or role")
+	ErrOverrideNotFound      = errors.New("feature override not found")
+)
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"time"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// Flag identifies a feature flag
+type Flag string
+
+const (
+	FlagEmailVerification Flag = "email_verification"
+	FlagTwoFactorAuth     Flag = "two_factor_auth"
+	FlagAdminAPI          Flag = "admin_api"
+	FlagMetrics           Flag = "metrics"
+	FlagFileUploads       Flag = "file_uploads"
+	FlagSocialLogin       Flag = "social_login"
+	FlagEmailTemplates    Flag = "email_templates"
+	FlagRateLimiting      Flag = "rate_limiting"
+	FlagCSRFProtection    Flag = "csrf_protection"
+	FlagSecurityHeaders   Flag = "security_headers"
+)
+
+// KnownFlags lists all flags that can be evaluated or overridden
+var KnownFlags = []Flag{
+	FlagEmailVerification,
+	FlagTwoFactorAuth,
+	FlagAdminAPI,
+	FlagMetrics,
+	FlagFileUploads,
+	FlagSocialLogin,
+	FlagEmailTemplates,
+	FlagRateLimiting,
+	FlagCSRFProtection,
+	FlagSecurityHeaders,
+}
+
+// IsKnownFlag checks if a flag is one of the known feature flags
+func IsKnownFlag(flag Flag) bool {
+	for _, known := range KnownFlags {
+		if known == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// EvaluationSource describes which layer decided a flag's value
+type EvaluationSource string
+
+const (
+	SourceGlobal EvaluationSource = "global"
+	SourceRole   EvaluationSource = "role"
+	SourceUser   EvaluationSource = "user"
+)
+
+// FeatureOverride overrides a global feature flag for a single user or a whole role. A flag has at
+// most one override per user and one per role, enforced by partial unique indexes.
+type FeatureOverride struct {
+	ID        uint                 `json:"id" gorm:"primarykey"`
+	Flag      Flag                 `json:"flag" gorm:"not null;index;uniqueIndex:idx_feature_override_user,where:user_id IS NOT NULL;uniqueIndex:idx_feature_override_role,where:user_id IS NULL"`
+	UserID    *uint                `json:"user_id,omitempty" gorm:"index;uniqueIndex:idx_feature_override_user,where:user_id IS NOT NULL"`
+	Role      *authdomain.UserRole `json:"role,omitempty" gorm:"index;uniqueIndex:idx_feature_override_role,where:user_id IS NULL"`
+	Enabled   bool                 `json:"enabled" gorm:"not null"`
+	Reason    string               `json:"reason"`
+	CreatedBy uint                 `json:"created_by"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// FeatureEvaluation represents the evaluated value of a flag for a user
+type FeatureEvaluation struct {
+	Flag    Flag             `json:"flag"`
+	Enabled bool             `json:"enabled"`
+	Source  EvaluationSource `json:"source"`
+}
+
+// UserFeaturesResponse lists every known flag as evaluated for the signed-in user
+type UserFeaturesResponse struct {
+	Features []*FeatureEvaluation `json:"features"`
+}
+
+// SetOverrideRequest represents a request to create or update a feature override
+type SetOverrideRequest struct {
+	Flag    Flag                 `json:"flag" binding:"required"`
+	UserID  *uint                `json:"user_id"`
+	Role    *authdomain.UserRole `json:"role" binding:"omitempty,oneof=user admin super_admin"`
+	Enabled *bool                `json:"enabled" binding:"required"`
+	Reason  string               `json:"reason" binding:"required,min=1,max=255"`
+}
+
+// ListOverridesRequest represents a request to list feature overrides
+type ListOverridesRequest struct {
+	Flag   Flag  `form:"flag"`
+	UserID *uint `form:"user_id"`
+}
+
+// ListOverridesResponse represents a list of feature overrides
+type ListOverridesResponse struct {
+	Overrides []*FeatureOverride `json:"overrides"`
+}
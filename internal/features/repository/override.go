@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/features/domain"
+)
+
+// OverrideRepository handles database operations for feature overrides
+type OverrideRepository struct {
+	db *gorm.DB
+}
+
+// NewOverrideRepository creates a new feature override repository
+func NewOverrideRepository(db *gorm.DB) *OverrideRepository {
+	return &OverrideRepository{
+		db: db,
+	}
+}
+
+// Upsert creates an override or updates the existing one for the same flag and target. The insert
+// and update are one statement against the unique index, so concurrent writes can't duplicate it.
+func (r *OverrideRepository) Upsert(override *domain.FeatureOverride) error {
+	target := []clause.Column{{Name: "flag"}, {Name: "user_id"}}
+	targetWhere := "user_id IS NOT NULL"
+	if override.UserID == nil {
+		target = []clause.Column{{Name: "flag"}, {Name: "role"}}
+		targetWhere = "user_id IS NULL"
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:     target,
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: targetWhere}}},
+		DoUpdates:   clause.AssignmentColumns([]string{"enabled", "reason", "created_by", "updated_at"}),
+	}).Create(override).Error
+	if err != nil {
+		return err
+	}
+
+	// Reload so an updated override reports its original creation time
+	return r.db.First(override, override.ID).Error
+}
+
+// GetByID gets a feature override by ID
+func (r *OverrideRepository) GetByID(id uint) (*domain.FeatureOverride, error) {
+	var override domain.FeatureOverride
+	err := r.db.First(&override, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrOverrideNotFound
+		}
+		return nil, err
+	}
+	return &override, nil
+}
+
+// Delete deletes a feature override by ID
+func (r *OverrideRepository) Delete(id uint) error {
+	return r.db.Delete(&domain.FeatureOverride{}, id).Error
+}
+
+// List lists feature overrides, optionally filtered by flag and user
+func (r *OverrideRepository) List(req *domain.ListOverridesRequest) ([]*domain.FeatureOverride, error) {
+	var overrides []*domain.FeatureOverride
+	query := r.db.Model(&domain.FeatureOverride{})
+
+	if req.Flag != "" {
+		query = query.Where("flag = ?", req.Flag)
+	}
+	if req.UserID != nil {
+		query = query.Where("user_id = ?", *req.UserID)
+	}
+
+	err := query.Order("flag ASC, id ASC").Find(&overrides).Error
+	return overrides, err
+}
+
+// GetForSubject gets the user and role overrides of a flag that apply to a user
+func (r *OverrideRepository) GetForSubject(
+	flag domain.Flag,
+	userID uint,
+	role authdomain.UserRole,
+) ([]*domain.FeatureOverride, error) {
+	var overrides []*domain.FeatureOverride
+	err := r.db.Where("flag = ? AND (user_id = ? OR (user_id IS NULL AND role = ?))", flag, userID, role).
+		Find(&overrides).Error
+	return overrides, err
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/features/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+// FeatureService evaluates feature flags, layering per-role and per-user overrides over global defaults
+type FeatureService struct {
+	config       *config.Config
+	logger       *slog.Logger
+	overrideRepo OverrideRepo
+	userRepo     UserRepo
+	auditRepo    AuditRepo
+	cache        map[string]*cachedEvaluation
+	cacheTTL     time.Duration
+	nextPrune    time.Time
+	mu           sync.RWMutex
+}
+
+type cachedEvaluation struct {
+	evaluation *domain.FeatureEvaluation
+	expiresAt  time.Time
+}
+
+// NewFeatureService creates a new feature service
+func NewFeatureService(
+	config *config.Config,
+	logger *slog.Logger,
+	overrideRepo OverrideRepo,
+	userRepo UserRepo,
+	auditRepo AuditRepo,
+) *FeatureService {
+	return &FeatureService{
+		config:       config,
+		logger:       logger,
+		overrideRepo: overrideRepo,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		cache:        make(map[string]*cachedEvaluation),
+		cacheTTL:     config.FeatureCacheTTLDuration(),
+	}
+}
+
+// IsEnabled checks a flag against the global configuration only
+func (s *FeatureService) IsEnabled(flag domain.Flag) bool {
+	return s.config.IsFeatureEnabled(string(flag))
+}
+
+// IsEnabledFor checks whether a flag is enabled for a specific user
+func (s *FeatureService) IsEnabledFor(user *authdomain.User, flag domain.Flag) bool {
+	return s.Evaluate(user, flag).Enabled
+}
+
+// Evaluate evaluates a flag for a user. User overrides win over role overrides,
+// which win over the global default.
+func (s *FeatureService) Evaluate(user *authdomain.User, flag domain.Flag) *domain.FeatureEvaluation {
+	global := &domain.FeatureEvaluation{
+		Flag:    flag,
+		Enabled: s.IsEnabled(flag),
+		Source:  domain.SourceGlobal,
+	}
+
+	if user == nil || !domain.IsKnownFlag(flag) {
+		return global
+	}
+
+	key := fmt.Sprintf("%s:%d:%s", flag, user.ID, user.Role)
+	if evaluation, ok := s.getCached(key); ok {
+		return evaluation
+	}
+
+	overrides, err := s.overrideRepo.GetForSubject(flag, user.ID, user.Role)
+	if err != nil {
+		// Fall back to the global default rather than failing the request
+		s.logger.Error("failed to load feature overrides", "flag", flag, "user_id", user.ID, "error", err)
+		return global
+	}
+
+	evaluation := global
+	for _, override := range overrides {
+		if override.UserID != nil {
+			evaluation = &domain.FeatureEvaluation{Flag: flag, Enabled: override.Enabled, Source: domain.SourceUser}
+			break
+		}
+		if override.Role != nil {
+			evaluation = &domain.FeatureEvaluation{Flag: flag, Enabled: override.Enabled, Source: domain.SourceRole}
+		}
+	}
+
+	s.setCached(key, evaluation)
+	return evaluation
+}
+
+// EvaluateAll evaluates every known flag for a user
+func (s *FeatureService) EvaluateAll(user *authdomain.User) []*domain.FeatureEvaluation {
+	evaluations := make([]*domain.FeatureEvaluation, 0, len(domain.KnownFlags))
+	for _, flag := range domain.KnownFlags {
+		evaluations = append(evaluations, s.Evaluate(user, flag))
+	}
+	return evaluations
+}
+
+// EvaluateAllForUser evaluates every known flag for the user with the given ID, so a client can
+// show or hide features the way the server decides them
+func (s *FeatureService) EvaluateAllForUser(userID uint) (*domain.UserFeaturesResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UserFeaturesResponse{Features: s.EvaluateAll(user)}, nil
+}
+
+// ListOverrides lists feature overrides
+func (s *FeatureService) ListOverrides(req *domain.ListOverridesRequest) (*domain.ListOverridesResponse, error) {
+	overrides, err := s.overrideRepo.List(req)
+	if err != nil {
+		s.logger.Error("failed to list feature overrides", "error", err)
+		return nil, err
+	}
+
+	return &domain.ListOverridesResponse{Overrides: overrides}, nil
+}
+
+// SetOverride creates or updates a per-user or per-role feature override
+func (s *FeatureService) SetOverride(
+	adminID uint,
+	req *domain.SetOverrideRequest,
+	ipAddress, userAgent string,
+) (*domain.FeatureOverride, error) {
+	if !domain.IsKnownFlag(req.Flag) {
+		return nil, domain.ErrUnknownFlag
+	}
+
+	// Exactly one target must be given
+	if (req.UserID == nil) == (req.Role == nil) {
+		return nil, domain.ErrInvalidOverrideTarget
+	}
+
+	override := &domain.FeatureOverride{
+		Flag:      req.Flag,
+		UserID:    req.UserID,
+		Role:      req.Role,
+		Enabled:   *req.Enabled,
+		Reason:    req.Reason,
+		CreatedBy: adminID,
+	}
+
+	if err := s.overrideRepo.Upsert(override); err != nil {
+		s.logger.Error("failed to save feature override", "admin_id", adminID, "flag", req.Flag, "error", err)
+		return nil, err
+	}
+
+	s.invalidateCache()
+
+	// Create audit log
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		req.UserID,
+		authdomain.AuditActionFeatureOverrideSet,
+		authdomain.AuditLevelInfo,
+		"admin",
+		fmt.Sprintf("Feature %s set to %t for %s: %s", req.Flag, *req.Enabled, describeTarget(override), req.Reason),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"override_id": override.ID,
+			"flag":        req.Flag,
+			"enabled":     *req.Enabled,
+			"target":      describeTarget(override),
+			"reason":      req.Reason,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for feature override", "admin_id", adminID, "error", err)
+	}
+
+	return override, nil
+}
+
+// DeleteOverride removes a feature override, restoring the next layer's value
+func (s *FeatureService) DeleteOverride(adminID, overrideID uint, ipAddress, userAgent string) error {
+	override, err := s.overrideRepo.GetByID(overrideID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.overrideRepo.Delete(overrideID); err != nil {
+		s.logger.Error("failed to delete feature override", "admin_id", adminID, "override_id", overrideID, "error", err)
+		return err
+	}
+
+	s.invalidateCache()
+
+	// Create audit log
+	if err := s.auditRepo.CreateAuditEntry(
+		&adminID,
+		override.UserID,
+		authdomain.AuditActionFeatureOverrideDel,
+		authdomain.AuditLevelInfo,
+		"admin",
+		fmt.Sprintf("Feature override for %s removed for %s", override.Flag, describeTarget(override)),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"override_id": override.ID,
+			"flag":        override.Flag,
+			"target":      describeTarget(override),
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for feature override removal", "admin_id", adminID, "error", err)
+	}
+
+	return nil
+}
+
+// getCached returns a cached evaluation if it has not expired
+func (s *FeatureService) getCached(key string) (*domain.FeatureEvaluation, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, exists := s.cache[key]
+	if !exists || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.evaluation, true
+}
+
+// setCached stores an evaluation in the cache. Expired entries are swept at most once per TTL, so the
+// cache holds only the users evaluated recently rather than every user since the last invalidation.
+func (s *FeatureService) setCached(key string, evaluation *domain.FeatureEvaluation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if !now.Before(s.nextPrune) {
+		for cachedKey, entry := range s.cache {
+			if now.After(entry.expiresAt) {
+				delete(s.cache, cachedKey)
+			}
+		}
+		s.nextPrune = now.Add(s.cacheTTL)
+	}
+
+	s.cache[key] = &cachedEvaluation{
+		evaluation: evaluation,
+		expiresAt:  now.Add(s.cacheTTL),
+	}
+}
+
+// invalidateCache drops all cached evaluations
+func (s *FeatureService) invalidateCache() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cache = make(map[string]*cachedEvaluation)
+}
+
+// describeTarget returns a human readable description of an override target
+func describeTarget(override *domain.FeatureOverride) string {
+	if override.UserID != nil {
+		return fmt.Sprintf("user %d", *override.UserID)
+	}
+	return fmt.Sprintf("role %s", *override.Role)
+}
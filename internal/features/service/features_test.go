@@ -0,0 +1,72 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/features/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+func newTestFeatureService(cfg *config.Config, users ...*authdomain.User) (*FeatureService, *fakeOverrideRepo) {
+	overrides := &fakeOverrideRepo{}
+	userRepo := &fakeUserRepo{users: map[uint]*authdomain.User{}}
+	for _, user := range users {
+		userRepo.users[user.ID] = user
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewFeatureService(cfg, logger, overrides, userRepo, &fakeAuditRepo{}), overrides
+}
+
+func TestEvaluateAllForUser(t *testing.T) {
+	userID := uint(7)
+	role := authdomain.RoleAdmin
+	user := &authdomain.User{ID: userID, Role: authdomain.RoleUser}
+	s, overrides := newTestFeatureService(&config.Config{FeatureCacheTTL: "1m"}, user)
+	overrides.overrides = []*domain.FeatureOverride{
+		{ID: 1, Flag: domain.FlagFileUploads, UserID: &userID, Enabled: true},
+		{ID: 2, Flag: domain.FlagSocialLogin, Role: &role, Enabled: true},
+	}
+
+	response, err := s.EvaluateAllForUser(userID)
+	if !assert.NoError(t, err) || !assert.Len(t, response.Features, len(domain.KnownFlags)) {
+		return
+	}
+
+	byFlag := map[domain.Flag]*domain.FeatureEvaluation{}
+	for _, evaluation := range response.Features {
+		byFlag[evaluation.Flag] = evaluation
+	}
+	assert.Equal(t, &domain.FeatureEvaluation{Flag: domain.FlagFileUploads, Enabled: true, Source: domain.SourceUser},
+		byFlag[domain.FlagFileUploads])
+	// Another role's override doesn't apply
+	assert.Equal(t, domain.SourceGlobal, byFlag[domain.FlagSocialLogin].Source)
+
+	_, err = s.EvaluateAllForUser(99)
+	assert.ErrorIs(t, err, userdomain.ErrUserNotFound)
+}
+
+func TestEvaluationCache(t *testing.T) {
+	t.Run("repeat evaluations are cached", func(t *testing.T) {
+		s, overrides := newTestFeatureService(&config.Config{FeatureCacheTTL: "1m"})
+		user := &authdomain.User{ID: 1, Role: authdomain.RoleUser}
+
+		s.IsEnabledFor(user, domain.FlagFileUploads)
+		s.IsEnabledFor(user, domain.FlagFileUploads)
+		assert.Equal(t, 1, overrides.lookups)
+	})
+
+	t.Run("expired entries are pruned", func(t *testing.T) {
+		s, _ := newTestFeatureService(&config.Config{FeatureCacheTTL: "1ns"})
+
+		for id := uint(1); id <= 100; id++ {
+			s.IsEnabledFor(&authdomain.User{ID: id, Role: authdomain.RoleUser}, domain.FlagFileUploads)
+		}
+		assert.LessOrEqual(t, len(s.cache), 2)
+	})
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/features/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// fakeOverrideRepo keeps overrides in memory and counts subject lookups, so tests can see the cache
+type fakeOverrideRepo struct {
+	overrides []*domain.FeatureOverride
+	lookups   int
+}
+
+func (r *fakeOverrideRepo) Upsert(override *domain.FeatureOverride) error {
+	r.overrides = append(r.overrides, override)
+	return nil
+}
+
+func (r *fakeOverrideRepo) GetByID(id uint) (*domain.FeatureOverride, error) {
+	for _, override := range r.overrides {
+		if override.ID == id {
+			return override, nil
+		}
+	}
+	return nil, domain.ErrOverrideNotFound
+}
+
+func (r *fakeOverrideRepo) Delete(id uint) error { return nil }
+
+func (r *fakeOverrideRepo) List(req *domain.ListOverridesRequest) ([]*domain.FeatureOverride, error) {
+	return r.overrides, nil
+}
+
+func (r *fakeOverrideRepo) GetForSubject(
+	flag domain.Flag,
+	userID uint,
+	role authdomain.UserRole,
+) ([]*domain.FeatureOverride, error) {
+	r.lookups++
+	var overrides []*domain.FeatureOverride
+	for _, override := range r.overrides {
+		if override.Flag != flag {
+			continue
+		}
+		if (override.UserID != nil && *override.UserID == userID) ||
+			(override.UserID == nil && override.Role != nil && *override.Role == role) {
+			overrides = append(overrides, override)
+		}
+	}
+	return overrides, nil
+}
+
+// fakeUserRepo keeps users in memory, keyed by ID
+type fakeUserRepo struct {
+	users map[uint]*authdomain.User
+}
+
+func (r *fakeUserRepo) GetByID(id uint) (*authdomain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, userdomain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// fakeAuditRepo discards audit entries
+type fakeAuditRepo struct{}
+
+func (r *fakeAuditRepo) CreateAuditEntry(
+	userID *uint,
+	targetID *uint,
+	action authdomain.AuditAction,
+	level authdomain.AuditLevel,
+	resource string,
+	description string,
+	ipAddress string,
+	userAgent string,
+	metadata map[string]interface{},
+) error {
+	return nil
+}
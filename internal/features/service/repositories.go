@@ -0,0 +1,44 @@
+package service
+
+import (
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/features/domain"
+	"github.com/acheevo/tfa/internal/features/repository"
+	userrepo "github.com/acheevo/tfa/internal/user/repository"
+)
+
+// OverrideRepo stores per-role and per-user feature flag overrides
+type OverrideRepo interface {
+	Upsert(override *domain.FeatureOverride) error
+	GetByID(id uint) (*domain.FeatureOverride, error)
+	Delete(id uint) error
+	List(req *domain.ListOverridesRequest) ([]*domain.FeatureOverride, error)
+	GetForSubject(flag domain.Flag, userID uint, role authdomain.UserRole) ([]*domain.FeatureOverride, error)
+}
+
+// UserRepo looks up the users flags are evaluated for
+type UserRepo interface {
+	GetByID(id uint) (*authdomain.User, error)
+}
+
+// AuditRepo records audit log entries
+type AuditRepo interface {
+	CreateAuditEntry(
+		userID *uint,
+		targetID *uint,
+		action authdomain.AuditAction,
+		level authdomain.AuditLevel,
+		resource string,
+		description string,
+		ipAddress string,
+		userAgent string,
+		metadata map[string]interface{},
+	) error
+}
+
+// The repositories used in production satisfy the interfaces
+var (
+	_ OverrideRepo = (*repository.OverrideRepository)(nil)
+	_ UserRepo     = (*userrepo.UserRepository)(nil)
+	_ AuditRepo    = (*userrepo.AuditRepository)(nil)
+)
@@ -0,0 +1,152 @@
+package transport
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/features/domain"
+	"github.com/acheevo/tfa/internal/features/service"
+	"github.com/acheevo/tfa/internal/shared/config"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
+)
+
+// FeatureHandler handles HTTP requests for feature flag overrides
+type FeatureHandler struct {
+	config         *config.Config
+	logger         *slog.Logger
+	featureService *service.FeatureService
+}
+
+// NewFeatureHandler creates a new feature handler
+func NewFeatureHandler(config *config.Config, logger *slog.Logger, featureService *service.FeatureService) *FeatureHandler {
+	return &FeatureHandler{
+		config:         config,
+		logger:         logger,
+		featureService: featureService,
+	}
+}
+
+// GetMyFeatures handles GET /api/user/features
+func (h *FeatureHandler) GetMyFeatures(c *gin.Context) {
+	userID := h.getUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	response, err := h.featureService.EvaluateAllForUser(userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListOverrides handles GET /api/admin/features/overrides
+func (h *FeatureHandler) ListOverrides(c *gin.Context) {
+	var req domain.ListOverridesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	response, err := h.featureService.ListOverrides(&req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetOverride handles PUT /api/admin/features/overrides
+func (h *FeatureHandler) SetOverride(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.SetOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	ipAddress := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	override, err := h.featureService.SetOverride(adminID, &req, ipAddress, userAgent)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+// DeleteOverride handles DELETE /api/admin/features/overrides/:id
+func (h *FeatureHandler) DeleteOverride(c *gin.Context) {
+	adminID := h.getUserID(c)
+	if adminID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	overrideID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid override ID"})
+		return
+	}
+
+	ipAddress := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	err = h.featureService.DeleteOverride(adminID, uint(overrideID), ipAddress, userAgent)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authdomain.MessageResponse{Message: "feature override deleted successfully"})
+}
+
+// Helper methods
+
+func (h *FeatureHandler) getUserID(c *gin.Context) uint {
+	if userID, exists := c.Get("user_id"); exists {
+		if id, ok := userID.(uint); ok {
+			return id
+		}
+	}
+	return 0
+}
+
+func (h *FeatureHandler) handleError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrUnknownFlag:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "unknown feature flag"})
+	case domain.ErrInvalidOverrideTarget:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "override must target exactly one of user_id or role"})
+	case domain.ErrOverrideNotFound:
+		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "feature override not found"})
+	case userdomain.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "user not found"})
+	default:
+		h.logger.Error("unhandled feature service error", "error", err)
+		c.JSON(http.StatusInternalServerError, authdomain.ErrorResponse{Error: "internal server error"})
+	}
+}
+
+func (h *FeatureHandler) handleValidationError(c *gin.Context, err error) {
+	h.logger.Error("validation error", "error", err)
+	c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+		Error:   "validation failed",
+		Details: map[string]string{"general": err.Error()},
+	})
+}
@@ -8,3 +8,10 @@ type HealthStatus struct {
 	Version   string                 `json:"version"`
 	Services  map[string]interface{} `json:"services"`
 }
+
+// PublicHealthStatus is what unauthenticated callers see of the instance's health: the overall
+// status only, with no detail about its dependencies
+type PublicHealthStatus struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
@@ -1,26 +1,46 @@
 package service
 
 import (
+	"context"
 	"log/slog"
 	"time"
 
 	"github.com/acheevo/tfa/internal/health/domain"
 	"github.com/acheevo/tfa/internal/shared/config"
-	"github.com/acheevo/tfa/internal/shared/database"
 	"github.com/acheevo/tfa/internal/shared/health"
+	"github.com/acheevo/tfa/internal/shared/httpclient"
 )
 
+// Database is the part of the database the health checks use
+type Database interface {
+	Ping() error
+	MigrationsComplete() bool
+}
+
 type HealthService struct {
-	config *config.Config
-	db     *database.DB
-	logger *slog.Logger
+	config  *config.Config
+	db      Database
+	logger  *slog.Logger
+	clients []*httpclient.Client
+	report  *health.EnhancedHealthService
 }
 
-func NewHealthService(config *config.Config, db *database.DB, logger *slog.Logger) *HealthService {
+// NewHealthService creates the health service. The outbound clients are optional; each shows up as
+// a dependency that is degraded while its circuit breaker is not closed. The report runs the
+// checkers behind the detailed health report, which includes their details.
+func NewHealthService(
+	config *config.Config,
+	db Database,
+	logger *slog.Logger,
+	clients []*httpclient.Client,
+	report *health.EnhancedHealthService,
+) *HealthService {
 	return &HealthService{
-		config: config,
-		db:     db,
-		logger: logger,
+		config:  config,
+		db:      db,
+		logger:  logger,
+		clients: clients,
+		report:  report,
 	}
 }
 
@@ -35,6 +55,18 @@ func (s *HealthService) GetHealth() *domain.HealthStatus {
 	services["database"] = map[string]string{"status": dbStatus}
 
 	overallStatus := string(health.StatusHealthy)
+
+	// A tripped breaker means the integration is failing fast, not that this instance is down
+	for _, client := range s.clients {
+		state := client.BreakerState()
+		clientStatus := string(health.StatusHealthy)
+		if state != httpclient.StateClosed {
+			clientStatus = string(health.StatusDegraded)
+			overallStatus = string(health.StatusDegraded)
+		}
+		services[client.Name()] = map[string]string{"status": clientStatus, "circuit": string(state)}
+	}
+
 	if dbStatus != string(health.StatusHealthy) {
 		overallStatus = string(health.StatusUnhealthy)
 	}
@@ -46,3 +78,24 @@ func (s *HealthService) GetHealth() *domain.HealthStatus {
 		Services:  services,
 	}
 }
+
+// GetDetailedHealth runs every registered checker and returns the full report, including details
+// such as connection counts and queue depths that are only shown to admins
+func (s *HealthService) GetDetailedHealth(ctx context.Context) *health.HealthReport {
+	return s.report.Check(ctx)
+}
+
+// GetReadiness reports whether the instance can serve traffic: the database must be reachable and
+// the schema fully migrated
+func (s *HealthService) GetReadiness() *domain.HealthStatus {
+	status := s.GetHealth()
+
+	migrationStatus := string(health.StatusHealthy)
+	if !s.db.MigrationsComplete() {
+		migrationStatus = string(health.StatusUnhealthy)
+		status.Status = string(health.StatusUnhealthy)
+	}
+	status.Services["migrations"] = map[string]string{"status": migrationStatus}
+
+	return status
+}
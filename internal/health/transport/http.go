@@ -3,27 +3,56 @@ package transport
 import (
 	"net/http"
 
+	"github.com/acheevo/tfa/internal/health/domain"
 	"github.com/acheevo/tfa/internal/health/service"
+	"github.com/acheevo/tfa/internal/shared/config"
 	"github.com/gin-gonic/gin"
 )
 
 type HealthHandler struct {
+	config  *config.Config
 	service *service.HealthService
 }
 
-func NewHealthHandler(service *service.HealthService) *HealthHandler {
+func NewHealthHandler(config *config.Config, service *service.HealthService) *HealthHandler {
 	return &HealthHandler{
+		config:  config,
 		service: service,
 	}
 }
 
+// GetHealth reports the instance's health, returning 503 only when it is unhealthy; a degraded
+// dependency still answers 200
 func (h *HealthHandler) GetHealth(c *gin.Context) {
-	health := h.service.GetHealth()
+	h.respond(c, h.service.GetHealth())
+}
+
+// GetReadiness answers readiness probes, returning 503 until migrations have completed
+func (h *HealthHandler) GetReadiness(c *gin.Context) {
+	h.respond(c, h.service.GetReadiness())
+}
 
+// GetDetailedHealth returns the full health report for admins, with every checker's details. It
+// answers 200 whatever the status, which is in the report.
+func (h *HealthHandler) GetDetailedHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.GetDetailedHealth(c.Request.Context()))
+}
+
+// respond writes a public health status. Probes are unauthenticated, so unless HEALTH_PUBLIC_DETAIL
+// is set only the overall status is shown and the per-dependency breakdown stays private.
+func (h *HealthHandler) respond(c *gin.Context, health *domain.HealthStatus) {
 	statusCode := http.StatusOK
-	if health.Status != "healthy" {
+	if health.Status == "unhealthy" {
 		statusCode = http.StatusServiceUnavailable
 	}
 
+	if !h.config.HealthPublicDetail {
+		c.JSON(statusCode, &domain.PublicHealthStatus{
+			Status:    health.Status,
+			Timestamp: health.Timestamp,
+		})
+		return
+	}
+
 	c.JSON(statusCode, health)
 }
@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/health/service"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/health"
+)
+
+// fakeDatabase answers pings with a fixed error
+type fakeDatabase struct {
+	pingErr error
+}
+
+func (d *fakeDatabase) Ping() error {
+	return d.pingErr
+}
+
+func (d *fakeDatabase) MigrationsComplete() bool {
+	return true
+}
+
+// detailChecker reports healthy with internals that must stay out of public responses
+type detailChecker struct{}
+
+func (detailChecker) Name() string {
+	return "database"
+}
+
+func (detailChecker) Check(ctx context.Context) *health.CheckResult {
+	return &health.CheckResult{
+		Name:      "database",
+		Status:    health.StatusHealthy,
+		Timestamp: time.Now(),
+		Details:   map[string]interface{}{"connections_open": 7},
+	}
+}
+
+func newTestHealthHandler(cfg *config.Config, db *fakeDatabase) *HealthHandler {
+	gin.SetMode(gin.TestMode)
+	cfg.HealthCheckTimeout = "1s"
+	cfg.HealthCheckOverallTimeout = "1s"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	report := health.NewEnhancedHealthService(cfg, logger)
+	report.RegisterChecker(detailChecker{})
+	return NewHealthHandler(cfg, service.NewHealthService(cfg, db, logger, nil, report))
+}
+
+func serveHealth(handler gin.HandlerFunc) (int, map[string]interface{}) {
+	router := gin.New()
+	router.GET("/health", handler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var body map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body
+}
+
+func TestPublicHealthOmitsDetails(t *testing.T) {
+	handler := newTestHealthHandler(&config.Config{}, &fakeDatabase{})
+
+	for name, h := range map[string]gin.HandlerFunc{"health": handler.GetHealth, "readiness": handler.GetReadiness} {
+		code, body := serveHealth(h)
+		assert.Equal(t, http.StatusOK, code, name)
+		assert.Equal(t, "healthy", body["status"], name)
+		assert.Contains(t, body, "timestamp", name)
+		assert.NotContains(t, body, "services", name)
+		assert.NotContains(t, body, "checks", name)
+	}
+
+	// An unhealthy instance still answers 503, without saying why
+	handler = newTestHealthHandler(&config.Config{}, &fakeDatabase{pingErr: errors.New("connection refused")})
+	code, body := serveHealth(handler.GetHealth)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]interface{}{"status": "unhealthy", "timestamp": body["timestamp"]}, body)
+}
+
+func TestPublicHealthDetailOptIn(t *testing.T) {
+	handler := newTestHealthHandler(&config.Config{HealthPublicDetail: true}, &fakeDatabase{})
+
+	code, body := serveHealth(handler.GetHealth)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{"database": map[string]interface{}{"status": "healthy"}}, body["services"])
+}
+
+func TestDetailedHealthIncludesDetails(t *testing.T) {
+	handler := newTestHealthHandler(&config.Config{}, &fakeDatabase{})
+
+	code, body := serveHealth(handler.GetDetailedHealth)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy", body["status"])
+
+	checks, ok := body["checks"].(map[string]interface{})
+	if !assert.True(t, ok) {
+		return
+	}
+	database, ok := checks["database"].(map[string]interface{})
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{"connections_open": float64(7)}, database["details"])
+}
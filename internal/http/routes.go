@@ -0,0 +1,367 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/middleware"
+)
+
+// routeAccess is the authentication a route requires before its permissions are checked
+type routeAccess int
+
+const (
+	// accessPublic routes need no token
+	accessPublic routeAccess = iota
+	// accessAuthenticated routes need a valid access token
+	accessAuthenticated
+	// accessActive routes also need the account to be active
+	accessActive
+	// accessUser routes also need a verified email when REQUIRE_VERIFIED_EMAIL_FOR_ROUTES is set
+	accessUser
+	// accessClient routes serve other services and need a client key from INTROSPECTION_CLIENT_KEYS
+	// instead of a user token
+	accessClient
+	// accessAdmin routes are audited and need an active admin, a verified email when required and,
+	// for super admin changes, a step-up token
+	accessAdmin
+)
+
+// routePolicy declares how one endpoint is protected
+type routePolicy struct {
+	Method      string
+	Path        string
+	Access      routeAccess
+	Permissions []authdomain.Permission
+	// StepUpOnAlert requires step-up authentication for changes while a security alert calls for it
+	StepUpOnAlert bool
+	// StepUp always requires step-up authentication, reads included, for routes that disclose enough
+	// to warrant re-entering the password
+	StepUp bool
+	// Head also answers HEAD requests on a GET route, with the GET response's headers and no body
+	Head bool
+}
+
+// routePolicies is the one place every API endpoint's protection is declared. Routes are registered
+// through Server.handle, which applies the declared middleware and refuses routes missing from here.
+var routePolicies = []routePolicy{
+	// Health and info
+	{Method: http.MethodGet, Path: "/api/health", Access: accessPublic, Head: true},
+	{Method: http.MethodGet, Path: "/api/ready", Access: accessPublic, Head: true},
+	{Method: http.MethodGet, Path: "/api/info", Access: accessPublic, Head: true},
+	{Method: http.MethodGet, Path: "/api/time", Access: accessPublic, Head: true},
+
+	// Authentication
+	{Method: http.MethodPost, Path: "/api/auth/login", Access: accessPublic},
+	{Method: http.MethodPost, Path: "/api/auth/register", Access: accessPublic},
+	{Method: http.MethodPost, Path: "/api/auth/refresh", Access: accessPublic},
+	{Method: http.MethodPost, Path: "/api/auth/logout", Access: accessPublic},
+	{Method: http.MethodPost, Path: "/api/auth/verify-email", Access: accessPublic},
+	{Method: http.MethodPost, Path: "/api/auth/forgot-password", Access: accessPublic},
+	{Method: http.MethodPost, Path: "/api/auth/reset-password", Access: accessPublic},
+	{Method: http.MethodPost, Path: "/api/auth/recovery-request", Access: accessPublic},
+	{Method: http.MethodPost, Path: "/api/auth/confirm-backup-email", Access: accessPublic},
+	{Method: http.MethodGet, Path: "/api/auth/email-available", Access: accessPublic},
+	{Method: http.MethodPost, Path: "/api/auth/introspect", Access: accessClient},
+	{Method: http.MethodPost, Path: "/api/auth/introspect/batch", Access: accessClient},
+	{Method: http.MethodGet, Path: "/api/auth/check", Access: accessAuthenticated},
+	{Method: http.MethodGet, Path: "/api/auth/session", Access: accessAuthenticated},
+	{Method: http.MethodPost, Path: "/api/auth/logout-all", Access: accessAuthenticated},
+	{Method: http.MethodPost, Path: "/api/auth/change-password", Access: accessAuthenticated},
+	{
+		Method: http.MethodPut, Path: "/api/auth/backup-email", Access: accessActive,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileUpdate},
+	},
+	{Method: http.MethodPost, Path: "/api/auth/step-up", Access: accessActive},
+	{Method: http.MethodGet, Path: "/api/auth/profile", Access: accessAuthenticated},
+	{
+		Method: http.MethodPatch, Path: "/api/auth/me", Access: accessActive,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileUpdate},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/auth/me/consents", Access: accessAuthenticated,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileRead},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/auth/me/consents", Access: accessActive,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileUpdate},
+	},
+	{Method: http.MethodPost, Path: "/api/auth/resend-verification", Access: accessAuthenticated},
+
+	// User self-service
+	{
+		Method: http.MethodGet, Path: "/api/user/profile", Access: accessUser,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileRead},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/user/profile", Access: accessUser,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileUpdate},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/user/preferences", Access: accessUser,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileRead},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/user/preferences", Access: accessUser,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileUpdate},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/user/change-email", Access: accessUser,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileUpdate},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/user/dashboard", Access: accessUser,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/user/features", Access: accessUser,
+		Permissions: []authdomain.Permission{authdomain.PermissionProfileRead},
+	},
+
+	// Admin health report, with the details the public probes leave out
+	{
+		Method: http.MethodGet, Path: "/api/admin/health", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionSystemRead},
+	},
+
+	// Admin user management
+	{
+		Method: http.MethodGet, Path: "/api/admin/users", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/users/:id", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/users/:id/audit", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAuditRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/users/:id/evidence", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAuditManage}, StepUp: true,
+	},
+	{
+		Method: http.MethodPut, Path: "/api/admin/users/:id", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage}, StepUpOnAlert: true,
+	},
+	{
+		Method: http.MethodPut, Path: "/api/admin/users/:id/role", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage}, StepUpOnAlert: true,
+	},
+	{
+		Method: http.MethodPut, Path: "/api/admin/users/:id/status", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage}, StepUpOnAlert: true,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/users/:id/approve", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage}, StepUpOnAlert: true,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/users/:id/email-verification", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/users/:id/resend-verification", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/users/:id/send-reset", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/users/:id/sessions", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/admin/sessions/:id", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/admin/users", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserDelete}, StepUpOnAlert: true,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/users/bulk", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage}, StepUpOnAlert: true,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/users/merge", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage}, StepUpOnAlert: true,
+	},
+
+	// Admin account recovery review
+	{
+		Method: http.MethodGet, Path: "/api/admin/recovery-requests", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/recovery-requests/:id/approve", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage}, StepUpOnAlert: true,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/recovery-requests/:id/reject", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionUserManage},
+	},
+
+	// Admin dashboard and monitoring
+	{
+		Method: http.MethodGet, Path: "/api/admin/stats", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/activity", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAuditRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/audit-logs", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAuditRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/security/login-stats", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionSecurityRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/security/alerts", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionSecurityRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/alerts/stream", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionSecurityRead},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/security/alerts/:id/resolve", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionSecurityRead, authdomain.PermissionAdminManage}, StepUp: true,
+	},
+
+	// Admin email queue
+	{
+		Method: http.MethodGet, Path: "/api/admin/email/queue", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/email/messages", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminRead},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/email/events", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminRead},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/email/process", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminManage},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/admin/email/scheduled/:message_id", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminManage},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/admin/email/broadcast", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminManage},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/email/broadcast/:id", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminManage},
+	},
+
+	// Admin exports; the download link is signed in place of a session
+	{
+		Method: http.MethodPost, Path: "/api/admin/exports", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAuditManage}, StepUp: true,
+	},
+	{
+		Method: http.MethodGet, Path: "/api/admin/exports/:id", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAuditManage},
+	},
+	{Method: http.MethodGet, Path: "/api/exports/:id/download", Access: accessPublic},
+
+	// Admin feature flag overrides
+	{
+		Method: http.MethodGet, Path: "/api/admin/features/overrides", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminRead},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/admin/features/overrides", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminWrite},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/admin/features/overrides/:id", Access: accessAdmin,
+		Permissions: []authdomain.Permission{authdomain.PermissionAdminWrite},
+	},
+}
+
+// findRoutePolicy returns the declared policy for a method and full route path
+func findRoutePolicy(method, fullPath string) (routePolicy, bool) {
+	for _, policy := range routePolicies {
+		if policy.Method == method && policy.Path == fullPath {
+			return policy, true
+		}
+	}
+	return routePolicy{}, false
+}
+
+// handle registers a route on the group behind the middleware its policy declares, followed by the
+// given handlers. A route without a declared policy is a programming error and panics at startup.
+func (s *Server) handle(group *gin.RouterGroup, method, relativePath string, handlers ...gin.HandlerFunc) {
+	fullPath := path.Join(group.BasePath(), relativePath)
+	policy, ok := findRoutePolicy(method, fullPath)
+	if !ok {
+		panic(fmt.Sprintf("no route policy declared for %s %s", method, fullPath))
+	}
+
+	chain := append(s.policyMiddleware(policy), handlers...)
+	group.Handle(method, relativePath, chain...)
+
+	if policy.Head && method == http.MethodGet {
+		headChain := append([]gin.HandlerFunc{middleware.HeadWithoutBody()}, chain...)
+		group.Handle(http.MethodHead, relativePath, headChain...)
+	}
+}
+
+// policyMiddleware builds the authentication, permission and step-up checks a policy declares
+func (s *Server) policyMiddleware(policy routePolicy) []gin.HandlerFunc {
+	var chain []gin.HandlerFunc
+
+	switch policy.Access {
+	case accessPublic:
+	case accessAuthenticated:
+		chain = append(chain, s.authMiddleware.RequireAuth())
+	case accessClient:
+		chain = append(chain, middleware.RequireClientKey(s.config.GetIntrospectionClientKeys(), s.logger))
+	case accessActive:
+		chain = append(chain, s.authMiddleware.RequireAuth(), s.authMiddleware.RequireActiveUser())
+	case accessUser:
+		chain = append(chain, s.authMiddleware.RequireAuth(), s.authMiddleware.RequireActiveUser())
+		if s.config.RequireVerifiedEmailForRoutes {
+			chain = append(chain, s.authMiddleware.RequireEmailVerified())
+		}
+	case accessAdmin:
+		// Every admin call is audited, including ones refused by the checks after it
+		chain = append(chain,
+			s.authMiddleware.RequireAuth(),
+			s.authMiddleware.AuditAdminAccess(s.config.AdminAccessAudit),
+			s.authMiddleware.RequireActiveUser(),
+			s.rbacMiddleware.RequireAdminAccess(),
+			s.authMiddleware.RequireSuperAdminStepUp(),
+		)
+		if s.config.RequireVerifiedEmailForRoutes {
+			chain = append(chain, s.authMiddleware.RequireEmailVerified())
+		}
+	}
+
+	for _, permission := range policy.Permissions {
+		chain = append(chain, s.rbacMiddleware.RequirePermission(permission))
+	}
+
+	if policy.StepUp {
+		chain = append(chain, s.authMiddleware.RequireStepUp())
+	} else if policy.StepUpOnAlert {
+		chain = append(chain, s.authMiddleware.RequireStepUpWhen(s.adminHandler.StepUpRequiredForChanges))
+	}
+
+	return chain
+}
@@ -5,14 +5,19 @@ import (
 	"log/slog"
 	"net/http"
 	"path/filepath"
-	"time"
+	"strings"
 
 	admintransport "github.com/acheevo/tfa/internal/admin/transport"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
 	authtransport "github.com/acheevo/tfa/internal/auth/transport"
+	featuretransport "github.com/acheevo/tfa/internal/features/transport"
 	healthtransport "github.com/acheevo/tfa/internal/health/transport"
 	infotransport "github.com/acheevo/tfa/internal/info/transport"
 	"github.com/acheevo/tfa/internal/middleware"
 	"github.com/acheevo/tfa/internal/shared/config"
+	apperrors "github.com/acheevo/tfa/internal/shared/errors"
+	"github.com/acheevo/tfa/internal/shared/monitoring"
+	"github.com/acheevo/tfa/internal/shared/monitoring/metrics"
 	usertransport "github.com/acheevo/tfa/internal/user/transport"
 	"github.com/gin-gonic/gin"
 )
@@ -25,9 +30,11 @@ type Server struct {
 	authHandler    *authtransport.AuthHandler
 	userHandler    *usertransport.UserHandler
 	adminHandler   *admintransport.AdminHandler
+	featureHandler *featuretransport.FeatureHandler
 	authMiddleware *middleware.AuthMiddleware
 	rbacMiddleware *middleware.RBACMiddleware
 	rateLimiter    *middleware.RateLimiter
+	metrics        metrics.MetricsCollector
 	router         *gin.Engine
 	server         *http.Server
 }
@@ -40,9 +47,11 @@ func NewServer(
 	authHandler *authtransport.AuthHandler,
 	userHandler *usertransport.UserHandler,
 	adminHandler *admintransport.AdminHandler,
+	featureHandler *featuretransport.FeatureHandler,
 	authMiddleware *middleware.AuthMiddleware,
 	rbacMiddleware *middleware.RBACMiddleware,
 	rateLimiter *middleware.RateLimiter,
+	metricsCollector metrics.MetricsCollector,
 ) *Server {
 	if !config.IsDevelopment() {
 		gin.SetMode(gin.ReleaseMode)
@@ -58,99 +67,192 @@ func NewServer(
 		authHandler:    authHandler,
 		userHandler:    userHandler,
 		adminHandler:   adminHandler,
+		featureHandler: featureHandler,
 		authMiddleware: authMiddleware,
 		rbacMiddleware: rbacMiddleware,
 		rateLimiter:    rateLimiter,
+		metrics:        metricsCollector,
 		router:         router,
 	}
 
 	s.setupMiddleware()
 	s.setupRoutes()
 
-	s.server = &http.Server{
+	s.server = newHTTPServer(config, router)
+
+	return s
+}
+
+// newHTTPServer builds the HTTP server with timeouts and a header size limit so slow or
+// oversized requests (slowloris) cannot hold connections open indefinitely
+func newHTTPServer(config *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
 		Addr:              ":" + config.Port,
-		Handler:           router,
-		ReadTimeout:       30 * time.Second,
-		WriteTimeout:      30 * time.Second,
-		IdleTimeout:       120 * time.Second,
-		ReadHeaderTimeout: 5 * time.Second,
+		Handler:           handler,
+		ReadHeaderTimeout: config.ServerReadHeaderTimeoutDuration(),
+		ReadTimeout:       config.ServerReadTimeoutDuration(),
+		WriteTimeout:      config.ServerWriteTimeoutDuration(),
+		IdleTimeout:       config.ServerIdleTimeoutDuration(),
+		MaxHeaderBytes:    config.ServerMaxHeaderBytesLimit(),
 	}
+}
 
-	return s
+// corsGroupMethods limits the methods advertised in CORS preflights to those each route group serves
+var corsGroupMethods = map[string][]string{
+	"/api/auth":  {http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodOptions},
+	"/api/user":  {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodOptions},
+	"/api/admin": {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
 }
 
 func (s *Server) setupMiddleware() {
-	s.router.Use(middleware.Logger(s.logger))
+	s.router.Use(middleware.JSONContentType(s.config.JSONContentTypeValue()))
+	s.router.Use(middleware.RequestID())
+	s.router.Use(middleware.Logger(s.config, s.logger))
 	s.router.Use(middleware.Recovery(s.logger))
-	s.router.Use(middleware.CORS())
+	s.router.Use(monitoring.MonitoringMiddleware(s.config, s.metrics))
+	s.router.Use(apperrors.ErrorMiddleware(s.logger, s.config.Environment))
+	s.router.Use(middleware.SecureCORS(s.config, corsGroupMethods))
+	s.router.Use(middleware.InputSanitization(s.config, s.logger))
 }
 
+// setupRoutes registers the API routes; their authentication and permissions are declared in routePolicies
 func (s *Server) setupRoutes() {
 	api := s.router.Group("/api")
+
+	// Health and info endpoints
+	s.handle(api, http.MethodGet, "/health", s.healthHandler.GetHealth)
+	s.handle(api, http.MethodGet, "/ready", s.healthHandler.GetReadiness)
+	s.handle(api, http.MethodGet, "/info", s.infoHandler.GetInfo)
+	s.handle(api, http.MethodGet, "/time", s.infoHandler.GetTime)
+
+	// Authentication routes with rate limiting
+	authGroup := api.Group("/auth")
+	authGroup.Use(s.rateLimiter.AuthRateLimit())
 	{
-		// Health and info endpoints
-		api.GET("/health", s.healthHandler.GetHealth)
-		api.GET("/info", s.infoHandler.GetInfo)
-
-		// Authentication routes with rate limiting
-		authGroup := api.Group("/auth")
-		authGroup.Use(s.rateLimiter.AuthRateLimit())
-
-		// Login with specific rate limiting
-		authGroup.POST("/login", s.authHandler.Login)
-
-		// Other auth routes
-		authGroup.POST("/register", s.authHandler.Register)
-		authGroup.POST("/refresh", s.authHandler.RefreshToken)
-		authGroup.POST("/logout", s.authHandler.Logout)
-		authGroup.POST("/verify-email", s.authHandler.VerifyEmail)
-		authGroup.POST("/forgot-password", s.authHandler.ForgotPassword)
-		authGroup.POST("/reset-password", s.authHandler.ResetPassword)
-
-		// Protected auth routes
-		protectedAuth := authGroup.Group("/")
-		protectedAuth.Use(s.authMiddleware.RequireAuth())
-		{
-			protectedAuth.GET("/check", s.authHandler.CheckAuth)
-			protectedAuth.POST("/logout-all", s.authHandler.LogoutAll)
-			protectedAuth.POST("/change-password", s.authHandler.ChangePassword)
-			protectedAuth.GET("/profile", s.authHandler.GetProfile)
-			protectedAuth.POST("/resend-verification", s.authHandler.ResendEmailVerification)
-		}
+		s.handle(authGroup, http.MethodPost, "/login", s.authHandler.Login)
+		s.handle(authGroup, http.MethodPost, "/verify-email", s.authHandler.VerifyEmail)
 
-		// User management routes (require authentication, active user, and profile permissions)
-		userGroup := api.Group("/user")
-		userGroup.Use(s.authMiddleware.RequireAuth(), s.authMiddleware.RequireActiveUser())
-		{
-			userGroup.GET("/profile", s.rbacMiddleware.RequirePermission("profile:read"), s.userHandler.GetProfile)
-			userGroup.PUT("/profile", s.rbacMiddleware.RequirePermission("profile:update"), s.userHandler.UpdateProfile)
-			userGroup.GET("/preferences", s.rbacMiddleware.RequirePermission("profile:read"), s.userHandler.GetPreferences)
-			userGroup.PUT("/preferences", s.rbacMiddleware.RequirePermission("profile:update"), s.userHandler.UpdatePreferences)
-			userGroup.POST("/change-email", s.rbacMiddleware.RequirePermission("profile:update"), s.userHandler.ChangeEmail)
-			userGroup.GET("/dashboard", s.rbacMiddleware.RequirePermission("profile:read"), s.userHandler.GetDashboard)
+		// Endpoints the configuration can switch off; they then answer 404 like unknown routes
+		if !s.config.AuthDisableRegister {
+			s.handle(authGroup, http.MethodPost, "/register", s.authHandler.Register)
 		}
+		if !s.config.AuthDisableRefresh {
+			s.handle(authGroup, http.MethodPost, "/refresh", s.authHandler.RefreshToken)
+		}
+		if !s.config.AuthDisableLogout {
+			s.handle(authGroup, http.MethodPost, "/logout", s.authHandler.Logout)
+		}
+		if !s.config.AuthDisableForgotPassword {
+			s.handle(authGroup, http.MethodPost, "/forgot-password", s.authHandler.ForgotPassword)
+		}
+		if !s.config.AuthDisableResetPassword {
+			s.handle(authGroup, http.MethodPost, "/reset-password", s.authHandler.ResetPassword)
+		}
+		s.handle(authGroup, http.MethodPost, "/recovery-request", s.authHandler.RequestAccountRecovery)
+		s.handle(authGroup, http.MethodPost, "/confirm-backup-email", s.authHandler.ConfirmBackupEmail)
+		s.handle(authGroup, http.MethodGet, "/email-available",
+			s.rateLimiter.EmailAvailabilityRateLimit(s.config.EmailAvailabilityRateLimit),
+			s.authHandler.CheckEmailAvailable,
+		)
 
-		// Admin routes (require authentication, active status, and specific permissions)
-		adminGroup := api.Group("/admin")
-		adminGroup.Use(
-			s.authMiddleware.RequireAuth(),
-			s.authMiddleware.RequireActiveUser(),
-			s.rbacMiddleware.RequireAdminAccess(),
+		s.handle(authGroup, http.MethodGet, "/check", s.authHandler.CheckAuth)
+		s.handle(authGroup, http.MethodGet, "/session", s.authHandler.GetSession)
+		s.handle(authGroup, http.MethodPost, "/logout-all", s.authHandler.LogoutAll)
+		s.handle(authGroup, http.MethodPost, "/change-password", s.authHandler.ChangePassword)
+		s.handle(authGroup, http.MethodPut, "/backup-email", s.authHandler.SetBackupEmail)
+		s.handle(authGroup, http.MethodPost, "/step-up", s.authHandler.StepUp)
+		s.handle(authGroup, http.MethodGet, "/profile", s.authHandler.GetProfile)
+		s.handle(authGroup, http.MethodPatch, "/me", s.userHandler.UpdateSelf)
+		s.handle(authGroup, http.MethodGet, "/me/consents", s.userHandler.GetConsents)
+		s.handle(authGroup, http.MethodPost, "/me/consents", s.userHandler.UpdateConsent)
+		s.handle(authGroup, http.MethodPost, "/resend-verification", s.authHandler.ResendEmailVerification)
+	}
+
+	// Token introspection for gateways; outside the auth rate limit, as one gateway serves many users
+	introspectGroup := api.Group("/auth/introspect")
+	{
+		s.handle(introspectGroup, http.MethodPost, "", s.authHandler.Introspect)
+		s.handle(introspectGroup, http.MethodPost, "/batch", s.authHandler.IntrospectBatch)
+	}
+
+	// User self-service routes
+	userGroup := api.Group("/user")
+	{
+		s.handle(userGroup, http.MethodGet, "/profile", s.userHandler.GetProfile)
+		s.handle(userGroup, http.MethodPut, "/profile", s.userHandler.UpdateProfile)
+		s.handle(userGroup, http.MethodGet, "/preferences", s.userHandler.GetPreferences)
+		s.handle(userGroup, http.MethodPut, "/preferences", s.userHandler.UpdatePreferences)
+		s.handle(userGroup, http.MethodPost, "/change-email", s.userHandler.ChangeEmail)
+		s.handle(userGroup, http.MethodGet, "/dashboard", s.userHandler.GetDashboard)
+		s.handle(userGroup, http.MethodGet, "/features", s.featureHandler.GetMyFeatures)
+	}
+
+	// Signed export downloads; the link in the notification email is opened without a session
+	s.handle(api, http.MethodGet, "/exports/:id/download", s.adminHandler.DownloadExport)
+
+	// Admin routes
+	adminGroup := api.Group("/admin")
+	{
+		s.handle(adminGroup, http.MethodGet, "/health", s.healthHandler.GetDetailedHealth)
+
+		// User management
+		s.handle(adminGroup, http.MethodGet, "/users", s.adminHandler.ListUsers)
+		s.handle(adminGroup, http.MethodGet, "/users/:id", s.adminHandler.GetUserDetails)
+		s.handle(adminGroup, http.MethodGet, "/users/:id/audit", s.adminHandler.GetUserAuditLogs)
+		s.handle(adminGroup, http.MethodGet, "/users/:id/evidence", s.adminHandler.ExportUserEvidence)
+		s.handle(adminGroup, http.MethodPut, "/users/:id", s.adminHandler.UpdateUser)
+		s.handle(adminGroup, http.MethodPut, "/users/:id/role", s.adminHandler.UpdateUserRole)
+		s.handle(adminGroup, http.MethodPut, "/users/:id/status", s.adminHandler.UpdateUserStatus)
+		s.handle(adminGroup, http.MethodPost, "/users/:id/approve", s.adminHandler.ApproveUser)
+		s.handle(adminGroup, http.MethodPost, "/users/:id/email-verification", s.adminHandler.UpdateEmailVerification)
+		s.handle(adminGroup, http.MethodPost, "/users/:id/resend-verification",
+			s.rateLimiter.AdminAccountEmailRateLimit(),
+			s.adminHandler.ResendVerificationEmail,
 		)
-		{
-			// User management (require user management permissions)
-			adminGroup.GET("/users", s.rbacMiddleware.RequireUserRead(), s.adminHandler.ListUsers)
-			adminGroup.GET("/users/:id", s.rbacMiddleware.RequireUserRead(), s.adminHandler.GetUserDetails)
-			adminGroup.PUT("/users/:id", s.rbacMiddleware.RequireUserManagement(), s.adminHandler.UpdateUser)
-			adminGroup.PUT("/users/:id/role", s.rbacMiddleware.RequireUserManagement(), s.adminHandler.UpdateUserRole)
-			adminGroup.PUT("/users/:id/status", s.rbacMiddleware.RequireUserManagement(), s.adminHandler.UpdateUserStatus)
-			adminGroup.DELETE("/users", s.rbacMiddleware.RequirePermission("user:delete"), s.adminHandler.DeleteUsers)
-			adminGroup.POST("/users/bulk", s.rbacMiddleware.RequireUserManagement(), s.adminHandler.BulkUpdateUsers)
-
-			// Admin dashboard and monitoring
-			adminGroup.GET("/stats", s.rbacMiddleware.RequirePermission("admin:read"), s.adminHandler.GetStats)
-			adminGroup.GET("/audit-logs", s.rbacMiddleware.RequireAuditAccess(), s.adminHandler.GetAuditLogs)
-		}
+		s.handle(adminGroup, http.MethodPost, "/users/:id/send-reset",
+			s.rateLimiter.AdminAccountEmailRateLimit(),
+			s.adminHandler.SendPasswordReset,
+		)
+		s.handle(adminGroup, http.MethodGet, "/users/:id/sessions", s.adminHandler.ListUserSessions)
+		s.handle(adminGroup, http.MethodDelete, "/sessions/:id", s.adminHandler.RevokeSession)
+		s.handle(adminGroup, http.MethodDelete, "/users", s.adminHandler.DeleteUsers)
+		s.handle(adminGroup, http.MethodPost, "/users/bulk", s.adminHandler.BulkUpdateUsers)
+		s.handle(adminGroup, http.MethodPost, "/users/merge", s.adminHandler.MergeUsers)
+
+		// Account recovery review
+		s.handle(adminGroup, http.MethodGet, "/recovery-requests", s.adminHandler.ListRecoveryRequests)
+		s.handle(adminGroup, http.MethodPost, "/recovery-requests/:id/approve", s.adminHandler.ApproveRecoveryRequest)
+		s.handle(adminGroup, http.MethodPost, "/recovery-requests/:id/reject", s.adminHandler.RejectRecoveryRequest)
+
+		// Admin dashboard and monitoring
+		s.handle(adminGroup, http.MethodGet, "/stats", s.adminHandler.GetStats)
+		s.handle(adminGroup, http.MethodGet, "/activity", s.adminHandler.GetActivityFeed)
+		s.handle(adminGroup, http.MethodGet, "/audit-logs", s.adminHandler.GetAuditLogs)
+		s.handle(adminGroup, http.MethodGet, "/security/login-stats", s.adminHandler.GetLoginStats)
+		s.handle(adminGroup, http.MethodGet, "/security/alerts", s.adminHandler.ListSecurityAlerts)
+		s.handle(adminGroup, http.MethodPost, "/security/alerts/:id/resolve", s.adminHandler.ResolveSecurityAlert)
+		s.handle(adminGroup, http.MethodGet, "/alerts/stream", s.adminHandler.StreamSecurityAlerts)
+
+		// Email queue operations
+		s.handle(adminGroup, http.MethodGet, "/email/queue", s.adminHandler.GetEmailQueueStats)
+		s.handle(adminGroup, http.MethodGet, "/email/messages", s.adminHandler.ListQueuedEmails)
+		s.handle(adminGroup, http.MethodGet, "/email/events", s.adminHandler.ListEmailEvents)
+		s.handle(adminGroup, http.MethodPost, "/email/process",
+			s.rateLimiter.EmailQueueRateLimit(),
+			s.adminHandler.ProcessEmailQueue,
+		)
+		s.handle(adminGroup, http.MethodDelete, "/email/scheduled/:message_id", s.adminHandler.CancelScheduledEmail)
+		s.handle(adminGroup, http.MethodPost, "/email/broadcast", s.adminHandler.StartBroadcast)
+		s.handle(adminGroup, http.MethodGet, "/email/broadcast/:id", s.adminHandler.GetBroadcast)
+
+		// Exports
+		s.handle(adminGroup, http.MethodPost, "/exports", s.adminHandler.CreateExport)
+		s.handle(adminGroup, http.MethodGet, "/exports/:id", s.adminHandler.GetExport)
+
+		// Feature flag overrides
+		s.handle(adminGroup, http.MethodGet, "/features/overrides", s.featureHandler.ListOverrides)
+		s.handle(adminGroup, http.MethodPut, "/features/overrides", s.featureHandler.SetOverride)
+		s.handle(adminGroup, http.MethodDelete, "/features/overrides/:id", s.featureHandler.DeleteOverride)
 	}
 
 	s.setupStaticRoutes()
@@ -164,6 +266,12 @@ func (s *Server) setupStaticRoutes() {
 	s.router.StaticFile("/favicon.ico", filepath.Join(frontendPath, "favicon.ico"))
 
 	s.router.NoRoute(func(c *gin.Context) {
+		// API clients expect a JSON error, not the frontend
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "not found"})
+			return
+		}
+
 		indexPath := filepath.Join(frontendPath, "index.html")
 		c.File(indexPath)
 	})
@@ -0,0 +1,263 @@
+package http
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	admintransport "github.com/acheevo/tfa/internal/admin/transport"
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	authtransport "github.com/acheevo/tfa/internal/auth/transport"
+	featuretransport "github.com/acheevo/tfa/internal/features/transport"
+	healthtransport "github.com/acheevo/tfa/internal/health/transport"
+	infoservice "github.com/acheevo/tfa/internal/info/service"
+	infotransport "github.com/acheevo/tfa/internal/info/transport"
+	"github.com/acheevo/tfa/internal/middleware"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	usertransport "github.com/acheevo/tfa/internal/user/transport"
+)
+
+func TestNewHTTPServerDefaults(t *testing.T) {
+	server := newHTTPServer(&config.Config{Port: "8080"}, http.NotFoundHandler())
+
+	assert.Equal(t, ":8080", server.Addr)
+	assert.Equal(t, 5*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 30*time.Second, server.ReadTimeout)
+	assert.Equal(t, 30*time.Second, server.WriteTimeout)
+	assert.Equal(t, 120*time.Second, server.IdleTimeout)
+	assert.Equal(t, 64<<10, server.MaxHeaderBytes)
+}
+
+func TestNewHTTPServerOverrides(t *testing.T) {
+	server := newHTTPServer(&config.Config{
+		Port:                    "9000",
+		ServerReadHeaderTimeout: "2s",
+		ServerReadTimeout:       "10s",
+		ServerWriteTimeout:      "15s",
+		ServerIdleTimeout:       "1m",
+		ServerMaxHeaderBytes:    8192,
+	}, http.NotFoundHandler())
+
+	assert.Equal(t, 2*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 10*time.Second, server.ReadTimeout)
+	assert.Equal(t, 15*time.Second, server.WriteTimeout)
+	assert.Equal(t, time.Minute, server.IdleTimeout)
+	assert.Equal(t, 8192, server.MaxHeaderBytes)
+}
+
+func TestNewHTTPServerRejectsInvalidTimeouts(t *testing.T) {
+	server := newHTTPServer(&config.Config{
+		ServerReadHeaderTimeout: "0s",
+		ServerReadTimeout:       "not-a-duration",
+	}, http.NotFoundHandler())
+
+	// Invalid or zero values fall back to safe defaults rather than disabling the timeout
+	assert.Equal(t, 5*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 30*time.Second, server.ReadTimeout)
+}
+
+// newRouteTestServer registers the routes against zero-value handlers and middleware; it is only
+// used to inspect the route table, never to serve requests
+func newRouteTestServer() *Server {
+	gin.SetMode(gin.TestMode)
+	s := &Server{
+		config:         &config.Config{},
+		healthHandler:  &healthtransport.HealthHandler{},
+		infoHandler:    &infotransport.InfoHandler{},
+		authHandler:    &authtransport.AuthHandler{},
+		userHandler:    &usertransport.UserHandler{},
+		adminHandler:   &admintransport.AdminHandler{},
+		featureHandler: &featuretransport.FeatureHandler{},
+		authMiddleware: &middleware.AuthMiddleware{},
+		rbacMiddleware: &middleware.RBACMiddleware{},
+		rateLimiter:    middleware.NewRateLimiter(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, clock.New(), 10, time.Minute),
+		router:         gin.New(),
+	}
+	s.setupRoutes()
+	return s
+}
+
+func TestEveryAPIRouteHasOnePolicy(t *testing.T) {
+	s := newRouteTestServer()
+
+	registered := map[string]bool{}
+	for _, route := range s.router.Routes() {
+		if strings.HasPrefix(route.Path, "/api/") {
+			registered[route.Method+" "+route.Path] = true
+		}
+	}
+
+	declared := map[string]bool{}
+	for _, policy := range routePolicies {
+		key := policy.Method + " " + policy.Path
+		assert.False(t, declared[key], "duplicate policy for %s", key)
+		declared[key] = true
+		if policy.Head {
+			declared[http.MethodHead+" "+policy.Path] = true
+		}
+	}
+
+	assert.Equal(t, declared, registered)
+}
+
+func TestNoMutatingEndpointIsAccidentallyPublic(t *testing.T) {
+	// Endpoints that must work without a session, and so are public on purpose
+	publicMutations := map[string]bool{
+		"POST /api/auth/login":                true,
+		"POST /api/auth/register":             true,
+		"POST /api/auth/refresh":              true,
+		"POST /api/auth/logout":               true,
+		"POST /api/auth/verify-email":         true,
+		"POST /api/auth/forgot-password":      true,
+		"POST /api/auth/reset-password":       true,
+		"POST /api/auth/recovery-request":     true,
+		"POST /api/auth/confirm-backup-email": true,
+	}
+
+	for _, policy := range routePolicies {
+		key := policy.Method + " " + policy.Path
+		if policy.Method == http.MethodGet || policy.Access != accessPublic {
+			continue
+		}
+		assert.True(t, publicMutations[key], "%s is public", key)
+	}
+}
+
+func TestRoutePolicies(t *testing.T) {
+	check, ok := findRoutePolicy(http.MethodGet, "/api/auth/check")
+	assert.True(t, ok)
+	assert.Equal(t, accessAuthenticated, check.Access)
+
+	// Introspection serves gateways with client keys, not users
+	introspect, ok := findRoutePolicy(http.MethodPost, "/api/auth/introspect")
+	assert.True(t, ok)
+	assert.Equal(t, accessClient, introspect.Access)
+
+	// Resolving an alert ends its automatic response, so it needs a fresh step-up
+	resolve, ok := findRoutePolicy(http.MethodPost, "/api/admin/security/alerts/:id/resolve")
+	assert.True(t, ok)
+	assert.True(t, resolve.StepUp)
+
+	for _, policy := range routePolicies {
+		if strings.HasPrefix(policy.Path, "/api/admin/") {
+			assert.Equal(t, accessAdmin, policy.Access, "%s %s", policy.Method, policy.Path)
+			assert.NotEmpty(t, policy.Permissions, "%s %s", policy.Method, policy.Path)
+		}
+		if strings.HasPrefix(policy.Path, "/api/user/") {
+			assert.Equal(t, accessUser, policy.Access, "%s %s", policy.Method, policy.Path)
+		}
+	}
+}
+
+func TestHandleRejectsUndeclaredRoute(t *testing.T) {
+	s := newRouteTestServer()
+
+	assert.Panics(t, func() {
+		s.handle(s.router.Group("/api"), http.MethodPost, "/undeclared", func(c *gin.Context) {})
+	})
+}
+
+func TestHeadOnReadOnlyEndpoints(t *testing.T) {
+	s := newRouteTestServer()
+	s.infoHandler = infotransport.NewInfoHandler(infoservice.NewInfoService(&config.Config{}, nil, nil))
+	s.router = gin.New()
+	s.setupRoutes()
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/api/time", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store, no-cache, must-revalidate", w.Header().Get("Cache-Control"))
+	assert.NotEmpty(t, w.Header().Get("Content-Length"))
+
+	// Only routes that declare it answer HEAD
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/api/auth/check", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDisabledAuthEndpoints(t *testing.T) {
+	s := newRouteTestServer()
+	s.config = &config.Config{
+		AuthDisableRegister:       true,
+		AuthDisableForgotPassword: true,
+		AuthDisableResetPassword:  true,
+	}
+	s.infoHandler = infotransport.NewInfoHandler(infoservice.NewInfoService(&config.Config{}, nil, nil))
+	s.router = gin.New()
+	s.router.Use(middleware.JSONContentType(s.config.JSONContentTypeValue()))
+	s.setupRoutes()
+
+	registered := map[string]bool{}
+	for _, route := range s.router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	assert.False(t, registered["POST /api/auth/register"])
+	assert.False(t, registered["POST /api/auth/forgot-password"])
+	assert.False(t, registered["POST /api/auth/reset-password"])
+	assert.True(t, registered["POST /api/auth/login"])
+	assert.True(t, registered["POST /api/auth/refresh"])
+	assert.True(t, registered["POST /api/auth/logout"])
+
+	// Disabled endpoints answer like unknown routes, while the rest keep serving
+	for _, path := range []string{"/api/auth/register", "/api/auth/forgot-password", "/api/auth/reset-password"} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`)))
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+		assert.JSONEq(t, `{"error":"not found"}`, w.Body.String(), path)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/time", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Refresh and logout switch off the same way
+	s.config = &config.Config{AuthDisableRefresh: true, AuthDisableLogout: true}
+	s.router = gin.New()
+	s.setupRoutes()
+	registered = map[string]bool{}
+	for _, route := range s.router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	assert.False(t, registered["POST /api/auth/refresh"])
+	assert.False(t, registered["POST /api/auth/logout"])
+	assert.True(t, registered["POST /api/auth/register"])
+	assert.True(t, registered["POST /api/auth/logout-all"])
+}
+
+func TestUnknownAPIRouteAnswersJSON(t *testing.T) {
+	s := newRouteTestServer()
+	s.router = gin.New()
+	s.router.Use(middleware.JSONContentType(s.config.JSONContentTypeValue()))
+	s.setupRoutes()
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/no-such-route", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"not found"}`, w.Body.String())
+}
+
+func TestDetailedHealthRequiresAdmin(t *testing.T) {
+	policy, ok := findRoutePolicy(http.MethodGet, "/api/admin/health")
+	assert.True(t, ok)
+	assert.Equal(t, accessAdmin, policy.Access)
+	assert.Equal(t, []authdomain.Permission{authdomain.PermissionSystemRead}, policy.Permissions)
+
+	s := newRouteTestServer()
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/health", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotContains(t, w.Body.String(), "checks")
+}
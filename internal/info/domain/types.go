@@ -1,8 +1,23 @@
 package domain
 
+import "time"
+
+// Info describes the running build for deploy verification. Fields not listed in INFO_FIELDS, and
+// build metadata that wasn't stamped into the binary, are omitted.
 type Info struct {
-	Name        string `json:"name"`
-	Version     string `json:"version"`
-	Environment string `json:"environment"`
-	BuildTime   string `json:"build_time"`
+	Name        string   `json:"name,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Commit      string   `json:"commit,omitempty"`
+	BuildTime   string   `json:"build_time,omitempty"`
+	GoVersion   string   `json:"go_version,omitempty"`
+	Environment string   `json:"environment,omitempty"`
+	Features    []string `json:"features,omitempty"`
+}
+
+// ServerTime is the authoritative server clock, so clients can detect skew that breaks token and
+// one-time code validation
+type ServerTime struct {
+	ServerTime       time.Time `json:"server_time"`
+	UnixMillis       int64     `json:"unix_millis"`
+	JWTLeewaySeconds float64   `json:"jwt_leeway_seconds"`
 }
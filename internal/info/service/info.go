@@ -2,9 +2,11 @@ package service
 
 import (
 	"log/slog"
+	"runtime"
 	"time"
 
 	"github.com/acheevo/tfa/internal/info/domain"
+	"github.com/acheevo/tfa/internal/shared/buildinfo"
 	"github.com/acheevo/tfa/internal/shared/config"
 	"github.com/acheevo/tfa/internal/shared/database"
 )
@@ -23,11 +25,66 @@ func NewInfoService(config *config.Config, db *database.DB, logger *slog.Logger)
 	}
 }
 
+// GetInfo returns the fields of the build description listed in INFO_FIELDS
 func (s *InfoService) GetInfo() *domain.Info {
-	return &domain.Info{
-		Name:        "Fullstack Template API",
-		Version:     "1.0.0",
-		Environment: s.config.Environment,
-		BuildTime:   time.Now().UTC().Format(time.RFC3339),
+	info := &domain.Info{}
+	for _, field := range s.config.GetInfoFields() {
+		switch field {
+		case "name":
+			info.Name = s.config.AppName
+		case "version":
+			info.Version = s.config.Version
+			if buildinfo.Version != "" {
+				info.Version = buildinfo.Version
+			}
+		case "commit":
+			info.Commit = buildinfo.Commit
+		case "build_time":
+			info.BuildTime = buildinfo.BuildTime
+		case "go_version":
+			info.GoVersion = runtime.Version()
+		case "environment":
+			info.Environment = s.config.Environment
+		case "features":
+			info.Features = enabledFeatures(s.config.FeatureFlags)
+		}
+	}
+	return info
+}
+
+// enabledFeatures lists the feature flags that are switched on
+func enabledFeatures(flags config.FeatureFlags) []string {
+	all := []struct {
+		name    string
+		enabled bool
+	}{
+		{"email_verification", flags.EmailVerification},
+		{"two_factor_auth", flags.TwoFactorAuth},
+		{"admin_api", flags.AdminAPI},
+		{"metrics", flags.Metrics},
+		{"file_uploads", flags.FileUploads},
+		{"social_login", flags.SocialLogin},
+		{"email_templates", flags.EmailTemplates},
+		{"rate_limiting", flags.RateLimiting},
+		{"csrf_protection", flags.CSRFProtection},
+		{"security_headers", flags.SecurityHeaders},
+	}
+
+	features := []string{}
+	for _, feature := range all {
+		if feature.enabled {
+			features = append(features, feature.name)
+		}
+	}
+	return features
+}
+
+// GetTime returns the current server time along with the clock skew tolerated for tokens
+func (s *InfoService) GetTime() *domain.ServerTime {
+	now := time.Now().UTC()
+	return &domain.ServerTime{
+		ServerTime:       now,
+		UnixMillis:       now.UnixMilli(),
+		JWTLeewaySeconds: s.config.JWTLeewayDuration().Seconds(),
 	}
 }
@@ -21,3 +21,10 @@ func (h *InfoHandler) GetInfo(c *gin.Context) {
 	info := h.service.GetInfo()
 	c.JSON(http.StatusOK, info)
 }
+
+// GetTime reports the server clock; responses must never be cached or the reported time goes stale
+func (h *InfoHandler) GetTime(c *gin.Context) {
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, h.service.GetTime())
+}
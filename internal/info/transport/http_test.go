@@ -0,0 +1,114 @@
+package transport
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/info/domain"
+	"github.com/acheevo/tfa/internal/info/service"
+	"github.com/acheevo/tfa/internal/shared/buildinfo"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func TestGetTime(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{JWTLeeway: "30s"}
+	h := NewInfoHandler(service.NewInfoService(cfg, nil, slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	router := gin.New()
+	router.GET("/api/time", h.GetTime)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/time", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Cache-Control"), "no-store")
+
+	var response domain.ServerTime
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.WithinDuration(t, time.Now(), response.ServerTime, 5*time.Second)
+	assert.Equal(t, response.ServerTime.UnixMilli(), response.UnixMillis)
+	assert.Equal(t, 30.0, response.JWTLeewaySeconds)
+}
+
+func TestGetInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	buildinfo.Commit, buildinfo.BuildTime = "abc1234", "2024-01-01T00:00:00Z"
+	t.Cleanup(func() { buildinfo.Commit, buildinfo.BuildTime = "", "" })
+
+	getInfo := func(t *testing.T, cfg *config.Config) map[string]interface{} {
+		h := NewInfoHandler(service.NewInfoService(cfg, nil, slog.New(slog.NewTextHandler(io.Discard, nil))))
+		router := gin.New()
+		router.GET("/api/info", h.GetInfo)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/info", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	base := config.Config{
+		AppName:      "Test App",
+		Version:      "2.1.0",
+		FeatureFlags: config.FeatureFlags{AdminAPI: true, RateLimiting: true},
+	}
+
+	t.Run("development exposes every field", func(t *testing.T) {
+		cfg := base
+		cfg.Environment = "development"
+
+		response := getInfo(t, &cfg)
+
+		assert.Equal(t, "Test App", response["name"])
+		assert.Equal(t, "2.1.0", response["version"])
+		assert.Equal(t, "abc1234", response["commit"])
+		assert.Equal(t, "2024-01-01T00:00:00Z", response["build_time"])
+		assert.Equal(t, runtime.Version(), response["go_version"])
+		assert.Equal(t, "development", response["environment"])
+		assert.Equal(t, []interface{}{"admin_api", "rate_limiting"}, response["features"])
+	})
+
+	t.Run("production omits deployment details", func(t *testing.T) {
+		cfg := base
+		cfg.Environment = "production"
+
+		response := getInfo(t, &cfg)
+
+		assert.Equal(t, map[string]interface{}{
+			"name":       "Test App",
+			"version":    "2.1.0",
+			"commit":     "abc1234",
+			"build_time": "2024-01-01T00:00:00Z",
+		}, response)
+	})
+
+	t.Run("configured fields", func(t *testing.T) {
+		cfg := base
+		cfg.Environment = "production"
+		cfg.InfoFields = "version,environment"
+
+		assert.Equal(t, map[string]interface{}{"version": "2.1.0", "environment": "production"}, getInfo(t, &cfg))
+	})
+
+	t.Run("stamped version overrides APP_VERSION", func(t *testing.T) {
+		buildinfo.Version = "2.2.0-rc.1"
+		t.Cleanup(func() { buildinfo.Version = "" })
+		cfg := base
+		cfg.InfoFields = "version"
+
+		assert.Equal(t, map[string]interface{}{"version": "2.2.0-rc.1"}, getInfo(t, &cfg))
+	})
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// Admin access audit modes
+const (
+	AdminAccessAuditAll       = "all"
+	AdminAccessAuditMutations = "mutations"
+	AdminAccessAuditOff       = "off"
+)
+
+// requestAuditedKey marks a request another middleware has already audited as a whole
+const requestAuditedKey = "request_audited"
+
+// AuditAdminAccess records every admin API call as an admin_access audit entry once the handler
+// has run, so reads of sensitive data leave a trace too. In mutations mode reads are skipped.
+// Requests already audited as a whole, such as super admin actions, are not recorded twice.
+// It must run after RequireAuth and before the authorization checks so refused calls are kept.
+func (m *AuthMiddleware) AuditAdminAccess(mode string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if mode == AdminAccessAuditOff || (mode == AdminAccessAuditMutations && isReadMethod(c.Request.Method)) {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		uid, _ := c.Get("user_id")
+		userID, ok := uid.(uint)
+		if !ok || c.GetBool(requestAuditedKey) {
+			return
+		}
+
+		access := &domain.AdminAccess{
+			UserID:    userID,
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Path:      c.Request.URL.Path,
+			Query:     c.Request.URL.RawQuery,
+			Status:    c.Writer.Status(),
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}
+		if strings.Contains(access.Route, "/users/:id") {
+			if id, err := strconv.ParseUint(c.Param("id"), 10, 32); err == nil {
+				targetID := uint(id)
+				access.TargetID = &targetID
+			}
+		}
+
+		m.authService.RecordAdminAccess(access)
+	}
+}
+
+// isReadMethod reports whether a request method only reads
+func isReadMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/acheevo/tfa/internal/auth/domain"
 	"github.com/acheevo/tfa/internal/auth/service"
+	apperrors "github.com/acheevo/tfa/internal/shared/errors"
 )
 
 // AuthMiddleware provides authentication middleware
@@ -25,14 +26,30 @@ func NewAuthMiddleware(logger *slog.Logger, authService *service.AuthService) *A
 	}
 }
 
+// CodedError adds a machine-readable code to an error response, with the code's message in the
+// request's language, so errors handlers answer themselves are localized like those of ErrorMiddleware
+func CodedError(c *gin.Context, code apperrors.ErrorCode, response domain.ErrorResponse) domain.ErrorResponse {
+	response.Code = code.String()
+	response.Message = apperrors.LocalizedMessage(c, code)
+	return response
+}
+
+// setPreferredLanguage makes the user's language preference win over Accept-Language when error
+// messages are localized; an empty preference leaves the header to decide
+func setPreferredLanguage(c *gin.Context, language string) {
+	if language != "" {
+		c.Set(apperrors.LanguageContextKey, language)
+	}
+}
+
 // RequireAuth middleware that requires valid authentication
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := m.extractToken(c)
 		if token == "" {
-			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
+			c.JSON(http.StatusUnauthorized, CodedError(c, apperrors.CodeUnauthorized, domain.ErrorResponse{
 				Error: "authentication required",
-			})
+			}))
 			c.Abort()
 			return
 		}
@@ -40,9 +57,9 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		claims, err := m.authService.ValidateAccessToken(token)
 		if err != nil {
 			m.logger.Warn("invalid access token", "error", err)
-			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
+			c.JSON(http.StatusUnauthorized, CodedError(c, apperrors.CodeTokenInvalid, domain.ErrorResponse{
 				Error: "invalid or expired token",
-			})
+			}))
 			c.Abort()
 			return
 		}
@@ -53,6 +70,10 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		c.Set("user_role", claims.Role)
 		c.Set("token_type", claims.TokenType)
 		c.Set("jwt_claims", claims)
+		// With JWT_CUSTOM_CLAIMS including language, the preference comes without a lookup
+		if language, ok := claims.Custom["language"].(string); ok {
+			setPreferredLanguage(c, language)
+		}
 
 		c.Next()
 	}
@@ -119,9 +140,13 @@ func (m *AuthMiddleware) RequireEmailVerified() gin.HandlerFunc {
 		}
 
 		if !profile.EmailVerified {
-			c.JSON(http.StatusForbidden, domain.ErrorResponse{
+			c.JSON(http.StatusForbidden, CodedError(c, apperrors.CodeEmailNotVerified, domain.ErrorResponse{
 				Error: "email verification required",
-			})
+				Details: map[string]string{
+					"reason":      "email_not_verified",
+					"resend_path": "/api/auth/resend-verification",
+				},
+			}))
 			c.Abort()
 			return
 		}
@@ -161,6 +186,7 @@ func (m *AuthMiddleware) RequireActiveUser() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		setPreferredLanguage(c, profile.Preferences.Language)
 
 		if profile.Status != domain.StatusActive {
 			c.JSON(http.StatusForbidden, domain.ErrorResponse{
@@ -170,10 +196,35 @@ func (m *AuthMiddleware) RequireActiveUser() gin.HandlerFunc {
 			return
 		}
 
+		if abortPendingSetup(c, profile) {
+			return
+		}
+
 		c.Next()
 	}
 }
 
+// abortPendingSetup refuses an account that hasn't finished its first-login setup, naming the step
+// left. Verifying the email and changing the password stay reachable, as they only require auth.
+func abortPendingSetup(c *gin.Context, profile *domain.UserResponse) bool {
+	reason := ""
+	switch profile.SetupError() {
+	case nil:
+		return false
+	case domain.ErrEmailNotVerified:
+		reason = "email_not_verified"
+	default:
+		reason = "password_change_required"
+	}
+
+	c.JSON(http.StatusForbidden, domain.ErrorResponse{
+		Error:   "account setup required",
+		Details: map[string]string{"reason": reason},
+	})
+	c.Abort()
+	return true
+}
+
 // extractToken extracts the token from the request
 // Checks in order: Authorization header, access_token cookie
 func (m *AuthMiddleware) extractToken(c *gin.Context) string {
@@ -195,7 +246,7 @@ func (m *AuthMiddleware) extractToken(c *gin.Context) string {
 	return ""
 }
 
-// RequireRole middleware that requires a specific role
+// RequireRole middleware that requires a specific role or one above it
 func (m *AuthMiddleware) RequireRole(role domain.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, exists := c.Get("user_id")
@@ -227,7 +278,7 @@ func (m *AuthMiddleware) RequireRole(role domain.UserRole) gin.HandlerFunc {
 			return
 		}
 
-		if profile.Role != role {
+		if !domain.IsRoleAtLeast(profile.Role, role) {
 			c.JSON(http.StatusForbidden, domain.ErrorResponse{
 				Error: "insufficient permissions",
 			})
@@ -279,8 +330,8 @@ func (m *AuthMiddleware) RequireUserRole() gin.HandlerFunc {
 			return
 		}
 
-		// Check if user has valid role (user or admin)
-		if profile.Role != domain.RoleUser && profile.Role != domain.RoleAdmin {
+		// Check if user has a valid role (user or above)
+		if !domain.IsRoleAtLeast(profile.Role, domain.RoleUser) {
 			c.JSON(http.StatusForbidden, domain.ErrorResponse{
 				Error: "insufficient permissions",
 			})
@@ -336,8 +387,12 @@ func (m *AuthMiddleware) RequireActiveUserWithRole(role domain.UserRole) gin.Han
 			return
 		}
 
+		if abortPendingSetup(c, profile) {
+			return
+		}
+
 		// Check role
-		if profile.Role != role {
+		if !domain.IsRoleAtLeast(profile.Role, role) {
 			c.JSON(http.StatusForbidden, domain.ErrorResponse{
 				Error: "insufficient permissions",
 			})
@@ -381,6 +436,119 @@ func GetCurrentUserEmail(c *gin.Context) (string, bool) {
 	return userEmail, ok
 }
 
+// StepUpTokenHeader carries the token issued by POST /api/auth/step-up
+const StepUpTokenHeader = "X-Step-Up-Token"
+
+// RequireSuperAdminStepUp forces every change a super admin makes through step-up authentication
+// and audits it at warning level. Reads, and requests from other roles, pass through untouched.
+// The role is loaded fresh so a token issued before a promotion cannot skip the check.
+func (m *AuthMiddleware) RequireSuperAdminStepUp() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		uid, ok := c.Get("user_id")
+		userID, valid := uid.(uint)
+		if !ok || !valid {
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
+				Error: "authentication required",
+			})
+			c.Abort()
+			return
+		}
+
+		profile, err := m.authService.GetUserProfile(userID)
+		if err != nil {
+			m.logger.Error("failed to get user profile for step-up check", "user_id", userID, "error", err)
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse{
+				Error: "failed to verify user role",
+			})
+			c.Abort()
+			return
+		}
+
+		if profile.Role != domain.RoleSuperAdmin {
+			c.Next()
+			return
+		}
+
+		if err := m.authService.VerifyStepUp(userID, c.GetHeader(StepUpTokenHeader)); err != nil {
+			m.logger.Warn("super admin action without step-up", "user_id", userID, "path", c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{
+				Error:   "step-up authentication required",
+				Details: map[string]string{"reason": "step_up_required"},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		m.authService.RecordSuperAdminAction(
+			userID,
+			c.Request.Method,
+			c.Request.URL.Path,
+			c.Writer.Status(),
+			c.ClientIP(),
+			c.Request.UserAgent(),
+		)
+		c.Set(requestAuditedKey, true)
+	}
+}
+
+// RequireStepUp asks for step-up authentication on every request, reads included
+func (m *AuthMiddleware) RequireStepUp() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := c.Get("user_id")
+		userID, _ := uid.(uint)
+		if err := m.authService.VerifyStepUp(userID, c.GetHeader(StepUpTokenHeader)); err != nil {
+			m.logger.Warn("request without step-up to a route that always requires it", "user_id", userID, "path", c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{
+				Error:   "step-up authentication required",
+				Details: map[string]string{"reason": "step_up_required"},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireStepUpWhen asks every user for step-up authentication on changes while required reports
+// true, e.g. while a security alert has tightened access to role changes. Reads pass through.
+func (m *AuthMiddleware) RequireStepUpWhen(required func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if !required() {
+			c.Next()
+			return
+		}
+
+		uid, _ := c.Get("user_id")
+		userID, _ := uid.(uint)
+		if err := m.authService.VerifyStepUp(userID, c.GetHeader(StepUpTokenHeader)); err != nil {
+			m.logger.Warn("change without step-up while a security response is active", "user_id", userID, "path", c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, domain.ErrorResponse{
+				Error:   "step-up authentication required",
+				Details: map[string]string{"reason": "step_up_required"},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // GetCurrentUserProfile is a helper function to get the current user profile from context
 func GetCurrentUserProfile(c *gin.Context) (*domain.UserResponse, bool) {
 	profile, exists := c.Get("user_profile")
@@ -398,13 +566,13 @@ func IsAuthenticated(c *gin.Context) bool {
 	return exists
 }
 
-// IsAdmin checks if the current user has admin role
+// IsAdmin checks if the current user has admin role or above
 func IsAdmin(c *gin.Context) bool {
 	profile, exists := GetCurrentUserProfile(c)
 	if !exists {
 		return false
 	}
-	return profile.Role == domain.RoleAdmin
+	return domain.IsRoleAtLeast(profile.Role, domain.RoleAdmin)
 }
 
 // IsActiveUser checks if the current user is active
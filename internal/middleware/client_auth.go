@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+)
+
+// RequireClientKey only lets through requests whose "Authorization: Bearer <key>" header carries one
+// of the given client keys. These routes serve other services, not users, so with no keys configured
+// they don't exist.
+func RequireClientKey(keys []string, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(keys) == 0 {
+			c.JSON(http.StatusNotFound, domain.ErrorResponse{Error: "not found"})
+			c.Abort()
+			return
+		}
+
+		presented, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || !matchesClientKey(presented, keys) {
+			logger.Warn("invalid client key", "ip", c.ClientIP(), "path", c.FullPath())
+			c.Header("WWW-Authenticate", `Bearer realm="introspection"`)
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{Error: "invalid client credentials"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// matchesClientKey compares the presented key with every configured key in constant time
+func matchesClientKey(presented string, keys []string) bool {
+	matched := 0
+	for _, key := range keys {
+		matched |= subtle.ConstantTimeCompare([]byte(presented), []byte(key))
+	}
+	return matched == 1
+}
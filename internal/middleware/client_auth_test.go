@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireClientKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name          string
+		keys          []string
+		authorization string
+		code          int
+	}{
+		{"valid key", []string{"gateway-key", "sidecar-key"}, "Bearer sidecar-key", http.StatusOK},
+		{"wrong key", []string{"gateway-key"}, "Bearer other-key", http.StatusUnauthorized},
+		{"missing key", []string{"gateway-key"}, "", http.StatusUnauthorized},
+		{"not a bearer key", []string{"gateway-key"}, "gateway-key", http.StatusUnauthorized},
+		{"no keys configured", nil, "Bearer gateway-key", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/introspect", RequireClientKey(tt.keys, logger), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/introspect", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"mime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JSONContentType gives every application/json response the same Content-Type, whichever handler,
+// middleware or error path wrote it. Other responses, such as downloads and event streams, keep their own.
+func JSONContentType(contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer = &jsonContentTypeWriter{ResponseWriter: c.Writer, contentType: contentType}
+		c.Next()
+	}
+}
+
+// jsonContentTypeWriter rewrites a JSON Content-Type just before the headers are sent
+type jsonContentTypeWriter struct {
+	gin.ResponseWriter
+	contentType string
+}
+
+func (w *jsonContentTypeWriter) setContentType() {
+	if w.Written() {
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err == nil && mediaType == "application/json" {
+		w.Header().Set("Content-Type", w.contentType)
+	}
+}
+
+func (w *jsonContentTypeWriter) WriteHeaderNow() {
+	w.setContentType()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *jsonContentTypeWriter) Write(data []byte) (int, error) {
+	w.setContentType()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *jsonContentTypeWriter) WriteString(s string) (int, error) {
+	w.setContentType()
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/errors"
+)
+
+const jsonUTF8 = "application/json; charset=utf-8"
+
+// newContentTypeRouter serves the routes with JSONContentType ahead of the middleware under test
+func newContentTypeRouter(contentType string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JSONContentType(contentType))
+	return router
+}
+
+func TestJSONContentTypeOnSuccessAndErrors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{FeatureFlags: config.FeatureFlags{CSRFProtection: true}}
+	rl := NewRateLimiter(logger, nil, clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)), 1, time.Minute)
+
+	router := newContentTypeRouter(jsonUTF8)
+	router.Use(errors.ErrorMiddleware(logger, "test"))
+	router.Use(InputSanitization(cfg, logger))
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) }
+	router.GET("/ok", ok)
+	router.POST("/csrf", CSRFProtection(cfg, logger), ok)
+	router.POST("/limited", rl.AuthRateLimit(), ok)
+	router.GET("/panic", Recovery(logger), func(c *gin.Context) { panic("boom") })
+	router.GET("/plain", func(c *gin.Context) {
+		c.Header("Content-Type", "application/json")
+		c.String(http.StatusBadRequest, `{"error":"bad"}`)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"success", http.MethodGet, "/ok", http.StatusOK},
+		{"CSRF rejection", http.MethodPost, "/csrf", http.StatusForbidden},
+		{"rate limit", http.MethodPost, "/limited", http.StatusTooManyRequests},
+		{"sanitization", http.MethodGet, "/ok?q=%3Cscript%3E", http.StatusBadRequest},
+		{"panic", http.MethodGet, "/panic", http.StatusInternalServerError},
+		{"JSON without a charset", http.MethodGet, "/plain", http.StatusBadRequest},
+	}
+
+	// Use up the rate limit, so the request under test is refused
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/limited", nil))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, jsonUTF8, w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestJSONContentTypeConfigured(t *testing.T) {
+	router := newContentTypeRouter("application/json")
+	router.GET("/error", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, domain.ErrorResponse{Error: "not found"})
+	})
+	router.GET("/head", HeadWithoutBody(), func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/error", nil))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/head", nil))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
+
+func TestJSONContentTypeLeavesOtherResponses(t *testing.T) {
+	router := newContentTypeRouter(jsonUTF8)
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.String(http.StatusOK, ": heartbeat\n\n")
+	})
+	router.GET("/problem", func(c *gin.Context) {
+		c.Header("Content-Type", "application/problem+json")
+		c.String(http.StatusBadRequest, "{}")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/problem", nil))
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+}
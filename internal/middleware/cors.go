@@ -2,21 +2,58 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/acheevo/tfa/internal/shared/config"
 )
 
-func CORS() gin.HandlerFunc {
+// DefaultCORSMethods are advertised for paths that don't belong to a restricted route group
+var DefaultCORSMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// SecureCORS applies CORS headers for allowed origins. groupMethods maps a route group prefix to
+// the methods that group serves, so preflights only advertise what the group actually accepts.
+func SecureCORS(config *config.Config, groupMethods map[string][]string) gin.HandlerFunc {
+	allowedMethods := make(map[string]string, len(groupMethods))
+	for prefix, methods := range groupMethods {
+		allowedMethods[strings.TrimSuffix(prefix, "/")] = strings.Join(methods, ", ")
+	}
+	defaultMethods := strings.Join(DefaultCORSMethods, ", ")
+	maxAge := strconv.Itoa(int(config.CORSMaxAgeDuration().Seconds()))
+
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
-		c.Header("Access-Control-Allow-Origin", origin)
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers",
-			"Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Length")
+
+		// The response depends on the request origin, so shared caches must key on it
+		c.Writer.Header().Add("Vary", "Origin")
+
+		// Check if origin is allowed
+		if isAllowedOrigin(origin, config.GetCORSOrigins()) {
+			c.Header("Access-Control-Allow-Origin", origin)
+		} else if config.IsDevelopment() {
+			// In development, be more permissive
+			c.Header("Access-Control-Allow-Origin", "*")
+		}
+
+		methods := defaultMethods
+		if groupMethods, ok := matchRouteGroup(c.Request.URL.Path, allowedMethods); ok {
+			methods = groupMethods
+		}
+
 		c.Header("Access-Control-Allow-Credentials", "true")
+		c.Header("Access-Control-Allow-Headers",
+			"Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, "+
+				"accept, origin, Cache-Control, X-Requested-With, X-API-Key, X-Step-Up-Token, X-Device-ID")
+		c.Header("Access-Control-Allow-Methods", methods)
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID, X-Trace-ID, "+CSRFHeader)
+		c.Header("Access-Control-Max-Age", maxAge)
 
-		if c.Request.Method == "OPTIONS" {
+		// Handle preflight requests
+		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
@@ -24,3 +61,31 @@ func CORS() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// matchRouteGroup returns the value for the longest group prefix that contains path
+func matchRouteGroup(path string, groups map[string]string) (string, bool) {
+	var (
+		best  string
+		value string
+		found bool
+	)
+	for prefix, v := range groups {
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		if !found || len(prefix) > len(best) {
+			best, value, found = prefix, v, true
+		}
+	}
+	return value, found
+}
+
+// isAllowedOrigin checks if an origin is in the allowed list
+func isAllowedOrigin(origin string, allowedOrigins []string) bool {
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
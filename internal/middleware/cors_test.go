@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func serveCORS(cfg *config.Config, groupMethods map[string][]string, method, path, origin string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(SecureCORS(cfg, groupMethods))
+	router.GET("/api/admin/users", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Origin", origin)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSecureCORSVaryOrigin(t *testing.T) {
+	cfg := &config.Config{Environment: "production", CORSOrigins: "https://app.example.com"}
+
+	allowed := serveCORS(cfg, nil, http.MethodGet, "/api/admin/users", "https://app.example.com")
+	assert.Equal(t, "https://app.example.com", allowed.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, allowed.Header().Values("Vary"), "Origin")
+
+	// Rejected origins must vary too, or a cache could replay an allowed response to them
+	rejected := serveCORS(cfg, nil, http.MethodGet, "/api/admin/users", "https://evil.example.com")
+	assert.Empty(t, rejected.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, rejected.Header().Values("Vary"), "Origin")
+}
+
+func TestSecureCORSGroupMethods(t *testing.T) {
+	cfg := &config.Config{Environment: "production", CORSOrigins: "https://app.example.com"}
+	groups := map[string][]string{
+		"/api/admin":       {http.MethodGet, http.MethodDelete, http.MethodOptions},
+		"/api/admin/email": {http.MethodGet, http.MethodOptions},
+	}
+
+	admin := serveCORS(cfg, groups, http.MethodOptions, "/api/admin/users", "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, admin.Code)
+	assert.Equal(t, "GET, DELETE, OPTIONS", admin.Header().Get("Access-Control-Allow-Methods"))
+
+	// The most specific group wins
+	email := serveCORS(cfg, groups, http.MethodOptions, "/api/admin/email/queue", "https://app.example.com")
+	assert.Equal(t, "GET, OPTIONS", email.Header().Get("Access-Control-Allow-Methods"))
+
+	// Prefixes only match whole path segments
+	other := serveCORS(cfg, groups, http.MethodOptions, "/api/administrator", "https://app.example.com")
+	assert.Equal(t, "GET, POST, PUT, PATCH, DELETE, OPTIONS", other.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestSecureCORSMaxAge(t *testing.T) {
+	defaults := serveCORS(&config.Config{}, nil, http.MethodOptions, "/api/admin/users", "")
+	assert.Equal(t, "86400", defaults.Header().Get("Access-Control-Max-Age"))
+
+	custom := serveCORS(&config.Config{CORSMaxAge: "10m"}, nil, http.MethodOptions, "/api/admin/users", "")
+	assert.Equal(t, "600", custom.Header().Get("Access-Control-Max-Age"))
+
+	disabled := serveCORS(&config.Config{CORSMaxAge: "0"}, nil, http.MethodOptions, "/api/admin/users", "")
+	assert.Equal(t, "0", disabled.Header().Get("Access-Control-Max-Age"))
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeadWithoutBody lets a GET handler answer HEAD requests. The response keeps the status and headers
+// the handler sets, with the Content-Length its body would have had, but the body is dropped.
+func HeadWithoutBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &headWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if !c.Writer.Written() {
+			c.Header("Content-Length", strconv.Itoa(writer.size))
+			c.Writer.WriteHeaderNow()
+		}
+	}
+}
+
+// headWriter counts the body written to it instead of sending it
+type headWriter struct {
+	gin.ResponseWriter
+	size int
+}
+
+func (w *headWriter) Write(data []byte) (int, error) {
+	w.size += len(data)
+	return len(data), nil
+}
+
+func (w *headWriter) WriteString(s string) (int, error) {
+	w.size += len(s)
+	return len(s), nil
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeadWithoutBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unhealthy"})
+	}
+
+	router := gin.New()
+	router.GET("/health", handler)
+	router.HEAD("/health", HeadWithoutBody(), handler)
+
+	get := httptest.NewRecorder()
+	router.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	head := httptest.NewRecorder()
+	router.ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/health", nil))
+
+	// Same status and headers as GET, but no body
+	assert.Equal(t, http.StatusServiceUnavailable, head.Code)
+	assert.Empty(t, head.Body.String())
+	assert.Equal(t, "no-store", head.Header().Get("Cache-Control"))
+	assert.Equal(t, get.Header().Get("Content-Type"), head.Header().Get("Content-Type"))
+	assert.Equal(t, strconv.Itoa(get.Body.Len()), head.Header().Get("Content-Length"))
+}
@@ -1,13 +1,32 @@
 package middleware
 
 import (
+	"hash/fnv"
 	"log/slog"
+	"math/rand"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/acheevo/tfa/internal/shared/config"
 )
 
-func Logger(logger *slog.Logger) gin.HandlerFunc {
+// sampleBuckets is the resolution of the access log sample rate
+const sampleBuckets = 10000
+
+// Logger writes an access log entry per request. Errors and slow requests are always logged;
+// other requests are sampled at ACCESS_LOG_SAMPLE_RATE to keep log volume down under load.
+func Logger(config *config.Config, logger *slog.Logger) gin.HandlerFunc {
+	rate := config.AccessLogSampleRate
+	slow := config.AccessLogSlowThresholdDuration()
+
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		requestID, _ := param.Keys["request_id"].(string)
+		if !shouldLogRequest(param.StatusCode, param.Latency, slow, rate, requestID) {
+			return ""
+		}
+
 		logger.Info("HTTP request",
 			"method", param.Method,
 			"path", param.Path,
@@ -15,7 +34,36 @@ func Logger(logger *slog.Logger) gin.HandlerFunc {
 			"latency", param.Latency,
 			"client_ip", param.ClientIP,
 			"user_agent", param.Request.UserAgent(),
+			"request_id", requestID,
 		)
 		return ""
 	})
 }
+
+// shouldLogRequest decides whether a finished request is written to the access log
+func shouldLogRequest(status int, latency, slow time.Duration, rate float64, requestID string) bool {
+	if status >= http.StatusBadRequest || latency >= slow {
+		return true
+	}
+	return sampleRequest(requestID, rate)
+}
+
+// sampleRequest keeps roughly rate of all requests. The choice is derived from the request ID,
+// so the access log and anything else sampled by ID keep the same requests.
+func sampleRequest(requestID string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+
+	threshold := uint64(rate * sampleBuckets)
+	if requestID == "" {
+		return uint64(rand.Intn(sampleBuckets)) < threshold
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(requestID))
+	return hash.Sum64()%sampleBuckets < threshold
+}
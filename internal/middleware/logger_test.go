@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func TestShouldLogRequestAlwaysLogsErrorsAndSlowRequests(t *testing.T) {
+	slow := time.Second
+
+	assert.True(t, shouldLogRequest(http.StatusNotFound, time.Millisecond, slow, 0, "req-1"))
+	assert.True(t, shouldLogRequest(http.StatusInternalServerError, time.Millisecond, slow, 0, "req-1"))
+	assert.True(t, shouldLogRequest(http.StatusOK, 2*time.Second, slow, 0, "req-1"))
+	assert.False(t, shouldLogRequest(http.StatusOK, time.Millisecond, slow, 0, "req-1"))
+	assert.True(t, shouldLogRequest(http.StatusOK, time.Millisecond, slow, 1, "req-1"))
+}
+
+func TestSampleRequestApproximatesRate(t *testing.T) {
+	for _, rate := range []float64{0.01, 0.1, 0.5} {
+		kept := 0
+		total := 20000
+		for i := 0; i < total; i++ {
+			if sampleRequest(fmt.Sprintf("req-%d", i), rate) {
+				kept++
+			}
+		}
+		assert.InDelta(t, rate, float64(kept)/float64(total), 0.02, "rate %v", rate)
+	}
+}
+
+func TestSampleRequestIsDeterministic(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		requestID := fmt.Sprintf("req-%d", i)
+		assert.Equal(t, sampleRequest(requestID, 0.3), sampleRequest(requestID, 0.3))
+	}
+}
+
+func TestLoggerSampling(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	cfg := &config.Config{AccessLogSampleRate: 0, AccessLogSlowThreshold: "1h"}
+
+	router := gin.New()
+	router.Use(RequestID(), Logger(cfg, logger))
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+
+	for _, path := range []string{"/ok", "/fail"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Request-ID", "req-"+strings.TrimPrefix(path, "/"))
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.NotContains(t, buf.String(), "path=/ok")
+	assert.Contains(t, buf.String(), "path=/fail")
+	assert.Contains(t, buf.String(), "request_id=req-fail")
+}
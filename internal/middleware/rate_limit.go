@@ -3,23 +3,36 @@ package middleware
 import (
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	admindomain "github.com/acheevo/tfa/internal/admin/domain"
 	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	applogger "github.com/acheevo/tfa/internal/shared/logger"
 )
 
 // RateLimiter implements a simple in-memory rate limiter
 type RateLimiter struct {
 	logger          *slog.Logger
+	securityLogger  *applogger.SecurityLogger
+	clock           clock.Clock
 	visitors        map[string]*visitor
 	mu              sync.RWMutex
 	rate            int           // requests per window
 	window          time.Duration // time window
 	cleanupInterval time.Duration // cleanup interval
+	tightenedUntil  time.Time     // login attempts are limited to half the rate until then
+
+	// prefixLimits replaces rate for keys starting with one of the prefixes
+	prefixLimits map[string]int
 }
 
 type visitor struct {
@@ -29,10 +42,19 @@ type visitor struct {
 }
 
 // NewRateLimiter creates a new rate limiter
-func NewRateLimiter(logger *slog.Logger, rate int, window time.Duration) *RateLimiter {
+func NewRateLimiter(
+	logger *slog.Logger,
+	securityLogger *applogger.SecurityLogger,
+	clk clock.Clock,
+	rate int,
+	window time.Duration,
+) *RateLimiter {
 	rl := &RateLimiter{
 		logger:          logger,
+		securityLogger:  securityLogger,
+		clock:           clk,
 		visitors:        make(map[string]*visitor),
+		prefixLimits:    make(map[string]int),
 		rate:            rate,
 		window:          window,
 		cleanupInterval: time.Minute * 5, // cleanup every 5 minutes
@@ -70,13 +92,21 @@ func (rl *RateLimiter) LoginRateLimit() gin.HandlerFunc {
 		ipKey := fmt.Sprintf("login:%s", c.ClientIP())
 		if !rl.allow(ipKey) {
 			rl.logger.Warn("login rate limit exceeded by IP", "ip", c.ClientIP())
+			c.Header("Retry-After", strconv.Itoa(rl.retryAfterSeconds(ipKey)))
 			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse{
-				Error: "too many login attempts, please try again later",
+				Error:   "too many login attempts, please try again later",
+				Details: map[string]string{"reason": "account_locked"},
 			})
 			c.Abort()
 			return
 		}
 
+		// This attempt used up the last slot, so further attempts are locked out until the window resets
+		if rl.GetRemainingRequests(ipKey) == 0 {
+			rl.securityLogger.Log(applogger.SecurityEventLockout, "login attempts locked out",
+				"ip_address", c.ClientIP(), "resets_at", rl.GetResetTime(ipKey))
+		}
+
 		c.Next()
 	}
 }
@@ -100,13 +130,77 @@ func (rl *RateLimiter) PasswordResetRateLimit() gin.HandlerFunc {
 	}
 }
 
+// EmailQueueRateLimit limits how often an operator can trigger email queue processing by hand
+func (rl *RateLimiter) EmailQueueRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := fmt.Sprintf("email_queue:%s", c.ClientIP())
+		if userID, exists := c.Get("user_id"); exists {
+			key = fmt.Sprintf("email_queue:user:%v", userID)
+		}
+
+		if !rl.allow(key) {
+			rl.logger.Warn("email queue processing rate limit exceeded", "key", key)
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse{
+				Error: "too many queue processing requests, please try again later",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AdminAccountEmailRateLimit limits how often an admin can send account emails to the same user,
+// so a support agent can't flood someone's inbox
+func (rl *RateLimiter) AdminAccountEmailRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		key := fmt.Sprintf("admin_account_email:%v:%s", userID, c.Param("id"))
+
+		if !rl.allow(key) {
+			rl.logger.Warn("admin account email rate limit exceeded", "key", key)
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse{
+				Error: "too many emails sent to this user, please try again later",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// EmailAvailabilityRateLimit limits email availability checks to limit per window for each IP, well
+// under the general auth limit, so the endpoint is too slow to enumerate registered addresses
+func (rl *RateLimiter) EmailAvailabilityRateLimit(limit int) gin.HandlerFunc {
+	rl.mu.Lock()
+	rl.prefixLimits["email_available:"] = max(limit, 1)
+	rl.mu.Unlock()
+
+	return func(c *gin.Context) {
+		key := fmt.Sprintf("email_available:%s", c.ClientIP())
+		if !rl.allow(key) {
+			rl.logger.Warn("email availability rate limit exceeded", "ip", c.ClientIP())
+			c.Header("Retry-After", strconv.Itoa(rl.retryAfterSeconds(key)))
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse{
+				Error: "too many email checks, please try again later",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // allow checks if a request is allowed based on the rate limit
 func (rl *RateLimiter) allow(key string) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
 	v, exists := rl.visitors[key]
-	now := time.Now()
+	now := rl.clock.Now()
 
 	if !exists {
 		rl.visitors[key] = &visitor{
@@ -126,7 +220,7 @@ func (rl *RateLimiter) allow(key string) bool {
 	}
 
 	// Check if rate limit exceeded
-	if v.count >= rl.rate {
+	if v.count >= rl.limitFor(key, now) {
 		v.lastSeen = now
 		return false
 	}
@@ -137,6 +231,36 @@ func (rl *RateLimiter) allow(key string) bool {
 	return true
 }
 
+// TightenLoginLimits halves the login attempts allowed per window until the given time; the zero
+// time lifts it. It is the automatic response to a spike in failed logins.
+func (rl *RateLimiter) TightenLoginLimits(until time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if until.Equal(rl.tightenedUntil) {
+		return
+	}
+	rl.tightenedUntil = until
+	if until.IsZero() {
+		rl.logger.Info("login rate limits restored")
+		return
+	}
+	rl.logger.Warn("login rate limits tightened", "until", until, "attempts_per_window", max(rl.rate/2, 1))
+}
+
+// limitFor returns the requests allowed per window for a key; the caller must hold the lock
+func (rl *RateLimiter) limitFor(key string, now time.Time) int {
+	if strings.HasPrefix(key, "login:") && now.Before(rl.tightenedUntil) {
+		return max(rl.rate/2, 1)
+	}
+	for prefix, limit := range rl.prefixLimits {
+		if strings.HasPrefix(key, prefix) {
+			return limit
+		}
+	}
+	return rl.rate
+}
+
 // getKey generates a key for the rate limiter based on IP
 func (rl *RateLimiter) getKey(c *gin.Context) string {
 	return fmt.Sprintf("auth:%s", c.ClientIP())
@@ -157,7 +281,7 @@ func (rl *RateLimiter) cleanupExpiredEntries() {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	now := time.Now()
+	now := rl.clock.Now()
 	cutoff := now.Add(-rl.window * 2) // Keep entries for 2 windows
 
 	for key, v := range rl.visitors {
@@ -172,23 +296,36 @@ func (rl *RateLimiter) GetRemainingRequests(key string) int {
 	rl.mu.RLock()
 	defer rl.mu.RUnlock()
 
+	now := rl.clock.Now()
+	limit := rl.limitFor(key, now)
+
 	v, exists := rl.visitors[key]
 	if !exists {
-		return rl.rate
+		return limit
 	}
 
 	// If window has passed, return full rate
-	if time.Now().After(v.resetTime) {
-		return rl.rate
+	if now.After(v.resetTime) {
+		return limit
 	}
 
-	remaining := rl.rate - v.count
+	remaining := limit - v.count
 	if remaining < 0 {
 		return 0
 	}
 	return remaining
 }
 
+// retryAfterSeconds returns the whole seconds until the rate limit resets for a key, at least 1
+func (rl *RateLimiter) retryAfterSeconds(key string) int {
+	wait := rl.GetResetTime(key).Sub(rl.clock.Now())
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
 // GetResetTime returns when the rate limit will reset for a key
 func (rl *RateLimiter) GetResetTime(key string) time.Time {
 	rl.mu.RLock()
@@ -196,8 +333,33 @@ func (rl *RateLimiter) GetResetTime(key string) time.Time {
 
 	v, exists := rl.visitors[key]
 	if !exists {
-		return time.Now()
+		return rl.clock.Now()
 	}
 
 	return v.resetTime
 }
+
+// CurrentLockouts returns the login keys that have exhausted their attempts in the current window
+func (rl *RateLimiter) CurrentLockouts() []admindomain.LoginLockout {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	now := rl.clock.Now()
+	lockouts := make([]admindomain.LoginLockout, 0)
+	for key, v := range rl.visitors {
+		if !strings.HasPrefix(key, "login:") || now.After(v.resetTime) || v.count < rl.limitFor(key, now) {
+			continue
+		}
+		lockouts = append(lockouts, admindomain.LoginLockout{
+			Key:      strings.TrimPrefix(key, "login:"),
+			Attempts: v.count,
+			ResetsAt: v.resetTime,
+		})
+	}
+
+	sort.Slice(lockouts, func(i, j int) bool {
+		return lockouts[i].ResetsAt.After(lockouts[j].ResetsAt)
+	})
+
+	return lockouts
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+)
+
+func TestRateLimiterWindow(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	rl := NewRateLimiter(logger, nil, clk, 3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, rl.allow("login:10.0.0.1"))
+	}
+	assert.False(t, rl.allow("login:10.0.0.1"))
+	assert.Equal(t, 0, rl.GetRemainingRequests("login:10.0.0.1"))
+
+	lockouts := rl.CurrentLockouts()
+	assert.Len(t, lockouts, 1)
+	assert.Equal(t, "10.0.0.1", lockouts[0].Key)
+
+	// The lockout lifts once the window has passed
+	clk.Advance(time.Minute + time.Second)
+	assert.Empty(t, rl.CurrentLockouts())
+	assert.True(t, rl.allow("login:10.0.0.1"))
+	assert.Equal(t, 2, rl.GetRemainingRequests("login:10.0.0.1"))
+}
+
+func TestLoginRateLimitRetryAfter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	rl := NewRateLimiter(logger, nil, clk, 1, time.Minute)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/login", rl.LoginRateLimit(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	login := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, login().Code)
+
+	clk.Advance(20 * time.Second)
+	w := login()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "40", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "account_locked")
+}
+
+func TestAdminAccountEmailRateLimitPerTarget(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	rl := NewRateLimiter(logger, nil, clk, 1, time.Minute)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/users/:id/send-reset", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+	}, rl.AdminAccountEmailRateLimit(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(target string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/"+target+"/send-reset", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("5"))
+	assert.Equal(t, http.StatusTooManyRequests, send("5"))
+	// Other users are limited separately
+	assert.Equal(t, http.StatusOK, send("6"))
+
+	clk.Advance(time.Minute + time.Second)
+	assert.Equal(t, http.StatusOK, send("5"))
+}
+
+func TestTightenLoginLimits(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	rl := NewRateLimiter(logger, nil, clk, 4, time.Minute)
+
+	rl.TightenLoginLimits(clk.Now().Add(10 * time.Minute))
+
+	assert.Equal(t, 2, rl.GetRemainingRequests("login:10.0.0.1"))
+	assert.True(t, rl.allow("login:10.0.0.1"))
+	assert.True(t, rl.allow("login:10.0.0.1"))
+	assert.False(t, rl.allow("login:10.0.0.1"))
+	assert.Len(t, rl.CurrentLockouts(), 1)
+
+	// Other limits keep the full rate
+	for i := 0; i < 4; i++ {
+		assert.True(t, rl.allow("password_reset:10.0.0.1"))
+	}
+
+	clk.Advance(10*time.Minute + time.Second)
+	assert.Equal(t, 4, rl.GetRemainingRequests("login:10.0.0.1"))
+
+	// Lifting it early restores the full rate straight away
+	rl.TightenLoginLimits(clk.Now().Add(time.Hour))
+	assert.Equal(t, 2, rl.GetRemainingRequests("login:10.0.0.2"))
+	rl.TightenLoginLimits(time.Time{})
+	assert.Equal(t, 4, rl.GetRemainingRequests("login:10.0.0.2"))
+}
+
+func TestEmailAvailabilityRateLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	rl := NewRateLimiter(logger, nil, clk, 10, time.Minute)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/email-available", rl.EmailAvailabilityRateLimit(3), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	check := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/email-available?email=a@example.com", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The check has its own limit, far below the general rate
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, check("10.0.0.1").Code)
+	}
+	w := check("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Other IPs and other keys are unaffected
+	assert.Equal(t, http.StatusOK, check("10.0.0.2").Code)
+	assert.Equal(t, 10, rl.GetRemainingRequests("auth:10.0.0.1"))
+
+	clk.Advance(time.Minute + time.Second)
+	assert.Equal(t, http.StatusOK, check("10.0.0.1").Code)
+}
@@ -9,19 +9,47 @@ import (
 
 	"github.com/acheevo/tfa/internal/auth/domain"
 	"github.com/acheevo/tfa/internal/auth/service"
+	applogger "github.com/acheevo/tfa/internal/shared/logger"
+)
+
+// Authorization decision logging modes
+const (
+	DecisionLogOff      = "off"
+	DecisionLogDebug    = "debug"
+	DecisionLogSecurity = "security"
+)
+
+// Authorization decision reasons
+const (
+	decisionGranted          = "granted"
+	decisionOwnResource      = "own_resource"
+	decisionNoRole           = "no_role_in_context"
+	decisionLacksPermission  = "role_lacks_permission"
+	decisionRoleMismatch     = "role_mismatch"
+	decisionRoleBelowMinimum = "role_below_minimum"
 )
 
 // RBACMiddleware provides role-based access control middleware
 type RBACMiddleware struct {
-	logger      *slog.Logger
-	authService *service.AuthService
+	logger         *slog.Logger
+	securityLogger *applogger.SecurityLogger
+	authService    *service.AuthService
+	decisionLog    string
 }
 
-// NewRBACMiddleware creates a new RBAC middleware
-func NewRBACMiddleware(logger *slog.Logger, authService *service.AuthService) *RBACMiddleware {
+// NewRBACMiddleware creates a new RBAC middleware. decisionLog selects where every
+// authorization decision is recorded: off, debug (application log) or security (security log).
+func NewRBACMiddleware(
+	logger *slog.Logger,
+	securityLogger *applogger.SecurityLogger,
+	authService *service.AuthService,
+	decisionLog string,
+) *RBACMiddleware {
 	return &RBACMiddleware{
-		logger:      logger,
-		authService: authService,
+		logger:         logger,
+		securityLogger: securityLogger,
+		authService:    authService,
+		decisionLog:    decisionLog,
 	}
 }
 
@@ -32,6 +60,7 @@ func (m *RBACMiddleware) RequirePermission(permission domain.Permission) gin.Han
 		userRole, exists := m.getUserRole(c)
 		if !exists {
 			m.logger.Warn("permission check failed: no user role in context", "permission", permission)
+			m.logDecision(c, permission, "", false, decisionNoRole)
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
 				Error: "authentication required",
 			})
@@ -48,6 +77,7 @@ func (m *RBACMiddleware) RequirePermission(permission domain.Permission) gin.Han
 				"required_permission", permission,
 				"path", c.Request.URL.Path,
 			)
+			m.logDecision(c, permission, userRole, false, decisionLacksPermission)
 
 			c.JSON(http.StatusForbidden, domain.ErrorResponse{
 				Error: "insufficient permissions",
@@ -56,6 +86,7 @@ func (m *RBACMiddleware) RequirePermission(permission domain.Permission) gin.Han
 			return
 		}
 
+		m.logDecision(c, permission, userRole, true, decisionGranted)
 		c.Next()
 	}
 }
@@ -66,6 +97,7 @@ func (m *RBACMiddleware) RequireAnyPermission(permissions []domain.Permission) g
 		userRole, exists := m.getUserRole(c)
 		if !exists {
 			m.logger.Warn("permission check failed: no user role in context", "permissions", permissions)
+			m.logDecision(c, permissions, "", false, decisionNoRole)
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
 				Error: "authentication required",
 			})
@@ -81,6 +113,7 @@ func (m *RBACMiddleware) RequireAnyPermission(permissions []domain.Permission) g
 				"required_permissions", permissions,
 				"path", c.Request.URL.Path,
 			)
+			m.logDecision(c, permissions, userRole, false, decisionLacksPermission)
 
 			c.JSON(http.StatusForbidden, domain.ErrorResponse{
 				Error: "insufficient permissions",
@@ -89,6 +122,7 @@ func (m *RBACMiddleware) RequireAnyPermission(permissions []domain.Permission) g
 			return
 		}
 
+		m.logDecision(c, permissions, userRole, true, decisionGranted)
 		c.Next()
 	}
 }
@@ -99,6 +133,7 @@ func (m *RBACMiddleware) RequireAllPermissions(permissions []domain.Permission)
 		userRole, exists := m.getUserRole(c)
 		if !exists {
 			m.logger.Warn("permission check failed: no user role in context", "permissions", permissions)
+			m.logDecision(c, permissions, "", false, decisionNoRole)
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
 				Error: "authentication required",
 			})
@@ -114,6 +149,7 @@ func (m *RBACMiddleware) RequireAllPermissions(permissions []domain.Permission)
 				"required_permissions", permissions,
 				"path", c.Request.URL.Path,
 			)
+			m.logDecision(c, permissions, userRole, false, decisionLacksPermission)
 
 			c.JSON(http.StatusForbidden, domain.ErrorResponse{
 				Error: "insufficient permissions",
@@ -122,6 +158,7 @@ func (m *RBACMiddleware) RequireAllPermissions(permissions []domain.Permission)
 			return
 		}
 
+		m.logDecision(c, permissions, userRole, true, decisionGranted)
 		c.Next()
 	}
 }
@@ -142,12 +179,13 @@ func (m *RBACMiddleware) RequireAdminAccess() gin.HandlerFunc {
 	return m.RequireRole(domain.RoleAdmin)
 }
 
-// RequireRole middleware that requires a specific role (enhanced version)
+// RequireRole middleware that requires a specific role or one above it (enhanced version)
 func (m *RBACMiddleware) RequireRole(role domain.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := m.getUserRole(c)
 		if !exists {
 			m.logger.Warn("role check failed: no user role in context", "required_role", role)
+			m.logDecision(c, role, "", false, decisionNoRole)
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
 				Error: "authentication required",
 			})
@@ -155,7 +193,7 @@ func (m *RBACMiddleware) RequireRole(role domain.UserRole) gin.HandlerFunc {
 			return
 		}
 
-		if userRole != role {
+		if !domain.IsRoleAtLeast(userRole, role) {
 			userID, _ := c.Get("user_id")
 			m.logger.Warn("role check failed",
 				"user_id", userID,
@@ -163,6 +201,7 @@ func (m *RBACMiddleware) RequireRole(role domain.UserRole) gin.HandlerFunc {
 				"required_role", role,
 				"path", c.Request.URL.Path,
 			)
+			m.logDecision(c, role, userRole, false, decisionRoleMismatch)
 
 			c.JSON(http.StatusForbidden, domain.ErrorResponse{
 				Error: "insufficient role permissions",
@@ -171,6 +210,7 @@ func (m *RBACMiddleware) RequireRole(role domain.UserRole) gin.HandlerFunc {
 			return
 		}
 
+		m.logDecision(c, role, userRole, true, decisionGranted)
 		c.Next()
 	}
 }
@@ -181,6 +221,7 @@ func (m *RBACMiddleware) RequireMinimumRole(minRole domain.UserRole) gin.Handler
 		userRole, exists := m.getUserRole(c)
 		if !exists {
 			m.logger.Warn("role check failed: no user role in context", "minimum_role", minRole)
+			m.logDecision(c, minRole, "", false, decisionNoRole)
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
 				Error: "authentication required",
 			})
@@ -197,6 +238,7 @@ func (m *RBACMiddleware) RequireMinimumRole(minRole domain.UserRole) gin.Handler
 				"minimum_role", minRole,
 				"path", c.Request.URL.Path,
 			)
+			m.logDecision(c, minRole, userRole, false, decisionRoleBelowMinimum)
 
 			c.JSON(http.StatusForbidden, domain.ErrorResponse{
 				Error: "insufficient role level",
@@ -205,6 +247,7 @@ func (m *RBACMiddleware) RequireMinimumRole(minRole domain.UserRole) gin.Handler
 			return
 		}
 
+		m.logDecision(c, minRole, userRole, true, decisionGranted)
 		c.Next()
 	}
 }
@@ -214,6 +257,7 @@ func (m *RBACMiddleware) RequireOwnResourceOrPermission(permission domain.Permis
 	return func(c *gin.Context) {
 		userID, exists := m.getCurrentUserID(c)
 		if !exists {
+			m.logDecision(c, permission, "", false, decisionNoRole)
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse{
 				Error: "authentication required",
 			})
@@ -244,6 +288,7 @@ func (m *RBACMiddleware) RequireOwnResourceOrPermission(permission domain.Permis
 
 		// Allow if accessing own resource
 		if userID == uint(targetID) {
+			m.logDecision(c, permission, userRole, true, decisionOwnResource)
 			c.Next()
 			return
 		}
@@ -257,6 +302,7 @@ func (m *RBACMiddleware) RequireOwnResourceOrPermission(permission domain.Permis
 				"required_permission", permission,
 				"path", c.Request.URL.Path,
 			)
+			m.logDecision(c, permission, userRole, false, decisionLacksPermission)
 
 			c.JSON(http.StatusForbidden, domain.ErrorResponse{
 				Error: "insufficient permissions",
@@ -265,6 +311,7 @@ func (m *RBACMiddleware) RequireOwnResourceOrPermission(permission domain.Permis
 			return
 		}
 
+		m.logDecision(c, permission, userRole, true, decisionGranted)
 		c.Next()
 	}
 }
@@ -290,6 +337,35 @@ func (m *RBACMiddleware) WithPermissionLogging() gin.HandlerFunc {
 
 // Helper functions
 
+// logDecision records the outcome of an authorization check when decision logging is enabled
+func (m *RBACMiddleware) logDecision(c *gin.Context, required any, role domain.UserRole, allowed bool, reason string) {
+	if m.decisionLog == "" || m.decisionLog == DecisionLogOff {
+		return
+	}
+
+	outcome := "denied"
+	if allowed {
+		outcome = "allowed"
+	}
+
+	userID, _ := c.Get("user_id")
+	args := []any{
+		"user_id", userID,
+		"user_role", role,
+		"required", required,
+		"outcome", outcome,
+		"reason", reason,
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+	}
+
+	if m.decisionLog == DecisionLogSecurity {
+		m.securityLogger.Log(applogger.SecurityEventAuthzDecision, "authorization decision", args...)
+		return
+	}
+	m.logger.Debug("authorization decision", args...)
+}
+
 // getUserRole gets the user role from the context
 func (m *RBACMiddleware) getUserRole(c *gin.Context) (domain.UserRole, bool) {
 	// First try to get from JWT claims if available
@@ -367,13 +443,13 @@ func HasCurrentUserPermission(c *gin.Context, permission domain.Permission) bool
 	return domain.HasPermission(role, permission)
 }
 
-// IsCurrentUserAdmin checks if the current user is an admin
+// IsCurrentUserAdmin checks if the current user is an admin or super admin
 func IsCurrentUserAdmin(c *gin.Context) bool {
 	role, exists := GetRoleFromContext(c)
 	if !exists {
 		return false
 	}
-	return role == domain.RoleAdmin
+	return domain.IsRoleAtLeast(role, domain.RoleAdmin)
 }
 
 // Convenience middleware functions
@@ -397,3 +473,8 @@ func (m *RBACMiddleware) RequireProfileAccess() gin.HandlerFunc {
 func (m *RBACMiddleware) RequireAuditAccess() gin.HandlerFunc {
 	return m.RequirePermission(domain.PermissionAuditRead)
 }
+
+// RequireSecurityAccess requires security report access
+func (m *RBACMiddleware) RequireSecurityAccess() gin.HandlerFunc {
+	return m.RequirePermission(domain.PermissionSecurityRead)
+}
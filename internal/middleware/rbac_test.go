@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	applogger "github.com/acheevo/tfa/internal/shared/logger"
+)
+
+// serveWithRole runs handler for a request made by a user with the given role
+func serveWithRole(role domain.UserRole, handler gin.HandlerFunc) int {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/resource", func(c *gin.Context) {
+		c.Set("user_id", uint(42))
+		c.Set("jwt_claims", &domain.JWTClaims{UserID: 42, Role: role})
+		c.Next()
+	}, handler, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/resource", nil))
+	return w.Code
+}
+
+func decodeEvents(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var events []map[string]any
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var event map[string]any
+		if !assert.NoError(t, decoder.Decode(&event)) {
+			break
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestRBACDecisionLoggingSecuritySink(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var buf bytes.Buffer
+	securityLogger := applogger.NewSecurityLoggerWithWriter(&buf, nil, nil)
+	m := NewRBACMiddleware(logger, securityLogger, nil, DecisionLogSecurity)
+
+	code := serveWithRole(domain.RoleUser, m.RequirePermission(domain.PermissionAuditRead))
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code = serveWithRole(domain.RoleAdmin, m.RequirePermission(domain.PermissionAuditRead))
+	assert.Equal(t, http.StatusOK, code)
+
+	events := decodeEvents(t, &buf)
+	if assert.Len(t, events, 2) {
+		denied := events[0]
+		assert.Equal(t, "authz_decision", denied["event"])
+		assert.Equal(t, "denied", denied["outcome"])
+		assert.Equal(t, "role_lacks_permission", denied["reason"])
+		assert.Equal(t, string(domain.PermissionAuditRead), denied["required"])
+		assert.Equal(t, string(domain.RoleUser), denied["user_role"])
+
+		assert.Equal(t, "allowed", events[1]["outcome"])
+		assert.Equal(t, "granted", events[1]["reason"])
+	}
+}
+
+func TestRBACDecisionLoggingDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	m := NewRBACMiddleware(logger, nil, nil, DecisionLogDebug)
+
+	code := serveWithRole(domain.RoleUser, m.RequireRole(domain.RoleAdmin))
+	assert.Equal(t, http.StatusForbidden, code)
+
+	var decision map[string]any
+	for _, event := range decodeEvents(t, &buf) {
+		if event["msg"] == "authorization decision" {
+			decision = event
+		}
+	}
+	if assert.NotNil(t, decision) {
+		assert.Equal(t, "DEBUG", decision["level"])
+		assert.Equal(t, "denied", decision["outcome"])
+		assert.Equal(t, "role_mismatch", decision["reason"])
+	}
+}
+
+func TestRBACDecisionLoggingOff(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	securityLogger := applogger.NewSecurityLoggerWithWriter(&buf, nil, nil)
+	m := NewRBACMiddleware(logger, securityLogger, nil, DecisionLogOff)
+
+	code := serveWithRole(domain.RoleUser, m.RequirePermission(domain.PermissionAuditRead))
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Empty(t, buf.String())
+}
+
+func TestRequireRoleAcceptsHigherRoles(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := NewRBACMiddleware(logger, nil, nil, DecisionLogOff)
+
+	assert.Equal(t, http.StatusOK, serveWithRole(domain.RoleSuperAdmin, m.RequireAdminAccess()))
+	assert.Equal(t, http.StatusOK, serveWithRole(domain.RoleAdmin, m.RequireAdminAccess()))
+	assert.Equal(t, http.StatusForbidden, serveWithRole(domain.RoleUser, m.RequireAdminAccess()))
+	assert.Equal(t, http.StatusForbidden, serveWithRole(domain.RoleAdmin, m.RequireRole(domain.RoleSuperAdmin)))
+}
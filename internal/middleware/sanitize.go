@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+// Sanitization modes for each category of input
+const (
+	SanitizeOff   = "off"
+	SanitizeLog   = "log"
+	SanitizeBlock = "block"
+)
+
+// DefaultSuspiciousQueryPatterns are script injection markers checked in query values.
+// Path traversal is checked against the request path instead, where it actually matters.
+var DefaultSuspiciousQueryPatterns = []string{
+	"<script",
+	"javascript:",
+	"vbscript:",
+	"data:text/html",
+	"<iframe",
+	"<object",
+	"<embed",
+	"document.cookie",
+	"document.write",
+}
+
+// DefaultScannerUserAgents are tokens of well-known vulnerability scanners. They are matched
+// against whole User-Agent tokens so names like "Zapier" don't trip the "zap" entry.
+var DefaultScannerUserAgents = []string{
+	"nikto", "sqlmap", "nmap", "masscan", "zap", "burp", "acunetix",
+	"nessus", "openvas", "w3af", "havij", "grabber",
+}
+
+// DefaultDangerousHeaders override the request URL or method in some proxies and frameworks
+// and have no legitimate use against this API
+var DefaultDangerousHeaders = []string{
+	"X-Original-URL",
+	"X-Rewrite-URL",
+	"X-HTTP-Method-Override",
+}
+
+// InputSanitization middleware inspects query values, the request path, the User-Agent and
+// known-dangerous headers, logging or rejecting suspicious input per the configured mode
+func InputSanitization(cfg *config.Config, logger *slog.Logger) gin.HandlerFunc {
+	queryPatterns := lowerAll(withDefault(cfg.GetSanitizeQueryPatterns(), DefaultSuspiciousQueryPatterns))
+	scannerAgents := lowerAll(withDefault(cfg.GetSanitizeUserAgentPatterns(), DefaultScannerUserAgents))
+	headers := withDefault(cfg.GetSanitizeHeaders(), DefaultDangerousHeaders)
+
+	queryMode := sanitizeMode(cfg.SanitizeQueryMode, SanitizeBlock)
+	pathMode := sanitizeMode(cfg.SanitizePathMode, SanitizeBlock)
+	userAgentMode := sanitizeMode(cfg.SanitizeUserAgentMode, SanitizeLog)
+	headerMode := sanitizeMode(cfg.SanitizeHeaderMode, SanitizeLog)
+
+	// flag logs the finding and reports whether the request should be rejected
+	flag := func(c *gin.Context, mode, category, message string, args ...any) bool {
+		args = append(args,
+			"category", category,
+			"mode", mode,
+			"ip", c.ClientIP(),
+			"path", c.Request.URL.Path,
+		)
+		logger.Warn(message, args...)
+		return mode == SanitizeBlock
+	}
+
+	return func(c *gin.Context) {
+		if pathMode != SanitizeOff && hasTraversalSegment(c.Request.URL.Path) {
+			if flag(c, pathMode, "path", "Path traversal detected") {
+				reject(c, http.StatusBadRequest, "invalid request path")
+				return
+			}
+		}
+
+		if queryMode != SanitizeOff {
+			for key, values := range c.Request.URL.Query() {
+				for _, value := range values {
+					if !containsAny(strings.ToLower(value), queryPatterns) {
+						continue
+					}
+					if flag(c, queryMode, "query", "Suspicious query parameter detected", "key", key, "value", value) {
+						reject(c, http.StatusBadRequest, "invalid request parameters")
+						return
+					}
+				}
+			}
+		}
+
+		if userAgentMode != SanitizeOff {
+			userAgent := c.Request.UserAgent()
+			if isScannerUserAgent(userAgent, scannerAgents) {
+				if flag(c, userAgentMode, "user_agent", "Suspicious User-Agent detected", "user_agent", userAgent) {
+					reject(c, http.StatusForbidden, "request blocked")
+					return
+				}
+			}
+		}
+
+		if headerMode != SanitizeOff {
+			for _, header := range headers {
+				if c.GetHeader(header) == "" {
+					continue
+				}
+				if flag(c, headerMode, "header", "Dangerous request header detected", "header", http.CanonicalHeaderKey(header)) {
+					reject(c, http.StatusBadRequest, "invalid request headers")
+					return
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// reject aborts the request with an error response
+func reject(c *gin.Context, status int, message string) {
+	c.JSON(status, domain.ErrorResponse{Error: message})
+	c.Abort()
+}
+
+// hasTraversalSegment reports whether a decoded path contains a ".." segment
+func hasTraversalSegment(path string) bool {
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if segment == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// isScannerUserAgent matches whole User-Agent tokens against the scanner list
+func isScannerUserAgent(userAgent string, scanners []string) bool {
+	tokens := strings.FieldsFunc(strings.ToLower(userAgent), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, token := range tokens {
+		for _, scanner := range scanners {
+			if token == scanner {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsAny(value string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(value, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizeMode(mode, fallback string) string {
+	switch mode {
+	case SanitizeOff, SanitizeLog, SanitizeBlock:
+		return mode
+	default:
+		return fallback
+	}
+}
+
+func withDefault(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+	return values
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
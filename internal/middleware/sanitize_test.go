@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+// serveSanitized runs a request through InputSanitization and returns the status and log output
+func serveSanitized(cfg *config.Config, req *http.Request) (int, string) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(InputSanitization(cfg, logger))
+	router.NoRoute(func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code, logs.String()
+}
+
+func TestInputSanitizationAllowsBenignInput(t *testing.T) {
+	requests := []*http.Request{
+		// Dots and slashes in values are legitimate outside the path
+		httptest.NewRequest(http.MethodGet, "/api/admin/users?search=../reports&sort=created_at", nil),
+		httptest.NewRequest(http.MethodGet, "/api/files/v1..2/notes", nil),
+		httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs?q=onerror%3Dretry", nil),
+	}
+	zapier := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	zapier.Header.Set("User-Agent", "Zapier/1.0 (+https://zapier.com)")
+	requests = append(requests, zapier)
+
+	for _, req := range requests {
+		code, logs := serveSanitized(&config.Config{}, req)
+		assert.Equal(t, http.StatusOK, code, req.URL.String())
+		assert.Empty(t, logs, req.URL.String())
+	}
+}
+
+func TestInputSanitizationBlocksMaliciousInput(t *testing.T) {
+	query := httptest.NewRequest(http.MethodGet, "/api/admin/users?search=%3Cscript%3Ealert(1)%3C/script%3E", nil)
+	code, _ := serveSanitized(&config.Config{}, query)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	traversal := httptest.NewRequest(http.MethodGet, "/api/files/..%2F..%2Fetc/passwd", nil)
+	code, _ = serveSanitized(&config.Config{}, traversal)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestInputSanitizationModes(t *testing.T) {
+	scanner := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		req.Header.Set("User-Agent", "sqlmap/1.7 (https://sqlmap.org)")
+		return req
+	}
+
+	// User-Agents are only logged by default
+	code, logs := serveSanitized(&config.Config{}, scanner())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, logs, "Suspicious User-Agent detected")
+
+	code, _ = serveSanitized(&config.Config{SanitizeUserAgentMode: SanitizeBlock}, scanner())
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, logs = serveSanitized(&config.Config{SanitizeUserAgentMode: SanitizeOff}, scanner())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, logs)
+
+	query := httptest.NewRequest(http.MethodGet, "/api/users?q=javascript:void(0)", nil)
+	code, logs = serveSanitized(&config.Config{SanitizeQueryMode: SanitizeLog}, query)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, logs, "Suspicious query parameter detected")
+}
+
+func TestInputSanitizationHeaders(t *testing.T) {
+	override := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	override.Header.Set("X-Original-URL", "/api/admin/users")
+
+	code, logs := serveSanitized(&config.Config{SanitizeHeaderMode: SanitizeBlock}, override)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, logs, "X-Original-Url")
+
+	// A custom header list replaces the defaults
+	custom := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	custom.Header.Set("X-Original-URL", "/api/admin/users")
+	custom.Header.Set("X-Debug", "1")
+	code, logs = serveSanitized(&config.Config{SanitizeHeaderMode: SanitizeBlock, SanitizeHeaders: "X-Debug"}, custom)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, logs, "X-Debug")
+	assert.NotContains(t, logs, "X-Original-Url")
+}
+
+func TestInputSanitizationCustomPatterns(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/users?q=DROP%20TABLE", nil)
+	code, _ := serveSanitized(&config.Config{SanitizeQueryPatterns: "drop table"}, req)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
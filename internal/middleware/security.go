@@ -129,25 +129,25 @@ func CSRFProtection(config *config.Config, logger *slog.Logger) gin.HandlerFunc
 // getCSRFToken extracts CSRF token from request
 func getCSRFToken(c *gin.Context) string {
 	// Try header first
-	token := c.GetHeader("X-CSRF-Token")
+	token := c.GetHeader(CSRFHeader)
 	if token != "" {
 		return token
 	}
 
 	// Try form field
-	token = c.PostForm("_csrf_token")
+	token = c.PostForm(CSRFCookieName)
 	if token != "" {
 		return token
 	}
 
 	// Try query parameter (less secure, only for specific cases)
-	return c.Query("_csrf_token")
+	return c.Query(CSRFCookieName)
 }
 
 // validateCSRFToken validates a CSRF token
 func validateCSRFToken(c *gin.Context, token string) bool {
 	// Get the expected token from cookie
-	cookie, err := c.Request.Cookie("_csrf_token")
+	cookie, err := c.Request.Cookie(CSRFCookieName)
 	if err != nil {
 		return false
 	}
@@ -156,25 +156,36 @@ func validateCSRFToken(c *gin.Context, token string) bool {
 	return subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) == 1
 }
 
-// GenerateCSRFToken generates a new CSRF token
-func GenerateCSRFToken(c *gin.Context, config *config.Config) string {
+// CSRFCookieName holds the double-submit CSRF token; CSRFHeader carries it back on unsafe requests
+const (
+	CSRFCookieName = "_csrf_token"
+	CSRFHeader     = "X-CSRF-Token"
+)
+
+// GenerateCSRFToken sets a new CSRF token cookie, replacing any earlier one, and returns the token
+func GenerateCSRFToken(c *gin.Context, secure bool) string {
 	// Generate a random token
 	token := generateSecureToken()
 
 	// Set cookie with the token
 	c.SetCookie(
-		"_csrf_token",
+		CSRFCookieName,
 		token,
 		3600, // 1 hour
 		"/",
-		"",                    // domain
-		config.IsProduction(), // secure
-		true,                  // httpOnly
+		"",     // domain
+		secure, // secure
+		true,   // httpOnly
 	)
 
 	return token
 }
 
+// ClearCSRFToken expires the CSRF token cookie, so a token can't outlive the session it was issued for
+func ClearCSRFToken(c *gin.Context, secure bool) {
+	c.SetCookie(CSRFCookieName, "", -1, "/", "", secure, true)
+}
+
 // isSafeMethod checks if HTTP method is safe (doesn't modify state)
 func isSafeMethod(method string) bool {
 	safeMethods := []string{"GET", "HEAD", "OPTIONS", "TRACE"}
@@ -272,139 +283,6 @@ func TrustedProxies(config *config.Config, logger *slog.Logger) gin.HandlerFunc
 	}
 }
 
-// InputSanitization middleware provides basic input sanitization
-func InputSanitization(logger *slog.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Check for potentially malicious patterns in query parameters
-		for key, values := range c.Request.URL.Query() {
-			for _, value := range values {
-				if containsSuspiciousContent(value) {
-					logger.Warn("Suspicious query parameter detected",
-						"key", key,
-						"value", value,
-						"ip", c.ClientIP(),
-						"user_agent", c.Request.UserAgent(),
-						"path", c.Request.URL.Path,
-					)
-
-					errors.AbortWithError(c, errors.BadRequest("Invalid request parameters"))
-					return
-				}
-			}
-		}
-
-		// Check User-Agent for suspicious patterns
-		userAgent := c.Request.UserAgent()
-		if containsSuspiciousUserAgent(userAgent) {
-			logger.Warn("Suspicious User-Agent detected",
-				"user_agent", userAgent,
-				"ip", c.ClientIP(),
-				"path", c.Request.URL.Path,
-			)
-		}
-
-		c.Next()
-	}
-}
-
-// containsSuspiciousContent checks for common attack patterns
-func containsSuspiciousContent(content string) bool {
-	suspiciousPatterns := []string{
-		"<script",
-		"javascript:",
-		"onload=",
-		"onerror=",
-		"eval(",
-		"alert(",
-		"document.cookie",
-		"document.write",
-		"../",
-		"..\\",
-		"<iframe",
-		"<object",
-		"<embed",
-		"data:text/html",
-		"vbscript:",
-	}
-
-	lowerContent := strings.ToLower(content)
-	for _, pattern := range suspiciousPatterns {
-		if strings.Contains(lowerContent, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
-
-// containsSuspiciousUserAgent checks for suspicious user agents
-func containsSuspiciousUserAgent(userAgent string) bool {
-	suspiciousPatterns := []string{
-		"nikto",
-		"sqlmap",
-		"nmap",
-		"masscan",
-		"zap",
-		"burp",
-		"acunetix",
-		"nessus",
-		"openvas",
-		"w3af",
-		"havij",
-		"grabber",
-	}
-
-	lowerUA := strings.ToLower(userAgent)
-	for _, pattern := range suspiciousPatterns {
-		if strings.Contains(lowerUA, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
-
-// CORS middleware with security considerations
-func SecureCORS(config *config.Config) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-
-		// Check if origin is allowed
-		if isAllowedOrigin(origin, config.GetCORSOrigins()) {
-			c.Header("Access-Control-Allow-Origin", origin)
-		} else if config.IsDevelopment() {
-			// In development, be more permissive
-			c.Header("Access-Control-Allow-Origin", "*")
-		}
-
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers",
-			"Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, "+
-				"accept, origin, Cache-Control, X-Requested-With, X-API-Key")
-		c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
-		c.Header("Access-Control-Expose-Headers", "X-Request-ID, X-Trace-ID")
-		c.Header("Access-Control-Max-Age", "86400") // 24 hours
-
-		// Handle preflight requests
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
-		}
-
-		c.Next()
-	}
-}
-
-// isAllowedOrigin checks if an origin is in the allowed list
-func isAllowedOrigin(origin string, allowedOrigins []string) bool {
-	for _, allowed := range allowedOrigins {
-		if allowed == "*" || allowed == origin {
-			return true
-		}
-	}
-	return false
-}
-
 // ContentLengthLimit middleware limits request body size
 func ContentLengthLimit(maxBytes int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -432,7 +310,7 @@ func ComprehensiveSecurity(config *config.Config, logger *slog.Logger) gin.Handl
 		TraceID()(c)
 
 		// Apply input sanitization
-		InputSanitization(logger)(c)
+		InputSanitization(config, logger)(c)
 
 		// Apply trusted proxies validation
 		TrustedProxies(config, logger)(c)
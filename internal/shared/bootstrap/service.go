@@ -8,22 +8,30 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/acheevo/tfa/internal/auth/domain"
+	authservice "github.com/acheevo/tfa/internal/auth/service"
 	"github.com/acheevo/tfa/internal/shared/config"
 )
 
+// SetupMailer sends the setup link to an admin created with BOOTSTRAP_ADMIN_SETUP_REQUIRED
+type SetupMailer interface {
+	SendAdminSetup(email, token, firstName string) error
+}
+
 // Service handles bootstrap operations for the application
 type Service struct {
 	config *config.Config
 	db     *gorm.DB
 	logger *slog.Logger
+	mailer SetupMailer
 }
 
 // NewService creates a new bootstrap service
-func NewService(cfg *config.Config, db *gorm.DB, logger *slog.Logger) *Service {
+func NewService(cfg *config.Config, db *gorm.DB, logger *slog.Logger, mailer SetupMailer) *Service {
 	return &Service{
 		config: cfg,
 		db:     db,
 		logger: logger,
+		mailer: mailer,
 	}
 }
 
@@ -36,6 +44,10 @@ func (s *Service) Bootstrap() error {
 
 	s.logger.Info("starting bootstrap process")
 
+	if s.config.BootstrapAdminSetupRequired && !s.config.EmailEnabled {
+		s.logger.Warn("BOOTSTRAP_ADMIN_SETUP_REQUIRED ignored while email is disabled, bootstrap admins are created verified")
+	}
+
 	if err := s.createDemoUsers(); err != nil {
 		s.logger.Error("failed to create demo users", "error", err)
 		return err
@@ -45,7 +57,7 @@ func (s *Service) Bootstrap() error {
 	return nil
 }
 
-// createDemoUsers creates the demo admin and user accounts
+// createDemoUsers creates the demo admin and user accounts, plus the configured super admin
 func (s *Service) createDemoUsers() error {
 	// Create admin user
 	if err := s.createUserIfNotExists(
@@ -58,6 +70,19 @@ func (s *Service) createDemoUsers() error {
 		return err
 	}
 
+	// Create the break-glass super admin, when configured
+	if s.config.SuperAdminEmail != "" {
+		if err := s.createUserIfNotExists(
+			s.config.SuperAdminEmail,
+			s.config.SuperAdminPassword,
+			"Super",
+			"Admin",
+			domain.RoleSuperAdmin,
+		); err != nil {
+			return err
+		}
+	}
+
 	// Create demo user
 	if err := s.createUserIfNotExists(
 		s.config.DemoUserEmail,
@@ -75,8 +100,9 @@ func (s *Service) createDemoUsers() error {
 // createUserIfNotExists creates a user if they don't already exist
 func (s *Service) createUserIfNotExists(email, password, firstName, lastName string, role domain.UserRole) error {
 	// Check if user already exists
+	email = domain.NormalizeEmail(email)
 	var existingUser domain.User
-	err := s.db.Where("email = ?", email).First(&existingUser).Error
+	err := s.db.Where("LOWER(email) = ?", email).First(&existingUser).Error
 	if err == nil {
 		// User exists, check if role matches
 		if existingUser.Role != role {
@@ -109,7 +135,7 @@ func (s *Service) createUserIfNotExists(email, password, firstName, lastName str
 		LastName:      lastName,
 		Role:          role,
 		Status:        domain.StatusActive,
-		EmailVerified: true, // Bootstrap users are auto-verified
+		EmailVerified: true, // Bootstrap users are auto-verified, unless admin setup is required
 		Preferences: domain.UserPreferences{
 			Theme:    "light",
 			Language: "en",
@@ -127,14 +153,45 @@ func (s *Service) createUserIfNotExists(email, password, firstName, lastName str
 		},
 	}
 
+	setup, err := s.applyAdminSetup(user)
+	if err != nil {
+		return err
+	}
+
 	if err := s.db.Create(user).Error; err != nil {
 		return err
 	}
 
 	s.logger.Info("created bootstrap user", "email", email, "role", role, "id", user.ID)
+
+	if setup {
+		// A failed send isn't fatal: the admin can sign in and ask for the link again
+		if err := s.mailer.SendAdminSetup(user.Email, user.EmailVerifyToken, user.FirstName); err != nil {
+			s.logger.Error("failed to send bootstrap admin setup email", "email", email, "error", err)
+		}
+		s.logger.Info("bootstrap admin must verify email and change password", "email", email)
+	}
 	return nil
 }
 
+// applyAdminSetup leaves a new admin unverified and requiring a password change when
+// BOOTSTRAP_ADMIN_SETUP_REQUIRED is on and email can reach them, reporting whether it did
+func (s *Service) applyAdminSetup(user *domain.User) (bool, error) {
+	if !s.config.BootstrapAdminSetupRequired || !s.config.EmailEnabled || !user.IsAdmin() {
+		return false, nil
+	}
+
+	token, err := authservice.NewLinkToken(s.config)
+	if err != nil {
+		return false, err
+	}
+
+	user.EmailVerified = false
+	user.EmailVerifyToken = token
+	user.PasswordChangeRequired = true
+	return true, nil
+}
+
 // DropDemoUsers removes demo users (useful for testing)
 func (s *Service) DropDemoUsers() error {
 	emails := []string{s.config.AdminEmail, s.config.DemoUserEmail}
@@ -0,0 +1,73 @@
+package bootstrap
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func TestApplyAdminSetup(t *testing.T) {
+	newUser := func(role domain.UserRole) *domain.User {
+		return &domain.User{Email: "admin@example.com", Role: role, EmailVerified: true}
+	}
+	newService := func(cfg *config.Config) *Service {
+		return NewService(cfg, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		s := newService(&config.Config{BootstrapAdminSetupRequired: true, EmailEnabled: true})
+
+		for _, role := range []domain.UserRole{domain.RoleAdmin, domain.RoleSuperAdmin} {
+			user := newUser(role)
+			setup, err := s.applyAdminSetup(user)
+
+			assert.NoError(t, err)
+			assert.True(t, setup, role)
+			assert.False(t, user.EmailVerified)
+			assert.NotEmpty(t, user.EmailVerifyToken)
+			assert.True(t, user.PasswordChangeRequired)
+		}
+	})
+
+	t.Run("demo user is left ready to use", func(t *testing.T) {
+		s := newService(&config.Config{BootstrapAdminSetupRequired: true, EmailEnabled: true})
+		user := newUser(domain.RoleUser)
+
+		setup, err := s.applyAdminSetup(user)
+
+		assert.NoError(t, err)
+		assert.False(t, setup)
+		assert.True(t, user.EmailVerified)
+		assert.False(t, user.PasswordChangeRequired)
+	})
+
+	t.Run("email disabled falls back to a verified admin", func(t *testing.T) {
+		s := newService(&config.Config{BootstrapAdminSetupRequired: true, EmailEnabled: false})
+		user := newUser(domain.RoleAdmin)
+
+		setup, err := s.applyAdminSetup(user)
+
+		assert.NoError(t, err)
+		assert.False(t, setup)
+		assert.True(t, user.EmailVerified)
+		assert.Empty(t, user.EmailVerifyToken)
+		assert.False(t, user.PasswordChangeRequired)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s := newService(&config.Config{EmailEnabled: true})
+		user := newUser(domain.RoleAdmin)
+
+		setup, err := s.applyAdminSetup(user)
+
+		assert.NoError(t, err)
+		assert.False(t, setup)
+		assert.True(t, user.EmailVerified)
+		assert.False(t, user.PasswordChangeRequired)
+	})
+}
@@ -0,0 +1,17 @@
+// Package buildinfo holds metadata stamped into the binary at build time, for example:
+//
+//	go build -ldflags "-X github.com/acheevo/tfa/internal/shared/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/acheevo/tfa/internal/shared/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values left unset are empty; `make build` and the Dockerfile set all three.
+package buildinfo
+
+// Set with -ldflags "-X"; they must stay uninitialized string variables for that to work
+var (
+	// Version is the release version, overriding APP_VERSION when set
+	Version string
+	// Commit is the source control revision the binary was built from
+	Commit string
+	// BuildTime is when the binary was built, in RFC 3339
+	BuildTime string
+)
@@ -0,0 +1,77 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/httpclient"
+)
+
+// ErrInvalid is returned for a token the CAPTCHA service did not accept
+var ErrInvalid = errors.New("captcha token was not accepted")
+
+// maxResponseBytes bounds how much of a verification response is read
+const maxResponseBytes = 64 << 10
+
+// HTTPVerifier checks CAPTCHA tokens with a siteverify endpoint configured by CAPTCHA_VERIFY_URL.
+// reCAPTCHA, hCaptcha and Cloudflare Turnstile all take the same form fields and answer the same way.
+type HTTPVerifier struct {
+	verifyURL string
+	secret    string
+	client    *httpclient.Client
+}
+
+// siteverifyResponse is the part of a siteverify answer the verifier needs
+type siteverifyResponse struct {
+	Success bool `json:"success"`
+}
+
+// NewHTTPVerifier creates a verifier against the configured siteverify endpoint
+func NewHTTPVerifier(cfg *config.Config, client *httpclient.Client) *HTTPVerifier {
+	return &HTTPVerifier{
+		verifyURL: cfg.CaptchaVerifyURL,
+		secret:    cfg.CaptchaSecret,
+		client:    client,
+	}
+}
+
+// Verify checks a token solved by the client at remoteIP, returning ErrInvalid if it was rejected
+func (v *HTTPVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("build captcha request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("captcha request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha request: unexpected status %d", resp.StatusCode)
+	}
+
+	var body siteverifyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&body); err != nil {
+		return fmt.Errorf("decode captcha response: %w", err)
+	}
+	if !body.Success {
+		return ErrInvalid
+	}
+	return nil
+}
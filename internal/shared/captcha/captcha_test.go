@@ -0,0 +1,47 @@
+package captcha
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/httpclient"
+)
+
+func newTestHTTPVerifier(url string) *HTTPVerifier {
+	cfg := &config.Config{CaptchaVerifyURL: url, CaptchaSecret: "secret", HTTPClientTimeout: "1s"}
+	return NewHTTPVerifier(cfg, httpclient.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), "captcha", nil))
+}
+
+func TestHTTPVerifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("secret") != "secret" || r.PostFormValue("remoteip") != "203.0.113.7" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.PostFormValue("response") {
+		case "solved":
+			_, _ = w.Write([]byte(`{"success":true,"hostname":"example.com"}`))
+		case "unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+		}
+	}))
+	defer server.Close()
+
+	verifier := newTestHTTPVerifier(server.URL)
+
+	assert.NoError(t, verifier.Verify(context.Background(), "solved", "203.0.113.7"))
+	assert.Equal(t, ErrInvalid, verifier.Verify(context.Background(), "guessed", "203.0.113.7"))
+
+	err := verifier.Verify(context.Background(), "unavailable", "203.0.113.7")
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrInvalid, err)
+}
@@ -0,0 +1,28 @@
+package clock
+
+import "time"
+
+// Clock provides the current time. Time-dependent services take a Clock instead of
+// calling time.Now directly so that expiry and window logic can be tested deterministically.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+// Real is a Clock backed by the system time
+type Real struct{}
+
+// New returns a Clock backed by the system time
+func New() Clock {
+	return Real{}
+}
+
+// Now returns the current system time
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Since returns the time elapsed since t
+func (Real) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
@@ -0,0 +1,43 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Mock is a Clock whose time only moves when told to. It is safe for concurrent use.
+type Mock struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewMock creates a mock clock set to the given time
+func NewMock(now time.Time) *Mock {
+	return &Mock{now: now}
+}
+
+// Now returns the mock's current time
+func (m *Mock) Now() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.now
+}
+
+// Since returns the time elapsed since t according to the mock
+func (m *Mock) Since(t time.Time) time.Duration {
+	return m.Now().Sub(t)
+}
+
+// Set moves the mock to the given time
+func (m *Mock) Set(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+// Advance moves the mock forward by d
+func (m *Mock) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+}
@@ -0,0 +1,54 @@
+package concurrency
+
+import "sync"
+
+// Limiter caps how many operations of each kind run at once. It never queues: a caller either gets
+// a slot straight away or is told to come back later. Limits are per process, so with several
+// instances the cluster-wide ceiling is the limit times the instance count.
+type Limiter struct {
+	mu      sync.Mutex
+	limits  map[string]int
+	running map[string]int
+}
+
+// NewLimiter creates a limiter from per-operation limits. Operations without a positive limit are
+// not limited.
+func NewLimiter(limits map[string]int) *Limiter {
+	copied := make(map[string]int, len(limits))
+	for operation, limit := range limits {
+		copied[operation] = limit
+	}
+	return &Limiter{
+		limits:  copied,
+		running: make(map[string]int),
+	}
+}
+
+// TryAcquire takes a slot for the operation without waiting. When one is free it returns a release
+// func that must be called once the operation finishes; calling it more than once is harmless.
+func (l *Limiter) TryAcquire(operation string) (release func(), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limits[operation]
+	if limit > 0 && l.running[operation] >= limit {
+		return nil, false
+	}
+	l.running[operation]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.running[operation]--
+		})
+	}, true
+}
+
+// Running returns how many operations of the kind currently hold a slot
+func (l *Limiter) Running(operation string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.running[operation]
+}
@@ -0,0 +1,80 @@
+package concurrency
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiterRejectsOverLimit(t *testing.T) {
+	limiter := NewLimiter(map[string]int{"bulk": 2})
+
+	first, ok := limiter.TryAcquire("bulk")
+	assert.True(t, ok)
+	_, ok = limiter.TryAcquire("bulk")
+	assert.True(t, ok)
+
+	release, ok := limiter.TryAcquire("bulk")
+	assert.False(t, ok)
+	assert.Nil(t, release)
+	assert.Equal(t, 2, limiter.Running("bulk"))
+
+	// Releasing twice only frees one slot
+	first()
+	first()
+	assert.Equal(t, 1, limiter.Running("bulk"))
+
+	_, ok = limiter.TryAcquire("bulk")
+	assert.True(t, ok)
+	_, ok = limiter.TryAcquire("bulk")
+	assert.False(t, ok)
+}
+
+func TestLimiterOperationsAreIndependent(t *testing.T) {
+	limiter := NewLimiter(map[string]int{"bulk": 1, "broadcast": 1})
+
+	_, ok := limiter.TryAcquire("bulk")
+	assert.True(t, ok)
+	_, ok = limiter.TryAcquire("broadcast")
+	assert.True(t, ok)
+	_, ok = limiter.TryAcquire("bulk")
+	assert.False(t, ok)
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	limiter := NewLimiter(map[string]int{"merge": 0})
+
+	for i := 0; i < 50; i++ {
+		_, ok := limiter.TryAcquire("merge")
+		assert.True(t, ok)
+		_, ok = limiter.TryAcquire("unconfigured")
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 50, limiter.Running("merge"))
+}
+
+func TestLimiterConcurrentCallers(t *testing.T) {
+	limiter := NewLimiter(map[string]int{"bulk": 3})
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		acquired int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := limiter.TryAcquire("bulk"); ok {
+				mu.Lock()
+				acquired++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, acquired)
+	assert.Equal(t, 3, limiter.Running("bulk"))
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"mime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,6 +14,16 @@ import (
 
 const (
 	MaskedValue = "***"
+
+	// maxPasswordLoginGrace is the longest PASSWORD_LOGIN_GRACE allowed
+	maxPasswordLoginGrace = 24 * time.Hour
+)
+
+// Policies for PASSWORD_RESET_PASSWORDLESS
+const (
+	PasswordlessResetRefuse  = "refuse"
+	PasswordlessResetConfirm = "confirm"
+	PasswordlessResetAllow   = "allow"
 )
 
 type Config struct {
@@ -21,6 +34,22 @@ type Config struct {
 	AppName     string `envconfig:"APP_NAME" default:"Fullstack Template"`
 	Version     string `envconfig:"APP_VERSION" default:"1.0.0"`
 
+	// Info Endpoint Fields; comma-separated fields /api/info exposes, out of name, version, commit,
+	// build_time, go_version, environment and features. Empty exposes name, version, commit and
+	// build_time in production, so it doesn't describe the deployment, and every field elsewhere.
+	InfoFields string `envconfig:"INFO_FIELDS"`
+
+	// HTTP Server Limits (guard against slow or oversized requests)
+	ServerReadHeaderTimeout string `envconfig:"SERVER_READ_HEADER_TIMEOUT" default:"5s"`
+	ServerReadTimeout       string `envconfig:"SERVER_READ_TIMEOUT" default:"30s"`
+	ServerWriteTimeout      string `envconfig:"SERVER_WRITE_TIMEOUT" default:"30s"`
+	ServerIdleTimeout       string `envconfig:"SERVER_IDLE_TIMEOUT" default:"120s"`
+	ServerMaxHeaderBytes    int    `envconfig:"SERVER_MAX_HEADER_BYTES" default:"65536" validate:"omitempty,min=4096"`
+
+	// JSON Response Content-Type, set on every JSON response, success and error alike; a JSON media
+	// type, with or without a charset parameter
+	JSONContentType string `envconfig:"JSON_CONTENT_TYPE" default:"application/json; charset=utf-8"`
+
 	// Database Configuration
 	DatabaseHost     string `envconfig:"DATABASE_HOST" default:"localhost" validate:"required"`
 	DatabasePort     string `envconfig:"DATABASE_PORT" default:"5432" validate:"numeric"`
@@ -35,11 +64,27 @@ type Config struct {
 	DBConnMaxLifetime string `envconfig:"DB_CONN_MAX_LIFETIME" default:"1h" validate:"required"`
 	DBConnMaxIdleTime string `envconfig:"DB_CONN_MAX_IDLE_TIME" default:"30m"`
 
+	// Migration Lock: how long startup waits for another replica to finish migrating the schema
+	MigrationLockTimeout string `envconfig:"MIGRATION_LOCK_TIMEOUT" default:"5m"`
+
 	// JWT Configuration
 	JWTSecret               string `envconfig:"JWT_SECRET" default:"your-super-secret-jwt-key-change-this-in-production-32chars-min" validate:"min=32"`
 	JWTAccessTokenDuration  string `envconfig:"JWT_ACCESS_TOKEN_DURATION" default:"15m" validate:"required"`
 	JWTRefreshTokenDuration string `envconfig:"JWT_REFRESH_TOKEN_DURATION" default:"7d" validate:"required"`
 	JWTIssuer               string `envconfig:"JWT_ISSUER" default:"fullstack-template"`
+	// JWTLeeway tolerates this much clock skew when checking token expiry and issue times
+	JWTLeeway string `envconfig:"JWT_LEEWAY" default:"0s"`
+	// JWT Custom Claims; user attributes or "custom.<key>" preferences copied into access tokens under "ext"
+	JWTCustomClaims         string `envconfig:"JWT_CUSTOM_CLAIMS"`
+	JWTCustomClaimsMaxBytes int    `envconfig:"JWT_CUSTOM_CLAIMS_MAX_BYTES" default:"1024" validate:"omitempty,min=1"`
+
+	// Access Token Revocation; revoked access tokens are denylisted until they would have expired.
+	// "memory" keeps the denylist in each process, so with several replicas only the one that revoked
+	// the tokens rejects them; "redis" shares it between replicas. While Redis can't be reached, access
+	// tokens are rejected rather than risk accepting a revoked one.
+	TokenRevocationBackend     string `envconfig:"TOKEN_REVOCATION_BACKEND" default:"memory" validate:"omitempty,oneof=memory redis"`
+	TokenRevocationRedisURL    string `envconfig:"TOKEN_REVOCATION_REDIS_URL"`
+	TokenRevocationRedisPrefix string `envconfig:"TOKEN_REVOCATION_REDIS_PREFIX" default:"token_revocations"`
 
 	// Email Configuration
 	EmailEnabled  bool   `envconfig:"EMAIL_ENABLED" default:"false"`
@@ -47,6 +92,11 @@ type Config struct {
 	EmailFrom     string `envconfig:"EMAIL_FROM" default:"noreply@example.com"`
 	EmailFromName string `envconfig:"EMAIL_FROM_NAME" default:"App"`
 
+	// Email Critical Templates (comma-separated IDs); when one fails to render, as after a bad edit, a
+	// minimal built-in version is sent instead and the failure is logged as an error. Only
+	// email_verification, password_reset and welcome have built-in versions.
+	EmailCriticalTemplates string `envconfig:"EMAIL_CRITICAL_TEMPLATES" default:"email_verification,password_reset"`
+
 	// SMTP Configuration
 	SMTPHost         string `envconfig:"SMTP_HOST" default:"localhost"`
 	SMTPPort         int    `envconfig:"SMTP_PORT" default:"587" validate:"min=1,max=65535"`
@@ -55,6 +105,48 @@ type Config struct {
 	SMTPUseTLS       bool   `envconfig:"SMTP_USE_TLS" default:"true"`
 	SMTPSkipTLSCheck bool   `envconfig:"SMTP_SKIP_TLS_CHECK" default:"false"`
 
+	// Email Environment Tagging; the subject prefix defaults to "[<ENVIRONMENT>]" and is only
+	// applied in the listed environments
+	EmailSubjectPrefix             string `envconfig:"EMAIL_SUBJECT_PREFIX"`
+	EmailSubjectPrefixEnvironments string `envconfig:"EMAIL_SUBJECT_PREFIX_ENVIRONMENTS" default:"development,staging"`
+
+	// Email Broadcasts; suppressed recipients are addresses or "@domain" entries that never receive
+	// broadcasts, and provider rate limits are messages per minute as "provider=limit" pairs
+	EmailSuppressionList    string `envconfig:"EMAIL_SUPPRESSION_LIST"`
+	EmailProviderRateLimits string `envconfig:"EMAIL_PROVIDER_RATE_LIMITS" default:"smtp=100,sendgrid=600,postmark=300,mailgun=300"`
+	// A running broadcast that hasn't saved progress for this long is marked failed, as the instance
+	// running it is assumed gone; running broadcasts are checked as often
+	BroadcastStaleAfter string `envconfig:"BROADCAST_STALE_AFTER" default:"15m"`
+
+	// Email Send Window; when enabled, non-transactional email to a recipient with a known timezone is
+	// held until the recipient's local hours are within [start, end)
+	EmailSendWindowEnabled bool `envconfig:"EMAIL_SEND_WINDOW_ENABLED" default:"false"`
+	EmailSendWindowStart   int  `envconfig:"EMAIL_SEND_WINDOW_START" default:"9" validate:"min=0,max=23"`
+	EmailSendWindowEnd     int  `envconfig:"EMAIL_SEND_WINDOW_END" default:"17" validate:"max=24"`
+
+	// Email Retry Sweep; every interval ("0" disables it) failed emails attempted fewer than max retries
+	// times and created within the max age ("0" has no limit) are requeued. Permanent failures such as
+	// hard bounces are never retried.
+	EmailRetryInterval   string `envconfig:"EMAIL_RETRY_INTERVAL" default:"15m"`
+	EmailRetryMaxRetries int    `envconfig:"EMAIL_RETRY_MAX_RETRIES" default:"6" validate:"omitempty,min=1"`
+	EmailRetryMaxAge     string `envconfig:"EMAIL_RETRY_MAX_AGE" default:"24h"`
+
+	// Email Queue Monitoring; every interval ("0" disables it) the queue depth per status and the wait
+	// of the oldest due pending email are published as gauges, and an alert is raised when the pending
+	// emails reach the depth or the oldest has waited the age ("0" disables either threshold). The email
+	// health check reports degraded from the same depth.
+	EmailQueueMonitorInterval string `envconfig:"EMAIL_QUEUE_MONITOR_INTERVAL" default:"1m"`
+	EmailQueueAlertDepth      int    `envconfig:"EMAIL_QUEUE_ALERT_DEPTH" default:"1000" validate:"omitempty,min=0"`
+	EmailQueueAlertAge        string `envconfig:"EMAIL_QUEUE_ALERT_AGE" default:"30m"`
+
+	// Email Queue Backend; "database" keeps queued email in Postgres, "redis" moves it to Redis for
+	// high-volume senders. A Redis claim not marked sent or failed within the lease is handed out
+	// again, so delivery is at least once.
+	EmailQueueBackend     string `envconfig:"EMAIL_QUEUE_BACKEND" default:"database" validate:"omitempty,oneof=database redis"`
+	EmailQueueRedisURL    string `envconfig:"EMAIL_QUEUE_REDIS_URL"`
+	EmailQueueRedisPrefix string `envconfig:"EMAIL_QUEUE_REDIS_PREFIX" default:"email_queue"`
+	EmailQueueRedisLease  string `envconfig:"EMAIL_QUEUE_REDIS_LEASE" default:"10m"`
+
 	// Email Service Provider Keys
 	SendGridAPIKey string `envconfig:"SENDGRID_API_KEY"`
 	PostmarkAPIKey string `envconfig:"POSTMARK_API_KEY"`
@@ -65,19 +157,256 @@ type Config struct {
 	FrontendURL string `envconfig:"FRONTEND_URL" default:"http://localhost:3000" validate:"url"`
 	BackendURL  string `envconfig:"BACKEND_URL" default:"http://localhost:8080" validate:"url"`
 
+	// Link URL templates; {frontend_url} and {token} are substituted
+	EmailVerifyURLTemplate   string `envconfig:"EMAIL_VERIFY_URL_TEMPLATE" default:"{frontend_url}/verify-email?token={token}"`
+	PasswordResetURLTemplate string `envconfig:"PASSWORD_RESET_URL_TEMPLATE" default:"{frontend_url}/reset-password?token={token}"`
+	BackupEmailURLTemplate   string `envconfig:"BACKUP_EMAIL_URL_TEMPLATE" default:"{frontend_url}/confirm-backup-email?token={token}"`
+
+	// Link Tokens; random bytes from crypto/rand in each email verification and password reset token,
+	// hex or base64url encoded so the token embeds in a link unescaped. 32 bytes carry 256 bits of
+	// entropy; fewer than 16 (128 bits) are rejected.
+	LinkTokenBytes    int    `envconfig:"LINK_TOKEN_BYTES" default:"32" validate:"omitempty,min=16,max=128"`
+	LinkTokenEncoding string `envconfig:"LINK_TOKEN_ENCODING" default:"hex" validate:"omitempty,oneof=hex base64url"`
+
 	// Security Configuration
 	CSRFSecret       string `envconfig:"CSRF_SECRET" default:"your-super-secret-jwt-key-change-this-in-production-32chars-min" validate:"min=32"`
 	CORSOrigins      string `envconfig:"CORS_ORIGINS" default:"http://localhost:3000,http://localhost:8080"`
 	SecureHeaders    bool   `envconfig:"SECURE_HEADERS" default:"true"`
 	RateLimitEnabled bool   `envconfig:"RATE_LIMIT_ENABLED" default:"true"`
 
+	// Auth Cookie Secure Flag; "true" or "false" overrides the default of secure outside development
+	// and for any request that arrived over HTTPS directly or through a proxy
+	CookieSecure string `envconfig:"COOKIE_SECURE" validate:"omitempty,oneof=true false"`
+
+	// Input Sanitization (modes: off, log or block; empty pattern lists use the built-in defaults)
+	SanitizeQueryMode         string `envconfig:"SANITIZE_QUERY_MODE" default:"block" validate:"omitempty,oneof=off log block"`
+	SanitizePathMode          string `envconfig:"SANITIZE_PATH_MODE" default:"block" validate:"omitempty,oneof=off log block"`
+	SanitizeUserAgentMode     string `envconfig:"SANITIZE_USER_AGENT_MODE" default:"log" validate:"omitempty,oneof=off log block"`
+	SanitizeHeaderMode        string `envconfig:"SANITIZE_HEADER_MODE" default:"log" validate:"omitempty,oneof=off log block"`
+	SanitizeQueryPatterns     string `envconfig:"SANITIZE_QUERY_PATTERNS"`
+	SanitizeUserAgentPatterns string `envconfig:"SANITIZE_USER_AGENT_PATTERNS"`
+	SanitizeHeaders           string `envconfig:"SANITIZE_HEADERS"`
+
+	// CORS preflight cache lifetime ("0" disables caching)
+	CORSMaxAge string `envconfig:"CORS_MAX_AGE" default:"24h"`
+
+	// Login Verification Nudge Configuration
+	LoginVerificationNudge         bool   `envconfig:"LOGIN_VERIFICATION_NUDGE" default:"false"`
+	LoginVerificationNudgeInterval string `envconfig:"LOGIN_VERIFICATION_NUDGE_INTERVAL" default:"24h"`
+
+	// Verification Resend Limits; at most VerificationResendMax resends per account in each window (0 disables the cap)
+	VerificationResendMax    int    `envconfig:"VERIFICATION_RESEND_MAX" default:"3" validate:"min=0"`
+	VerificationResendWindow string `envconfig:"VERIFICATION_RESEND_WINDOW" default:"1h"`
+
+	// Email Verification Enforcement; login enforcement refuses tokens to unverified users, route
+	// enforcement lets them sign in but blocks the user and admin APIs. Either or both may be enabled.
+	RequireVerifiedEmailForLogin  bool `envconfig:"REQUIRE_VERIFIED_EMAIL_FOR_LOGIN" default:"false"`
+	RequireVerifiedEmailForRoutes bool `envconfig:"REQUIRE_VERIFIED_EMAIL_FOR_ROUTES" default:"false"`
+
+	// Registration Email Domains (comma-separated; "example.com" also matches subdomains,
+	// "*.example.com" matches only subdomains). An empty allowlist allows every domain.
+	RegistrationAllowedDomains  string `envconfig:"REGISTRATION_ALLOWED_DOMAINS"`
+	RegistrationBlockedDomains  string `envconfig:"REGISTRATION_BLOCKED_DOMAINS"`
+	RegistrationBlockDisposable bool   `envconfig:"REGISTRATION_BLOCK_DISPOSABLE" default:"false"`
+
+	// Usernames; optional at registration and changeable from the profile. Reserved names (comma-separated)
+	// can't be taken in any case or with separators added, so nobody poses as staff or shadows a route.
+	UsernameMinLength int    `envconfig:"USERNAME_MIN_LENGTH" default:"3" validate:"omitempty,min=1,max=64"`
+	UsernameMaxLength int    `envconfig:"USERNAME_MAX_LENGTH" default:"30" validate:"omitempty,min=1,max=64"`
+	UsernameReserved  string `envconfig:"USERNAME_RESERVED" default:"admin,administrator,root,superuser,system,support,help,helpdesk,security,staff,moderator,official,api,auth,login,logout,signup,register,account,settings,me,www,mail,email,postmaster,abuse,noreply,webmaster,billing,null,undefined"`
+
+	// Token Introspection; comma-separated keys that gateways present as "Authorization: Bearer <key>"
+	// to call /api/auth/introspect (empty disables the endpoint)
+	IntrospectionClientKeys string `envconfig:"INTROSPECTION_CLIENT_KEYS"`
+
+	// Email Availability Check; GET /api/auth/email-available tells the signup form whether an email is
+	// taken. It reveals which addresses are registered, so it is off by default and limited per IP per minute.
+	EmailAvailabilityCheck     bool `envconfig:"EMAIL_AVAILABILITY_CHECK" default:"false"`
+	EmailAvailabilityRateLimit int  `envconfig:"EMAIL_AVAILABILITY_RATE_LIMIT" default:"5" validate:"omitempty,min=1"`
+
+	// Auth Endpoint Switches; a disabled endpoint isn't registered and answers 404 like an unknown route,
+	// as for a deployment without public signups or without password resets
+	AuthDisableRegister       bool `envconfig:"AUTH_DISABLE_REGISTER" default:"false"`
+	AuthDisableForgotPassword bool `envconfig:"AUTH_DISABLE_FORGOT_PASSWORD" default:"false"`
+	AuthDisableResetPassword  bool `envconfig:"AUTH_DISABLE_RESET_PASSWORD" default:"false"`
+	AuthDisableRefresh        bool `envconfig:"AUTH_DISABLE_REFRESH" default:"false"`
+	AuthDisableLogout         bool `envconfig:"AUTH_DISABLE_LOGOUT" default:"false"`
+
+	// Account Recovery; comma-separated methods offered at POST /api/auth/recovery-request (empty disables
+	// recovery). "backup_email" mails a reset link to the account's confirmed backup email, "admin_approval"
+	// queues the request for an admin, who sends the reset link to the contact email once identity is verified.
+	AccountRecoveryMethods string `envconfig:"ACCOUNT_RECOVERY_METHODS"`
+
+	// Registration Defaults; the role and status given to self-registered users. A "pending" status
+	// requires an admin to approve each signup before it can sign in.
+	RegistrationDefaultRole   string `envconfig:"REGISTRATION_DEFAULT_ROLE" default:"user" validate:"omitempty,oneof=user admin"`
+	RegistrationDefaultStatus string `envconfig:"REGISTRATION_DEFAULT_STATUS" default:"active" validate:"omitempty,oneof=active inactive pending"`
+
+	// Consent Policy Versions; recorded with every consent so a later policy change shows who agreed to what
+	TermsVersion         string `envconfig:"TERMS_VERSION" default:"1"`
+	PrivacyPolicyVersion string `envconfig:"PRIVACY_POLICY_VERSION" default:"1"`
+
+	// Password Change Session Policy (keep_current or revoke_all)
+	PasswordChangeSessions string `envconfig:"PASSWORD_CHANGE_SESSIONS" default:"keep_current" validate:"omitempty,oneof=keep_current revoke_all"`
+
+	// Password Reset Session Policy (revoke_all or keep_current); keep_current spares the session of the
+	// browser that performed the reset
+	PasswordResetSessions string `envconfig:"PASSWORD_RESET_SESSIONS" default:"revoke_all" validate:"omitempty,oneof=keep_current revoke_all"`
+
+	// Password Reset Policy for accounts without a local password (refuse, confirm or allow). "refuse" mails
+	// the owner that there is no password to reset, "confirm" only adds a password when the reset confirms
+	// converting the account, "allow" adds one like any other reset.
+	PasswordResetPasswordless string `envconfig:"PASSWORD_RESET_PASSWORDLESS" default:"refuse" validate:"omitempty,oneof=refuse confirm allow"`
+
+	// Password Reset Email Interval; forgot-password requests for an address within this long of the last
+	// reset email sent to it are dropped silently, so the address can't be flooded. "0" disables it.
+	PasswordResetEmailInterval string `envconfig:"PASSWORD_RESET_EMAIL_INTERVAL" default:"60s"`
+
+	// Previous Password Login Grace; for this long after a password reset the old password still signs in,
+	// each use audited and emailed to the owner, so devices logged out by the reset aren't locked out at once.
+	// A password change, which is how a user responds to a leak, and a bootstrap admin's default password
+	// never get a grace. "0" disables it, which security-strict deployments should keep: a password reset
+	// because it leaked stays usable for the whole grace.
+	PasswordLoginGrace string `envconfig:"PASSWORD_LOGIN_GRACE" default:"0"`
+
+	// Login CAPTCHA Escalation; once an IP address or an account has this many failed logins within the
+	// window, logins from it must carry a CAPTCHA token, checked with a siteverify endpoint (reCAPTCHA,
+	// hCaptcha or Turnstile). "0" never asks for one. Failures are counted per instance.
+	LoginCaptchaThreshold int    `envconfig:"LOGIN_CAPTCHA_THRESHOLD" default:"0" validate:"omitempty,min=0"`
+	LoginCaptchaWindow    string `envconfig:"LOGIN_CAPTCHA_WINDOW" default:"15m"`
+	CaptchaVerifyURL      string `envconfig:"CAPTCHA_VERIFY_URL"`
+	CaptchaSecret         string `envconfig:"CAPTCHA_SECRET"`
+
+	// Refresh Token Device Policy; when enabled a login replaces the refresh token the same device was issued
+	// (by X-Device-ID header, or IP address and user agent without one) instead of adding another
+	RefreshTokenPerDevice bool `envconfig:"REFRESH_TOKEN_PER_DEVICE" default:"false"`
+
+	// Refresh Token Rotation; when enabled a refresh replaces the refresh token, at most once per interval.
+	// A refresh sooner than that gets a new access token and keeps its refresh token, so a client calling
+	// /refresh in a loop can't churn tokens or cause a write per call ("0" rotates on every refresh)
+	RefreshTokenRotation         bool   `envconfig:"REFRESH_TOKEN_ROTATION" default:"false"`
+	RefreshTokenRotationInterval string `envconfig:"REFRESH_TOKEN_ROTATION_INTERVAL" default:"1m"`
+
+	// Session Max Age; opt-in. A session can't be refreshed once this long has passed since the user
+	// signed in, however often it was refreshed, so they must sign in again ("0" never ends sessions)
+	SessionMaxAge string `envconfig:"SESSION_MAX_AGE" default:"0"`
+
+	// Role Change Session Policy; when enabled a role change ends the user's sessions so the new role
+	// applies immediately instead of when their access token expires. With several replicas, set
+	// TOKEN_REVOCATION_BACKEND=redis so every replica rejects the old access tokens
+	RoleChangeRevokesSessions bool `envconfig:"ROLE_CHANGE_REVOKES_SESSIONS" default:"true"`
+
+	// Last Admin Protection; when enabled admin actions that would leave no active admin are rejected
+	LastAdminProtection bool `envconfig:"LAST_ADMIN_PROTECTION" default:"true"`
+
+	// Dormant Admin Demotion; opt-in. Every interval, admins with no audited admin activity and no admin
+	// grant within the period are demoted to user until re-granted. Allowlisted emails are spared, as is
+	// whoever would otherwise leave no active admin. Super admins are never demoted.
+	AdminDormantDemotion      bool   `envconfig:"ADMIN_DORMANT_DEMOTION" default:"false"`
+	AdminDormantPeriod        string `envconfig:"ADMIN_DORMANT_PERIOD" default:"2160h"`
+	AdminDormantCheckInterval string `envconfig:"ADMIN_DORMANT_CHECK_INTERVAL" default:"24h"`
+	AdminDormantAllowlist     string `envconfig:"ADMIN_DORMANT_ALLOWLIST"`
+
+	// Bulk Self Action; how a bulk user action that lists the acting admin is handled. "reject" fails the
+	// whole request before anything changes; "skip" reports the admin's own ID as a failed item and acts on
+	// the rest. Deleting several users by ID is all-or-nothing, so it always rejects.
+	BulkSelfAction string `envconfig:"BULK_SELF_ACTION" default:"reject" validate:"omitempty,oneof=reject skip"`
+
+	// User Deletion Configuration; soft-deleted users are kept until deleted for good unless a retention
+	// period is set, e.g. "720h", after which the purge job removes them permanently. Off ("0") by default,
+	// as purged users can't be restored.
+	UserDeleteMode       string `envconfig:"USER_DELETE_MODE" default:"soft" validate:"omitempty,oneof=soft hard"`
+	UserDeletedRetention string `envconfig:"USER_DELETED_RETENTION" default:"0"`
+	UserPurgeInterval    string `envconfig:"USER_PURGE_INTERVAL" default:"1h"`
+	// Whether a soft-deleted user's email may be registered or taken by another account before the user
+	// is purged. Off by default, so a deleted account can still be restored under its own email.
+	UserDeletedEmailReuse bool `envconfig:"USER_DELETED_EMAIL_REUSE" default:"false"`
+
+	// Super Admin Configuration; the optional break-glass account is created at bootstrap and every
+	// change a super admin makes requires a step-up token no older than the TTL
+	SuperAdminEmail    string `envconfig:"SUPER_ADMIN_EMAIL" validate:"omitempty,email"`
+	SuperAdminPassword string `envconfig:"SUPER_ADMIN_PASSWORD"`
+	StepUpTokenTTL     string `envconfig:"STEP_UP_TOKEN_TTL" default:"5m"`
+
+	// Audit Configuration
+	AuditIncludeActions string `envconfig:"AUDIT_INCLUDE_ACTIONS"` // empty records every action
+	AuditExcludeActions string `envconfig:"AUDIT_EXCLUDE_ACTIONS"`
+	// AuditMaxMetadataBytes caps the serialized metadata stored per entry; larger metadata is summarized (0 disables)
+	AuditMaxMetadataBytes int `envconfig:"AUDIT_MAX_METADATA_BYTES" default:"8192" validate:"omitempty,min=256"`
+
+	// Admin Access Audit; records admin API calls as admin_access entries next to the detailed entries
+	// services write for changes (all, mutations or off)
+	AdminAccessAudit string `envconfig:"ADMIN_ACCESS_AUDIT" default:"all" validate:"omitempty,oneof=all mutations off"`
+
+	// How admin user and audit listings total their results unless the request asks: exact (COUNT(*)),
+	// estimated (planner estimate for unfiltered listings of large tables) or none (has_next only)
+	ListCountMode string `envconfig:"LIST_COUNT_MODE" default:"exact" validate:"omitempty,oneof=exact estimated none"`
+
+	// Security Event Log Configuration
+	SecurityLogSink   string `envconfig:"SECURITY_LOG_SINK" default:"stdout" validate:"omitempty,oneof=stdout file none"`
+	SecurityLogFile   string `envconfig:"SECURITY_LOG_FILE"`
+	SecurityLogEvents string `envconfig:"SECURITY_LOG_EVENTS"` // empty logs every event
+
+	// Authorization Decision Logging (off, debug or security)
+	AuthzDecisionLog string `envconfig:"AUTHZ_DECISION_LOG" default:"off" validate:"omitempty,oneof=off debug security"`
+
+	// Security Reporting Configuration
+	SecurityStatsWindow    string `envconfig:"SECURITY_STATS_WINDOW" default:"24h"`
+	SecurityStatsMaxWindow string `envconfig:"SECURITY_STATS_MAX_WINDOW" default:"720h"`
+	SecurityStatsMaxLimit  int    `envconfig:"SECURITY_STATS_MAX_LIMIT" default:"100"`
+
+	// Security Alerts; every interval the audited activity of the last window is compared with the
+	// thresholds ("0" disables one) and a breach raises a persisted alert, posted to the webhook and
+	// emailed to the recipients when set. Only the listed alert types are sent to them, empty meaning all.
+	// Automatic responses (require_step_up, tighten_login_limits) are off unless listed.
+	SecurityAlertInterval        string `envconfig:"SECURITY_ALERT_INTERVAL" default:"1m"`
+	SecurityAlertWindow          string `envconfig:"SECURITY_ALERT_WINDOW" default:"1h"`
+	SecurityAlertAdminPromotions int    `envconfig:"SECURITY_ALERT_ADMIN_PROMOTIONS" default:"5" validate:"omitempty,min=0"`
+	SecurityAlertFailedLogins    int    `envconfig:"SECURITY_ALERT_FAILED_LOGINS" default:"200" validate:"omitempty,min=0"`
+	SecurityAlertDeletions       int    `envconfig:"SECURITY_ALERT_DELETIONS" default:"50" validate:"omitempty,min=0"`
+	SecurityAlertWebhookURL      string `envconfig:"SECURITY_ALERT_WEBHOOK_URL" validate:"omitempty,url"`
+	SecurityAlertWebhookSecret   string `envconfig:"SECURITY_ALERT_WEBHOOK_SECRET"`
+	SecurityAlertEmailRecipients string `envconfig:"SECURITY_ALERT_EMAIL_RECIPIENTS"`
+	SecurityAlertNotifyTypes     string `envconfig:"SECURITY_ALERT_NOTIFY_TYPES"`
+	SecurityAlertResponses       string `envconfig:"SECURITY_ALERT_RESPONSES"`
+	SecurityAlertResponseTTL     string `envconfig:"SECURITY_ALERT_RESPONSE_TTL" default:"1h"`
+
+	// Security Alert Stream; admins connected to the live alert stream get a comment line every
+	// heartbeat so proxies keep it open. Each client has a buffer of alerts; one that falls that far
+	// behind is disconnected instead of holding back the others. Clients are capped per instance
+	// ("0" is unlimited).
+	SecurityAlertStreamHeartbeat  string `envconfig:"SECURITY_ALERT_STREAM_HEARTBEAT" default:"15s"`
+	SecurityAlertStreamBuffer     int    `envconfig:"SECURITY_ALERT_STREAM_BUFFER" default:"16" validate:"omitempty,min=1,max=1000"`
+	SecurityAlertStreamMaxClients int    `envconfig:"SECURITY_ALERT_STREAM_MAX_CLIENTS" default:"50" validate:"omitempty,min=0"`
+
+	// Admin Operation Concurrency; caps how many of each expensive admin operation run at once on this
+	// instance ("0" is unlimited). Requests over the limit are rejected with Retry-After.
+	AdminBulkConcurrency       int    `envconfig:"ADMIN_BULK_CONCURRENCY" default:"2" validate:"omitempty,min=0"`
+	AdminBroadcastConcurrency  int    `envconfig:"ADMIN_BROADCAST_CONCURRENCY" default:"1" validate:"omitempty,min=0"`
+	AdminMergeConcurrency      int    `envconfig:"ADMIN_MERGE_CONCURRENCY" default:"2" validate:"omitempty,min=0"`
+	AdminEmailQueueConcurrency int    `envconfig:"ADMIN_EMAIL_QUEUE_CONCURRENCY" default:"1" validate:"omitempty,min=0"`
+	AdminOperationRetryAfter   string `envconfig:"ADMIN_OPERATION_RETRY_AFTER" default:"10s"`
+
+	// Admin Exports; requested exports are run by a background job every interval ("0" disables exports)
+	// and given up on after the timeout. Finished files are kept in file storage for the retention period
+	// and handed out through signed download links valid for the link TTL.
+	AdminExportInterval  string `envconfig:"ADMIN_EXPORT_INTERVAL" default:"10s"`
+	AdminExportTimeout   string `envconfig:"ADMIN_EXPORT_TIMEOUT" default:"30m"`
+	AdminExportRetention string `envconfig:"ADMIN_EXPORT_RETENTION" default:"24h"`
+	AdminExportLinkTTL   string `envconfig:"ADMIN_EXPORT_LINK_TTL" default:"1h"`
+
 	// Production Validation Settings
 	StrictProductionValidation bool `envconfig:"STRICT_PRODUCTION_VALIDATION" default:"false"`
 	AllowDevSecretsInProd      bool `envconfig:"ALLOW_DEV_SECRETS_IN_PROD" default:"false"`
 	AllowInsecureDBInProd      bool `envconfig:"ALLOW_INSECURE_DB_IN_PROD" default:"false"`
 
+	// JWT Secret Strength; rejects low-entropy or patterned JWT secrets. "production" checks as part of
+	// strict production validation, "always" checks in every environment, "off" disables the check.
+	JWTSecretStrength string `envconfig:"JWT_SECRET_STRENGTH" default:"production" validate:"omitempty,oneof=off production always"`
+
 	// Feature Flags
-	FeatureFlags FeatureFlags `envconfig:"FEATURES"`
+	FeatureFlags    FeatureFlags `envconfig:"FEATURES"`
+	FeatureCacheTTL string       `envconfig:"FEATURE_CACHE_TTL" default:"1m"`
 
 	// Monitoring Configuration
 	MetricsEnabled  bool   `envconfig:"METRICS_ENABLED" default:"true"`
@@ -86,6 +415,49 @@ type Config struct {
 	SentryDSN       string `envconfig:"SENTRY_DSN"`
 	TracingEnabled  bool   `envconfig:"TRACING_ENABLED" default:"false"`
 
+	// Access Log Sampling; errors (4xx/5xx) and requests slower than the threshold are always logged,
+	// other requests at the sample rate (0-1), chosen by request ID so every instance keeps the same ones
+	AccessLogSampleRate    float64 `envconfig:"ACCESS_LOG_SAMPLE_RATE" default:"1" validate:"min=0,max=1"`
+	AccessLogSlowThreshold string  `envconfig:"ACCESS_LOG_SLOW_THRESHOLD" default:"1s"`
+
+	// Samples kept per summary series by the in-memory metrics collector; the oldest are dropped first
+	MetricsSummaryMaxSamples int `envconfig:"METRICS_SUMMARY_MAX_SAMPLES" default:"1000" validate:"omitempty,min=10,max=100000"`
+
+	// User Activity Metrics; every interval ("0" disables it) the active accounts and the users who
+	// logged in over the last day and 30 days are counted in one query and published as gauges
+	UserActivityMetricsInterval string `envconfig:"USER_ACTIVITY_METRICS_INTERVAL" default:"5m"`
+
+	// Health Check Configuration (per-checker and overall deadlines)
+	HealthCheckTimeout        string `envconfig:"HEALTH_CHECK_TIMEOUT" default:"5s"`
+	HealthCheckOverallTimeout string `envconfig:"HEALTH_CHECK_OVERALL_TIMEOUT" default:"10s"`
+	// The public health and readiness probes show only the overall status unless this is set; the
+	// full report with each checker's details is always available to admins at /api/admin/health
+	HealthPublicDetail bool `envconfig:"HEALTH_PUBLIC_DETAIL" default:"false"`
+
+	// Outbound HTTP Client Configuration; the timeout applies to each attempt and retries only apply
+	// to idempotent requests
+	HTTPClientTimeout             string `envconfig:"HTTP_CLIENT_TIMEOUT" default:"10s"`
+	HTTPClientDialTimeout         string `envconfig:"HTTP_CLIENT_DIAL_TIMEOUT" default:"5s"`
+	HTTPClientIdleConnTimeout     string `envconfig:"HTTP_CLIENT_IDLE_CONN_TIMEOUT" default:"90s"`
+	HTTPClientMaxIdleConns        int    `envconfig:"HTTP_CLIENT_MAX_IDLE_CONNS" default:"100" validate:"omitempty,min=0"`
+	HTTPClientMaxIdleConnsPerHost int    `envconfig:"HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST" default:"10" validate:"omitempty,min=0"`
+	HTTPClientMaxRetries          int    `envconfig:"HTTP_CLIENT_MAX_RETRIES" default:"2" validate:"omitempty,min=0,max=10"`
+	HTTPClientRetryBackoff        string `envconfig:"HTTP_CLIENT_RETRY_BACKOFF" default:"200ms"`
+
+	// Outbound Circuit Breaker Configuration; each client's breaker opens once the failure rate over the
+	// window reaches the threshold (with at least the minimum requests), fails fast for the cooldown, then
+	// lets a probe through to test recovery. A rate of "0" disables the breaker.
+	HTTPClientBreakerFailureRate float64 `envconfig:"HTTP_CLIENT_BREAKER_FAILURE_RATE" default:"0.5" validate:"min=0,max=1"`
+	HTTPClientBreakerMinRequests int     `envconfig:"HTTP_CLIENT_BREAKER_MIN_REQUESTS" default:"10" validate:"omitempty,min=1"`
+	HTTPClientBreakerWindow      string  `envconfig:"HTTP_CLIENT_BREAKER_WINDOW" default:"1m"`
+	HTTPClientBreakerCooldown    string  `envconfig:"HTTP_CLIENT_BREAKER_COOLDOWN" default:"30s"`
+
+	// GeoIP Enrichment; when a service URL is set, IPs shown in security views are resolved to an approximate
+	// location in the background and cached. "{ip}" in the URL is replaced with the address being looked up.
+	GeoIPServiceURL string `envconfig:"GEOIP_SERVICE_URL"`
+	GeoIPCacheTTL   string `envconfig:"GEOIP_CACHE_TTL" default:"24h"`
+	GeoIPCacheSize  int    `envconfig:"GEOIP_CACHE_SIZE" default:"10000" validate:"omitempty,min=1"`
+
 	// Cache Configuration
 	RedisURL     string `envconfig:"REDIS_URL" default:"redis://localhost:6379/0"`
 	CacheEnabled bool   `envconfig:"CACHE_ENABLED" default:"true"`
@@ -99,12 +471,19 @@ type Config struct {
 	GCSBucket        string `envconfig:"GCS_BUCKET"`
 	LocalStoragePath string `envconfig:"LOCAL_STORAGE_PATH" default:"./uploads"`
 
+	// Longest storage key accepted; keys are generated server-side and never taken from client filenames
+	StorageMaxKeyLength int `envconfig:"STORAGE_MAX_KEY_LENGTH" default:"255" validate:"omitempty,min=64,max=1024"`
+
 	// Bootstrap Configuration
 	BootstrapEnabled bool   `envconfig:"BOOTSTRAP_ENABLED" default:"true"`
 	AdminEmail       string `envconfig:"ADMIN_EMAIL" default:"admin@example.com"`
 	AdminPassword    string `envconfig:"ADMIN_PASSWORD" default:"admin123"`
 	DemoUserEmail    string `envconfig:"DEMO_USER_EMAIL" default:"user@example.com"`
 	DemoUserPassword string `envconfig:"DEMO_USER_PASSWORD" default:"user1234"`
+
+	// Create the bootstrap admins unverified with a required password change, and email them a setup link.
+	// Ignored while EMAIL_ENABLED is off, so local setups keep their ready-to-use admin.
+	BootstrapAdminSetupRequired bool `envconfig:"BOOTSTRAP_ADMIN_SETUP_REQUIRED" default:"false"`
 }
 
 // FeatureFlags represents application feature flags
@@ -155,6 +534,110 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.validateLinkTemplates(); err != nil {
+		return err
+	}
+
+	// The break-glass account needs both an email and a password
+	if (c.SuperAdminEmail == "") != (c.SuperAdminPassword == "") {
+		return fmt.Errorf("SUPER_ADMIN_EMAIL and SUPER_ADMIN_PASSWORD must be set together")
+	}
+
+	if c.TokenRevocationBackend == "redis" && c.TokenRevocationRedisURL == "" {
+		return fmt.Errorf("TOKEN_REVOCATION_BACKEND=redis requires TOKEN_REVOCATION_REDIS_URL")
+	}
+
+	if c.EmailQueueBackend == "redis" && c.EmailQueueRedisURL == "" {
+		return fmt.Errorf("EMAIL_QUEUE_BACKEND=redis requires EMAIL_QUEUE_REDIS_URL")
+	}
+
+	if c.EmailSendWindowEnabled && c.EmailSendWindowStart >= c.EmailSendWindowEnd {
+		return fmt.Errorf("EMAIL_SEND_WINDOW_START must be before EMAIL_SEND_WINDOW_END")
+	}
+
+	if err := c.validateJWTSecretStrength(); err != nil {
+		return err
+	}
+
+	// Otherwise anyone who signs up becomes an admin
+	if c.RegistrationDefaultRole == "admin" && !c.RegistrationRequiresApproval() {
+		return fmt.Errorf("REGISTRATION_DEFAULT_ROLE=admin requires REGISTRATION_DEFAULT_STATUS=pending")
+	}
+
+	// Client keys are the only thing between the internet and token introspection
+	for _, key := range c.GetIntrospectionClientKeys() {
+		if len(key) < 32 {
+			return fmt.Errorf("INTROSPECTION_CLIENT_KEYS entries must be at least 32 characters")
+		}
+	}
+
+	for _, field := range splitList(c.InfoFields) {
+		if !slices.Contains(InfoFieldNames, field) {
+			return fmt.Errorf("INFO_FIELDS entries must be one of %s, got %q", strings.Join(InfoFieldNames, ", "), field)
+		}
+	}
+
+	for _, method := range c.GetAccountRecoveryMethods() {
+		if method != "backup_email" && method != "admin_approval" {
+			return fmt.Errorf("ACCOUNT_RECOVERY_METHODS entries must be backup_email or admin_approval, got %q", method)
+		}
+	}
+
+	// Reset links are mailed by forgot password and account recovery; without the endpoint they'd be dead
+	if c.AuthDisableResetPassword && !c.AuthDisableForgotPassword {
+		return fmt.Errorf("AUTH_DISABLE_RESET_PASSWORD requires AUTH_DISABLE_FORGOT_PASSWORD")
+	}
+	if c.AuthDisableResetPassword && len(c.GetAccountRecoveryMethods()) > 0 {
+		return fmt.Errorf("AUTH_DISABLE_RESET_PASSWORD requires ACCOUNT_RECOVERY_METHODS to be empty")
+	}
+
+	// The availability check serves the signup form, and only reveals registered emails without it
+	if c.AuthDisableRegister && c.EmailAvailabilityCheck {
+		return fmt.Errorf("AUTH_DISABLE_REGISTER requires EMAIL_AVAILABILITY_CHECK=false")
+	}
+
+	if c.UsernameMinLength > 0 && c.UsernameMaxLength > 0 && c.UsernameMinLength > c.UsernameMaxLength {
+		return fmt.Errorf("USERNAME_MIN_LENGTH may not exceed USERNAME_MAX_LENGTH")
+	}
+
+	// The grace is meant to smooth a changeover, not to keep a replaced password alive
+	if c.PasswordLoginGraceDuration() > maxPasswordLoginGrace {
+		return fmt.Errorf("PASSWORD_LOGIN_GRACE may not exceed %s", maxPasswordLoginGrace)
+	}
+
+	// A mistyped maximum age would otherwise leave sessions unbounded without a word
+	if c.SessionMaxAge != "" {
+		if maxAge, err := time.ParseDuration(c.SessionMaxAge); err != nil || maxAge < 0 {
+			return fmt.Errorf("SESSION_MAX_AGE must be a duration of zero or more, got %q", c.SessionMaxAge)
+		}
+	}
+
+	// A mistyped type would otherwise silently stop those alerts from going out
+	for _, alertType := range splitList(c.SecurityAlertNotifyTypes) {
+		if !slices.Contains(SecurityAlertTypes, alertType) {
+			return fmt.Errorf("SECURITY_ALERT_NOTIFY_TYPES entries must be one of %s, got %q",
+				strings.Join(SecurityAlertTypes, ", "), alertType)
+		}
+	}
+
+	for _, recipient := range c.GetSecurityAlertEmailRecipients() {
+		if err := validate.Var(recipient, "email"); err != nil {
+			return fmt.Errorf("SECURITY_ALERT_EMAIL_RECIPIENTS entries must be email addresses, got %q", recipient)
+		}
+	}
+
+	if c.LoginCaptchaThreshold > 0 && (c.CaptchaVerifyURL == "" || c.CaptchaSecret == "") {
+		return fmt.Errorf("LOGIN_CAPTCHA_THRESHOLD requires CAPTCHA_VERIFY_URL and CAPTCHA_SECRET")
+	}
+
+	if c.JSONContentType != "" && !isJSONMediaType(c.JSONContentType) {
+		return fmt.Errorf("JSON_CONTENT_TYPE must be a JSON media type, got %q", c.JSONContentType)
+	}
+
+	if c.GeoIPServiceURL != "" && !strings.Contains(c.GeoIPServiceURL, "{ip}") {
+		return fmt.Errorf("GEOIP_SERVICE_URL must contain the {ip} placeholder")
+	}
+
 	// Conditional email validation
 	if c.EmailEnabled {
 		if err := validate.Var(c.EmailFrom, "required,email"); err != nil {
@@ -204,6 +687,13 @@ func (c *Config) validateProductionSettings() error {
 		}
 	}
 
+	// Reject secrets that meet the length bar but are guessable (set JWT_SECRET_STRENGTH=off to override)
+	if c.JWTSecretStrength == SecretStrengthProduction {
+		if err := checkSecretStrength(c.JWTSecret); err != nil {
+			errors = append(errors, fmt.Sprintf("JWT_SECRET %s (set JWT_SECRET_STRENGTH=off to override)", err))
+		}
+	}
+
 	// Check database SSL mode (unless explicitly allowed)
 	if !c.AllowInsecureDBInProd && c.DatabaseSSLMode == "disable" {
 		errors = append(errors, "DATABASE_SSL_MODE should not be 'disable' in production (set ALLOW_INSECURE_DB_IN_PROD=true to override)")
@@ -260,6 +750,15 @@ func (c *Config) JWTRefreshTokenDurationParsed() time.Duration {
 	return duration
 }
 
+// JWTLeewayDuration parses the tolerated clock skew for token validation
+func (c *Config) JWTLeewayDuration() time.Duration {
+	duration, err := time.ParseDuration(c.JWTLeeway)
+	if err != nil || duration < 0 {
+		return 0
+	}
+	return duration
+}
+
 // IsProduction returns true if the environment is production
 func (c *Config) IsProduction() bool {
 	return c.Environment == "production"
@@ -284,6 +783,15 @@ func (c *Config) DBConnMaxIdleTimeDuration() time.Duration {
 	return duration
 }
 
+// MigrationLockTimeoutDuration parses how long to wait for the migration lock
+func (c *Config) MigrationLockTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.MigrationLockTimeout)
+	if err != nil || duration <= 0 {
+		return 5 * time.Minute
+	}
+	return duration
+}
+
 // CacheTTLDuration parses the cache TTL duration
 func (c *Config) CacheTTLDuration() time.Duration {
 	duration, err := time.ParseDuration(c.CacheTTL)
@@ -293,6 +801,479 @@ func (c *Config) CacheTTLDuration() time.Duration {
 	return duration
 }
 
+// FeatureCacheTTLDuration parses the feature evaluation cache TTL duration
+func (c *Config) FeatureCacheTTLDuration() time.Duration {
+	duration, err := time.ParseDuration(c.FeatureCacheTTL)
+	if err != nil {
+		return time.Minute
+	}
+	return duration
+}
+
+// PasswordChangeRevokesAllSessions reports whether a password change also ends the current session
+func (c *Config) PasswordChangeRevokesAllSessions() bool {
+	return c.PasswordChangeSessions == "revoke_all"
+}
+
+// PasswordResetKeepsCurrentSession reports whether a password reset spares the resetting browser's session
+func (c *Config) PasswordResetKeepsCurrentSession() bool {
+	return c.PasswordResetSessions == "keep_current"
+}
+
+// PasswordlessResetPolicy returns how a password reset treats an account without a local password
+func (c *Config) PasswordlessResetPolicy() string {
+	if c.PasswordResetPasswordless == "" {
+		return PasswordlessResetRefuse
+	}
+	return c.PasswordResetPasswordless
+}
+
+// PasswordResetEmailIntervalDuration parses the minimum time between password reset emails to an address
+func (c *Config) PasswordResetEmailIntervalDuration() time.Duration {
+	duration, err := time.ParseDuration(c.PasswordResetEmailInterval)
+	if err != nil || duration < 0 {
+		return time.Minute
+	}
+	return duration
+}
+
+// PasswordLoginGraceDuration parses how long a replaced password still signs in; zero disables the grace
+func (c *Config) PasswordLoginGraceDuration() time.Duration {
+	duration, err := time.ParseDuration(c.PasswordLoginGrace)
+	if err != nil || duration < 0 {
+		return 0
+	}
+	return duration
+}
+
+// LoginCaptchaWindowDuration parses how long failed logins count towards the CAPTCHA threshold
+func (c *Config) LoginCaptchaWindowDuration() time.Duration {
+	duration, err := time.ParseDuration(c.LoginCaptchaWindow)
+	if err != nil || duration <= 0 {
+		return 15 * time.Minute
+	}
+	return duration
+}
+
+// RefreshTokenRotationIntervalDuration parses the minimum time between refresh token rotations; an
+// invalid value falls back to one minute
+func (c *Config) RefreshTokenRotationIntervalDuration() time.Duration {
+	duration, err := time.ParseDuration(c.RefreshTokenRotationInterval)
+	if err != nil || duration < 0 {
+		return time.Minute
+	}
+	return duration
+}
+
+// SessionMaxAgeDuration parses the absolute session lifetime; zero leaves sessions unbounded. Validate
+// refuses an invalid or negative value.
+func (c *Config) SessionMaxAgeDuration() time.Duration {
+	duration, err := time.ParseDuration(c.SessionMaxAge)
+	if err != nil || duration < 0 {
+		return 0
+	}
+	return duration
+}
+
+// SecureCookies reports whether auth cookies carry the Secure flag for a request that did or did not
+// arrive over HTTPS; COOKIE_SECURE wins over the environment and the request
+func (c *Config) SecureCookies(requestHTTPS bool) bool {
+	if c.CookieSecure != "" {
+		return c.CookieSecure == "true"
+	}
+	return !c.IsDevelopment() || requestHTTPS
+}
+
+// RegistrationRequiresApproval reports whether self-registered users wait for an admin to approve them
+func (c *Config) RegistrationRequiresApproval() bool {
+	return c.RegistrationDefaultStatus == "pending"
+}
+
+// UserHardDeleteByDefault reports whether user deletion is permanent when a request does not say
+func (c *Config) UserHardDeleteByDefault() bool {
+	return c.UserDeleteMode == "hard"
+}
+
+// UserDeletedRetentionDuration parses how long soft-deleted users are kept; zero, or a value that
+// can't be parsed, disables the purge
+func (c *Config) UserDeletedRetentionDuration() time.Duration {
+	duration, err := time.ParseDuration(c.UserDeletedRetention)
+	if err != nil || duration < 0 {
+		return 0
+	}
+	return duration
+}
+
+// AdminDormantPeriodDuration parses how long an admin may go without admin activity before demotion
+func (c *Config) AdminDormantPeriodDuration() time.Duration {
+	duration, err := time.ParseDuration(c.AdminDormantPeriod)
+	if err != nil || duration <= 0 {
+		return 90 * 24 * time.Hour
+	}
+	return duration
+}
+
+// AdminDormantCheckIntervalDuration parses how often dormant admins are looked for
+func (c *Config) AdminDormantCheckIntervalDuration() time.Duration {
+	duration, err := time.ParseDuration(c.AdminDormantCheckInterval)
+	if err != nil || duration <= 0 {
+		return 24 * time.Hour
+	}
+	return duration
+}
+
+// GetAdminDormantAllowlist returns the emails of admins that are never demoted for inactivity
+func (c *Config) GetAdminDormantAllowlist() []string {
+	allowlist := splitList(c.AdminDormantAllowlist)
+	for i, email := range allowlist {
+		allowlist[i] = strings.ToLower(email)
+	}
+	return allowlist
+}
+
+// UserPurgeIntervalDuration parses how often the soft-deleted user purge runs
+func (c *Config) UserPurgeIntervalDuration() time.Duration {
+	duration, err := time.ParseDuration(c.UserPurgeInterval)
+	if err != nil || duration <= 0 {
+		return time.Hour
+	}
+	return duration
+}
+
+// LoginVerificationNudgeIntervalDuration parses the minimum time between verification reminder emails
+func (c *Config) LoginVerificationNudgeIntervalDuration() time.Duration {
+	duration, err := time.ParseDuration(c.LoginVerificationNudgeInterval)
+	if err != nil {
+		return 24 * time.Hour
+	}
+	return duration
+}
+
+// VerificationResendWindowDuration parses the window verification resends are counted over
+func (c *Config) VerificationResendWindowDuration() time.Duration {
+	duration, err := time.ParseDuration(c.VerificationResendWindow)
+	if err != nil || duration <= 0 {
+		return time.Hour
+	}
+	return duration
+}
+
+// ServerReadHeaderTimeoutDuration parses the time allowed to read request headers
+func (c *Config) ServerReadHeaderTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.ServerReadHeaderTimeout)
+	if err != nil || duration <= 0 {
+		return 5 * time.Second
+	}
+	return duration
+}
+
+// ServerReadTimeoutDuration parses the time allowed to read an entire request
+func (c *Config) ServerReadTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.ServerReadTimeout)
+	if err != nil || duration <= 0 {
+		return 30 * time.Second
+	}
+	return duration
+}
+
+// ServerWriteTimeoutDuration parses the time allowed to write a response
+func (c *Config) ServerWriteTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.ServerWriteTimeout)
+	if err != nil || duration <= 0 {
+		return 30 * time.Second
+	}
+	return duration
+}
+
+// ServerIdleTimeoutDuration parses how long keep-alive connections may stay idle
+func (c *Config) ServerIdleTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.ServerIdleTimeout)
+	if err != nil || duration <= 0 {
+		return 120 * time.Second
+	}
+	return duration
+}
+
+// CORSMaxAgeDuration parses how long browsers may cache CORS preflight responses
+func (c *Config) CORSMaxAgeDuration() time.Duration {
+	duration, err := time.ParseDuration(c.CORSMaxAge)
+	if err != nil || duration < 0 {
+		return 24 * time.Hour
+	}
+	return duration
+}
+
+// StepUpTokenTTLDuration parses how long a step-up token stays valid after the password is re-entered
+func (c *Config) StepUpTokenTTLDuration() time.Duration {
+	duration, err := time.ParseDuration(c.StepUpTokenTTL)
+	if err != nil || duration <= 0 {
+		return 5 * time.Minute
+	}
+	return duration
+}
+
+// ServerMaxHeaderBytesLimit returns the maximum size of request headers
+func (c *Config) ServerMaxHeaderBytesLimit() int {
+	if c.ServerMaxHeaderBytes <= 0 {
+		return 64 << 10
+	}
+	return c.ServerMaxHeaderBytes
+}
+
+// JSONContentTypeValue returns the Content-Type set on JSON responses
+func (c *Config) JSONContentTypeValue() string {
+	if c.JSONContentType == "" {
+		return "application/json; charset=utf-8"
+	}
+	return c.JSONContentType
+}
+
+// isJSONMediaType reports whether a Content-Type value is application/json or a +json type
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || (strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// AccessLogSlowThresholdDuration parses the latency above which a request is always logged
+func (c *Config) AccessLogSlowThresholdDuration() time.Duration {
+	duration, err := time.ParseDuration(c.AccessLogSlowThreshold)
+	if err != nil || duration <= 0 {
+		return time.Second
+	}
+	return duration
+}
+
+// HealthCheckTimeoutDuration parses the default per-checker health check timeout
+func (c *Config) HealthCheckTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.HealthCheckTimeout)
+	if err != nil || duration <= 0 {
+		return 5 * time.Second
+	}
+	return duration
+}
+
+// HealthCheckOverallTimeoutDuration parses the deadline for a full health report
+func (c *Config) HealthCheckOverallTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.HealthCheckOverallTimeout)
+	if err != nil || duration <= 0 {
+		return 10 * time.Second
+	}
+	return duration
+}
+
+// HTTPClientTimeoutDuration parses the overall deadline for an outbound HTTP request
+func (c *Config) HTTPClientTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.HTTPClientTimeout)
+	if err != nil || duration <= 0 {
+		return 10 * time.Second
+	}
+	return duration
+}
+
+// HTTPClientDialTimeoutDuration parses the time allowed to open an outbound connection
+func (c *Config) HTTPClientDialTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.HTTPClientDialTimeout)
+	if err != nil || duration <= 0 {
+		return 5 * time.Second
+	}
+	return duration
+}
+
+// HTTPClientIdleConnTimeoutDuration parses how long pooled outbound connections may stay idle
+func (c *Config) HTTPClientIdleConnTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.HTTPClientIdleConnTimeout)
+	if err != nil || duration <= 0 {
+		return 90 * time.Second
+	}
+	return duration
+}
+
+// HTTPClientRetryBackoffDuration parses the delay before the first outbound retry
+func (c *Config) HTTPClientRetryBackoffDuration() time.Duration {
+	duration, err := time.ParseDuration(c.HTTPClientRetryBackoff)
+	if err != nil || duration < 0 {
+		return 200 * time.Millisecond
+	}
+	return duration
+}
+
+// HTTPClientBreakerWindowDuration parses the window over which outbound failure rates are measured
+func (c *Config) HTTPClientBreakerWindowDuration() time.Duration {
+	duration, err := time.ParseDuration(c.HTTPClientBreakerWindow)
+	if err != nil || duration <= 0 {
+		return time.Minute
+	}
+	return duration
+}
+
+// HTTPClientBreakerCooldownDuration parses how long a tripped breaker fails fast before probing
+func (c *Config) HTTPClientBreakerCooldownDuration() time.Duration {
+	duration, err := time.ParseDuration(c.HTTPClientBreakerCooldown)
+	if err != nil || duration <= 0 {
+		return 30 * time.Second
+	}
+	return duration
+}
+
+// GeoIPCacheTTLDuration parses how long resolved IP locations are cached
+func (c *Config) GeoIPCacheTTLDuration() time.Duration {
+	duration, err := time.ParseDuration(c.GeoIPCacheTTL)
+	if err != nil || duration <= 0 {
+		return 24 * time.Hour
+	}
+	return duration
+}
+
+// SecurityStatsWindowDuration parses the default login stats window
+func (c *Config) SecurityStatsWindowDuration() time.Duration {
+	duration, err := time.ParseDuration(c.SecurityStatsWindow)
+	if err != nil {
+		return 24 * time.Hour
+	}
+	return duration
+}
+
+// SecurityStatsMaxWindowDuration parses the largest login stats window a caller may request
+func (c *Config) SecurityStatsMaxWindowDuration() time.Duration {
+	duration, err := time.ParseDuration(c.SecurityStatsMaxWindow)
+	if err != nil {
+		return 30 * 24 * time.Hour
+	}
+	return duration
+}
+
+// SecurityStatsLimit returns the most top offenders and lockouts a login stats response may list; an
+// unset or invalid SECURITY_STATS_MAX_LIMIT falls back to the default rather than lifting the cap
+func (c *Config) SecurityStatsLimit() int {
+	if c.SecurityStatsMaxLimit <= 0 {
+		return 100
+	}
+	return c.SecurityStatsMaxLimit
+}
+
+// SecurityAlertIntervalDuration parses how often security thresholds are checked; zero disables the check
+func (c *Config) SecurityAlertIntervalDuration() time.Duration {
+	duration, err := time.ParseDuration(c.SecurityAlertInterval)
+	if err != nil {
+		return time.Minute
+	}
+	return duration
+}
+
+// SecurityAlertWindowDuration parses how far back activity counts towards the security thresholds
+func (c *Config) SecurityAlertWindowDuration() time.Duration {
+	duration, err := time.ParseDuration(c.SecurityAlertWindow)
+	if err != nil || duration <= 0 {
+		return time.Hour
+	}
+	return duration
+}
+
+// SecurityAlertResponseTTLDuration parses how long an automatic response to a security alert lasts
+func (c *Config) SecurityAlertResponseTTLDuration() time.Duration {
+	duration, err := time.ParseDuration(c.SecurityAlertResponseTTL)
+	if err != nil || duration <= 0 {
+		return time.Hour
+	}
+	return duration
+}
+
+// SecurityAlertStreamHeartbeatDuration parses how often an idle alert stream sends a keep-alive
+func (c *Config) SecurityAlertStreamHeartbeatDuration() time.Duration {
+	duration, err := time.ParseDuration(c.SecurityAlertStreamHeartbeat)
+	if err != nil || duration <= 0 {
+		return 15 * time.Second
+	}
+	return duration
+}
+
+// SecurityAlertResponseEnabled reports whether an automatic response is listed in SECURITY_ALERT_RESPONSES
+func (c *Config) SecurityAlertResponseEnabled(response string) bool {
+	for _, enabled := range strings.Split(c.SecurityAlertResponses, ",") {
+		if strings.TrimSpace(enabled) == response {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSecurityAlertEmailRecipients returns the addresses security alerts are emailed to, each listed once
+func (c *Config) GetSecurityAlertEmailRecipients() []string {
+	var recipients []string
+	for _, recipient := range splitList(c.SecurityAlertEmailRecipients) {
+		recipient = strings.ToLower(recipient)
+		if !slices.Contains(recipients, recipient) {
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients
+}
+
+// SecurityAlertTypes lists the security alert types the service raises, which SECURITY_ALERT_NOTIFY_TYPES
+// chooses from
+var SecurityAlertTypes = []string{
+	"role_change",
+	"admin_promotion_spike",
+	"failed_login_spike",
+	"mass_deletion",
+	"email_queue_depth",
+	"email_queue_age",
+}
+
+// SecurityAlertNotifies reports whether alerts of a type are sent to the webhook and email recipients
+func (c *Config) SecurityAlertNotifies(alertType string) bool {
+	types := splitList(c.SecurityAlertNotifyTypes)
+	return len(types) == 0 || slices.Contains(types, alertType)
+}
+
+// AdminOperationRetryAfterDuration parses how long clients are told to wait when an admin operation is saturated
+func (c *Config) AdminOperationRetryAfterDuration() time.Duration {
+	duration, err := time.ParseDuration(c.AdminOperationRetryAfter)
+	if err != nil || duration < time.Second {
+		return 10 * time.Second
+	}
+	return duration
+}
+
+// AdminExportIntervalDuration parses how often pending exports are picked up; zero disables exports
+func (c *Config) AdminExportIntervalDuration() time.Duration {
+	duration, err := time.ParseDuration(c.AdminExportInterval)
+	if err != nil || duration < 0 {
+		return 10 * time.Second
+	}
+	return duration
+}
+
+// AdminExportTimeoutDuration parses how long an export may run before it is given up on
+func (c *Config) AdminExportTimeoutDuration() time.Duration {
+	duration, err := time.ParseDuration(c.AdminExportTimeout)
+	if err != nil || duration <= 0 {
+		return 30 * time.Minute
+	}
+	return duration
+}
+
+// AdminExportRetentionDuration parses how long a finished export's file is kept
+func (c *Config) AdminExportRetentionDuration() time.Duration {
+	duration, err := time.ParseDuration(c.AdminExportRetention)
+	if err != nil || duration <= 0 {
+		return 24 * time.Hour
+	}
+	return duration
+}
+
+// AdminExportLinkTTLDuration parses how long a signed export download link stays valid
+func (c *Config) AdminExportLinkTTLDuration() time.Duration {
+	duration, err := time.ParseDuration(c.AdminExportLinkTTL)
+	if err != nil || duration <= 0 {
+		return time.Hour
+	}
+	return duration
+}
+
 // GetCORSOrigins returns the CORS origins as a slice
 func (c *Config) GetCORSOrigins() []string {
 	if c.CORSOrigins == "" {
@@ -301,6 +1282,223 @@ func (c *Config) GetCORSOrigins() []string {
 	return strings.Split(c.CORSOrigins, ",")
 }
 
+// GetAuditIncludeActions returns the audit actions to record, empty meaning all
+func (c *Config) GetAuditIncludeActions() []string {
+	return splitList(c.AuditIncludeActions)
+}
+
+// GetAuditExcludeActions returns the audit actions to skip
+func (c *Config) GetAuditExcludeActions() []string {
+	return splitList(c.AuditExcludeActions)
+}
+
+// GetSecurityLogEvents returns the security events to log, empty meaning all
+func (c *Config) GetSecurityLogEvents() []string {
+	return splitList(c.SecurityLogEvents)
+}
+
+// GetSanitizeQueryPatterns returns the configured suspicious query value patterns
+func (c *Config) GetSanitizeQueryPatterns() []string {
+	return splitList(c.SanitizeQueryPatterns)
+}
+
+// GetSanitizeUserAgentPatterns returns the configured scanner User-Agent tokens
+func (c *Config) GetSanitizeUserAgentPatterns() []string {
+	return splitList(c.SanitizeUserAgentPatterns)
+}
+
+// GetSanitizeHeaders returns the configured headers that should never reach the API
+func (c *Config) GetSanitizeHeaders() []string {
+	return splitList(c.SanitizeHeaders)
+}
+
+// splitList splits a comma-separated value, dropping blank entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// EmailSubjectPrefixForEnvironment returns the subject prefix for outgoing email in the current
+// environment, or "" when the environment is not configured for one
+func (c *Config) EmailSubjectPrefixForEnvironment() string {
+	for _, environment := range splitList(c.EmailSubjectPrefixEnvironments) {
+		if environment != c.Environment {
+			continue
+		}
+		if c.EmailSubjectPrefix != "" {
+			return c.EmailSubjectPrefix
+		}
+		return "[" + strings.ToUpper(c.Environment) + "]"
+	}
+	return ""
+}
+
+// GetRegistrationAllowedDomains returns the email domains allowed to register, empty for all
+func (c *Config) GetRegistrationAllowedDomains() []string {
+	return splitList(strings.ToLower(c.RegistrationAllowedDomains))
+}
+
+// GetRegistrationBlockedDomains returns the email domains that may not register
+func (c *Config) GetRegistrationBlockedDomains() []string {
+	return splitList(strings.ToLower(c.RegistrationBlockedDomains))
+}
+
+// GetUsernameReserved returns the usernames nobody may take
+func (c *Config) GetUsernameReserved() []string {
+	return splitList(strings.ToLower(c.UsernameReserved))
+}
+
+// GetIntrospectionClientKeys returns the client keys allowed to call the token introspection endpoint
+func (c *Config) GetIntrospectionClientKeys() []string {
+	return splitList(c.IntrospectionClientKeys)
+}
+
+// InfoFieldNames lists the fields /api/info can expose, in response order
+var InfoFieldNames = []string{"name", "version", "commit", "build_time", "go_version", "environment", "features"}
+
+// GetInfoFields returns the fields /api/info exposes; see InfoFields for the defaults
+func (c *Config) GetInfoFields() []string {
+	if fields := splitList(c.InfoFields); len(fields) > 0 {
+		return fields
+	}
+	if c.IsProduction() {
+		return []string{"name", "version", "commit", "build_time"}
+	}
+	return InfoFieldNames
+}
+
+// GetAccountRecoveryMethods returns the account recovery methods users may request
+func (c *Config) GetAccountRecoveryMethods() []string {
+	return splitList(c.AccountRecoveryMethods)
+}
+
+// AccountRecoveryMethodEnabled reports whether users may request account recovery with the method
+func (c *Config) AccountRecoveryMethodEnabled(method string) bool {
+	for _, enabled := range c.GetAccountRecoveryMethods() {
+		if enabled == method {
+			return true
+		}
+	}
+	return false
+}
+
+// GetJWTCustomClaims returns the names of the custom claims added to access tokens
+func (c *Config) GetJWTCustomClaims() []string {
+	return splitList(c.JWTCustomClaims)
+}
+
+// JWTCustomClaimsLimit returns the largest encoded size of an access token's custom claims
+func (c *Config) JWTCustomClaimsLimit() int {
+	if c.JWTCustomClaimsMaxBytes <= 0 {
+		return 1024
+	}
+	return c.JWTCustomClaimsMaxBytes
+}
+
+// GetEmailCriticalTemplates returns the IDs of the templates that fall back to a built-in version
+func (c *Config) GetEmailCriticalTemplates() []string {
+	return splitList(c.EmailCriticalTemplates)
+}
+
+// GetEmailSuppressionList returns the addresses and "@domain" entries excluded from broadcasts
+func (c *Config) GetEmailSuppressionList() []string {
+	return splitList(strings.ToLower(c.EmailSuppressionList))
+}
+
+// BroadcastStaleAfterDuration parses how long a running broadcast may go without progress before it
+// is marked failed
+func (c *Config) BroadcastStaleAfterDuration() time.Duration {
+	duration, err := time.ParseDuration(c.BroadcastStaleAfter)
+	if err != nil || duration <= 0 {
+		return 15 * time.Minute
+	}
+	return duration
+}
+
+// EmailProviderRateLimit returns the messages per minute allowed for the configured email provider
+func (c *Config) EmailProviderRateLimit() int {
+	for _, entry := range splitList(c.EmailProviderRateLimits) {
+		provider, limit, found := strings.Cut(entry, "=")
+		if !found || strings.TrimSpace(provider) != c.EmailProvider {
+			continue
+		}
+		if parsed, err := strconv.Atoi(strings.TrimSpace(limit)); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return 100
+}
+
+// LinkTokenByteLength returns the random bytes in each link token, 32 when unset or below the
+// 16-byte minimum
+func (c *Config) LinkTokenByteLength() int {
+	if c.LinkTokenBytes < 16 {
+		return 32
+	}
+	return c.LinkTokenBytes
+}
+
+// EmailRetryIntervalDuration parses how often failed emails are swept for retry; zero disables the sweep
+func (c *Config) EmailRetryIntervalDuration() time.Duration {
+	duration, err := time.ParseDuration(c.EmailRetryInterval)
+	if err != nil {
+		return 15 * time.Minute
+	}
+	return duration
+}
+
+// EmailQueueRedisLeaseDuration parses how long a worker may hold a Redis queue claim before the email
+// is handed out again; an invalid or non-positive value falls back to ten minutes
+func (c *Config) EmailQueueRedisLeaseDuration() time.Duration {
+	duration, err := time.ParseDuration(c.EmailQueueRedisLease)
+	if err != nil || duration <= 0 {
+		return 10 * time.Minute
+	}
+	return duration
+}
+
+// EmailRetryMaxAgeDuration parses how old a failed email may be and still be retried; zero has no limit
+func (c *Config) EmailRetryMaxAgeDuration() time.Duration {
+	duration, err := time.ParseDuration(c.EmailRetryMaxAge)
+	if err != nil || duration < 0 {
+		return 24 * time.Hour
+	}
+	return duration
+}
+
+// UserActivityMetricsIntervalDuration parses how often user activity is counted; zero disables it
+func (c *Config) UserActivityMetricsIntervalDuration() time.Duration {
+	duration, err := time.ParseDuration(c.UserActivityMetricsInterval)
+	if err != nil {
+		return 5 * time.Minute
+	}
+	return duration
+}
+
+// EmailQueueMonitorIntervalDuration parses how often the email queue is measured; zero disables it
+func (c *Config) EmailQueueMonitorIntervalDuration() time.Duration {
+	duration, err := time.ParseDuration(c.EmailQueueMonitorInterval)
+	if err != nil {
+		return time.Minute
+	}
+	return duration
+}
+
+// EmailQueueAlertAgeDuration parses how long the oldest pending email may wait before an alert is
+// raised; zero disables the alert
+func (c *Config) EmailQueueAlertAgeDuration() time.Duration {
+	duration, err := time.ParseDuration(c.EmailQueueAlertAge)
+	if err != nil || duration < 0 {
+		return 30 * time.Minute
+	}
+	return duration
+}
+
 // GetEmailConfig returns email configuration based on provider
 func (c *Config) GetEmailConfig() map[string]any {
 	config := map[string]any{
@@ -359,16 +1557,17 @@ func (c *Config) IsFeatureEnabled(feature string) bool {
 // GetDatabaseConfig returns database configuration map
 func (c *Config) GetDatabaseConfig() map[string]any {
 	return map[string]any{
-		"host":               c.DatabaseHost,
-		"port":               c.DatabasePort,
-		"user":               c.DatabaseUser,
-		"password":           c.DatabasePassword,
-		"name":               c.DatabaseName,
-		"ssl_mode":           c.DatabaseSSLMode,
-		"max_idle_conns":     c.DBMaxIdleConns,
-		"max_open_conns":     c.DBMaxOpenConns,
-		"conn_max_lifetime":  c.DBConnMaxLifetime,
-		"conn_max_idle_time": c.DBConnMaxIdleTime,
+		"host":                   c.DatabaseHost,
+		"port":                   c.DatabasePort,
+		"user":                   c.DatabaseUser,
+		"password":               c.DatabasePassword,
+		"name":                   c.DatabaseName,
+		"ssl_mode":               c.DatabaseSSLMode,
+		"max_idle_conns":         c.DBMaxIdleConns,
+		"max_open_conns":         c.DBMaxOpenConns,
+		"conn_max_lifetime":      c.DBConnMaxLifetime,
+		"conn_max_idle_time":     c.DBConnMaxIdleTime,
+		"migration_lock_timeout": c.MigrationLockTimeout,
 	}
 }
 
@@ -382,5 +1581,7 @@ func (c *Config) MaskSensitiveData() *Config {
 	masked.SendGridAPIKey = MaskedValue
 	masked.PostmarkAPIKey = MaskedValue
 	masked.MailgunAPIKey = MaskedValue
+	masked.SuperAdminPassword = MaskedValue
+	masked.CaptchaSecret = MaskedValue
 	return &masked
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -38,6 +39,15 @@ func TestConfigValidation(t *testing.T) {
 
 	err := cfg.Validate()
 	assert.NoError(t, err)
+
+	cfg.TokenRevocationBackend = "redis"
+	assert.ErrorContains(t, cfg.Validate(), "TOKEN_REVOCATION_REDIS_URL")
+
+	cfg.TokenRevocationRedisURL = "redis://localhost:6379/0"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.TokenRevocationBackend = "database"
+	assert.Error(t, cfg.Validate())
 }
 
 func TestConfigHelperMethods(t *testing.T) {
@@ -77,3 +87,386 @@ func TestDatabaseDSN(t *testing.T) {
 	assert.Contains(t, dsn, "testdb")
 	assert.Contains(t, dsn, "sslmode=disable")
 }
+
+func TestLinkURLTemplates(t *testing.T) {
+	cfg := &Config{FrontendURL: "https://app.example.com/"}
+
+	// Defaults apply when no template is configured
+	assert.Equal(t, "https://app.example.com/verify-email?token=abc123", cfg.EmailVerifyURL("abc123"))
+	assert.Equal(t, "https://app.example.com/reset-password?token=abc123", cfg.PasswordResetURL("abc123"))
+	assert.Equal(t, "https://app.example.com/confirm-backup-email?token=abc123", cfg.BackupEmailURL("abc123"))
+
+	// Token in path is path-escaped
+	cfg.PasswordResetURLTemplate = "{frontend_url}/reset/{token}"
+	assert.Equal(t, "https://app.example.com/reset/a%2Fb%20c", cfg.PasswordResetURL("a/b c"))
+
+	// Token in query is query-escaped
+	cfg.EmailVerifyURLTemplate = "https://auth.example.com/verify?source=email&t={token}"
+	assert.Equal(t, "https://auth.example.com/verify?source=email&t=a%26b%3Dc+d", cfg.EmailVerifyURL("a&b=c d"))
+}
+
+func TestLinkURLTemplateValidation(t *testing.T) {
+	cfg := &Config{FrontendURL: "http://localhost:3000"}
+	assert.NoError(t, cfg.validateLinkTemplates())
+
+	cfg.PasswordResetURLTemplate = "{frontend_url}/reset/{token}"
+	assert.NoError(t, cfg.validateLinkTemplates())
+
+	cfg.PasswordResetURLTemplate = "{frontend_url}/reset"
+	assert.Error(t, cfg.validateLinkTemplates())
+
+	cfg.PasswordResetURLTemplate = "{frontend_url}/reset/{token}?again={token}"
+	assert.Error(t, cfg.validateLinkTemplates())
+
+	cfg.PasswordResetURLTemplate = "/reset/{token}"
+	assert.Error(t, cfg.validateLinkTemplates())
+}
+
+func TestEmailSubjectPrefixForEnvironment(t *testing.T) {
+	cfg := &Config{EmailSubjectPrefixEnvironments: "development, staging"}
+
+	cfg.Environment = "staging"
+	assert.Equal(t, "[STAGING]", cfg.EmailSubjectPrefixForEnvironment())
+
+	cfg.EmailSubjectPrefix = "[QA]"
+	assert.Equal(t, "[QA]", cfg.EmailSubjectPrefixForEnvironment())
+
+	cfg.Environment = "production"
+	assert.Empty(t, cfg.EmailSubjectPrefixForEnvironment())
+
+	cfg.EmailSubjectPrefixEnvironments = ""
+	cfg.Environment = "staging"
+	assert.Empty(t, cfg.EmailSubjectPrefixForEnvironment())
+}
+
+func TestEmailProviderRateLimit(t *testing.T) {
+	cfg := &Config{EmailProvider: "sendgrid", EmailProviderRateLimits: "smtp=50, sendgrid = 600"}
+	assert.Equal(t, 600, cfg.EmailProviderRateLimit())
+
+	cfg.EmailProvider = "mailgun"
+	assert.Equal(t, 100, cfg.EmailProviderRateLimit())
+
+	cfg.EmailProviderRateLimits = "mailgun=0"
+	assert.Equal(t, 100, cfg.EmailProviderRateLimit())
+}
+
+func TestGetEmailSuppressionList(t *testing.T) {
+	cfg := &Config{EmailSuppressionList: "Bounced@Example.com, @blocked.dev,"}
+	assert.Equal(t, []string{"bounced@example.com", "@blocked.dev"}, cfg.GetEmailSuppressionList())
+}
+
+func TestRegistrationDomainLists(t *testing.T) {
+	cfg := &Config{RegistrationAllowedDomains: "Acme.com, *.partner.io", RegistrationBlockedDomains: ""}
+	assert.Equal(t, []string{"acme.com", "*.partner.io"}, cfg.GetRegistrationAllowedDomains())
+	assert.Empty(t, cfg.GetRegistrationBlockedDomains())
+}
+
+func TestSecurityAlertResponseEnabled(t *testing.T) {
+	cfg := &Config{SecurityAlertResponses: "require_step_up, tighten_login_limits"}
+	assert.True(t, cfg.SecurityAlertResponseEnabled("require_step_up"))
+	assert.True(t, cfg.SecurityAlertResponseEnabled("tighten_login_limits"))
+
+	cfg.SecurityAlertResponses = ""
+	assert.False(t, cfg.SecurityAlertResponseEnabled("require_step_up"))
+}
+
+func TestSecurityAlertNotifications(t *testing.T) {
+	cfg := &Config{SecurityAlertEmailRecipients: "Security@Example.com, oncall@example.com, security@example.com,"}
+	assert.Equal(t, []string{"security@example.com", "oncall@example.com"}, cfg.GetSecurityAlertEmailRecipients())
+
+	// Every type is sent until the list narrows it
+	assert.True(t, cfg.SecurityAlertNotifies("email_queue_depth"))
+
+	cfg.SecurityAlertNotifyTypes = "role_change, mass_deletion"
+	assert.True(t, cfg.SecurityAlertNotifies("mass_deletion"))
+	assert.False(t, cfg.SecurityAlertNotifies("email_queue_depth"))
+}
+
+func TestCheckSecretStrength(t *testing.T) {
+	strong := []string{
+		"9f86d081884c7d659a2feaa0c55ad015", // 32 hex characters
+		"q2Zr8Jb1X0vKpL6nYw4TcHsE9uMaGdFi7oRzVxNj3Bk=",
+		"Tr0ub4dor&3-correct-horse-battery-staple-91",
+	}
+	for _, secret := range strong {
+		assert.NoError(t, checkSecretStrength(secret), secret)
+	}
+
+	weak := []string{
+		"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"abcabcabcabcabcabcabcabcabcabcabc",
+		"Password123Password123Password123",
+		"abcdefghijklmnopqrstuvwxyz0123456789",
+		"9876543210zyxwvutsrqponmlkjihgfedcba",
+		"aabbaabbccddccddaabbaabbccddccddxy",
+	}
+	for _, secret := range weak {
+		assert.ErrorIs(t, checkSecretStrength(secret), ErrWeakSecret, secret)
+	}
+}
+
+func TestJWTSecretStrengthModes(t *testing.T) {
+	weak := "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	always := &Config{JWTSecret: weak, JWTSecretStrength: SecretStrengthAlways}
+	assert.ErrorIs(t, always.validateJWTSecretStrength(), ErrWeakSecret)
+
+	always.JWTSecret = "q2Zr8Jb1X0vKpL6nYw4TcHsE9uMaGdFi7oRzVxNj3Bk="
+	assert.NoError(t, always.validateJWTSecretStrength())
+
+	// The production mode only applies with the other production checks
+	production := &Config{JWTSecret: weak, JWTSecretStrength: SecretStrengthProduction, DatabaseSSLMode: "require"}
+	assert.NoError(t, production.validateJWTSecretStrength())
+	assert.ErrorContains(t, production.validateProductionSettings(), "JWT_SECRET is too weak")
+
+	off := &Config{JWTSecret: weak, JWTSecretStrength: SecretStrengthOff, DatabaseSSLMode: "require"}
+	assert.NoError(t, off.validateProductionSettings())
+}
+
+func TestRegistrationDefaults(t *testing.T) {
+	cfg := &Config{RegistrationDefaultStatus: "pending"}
+	assert.True(t, cfg.RegistrationRequiresApproval())
+
+	cfg.RegistrationDefaultStatus = "active"
+	assert.False(t, cfg.RegistrationRequiresApproval())
+}
+
+func TestRegistrationDefaultsValidation(t *testing.T) {
+	valid := func() *Config {
+		return &Config{
+			Environment:               "development",
+			Port:                      "8080",
+			LogLevel:                  "info",
+			DatabaseHost:              "localhost",
+			DatabasePort:              "5432",
+			DatabaseUser:              "test",
+			DatabaseName:              "test",
+			DatabaseSSLMode:           "disable",
+			DBMaxIdleConns:            10,
+			DBMaxOpenConns:            100,
+			DBConnMaxLifetime:         "1h",
+			JWTSecret:                 "test-secret-key-for-testing-only-32chars",
+			JWTAccessTokenDuration:    "15m",
+			JWTRefreshTokenDuration:   "7d",
+			EmailProvider:             "smtp",
+			SMTPPort:                  587,
+			FrontendURL:               "http://localhost:3000",
+			BackendURL:                "http://localhost:8080",
+			CSRFSecret:                "test-csrf-secret-32-characters-long",
+			StorageProvider:           "local",
+			RegistrationDefaultRole:   "user",
+			RegistrationDefaultStatus: "pending",
+		}
+	}
+
+	assert.NoError(t, valid().Validate())
+
+	unknownStatus := valid()
+	unknownStatus.RegistrationDefaultStatus = "suspended"
+	assert.Error(t, unknownStatus.Validate())
+
+	superAdmin := valid()
+	superAdmin.RegistrationDefaultRole = "super_admin"
+	assert.Error(t, superAdmin.Validate())
+
+	// Admin signups are only allowed behind approval
+	approvedAdmin := valid()
+	approvedAdmin.RegistrationDefaultRole = "admin"
+	assert.NoError(t, approvedAdmin.Validate())
+
+	openAdmin := valid()
+	openAdmin.RegistrationDefaultRole = "admin"
+	openAdmin.RegistrationDefaultStatus = "active"
+	assert.ErrorContains(t, openAdmin.Validate(), "REGISTRATION_DEFAULT_ROLE=admin")
+
+	badRecipient := valid()
+	badRecipient.SecurityAlertEmailRecipients = "security@example.com, on-call"
+	assert.ErrorContains(t, badRecipient.Validate(), "SECURITY_ALERT_EMAIL_RECIPIENTS")
+
+	notifyTypes := valid()
+	notifyTypes.SecurityAlertNotifyTypes = "role_change, mass_deletion"
+	assert.NoError(t, notifyTypes.Validate())
+
+	unknownNotifyType := valid()
+	unknownNotifyType.SecurityAlertNotifyTypes = "role_change, mass_deletions"
+	assert.ErrorContains(t, unknownNotifyType.Validate(), `SECURITY_ALERT_NOTIFY_TYPES entries must be one of role_change`)
+
+	longGrace := valid()
+	longGrace.PasswordLoginGrace = "48h"
+	assert.ErrorContains(t, longGrace.Validate(), "PASSWORD_LOGIN_GRACE")
+
+	captchaWithoutService := valid()
+	captchaWithoutService.LoginCaptchaThreshold = 5
+	assert.ErrorContains(t, captchaWithoutService.Validate(), "LOGIN_CAPTCHA_THRESHOLD")
+
+	captcha := valid()
+	captcha.LoginCaptchaThreshold = 5
+	captcha.CaptchaVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+	captcha.CaptchaSecret = "secret"
+	assert.NoError(t, captcha.Validate())
+
+	vendorJSON := valid()
+	vendorJSON.JSONContentType = "application/vnd.api+json"
+	assert.NoError(t, vendorJSON.Validate())
+
+	notJSON := valid()
+	notJSON.JSONContentType = "text/plain; charset=utf-8"
+	assert.ErrorContains(t, notJSON.Validate(), "JSON_CONTENT_TYPE")
+
+	for _, maxAge := range []string{"12", "-1h", "forever"} {
+		badMaxAge := valid()
+		badMaxAge.SessionMaxAge = maxAge
+		assert.ErrorContains(t, badMaxAge.Validate(), "SESSION_MAX_AGE", maxAge)
+	}
+
+	maxAge := valid()
+	maxAge.SessionMaxAge = "720h"
+	assert.NoError(t, maxAge.Validate())
+
+	redisWithoutURL := valid()
+	redisWithoutURL.EmailQueueBackend = "redis"
+	assert.ErrorContains(t, redisWithoutURL.Validate(), "EMAIL_QUEUE_REDIS_URL")
+
+	redisQueue := valid()
+	redisQueue.EmailQueueBackend = "redis"
+	redisQueue.EmailQueueRedisURL = "redis://localhost:6379/0"
+	assert.NoError(t, redisQueue.Validate())
+
+	unknownBackend := valid()
+	unknownBackend.EmailQueueBackend = "memory"
+	assert.Error(t, unknownBackend.Validate())
+
+	resetWithoutForgot := valid()
+	resetWithoutForgot.AuthDisableResetPassword = true
+	assert.ErrorContains(t, resetWithoutForgot.Validate(), "AUTH_DISABLE_FORGOT_PASSWORD")
+
+	noResets := valid()
+	noResets.AuthDisableResetPassword = true
+	noResets.AuthDisableForgotPassword = true
+	assert.NoError(t, noResets.Validate())
+	noResets.AccountRecoveryMethods = "backup_email"
+	assert.ErrorContains(t, noResets.Validate(), "ACCOUNT_RECOVERY_METHODS")
+
+	noSignups := valid()
+	noSignups.AuthDisableRegister = true
+	assert.NoError(t, noSignups.Validate())
+	noSignups.EmailAvailabilityCheck = true
+	assert.ErrorContains(t, noSignups.Validate(), "EMAIL_AVAILABILITY_CHECK")
+
+	usernameLengths := valid()
+	usernameLengths.UsernameMinLength = 20
+	usernameLengths.UsernameMaxLength = 10
+	assert.ErrorContains(t, usernameLengths.Validate(), "USERNAME_MIN_LENGTH")
+}
+
+func TestGetUsernameReserved(t *testing.T) {
+	cfg := &Config{UsernameReserved: "Admin, support,,root"}
+	assert.Equal(t, []string{"admin", "support", "root"}, cfg.GetUsernameReserved())
+	assert.Empty(t, (&Config{}).GetUsernameReserved())
+}
+
+func TestJSONContentTypeValue(t *testing.T) {
+	assert.Equal(t, "application/json; charset=utf-8", (&Config{}).JSONContentTypeValue())
+	assert.Equal(t, "application/json", (&Config{JSONContentType: "application/json"}).JSONContentTypeValue())
+}
+
+func TestPasswordLoginGraceDuration(t *testing.T) {
+	assert.Equal(t, 15*time.Minute, (&Config{PasswordLoginGrace: "15m"}).PasswordLoginGraceDuration())
+	assert.Zero(t, (&Config{PasswordLoginGrace: "0"}).PasswordLoginGraceDuration())
+	assert.Zero(t, (&Config{PasswordLoginGrace: "soon"}).PasswordLoginGraceDuration())
+}
+
+func TestPasswordResetEmailIntervalDuration(t *testing.T) {
+	assert.Equal(t, time.Minute, (&Config{}).PasswordResetEmailIntervalDuration())
+	assert.Equal(t, 5*time.Minute, (&Config{PasswordResetEmailInterval: "5m"}).PasswordResetEmailIntervalDuration())
+	assert.Zero(t, (&Config{PasswordResetEmailInterval: "0"}).PasswordResetEmailIntervalDuration())
+	assert.Equal(t, time.Minute, (&Config{PasswordResetEmailInterval: "-1s"}).PasswordResetEmailIntervalDuration())
+}
+
+func TestSessionMaxAgeDuration(t *testing.T) {
+	assert.Zero(t, (&Config{}).SessionMaxAgeDuration())
+	assert.Zero(t, (&Config{SessionMaxAge: "0"}).SessionMaxAgeDuration())
+	assert.Equal(t, 12*time.Hour, (&Config{SessionMaxAge: "12h"}).SessionMaxAgeDuration())
+	assert.Zero(t, (&Config{SessionMaxAge: "-1h"}).SessionMaxAgeDuration())
+	assert.Zero(t, (&Config{SessionMaxAge: "soon"}).SessionMaxAgeDuration())
+}
+
+func TestEmailRetryDurations(t *testing.T) {
+	cfg := &Config{EmailRetryInterval: "0", EmailRetryMaxAge: "0"}
+	assert.Equal(t, time.Duration(0), cfg.EmailRetryIntervalDuration())
+	assert.Equal(t, time.Duration(0), cfg.EmailRetryMaxAgeDuration())
+
+	cfg = &Config{EmailRetryInterval: "bogus", EmailRetryMaxAge: "-1h"}
+	assert.Equal(t, 15*time.Minute, cfg.EmailRetryIntervalDuration())
+	assert.Equal(t, 24*time.Hour, cfg.EmailRetryMaxAgeDuration())
+}
+
+func TestEmailQueueMonitorDurations(t *testing.T) {
+	cfg := &Config{EmailQueueMonitorInterval: "0", EmailQueueAlertAge: "0"}
+	assert.Equal(t, time.Duration(0), cfg.EmailQueueMonitorIntervalDuration())
+	assert.Equal(t, time.Duration(0), cfg.EmailQueueAlertAgeDuration())
+
+	cfg = &Config{EmailQueueMonitorInterval: "bogus", EmailQueueAlertAge: "-1m"}
+	assert.Equal(t, time.Minute, cfg.EmailQueueMonitorIntervalDuration())
+	assert.Equal(t, 30*time.Minute, cfg.EmailQueueAlertAgeDuration())
+}
+
+func TestEmailQueueRedisLeaseDuration(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, (&Config{EmailQueueRedisLease: "5m"}).EmailQueueRedisLeaseDuration())
+	assert.Equal(t, 10*time.Minute, (&Config{EmailQueueRedisLease: "0"}).EmailQueueRedisLeaseDuration())
+	assert.Equal(t, 10*time.Minute, (&Config{EmailQueueRedisLease: "bogus"}).EmailQueueRedisLeaseDuration())
+}
+
+func TestAdminExportDurations(t *testing.T) {
+	cfg := &Config{AdminExportInterval: "0", AdminExportLinkTTL: "15m"}
+	assert.Equal(t, time.Duration(0), cfg.AdminExportIntervalDuration())
+	assert.Equal(t, 30*time.Minute, cfg.AdminExportTimeoutDuration())
+	assert.Equal(t, 24*time.Hour, cfg.AdminExportRetentionDuration())
+	assert.Equal(t, 15*time.Minute, cfg.AdminExportLinkTTLDuration())
+
+	cfg = &Config{AdminExportInterval: "bogus", AdminExportRetention: "-1h", AdminExportLinkTTL: "0"}
+	assert.Equal(t, 10*time.Second, cfg.AdminExportIntervalDuration())
+	assert.Equal(t, 24*time.Hour, cfg.AdminExportRetentionDuration())
+	assert.Equal(t, time.Hour, cfg.AdminExportLinkTTLDuration())
+}
+
+func TestVerificationResendWindowDuration(t *testing.T) {
+	assert.Equal(t, 30*time.Minute, (&Config{VerificationResendWindow: "30m"}).VerificationResendWindowDuration())
+	assert.Equal(t, time.Hour, (&Config{VerificationResendWindow: "0"}).VerificationResendWindowDuration())
+	assert.Equal(t, time.Hour, (&Config{VerificationResendWindow: "bogus"}).VerificationResendWindowDuration())
+}
+
+func TestSecureCookies(t *testing.T) {
+	tests := []struct {
+		name         string
+		environment  string
+		cookieSecure string
+		requestHTTPS bool
+		want         bool
+	}{
+		{"production", "production", "", false, true},
+		{"development over http", "development", "", false, false},
+		{"development over https", "development", "", true, true},
+		{"forced on in development", "development", "true", false, true},
+		{"forced off in production", "production", "false", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Environment: tt.environment, CookieSecure: tt.cookieSecure}
+			assert.Equal(t, tt.want, cfg.SecureCookies(tt.requestHTTPS))
+		})
+	}
+}
+
+func TestGetInfoFields(t *testing.T) {
+	assert.Equal(t, InfoFieldNames, (&Config{Environment: "development"}).GetInfoFields())
+	assert.Equal(t, []string{"name", "version", "commit", "build_time"}, (&Config{Environment: "production"}).GetInfoFields())
+	assert.Equal(t, []string{"version", "features"}, (&Config{Environment: "production", InfoFields: "version, features"}).GetInfoFields())
+}
+
+func TestSecurityStatsLimit(t *testing.T) {
+	assert.Equal(t, 25, (&Config{SecurityStatsMaxLimit: 25}).SecurityStatsLimit())
+	assert.Equal(t, 100, (&Config{}).SecurityStatsLimit())
+	assert.Equal(t, 100, (&Config{SecurityStatsMaxLimit: -1}).SecurityStatsLimit())
+}
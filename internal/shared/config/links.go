@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Placeholders supported in link URL templates
+const (
+	placeholderFrontendURL = "{frontend_url}"
+	placeholderToken       = "{token}"
+)
+
+// Default link URL templates, matching the bundled frontend routes
+const (
+	DefaultEmailVerifyURLTemplate   = "{frontend_url}/verify-email?token={token}"
+	DefaultPasswordResetURLTemplate = "{frontend_url}/reset-password?token={token}"
+	DefaultBackupEmailURLTemplate   = "{frontend_url}/confirm-backup-email?token={token}"
+)
+
+// EmailVerifyURL builds the email verification link for a token
+func (c *Config) EmailVerifyURL(token string) string {
+	return c.buildLinkURL(c.EmailVerifyURLTemplate, DefaultEmailVerifyURLTemplate, token)
+}
+
+// PasswordResetURL builds the password reset link for a token
+func (c *Config) PasswordResetURL(token string) string {
+	return c.buildLinkURL(c.PasswordResetURLTemplate, DefaultPasswordResetURLTemplate, token)
+}
+
+// BackupEmailURL builds the link confirming a new backup email for a token
+func (c *Config) BackupEmailURL(token string) string {
+	return c.buildLinkURL(c.BackupEmailURLTemplate, DefaultBackupEmailURLTemplate, token)
+}
+
+// buildLinkURL substitutes the frontend URL and token into a template. The token is
+// query-escaped when it appears in the query string and path-escaped otherwise.
+func (c *Config) buildLinkURL(template, fallback, token string) string {
+	if template == "" {
+		template = fallback
+	}
+
+	link := strings.ReplaceAll(template, placeholderFrontendURL, strings.TrimRight(c.FrontendURL, "/"))
+
+	escaped := url.PathEscape(token)
+	if query := strings.Index(link, "?"); query >= 0 && query < strings.Index(link, placeholderToken) {
+		escaped = url.QueryEscape(token)
+	}
+
+	return strings.ReplaceAll(link, placeholderToken, escaped)
+}
+
+// validateLinkTemplates checks that configured link templates produce absolute URLs
+func (c *Config) validateLinkTemplates() error {
+	templates := map[string]string{
+		"EMAIL_VERIFY_URL_TEMPLATE":   c.EmailVerifyURLTemplate,
+		"PASSWORD_RESET_URL_TEMPLATE": c.PasswordResetURLTemplate,
+		"BACKUP_EMAIL_URL_TEMPLATE":   c.BackupEmailURLTemplate,
+	}
+
+	for name, template := range templates {
+		if template == "" {
+			continue
+		}
+		if err := c.validateLinkTemplate(template); err != nil {
+			return fmt.Errorf("%s is invalid: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateLinkTemplate checks a single link template
+func (c *Config) validateLinkTemplate(template string) error {
+	if strings.Count(template, placeholderToken) != 1 {
+		return fmt.Errorf("template must contain %s exactly once", placeholderToken)
+	}
+
+	parsed, err := url.Parse(c.buildLinkURL(template, "", "sample-token"))
+	if err != nil {
+		return err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("template must produce an absolute http(s) URL")
+	}
+
+	return nil
+}
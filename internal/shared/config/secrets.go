@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// JWT secret strength modes
+const (
+	SecretStrengthOff        = "off"
+	SecretStrengthProduction = "production"
+	SecretStrengthAlways     = "always"
+)
+
+// Limits for accepting a secret. A random 32 character hex string estimates at about 3.6 bits per
+// character, so these reject repetitive secrets without rejecting hex or base64 keys.
+const (
+	minSecretBitsPerChar    = 3.0
+	minSecretEntropyBits    = 96.0
+	maxSecretPatternedRatio = 0.5
+)
+
+// ErrWeakSecret is returned for secrets that are long enough but easy to guess
+var ErrWeakSecret = errors.New("is too weak")
+
+// validateJWTSecretStrength checks the JWT secret in every environment when JWT_SECRET_STRENGTH=always;
+// the "production" mode is checked with the other production settings instead
+func (c *Config) validateJWTSecretStrength() error {
+	if c.JWTSecretStrength != SecretStrengthAlways {
+		return nil
+	}
+	if err := checkSecretStrength(c.JWTSecret); err != nil {
+		return fmt.Errorf("JWT_SECRET %w", err)
+	}
+	return nil
+}
+
+// checkSecretStrength rejects secrets made of a repeated block, dominated by runs of repeated or
+// sequential characters ("aaaa", "abcd", "4321"), or with too little Shannon entropy
+func checkSecretStrength(secret string) error {
+	chars := []rune(secret)
+	if len(chars) == 0 {
+		return fmt.Errorf("%w: it is empty", ErrWeakSecret)
+	}
+
+	if period := repeatPeriod(chars); period > 0 {
+		return fmt.Errorf("%w: it repeats a %d character block", ErrWeakSecret, period)
+	}
+
+	if patternedRatio(chars) > maxSecretPatternedRatio {
+		return fmt.Errorf("%w: it is mostly repeated or sequential characters", ErrWeakSecret)
+	}
+
+	perChar := shannonEntropy(chars)
+	if total := perChar * float64(len(chars)); perChar < minSecretBitsPerChar || total < minSecretEntropyBits {
+		return fmt.Errorf("%w: estimated entropy is %.0f bits (%.1f per character), need %.0f (%.1f per character)",
+			ErrWeakSecret, total, perChar, minSecretEntropyBits, minSecretBitsPerChar)
+	}
+
+	return nil
+}
+
+// shannonEntropy returns the average bits of information per character
+func shannonEntropy(chars []rune) float64 {
+	counts := make(map[rune]int)
+	for _, char := range chars {
+		counts[char]++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(len(chars))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// repeatPeriod returns the length of the block the secret repeats, or 0 when it is not a repetition
+func repeatPeriod(chars []rune) int {
+	for period := 1; period <= len(chars)/2; period++ {
+		repeats := true
+		for i := period; i < len(chars); i++ {
+			if chars[i] != chars[i-period] {
+				repeats = false
+				break
+			}
+		}
+		if repeats {
+			return period
+		}
+	}
+	return 0
+}
+
+// patternedRatio returns the share of characters that continue a run of repeated or sequential
+// characters, i.e. that step from the previous character the same way it stepped from the one before
+func patternedRatio(chars []rune) float64 {
+	if len(chars) < 3 {
+		return 0
+	}
+
+	patterned := 0
+	for i := 2; i < len(chars); i++ {
+		step := chars[i] - chars[i-1]
+		if step == chars[i-1]-chars[i-2] && step >= -1 && step <= 1 {
+			patterned++
+		}
+	}
+	return float64(patterned) / float64(len(chars))
+}
@@ -0,0 +1,126 @@
+// Package cursor encodes keyset pagination positions as opaque, signed cursors. A cursor carries a
+// fingerprint of the filters of the query that issued it, so it can't be edited to jump elsewhere
+// or replayed against a differently filtered query; both are rejected instead of silently returning
+// the wrong page. Every list endpoint paginating by cursor should issue and read them through a Codec.
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// version is bumped whenever the encoded layout changes, so older cursors are rejected cleanly
+const version = 1
+
+var (
+	// ErrInvalid is returned for a cursor that is malformed, was altered or was signed with another secret
+	ErrInvalid = errors.New("invalid cursor")
+	// ErrFilterMismatch is returned for a genuine cursor issued for a query with different filters
+	ErrFilterMismatch = errors.New("cursor was issued for different filters")
+)
+
+// payload is what a cursor carries under its signature
+type payload struct {
+	Version     int             `json:"v"`
+	Fingerprint string          `json:"f"`
+	Position    json.RawMessage `json:"p"`
+}
+
+// Codec signs and checks cursors with a server secret. It is safe for concurrent use.
+type Codec struct {
+	key []byte
+}
+
+// NewCodec creates a codec signing with secret. Cursors only decode on servers sharing the secret.
+func NewCodec(secret string) *Codec {
+	// Derive a key of its own so a cursor signature can never stand in for another use of the secret
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("pagination-cursor"))
+	return &Codec{key: mac.Sum(nil)}
+}
+
+// Fingerprint identifies the filters a query was run with. Order doesn't matter, and an empty
+// value is treated the same as a missing one.
+func Fingerprint(filters map[string]string) string {
+	keys := make([]string, 0, len(filters))
+	for key, value := range filters {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([][2]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = [2]string{key, filters[key]}
+	}
+	// JSON keeps keys and values that contain separators unambiguous
+	encoded, _ := json.Marshal(pairs)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:16])
+}
+
+// Encode returns the cursor for a keyset position, any value that marshals to JSON, valid only for
+// queries with the given filter fingerprint
+func (c *Codec) Encode(position interface{}, fingerprint string) (string, error) {
+	raw, err := json.Marshal(position)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor position: %w", err)
+	}
+
+	body, err := json.Marshal(payload{Version: version, Fingerprint: fingerprint, Position: raw})
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(body) + "." + base64.RawURLEncoding.EncodeToString(c.sign(body)), nil
+}
+
+// Decode checks a cursor's signature and fingerprint and unmarshals its position into position. It
+// returns ErrInvalid for anything not issued by this secret and ErrFilterMismatch for a cursor from
+// a differently filtered query.
+func (c *Codec) Decode(cursor, fingerprint string, position interface{}) error {
+	encodedBody, encodedSignature, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return ErrInvalid
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(encodedBody)
+	if err != nil {
+		return ErrInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return ErrInvalid
+	}
+	if !hmac.Equal(signature, c.sign(body)) {
+		return ErrInvalid
+	}
+
+	var decoded payload
+	if err := json.Unmarshal(body, &decoded); err != nil || decoded.Version != version {
+		return ErrInvalid
+	}
+	if decoded.Fingerprint != fingerprint {
+		return ErrFilterMismatch
+	}
+
+	if err := json.Unmarshal(decoded.Position, position); err != nil {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// sign returns the HMAC-SHA256 of body
+func (c *Codec) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
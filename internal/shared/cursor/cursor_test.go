@@ -0,0 +1,91 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// position is a typical keyset position: the sort value of the last row and its ID as a tiebreaker
+type position struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        uint      `json:"id"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	codec := NewCodec("test-secret")
+	fingerprint := Fingerprint(map[string]string{"action": "login_failed", "level": "warning"})
+	want := position{CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), ID: 42}
+
+	cursor, err := codec.Encode(want, fingerprint)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotContains(t, cursor, "42", "cursors are opaque, not readable query parameters")
+
+	var got position
+	assert.NoError(t, codec.Decode(cursor, fingerprint, &got))
+	assert.Equal(t, want, got)
+}
+
+func TestTamperRejection(t *testing.T) {
+	codec := NewCodec("test-secret")
+	fingerprint := Fingerprint(nil)
+	cursor, err := codec.Encode(position{ID: 42}, fingerprint)
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, signature, _ := strings.Cut(cursor, ".")
+
+	// Re-encode the body with another ID, keeping the original signature
+	flipped := "A"
+	if signature[0] == 'A' {
+		flipped = "B"
+	}
+	decoded, _ := base64.RawURLEncoding.DecodeString(body)
+	edited := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(decoded), "42", "43", 1)))
+
+	tests := map[string]string{
+		"edited position":   edited + "." + signature,
+		"edited signature":  body + "." + flipped + signature[1:],
+		"missing signature": body,
+		"not base64":        "!!!." + signature,
+		"empty":             "",
+	}
+	for name, tampered := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got position
+			assert.ErrorIs(t, codec.Decode(tampered, fingerprint, &got), ErrInvalid)
+		})
+	}
+
+	t.Run("another secret", func(t *testing.T) {
+		var got position
+		assert.ErrorIs(t, NewCodec("other-secret").Decode(cursor, fingerprint, &got), ErrInvalid)
+	})
+}
+
+func TestFilterMismatch(t *testing.T) {
+	codec := NewCodec("test-secret")
+	cursor, err := codec.Encode(position{ID: 42}, Fingerprint(map[string]string{"status": "active"}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var got position
+	err = codec.Decode(cursor, Fingerprint(map[string]string{"status": "suspended"}), &got)
+	assert.ErrorIs(t, err, ErrFilterMismatch)
+	assert.Zero(t, got)
+}
+
+func TestFingerprint(t *testing.T) {
+	a := Fingerprint(map[string]string{"status": "active", "role": "admin"})
+	assert.Equal(t, a, Fingerprint(map[string]string{"role": "admin", "status": "active"}))
+	assert.Equal(t, a, Fingerprint(map[string]string{"role": "admin", "status": "active", "search": ""}))
+
+	// Values that would run together without a separator still differ
+	assert.NotEqual(t, Fingerprint(map[string]string{"ab": "c"}), Fingerprint(map[string]string{"a": "bc"}))
+}
@@ -3,28 +3,56 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 
+	admindomain "github.com/acheevo/tfa/internal/admin/domain"
 	"github.com/acheevo/tfa/internal/auth/domain"
+	featuredomain "github.com/acheevo/tfa/internal/features/domain"
 	"github.com/acheevo/tfa/internal/shared/database/migrations"
 	"github.com/acheevo/tfa/internal/shared/database/seed"
 	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
+	userdomain "github.com/acheevo/tfa/internal/user/domain"
 )
 
+// DefaultMigrationLockTimeout is how long startup waits for another replica to finish migrating
+const DefaultMigrationLockTimeout = 5 * time.Minute
+
+const (
+	// migrationLockKey identifies the Postgres advisory lock held while the schema is migrated
+	migrationLockKey          int64 = 0x74666124
+	migrationLockPollInterval       = 250 * time.Millisecond
+)
+
+// ErrMigrationLockTimeout is returned when the migration lock could not be acquired in time
+var ErrMigrationLockTimeout = errors.New("timed out waiting for migration lock")
+
 type DB struct {
 	*gorm.DB
 	sqlDB    *sql.DB
 	migrator *migrations.Migrator
 	seeder   *seed.Seeder
 	logger   *slog.Logger
+	migrated atomic.Bool
 }
 
+// New connects to the database and migrates the schema, waiting up to DefaultMigrationLockTimeout
+// for other replicas that are migrating at the same time
 func New(dsn string, isDevelopment bool, logger *slog.Logger, environment string) (*DB, error) {
+	return NewWithMigrationLockTimeout(dsn, isDevelopment, logger, environment, DefaultMigrationLockTimeout)
+}
+
+// NewWithMigrationLockTimeout is New with a custom wait for the migration lock
+func NewWithMigrationLockTimeout(
+	dsn string, isDevelopment bool, logger *slog.Logger, environment string, lockTimeout time.Duration,
+) (*DB, error) {
 	logLevel := gormlogger.Silent
 	if isDevelopment {
 		logLevel = gormlogger.Info
@@ -56,14 +84,68 @@ func New(dsn string, isDevelopment bool, logger *slog.Logger, environment string
 	// Initialize seeders
 	db.initializeSeeders()
 
-	// Auto-migrate authentication tables (legacy support)
-	if err := db.migrate(); err != nil {
+	// Auto-migrate authentication tables (legacy support); only one replica migrates at a time
+	if err := db.WithMigrationLock(lockTimeout, db.migrate); err != nil {
+		_ = sqlDB.Close()
 		return nil, err
 	}
+	db.migrated.Store(true)
 
 	return db, nil
 }
 
+// MigrationsComplete reports whether the schema has been migrated, so readiness can wait for it
+func (db *DB) MigrationsComplete() bool {
+	return db.migrated.Load()
+}
+
+// WithMigrationLock runs fn while holding the cluster-wide migration advisory lock, waiting up to
+// timeout for a replica that holds it. The lock is session scoped, so it is taken and released on
+// one dedicated connection.
+func (db *DB) WithMigrationLock(timeout time.Duration, fn func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := db.sqlDB.Conn(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w after %s", ErrMigrationLockTimeout, timeout)
+		}
+		return fmt.Errorf("acquire migration lock connection: %w", err)
+	}
+	defer conn.Close()
+
+	for waited := false; ; waited = true {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockKey).Scan(&acquired); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w after %s", ErrMigrationLockTimeout, timeout)
+			}
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		if acquired {
+			break
+		}
+		if !waited {
+			db.logger.Info("waiting for another instance to finish database migrations")
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w after %s", ErrMigrationLockTimeout, timeout)
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			db.logger.Error("failed to release migration lock", "error", err)
+		}
+	}()
+
+	return fn()
+}
+
 func (db *DB) SetConnectionPool(maxIdleConns, maxOpenConns int, maxLifetime time.Duration) error {
 	db.sqlDB.SetMaxIdleConns(maxIdleConns)
 	db.sqlDB.SetMaxOpenConns(maxOpenConns)
@@ -81,14 +163,67 @@ func (db *DB) Ping() error {
 
 // migrate runs database migrations for all models (legacy support)
 func (db *DB) migrate() error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&domain.User{},
 		&domain.RefreshToken{},
 		&domain.PasswordReset{},
 		&domain.AuditLog{},
+		&domain.SecurityAlert{},
+		&domain.RecoveryRequest{},
 		&emaildomain.QueuedEmail{},
 		&emaildomain.EmailDeliveryEvent{},
-	)
+		&featuredomain.FeatureOverride{},
+		&admindomain.BroadcastJob{},
+		&admindomain.ExportJob{},
+		&userdomain.UserConsent{},
+	); err != nil {
+		return err
+	}
+
+	if err := db.migrateCaseInsensitiveEmail(); err != nil {
+		return err
+	}
+	return db.migrateAuditHistoryIndexes()
+}
+
+// migrateCaseInsensitiveEmail lowercases stored emails and adds a unique index on lower(email),
+// so "User@x.com" and "user@x.com" can no longer coexist among active users. Existing mixed-case duplicates make
+// this fail loudly and have to be merged by hand.
+func (db *DB) migrateCaseInsensitiveEmail() error {
+	if err := db.Exec(`UPDATE users SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email))`).Error; err != nil {
+		return fmt.Errorf("normalize user emails: %w", err)
+	}
+	return db.migrateActiveEmailIndex()
+}
+
+// migrateActiveEmailIndex makes email unique among active users only. A soft-deleted user keeps its row
+// until purged, and with a plain unique index its email could never be registered again, whatever
+// USER_DELETED_EMAIL_REUSE says; the services decide whether deleted users' emails are taken. The
+// partial index is created before the older full indexes are dropped so email is never left unguarded.
+func (db *DB) migrateActiveEmailIndex() error {
+	if err := db.Exec(
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower_active ON users (LOWER(email)) WHERE deleted_at IS NULL`,
+	).Error; err != nil {
+		return fmt.Errorf("create active email index: %w", err)
+	}
+	for _, index := range []string{"idx_users_email_lower", "idx_users_email"} {
+		if err := db.Exec(`DROP INDEX IF EXISTS ` + index).Error; err != nil {
+			return fmt.Errorf("drop email index %s: %w", index, err)
+		}
+	}
+	return nil
+}
+
+// migrateAuditHistoryIndexes adds the indexes behind the paginated per-user audit history, which
+// reads entries where the user is either the actor or the target, newest first
+func (db *DB) migrateAuditHistoryIndexes() error {
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_audit_logs_user_created ON audit_logs (user_id, created_at DESC)`).Error; err != nil {
+		return fmt.Errorf("create audit log user history index: %w", err)
+	}
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_audit_logs_target_created ON audit_logs (target_id, created_at DESC)`).Error; err != nil {
+		return fmt.Errorf("create audit log target history index: %w", err)
+	}
+	return nil
 }
 
 // GetMigrator returns the database migrator
@@ -24,6 +24,9 @@ var (
 	// ErrEmailNotFound is returned when an email is not found in the queue
 	ErrEmailNotFound = errors.New("email not found")
 
+	// ErrEmailNotCancellable is returned when an email is already being sent or is no longer scheduled
+	ErrEmailNotCancellable = errors.New("email can no longer be canceled")
+
 	// ErrMaxRetriesExceeded is returned when maximum retry attempts are exceeded
 	ErrMaxRetriesExceeded = errors.New("maximum retry attempts exceeded")
 
@@ -42,9 +45,33 @@ var (
 	// ErrProviderPermanentFailure is returned when the provider has a permanent failure
 	ErrProviderPermanentFailure = errors.New("provider permanent failure")
 
+	// ErrTemplatesNotSupported is returned by providers that don't support server-side templates
+	ErrTemplatesNotSupported = errors.New("provider does not support server-side templates")
+
 	// ErrWebhookSignatureInvalid is returned when a webhook signature is invalid
 	ErrWebhookSignatureInvalid = errors.New("webhook signature is invalid")
 
 	// ErrDeliveryTracking is returned when delivery tracking fails
 	ErrDeliveryTracking = errors.New("delivery tracking failed")
 )
+
+// IsRetryableError reports whether a provider error may succeed on a later attempt.
+// Temporary failures, rate limits, context errors and errors a provider did not classify are retryable.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, permanent := range []error{
+		ErrProviderPermanentFailure,
+		ErrInvalidEmailAddress,
+		ErrEmailTooLarge,
+		ErrAttachmentTooLarge,
+		ErrTemplatesNotSupported,
+		ErrEmailProviderNotConfigured,
+	} {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	return true
+}
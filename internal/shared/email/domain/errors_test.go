@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	assert.False(t, IsRetryableError(nil))
+
+	assert.True(t, IsRetryableError(ErrProviderTemporaryFailure))
+	assert.True(t, IsRetryableError(fmt.Errorf("%w: connection refused", ErrProviderRateLimit)))
+	assert.True(t, IsRetryableError(context.DeadlineExceeded))
+	assert.True(t, IsRetryableError(errors.New("unclassified provider error")))
+
+	assert.False(t, IsRetryableError(fmt.Errorf("%w: 550 no such user", ErrProviderPermanentFailure)))
+	assert.False(t, IsRetryableError(ErrInvalidEmailAddress))
+	assert.False(t, IsRetryableError(ErrTemplatesNotSupported))
+}
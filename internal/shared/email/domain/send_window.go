@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// Metadata keys that decide when a queued message may be delivered
+const (
+	// MetadataRecipientTimezone is the recipient's IANA timezone, used to hold non-urgent email
+	// until the recipient's send window
+	MetadataRecipientTimezone = "recipient_timezone"
+	// MetadataTransactional marks account and security email ("true") that is never held
+	MetadataTransactional = "transactional"
+)
+
+// SendWindow is the range of local hours, from StartHour up to but excluding EndHour, in which
+// non-urgent email is delivered
+type SendWindow struct {
+	StartHour int
+	EndHour   int
+}
+
+// Next returns t when it falls inside the window in loc, otherwise the next time the window opens
+func (w SendWindow) Next(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	if local.Hour() >= w.StartHour && local.Hour() < w.EndHour {
+		return t
+	}
+
+	opens := time.Date(local.Year(), local.Month(), local.Day(), w.StartHour, 0, 0, 0, loc)
+	if !local.Before(opens) {
+		opens = time.Date(local.Year(), local.Month(), local.Day()+1, w.StartHour, 0, 0, 0, loc)
+	}
+	return opens
+}
+
+// IsTransactional reports whether a message goes out without waiting for a send window. High
+// priority email is always treated as transactional.
+func (m *EmailMessage) IsTransactional() bool {
+	return m.Priority >= PriorityHigh || m.Metadata[MetadataTransactional] == "true"
+}
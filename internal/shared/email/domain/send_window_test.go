@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendWindowNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if !assert.NoError(t, err) {
+		return
+	}
+	window := SendWindow{StartHour: 9, EndHour: 17}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"inside window", time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC)},
+		{"before window opens", time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 9, 0, 0, 0, newYork)},
+		{"after window closes", time.Date(2024, 3, 4, 23, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 9, 0, 0, 0, newYork)},
+		{"at closing hour", time.Date(2024, 3, 4, 17, 0, 0, 0, newYork), time.Date(2024, 3, 5, 9, 0, 0, 0, newYork)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.want.Equal(window.Next(tt.now, newYork)), "got %s", window.Next(tt.now, newYork))
+		})
+	}
+}
+
+func TestIsTransactional(t *testing.T) {
+	assert.False(t, (&EmailMessage{Priority: PriorityNormal}).IsTransactional())
+	assert.True(t, (&EmailMessage{Priority: PriorityHigh}).IsTransactional())
+	assert.True(t, (&EmailMessage{Metadata: map[string]string{MetadataTransactional: "true"}}).IsTransactional())
+}
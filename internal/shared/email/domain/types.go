@@ -35,6 +35,7 @@ const (
 	StatusFailed    EmailStatus = "failed"
 	StatusRetrying  EmailStatus = "retrying"
 	StatusCancelled EmailStatus = "canceled"
+	StatusSkipped   EmailStatus = "skipped" // Not sent because email is disabled
 )
 
 // EmailTemplate represents an email template
@@ -45,11 +46,20 @@ type EmailTemplate struct {
 	HTMLBody  string            `json:"html_body"`
 	TextBody  string            `json:"text_body"`
 	Variables []string          `json:"variables"`
+	Layout    string            `json:"layout,omitempty"` // Partial the bodies are rendered inside; empty renders them standalone
 	Metadata  map[string]string `json:"metadata"`
 	CreatedAt time.Time         `json:"created_at"`
 	UpdatedAt time.Time         `json:"updated_at"`
 }
 
+// EmailPartial is a reusable piece of template, such as a footer, that templates include with
+// {{template "name" .}}. Used as a layout it includes the template's body with {{template "content" .}}.
+type EmailPartial struct {
+	Name     string `json:"name"`
+	HTMLBody string `json:"html_body"`
+	TextBody string `json:"text_body"`
+}
+
 // EmailMessage represents an email message
 type EmailMessage struct {
 	ID          string                 `json:"id"`
@@ -73,6 +83,9 @@ type EmailMessage struct {
 	CreatedAt   time.Time              `json:"created_at"`
 }
 
+// HeaderEnvironment names the environment an email was sent from
+const HeaderEnvironment = "X-Environment"
+
 // EmailAttachment represents an email attachment
 type EmailAttachment struct {
 	Name        string `json:"name"`
@@ -95,21 +108,22 @@ type QueuedEmail struct {
 	Subject      string        `json:"subject" gorm:"not null"`
 	HTMLBody     string        `json:"html_body" gorm:"type:text"`
 	TextBody     string        `json:"text_body" gorm:"type:text"`
-	TemplateID   string        `json:"template_id"`
+	TemplateID   string        `json:"template_id" gorm:"index"`
 	Variables    string        `json:"variables" gorm:"type:text"`   // JSON as string
 	Attachments  string        `json:"attachments" gorm:"type:text"` // JSON as string
 	Headers      string        `json:"headers" gorm:"type:text"`     // JSON as string
 	Tags         string        `json:"tags"`                         // JSON array as string
 	Metadata     string        `json:"metadata" gorm:"type:text"`    // JSON as string
 	Priority     EmailPriority `json:"priority" gorm:"default:1"`
-	Status       EmailStatus   `json:"status" gorm:"default:'pending'"`
+	Status       EmailStatus   `json:"status" gorm:"default:'pending';index"`
 	Provider     EmailProvider `json:"provider"`
 	AttemptCount int           `json:"attempt_count" gorm:"default:0"`
 	MaxRetries   int           `json:"max_retries" gorm:"default:3"`
 	LastError    string        `json:"last_error" gorm:"type:text"`
+	Permanent    bool          `json:"permanent" gorm:"default:false"` // Last failure cannot succeed on retry, e.g. a hard bounce
 	ScheduledAt  *time.Time    `json:"scheduled_at"`
 	SentAt       *time.Time    `json:"sent_at"`
-	CreatedAt    time.Time     `json:"created_at"`
+	CreatedAt    time.Time     `json:"created_at" gorm:"index"`
 	UpdatedAt    time.Time     `json:"updated_at"`
 }
 
@@ -117,10 +131,10 @@ type QueuedEmail struct {
 type EmailDeliveryEvent struct {
 	ID        string        `json:"id" gorm:"primarykey"`
 	EmailID   string        `json:"email_id" gorm:"not null;index"`
-	Event     string        `json:"event" gorm:"not null"` // sent, delivered, opened, clicked, bounced, complained
-	Data      string        `json:"data" gorm:"type:text"` // JSON data specific to event
+	Event     string        `json:"event" gorm:"not null;index"` // sent, delivered, opened, clicked, bounced, complained
+	Data      string        `json:"data" gorm:"type:text"`       // JSON data specific to event
 	Provider  EmailProvider `json:"provider"`
-	Timestamp time.Time     `json:"timestamp"`
+	Timestamp time.Time     `json:"timestamp" gorm:"index"`
 	CreatedAt time.Time     `json:"created_at"`
 }
 
@@ -138,7 +152,16 @@ type EmailStats struct {
 	BounceRate     float64 `json:"bounce_rate"`
 }
 
-// EmailProvider interface defines the contract for email providers
+// EmailProvider interface defines the contract for email providers.
+//
+// Send and SendTemplate either accept the message and return a result with StatusSent and a nil
+// error, or return a non-nil error. On failure the result may be nil; when it is not, its status is
+// StatusFailed. Results always carry the MessageID of the message that was sent.
+//
+// Errors are classified with IsRetryableError: providers wrap ErrProviderTemporaryFailure or
+// ErrProviderRateLimit when a later attempt may succeed, and ErrProviderPermanentFailure or
+// ErrInvalidEmailAddress when it cannot. A canceled or expired context is returned as ctx.Err().
+// Providers without server-side templates return ErrTemplatesNotSupported from SendTemplate.
 type EmailProviderInterface interface {
 	Send(ctx context.Context, message *EmailMessage) (*EmailResult, error)
 	SendTemplate(
@@ -153,7 +176,8 @@ type EmailProviderInterface interface {
 	GetProviderName() EmailProvider
 }
 
-// EmailResult represents the result of sending an email
+// EmailResult represents the result of sending an email. StatusSent means the provider accepted
+// the message for delivery, not that it reached the inbox; StatusFailed means it was not accepted.
 type EmailResult struct {
 	MessageID  string            `json:"message_id"`
 	ProviderID string            `json:"provider_id"`
@@ -180,9 +204,33 @@ type EmailQueueInterface interface {
 	Dequeue(ctx context.Context, limit int) ([]*QueuedEmail, error)
 	MarkSent(ctx context.Context, emailID string, result *EmailResult) error
 	MarkFailed(ctx context.Context, emailID string, err error) error
-	RetryFailed(ctx context.Context, maxRetries int) error
+	RetryFailed(ctx context.Context, maxRetries int, maxAge time.Duration) (int64, error)
 	GetStats(ctx context.Context) (*QueueStats, error)
+	CancelScheduled(ctx context.Context, messageID string) error
 	PurgeOld(ctx context.Context, olderThan time.Duration) error
+	ListQueued(ctx context.Context, filter *QueuedEmailFilter) ([]*QueuedEmail, int, error)
+	ListEvents(ctx context.Context, filter *DeliveryEventFilter) ([]*EmailDeliveryEvent, int, error)
+}
+
+// QueuedEmailFilter selects a page of queued emails; zero values do not filter
+type QueuedEmailFilter struct {
+	Status     EmailStatus
+	Recipient  string
+	TemplateID string
+	DateFrom   *time.Time
+	DateTo     *time.Time
+	Page       int
+	PageSize   int
+}
+
+// DeliveryEventFilter selects a page of delivery events; zero values do not filter
+type DeliveryEventFilter struct {
+	EmailID  string
+	Event    string
+	DateFrom *time.Time
+	DateTo   *time.Time
+	Page     int
+	PageSize int
 }
 
 // QueueStats represents queue statistics
@@ -193,12 +241,15 @@ type QueueStats struct {
 	Failed    int64 `json:"failed"`
 	Retrying  int64 `json:"retrying"`
 	Scheduled int64 `json:"scheduled"`
+	// OldestPendingAt is when the longest-waiting pending email became due, nil when none is due
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
 }
 
 // EmailTemplateEngine interface defines the contract for template engines
 type EmailTemplateEngine interface {
 	Render(templateID string, variables map[string]interface{}) (*RenderedTemplate, error)
 	RegisterTemplate(template *EmailTemplate) error
+	RegisterPartial(partial *EmailPartial) error
 	GetTemplate(templateID string) (*EmailTemplate, error)
 	ListTemplates() ([]*EmailTemplate, error)
 	ValidateTemplate(template *EmailTemplate) error
@@ -209,6 +260,8 @@ type RenderedTemplate struct {
 	Subject  string `json:"subject"`
 	HTMLBody string `json:"html_body"`
 	TextBody string `json:"text_body"`
+	// Fallback reports that the template failed to render and its built-in fallback was used
+	Fallback bool `json:"fallback,omitempty"`
 }
 
 // EmailService interface defines the main email service contract
@@ -220,9 +273,11 @@ type EmailServiceInterface interface {
 
 	// Scheduling
 	Schedule(ctx context.Context, message *EmailMessage, scheduledAt time.Time) error
+	CancelScheduled(ctx context.Context, messageID string) error
 
 	// Template management
 	RegisterTemplate(template *EmailTemplate) error
+	RegisterPartial(partial *EmailPartial) error
 	GetTemplate(templateID string) (*EmailTemplate, error)
 
 	// Queue management
@@ -0,0 +1,69 @@
+package email
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+var (
+	// Blocks whose content is never shown to the reader
+	hiddenBlockPattern = regexp.MustCompile(`(?is)<(head|style|script|title)[^>]*>.*?</(head|style|script|title)>`)
+	commentPattern     = regexp.MustCompile(`(?s)<!--.*?-->`)
+	linkPattern        = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a>`)
+	lineBreakPattern   = regexp.MustCompile(`(?i)<br\s*/?>`)
+	listItemPattern    = regexp.MustCompile(`(?i)<li[^>]*>`)
+	blockEndPattern    = regexp.MustCompile(`(?i)</(p|div|h[1-6]|tr|table|ul|ol|blockquote)>|<hr\s*/?>`)
+	cellEndPattern     = regexp.MustCompile(`(?i)</t[dh]>`)
+	tagPattern         = regexp.MustCompile(`(?s)<[^>]*>`)
+	spacePattern       = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLinesPattern  = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText produces a readable plain-text version of an HTML body. It is a best-effort
+// converter for deliverability and text-only clients: links keep their URL, block elements
+// become paragraphs, list items become dashes and all other markup is dropped.
+func HTMLToText(body string) string {
+	text := commentPattern.ReplaceAllString(body, "")
+	text = hiddenBlockPattern.ReplaceAllString(text, "")
+
+	text = linkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := linkPattern.FindStringSubmatch(match)
+		href := strings.TrimSpace(parts[1])
+		label := strings.TrimSpace(tagPattern.ReplaceAllString(parts[2], ""))
+		switch {
+		case href == "" || strings.HasPrefix(href, "#"):
+			return label
+		case label == "" || label == href:
+			return href
+		default:
+			return label + " (" + href + ")"
+		}
+	})
+
+	text = lineBreakPattern.ReplaceAllString(text, "\n")
+	text = listItemPattern.ReplaceAllString(text, "\n- ")
+	text = blockEndPattern.ReplaceAllString(text, "\n\n")
+	text = cellEndPattern.ReplaceAllString(text, " ")
+	text = tagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	// Normalize whitespace line by line so indentation in the source doesn't leak through
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spacePattern.ReplaceAllString(line, " "))
+	}
+	text = strings.Join(lines, "\n")
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+
+	return strings.TrimSpace(text)
+}
+
+// ensureTextBody fills in a plain-text body generated from the HTML body when none was provided
+func ensureTextBody(message *domain.EmailMessage) {
+	if strings.TrimSpace(message.TextBody) == "" && strings.TrimSpace(message.HTMLBody) != "" {
+		message.TextBody = HTMLToText(message.HTMLBody)
+	}
+}
@@ -0,0 +1,75 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "paragraphs and line breaks",
+			html:     "<p>Hello Jane,</p><p>Welcome aboard.<br>See you soon.</p>",
+			expected: "Hello Jane,\n\nWelcome aboard.\nSee you soon.",
+		},
+		{
+			name:     "links keep their URL",
+			html:     `<p>Please <a href="https://example.com/verify?token=abc&amp;x=1">verify your email</a>.</p>`,
+			expected: "Please verify your email (https://example.com/verify?token=abc&x=1).",
+		},
+		{
+			name:     "bare link is not duplicated",
+			html:     `<a href="https://example.com">https://example.com</a>`,
+			expected: "https://example.com",
+		},
+		{
+			name:     "lists become dashes",
+			html:     "<h2>Next steps</h2><ul><li>Complete your profile</li><li>Invite your team</li></ul>",
+			expected: "Next steps\n\n- Complete your profile\n- Invite your team",
+		},
+		{
+			name: "document with head, styles and entities",
+			html: `<html><head><title>Ignored</title><style>p { color: red; }</style></head>
+				<body>
+					<div class="container">
+						<h1>Password   reset</h1>
+						<!-- tracking comment -->
+						<p>Tom &amp; Jerry &lt;team&gt;</p>
+					</div>
+				</body></html>`,
+			expected: "Password reset\n\nTom & Jerry <team>",
+		},
+		{
+			name:     "table cells are separated",
+			html:     "<table><tr><td>Plan</td><td>Pro</td></tr><tr><td>Seats</td><td>5</td></tr></table>",
+			expected: "Plan Pro\n\nSeats 5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, HTMLToText(tt.html))
+		})
+	}
+}
+
+func TestEnsureTextBody(t *testing.T) {
+	generated := &domain.EmailMessage{HTMLBody: "<p>Hi</p>"}
+	ensureTextBody(generated)
+	assert.Equal(t, "Hi", generated.TextBody)
+
+	explicit := &domain.EmailMessage{HTMLBody: "<p>Hi</p>", TextBody: "Custom text"}
+	ensureTextBody(explicit)
+	assert.Equal(t, "Custom text", explicit.TextBody)
+
+	textOnly := &domain.EmailMessage{TextBody: "Plain"}
+	ensureTextBody(textOnly)
+	assert.Equal(t, "Plain", textOnly.TextBody)
+}
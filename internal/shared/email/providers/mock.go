@@ -0,0 +1,165 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+// ProviderMock identifies the in-memory mock provider
+const ProviderMock domain.EmailProvider = "mock"
+
+// MockProvider is an in-memory EmailProviderInterface for tests. Sends succeed by default;
+// failures and latency can be programmed, and every accepted message is recorded.
+type MockProvider struct {
+	mu        sync.Mutex
+	latency   time.Duration
+	failNext  []error
+	failAll   error
+	templates bool
+	sent      []*domain.EmailMessage
+	attempts  int
+}
+
+// NewMockProvider creates a mock provider that accepts every message
+func NewMockProvider() *MockProvider {
+	return &MockProvider{}
+}
+
+// FailNext makes the next sends fail with the given errors, one per send, before succeeding again
+func (p *MockProvider) FailNext(errs ...error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failNext = append(p.failNext, errs...)
+}
+
+// FailAlways makes every send fail with err until it is called again with nil
+func (p *MockProvider) FailAlways(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failAll = err
+}
+
+// SetLatency delays each send by d, returning early if the context is done
+func (p *MockProvider) SetLatency(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latency = d
+}
+
+// SetSupportsTemplates controls whether SendTemplate is accepted
+func (p *MockProvider) SetSupportsTemplates(supported bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.templates = supported
+}
+
+// Sent returns the messages the provider accepted, in order
+func (p *MockProvider) Sent() []*domain.EmailMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*domain.EmailMessage(nil), p.sent...)
+}
+
+// Attempts returns the number of send calls, including failed ones
+func (p *MockProvider) Attempts() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.attempts
+}
+
+// Send records the message or fails as programmed
+func (p *MockProvider) Send(ctx context.Context, message *domain.EmailMessage) (*domain.EmailResult, error) {
+	p.mu.Lock()
+	p.attempts++
+	latency := p.latency
+	err := p.failAll
+	if err == nil && len(p.failNext) > 0 {
+		err = p.failNext[0]
+		p.failNext = p.failNext[1:]
+	}
+	p.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	if err != nil {
+		return &domain.EmailResult{
+			MessageID: message.ID,
+			Status:    domain.StatusFailed,
+			Message:   err.Error(),
+		}, err
+	}
+
+	p.mu.Lock()
+	p.sent = append(p.sent, message)
+	p.mu.Unlock()
+
+	return &domain.EmailResult{
+		MessageID:  message.ID,
+		ProviderID: fmt.Sprintf("mock-%s", message.ID),
+		Status:     domain.StatusSent,
+		Message:    "Email accepted by mock provider",
+	}, nil
+}
+
+// SendTemplate sends a message built from the template ID when templates are supported
+func (p *MockProvider) SendTemplate(
+	ctx context.Context,
+	templateID string,
+	to []string,
+	variables map[string]interface{},
+) (*domain.EmailResult, error) {
+	if !p.SupportsTemplates() {
+		return nil, domain.ErrTemplatesNotSupported
+	}
+
+	return p.Send(ctx, &domain.EmailMessage{
+		ID:         fmt.Sprintf("template-%s-%d", templateID, time.Now().UnixNano()),
+		To:         to,
+		TemplateID: templateID,
+		Variables:  variables,
+	})
+}
+
+// GetDeliveryStatus reports accepted messages as sent
+func (p *MockProvider) GetDeliveryStatus(ctx context.Context, messageID string) (*domain.EmailDeliveryStatus, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, message := range p.sent {
+		if message.ID == messageID {
+			return &domain.EmailDeliveryStatus{MessageID: messageID, Status: domain.StatusSent}, nil
+		}
+	}
+	return nil, domain.ErrEmailNotFound
+}
+
+// SupportsTemplates returns whether this provider supports server-side templates
+func (p *MockProvider) SupportsTemplates() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.templates
+}
+
+// SupportsWebhooks returns whether this provider supports webhooks
+func (p *MockProvider) SupportsWebhooks() bool {
+	return false
+}
+
+// GetProviderName returns the provider name
+func (p *MockProvider) GetProviderName() domain.EmailProvider {
+	return ProviderMock
+}
@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+	"github.com/acheevo/tfa/internal/shared/email/providers/providertest"
+)
+
+func TestMockProviderCompliance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) *providertest.Harness {
+		provider := NewMockProvider()
+		return &providertest.Harness{
+			Provider: provider,
+			Accept:   func() {},
+			Reject:   func(err error) { provider.FailNext(err) },
+		}
+	})
+}
+
+func TestMockProviderProgrammedFailures(t *testing.T) {
+	provider := NewMockProvider()
+	provider.FailNext(domain.ErrProviderRateLimit)
+
+	message := &domain.EmailMessage{ID: "first", To: []string{"user@example.com"}}
+	_, err := provider.Send(context.Background(), message)
+	assert.ErrorIs(t, err, domain.ErrProviderRateLimit)
+
+	_, err = provider.Send(context.Background(), message)
+	assert.NoError(t, err)
+
+	provider.FailAlways(domain.ErrProviderPermanentFailure)
+	_, err = provider.Send(context.Background(), message)
+	assert.ErrorIs(t, err, domain.ErrProviderPermanentFailure)
+
+	assert.Equal(t, 3, provider.Attempts())
+	assert.Len(t, provider.Sent(), 1)
+}
+
+func TestMockProviderLatencyHonorsContext(t *testing.T) {
+	provider := NewMockProvider()
+	provider.SetLatency(time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := provider.Send(ctx, &domain.EmailMessage{ID: "slow"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, provider.Sent())
+}
@@ -0,0 +1,114 @@
+// Package providertest holds the interface compliance suite every email provider must pass.
+package providertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+// Harness lets the suite drive a provider into each outcome it checks
+type Harness struct {
+	Provider domain.EmailProviderInterface
+	// Accept arranges for the next send to be accepted
+	Accept func()
+	// Reject arranges for the next send to fail with the class of err, temporary or permanent
+	Reject func(err error)
+}
+
+// Run checks that a provider follows the EmailProviderInterface contract. newHarness is called
+// once per case so state from one case cannot leak into the next.
+func Run(t *testing.T, newHarness func(t *testing.T) *Harness) {
+	t.Run("ProviderName", func(t *testing.T) {
+		h := newHarness(t)
+		assert.NotEmpty(t, h.Provider.GetProviderName())
+	})
+
+	t.Run("AcceptedSend", func(t *testing.T) {
+		h := newHarness(t)
+		h.Accept()
+
+		message := testMessage("accepted")
+		result, err := h.Provider.Send(context.Background(), message)
+		if !assert.NoError(t, err) || !assert.NotNil(t, result) {
+			return
+		}
+		assert.Equal(t, domain.StatusSent, result.Status)
+		assert.Equal(t, message.ID, result.MessageID)
+
+		status, err := h.Provider.GetDeliveryStatus(context.Background(), message.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, message.ID, status.MessageID)
+		}
+	})
+
+	t.Run("TemporaryFailureIsRetryable", func(t *testing.T) {
+		h := newHarness(t)
+		h.Reject(domain.ErrProviderTemporaryFailure)
+
+		message := testMessage("temporary")
+		result, err := h.Provider.Send(context.Background(), message)
+		assert.Error(t, err)
+		assert.True(t, domain.IsRetryableError(err), "temporary failure should be retryable: %v", err)
+		assertFailedResult(t, message, result)
+	})
+
+	t.Run("PermanentFailureIsNotRetryable", func(t *testing.T) {
+		h := newHarness(t)
+		h.Reject(domain.ErrProviderPermanentFailure)
+
+		message := testMessage("permanent")
+		result, err := h.Provider.Send(context.Background(), message)
+		assert.Error(t, err)
+		assert.False(t, domain.IsRetryableError(err), "permanent failure should not be retried: %v", err)
+		assertFailedResult(t, message, result)
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		h := newHarness(t)
+		h.Accept()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		message := testMessage("canceled")
+		result, err := h.Provider.Send(ctx, message)
+		assert.True(t, errors.Is(err, context.Canceled), "expected context.Canceled, got %v", err)
+		assertFailedResult(t, message, result)
+	})
+
+	t.Run("TemplatesContract", func(t *testing.T) {
+		h := newHarness(t)
+		if h.Provider.SupportsTemplates() {
+			t.Skip("provider supports server-side templates")
+		}
+
+		_, err := h.Provider.SendTemplate(context.Background(), "welcome", []string{"user@example.com"}, nil)
+		assert.ErrorIs(t, err, domain.ErrTemplatesNotSupported)
+		assert.False(t, domain.IsRetryableError(err))
+	})
+}
+
+// assertFailedResult checks the optional result returned alongside a send error
+func assertFailedResult(t *testing.T, message *domain.EmailMessage, result *domain.EmailResult) {
+	t.Helper()
+	if result == nil {
+		return
+	}
+	assert.Equal(t, domain.StatusFailed, result.Status)
+	assert.Equal(t, message.ID, result.MessageID)
+}
+
+func testMessage(id string) *domain.EmailMessage {
+	return &domain.EmailMessage{
+		ID:       id,
+		From:     "noreply@example.com",
+		To:       []string{"user@example.com"},
+		Subject:  "Compliance check",
+		TextBody: "Hello",
+	}
+}
@@ -3,9 +3,14 @@ package providers
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"strconv"
 	"time"
 
 	"gopkg.in/gomail.v2"
@@ -14,6 +19,9 @@ import (
 	"github.com/acheevo/tfa/internal/shared/email/domain"
 )
 
+// smtpDialTimeout bounds connecting to the SMTP server, matching gomail's dialer
+const smtpDialTimeout = 10 * time.Second
+
 // SMTPProvider implements the EmailProvider interface for SMTP
 type SMTPProvider struct {
 	config *config.Config
@@ -54,6 +62,23 @@ func (p *SMTPProvider) Send(ctx context.Context, message *domain.EmailMessage) (
 		return nil, domain.ErrEmailProviderNotConfigured
 	}
 
+	if err := ctx.Err(); err != nil {
+		return &domain.EmailResult{
+			MessageID: message.ID,
+			Status:    domain.StatusFailed,
+			Message:   "SMTP send canceled",
+		}, err
+	}
+
+	recipients, err := smtpRecipients(message)
+	if err != nil {
+		return &domain.EmailResult{
+			MessageID: message.ID,
+			Status:    domain.StatusFailed,
+			Message:   err.Error(),
+		}, err
+	}
+
 	// Create the email message
 	m := gomail.NewMessage()
 
@@ -118,7 +143,7 @@ func (p *SMTPProvider) Send(ctx context.Context, message *domain.EmailMessage) (
 	// Send with timeout
 	done := make(chan error, 1)
 	go func() {
-		done <- p.dialer.DialAndSend(m)
+		done <- p.deliver(message.From, recipients, m)
 	}()
 
 	select {
@@ -159,7 +184,118 @@ func (p *SMTPProvider) SendTemplate(
 	variables map[string]interface{},
 ) (*domain.EmailResult, error) {
 	// SMTP doesn't support server-side templates, this should be handled by the template engine
-	return nil, fmt.Errorf("SMTP provider does not support server-side templates, use the template engine")
+	return nil, domain.ErrTemplatesNotSupported
+}
+
+// deliver sends a message over a fresh SMTP connection, classifying failures by reply code.
+// The session is driven with net/smtp rather than gomail's sender, which redials forever when
+// the server drops the connection before MAIL FROM; a dropped connection is returned once and
+// left to the queue's retry backoff.
+func (p *SMTPProvider) deliver(from string, recipients []string, m *gomail.Message) error {
+	client, err := p.dial()
+	if err != nil {
+		// Failures while connecting or authenticating are about the server or credentials rather
+		// than this message, so they are temporary even for 5xx replies
+		return fmt.Errorf("%w: %v", domain.ErrProviderTemporaryFailure, err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if err := client.Mail(from); err != nil {
+		return classifySMTPError(err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return classifySMTPError(err)
+		}
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return classifySMTPError(err)
+	}
+	if _, err := m.WriteTo(data); err != nil {
+		_ = data.Close()
+		return classifySMTPError(err)
+	}
+	if err := data.Close(); err != nil {
+		return classifySMTPError(err)
+	}
+
+	// The message is accepted once the data is; a failed QUIT does not change that
+	_ = client.Quit()
+	return nil
+}
+
+// dial connects and authenticates with the dialer's settings, upgrading to TLS when offered
+func (p *SMTPProvider) dial() (*smtp.Client, error) {
+	address := net.JoinHostPort(p.dialer.Host, strconv.Itoa(p.dialer.Port))
+	conn, err := net.DialTimeout("tcp", address, smtpDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := p.dialer.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: p.dialer.Host, MinVersion: tls.VersionTLS12}
+	}
+	if p.dialer.SSL {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, p.dialer.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if !p.dialer.SSL {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				_ = client.Close()
+				return nil, err
+			}
+		}
+	}
+
+	if p.dialer.Auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(p.dialer.Auth); err != nil {
+				_ = client.Close()
+				return nil, err
+			}
+		}
+	}
+
+	return client, nil
+}
+
+// smtpRecipients returns the envelope recipients for a message, including CC and BCC
+func smtpRecipients(message *domain.EmailMessage) ([]string, error) {
+	var recipients []string
+	for _, list := range [][]string{message.To, message.CC, message.BCC} {
+		for _, address := range list {
+			parsed, err := mail.ParseAddress(address)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", domain.ErrInvalidEmailAddress, address)
+			}
+			recipients = append(recipients, parsed.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("%w: no recipients", domain.ErrInvalidEmailAddress)
+	}
+	return recipients, nil
+}
+
+// classifySMTPError maps 5xx replies to permanent failures; connection errors and 4xx replies are temporary
+func classifySMTPError(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return fmt.Errorf("%w: %v", domain.ErrProviderPermanentFailure, err)
+	}
+	return fmt.Errorf("%w: %v", domain.ErrProviderTemporaryFailure, err)
 }
 
 // GetDeliveryStatus gets the delivery status of an email (SMTP doesn't support delivery tracking)
@@ -0,0 +1,202 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+	"github.com/acheevo/tfa/internal/shared/email/providers/providertest"
+)
+
+// closeConnection makes the fake server drop the connection instead of replying
+const closeConnection = "close"
+
+// fakeSMTPServer is a minimal SMTP server whose reply at each stage can be programmed.
+// Stages are the greeting ("GREETING"), the command verbs and the end of message data ("DATA_END").
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	replies  map[string]string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := &fakeSMTPServer{listener: listener, replies: map[string]string{}}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeSMTPServer) setReply(stage, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies[stage] = reply
+}
+
+func (s *fakeSMTPServer) setRcptReply(reply string) {
+	s.setReply("RCPT", reply)
+}
+
+func (s *fakeSMTPServer) replyFor(stage, fallback string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reply, ok := s.replies[stage]; ok {
+		return reply
+	}
+	return fallback
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	// reply writes the programmed reply for stage and reports whether the session continues
+	reply := func(stage, fallback string) bool {
+		line := s.replyFor(stage, fallback)
+		if line == closeConnection {
+			return false
+		}
+		_, _ = conn.Write([]byte(line + "\r\n"))
+		return true
+	}
+
+	if !reply("GREETING", "220 localhost ESMTP") {
+		return
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		verb, _, _ := strings.Cut(command, " ")
+		switch verb {
+		case "EHLO", "HELO":
+			if !reply(verb, "250 localhost") {
+				return
+			}
+		case "DATA":
+			if !reply(verb, "354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			for {
+				data, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimRight(data, "\r\n") == "." {
+					break
+				}
+			}
+			if !reply("DATA_END", "250 OK") {
+				return
+			}
+		case "QUIT":
+			reply(verb, "221 Bye")
+			return
+		default:
+			if !reply(verb, "250 OK") {
+				return
+			}
+		}
+	}
+}
+
+func TestSMTPProviderCompliance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) *providertest.Harness {
+		server := newFakeSMTPServer(t)
+		provider := NewSMTPProvider(&config.Config{SMTPHost: "127.0.0.1", SMTPPort: server.port()})
+
+		return &providertest.Harness{
+			Provider: provider,
+			Accept:   func() { server.setRcptReply("250 OK") },
+			Reject: func(err error) {
+				if domain.IsRetryableError(err) {
+					server.setRcptReply("451 4.3.0 Try again later")
+				} else {
+					server.setRcptReply("550 5.1.1 No such user")
+				}
+			},
+		}
+	})
+}
+
+func TestSMTPProviderConnectionFailureIsRetryable(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	port := server.port()
+	_ = server.listener.Close()
+
+	provider := NewSMTPProvider(&config.Config{SMTPHost: "127.0.0.1", SMTPPort: port})
+	_, err := provider.Send(context.Background(), &domain.EmailMessage{
+		ID:   "offline",
+		From: "noreply@example.com",
+		To:   []string{"user@example.com"},
+	})
+	if !domain.IsRetryableError(err) {
+		t.Errorf("expected connection failure to be retryable, got %v", err)
+	}
+}
+
+func TestSMTPProviderFailureClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		stage     string
+		reply     string
+		retryable bool
+	}{
+		{name: "invalid recipient", stage: "RCPT", reply: "550 5.1.1 No such user", retryable: false},
+		{name: "mailbox full", stage: "RCPT", reply: "552 5.2.2 Mailbox full", retryable: false},
+		{name: "greylisted", stage: "RCPT", reply: "451 4.7.1 Greylisted, try again later", retryable: true},
+		{name: "sender rejected", stage: "MAIL", reply: "553 5.1.8 Sender domain does not exist", retryable: false},
+		{name: "rejected after data", stage: "DATA_END", reply: "554 5.7.1 Message rejected as spam", retryable: false},
+		{name: "server shutting down", stage: "MAIL", reply: "421 4.3.2 Service shutting down", retryable: true},
+		{name: "connection reset mid-session", stage: "RCPT", reply: closeConnection, retryable: true},
+		{name: "connection reset after greeting", stage: "EHLO", reply: closeConnection, retryable: true},
+		{name: "service refused at greeting", stage: "GREETING", reply: "554 5.3.2 No service here", retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t)
+			server.setReply(tt.stage, tt.reply)
+
+			provider := NewSMTPProvider(&config.Config{SMTPHost: "127.0.0.1", SMTPPort: server.port()})
+			result, err := provider.Send(context.Background(), &domain.EmailMessage{
+				ID:       "classified",
+				From:     "noreply@example.com",
+				To:       []string{"user@example.com"},
+				Subject:  "Hello",
+				TextBody: "Hello",
+			})
+			if err == nil {
+				t.Fatal("expected send to fail")
+			}
+			if got := domain.IsRetryableError(err); got != tt.retryable {
+				t.Errorf("expected retryable %v, got %v for %v", tt.retryable, got, err)
+			}
+			if result == nil || result.Status != domain.StatusFailed {
+				t.Errorf("expected a failed result, got %+v", result)
+			}
+		})
+	}
+}
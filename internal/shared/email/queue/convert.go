@@ -0,0 +1,99 @@
+package queue
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+// ToQueuedEmail converts an EmailMessage to a pending QueuedEmail created at now. Every queue
+// backend stores the same representation, so the worker reads emails the same way from any of them.
+func ToQueuedEmail(message *domain.EmailMessage, now time.Time) *domain.QueuedEmail {
+	// Generate ID if not provided
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+
+	// Marshal complex fields to JSON
+	toJSON, _ := json.Marshal(message.To)
+	ccJSON, _ := json.Marshal(message.CC)
+	bccJSON, _ := json.Marshal(message.BCC)
+	variablesJSON, _ := json.Marshal(message.Variables)
+	attachmentsJSON, _ := json.Marshal(message.Attachments)
+	headersJSON, _ := json.Marshal(message.Headers)
+	tagsJSON, _ := json.Marshal(message.Tags)
+	metadataJSON, _ := json.Marshal(message.Metadata)
+
+	return &domain.QueuedEmail{
+		ID:          uuid.New().String(),
+		MessageID:   message.ID,
+		From:        message.From,
+		FromName:    message.FromName,
+		To:          string(toJSON),
+		CC:          string(ccJSON),
+		BCC:         string(bccJSON),
+		ReplyTo:     message.ReplyTo,
+		Subject:     message.Subject,
+		HTMLBody:    message.HTMLBody,
+		TextBody:    message.TextBody,
+		TemplateID:  message.TemplateID,
+		Variables:   string(variablesJSON),
+		Attachments: string(attachmentsJSON),
+		Headers:     string(headersJSON),
+		Tags:        string(tagsJSON),
+		Metadata:    string(metadataJSON),
+		Priority:    message.Priority,
+		Status:      domain.StatusPending,
+		MaxRetries:  3, // Default max retries
+		ScheduledAt: message.ScheduledAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// ToMessage converts a QueuedEmail back to an EmailMessage. Fields that fail to unmarshal are
+// logged and left empty rather than failing the whole email.
+func ToMessage(queuedEmail *domain.QueuedEmail, logger *slog.Logger) *domain.EmailMessage {
+	message := &domain.EmailMessage{
+		ID:          queuedEmail.MessageID,
+		From:        queuedEmail.From,
+		FromName:    queuedEmail.FromName,
+		ReplyTo:     queuedEmail.ReplyTo,
+		Subject:     queuedEmail.Subject,
+		HTMLBody:    queuedEmail.HTMLBody,
+		TextBody:    queuedEmail.TextBody,
+		TemplateID:  queuedEmail.TemplateID,
+		Priority:    queuedEmail.Priority,
+		ScheduledAt: queuedEmail.ScheduledAt,
+		CreatedAt:   queuedEmail.CreatedAt,
+	}
+
+	fields := []struct {
+		name  string
+		value string
+		dest  interface{}
+	}{
+		{"To", queuedEmail.To, &message.To},
+		{"CC", queuedEmail.CC, &message.CC},
+		{"BCC", queuedEmail.BCC, &message.BCC},
+		{"Variables", queuedEmail.Variables, &message.Variables},
+		{"Attachments", queuedEmail.Attachments, &message.Attachments},
+		{"Headers", queuedEmail.Headers, &message.Headers},
+		{"Tags", queuedEmail.Tags, &message.Tags},
+		{"Metadata", queuedEmail.Metadata, &message.Metadata},
+	}
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(field.value), field.dest); err != nil {
+			logger.Error("failed to unmarshal "+field.name+" field", "error", err, "email_id", queuedEmail.ID)
+		}
+	}
+
+	return message
+}
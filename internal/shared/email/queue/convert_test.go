@@ -0,0 +1,49 @@
+package queue
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+func TestQueuedEmailRoundTrip(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	scheduled := now.Add(time.Hour)
+	message := &domain.EmailMessage{
+		ID:          "message-1",
+		From:        "noreply@example.com",
+		To:          []string{"user@example.com"},
+		CC:          []string{"cc@example.com"},
+		Subject:     "Welcome",
+		TextBody:    "Hello",
+		TemplateID:  "welcome",
+		Variables:   map[string]interface{}{"name": "Test"},
+		Headers:     map[string]string{domain.HeaderEnvironment: "test"},
+		Tags:        []string{"onboarding"},
+		Metadata:    map[string]string{"environment": "test"},
+		Priority:    domain.PriorityHigh,
+		ScheduledAt: &scheduled,
+	}
+
+	queued := ToQueuedEmail(message, now)
+	assert.Equal(t, domain.StatusPending, queued.Status)
+	assert.Equal(t, "message-1", queued.MessageID)
+	assert.Equal(t, now, queued.CreatedAt)
+
+	message.CreatedAt = now
+	assert.Equal(t, message, ToMessage(queued, slog.New(slog.NewTextHandler(io.Discard, nil))))
+}
+
+func TestToMessageSkipsMalformedFields(t *testing.T) {
+	queued := &domain.QueuedEmail{ID: "email-1", MessageID: "message-1", To: `["user@example.com"]`, Tags: "not json"}
+
+	message := ToMessage(queued, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	assert.Equal(t, []string{"user@example.com"}, message.To)
+	assert.Empty(t, message.Tags)
+}
@@ -5,11 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/acheevo/tfa/internal/shared/clock"
 	"github.com/acheevo/tfa/internal/shared/email/domain"
 )
 
@@ -17,13 +18,15 @@ import (
 type DatabaseQueue struct {
 	db     *gorm.DB
 	logger *slog.Logger
+	clock  clock.Clock
 }
 
 // NewDatabaseQueue creates a new database-backed email queue
-func NewDatabaseQueue(db *gorm.DB, logger *slog.Logger) *DatabaseQueue {
+func NewDatabaseQueue(db *gorm.DB, logger *slog.Logger, clk clock.Clock) *DatabaseQueue {
 	return &DatabaseQueue{
 		db:     db,
 		logger: logger,
+		clock:  clk,
 	}
 }
 
@@ -53,7 +56,7 @@ func (q *DatabaseQueue) Dequeue(ctx context.Context, limit int) ([]*domain.Queue
 	var emails []*domain.QueuedEmail
 
 	// Get emails ready for processing (pending or retrying, and scheduled time has passed)
-	now := time.Now()
+	now := q.clock.Now()
 	err := q.db.WithContext(ctx).
 		Where("status IN (?, ?) AND (scheduled_at IS NULL OR scheduled_at <= ?)",
 			domain.StatusPending, domain.StatusRetrying, now).
@@ -90,7 +93,7 @@ func (q *DatabaseQueue) Dequeue(ctx context.Context, limit int) ([]*domain.Queue
 func (q *DatabaseQueue) MarkSent(ctx context.Context, emailID string, result *domain.EmailResult) error {
 	updates := map[string]interface{}{
 		"status":  domain.StatusSent,
-		"sent_at": time.Now(),
+		"sent_at": q.clock.Now(),
 	}
 
 	if result != nil {
@@ -124,7 +127,15 @@ func (q *DatabaseQueue) MarkFailed(ctx context.Context, emailID string, failureE
 	queuedEmail.LastError = failureErr.Error()
 
 	// Check if we should retry or mark as permanently failed
-	if queuedEmail.AttemptCount >= queuedEmail.MaxRetries {
+	if !domain.IsRetryableError(failureErr) {
+		queuedEmail.Status = domain.StatusFailed
+		queuedEmail.Permanent = true
+		q.logger.Warn("email permanently failed with non-retryable error",
+			"email_id", emailID,
+			"attempts", queuedEmail.AttemptCount,
+			"error", failureErr.Error(),
+		)
+	} else if queuedEmail.AttemptCount >= queuedEmail.MaxRetries {
 		queuedEmail.Status = domain.StatusFailed
 		q.logger.Warn("email permanently failed after max retries",
 			"email_id", emailID,
@@ -135,7 +146,7 @@ func (q *DatabaseQueue) MarkFailed(ctx context.Context, emailID string, failureE
 		queuedEmail.Status = domain.StatusRetrying
 		// Calculate exponential backoff for next retry
 		backoffSeconds := calculateBackoff(queuedEmail.AttemptCount)
-		nextRetry := time.Now().Add(time.Duration(backoffSeconds) * time.Second)
+		nextRetry := q.clock.Now().Add(time.Duration(backoffSeconds) * time.Second)
 		queuedEmail.ScheduledAt = &nextRetry
 
 		q.logger.Info("email scheduled for retry",
@@ -154,28 +165,34 @@ func (q *DatabaseQueue) MarkFailed(ctx context.Context, emailID string, failureE
 	return nil
 }
 
-// RetryFailed retries failed emails that haven't exceeded max retries
-func (q *DatabaseQueue) RetryFailed(ctx context.Context, maxRetries int) error {
-	result := q.db.WithContext(ctx).
+// RetryFailed requeues failed emails attempted fewer than maxRetries times and created within
+// maxAge ("0" has no age limit). Permanent failures such as hard bounces are never requeued.
+// It returns the number of emails requeued.
+func (q *DatabaseQueue) RetryFailed(ctx context.Context, maxRetries int, maxAge time.Duration) (int64, error) {
+	query := q.db.WithContext(ctx).
 		Model(&domain.QueuedEmail{}).
-		Where("status = ? AND attempt_count < ?", domain.StatusFailed, maxRetries).
-		Updates(map[string]interface{}{
-			"status":       domain.StatusPending,
-			"scheduled_at": nil,
-		})
+		Where("status = ? AND permanent = ? AND attempt_count < ?", domain.StatusFailed, false, maxRetries)
+	if maxAge > 0 {
+		query = query.Where("created_at >= ?", q.clock.Now().Add(-maxAge))
+	}
 
+	result := query.Updates(map[string]interface{}{
+		"status":       domain.StatusPending,
+		"scheduled_at": nil,
+	})
 	if result.Error != nil {
 		q.logger.Error("failed to retry failed emails", "error", result.Error)
-		return fmt.Errorf("failed to retry failed emails: %w", result.Error)
+		return 0, fmt.Errorf("failed to retry failed emails: %w", result.Error)
 	}
 
 	q.logger.Info("retried failed emails", "count", result.RowsAffected)
-	return nil
+	return result.RowsAffected, nil
 }
 
 // GetStats returns queue statistics
 func (q *DatabaseQueue) GetStats(ctx context.Context) (*domain.QueueStats, error) {
 	stats := &domain.QueueStats{}
+	now := q.clock.Now()
 
 	// Get counts for each status
 	statusCounts := []struct {
@@ -211,18 +228,140 @@ func (q *DatabaseQueue) GetStats(ctx context.Context) (*domain.QueueStats, error
 	// Count scheduled emails
 	err = q.db.WithContext(ctx).
 		Model(&domain.QueuedEmail{}).
-		Where("scheduled_at > ?", time.Now()).
+		Where("scheduled_at > ?", now).
 		Count(&stats.Scheduled).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to count scheduled emails: %w", err)
 	}
 
+	// A scheduled email starts waiting at its scheduled time, not when it was queued
+	var oldest struct {
+		DueAt *time.Time
+	}
+	err = q.db.WithContext(ctx).
+		Model(&domain.QueuedEmail{}).
+		Select("MIN(COALESCE(scheduled_at, created_at)) AS due_at").
+		Where("status = ? AND (scheduled_at IS NULL OR scheduled_at <= ?)", domain.StatusPending, now).
+		Scan(&oldest).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oldest pending email: %w", err)
+	}
+	stats.OldestPendingAt = oldest.DueAt
+
 	return stats, nil
 }
 
+// ListQueued returns a page of queued emails matching the filter, newest first, with the total match count
+func (q *DatabaseQueue) ListQueued(ctx context.Context, filter *domain.QueuedEmailFilter) ([]*domain.QueuedEmail, int, error) {
+	var emails []*domain.QueuedEmail
+	var total int64
+
+	query := q.db.WithContext(ctx).Model(&domain.QueuedEmail{})
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.Recipient != "" {
+		// Recipients are stored as a JSON array, so match any part of it
+		query = query.Where(`LOWER("to") LIKE ?`, "%"+strings.ToLower(filter.Recipient)+"%")
+	}
+
+	if filter.TemplateID != "" {
+		query = query.Where("template_id = ?", filter.TemplateID)
+	}
+
+	if filter.DateFrom != nil {
+		query = query.Where("created_at >= ?", *filter.DateFrom)
+	}
+
+	if filter.DateTo != nil {
+		query = query.Where("created_at < ?", filter.DateTo.AddDate(0, 0, 1))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count queued emails: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(filter.PageSize).Find(&emails).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list queued emails: %w", err)
+	}
+
+	return emails, int(total), nil
+}
+
+// ListEvents returns a page of delivery events matching the filter, newest first, with the total match count
+func (q *DatabaseQueue) ListEvents(ctx context.Context, filter *domain.DeliveryEventFilter) ([]*domain.EmailDeliveryEvent, int, error) {
+	var events []*domain.EmailDeliveryEvent
+	var total int64
+
+	query := q.db.WithContext(ctx).Model(&domain.EmailDeliveryEvent{})
+
+	if filter.EmailID != "" {
+		query = query.Where("email_id = ?", filter.EmailID)
+	}
+
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+
+	if filter.DateFrom != nil {
+		query = query.Where("timestamp >= ?", *filter.DateFrom)
+	}
+
+	if filter.DateTo != nil {
+		query = query.Where("timestamp < ?", filter.DateTo.AddDate(0, 0, 1))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count delivery events: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	if err := query.Order("timestamp DESC").Offset(offset).Limit(filter.PageSize).Find(&events).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list delivery events: %w", err)
+	}
+
+	return events, int(total), nil
+}
+
+// CancelScheduled cancels a pending email that is scheduled in the future. The status check and
+// update happen in one statement so an email cannot be canceled once processing has picked it up.
+func (q *DatabaseQueue) CancelScheduled(ctx context.Context, messageID string) error {
+	result := q.db.WithContext(ctx).
+		Model(&domain.QueuedEmail{}).
+		Where("message_id = ? AND status = ? AND scheduled_at > ?", messageID, domain.StatusPending, q.clock.Now()).
+		Update("status", domain.StatusCancelled)
+	if result.Error != nil {
+		q.logger.Error("failed to cancel scheduled email", "error", result.Error, "message_id", messageID)
+		return fmt.Errorf("failed to cancel scheduled email: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		q.logger.Info("scheduled email canceled", "message_id", messageID)
+		return nil
+	}
+
+	// Nothing was canceled, so tell a missing email apart from one that is too late to cancel
+	var count int64
+	err := q.db.WithContext(ctx).
+		Model(&domain.QueuedEmail{}).
+		Where("message_id = ?", messageID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("failed to find email: %w", err)
+	}
+	if count == 0 {
+		return domain.ErrEmailNotFound
+	}
+
+	return domain.ErrEmailNotCancellable
+}
+
 // PurgeOld removes old emails from the queue
 func (q *DatabaseQueue) PurgeOld(ctx context.Context, olderThan time.Duration) error {
-	cutoff := time.Now().Add(-olderThan)
+	cutoff := q.clock.Now().Add(-olderThan)
 
 	result := q.db.WithContext(ctx).
 		Where("created_at < ? AND status IN (?, ?)", cutoff, domain.StatusSent, domain.StatusFailed).
@@ -239,109 +378,12 @@ func (q *DatabaseQueue) PurgeOld(ctx context.Context, olderThan time.Duration) e
 
 // messageToQueuedEmail converts an EmailMessage to a QueuedEmail
 func (q *DatabaseQueue) messageToQueuedEmail(message *domain.EmailMessage) *domain.QueuedEmail {
-	// Generate ID if not provided
-	if message.ID == "" {
-		message.ID = uuid.New().String()
-	}
-
-	// Marshal complex fields to JSON
-	toJSON, _ := json.Marshal(message.To)
-	ccJSON, _ := json.Marshal(message.CC)
-	bccJSON, _ := json.Marshal(message.BCC)
-	variablesJSON, _ := json.Marshal(message.Variables)
-	attachmentsJSON, _ := json.Marshal(message.Attachments)
-	headersJSON, _ := json.Marshal(message.Headers)
-	tagsJSON, _ := json.Marshal(message.Tags)
-	metadataJSON, _ := json.Marshal(message.Metadata)
-
-	queuedEmail := &domain.QueuedEmail{
-		ID:          uuid.New().String(),
-		MessageID:   message.ID,
-		From:        message.From,
-		FromName:    message.FromName,
-		To:          string(toJSON),
-		CC:          string(ccJSON),
-		BCC:         string(bccJSON),
-		ReplyTo:     message.ReplyTo,
-		Subject:     message.Subject,
-		HTMLBody:    message.HTMLBody,
-		TextBody:    message.TextBody,
-		TemplateID:  message.TemplateID,
-		Variables:   string(variablesJSON),
-		Attachments: string(attachmentsJSON),
-		Headers:     string(headersJSON),
-		Tags:        string(tagsJSON),
-		Metadata:    string(metadataJSON),
-		Priority:    message.Priority,
-		Status:      domain.StatusPending,
-		MaxRetries:  3, // Default max retries
-		ScheduledAt: message.ScheduledAt,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
-
-	return queuedEmail
+	return ToQueuedEmail(message, q.clock.Now())
 }
 
 // QueuedEmailToMessage converts a QueuedEmail back to an EmailMessage
 func (q *DatabaseQueue) QueuedEmailToMessage(queuedEmail *domain.QueuedEmail) (*domain.EmailMessage, error) {
-	message := &domain.EmailMessage{
-		ID:          queuedEmail.MessageID,
-		From:        queuedEmail.From,
-		FromName:    queuedEmail.FromName,
-		ReplyTo:     queuedEmail.ReplyTo,
-		Subject:     queuedEmail.Subject,
-		HTMLBody:    queuedEmail.HTMLBody,
-		TextBody:    queuedEmail.TextBody,
-		TemplateID:  queuedEmail.TemplateID,
-		Priority:    queuedEmail.Priority,
-		ScheduledAt: queuedEmail.ScheduledAt,
-		CreatedAt:   queuedEmail.CreatedAt,
-	}
-
-	// Unmarshal JSON fields
-	if queuedEmail.To != "" {
-		if err := json.Unmarshal([]byte(queuedEmail.To), &message.To); err != nil {
-			q.logger.Error("failed to unmarshal To field", "error", err, "email_id", queuedEmail.ID)
-		}
-	}
-	if queuedEmail.CC != "" {
-		if err := json.Unmarshal([]byte(queuedEmail.CC), &message.CC); err != nil {
-			q.logger.Error("failed to unmarshal CC field", "error", err, "email_id", queuedEmail.ID)
-		}
-	}
-	if queuedEmail.BCC != "" {
-		if err := json.Unmarshal([]byte(queuedEmail.BCC), &message.BCC); err != nil {
-			q.logger.Error("failed to unmarshal BCC field", "error", err, "email_id", queuedEmail.ID)
-		}
-	}
-	if queuedEmail.Variables != "" {
-		if err := json.Unmarshal([]byte(queuedEmail.Variables), &message.Variables); err != nil {
-			q.logger.Error("failed to unmarshal Variables field", "error", err, "email_id", queuedEmail.ID)
-		}
-	}
-	if queuedEmail.Attachments != "" {
-		if err := json.Unmarshal([]byte(queuedEmail.Attachments), &message.Attachments); err != nil {
-			q.logger.Error("failed to unmarshal Attachments field", "error", err, "email_id", queuedEmail.ID)
-		}
-	}
-	if queuedEmail.Headers != "" {
-		if err := json.Unmarshal([]byte(queuedEmail.Headers), &message.Headers); err != nil {
-			q.logger.Error("failed to unmarshal Headers field", "error", err, "email_id", queuedEmail.ID)
-		}
-	}
-	if queuedEmail.Tags != "" {
-		if err := json.Unmarshal([]byte(queuedEmail.Tags), &message.Tags); err != nil {
-			q.logger.Error("failed to unmarshal Tags field", "error", err, "email_id", queuedEmail.ID)
-		}
-	}
-	if queuedEmail.Metadata != "" {
-		if err := json.Unmarshal([]byte(queuedEmail.Metadata), &message.Metadata); err != nil {
-			q.logger.Error("failed to unmarshal Metadata field", "error", err, "email_id", queuedEmail.ID)
-		}
-	}
-
-	return message, nil
+	return ToMessage(queuedEmail, q.logger), nil
 }
 
 // calculateBackoff calculates exponential backoff delay in seconds
@@ -0,0 +1,581 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+// RedisQueue implements EmailQueueInterface on Redis, taking email throughput off Postgres. Each email
+// is a hash holding the queued email as JSON and its status; sorted sets order the emails that are
+// ready, scheduled and claimed, and a set per status answers the stats. Every key shares one hash tag,
+// so the queue also works on a Redis Cluster.
+//
+// Dequeue claims emails for the lease. A claim that is not marked sent or failed in time, because the
+// worker died, is handed out again, so delivery is at least once.
+type RedisQueue struct {
+	client redis.UniversalClient
+	prefix string
+	lease  time.Duration
+	logger *slog.Logger
+	clock  clock.Clock
+}
+
+// NewRedisQueue creates a new Redis-backed email queue whose keys start with prefix
+func NewRedisQueue(
+	client redis.UniversalClient,
+	prefix string,
+	lease time.Duration,
+	logger *slog.Logger,
+	clk clock.Clock,
+) *RedisQueue {
+	return &RedisQueue{
+		client: client,
+		prefix: "{" + prefix + "}",
+		lease:  lease,
+		logger: logger,
+		clock:  clk,
+	}
+}
+
+// listBatchSize is how many emails ListQueued, RetryFailed and PurgeOld load per round trip
+const listBatchSize = 100
+
+// Key layout, all under the queue's prefix
+func (q *RedisQueue) emailKey(id string) string          { return q.prefix + ":email:" + id }
+func (q *RedisQueue) messageKey(messageID string) string { return q.prefix + ":message:" + messageID }
+func (q *RedisQueue) statusKey(status domain.EmailStatus) string {
+	return q.prefix + ":status:" + string(status)
+}
+func (q *RedisQueue) allKey() string        { return q.prefix + ":all" }         // every email by creation time
+func (q *RedisQueue) readyKey() string      { return q.prefix + ":ready" }       // due emails by rank
+func (q *RedisQueue) scheduledKey() string  { return q.prefix + ":scheduled" }   // emails by the time they fall due
+func (q *RedisQueue) processingKey() string { return q.prefix + ":processing" }  // claimed emails by claim time
+func (q *RedisQueue) pendingDueKey() string { return q.prefix + ":pending_due" } // pending emails by the time they fell due
+
+// rank orders ready emails as the database queue does: higher priority first, then oldest first
+func rank(email *domain.QueuedEmail) float64 {
+	return float64(-int64(email.Priority)*1e13 + email.CreatedAt.UnixMilli())
+}
+
+// dueAt is when an email may first be sent
+func dueAt(email *domain.QueuedEmail) time.Time {
+	if email.ScheduledAt != nil {
+		return *email.ScheduledAt
+	}
+	return email.CreatedAt
+}
+
+func millis(t time.Time) float64 {
+	return float64(t.UnixMilli())
+}
+
+// Enqueue adds an email message to the queue
+func (q *RedisQueue) Enqueue(ctx context.Context, message *domain.EmailMessage) error {
+	now := q.clock.Now()
+	queuedEmail := ToQueuedEmail(message, now)
+
+	data, err := json.Marshal(queuedEmail)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+
+	// Message IDs are unique, as the database queue's index makes them
+	claimed, err := q.client.SetNX(ctx, q.messageKey(queuedEmail.MessageID), queuedEmail.ID, 0).Result()
+	if err != nil {
+		q.logger.Error("failed to enqueue email", "error", err, "message_id", message.ID)
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	if !claimed {
+		return fmt.Errorf("failed to enqueue email: message %s is already queued", queuedEmail.MessageID)
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.emailKey(queuedEmail.ID),
+			"data", data,
+			"status", string(domain.StatusPending),
+			"rank", rank(queuedEmail))
+		pipe.ZAdd(ctx, q.allKey(), redis.Z{Score: millis(now), Member: queuedEmail.ID})
+		pipe.SAdd(ctx, q.statusKey(domain.StatusPending), queuedEmail.ID)
+		pipe.ZAdd(ctx, q.pendingDueKey(), redis.Z{Score: millis(dueAt(queuedEmail)), Member: queuedEmail.ID})
+		if dueAt(queuedEmail).After(now) {
+			pipe.ZAdd(ctx, q.scheduledKey(), redis.Z{Score: millis(dueAt(queuedEmail)), Member: queuedEmail.ID})
+		} else {
+			pipe.ZAdd(ctx, q.readyKey(), redis.Z{Score: rank(queuedEmail), Member: queuedEmail.ID})
+		}
+		return nil
+	})
+	if err != nil {
+		q.client.Del(ctx, q.messageKey(queuedEmail.MessageID))
+		q.logger.Error("failed to enqueue email", "error", err, "message_id", message.ID)
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+
+	q.logger.Info("email enqueued successfully",
+		"message_id", message.ID,
+		"to", message.To,
+		"subject", message.Subject,
+		"priority", message.Priority,
+	)
+
+	return nil
+}
+
+// claimScript hands out up to ARGV[3] due emails in one step, so two workers never claim the same
+// email. Claims older than ARGV[4] are first returned as retrying, and scheduled emails that have
+// fallen due join the ready set.
+var claimScript = redis.NewScript(`
+local readyKey, scheduledKey, processingKey, pendingDueKey = KEYS[1], KEYS[2], KEYS[3], KEYS[4]
+local prefix = ARGV[1]
+local now = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local leaseCutoff = tonumber(ARGV[4])
+
+local expired = redis.call('ZRANGEBYSCORE', processingKey, '-inf', leaseCutoff)
+for _, id in ipairs(expired) do
+  redis.call('ZREM', processingKey, id)
+  local key = prefix .. ':email:' .. id
+  if redis.call('HGET', key, 'status') == 'sending' then
+    redis.call('HSET', key, 'status', 'retrying')
+    redis.call('SMOVE', prefix .. ':status:sending', prefix .. ':status:retrying', id)
+    redis.call('ZADD', scheduledKey, now, id)
+  end
+end
+
+local due = redis.call('ZRANGEBYSCORE', scheduledKey, '-inf', now)
+for _, id in ipairs(due) do
+  redis.call('ZREM', scheduledKey, id)
+  local rank = redis.call('HGET', prefix .. ':email:' .. id, 'rank')
+  if rank then
+    redis.call('ZADD', readyKey, rank, id)
+  end
+end
+
+local ids = redis.call('ZRANGE', readyKey, 0, limit - 1)
+for _, id in ipairs(ids) do
+  local key = prefix .. ':email:' .. id
+  local status = redis.call('HGET', key, 'status')
+  redis.call('ZREM', readyKey, id)
+  redis.call('ZREM', pendingDueKey, id)
+  redis.call('ZADD', processingKey, now, id)
+  redis.call('HSET', key, 'status', 'sending')
+  if status then
+    redis.call('SMOVE', prefix .. ':status:' .. status, prefix .. ':status:sending', id)
+  end
+end
+return ids
+`)
+
+// Dequeue claims emails from the queue for processing
+func (q *RedisQueue) Dequeue(ctx context.Context, limit int) ([]*domain.QueuedEmail, error) {
+	if limit <= 0 {
+		return []*domain.QueuedEmail{}, nil
+	}
+
+	now := q.clock.Now()
+	ids, err := claimScript.Run(ctx, q.client,
+		[]string{q.readyKey(), q.scheduledKey(), q.processingKey(), q.pendingDueKey()},
+		q.prefix, millis(now), limit, millis(now.Add(-q.lease)),
+	).StringSlice()
+	if err != nil {
+		q.logger.Error("failed to dequeue emails", "error", err)
+		return nil, fmt.Errorf("failed to dequeue emails: %w", err)
+	}
+
+	emails, err := q.load(ctx, ids)
+	if err != nil {
+		q.logger.Error("failed to load dequeued emails", "error", err)
+		return nil, fmt.Errorf("failed to dequeue emails: %w", err)
+	}
+
+	q.logger.Debug("dequeued emails for processing", "count", len(emails))
+	return emails, nil
+}
+
+// MarkSent marks an email as successfully sent
+func (q *RedisQueue) MarkSent(ctx context.Context, emailID string, result *domain.EmailResult) error {
+	queuedEmail, err := q.get(ctx, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to find email: %w", err)
+	}
+
+	previous := queuedEmail.Status
+	now := q.clock.Now()
+	queuedEmail.Status = domain.StatusSent
+	queuedEmail.SentAt = &now
+	if result != nil && result.Metadata != nil {
+		metadataJSON, _ := json.Marshal(result.Metadata)
+		queuedEmail.Metadata = string(metadataJSON)
+	}
+
+	if err := q.save(ctx, queuedEmail, previous, nil); err != nil {
+		q.logger.Error("failed to mark email as sent", "error", err, "email_id", emailID)
+		return fmt.Errorf("failed to mark email as sent: %w", err)
+	}
+
+	q.logger.Info("email marked as sent", "email_id", emailID)
+	return nil
+}
+
+// MarkFailed marks an email as failed, scheduling a retry with backoff while retries remain
+func (q *RedisQueue) MarkFailed(ctx context.Context, emailID string, failureErr error) error {
+	queuedEmail, err := q.get(ctx, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to find email: %w", err)
+	}
+
+	previous := queuedEmail.Status
+	queuedEmail.AttemptCount++
+	queuedEmail.LastError = failureErr.Error()
+
+	var retryAt *time.Time
+	if !domain.IsRetryableError(failureErr) {
+		queuedEmail.Status = domain.StatusFailed
+		queuedEmail.Permanent = true
+		q.logger.Warn("email permanently failed with non-retryable error",
+			"email_id", emailID,
+			"attempts", queuedEmail.AttemptCount,
+			"error", failureErr.Error(),
+		)
+	} else if queuedEmail.AttemptCount >= queuedEmail.MaxRetries {
+		queuedEmail.Status = domain.StatusFailed
+		q.logger.Warn("email permanently failed after max retries",
+			"email_id", emailID,
+			"attempts", queuedEmail.AttemptCount,
+			"error", failureErr.Error(),
+		)
+	} else {
+		queuedEmail.Status = domain.StatusRetrying
+		nextRetry := q.clock.Now().Add(time.Duration(calculateBackoff(queuedEmail.AttemptCount)) * time.Second)
+		queuedEmail.ScheduledAt = &nextRetry
+		retryAt = &nextRetry
+
+		q.logger.Info("email scheduled for retry",
+			"email_id", emailID,
+			"attempt", queuedEmail.AttemptCount,
+			"next_retry", nextRetry,
+			"error", failureErr.Error(),
+		)
+	}
+
+	if err := q.save(ctx, queuedEmail, previous, retryAt); err != nil {
+		q.logger.Error("failed to update email failure status", "error", err, "email_id", emailID)
+		return fmt.Errorf("failed to update email failure status: %w", err)
+	}
+
+	return nil
+}
+
+// RetryFailed requeues failed emails attempted fewer than maxRetries times and created within
+// maxAge ("0" has no age limit). Permanent failures such as hard bounces are never requeued.
+// It returns the number of emails requeued.
+func (q *RedisQueue) RetryFailed(ctx context.Context, maxRetries int, maxAge time.Duration) (int64, error) {
+	ids, err := q.client.SMembers(ctx, q.statusKey(domain.StatusFailed)).Result()
+	if err != nil {
+		q.logger.Error("failed to retry failed emails", "error", err)
+		return 0, fmt.Errorf("failed to retry failed emails: %w", err)
+	}
+
+	var cutoff time.Time
+	if maxAge > 0 {
+		cutoff = q.clock.Now().Add(-maxAge)
+	}
+
+	var retried int64
+	for start := 0; start < len(ids); start += listBatchSize {
+		emails, err := q.load(ctx, ids[start:min(start+listBatchSize, len(ids))])
+		if err != nil {
+			return retried, fmt.Errorf("failed to retry failed emails: %w", err)
+		}
+
+		for _, queuedEmail := range emails {
+			if queuedEmail.Status != domain.StatusFailed || queuedEmail.Permanent ||
+				queuedEmail.AttemptCount >= maxRetries || queuedEmail.CreatedAt.Before(cutoff) {
+				continue
+			}
+
+			queuedEmail.Status = domain.StatusPending
+			queuedEmail.ScheduledAt = nil
+			if err := q.save(ctx, queuedEmail, domain.StatusFailed, nil); err != nil {
+				q.logger.Error("failed to retry failed emails", "error", err, "email_id", queuedEmail.ID)
+				return retried, fmt.Errorf("failed to retry failed emails: %w", err)
+			}
+			retried++
+		}
+	}
+
+	q.logger.Info("retried failed emails", "count", retried)
+	return retried, nil
+}
+
+// GetStats returns queue statistics
+func (q *RedisQueue) GetStats(ctx context.Context) (*domain.QueueStats, error) {
+	now := q.clock.Now()
+	statuses := []domain.EmailStatus{
+		domain.StatusPending, domain.StatusSending, domain.StatusSent, domain.StatusFailed, domain.StatusRetrying,
+	}
+
+	counts := make([]*redis.IntCmd, len(statuses))
+	var scheduled *redis.IntCmd
+	var oldest *redis.ZSliceCmd
+	_, err := q.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, status := range statuses {
+			counts[i] = pipe.SCard(ctx, q.statusKey(status))
+		}
+		scheduled = pipe.ZCount(ctx, q.scheduledKey(), "("+formatScore(millis(now)), "+inf")
+		oldest = pipe.ZRangeByScoreWithScores(ctx, q.pendingDueKey(), &redis.ZRangeBy{
+			Min: "-inf", Max: formatScore(millis(now)), Count: 1,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue stats: %w", err)
+	}
+
+	stats := &domain.QueueStats{
+		Pending:   counts[0].Val(),
+		Sending:   counts[1].Val(),
+		Sent:      counts[2].Val(),
+		Failed:    counts[3].Val(),
+		Retrying:  counts[4].Val(),
+		Scheduled: scheduled.Val(),
+	}
+	// A scheduled email starts waiting at its scheduled time, not when it was queued
+	if due := oldest.Val(); len(due) > 0 {
+		at := time.UnixMilli(int64(due[0].Score)).UTC()
+		stats.OldestPendingAt = &at
+	}
+
+	return stats, nil
+}
+
+// ListQueued returns a page of queued emails matching the filter, newest first, with the total match
+// count. The date range narrows the scan; the other filters are applied to each email in turn, so
+// unfiltered listings of a large queue are slower than on the database backend.
+func (q *RedisQueue) ListQueued(ctx context.Context, filter *domain.QueuedEmailFilter) ([]*domain.QueuedEmail, int, error) {
+	window := &redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+	if filter.DateFrom != nil {
+		window.Min = formatScore(millis(*filter.DateFrom))
+	}
+	if filter.DateTo != nil {
+		window.Max = "(" + formatScore(millis(filter.DateTo.AddDate(0, 0, 1)))
+	}
+
+	ids, err := q.client.ZRevRangeByScore(ctx, q.allKey(), window).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list queued emails: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	recipient := strings.ToLower(filter.Recipient)
+	emails := []*domain.QueuedEmail{}
+	total := 0
+	for start := 0; start < len(ids); start += listBatchSize {
+		batch, err := q.load(ctx, ids[start:min(start+listBatchSize, len(ids))])
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to list queued emails: %w", err)
+		}
+
+		for _, queuedEmail := range batch {
+			if (filter.Status != "" && queuedEmail.Status != filter.Status) ||
+				(recipient != "" && !strings.Contains(strings.ToLower(queuedEmail.To), recipient)) ||
+				(filter.TemplateID != "" && queuedEmail.TemplateID != filter.TemplateID) {
+				continue
+			}
+			if total >= offset && len(emails) < filter.PageSize {
+				emails = append(emails, queuedEmail)
+			}
+			total++
+		}
+	}
+
+	return emails, total, nil
+}
+
+// ListEvents returns no events. The Redis queue keeps no delivery event history; each email's
+// status, attempts and last error live on the email itself.
+func (q *RedisQueue) ListEvents(ctx context.Context, filter *domain.DeliveryEventFilter) ([]*domain.EmailDeliveryEvent, int, error) {
+	return []*domain.EmailDeliveryEvent{}, 0, nil
+}
+
+// cancelScript cancels a pending email scheduled after ARGV[2] in one step, so an email can't be
+// canceled once processing has picked it up. It returns 1 when the email was canceled.
+var cancelScript = redis.NewScript(`
+local emailKey, scheduledKey, pendingDueKey = KEYS[1], KEYS[2], KEYS[3]
+local prefix, id, now = ARGV[1], ARGV[3], tonumber(ARGV[2])
+
+if redis.call('HGET', emailKey, 'status') ~= 'pending' then
+  return 0
+end
+local due = redis.call('ZSCORE', scheduledKey, id)
+if not due or tonumber(due) <= now then
+  return 0
+end
+
+redis.call('HSET', emailKey, 'status', 'canceled')
+redis.call('SMOVE', prefix .. ':status:pending', prefix .. ':status:canceled', id)
+redis.call('ZREM', scheduledKey, id)
+redis.call('ZREM', pendingDueKey, id)
+return 1
+`)
+
+// CancelScheduled cancels a pending email that is scheduled in the future
+func (q *RedisQueue) CancelScheduled(ctx context.Context, messageID string) error {
+	id, err := q.client.Get(ctx, q.messageKey(messageID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return domain.ErrEmailNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find email: %w", err)
+	}
+
+	canceled, err := cancelScript.Run(ctx, q.client,
+		[]string{q.emailKey(id), q.scheduledKey(), q.pendingDueKey()},
+		q.prefix, millis(q.clock.Now()), id,
+	).Int()
+	if err != nil {
+		q.logger.Error("failed to cancel scheduled email", "error", err, "message_id", messageID)
+		return fmt.Errorf("failed to cancel scheduled email: %w", err)
+	}
+	if canceled == 0 {
+		return domain.ErrEmailNotCancellable
+	}
+
+	q.logger.Info("scheduled email canceled", "message_id", messageID)
+	return nil
+}
+
+// PurgeOld removes sent and failed emails created more than olderThan ago
+func (q *RedisQueue) PurgeOld(ctx context.Context, olderThan time.Duration) error {
+	cutoff := q.clock.Now().Add(-olderThan)
+	ids, err := q.client.ZRangeByScore(ctx, q.allKey(), &redis.ZRangeBy{
+		Min: "-inf", Max: "(" + formatScore(millis(cutoff)),
+	}).Result()
+	if err != nil {
+		q.logger.Error("failed to purge old emails", "error", err)
+		return fmt.Errorf("failed to purge old emails: %w", err)
+	}
+
+	var purged int
+	for start := 0; start < len(ids); start += listBatchSize {
+		emails, err := q.load(ctx, ids[start:min(start+listBatchSize, len(ids))])
+		if err != nil {
+			return fmt.Errorf("failed to purge old emails: %w", err)
+		}
+
+		_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, queuedEmail := range emails {
+				if queuedEmail.Status != domain.StatusSent && queuedEmail.Status != domain.StatusFailed {
+					continue
+				}
+				pipe.Del(ctx, q.emailKey(queuedEmail.ID), q.messageKey(queuedEmail.MessageID))
+				pipe.ZRem(ctx, q.allKey(), queuedEmail.ID)
+				pipe.SRem(ctx, q.statusKey(queuedEmail.Status), queuedEmail.ID)
+				purged++
+			}
+			return nil
+		})
+		if err != nil {
+			q.logger.Error("failed to purge old emails", "error", err)
+			return fmt.Errorf("failed to purge old emails: %w", err)
+		}
+	}
+
+	q.logger.Info("purged old emails", "count", purged, "older_than", olderThan)
+	return nil
+}
+
+// get loads one email, failing with ErrEmailNotFound when it doesn't exist
+func (q *RedisQueue) get(ctx context.Context, id string) (*domain.QueuedEmail, error) {
+	emails, err := q.load(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(emails) == 0 {
+		return nil, domain.ErrEmailNotFound
+	}
+	return emails[0], nil
+}
+
+// load reads the emails with the given IDs in one round trip, in order, skipping any that are gone
+func (q *RedisQueue) load(ctx context.Context, ids []string) ([]*domain.QueuedEmail, error) {
+	cmds := make([]*redis.SliceCmd, len(ids))
+	_, err := q.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HMGet(ctx, q.emailKey(id), "data", "status")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]*domain.QueuedEmail, 0, len(ids))
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		data, ok := fields[0].(string)
+		if !ok {
+			continue
+		}
+		var queuedEmail domain.QueuedEmail
+		if err := json.Unmarshal([]byte(data), &queuedEmail); err != nil {
+			q.logger.Error("failed to decode queued email", "error", err, "email_id", ids[i])
+			continue
+		}
+		// The status is kept beside the data so the scripts can change it
+		if status, ok := fields[1].(string); ok {
+			queuedEmail.Status = domain.EmailStatus(status)
+		}
+		emails = append(emails, &queuedEmail)
+	}
+	return emails, nil
+}
+
+// save stores an email whose status moved from previous, releasing any claim on it and putting it
+// back in line: a retry waits until retryAt, and a pending email is ready at once
+func (q *RedisQueue) save(
+	ctx context.Context,
+	queuedEmail *domain.QueuedEmail,
+	previous domain.EmailStatus,
+	retryAt *time.Time,
+) error {
+	queuedEmail.UpdatedAt = q.clock.Now()
+	data, err := json.Marshal(queuedEmail)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.emailKey(queuedEmail.ID), "data", data, "status", string(queuedEmail.Status))
+		pipe.SMove(ctx, q.statusKey(previous), q.statusKey(queuedEmail.Status), queuedEmail.ID)
+		pipe.ZRem(ctx, q.processingKey(), queuedEmail.ID)
+		switch {
+		case retryAt != nil:
+			pipe.ZAdd(ctx, q.scheduledKey(), redis.Z{Score: millis(*retryAt), Member: queuedEmail.ID})
+		case queuedEmail.Status == domain.StatusPending:
+			pipe.ZAdd(ctx, q.readyKey(), redis.Z{Score: rank(queuedEmail), Member: queuedEmail.ID})
+			pipe.ZAdd(ctx, q.pendingDueKey(), redis.Z{Score: millis(dueAt(queuedEmail)), Member: queuedEmail.ID})
+		}
+		return nil
+	})
+	return err
+}
+
+// QueuedEmailToMessage converts a QueuedEmail back to an EmailMessage
+func (q *RedisQueue) QueuedEmailToMessage(queuedEmail *domain.QueuedEmail) (*domain.EmailMessage, error) {
+	return ToMessage(queuedEmail, q.logger), nil
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
@@ -0,0 +1,281 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+func newTestRedisQueue(t *testing.T) (*RedisQueue, *clock.Mock) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	clk := clock.NewMock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewRedisQueue(client, "test_queue", 10*time.Minute, logger, clk), clk
+}
+
+func testMessage(id string, priority domain.EmailPriority) *domain.EmailMessage {
+	return &domain.EmailMessage{
+		ID:         id,
+		From:       "noreply@example.com",
+		To:         []string{id + "@example.com"},
+		Subject:    "Hello",
+		TextBody:   "Hello",
+		TemplateID: "welcome",
+		Priority:   priority,
+	}
+}
+
+func dequeuedIDs(emails []*domain.QueuedEmail) []string {
+	ids := make([]string, 0, len(emails))
+	for _, email := range emails {
+		ids = append(ids, email.MessageID)
+	}
+	return ids
+}
+
+func TestRedisQueueLifecycle(t *testing.T) {
+	ctx := context.Background()
+	q, clk := newTestRedisQueue(t)
+
+	// Higher priority first, then oldest first
+	for _, message := range []*domain.EmailMessage{
+		testMessage("normal-1", domain.PriorityNormal),
+		testMessage("high", domain.PriorityHigh),
+		testMessage("normal-2", domain.PriorityNormal),
+	} {
+		if !assert.NoError(t, q.Enqueue(ctx, message)) {
+			return
+		}
+		clk.Advance(time.Second)
+	}
+	assert.Error(t, q.Enqueue(ctx, testMessage("high", domain.PriorityHigh)), "message IDs are unique")
+
+	stats, err := q.GetStats(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(3), stats.Pending)
+	assert.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), *stats.OldestPendingAt)
+
+	emails, err := q.Dequeue(ctx, 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"high", "normal-1"}, dequeuedIDs(emails))
+	assert.Equal(t, domain.StatusSending, emails[0].Status)
+	message := ToMessage(emails[0], q.logger)
+	assert.Equal(t, []string{"high@example.com"}, message.To)
+
+	// Claimed emails aren't handed out twice
+	rest, err := q.Dequeue(ctx, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"normal-2"}, dequeuedIDs(rest))
+
+	assert.NoError(t, q.MarkSent(ctx, emails[0].ID, &domain.EmailResult{Metadata: map[string]string{"provider_id": "p1"}}))
+	assert.NoError(t, q.MarkFailed(ctx, emails[1].ID, domain.ErrProviderPermanentFailure))
+	assert.NoError(t, q.MarkFailed(ctx, rest[0].ID, domain.ErrProviderTemporaryFailure))
+
+	stats, err = q.GetStats(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &domain.QueueStats{Sent: 1, Failed: 1, Retrying: 1, Scheduled: 1}, stats)
+
+	sent, err := q.get(ctx, emails[0].ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, domain.StatusSent, sent.Status)
+		assert.NotNil(t, sent.SentAt)
+		assert.JSONEq(t, `{"provider_id":"p1"}`, sent.Metadata)
+	}
+	failed, err := q.get(ctx, emails[1].ID)
+	if assert.NoError(t, err) {
+		assert.True(t, failed.Permanent)
+		assert.Equal(t, 1, failed.AttemptCount)
+	}
+
+	// The retry waits out its backoff
+	emails, err = q.Dequeue(ctx, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, emails)
+	clk.Advance(2 * time.Minute)
+	emails, err = q.Dequeue(ctx, 10)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"normal-2"}, dequeuedIDs(emails))
+	}
+}
+
+func TestRedisQueueLeaseExpiry(t *testing.T) {
+	ctx := context.Background()
+	q, clk := newTestRedisQueue(t)
+	assert.NoError(t, q.Enqueue(ctx, testMessage("m1", domain.PriorityNormal)))
+
+	claimed, err := q.Dequeue(ctx, 1)
+	if !assert.NoError(t, err) || !assert.Len(t, claimed, 1) {
+		return
+	}
+
+	// The worker never reports back; within the lease the email stays claimed
+	clk.Advance(5 * time.Minute)
+	emails, err := q.Dequeue(ctx, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, emails)
+
+	// Once the lease has passed it is handed out again
+	clk.Advance(5 * time.Minute)
+	emails, err = q.Dequeue(ctx, 1)
+	if assert.NoError(t, err) && assert.Len(t, emails, 1) {
+		assert.Equal(t, claimed[0].ID, emails[0].ID)
+		assert.Equal(t, domain.StatusSending, emails[0].Status)
+	}
+
+	stats, err := q.GetStats(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), stats.Sending)
+		assert.Zero(t, stats.Retrying)
+	}
+}
+
+func TestRedisQueueScheduledAndCancel(t *testing.T) {
+	ctx := context.Background()
+	q, clk := newTestRedisQueue(t)
+
+	later := clk.Now().Add(time.Hour)
+	scheduled := testMessage("scheduled", domain.PriorityNormal)
+	scheduled.ScheduledAt = &later
+	assert.NoError(t, q.Enqueue(ctx, scheduled))
+	assert.NoError(t, q.Enqueue(ctx, testMessage("now", domain.PriorityNormal)))
+
+	stats, err := q.GetStats(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), stats.Pending)
+		assert.Equal(t, int64(1), stats.Scheduled)
+	}
+
+	emails, err := q.Dequeue(ctx, 10)
+	if !assert.NoError(t, err) || !assert.Equal(t, []string{"now"}, dequeuedIDs(emails)) {
+		return
+	}
+	assert.NoError(t, q.MarkSent(ctx, emails[0].ID, nil))
+
+	assert.ErrorIs(t, q.CancelScheduled(ctx, "missing"), domain.ErrEmailNotFound)
+	assert.ErrorIs(t, q.CancelScheduled(ctx, "now"), domain.ErrEmailNotCancellable)
+	assert.NoError(t, q.CancelScheduled(ctx, "scheduled"))
+	assert.ErrorIs(t, q.CancelScheduled(ctx, "scheduled"), domain.ErrEmailNotCancellable)
+
+	// A canceled email is never sent
+	clk.Advance(2 * time.Hour)
+	emails, err = q.Dequeue(ctx, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, emails)
+}
+
+func TestRedisQueueRetryFailed(t *testing.T) {
+	ctx := context.Background()
+	q, clk := newTestRedisQueue(t)
+
+	fail := func(id string, failure error) {
+		assert.NoError(t, q.Enqueue(ctx, testMessage(id, domain.PriorityNormal)))
+		emails, err := q.Dequeue(ctx, 1)
+		if assert.NoError(t, err) && assert.Len(t, emails, 1) {
+			assert.NoError(t, q.MarkFailed(ctx, emails[0].ID, failure))
+		}
+	}
+	fail("old", domain.ErrProviderPermanentFailure)
+	clk.Advance(48 * time.Hour)
+	fail("bounced", domain.ErrProviderPermanentFailure)
+
+	// Permanent failures stay failed
+	retried, err := q.RetryFailed(ctx, 6, 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Zero(t, retried)
+
+	// Clear the permanent flag, as a failure after max retries would leave it
+	for _, id := range []string{"old", "bounced"} {
+		emails, _, err := q.ListQueued(ctx, &domain.QueuedEmailFilter{Recipient: id + "@", Page: 1, PageSize: 10})
+		if assert.NoError(t, err) && assert.Len(t, emails, 1) {
+			emails[0].Permanent = false
+			assert.NoError(t, q.save(ctx, emails[0], domain.StatusFailed, nil))
+		}
+	}
+
+	// Only emails within the age limit are requeued
+	retried, err = q.RetryFailed(ctx, 6, 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), retried)
+
+	emails, err := q.Dequeue(ctx, 10)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"bounced"}, dequeuedIDs(emails))
+	}
+}
+
+func TestRedisQueueListAndPurge(t *testing.T) {
+	ctx := context.Background()
+	q, clk := newTestRedisQueue(t)
+
+	for i := 1; i <= 5; i++ {
+		message := testMessage(fmt.Sprintf("m%d", i), domain.PriorityNormal)
+		if i == 5 {
+			message.TemplateID = "password_reset"
+		}
+		assert.NoError(t, q.Enqueue(ctx, message))
+		clk.Advance(time.Minute)
+	}
+
+	// Newest first, paged, with the total match count
+	emails, total, err := q.ListQueued(ctx, &domain.QueuedEmailFilter{Page: 2, PageSize: 2})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 5, total)
+		assert.Equal(t, []string{"m3", "m2"}, dequeuedIDs(emails))
+	}
+
+	emails, total, err = q.ListQueued(ctx, &domain.QueuedEmailFilter{TemplateID: "password_reset", Page: 1, PageSize: 10})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []string{"m5"}, dequeuedIDs(emails))
+	}
+
+	// Send two; only sent and failed emails are purged
+	claimed, err := q.Dequeue(ctx, 2)
+	if !assert.NoError(t, err) || !assert.Len(t, claimed, 2) {
+		return
+	}
+	for _, email := range claimed {
+		assert.NoError(t, q.MarkSent(ctx, email.ID, nil))
+	}
+
+	clk.Advance(time.Hour)
+	assert.NoError(t, q.PurgeOld(ctx, 30*time.Minute))
+
+	_, total, err = q.ListQueued(ctx, &domain.QueuedEmailFilter{Page: 1, PageSize: 10})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, total)
+	}
+	_, total, err = q.ListQueued(ctx, &domain.QueuedEmailFilter{Status: domain.StatusSent, Page: 1, PageSize: 10})
+	if assert.NoError(t, err) {
+		assert.Zero(t, total)
+	}
+	stats, err := q.GetStats(ctx)
+	if assert.NoError(t, err) {
+		assert.Zero(t, stats.Sent)
+		assert.Equal(t, int64(3), stats.Pending)
+	}
+
+	// A purged message ID can be queued again
+	assert.NoError(t, q.Enqueue(ctx, testMessage(claimed[0].MessageID, domain.PriorityNormal)))
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
+	"github.com/acheevo/tfa/internal/shared/clock"
 	"github.com/acheevo/tfa/internal/shared/config"
 	"github.com/acheevo/tfa/internal/shared/email/domain"
 	"github.com/acheevo/tfa/internal/shared/email/providers"
@@ -23,6 +26,7 @@ type Service struct {
 	provider       domain.EmailProviderInterface
 	queue          domain.EmailQueueInterface
 	templateEngine domain.EmailTemplateEngine
+	clock          clock.Clock
 }
 
 // NewService creates a new email service
@@ -31,6 +35,7 @@ func NewService(
 	logger *slog.Logger,
 	db interface{}, // Can be *gorm.DB or other database interface
 	templateEngine domain.EmailTemplateEngine,
+	clk clock.Clock,
 ) (*Service, error) {
 	// Create email provider based on configuration
 	provider, err := createProvider(cfg)
@@ -38,22 +43,18 @@ func NewService(
 		return nil, fmt.Errorf("failed to create email provider: %w", err)
 	}
 
-	// Create queue (assuming database queue for now)
-	var emailQueue domain.EmailQueueInterface
-	if gormDB, ok := db.(interface{ DB() interface{} }); ok {
-		// Extract gorm.DB from the wrapper
-		if actualDB, ok := gormDB.DB().(*gorm.DB); ok {
-			emailQueue = queue.NewDatabaseQueue(actualDB, logger)
-		}
-	}
-
-	if emailQueue == nil {
-		return nil, fmt.Errorf("failed to create email queue: unsupported database type")
+	emailQueue, err := createQueue(cfg, logger, db, clk)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use provided template engine or create default one
 	if templateEngine == nil {
-		templateEngine = templates.NewDefaultTemplateEngine(logger)
+		engine := templates.NewDefaultTemplateEngine(logger)
+		if err := engine.SetCriticalTemplates(cfg.GetEmailCriticalTemplates()); err != nil {
+			return nil, fmt.Errorf("invalid EMAIL_CRITICAL_TEMPLATES: %w", err)
+		}
+		templateEngine = engine
 	}
 
 	service := &Service{
@@ -62,13 +63,48 @@ func NewService(
 		provider:       provider,
 		queue:          emailQueue,
 		templateEngine: templateEngine,
+		clock:          clk,
 	}
 
 	return service, nil
 }
 
-// Send queues an email for asynchronous sending
+// createQueue creates the queue EMAIL_QUEUE_BACKEND selects: Redis, or by default the database
+func createQueue(cfg *config.Config, logger *slog.Logger, db interface{}, clk clock.Clock) (domain.EmailQueueInterface, error) {
+	if cfg.EmailQueueBackend == "redis" {
+		options, err := redis.ParseURL(cfg.EmailQueueRedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EMAIL_QUEUE_REDIS_URL: %w", err)
+		}
+		return queue.NewRedisQueue(
+			redis.NewClient(options),
+			cfg.EmailQueueRedisPrefix,
+			cfg.EmailQueueRedisLeaseDuration(),
+			logger,
+			clk,
+		), nil
+	}
+
+	if gormDB, ok := db.(*gorm.DB); ok {
+		return queue.NewDatabaseQueue(gormDB, logger, clk), nil
+	}
+	if gormDB, ok := db.(interface{ DB() interface{} }); ok {
+		// Extract gorm.DB from the wrapper
+		if actualDB, ok := gormDB.DB().(*gorm.DB); ok {
+			return queue.NewDatabaseQueue(actualDB, logger, clk), nil
+		}
+	}
+	return nil, fmt.Errorf("failed to create email queue: unsupported database type")
+}
+
+// Send queues an email for asynchronous sending. When email is disabled the message is dropped
+// with an info log and nil is returned, so callers don't treat it as a failure.
 func (s *Service) Send(ctx context.Context, message *domain.EmailMessage) error {
+	if !s.config.EmailEnabled {
+		s.logger.Info("email disabled, skipping email", "to", message.To, "subject", message.Subject)
+		return nil
+	}
+
 	// Set default values
 	if message.ID == "" {
 		message.ID = uuid.New().String()
@@ -86,7 +122,13 @@ func (s *Service) Send(ctx context.Context, message *domain.EmailMessage) error
 		message.Priority = domain.PriorityNormal
 	}
 
-	message.CreatedAt = time.Now()
+	message.CreatedAt = s.clock.Now()
+
+	s.applySendWindow(message)
+	s.tagEnvironment(message)
+
+	// Generate a text alternative for HTML-only messages
+	ensureTextBody(message)
 
 	// Validate email
 	if err := s.validateMessage(message); err != nil {
@@ -108,13 +150,18 @@ func (s *Service) Send(ctx context.Context, message *domain.EmailMessage) error
 	return nil
 }
 
-// SendTemplate sends an email using a template
+// SendTemplate sends an email using a template; like Send it is a no-op while email is disabled
 func (s *Service) SendTemplate(
 	ctx context.Context,
 	templateID string,
 	to []string,
 	variables map[string]interface{},
 ) error {
+	if !s.config.EmailEnabled {
+		s.logger.Info("email disabled, skipping email", "to", to, "template_id", templateID)
+		return nil
+	}
+
 	// Render the template
 	rendered, err := s.templateEngine.Render(templateID, variables)
 	if err != nil {
@@ -135,17 +182,28 @@ func (s *Service) SendTemplate(
 			"template_id": templateID,
 		},
 	}
+	tagFallback(message, rendered)
 
 	return s.Send(ctx, message)
 }
 
-// SendImmediate sends an email immediately without queuing
+// SendImmediate sends an email immediately without queuing. When email is disabled nothing is
+// sent and the result has StatusSkipped.
 func (s *Service) SendImmediate(ctx context.Context, message *domain.EmailMessage) (*domain.EmailResult, error) {
 	// Set default values
 	if message.ID == "" {
 		message.ID = uuid.New().String()
 	}
 
+	if !s.config.EmailEnabled {
+		s.logger.Info("email disabled, skipping email", "message_id", message.ID, "to", message.To, "subject", message.Subject)
+		return &domain.EmailResult{
+			MessageID: message.ID,
+			Status:    domain.StatusSkipped,
+			Message:   "email is disabled",
+		}, nil
+	}
+
 	if message.From == "" {
 		message.From = s.config.EmailFrom
 	}
@@ -154,6 +212,11 @@ func (s *Service) SendImmediate(ctx context.Context, message *domain.EmailMessag
 		message.FromName = s.config.EmailFromName
 	}
 
+	s.tagEnvironment(message)
+
+	// Generate a text alternative for HTML-only messages
+	ensureTextBody(message)
+
 	// Validate email
 	if err := s.validateMessage(message); err != nil {
 		return nil, fmt.Errorf("message validation failed: %w", err)
@@ -182,11 +245,105 @@ func (s *Service) Schedule(ctx context.Context, message *domain.EmailMessage, sc
 	return s.Send(ctx, message)
 }
 
+// ScheduleTemplate renders a template and schedules it for sending, tagging the message with metadata
+func (s *Service) ScheduleTemplate(
+	ctx context.Context,
+	templateID string,
+	to []string,
+	variables map[string]interface{},
+	metadata map[string]string,
+	scheduledAt time.Time,
+) error {
+	rendered, err := s.templateEngine.Render(templateID, variables)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	message := &domain.EmailMessage{
+		ID:         uuid.New().String(),
+		To:         to,
+		Subject:    rendered.Subject,
+		HTMLBody:   rendered.HTMLBody,
+		TextBody:   rendered.TextBody,
+		TemplateID: templateID,
+		Variables:  variables,
+		Priority:   domain.PriorityNormal,
+		Metadata: map[string]string{
+			"template_id": templateID,
+		},
+	}
+	tagFallback(message, rendered)
+	for key, value := range metadata {
+		message.Metadata[key] = value
+	}
+
+	return s.Schedule(ctx, message, scheduledAt)
+}
+
+// tagFallback marks a message rendered from a template's built-in fallback, so the queue shows which
+// emails went out without the custom template
+func tagFallback(message *domain.EmailMessage, rendered *domain.RenderedTemplate) {
+	if rendered.Fallback {
+		message.Metadata["template_fallback"] = "true"
+	}
+}
+
+// applySendWindow holds non-transactional email until the recipient's local send window opens.
+// Messages without a recipient timezone, or with one that does not load, keep their schedule.
+func (s *Service) applySendWindow(message *domain.EmailMessage) {
+	if !s.config.EmailSendWindowEnabled || message.IsTransactional() {
+		return
+	}
+
+	timezone := message.Metadata[domain.MetadataRecipientTimezone]
+	if timezone == "" {
+		return
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		s.logger.Warn("unknown recipient timezone, ignoring send window", "message_id", message.ID, "timezone", timezone)
+		return
+	}
+
+	sendAt := s.clock.Now()
+	if message.ScheduledAt != nil && message.ScheduledAt.After(sendAt) {
+		sendAt = *message.ScheduledAt
+	}
+
+	window := domain.SendWindow{StartHour: s.config.EmailSendWindowStart, EndHour: s.config.EmailSendWindowEnd}
+	if next := window.Next(sendAt, location); next.After(sendAt) {
+		message.ScheduledAt = &next
+		s.logger.Debug("email held for recipient send window", "message_id", message.ID, "timezone", timezone, "scheduled_at", next)
+	}
+}
+
+// IsSuppressed reports whether an address is on the configured suppression list, either
+// directly or through its domain
+func (s *Service) IsSuppressed(address string) bool {
+	address = strings.ToLower(strings.TrimSpace(address))
+	for _, entry := range s.config.GetEmailSuppressionList() {
+		if entry == address || (strings.HasPrefix(entry, "@") && strings.HasSuffix(address, entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CancelScheduled cancels a scheduled email that has not started sending yet
+func (s *Service) CancelScheduled(ctx context.Context, messageID string) error {
+	return s.queue.CancelScheduled(ctx, messageID)
+}
+
 // RegisterTemplate registers a new email template
 func (s *Service) RegisterTemplate(template *domain.EmailTemplate) error {
 	return s.templateEngine.RegisterTemplate(template)
 }
 
+// RegisterPartial registers a partial or layout that templates can include
+func (s *Service) RegisterPartial(partial *domain.EmailPartial) error {
+	return s.templateEngine.RegisterPartial(partial)
+}
+
 // GetTemplate retrieves a template by ID
 func (s *Service) GetTemplate(templateID string) (*domain.EmailTemplate, error) {
 	return s.templateEngine.GetTemplate(templateID)
@@ -247,11 +404,27 @@ func (s *Service) ProcessQueue(ctx context.Context) error {
 	return nil
 }
 
+// RetryFailed requeues failed emails that are still within the configured retry limits, leaving
+// permanent failures alone, and returns how many were requeued
+func (s *Service) RetryFailed(ctx context.Context) (int64, error) {
+	return s.queue.RetryFailed(ctx, s.config.EmailRetryMaxRetries, s.config.EmailRetryMaxAgeDuration())
+}
+
 // GetQueueStats returns queue statistics
 func (s *Service) GetQueueStats(ctx context.Context) (*domain.QueueStats, error) {
 	return s.queue.GetStats(ctx)
 }
 
+// ListQueuedEmails returns a page of queued emails matching the filter
+func (s *Service) ListQueuedEmails(ctx context.Context, filter *domain.QueuedEmailFilter) ([]*domain.QueuedEmail, int, error) {
+	return s.queue.ListQueued(ctx, filter)
+}
+
+// ListDeliveryEvents returns a page of delivery events matching the filter
+func (s *Service) ListDeliveryEvents(ctx context.Context, filter *domain.DeliveryEventFilter) ([]*domain.EmailDeliveryEvent, int, error) {
+	return s.queue.ListEvents(ctx, filter)
+}
+
 // GetDeliveryStatus gets the delivery status of an email
 func (s *Service) GetDeliveryStatus(ctx context.Context, messageID string) (*domain.EmailDeliveryStatus, error) {
 	return s.provider.GetDeliveryStatus(ctx, messageID)
@@ -336,15 +509,30 @@ func (s *Service) validateMessage(message *domain.EmailMessage) error {
 	return nil
 }
 
-// queuedEmailToMessage converts a queued email back to a message
-func (s *Service) queuedEmailToMessage(queuedEmail *domain.QueuedEmail) (*domain.EmailMessage, error) {
-	// This conversion logic should be in the queue implementation
-	// For now, we'll implement a basic conversion
-	if dbQueue, ok := s.queue.(*queue.DatabaseQueue); ok {
-		return dbQueue.QueuedEmailToMessage(queuedEmail)
+// tagEnvironment prefixes the subject and records the sending environment, so mail from
+// environments that share an inbox can be told apart
+func (s *Service) tagEnvironment(message *domain.EmailMessage) {
+	if prefix := s.config.EmailSubjectPrefixForEnvironment(); prefix != "" && !strings.HasPrefix(message.Subject, prefix) {
+		message.Subject = prefix + " " + message.Subject
 	}
 
-	return nil, fmt.Errorf("unsupported queue type for message conversion")
+	if s.config.Environment == "" {
+		return
+	}
+	if message.Headers == nil {
+		message.Headers = make(map[string]string)
+	}
+	message.Headers[domain.HeaderEnvironment] = s.config.Environment
+	if message.Metadata == nil {
+		message.Metadata = make(map[string]string)
+	}
+	message.Metadata["environment"] = s.config.Environment
+}
+
+// queuedEmailToMessage converts a queued email back to a message; the conversion does not depend on
+// the queue backend the email was stored in
+func (s *Service) queuedEmailToMessage(queuedEmail *domain.QueuedEmail) (*domain.EmailMessage, error) {
+	return queue.ToMessage(queuedEmail, s.logger), nil
 }
 
 // createProvider creates an email provider based on configuration
@@ -0,0 +1,217 @@
+package email
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+	"github.com/acheevo/tfa/internal/shared/email/templates"
+)
+
+func TestTagEnvironment(t *testing.T) {
+	tests := []struct {
+		environment string
+		subject     string
+	}{
+		{"development", "[DEVELOPMENT] Welcome"},
+		{"staging", "[STAGING] Welcome"},
+		{"production", "Welcome"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.environment, func(t *testing.T) {
+			s := &Service{config: &config.Config{
+				Environment:                    tt.environment,
+				EmailSubjectPrefixEnvironments: "development,staging",
+			}}
+
+			message := &domain.EmailMessage{Subject: "Welcome"}
+			s.tagEnvironment(message)
+			assert.Equal(t, tt.subject, message.Subject)
+			assert.Equal(t, tt.environment, message.Headers[domain.HeaderEnvironment])
+			assert.Equal(t, tt.environment, message.Metadata["environment"])
+
+			// Tagging twice does not stack prefixes
+			s.tagEnvironment(message)
+			assert.Equal(t, tt.subject, message.Subject)
+		})
+	}
+}
+
+func TestIsSuppressed(t *testing.T) {
+	s := &Service{config: &config.Config{EmailSuppressionList: "bounced@example.com,@blocked.dev"}}
+
+	assert.True(t, s.IsSuppressed("Bounced@Example.com"))
+	assert.True(t, s.IsSuppressed("anyone@blocked.dev"))
+	assert.False(t, s.IsSuppressed("user@example.com"))
+	assert.False(t, s.IsSuppressed("user@notblocked.dev"))
+}
+
+func TestSendSkippedWhenDisabled(t *testing.T) {
+	// No queue or provider: a disabled service must not touch either
+	s := &Service{
+		config: &config.Config{},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	message := &domain.EmailMessage{To: []string{"user@example.com"}, Subject: "Hi", TextBody: "Hello"}
+
+	assert.NoError(t, s.Send(context.Background(), message))
+	assert.NoError(t, s.SendTemplate(context.Background(), "welcome", []string{"user@example.com"}, nil))
+
+	result, err := s.SendImmediate(context.Background(), message)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusSkipped, result.Status)
+	assert.NotEmpty(t, result.MessageID)
+}
+
+// recordingQueue keeps enqueued messages and retry limits; other queue methods are not used
+type recordingQueue struct {
+	domain.EmailQueueInterface
+	enqueued        []*domain.EmailMessage
+	retryMaxRetries int
+	retryMaxAge     time.Duration
+}
+
+func (q *recordingQueue) Enqueue(ctx context.Context, message *domain.EmailMessage) error {
+	q.enqueued = append(q.enqueued, message)
+	return nil
+}
+
+func (q *recordingQueue) RetryFailed(ctx context.Context, maxRetries int, maxAge time.Duration) (int64, error) {
+	q.retryMaxRetries = maxRetries
+	q.retryMaxAge = maxAge
+	return 2, nil
+}
+
+func TestRetryFailedUsesConfiguredLimits(t *testing.T) {
+	q := &recordingQueue{}
+	s := &Service{
+		config: &config.Config{EmailRetryMaxRetries: 5, EmailRetryMaxAge: "12h"},
+		queue:  q,
+	}
+
+	retried, err := s.RetryFailed(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), retried)
+	assert.Equal(t, 5, q.retryMaxRetries)
+	assert.Equal(t, 12*time.Hour, q.retryMaxAge)
+}
+
+func TestSendHoldsEmailForRecipientSendWindow(t *testing.T) {
+	// 03:00 in New York
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	newYork, err := time.LoadLocation("America/New_York")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	newService := func(enabled bool) (*Service, *recordingQueue) {
+		q := &recordingQueue{}
+		return &Service{
+			config: &config.Config{
+				EmailEnabled:           true,
+				EmailSendWindowEnabled: enabled,
+				EmailSendWindowStart:   9,
+				EmailSendWindowEnd:     17,
+			},
+			logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+			queue:  q,
+			clock:  clock.NewMock(now),
+		}, q
+	}
+	reminder := func(metadata map[string]string) *domain.EmailMessage {
+		return &domain.EmailMessage{
+			To:       []string{"user@example.com"},
+			From:     "noreply@example.com",
+			Subject:  "Reminder",
+			TextBody: "Don't forget",
+			Metadata: metadata,
+		}
+	}
+
+	t.Run("reminder is scheduled into the window", func(t *testing.T) {
+		s, q := newService(true)
+		assert.NoError(t, s.Send(context.Background(), reminder(map[string]string{
+			domain.MetadataRecipientTimezone: "America/New_York",
+		})))
+
+		if assert.Len(t, q.enqueued, 1) && assert.NotNil(t, q.enqueued[0].ScheduledAt) {
+			assert.True(t, time.Date(2024, 3, 4, 9, 0, 0, 0, newYork).Equal(*q.enqueued[0].ScheduledAt))
+		}
+	})
+
+	t.Run("transactional email is not held", func(t *testing.T) {
+		s, q := newService(true)
+		assert.NoError(t, s.Send(context.Background(), reminder(map[string]string{
+			domain.MetadataRecipientTimezone: "America/New_York",
+			domain.MetadataTransactional:     "true",
+		})))
+
+		if assert.Len(t, q.enqueued, 1) {
+			assert.Nil(t, q.enqueued[0].ScheduledAt)
+		}
+	})
+
+	t.Run("unknown timezone is not held", func(t *testing.T) {
+		s, q := newService(true)
+		assert.NoError(t, s.Send(context.Background(), reminder(map[string]string{
+			domain.MetadataRecipientTimezone: "Mars/Olympus_Mons",
+		})))
+
+		if assert.Len(t, q.enqueued, 1) {
+			assert.Nil(t, q.enqueued[0].ScheduledAt)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s, q := newService(false)
+		assert.NoError(t, s.Send(context.Background(), reminder(map[string]string{
+			domain.MetadataRecipientTimezone: "America/New_York",
+		})))
+
+		if assert.Len(t, q.enqueued, 1) {
+			assert.Nil(t, q.enqueued[0].ScheduledAt)
+		}
+	})
+}
+
+func TestSendTemplateTagsFallback(t *testing.T) {
+	engine := templates.NewDefaultTemplateEngine(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if !assert.NoError(t, engine.SetCriticalTemplates([]string{"welcome"})) {
+		return
+	}
+	// A welcome template edited so that it parses but no longer renders
+	if !assert.NoError(t, engine.RegisterTemplate(&domain.EmailTemplate{
+		ID:       "welcome",
+		Name:     "Welcome Email",
+		Subject:  "Welcome!",
+		HTMLBody: `<p>Hi {{.user_name.first}}</p>`,
+	})) {
+		return
+	}
+
+	q := &recordingQueue{}
+	s := &Service{
+		config:         &config.Config{EmailEnabled: true},
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		queue:          q,
+		templateEngine: engine,
+		clock:          clock.NewMock(time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)),
+	}
+
+	err := s.SendTemplate(context.Background(), "welcome", []string{"user@example.com"}, map[string]interface{}{
+		"user_name": "Ada",
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, q.enqueued, 1) {
+		return
+	}
+	assert.Equal(t, "true", q.enqueued[0].Metadata["template_fallback"])
+	assert.Contains(t, q.enqueued[0].TextBody, "Hi Ada,")
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"html/template"
 	"log/slog"
+	"sort"
 	"strings"
 	"sync"
 	textTemplate "text/template"
+	"text/template/parse"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -15,17 +17,27 @@ import (
 	"github.com/acheevo/tfa/internal/shared/email/domain"
 )
 
+// contentTemplate is the name a layout uses to include the body of the template it wraps
+const contentTemplate = "content"
+
 // DefaultTemplateEngine implements EmailTemplateEngine
 type DefaultTemplateEngine struct {
 	templates map[string]*domain.EmailTemplate
+	partials  map[string]*domain.EmailPartial
+	fallbacks map[string]*domain.EmailTemplate
+	critical  map[string]bool
 	mutex     sync.RWMutex
 	logger    *slog.Logger
 }
 
-// NewDefaultTemplateEngine creates a new template engine
+// NewDefaultTemplateEngine creates a new template engine. No template is critical until
+// SetCriticalTemplates is called.
 func NewDefaultTemplateEngine(logger *slog.Logger) *DefaultTemplateEngine {
 	engine := &DefaultTemplateEngine{
 		templates: make(map[string]*domain.EmailTemplate),
+		partials:  make(map[string]*domain.EmailPartial),
+		fallbacks: make(map[string]*domain.EmailTemplate),
+		critical:  make(map[string]bool),
 		logger:    logger,
 	}
 
@@ -34,41 +46,93 @@ func NewDefaultTemplateEngine(logger *slog.Logger) *DefaultTemplateEngine {
 		logger.Error("failed to register default templates", "error", err)
 	}
 
+	for _, fallback := range fallbackTemplates {
+		if err := engine.ValidateTemplate(fallback); err != nil {
+			logger.Error("failed to register fallback template", "template_id", fallback.ID, "error", err)
+			continue
+		}
+		engine.fallbacks[fallback.ID] = fallback
+	}
+
 	return engine
 }
 
-// Render renders a template with the given variables
+// SetCriticalTemplates sets the templates that are sent as their built-in fallback when they fail to
+// render. Every one of them must have a fallback.
+func (e *DefaultTemplateEngine) SetCriticalTemplates(templateIDs []string) error {
+	critical := make(map[string]bool, len(templateIDs))
+	for _, id := range templateIDs {
+		if _, exists := e.fallbacks[id]; !exists {
+			return fmt.Errorf("%w: template %q has no built-in fallback", domain.ErrTemplateInvalid, id)
+		}
+		critical[id] = true
+	}
+
+	e.mutex.Lock()
+	e.critical = critical
+	e.mutex.Unlock()
+	return nil
+}
+
+// Render renders a template with the given variables. When a critical template fails to render, its
+// built-in fallback is rendered instead and the result is marked as a fallback, so the email still
+// goes out while the error is logged for operators to fix the template.
 func (e *DefaultTemplateEngine) Render(
 	templateID string,
 	variables map[string]interface{},
 ) (*domain.RenderedTemplate, error) {
 	e.mutex.RLock()
 	tmpl, exists := e.templates[templateID]
+	partials := e.snapshotPartials()
+	fallback := e.fallbacks[templateID]
+	critical := e.critical[templateID]
 	e.mutex.RUnlock()
 
 	if !exists {
 		return nil, domain.ErrTemplateNotFound
 	}
 
+	rendered, err := e.render(tmpl, partials, variables)
+	if err == nil || !critical {
+		return rendered, err
+	}
+
+	e.logger.Error("email template failed to render, sending its built-in fallback; fix the template",
+		"template_id", templateID, "error", err)
+	rendered, fallbackErr := e.render(fallback, nil, variables)
+	if fallbackErr != nil {
+		e.logger.Error("fallback email template failed to render", "template_id", templateID, "error", fallbackErr)
+		return nil, err
+	}
+	rendered.Fallback = true
+	return rendered, nil
+}
+
+// render renders a template, its layout and the partials it includes
+func (e *DefaultTemplateEngine) render(
+	tmpl *domain.EmailTemplate,
+	partials map[string]*domain.EmailPartial,
+	variables map[string]interface{},
+) (*domain.RenderedTemplate, error) {
 	// Validate required variables
 	if err := e.validateVariables(tmpl, variables); err != nil {
 		return nil, err
 	}
 
 	// Render subject
-	subject, err := e.renderText(tmpl.Subject, variables)
+	subject, err := e.renderText(tmpl.Subject, "", partials, variables)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render subject: %w", err)
 	}
 
 	// Render HTML body
-	htmlBody, err := e.renderHTML(tmpl.HTMLBody, variables)
+	htmlBody, err := e.renderHTML(tmpl.HTMLBody, tmpl.Layout, partials, variables)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render HTML body: %w", err)
 	}
 
 	// Render text body
-	textBody, err := e.renderText(tmpl.TextBody, variables)
+	textBody, err := e.renderText(tmpl.TextBody, tmpl.Layout, partials, variables)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render text body: %w", err)
 	}
@@ -94,6 +158,37 @@ func (e *DefaultTemplateEngine) RegisterTemplate(tmpl *domain.EmailTemplate) err
 	return nil
 }
 
+// RegisterPartial registers a partial or layout that templates include by name. Partials it
+// includes must already be registered; a layout may also include the wrapped body as "content".
+// Templates registered earlier keep rendering with the partial's current version.
+func (e *DefaultTemplateEngine) RegisterPartial(partial *domain.EmailPartial) error {
+	if partial.Name == "" {
+		return fmt.Errorf("%w: partial name is required", domain.ErrTemplateInvalid)
+	}
+	if partial.Name == contentTemplate {
+		return fmt.Errorf("%w: %q is reserved for the body a layout wraps", domain.ErrTemplateInvalid, contentTemplate)
+	}
+	if partial.HTMLBody == "" && partial.TextBody == "" {
+		return fmt.Errorf("%w: partial must have either HTML or text body", domain.ErrTemplateInvalid)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for kind, body := range map[string]string{"HTML": partial.HTMLBody, "text": partial.TextBody} {
+		if body == "" {
+			continue
+		}
+		if err := e.checkReferences(kind, body, true); err != nil {
+			return fmt.Errorf("partial %q: %w", partial.Name, err)
+		}
+	}
+
+	e.partials[partial.Name] = partial
+	e.logger.Info("template partial registered", "name", partial.Name)
+	return nil
+}
+
 // GetTemplate retrieves a template by ID
 func (e *DefaultTemplateEngine) GetTemplate(templateID string) (*domain.EmailTemplate, error) {
 	e.mutex.RLock()
@@ -120,7 +215,7 @@ func (e *DefaultTemplateEngine) ListTemplates() ([]*domain.EmailTemplate, error)
 	return templates, nil
 }
 
-// ValidateTemplate validates a template
+// ValidateTemplate validates a template, including that its layout and the partials it includes exist
 func (e *DefaultTemplateEngine) ValidateTemplate(tmpl *domain.EmailTemplate) error {
 	if tmpl.ID == "" {
 		return fmt.Errorf("%w: template ID is required", domain.ErrTemplateInvalid)
@@ -138,24 +233,33 @@ func (e *DefaultTemplateEngine) ValidateTemplate(tmpl *domain.EmailTemplate) err
 		return fmt.Errorf("%w: template must have either HTML or text body", domain.ErrTemplateInvalid)
 	}
 
-	// Validate template syntax
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if tmpl.Layout != "" {
+		if _, exists := e.partials[tmpl.Layout]; !exists {
+			return fmt.Errorf("%w: unknown layout %q", domain.ErrTemplateInvalid, tmpl.Layout)
+		}
+	}
+
+	// Validate template syntax and references
 	if tmpl.HTMLBody != "" {
-		_, err := template.New("test").Parse(tmpl.HTMLBody)
-		if err != nil {
+		if _, err := template.New("test").Funcs(e.getTemplateFunctions()).Parse(tmpl.HTMLBody); err != nil {
 			return fmt.Errorf("%w: HTML template syntax error: %v", domain.ErrTemplateInvalid, err)
 		}
+		if err := e.checkReferences("HTML", tmpl.HTMLBody, false); err != nil {
+			return err
+		}
 	}
 
 	if tmpl.TextBody != "" {
-		_, err := textTemplate.New("test").Parse(tmpl.TextBody)
-		if err != nil {
-			return fmt.Errorf("%w: text template syntax error: %v", domain.ErrTemplateInvalid, err)
+		if err := e.checkReferences("text", tmpl.TextBody, false); err != nil {
+			return err
 		}
 	}
 
 	if tmpl.Subject != "" {
-		_, err := textTemplate.New("test").Parse(tmpl.Subject)
-		if err != nil {
+		if _, err := textTemplate.New("test").Funcs(e.getTextTemplateFunctions()).Parse(tmpl.Subject); err != nil {
 			return fmt.Errorf("%w: subject template syntax error: %v", domain.ErrTemplateInvalid, err)
 		}
 	}
@@ -163,30 +267,146 @@ func (e *DefaultTemplateEngine) ValidateTemplate(tmpl *domain.EmailTemplate) err
 	return nil
 }
 
-// renderHTML renders an HTML template
-func (e *DefaultTemplateEngine) renderHTML(tmplText string, variables map[string]interface{}) (string, error) {
-	tmpl, err := template.New("html").Funcs(e.getTemplateFunctions()).Parse(tmplText)
+// checkReferences parses body and checks that every template it includes is defined in body itself
+// or is a registered partial with a body of the same kind. Callers hold the mutex.
+func (e *DefaultTemplateEngine) checkReferences(kind, body string, allowContent bool) error {
+	parsed, err := textTemplate.New("body").Funcs(e.getTextTemplateFunctions()).Parse(body)
 	if err != nil {
+		return fmt.Errorf("%w: %s template syntax error: %v", domain.ErrTemplateInvalid, kind, err)
+	}
+
+	references := map[string]bool{}
+	for _, defined := range parsed.Templates() {
+		if defined.Tree != nil {
+			collectReferences(defined.Tree.Root, references)
+		}
+	}
+
+	var missing []string
+	for name := range references {
+		if name == contentTemplate {
+			// Only a layout can include the body, which is itself rendered as "content"
+			if allowContent {
+				continue
+			}
+			missing = append(missing, name)
+			continue
+		}
+		if defined := parsed.Lookup(name); defined != nil && defined.Tree != nil {
+			continue
+		}
+		partial, exists := e.partials[name]
+		if !exists || (kind == "HTML" && partial.HTMLBody == "") || (kind == "text" && partial.TextBody == "") {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%w: %s body includes unknown partials: %s", domain.ErrTemplateInvalid, kind, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// collectReferences adds the names of templates included under node to references
+func collectReferences(node parse.Node, references map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectReferences(child, references)
+		}
+	case *parse.TemplateNode:
+		references[n.Name] = true
+	case *parse.IfNode:
+		collectReferences(n.List, references)
+		collectReferences(n.ElseList, references)
+	case *parse.RangeNode:
+		collectReferences(n.List, references)
+		collectReferences(n.ElseList, references)
+	case *parse.WithNode:
+		collectReferences(n.List, references)
+		collectReferences(n.ElseList, references)
+	}
+}
+
+// snapshotPartials copies the partials so rendering can run without holding the mutex.
+// Callers hold the mutex.
+func (e *DefaultTemplateEngine) snapshotPartials() map[string]*domain.EmailPartial {
+	partials := make(map[string]*domain.EmailPartial, len(e.partials))
+	for name, partial := range e.partials {
+		partials[name] = partial
+	}
+	return partials
+}
+
+// renderHTML renders an HTML body with the partials available, inside layout when it has an HTML body
+func (e *DefaultTemplateEngine) renderHTML(
+	body, layout string,
+	partials map[string]*domain.EmailPartial,
+	variables map[string]interface{},
+) (string, error) {
+	if body == "" {
+		return "", nil
+	}
+
+	set := template.New(contentTemplate).Funcs(e.getTemplateFunctions())
+	for name, partial := range partials {
+		if partial.HTMLBody == "" {
+			continue
+		}
+		if _, err := set.New(name).Parse(partial.HTMLBody); err != nil {
+			return "", fmt.Errorf("partial %q: %w", name, err)
+		}
+	}
+	if _, err := set.Parse(body); err != nil {
 		return "", err
 	}
 
+	entry := contentTemplate
+	if partial, exists := partials[layout]; exists && partial.HTMLBody != "" {
+		entry = layout
+	}
+
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, variables); err != nil {
+	if err := set.ExecuteTemplate(&buf, entry, variables); err != nil {
 		return "", err
 	}
 
 	return buf.String(), nil
 }
 
-// renderText renders a text template
-func (e *DefaultTemplateEngine) renderText(tmplText string, variables map[string]interface{}) (string, error) {
-	tmpl, err := textTemplate.New("text").Funcs(e.getTextTemplateFunctions()).Parse(tmplText)
-	if err != nil {
+// renderText renders a text body with the partials available, inside layout when it has a text body
+func (e *DefaultTemplateEngine) renderText(
+	body, layout string,
+	partials map[string]*domain.EmailPartial,
+	variables map[string]interface{},
+) (string, error) {
+	if body == "" {
+		return "", nil
+	}
+
+	set := textTemplate.New(contentTemplate).Funcs(e.getTextTemplateFunctions())
+	for name, partial := range partials {
+		if partial.TextBody == "" {
+			continue
+		}
+		if _, err := set.New(name).Parse(partial.TextBody); err != nil {
+			return "", fmt.Errorf("partial %q: %w", name, err)
+		}
+	}
+	if _, err := set.Parse(body); err != nil {
 		return "", err
 	}
 
+	entry := contentTemplate
+	if partial, exists := partials[layout]; exists && partial.TextBody != "" {
+		entry = layout
+	}
+
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, variables); err != nil {
+	if err := set.ExecuteTemplate(&buf, entry, variables); err != nil {
 		return "", err
 	}
 
@@ -244,32 +464,63 @@ func (e *DefaultTemplateEngine) getTextTemplateFunctions() textTemplate.FuncMap
 	}
 }
 
-// registerDefaultTemplates registers built-in templates
-func (e *DefaultTemplateEngine) registerDefaultTemplates() error {
-	// Email verification template
-	if err := e.RegisterTemplate(&domain.EmailTemplate{
-		ID:        "email_verification",
-		Name:      "Email Verification",
-		Subject:   "Verify your email address",
-		Variables: []string{"user_name", "verification_url", "app_name"},
+// Built-in partials shared by the default templates; "base" is the layout they render inside
+var defaultPartials = []*domain.EmailPartial{
+	{
+		Name: "footer",
+		HTMLBody: `<div class="footer">
+            <p>Best regards,<br>{{.app_name}} Team</p>
+        </div>`,
+		TextBody: `Best regards,
+{{.app_name}} Team`,
+	},
+	{
+		Name: "base",
 		HTMLBody: `<!DOCTYPE html>
 <html>
 <head>
     <meta charset="utf-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Verify your email</title>
+    <title>{{block "title" .}}{{.app_name}}{{end}}</title>
     <style>
         body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
         .container { max-width: 600px; margin: 0 auto; padding: 20px; }
         .header { text-align: center; margin-bottom: 30px; }
-        .button { display: inline-block; padding: 12px 24px; background-color: #007bff; color: white; 
+        .button { display: inline-block; padding: 12px 24px; background-color: #007bff; color: white;
                   text-decoration: none; border-radius: 4px; margin: 20px 0; }
+        .button-danger { background-color: #dc3545; }
         .footer { margin-top: 30px; font-size: 12px; color: #666; }
     </style>
 </head>
 <body>
     <div class="container">
-        <div class="header">
+        {{template "content" .}}
+        {{template "footer" .}}
+    </div>
+</body>
+</html>`,
+		TextBody: `{{template "content" .}}
+
+{{template "footer" .}}`,
+	},
+}
+
+// registerDefaultTemplates registers built-in partials and templates
+func (e *DefaultTemplateEngine) registerDefaultTemplates() error {
+	for _, partial := range defaultPartials {
+		if err := e.RegisterPartial(partial); err != nil {
+			return fmt.Errorf("failed to register %s partial: %w", partial.Name, err)
+		}
+	}
+
+	// Email verification template
+	if err := e.RegisterTemplate(&domain.EmailTemplate{
+		ID:        "email_verification",
+		Name:      "Email Verification",
+		Subject:   "Verify your email address",
+		Variables: []string{"user_name", "verification_url", "app_name"},
+		Layout:    "base",
+		HTMLBody: `{{define "title"}}Verify your email{{end}}<div class="header">
             <h1>Verify your email address</h1>
         </div>
         <p>Hi {{.user_name | default "there"}},</p>
@@ -279,23 +530,14 @@ func (e *DefaultTemplateEngine) registerDefaultTemplates() error {
         </p>
         <p>If the button doesn't work, you can copy and paste this link into your browser:</p>
         <p><a href="{{.verification_url}}">{{.verification_url}}</a></p>
-        <p>If you didn't create an account, you can safely ignore this email.</p>
-        <div class="footer">
-            <p>Best regards,<br>{{.app_name}} Team</p>
-        </div>
-    </div>
-</body>
-</html>`,
+        <p>If you didn't create an account, you can safely ignore this email.</p>`,
 		TextBody: `Hi {{.user_name | default "there"}},
 
 Thank you for creating an account! Please verify your email address by clicking the link below:
 
 {{.verification_url}}
 
-If you didn't create an account, you can safely ignore this email.
-
-Best regards,
-{{.app_name}} Team`,
+If you didn't create an account, you can safely ignore this email.`,
 	}); err != nil {
 		return fmt.Errorf("failed to register email verification template: %w", err)
 	}
@@ -306,43 +548,19 @@ Best regards,
 		Name:      "Password Reset",
 		Subject:   "Reset your password",
 		Variables: []string{"user_name", "reset_url", "app_name"},
-		HTMLBody: `<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="utf-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Reset your password</title>
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { text-align: center; margin-bottom: 30px; }
-        .button { 
-            display: inline-block; padding: 12px 24px; background-color: #dc3545; 
-            color: white; text-decoration: none; border-radius: 4px; margin: 20px 0; 
-        }
-        .footer { margin-top: 30px; font-size: 12px; color: #666; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
+		Layout:    "base",
+		HTMLBody: `{{define "title"}}Reset your password{{end}}<div class="header">
             <h1>Reset your password</h1>
         </div>
         <p>Hi {{.user_name | default "there"}},</p>
         <p>You requested to reset your password. Click the button below to reset it:</p>
         <p style="text-align: center;">
-            <a href="{{.reset_url}}" class="button">Reset Password</a>
+            <a href="{{.reset_url}}" class="button button-danger">Reset Password</a>
         </p>
         <p>If the button doesn't work, you can copy and paste this link into your browser:</p>
         <p><a href="{{.reset_url}}">{{.reset_url}}</a></p>
         <p><strong>This link will expire in 24 hours.</strong></p>
-        <p>If you didn't request this password reset, you can safely ignore this email.</p>
-        <div class="footer">
-            <p>Best regards,<br>{{.app_name}} Team</p>
-        </div>
-    </div>
-</body>
-</html>`,
+        <p>If you didn't request this password reset, you can safely ignore this email.</p>`,
 		TextBody: `Hi {{.user_name | default "there"}},
 
 You requested to reset your password. Click the link below to reset it:
@@ -351,10 +569,7 @@ You requested to reset your password. Click the link below to reset it:
 
 This link will expire in 24 hours.
 
-If you didn't request this password reset, you can safely ignore this email.
-
-Best regards,
-{{.app_name}} Team`,
+If you didn't request this password reset, you can safely ignore this email.`,
 	}); err != nil {
 		return fmt.Errorf("failed to register password reset template: %w", err)
 	}
@@ -365,49 +580,45 @@ Best regards,
 		Name:      "Welcome Email",
 		Subject:   "Welcome to {{.app_name}}!",
 		Variables: []string{"user_name", "app_name"},
-		HTMLBody: `<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="utf-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Welcome!</title>
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { text-align: center; margin-bottom: 30px; }
-        .footer { margin-top: 30px; font-size: 12px; color: #666; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
+		Layout:    "base",
+		HTMLBody: `{{define "title"}}Welcome!{{end}}<div class="header">
             <h1>Welcome to {{.app_name}}!</h1>
         </div>
         <p>Hi {{.user_name | default "there"}},</p>
         <p>Welcome to {{.app_name}}! Your account has been successfully created and verified.</p>
-        <p>You can now access all the features of our platform. If you have any questions, 
+        <p>You can now access all the features of our platform. If you have any questions,
         feel free to reach out to our support team.</p>
-        <p>Thank you for joining us!</p>
-        <div class="footer">
-            <p>Best regards,<br>{{.app_name}} Team</p>
-        </div>
-    </div>
-</body>
-</html>`,
+        <p>Thank you for joining us!</p>`,
 		TextBody: `Hi {{.user_name | default "there"}},
 
 Welcome to {{.app_name}}! Your account has been successfully created and verified.
 
-You can now access all the features of our platform. If you have any questions, 
+You can now access all the features of our platform. If you have any questions,
 feel free to reach out to our support team.
 
-Thank you for joining us!
-
-Best regards,
-{{.app_name}} Team`,
+Thank you for joining us!`,
 	}); err != nil {
 		return fmt.Errorf("failed to register welcome template: %w", err)
 	}
 
+	// Announcement template used for admin broadcasts
+	if err := e.RegisterTemplate(&domain.EmailTemplate{
+		ID:        "announcement",
+		Name:      "Announcement",
+		Subject:   "{{.subject}}",
+		Variables: []string{"user_name", "app_name", "subject", "message"},
+		Layout:    "base",
+		HTMLBody: `{{define "title"}}{{.subject}}{{end}}<div class="header">
+            <h1>{{.subject}}</h1>
+        </div>
+        <p>Hi {{.user_name | default "there"}},</p>
+        <p>{{.message}}</p>`,
+		TextBody: `Hi {{.user_name | default "there"}},
+
+{{.message}}`,
+	}); err != nil {
+		return fmt.Errorf("failed to register announcement template: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,276 @@
+package templates
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/email/domain"
+)
+
+func newTestEngine() *DefaultTemplateEngine {
+	return NewDefaultTemplateEngine(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestDefaultTemplatesRegistered(t *testing.T) {
+	engine := newTestEngine()
+
+	for _, id := range []string{"email_verification", "password_reset", "welcome", "announcement"} {
+		rendered, err := engine.Render(id, map[string]interface{}{
+			"user_name":        "Ada",
+			"app_name":         "Acme",
+			"verification_url": "https://acme.test/verify",
+			"reset_url":        "https://acme.test/reset",
+			"subject":          "News",
+			"message":          "Hello",
+		})
+		if !assert.NoError(t, err, id) {
+			continue
+		}
+		assert.Contains(t, rendered.HTMLBody, "<!DOCTYPE html>", id)
+		assert.Contains(t, rendered.HTMLBody, "Best regards,<br>Acme Team", id)
+		assert.True(t, strings.HasSuffix(rendered.TextBody, "Best regards,\nAcme Team"), id)
+	}
+}
+
+func TestRenderTemplateWithSharedFooter(t *testing.T) {
+	engine := newTestEngine()
+
+	err := engine.RegisterTemplate(&domain.EmailTemplate{
+		ID:       "receipt",
+		Name:     "Receipt",
+		Subject:  "Your receipt",
+		HTMLBody: `<p>Thanks for your order.</p>{{template "footer" .}}`,
+		TextBody: "Thanks for your order.\n{{template \"footer\" .}}",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rendered, err := engine.Render("receipt", map[string]interface{}{"app_name": "<Acme>"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(rendered.HTMLBody, "<p>Thanks for your order.</p><div class=\"footer\">"))
+	assert.Contains(t, rendered.HTMLBody, "&lt;Acme&gt; Team", "variables in partials are escaped")
+	assert.Equal(t, "Thanks for your order.\nBest regards,\n<Acme> Team", rendered.TextBody)
+}
+
+func TestRenderTemplateInsideLayout(t *testing.T) {
+	engine := newTestEngine()
+
+	err := engine.RegisterTemplate(&domain.EmailTemplate{
+		ID:       "digest",
+		Name:     "Digest",
+		Subject:  "Your digest",
+		Layout:   "base",
+		HTMLBody: `{{define "title"}}Weekly digest{{end}}<p>{{.summary}}</p>`,
+		TextBody: `{{.summary}}`,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rendered, err := engine.Render("digest", map[string]interface{}{"app_name": "Acme", "summary": "3 new items"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, rendered.HTMLBody, "<title>Weekly digest</title>")
+	assert.Contains(t, rendered.HTMLBody, "<p>3 new items</p>")
+	assert.Contains(t, rendered.HTMLBody, "Best regards,<br>Acme Team")
+	assert.Equal(t, "3 new items\n\nBest regards,\nAcme Team", rendered.TextBody)
+}
+
+func TestStandaloneTemplateRendersUnchanged(t *testing.T) {
+	engine := newTestEngine()
+
+	err := engine.RegisterTemplate(&domain.EmailTemplate{
+		ID:       "plain",
+		Name:     "Plain",
+		Subject:  "Hi {{.name}}",
+		HTMLBody: `<html><body><p>Hi {{.name | default "there"}}</p></body></html>`,
+		TextBody: `Hi {{.name | default "there"}}`,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rendered, err := engine.Render("plain", map[string]interface{}{"name": "Ada"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "Hi Ada", rendered.Subject)
+	assert.Equal(t, "<html><body><p>Hi Ada</p></body></html>", rendered.HTMLBody)
+	assert.Equal(t, "Hi Ada", rendered.TextBody)
+}
+
+func TestRegisterTemplateRejectsMissingPartials(t *testing.T) {
+	engine := newTestEngine()
+
+	tests := []struct {
+		name     string
+		template *domain.EmailTemplate
+		message  string
+	}{
+		{
+			name:     "unknown partial",
+			template: &domain.EmailTemplate{ID: "a", Name: "A", Subject: "A", HTMLBody: `{{template "signature" .}}`},
+			message:  "signature",
+		},
+		{
+			name:     "unknown layout",
+			template: &domain.EmailTemplate{ID: "b", Name: "B", Subject: "B", Layout: "fancy", HTMLBody: "<p>Hi</p>"},
+			message:  "fancy",
+		},
+		{
+			name:     "partial without a text body",
+			template: &domain.EmailTemplate{ID: "c", Name: "C", Subject: "C", TextBody: `{{template "banner" .}}`},
+			message:  "banner",
+		},
+		{
+			name:     "content outside a layout",
+			template: &domain.EmailTemplate{ID: "d", Name: "D", Subject: "D", HTMLBody: `{{template "content" .}}`},
+			message:  "content",
+		},
+	}
+
+	assert.NoError(t, engine.RegisterPartial(&domain.EmailPartial{Name: "banner", HTMLBody: "<div>Sale</div>"}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.RegisterTemplate(tt.template)
+			assert.ErrorIs(t, err, domain.ErrTemplateInvalid)
+			if err != nil {
+				assert.Contains(t, err.Error(), tt.message)
+			}
+			_, err = engine.GetTemplate(tt.template.ID)
+			assert.ErrorIs(t, err, domain.ErrTemplateNotFound)
+		})
+	}
+}
+
+func TestRegisterPartial(t *testing.T) {
+	engine := newTestEngine()
+
+	assert.ErrorIs(t, engine.RegisterPartial(&domain.EmailPartial{HTMLBody: "<p>x</p>"}), domain.ErrTemplateInvalid)
+	assert.ErrorIs(t, engine.RegisterPartial(&domain.EmailPartial{Name: "empty"}), domain.ErrTemplateInvalid)
+	assert.ErrorIs(t, engine.RegisterPartial(&domain.EmailPartial{Name: "content", HTMLBody: "<p>x</p>"}), domain.ErrTemplateInvalid)
+	assert.ErrorIs(t, engine.RegisterPartial(&domain.EmailPartial{Name: "broken", HTMLBody: "{{.x"}), domain.ErrTemplateInvalid)
+	assert.ErrorIs(t, engine.RegisterPartial(&domain.EmailPartial{Name: "outer", HTMLBody: `{{template "inner" .}}`}), domain.ErrTemplateInvalid)
+
+	// Layouts may include the wrapped body, and partials may include partials registered before them
+	assert.NoError(t, engine.RegisterPartial(&domain.EmailPartial{Name: "inner", HTMLBody: "<b>inner</b>"}))
+	assert.NoError(t, engine.RegisterPartial(&domain.EmailPartial{
+		Name:     "outer",
+		HTMLBody: `<main>{{template "content" .}}</main>{{template "inner" .}}`,
+	}))
+	assert.NoError(t, engine.RegisterTemplate(&domain.EmailTemplate{
+		ID: "nested", Name: "Nested", Subject: "Nested", Layout: "outer", HTMLBody: "<p>body</p>",
+	}))
+
+	rendered, err := engine.Render("nested", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "<main><p>body</p></main><b>inner</b>", rendered.HTMLBody)
+	}
+}
+
+func TestCriticalTemplateFallsBack(t *testing.T) {
+	variables := map[string]interface{}{
+		"user_name": "Ada",
+		"app_name":  "Acme",
+		"reset_url": "https://acme.test/reset",
+	}
+	// brokenReset stands in for a template edited so that it parses but no longer renders
+	brokenReset := &domain.EmailTemplate{
+		ID:        "password_reset",
+		Name:      "Password Reset",
+		Subject:   "Reset your password",
+		Variables: []string{"user_name", "reset_url"},
+		HTMLBody:  `<p>Hi {{.user_name.first}}, <a href="{{.reset_url}}">reset</a></p>`,
+		TextBody:  `Hi {{.user_name.first}}, {{.reset_url}}`,
+	}
+
+	t.Run("not critical", func(t *testing.T) {
+		engine := newTestEngine()
+		if !assert.NoError(t, engine.RegisterTemplate(brokenReset)) {
+			return
+		}
+
+		_, err := engine.Render("password_reset", variables)
+		assert.Error(t, err)
+	})
+
+	t.Run("critical", func(t *testing.T) {
+		engine := newTestEngine()
+		if !assert.NoError(t, engine.SetCriticalTemplates([]string{"password_reset"})) {
+			return
+		}
+		if !assert.NoError(t, engine.RegisterTemplate(brokenReset)) {
+			return
+		}
+
+		rendered, err := engine.Render("password_reset", variables)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, rendered.Fallback)
+		assert.Equal(t, "Reset your password", rendered.Subject)
+		assert.Contains(t, rendered.HTMLBody, `<a href="https://acme.test/reset">`)
+		assert.Contains(t, rendered.TextBody, "Hi Ada,")
+		assert.Contains(t, rendered.TextBody, "https://acme.test/reset")
+	})
+
+	t.Run("critical template needing a new variable", func(t *testing.T) {
+		engine := newTestEngine()
+		if !assert.NoError(t, engine.SetCriticalTemplates([]string{"password_reset"})) {
+			return
+		}
+		edited := *brokenReset
+		edited.Variables = []string{"reset_url", "support_phone"}
+		edited.HTMLBody = `<p>Call {{.support_phone}} or <a href="{{.reset_url}}">reset</a></p>`
+		edited.TextBody = ""
+		if !assert.NoError(t, engine.RegisterTemplate(&edited)) {
+			return
+		}
+
+		rendered, err := engine.Render("password_reset", variables)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, rendered.Fallback)
+	})
+
+	t.Run("working template is not replaced", func(t *testing.T) {
+		engine := newTestEngine()
+		if !assert.NoError(t, engine.SetCriticalTemplates([]string{"password_reset"})) {
+			return
+		}
+
+		rendered, err := engine.Render("password_reset", variables)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.False(t, rendered.Fallback)
+		assert.Contains(t, rendered.HTMLBody, "<!DOCTYPE html>")
+	})
+
+	t.Run("fallback still needs its own variables", func(t *testing.T) {
+		engine := newTestEngine()
+		if !assert.NoError(t, engine.SetCriticalTemplates([]string{"password_reset"})) {
+			return
+		}
+
+		_, err := engine.Render("password_reset", map[string]interface{}{"user_name": "Ada"})
+		assert.ErrorIs(t, err, domain.ErrTemplateMissingVariables)
+	})
+}
+
+func TestSetCriticalTemplatesNeedsFallback(t *testing.T) {
+	engine := newTestEngine()
+
+	assert.NoError(t, engine.SetCriticalTemplates([]string{"email_verification", "password_reset", "welcome"}))
+	assert.ErrorIs(t, engine.SetCriticalTemplates([]string{"announcement"}), domain.ErrTemplateInvalid)
+}
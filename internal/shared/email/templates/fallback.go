@@ -0,0 +1,54 @@
+package templates
+
+import "github.com/acheevo/tfa/internal/shared/email/domain"
+
+// fallbackTemplates are minimal versions of the built-in templates that flows such as password reset
+// depend on. They use no layout or partials, so a broken partial can't break them too, and only need
+// the variables the flow can't do without. A critical template that fails to render is sent as its
+// fallback instead.
+var fallbackTemplates = []*domain.EmailTemplate{
+	{
+		ID:        "email_verification",
+		Name:      "Email Verification (fallback)",
+		Subject:   "Verify your email address",
+		Variables: []string{"verification_url"},
+		HTMLBody: `<p>Hi {{.user_name | default "there"}},</p>
+<p>Please verify your email address by opening this link:</p>
+<p><a href="{{.verification_url}}">{{.verification_url}}</a></p>
+<p>If you didn't create an account, you can safely ignore this email.</p>`,
+		TextBody: `Hi {{.user_name | default "there"}},
+
+Please verify your email address by opening this link:
+
+{{.verification_url}}
+
+If you didn't create an account, you can safely ignore this email.`,
+	},
+	{
+		ID:        "password_reset",
+		Name:      "Password Reset (fallback)",
+		Subject:   "Reset your password",
+		Variables: []string{"reset_url"},
+		HTMLBody: `<p>Hi {{.user_name | default "there"}},</p>
+<p>You requested to reset your password. Open this link to reset it:</p>
+<p><a href="{{.reset_url}}">{{.reset_url}}</a></p>
+<p>This link will expire in 24 hours. If you didn't request this password reset, you can safely ignore this email.</p>`,
+		TextBody: `Hi {{.user_name | default "there"}},
+
+You requested to reset your password. Open this link to reset it:
+
+{{.reset_url}}
+
+This link will expire in 24 hours. If you didn't request this password reset, you can safely ignore this email.`,
+	},
+	{
+		ID:      "welcome",
+		Name:    "Welcome Email (fallback)",
+		Subject: "Welcome!",
+		HTMLBody: `<p>Hi {{.user_name | default "there"}},</p>
+<p>Your account has been created and verified. Thank you for joining us!</p>`,
+		TextBody: `Hi {{.user_name | default "there"}},
+
+Your account has been created and verified. Thank you for joining us!`,
+	},
+}
@@ -20,6 +20,7 @@ type ErrorHandler struct {
 	logger      *slog.Logger
 	environment string
 	mapper      *ErrorMapper
+	catalog     *MessageCatalog
 }
 
 // NewErrorHandler creates a new error handler
@@ -28,6 +29,7 @@ func NewErrorHandler(logger *slog.Logger, environment string) *ErrorHandler {
 		logger:      logger,
 		environment: environment,
 		mapper:      defaultErrorMapper,
+		catalog:     NewMessageCatalog(),
 	}
 }
 
@@ -49,7 +51,7 @@ func (h *ErrorHandler) HandleError(c *gin.Context, err error) {
 	h.logError(c, appErr)
 
 	// Create response
-	response := h.createErrorResponse(appErr)
+	response := h.createErrorResponse(c, appErr)
 
 	// Send HTTP response
 	c.JSON(appErr.HTTPStatus, response)
@@ -102,8 +104,9 @@ func (h *ErrorHandler) toAppError(err error) *AppError {
 	}
 }
 
-// createErrorResponse creates an ErrorResponse from an AppError
-func (h *ErrorHandler) createErrorResponse(appErr *AppError) *ErrorResponse {
+// createErrorResponse creates an ErrorResponse from an AppError, localizing the message to the
+// request's language. The code stays the same in every language so clients can rely on it.
+func (h *ErrorHandler) createErrorResponse(c *gin.Context, appErr *AppError) *ErrorResponse {
 	response := &ErrorResponse{
 		Error:     appErr.Code.String(),
 		Code:      appErr.Code,
@@ -126,6 +129,8 @@ func (h *ErrorHandler) createErrorResponse(appErr *AppError) *ErrorResponse {
 		}
 	}
 
+	response.Message = h.catalog.localize(c, appErr.Code, response.Message)
+
 	return response
 }
 
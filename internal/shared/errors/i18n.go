@@ -0,0 +1,146 @@
+package errors
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// LanguageContextKey is the Gin context key holding the user's preferred language, if known.
+// It takes precedence over the Accept-Language header.
+const LanguageContextKey = "language"
+
+// MessageCatalog holds user-facing error messages per language, keyed by error code.
+// English falls back to the error mapper's default messages.
+type MessageCatalog struct {
+	messages map[language.Tag]map[ErrorCode]string
+	tags     []language.Tag
+	matcher  language.Matcher
+}
+
+// NewMessageCatalog creates a catalog with the built-in translations
+func NewMessageCatalog() *MessageCatalog {
+	catalog := &MessageCatalog{messages: make(map[language.Tag]map[ErrorCode]string)}
+	catalog.Register(language.English, nil)
+	catalog.Register(language.Spanish, spanishMessages)
+	catalog.Register(language.French, frenchMessages)
+	return catalog
+}
+
+// Register adds or replaces the messages for a language. The first language registered is
+// the fallback for requests that match none of them.
+func (mc *MessageCatalog) Register(tag language.Tag, messages map[ErrorCode]string) {
+	if _, exists := mc.messages[tag]; !exists {
+		mc.tags = append(mc.tags, tag)
+		mc.matcher = language.NewMatcher(mc.tags)
+	}
+	mc.messages[tag] = messages
+}
+
+// Negotiate picks the best supported language for a preference and an Accept-Language header
+func (mc *MessageCatalog) Negotiate(preferred, acceptLanguage string) language.Tag {
+	var desired []language.Tag
+	if preferred != "" {
+		if tag, err := language.Parse(preferred); err == nil {
+			desired = append(desired, tag)
+		}
+	}
+	if tags, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil {
+		desired = append(desired, tags...)
+	}
+
+	_, index, confidence := mc.matcher.Match(desired...)
+	if confidence == language.No {
+		return mc.tags[0]
+	}
+	return mc.tags[index]
+}
+
+// Message returns the message for code in lang, or false if it has no translation
+func (mc *MessageCatalog) Message(code ErrorCode, lang language.Tag) (string, bool) {
+	message, ok := mc.messages[lang][code]
+	return message, ok
+}
+
+// requestLanguage negotiates the response language for a request
+func (mc *MessageCatalog) requestLanguage(c *gin.Context) language.Tag {
+	return mc.Negotiate(c.GetString(LanguageContextKey), c.GetHeader("Accept-Language"))
+}
+
+// localize returns the message for code in the request's language, or fallback in English when it
+// has no translation, and names the language used in the Content-Language header
+func (mc *MessageCatalog) localize(c *gin.Context, code ErrorCode, fallback string) string {
+	lang := mc.requestLanguage(c)
+	message, ok := mc.Message(code, lang)
+	if !ok {
+		lang = language.English
+		message = fallback
+	}
+	c.Header("Content-Language", lang.String())
+	return message
+}
+
+// defaultCatalog localizes requests that don't pass through ErrorMiddleware
+var defaultCatalog = NewMessageCatalog()
+
+// LocalizedMessage returns the message for code in the request's language, falling back to the code's
+// English default, for handlers that write their own error responses
+func LocalizedMessage(c *gin.Context, code ErrorCode) string {
+	catalog := defaultCatalog
+	if handler, exists := c.Get("error_handler"); exists {
+		if h, ok := handler.(*ErrorHandler); ok {
+			catalog = h.catalog
+		}
+	}
+
+	fallback := "An error occurred"
+	if mapping, exists := defaultErrorMapper.GetMapping(code); exists {
+		fallback = mapping.DefaultMessage
+	}
+	return catalog.localize(c, code, fallback)
+}
+
+var spanishMessages = map[ErrorCode]string{
+	CodeBadRequest:         "Solicitud incorrecta",
+	CodeUnauthorized:       "Se requiere autenticación",
+	CodeForbidden:          "Acceso prohibido",
+	CodeNotFound:           "Recurso no encontrado",
+	CodeConflict:           "Conflicto con el recurso",
+	CodeValidationFailed:   "La validación ha fallado",
+	CodeRateLimitExceeded:  "Demasiadas solicitudes, inténtalo más tarde",
+	CodeRequestTooLarge:    "La solicitud es demasiado grande",
+	CodeInvalidCredentials: "Correo electrónico o contraseña incorrectos",
+	CodeTokenExpired:       "La sesión ha caducado",
+	CodeTokenInvalid:       "Token no válido",
+	CodeEmailNotVerified:   "El correo electrónico no está verificado",
+	CodeAccountLocked:      "La cuenta está bloqueada temporalmente",
+	CodePermissionDenied:   "Permiso denegado",
+	CodeUserNotFound:       "Usuario no encontrado",
+	CodeUserAlreadyExists:  "El usuario ya existe",
+	CodeEmailAlreadyExists: "El correo electrónico ya está registrado",
+	CodeInternalError:      "Error interno del servidor",
+	CodeServiceUnavailable: "Servicio no disponible",
+	CodeTimeoutError:       "Tiempo de espera agotado",
+}
+
+var frenchMessages = map[ErrorCode]string{
+	CodeBadRequest:         "Requête invalide",
+	CodeUnauthorized:       "Authentification requise",
+	CodeForbidden:          "Accès interdit",
+	CodeNotFound:           "Ressource introuvable",
+	CodeConflict:           "Conflit de ressource",
+	CodeValidationFailed:   "La validation a échoué",
+	CodeRateLimitExceeded:  "Trop de requêtes, veuillez réessayer plus tard",
+	CodeRequestTooLarge:    "Requête trop volumineuse",
+	CodeInvalidCredentials: "Adresse e-mail ou mot de passe incorrect",
+	CodeTokenExpired:       "La session a expiré",
+	CodeTokenInvalid:       "Jeton invalide",
+	CodeEmailNotVerified:   "Adresse e-mail non vérifiée",
+	CodeAccountLocked:      "Le compte est temporairement verrouillé",
+	CodePermissionDenied:   "Permission refusée",
+	CodeUserNotFound:       "Utilisateur introuvable",
+	CodeUserAlreadyExists:  "L'utilisateur existe déjà",
+	CodeEmailAlreadyExists: "Adresse e-mail déjà utilisée",
+	CodeInternalError:      "Erreur interne du serveur",
+	CodeServiceUnavailable: "Service indisponible",
+	CodeTimeoutError:       "Délai d'attente dépassé",
+}
@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestMessageCatalogNegotiate(t *testing.T) {
+	catalog := NewMessageCatalog()
+
+	assert.Equal(t, language.Spanish, catalog.Negotiate("", "es-MX,es;q=0.9,en;q=0.8"))
+	assert.Equal(t, language.French, catalog.Negotiate("", "de-DE,fr;q=0.7"))
+	assert.Equal(t, language.English, catalog.Negotiate("", "de-DE"))
+	assert.Equal(t, language.English, catalog.Negotiate("", ""))
+	assert.Equal(t, language.English, catalog.Negotiate("", "not a language;;"))
+
+	// The user's saved preference wins over the browser header
+	assert.Equal(t, language.French, catalog.Negotiate("fr", "es"))
+}
+
+func TestErrorHandlerLocalizesMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewErrorHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), "production")
+
+	respond := func(acceptLanguage string) (*httptest.ResponseRecorder, ErrorResponse) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+		c.Request.Header.Set("Accept-Language", acceptLanguage)
+
+		handler.HandleError(c, New(CodeInvalidCredentials, ""))
+
+		var response ErrorResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	w, response := respond("fr-CA")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, CodeInvalidCredentials, response.Code)
+	assert.Equal(t, "Adresse e-mail ou mot de passe incorrect", response.Message)
+	assert.Equal(t, "fr", w.Header().Get("Content-Language"))
+
+	// Unsupported languages fall back to English
+	w, response = respond("ja")
+	assert.Equal(t, CodeInvalidCredentials, response.Code)
+	assert.Equal(t, "Invalid credentials", response.Message)
+	assert.Equal(t, "en", w.Header().Get("Content-Language"))
+
+	// Codes without a translation stay in English too
+	w = httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Accept-Language", "es")
+	handler.HandleError(c, New(CodeTemplateNotFound, ""))
+	assert.Equal(t, "en", w.Header().Get("Content-Language"))
+}
+
+func TestLocalizedMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/change-password", nil)
+	c.Request.Header.Set("Accept-Language", "fr")
+	c.Set(LanguageContextKey, "es")
+
+	// Handlers answering errors themselves get the same messages, the user's preference first
+	assert.Equal(t, "Correo electrónico o contraseña incorrectos", LocalizedMessage(c, CodeInvalidCredentials))
+	assert.Equal(t, "es", w.Header().Get("Content-Language"))
+
+	assert.Equal(t, "Email template not found", LocalizedMessage(c, CodeTemplateNotFound))
+	assert.Equal(t, "en", w.Header().Get("Content-Language"))
+}
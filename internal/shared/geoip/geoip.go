@@ -0,0 +1,38 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// ErrNotFound is returned by a Lookup that has no location for an address
+var ErrNotFound = errors.New("no location for ip address")
+
+// Location is the approximate place an IP address is registered to. It is only ever used for
+// display and must not be relied on for access decisions.
+type Location struct {
+	City        string `json:"city,omitempty"`
+	Region      string `json:"region,omitempty"`
+	Country     string `json:"country,omitempty"`
+	CountryCode string `json:"country_code,omitempty"`
+}
+
+// Lookup resolves a single IP address to a location, e.g. from a MaxMind database or a web service
+type Lookup interface {
+	Lookup(ctx context.Context, ip string) (*Location, error)
+}
+
+// IsPublic reports whether ip is a valid address worth looking up. Private, loopback, link-local
+// and unspecified addresses have no meaningful location.
+func IsPublic(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	return !parsed.IsPrivate() &&
+		!parsed.IsLoopback() &&
+		!parsed.IsLinkLocalUnicast() &&
+		!parsed.IsLinkLocalMulticast() &&
+		!parsed.IsUnspecified()
+}
@@ -0,0 +1,98 @@
+package geoip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/httpclient"
+)
+
+// maxResponseBytes bounds how much of a lookup response is read
+const maxResponseBytes = 64 << 10
+
+// HTTPLookup resolves addresses with a JSON web service configured by GEOIP_SERVICE_URL
+type HTTPLookup struct {
+	urlTemplate string
+	client      *httpclient.Client
+}
+
+// serviceResponse accepts the field names used by the common free GeoIP services
+// (ipapi.co style snake_case and ip-api.com style camelCase)
+type serviceResponse struct {
+	City           string `json:"city"`
+	Region         string `json:"region"`
+	RegionName     string `json:"regionName"`
+	Country        string `json:"country"`
+	CountryName    string `json:"country_name"`
+	CountryCode    string `json:"country_code"`
+	CountryCodeAlt string `json:"countryCode"`
+	Status         string `json:"status"`
+	Error          bool   `json:"error"`
+	Reserved       bool   `json:"reserved"`
+}
+
+// NewHTTPLookup creates a lookup against the configured service URL
+func NewHTTPLookup(cfg *config.Config, client *httpclient.Client) *HTTPLookup {
+	return &HTTPLookup{
+		urlTemplate: cfg.GeoIPServiceURL,
+		client:      client,
+	}
+}
+
+// Lookup fetches the location of ip from the service
+func (l *HTTPLookup) Lookup(ctx context.Context, ip string) (*Location, error) {
+	endpoint := strings.ReplaceAll(l.urlTemplate, "{ip}", url.PathEscape(ip))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build geoip request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("geoip request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geoip request: unexpected status %d", resp.StatusCode)
+	}
+
+	var body serviceResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode geoip response: %w", err)
+	}
+	if body.Error || body.Reserved || body.Status == "fail" {
+		return nil, ErrNotFound
+	}
+
+	location := &Location{
+		City:        body.City,
+		Region:      firstNonEmpty(body.RegionName, body.Region),
+		Country:     firstNonEmpty(body.CountryName, body.Country),
+		CountryCode: strings.ToUpper(firstNonEmpty(body.CountryCode, body.CountryCodeAlt)),
+	}
+	if location.Country == "" && location.CountryCode == "" {
+		return nil, ErrNotFound
+	}
+	return location, nil
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package geoip
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/httpclient"
+)
+
+func newTestHTTPLookup(url string) *HTTPLookup {
+	cfg := &config.Config{GeoIPServiceURL: url, HTTPClientTimeout: "1s"}
+	return NewHTTPLookup(cfg, httpclient.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), "geoip", nil))
+}
+
+func TestHTTPLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/8.8.8.8/json":
+			_, _ = w.Write([]byte(`{"city":"Mountain View","region":"California","country_name":"United States","country_code":"us"}`))
+		case "/1.1.1.1/json":
+			_, _ = w.Write([]byte(`{"status":"success","city":"Sydney","regionName":"NSW","country":"Australia","countryCode":"AU"}`))
+		case "/2.2.2.2/json":
+			_, _ = w.Write([]byte(`{"status":"fail","message":"reserved range"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	lookup := newTestHTTPLookup(server.URL + "/{ip}/json")
+
+	location, err := lookup.Lookup(context.Background(), "8.8.8.8")
+	assert.NoError(t, err)
+	assert.Equal(t, &Location{City: "Mountain View", Region: "California", Country: "United States", CountryCode: "US"}, location)
+
+	location, err = lookup.Lookup(context.Background(), "1.1.1.1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Location{City: "Sydney", Region: "NSW", Country: "Australia", CountryCode: "AU"}, location)
+
+	_, err = lookup.Lookup(context.Background(), "2.2.2.2")
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = lookup.Lookup(context.Background(), "3.3.3.3")
+	assert.Error(t, err)
+}
@@ -0,0 +1,123 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+const (
+	// lookupTimeout bounds a single background lookup
+	lookupTimeout = 5 * time.Second
+	// maxPending bounds concurrent background lookups; addresses over the limit are retried on a later view
+	maxPending = 32
+	// failureTTL is how long a failed lookup is remembered before it is retried
+	failureTTL = 10 * time.Minute
+)
+
+// Resolver enriches IP addresses with cached locations without ever blocking the caller. A cache
+// miss returns nil and starts a background lookup, so the location appears on a later request.
+// A nil Resolver is valid and never returns a location.
+type Resolver struct {
+	lookup     Lookup
+	ttl        time.Duration
+	maxEntries int
+	clock      clock.Clock
+	logger     *slog.Logger
+
+	mu      sync.Mutex
+	cache   map[string]cacheEntry
+	pending map[string]bool
+}
+
+// cacheEntry is a cached lookup result; a nil location records a miss or failure
+type cacheEntry struct {
+	location  *Location
+	expiresAt time.Time
+}
+
+// NewResolver creates a resolver around lookup, cached per GEOIP_CACHE_TTL and GEOIP_CACHE_SIZE
+func NewResolver(cfg *config.Config, logger *slog.Logger, lookup Lookup, clk clock.Clock) *Resolver {
+	maxEntries := cfg.GeoIPCacheSize
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+
+	return &Resolver{
+		lookup:     lookup,
+		ttl:        cfg.GeoIPCacheTTLDuration(),
+		maxEntries: maxEntries,
+		clock:      clk,
+		logger:     logger,
+		cache:      make(map[string]cacheEntry),
+		pending:    make(map[string]bool),
+	}
+}
+
+// Locate returns the cached location of ip, or nil if it isn't known yet, starting a background
+// lookup on a miss
+func (r *Resolver) Locate(ip string) *Location {
+	if r == nil || !IsPublic(ip) {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.cache[ip]; ok && r.clock.Now().Before(entry.expiresAt) {
+		return entry.location
+	}
+
+	if !r.pending[ip] && len(r.pending) < maxPending {
+		r.pending[ip] = true
+		go r.resolve(ip)
+	}
+	return nil
+}
+
+// resolve looks ip up and caches the result
+func (r *Resolver) resolve(ip string) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	location, err := r.lookup.Lookup(ctx, ip)
+	ttl := r.ttl
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			r.logger.Warn("geoip lookup failed", "ip", ip, "error", err)
+			ttl = min(ttl, failureTTL)
+		}
+		location = nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.pending, ip)
+	if len(r.cache) >= r.maxEntries {
+		r.evict()
+	}
+	r.cache[ip] = cacheEntry{location: location, expiresAt: r.clock.Now().Add(ttl)}
+}
+
+// evict makes room in the cache, dropping expired entries first and otherwise an arbitrary
+// one. The caller must hold the lock.
+func (r *Resolver) evict() {
+	now := r.clock.Now()
+	for ip, entry := range r.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(r.cache, ip)
+		}
+	}
+	for ip := range r.cache {
+		if len(r.cache) < r.maxEntries {
+			return
+		}
+		delete(r.cache, ip)
+	}
+}
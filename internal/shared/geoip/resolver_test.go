@@ -0,0 +1,124 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+// mockLookup returns fixed results and counts calls per address
+type mockLookup struct {
+	mu        sync.Mutex
+	calls     map[string]int
+	locations map[string]*Location
+	err       error
+	block     chan struct{}
+}
+
+func (m *mockLookup) Lookup(ctx context.Context, ip string) (*Location, error) {
+	if m.block != nil {
+		<-m.block
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls[ip]++
+	if m.err != nil {
+		return nil, m.err
+	}
+	if location, ok := m.locations[ip]; ok {
+		return location, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (m *mockLookup) count(ip string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[ip]
+}
+
+func newTestResolver(lookup Lookup, clk clock.Clock) *Resolver {
+	return NewResolver(
+		&config.Config{GeoIPCacheTTL: "1h", GeoIPCacheSize: 100},
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		lookup,
+		clk,
+	)
+}
+
+var berlin = &Location{City: "Berlin", Country: "Germany", CountryCode: "DE"}
+
+func TestResolverCachesInBackground(t *testing.T) {
+	lookup := &mockLookup{calls: map[string]int{}, locations: map[string]*Location{"8.8.8.8": berlin}}
+	clk := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	resolver := newTestResolver(lookup, clk)
+
+	// The first view never waits for the lookup
+	assert.Nil(t, resolver.Locate("8.8.8.8"))
+	assert.Eventually(t, func() bool { return resolver.Locate("8.8.8.8") != nil }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, berlin, resolver.Locate("8.8.8.8"))
+	assert.Equal(t, 1, lookup.count("8.8.8.8"))
+
+	// Entries expire after the TTL and are looked up again
+	clk.Advance(2 * time.Hour)
+	resolver.Locate("8.8.8.8")
+	assert.Eventually(t, func() bool { return lookup.count("8.8.8.8") == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestResolverNeverBlocks(t *testing.T) {
+	lookup := &mockLookup{calls: map[string]int{}, block: make(chan struct{})}
+	resolver := newTestResolver(lookup, clock.New())
+	defer close(lookup.block)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			resolver.Locate("1.1.1.1")
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Locate blocked on a slow lookup")
+	}
+}
+
+func TestResolverSkipsPrivateAddresses(t *testing.T) {
+	lookup := &mockLookup{calls: map[string]int{}}
+	resolver := newTestResolver(lookup, clock.New())
+
+	for _, ip := range []string{"10.0.0.1", "192.168.1.1", "127.0.0.1", "::1", "fe80::1", "not-an-ip", ""} {
+		assert.Nil(t, resolver.Locate(ip), ip)
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, lookup.calls)
+}
+
+func TestResolverRemembersFailures(t *testing.T) {
+	lookup := &mockLookup{calls: map[string]int{}, err: errors.New("service down")}
+	resolver := newTestResolver(lookup, clock.New())
+
+	resolver.Locate("9.9.9.9")
+	assert.Eventually(t, func() bool { return lookup.count("9.9.9.9") == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Nil(t, resolver.Locate("9.9.9.9"))
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 1, lookup.count("9.9.9.9"))
+}
+
+func TestNilResolver(t *testing.T) {
+	var resolver *Resolver
+	assert.Nil(t, resolver.Locate("8.8.8.8"))
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/acheevo/tfa/internal/shared/config"
 	"github.com/acheevo/tfa/internal/shared/email/domain"
+	"github.com/acheevo/tfa/internal/shared/httpclient"
 )
 
 // Status represents the health status
@@ -62,18 +63,24 @@ type HealthChecker interface {
 
 // EnhancedHealthService provides comprehensive health checking
 type EnhancedHealthService struct {
-	config   *config.Config
-	logger   *slog.Logger
-	checkers map[string]HealthChecker
-	mu       sync.RWMutex
+	config          *config.Config
+	logger          *slog.Logger
+	checkers        map[string]HealthChecker
+	checkerTimeouts map[string]time.Duration
+	defaultTimeout  time.Duration
+	overallTimeout  time.Duration
+	mu              sync.RWMutex
 }
 
 // NewEnhancedHealthService creates a new enhanced health service
 func NewEnhancedHealthService(config *config.Config, logger *slog.Logger) *EnhancedHealthService {
 	service := &EnhancedHealthService{
-		config:   config,
-		logger:   logger,
-		checkers: make(map[string]HealthChecker),
+		config:          config,
+		logger:          logger,
+		checkers:        make(map[string]HealthChecker),
+		checkerTimeouts: make(map[string]time.Duration),
+		defaultTimeout:  config.HealthCheckTimeoutDuration(),
+		overallTimeout:  config.HealthCheckOverallTimeoutDuration(),
 	}
 
 	return service
@@ -88,6 +95,16 @@ func (h *EnhancedHealthService) RegisterChecker(checker HealthChecker) {
 	h.logger.Info("Health checker registered", "name", checker.Name())
 }
 
+// RegisterCheckerWithTimeout registers a health checker with its own timeout instead of the default
+func (h *EnhancedHealthService) RegisterCheckerWithTimeout(checker HealthChecker, timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checkers[checker.Name()] = checker
+	h.checkerTimeouts[checker.Name()] = timeout
+	h.logger.Info("Health checker registered", "name", checker.Name(), "timeout", timeout)
+}
+
 // Check performs all health checks and returns a comprehensive report
 func (h *EnhancedHealthService) Check(ctx context.Context) *HealthReport {
 	start := time.Now()
@@ -99,6 +116,10 @@ func (h *EnhancedHealthService) Check(ctx context.Context) *HealthReport {
 	}
 	h.mu.RUnlock()
 
+	// Bound the whole report so a slow checker cannot hold the request open
+	ctx, cancel := context.WithTimeout(ctx, h.overallTimeout)
+	defer cancel()
+
 	// Perform checks concurrently
 	results := make(chan *CheckResult, len(checkers))
 	var wg sync.WaitGroup
@@ -107,8 +128,7 @@ func (h *EnhancedHealthService) Check(ctx context.Context) *HealthReport {
 		wg.Add(1)
 		go func(c HealthChecker) {
 			defer wg.Done()
-			result := c.Check(ctx)
-			results <- result
+			results <- h.runCheck(ctx, c)
 		}(checker)
 	}
 
@@ -176,7 +196,41 @@ func (h *EnhancedHealthService) CheckSingle(ctx context.Context, name string) *C
 		}
 	}
 
-	return checker.Check(ctx)
+	return h.runCheck(ctx, checker)
+}
+
+// runCheck runs a checker under its timeout, reporting it unhealthy if it does not finish in time
+func (h *EnhancedHealthService) runCheck(ctx context.Context, checker HealthChecker) *CheckResult {
+	h.mu.RLock()
+	timeout, exists := h.checkerTimeouts[checker.Name()]
+	h.mu.RUnlock()
+	if !exists {
+		timeout = h.defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan *CheckResult, 1)
+	go func() {
+		done <- checker.Check(ctx)
+	}()
+
+	select {
+	case result := <-done:
+		return result
+	case <-ctx.Done():
+		h.logger.Warn("Health check timed out", "name", checker.Name(), "timeout", timeout)
+		return &CheckResult{
+			Name:      checker.Name(),
+			Status:    StatusUnhealthy,
+			Message:   fmt.Sprintf("Health check timed out after %s", time.Since(start).Round(time.Millisecond)),
+			Duration:  time.Since(start),
+			Timestamp: time.Now(),
+			Error:     ctx.Err(),
+		}
+	}
 }
 
 // ListCheckers returns the names of all registered health checkers
@@ -292,13 +346,16 @@ func (d *DatabaseHealthChecker) Check(ctx context.Context) *CheckResult {
 type EmailHealthChecker struct {
 	name         string
 	emailService domain.EmailServiceInterface
+	maxPending   int
 }
 
-// NewEmailHealthChecker creates a new email health checker
-func NewEmailHealthChecker(name string, emailService domain.EmailServiceInterface) *EmailHealthChecker {
+// NewEmailHealthChecker creates a new email health checker. The check reports degraded once
+// maxPending emails are pending (EMAIL_QUEUE_ALERT_DEPTH); zero never does.
+func NewEmailHealthChecker(name string, emailService domain.EmailServiceInterface, maxPending int) *EmailHealthChecker {
 	return &EmailHealthChecker{
 		name:         name,
 		emailService: emailService,
+		maxPending:   maxPending,
 	}
 }
 
@@ -337,7 +394,7 @@ func (e *EmailHealthChecker) Check(ctx context.Context) *CheckResult {
 		result.Details["emails_failed"] = queueStats.Failed
 
 		// Check if queue is backing up
-		if queueStats.Pending > 1000 {
+		if e.maxPending > 0 && queueStats.Pending >= int64(e.maxPending) {
 			result.Status = StatusDegraded
 			result.Message = fmt.Sprintf("Email queue backing up: %d pending", queueStats.Pending)
 		} else {
@@ -389,6 +446,43 @@ func (e *ExternalServiceHealthChecker) Check(ctx context.Context) *CheckResult {
 	return result
 }
 
+// CircuitBreakerHealthChecker reports an outbound client as degraded while its circuit breaker is
+// not closed; the integration is failing fast, which does not make this instance unhealthy
+type CircuitBreakerHealthChecker struct {
+	client *httpclient.Client
+}
+
+// NewCircuitBreakerHealthChecker creates a checker named after the client
+func NewCircuitBreakerHealthChecker(client *httpclient.Client) *CircuitBreakerHealthChecker {
+	return &CircuitBreakerHealthChecker{
+		client: client,
+	}
+}
+
+// Name returns the checker name
+func (b *CircuitBreakerHealthChecker) Name() string {
+	return b.client.Name()
+}
+
+// Check reports the client's circuit breaker state
+func (b *CircuitBreakerHealthChecker) Check(ctx context.Context) *CheckResult {
+	state := b.client.BreakerState()
+	result := &CheckResult{
+		Name:      b.client.Name(),
+		Status:    StatusHealthy,
+		Message:   "Circuit closed",
+		Timestamp: time.Now(),
+		Details:   map[string]interface{}{"circuit": string(state)},
+	}
+
+	if state != httpclient.StateClosed {
+		result.Status = StatusDegraded
+		result.Message = fmt.Sprintf("Circuit %s", state)
+	}
+
+	return result
+}
+
 // MemoryHealthChecker checks memory usage
 type MemoryHealthChecker struct {
 	name string
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+// stubChecker reports healthy after an optional delay, ignoring context cancellation
+// to simulate a checker that hangs on a slow dependency
+type stubChecker struct {
+	name  string
+	delay time.Duration
+}
+
+func (s *stubChecker) Name() string {
+	return s.name
+}
+
+func (s *stubChecker) Check(ctx context.Context) *CheckResult {
+	time.Sleep(s.delay)
+	return &CheckResult{Name: s.name, Status: StatusHealthy, Timestamp: time.Now()}
+}
+
+func newTestHealthService(checkerTimeout, overallTimeout string) *EnhancedHealthService {
+	cfg := &config.Config{
+		HealthCheckTimeout:        checkerTimeout,
+		HealthCheckOverallTimeout: overallTimeout,
+	}
+	return NewEnhancedHealthService(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestCheckTimesOutSlowChecker(t *testing.T) {
+	service := newTestHealthService("50ms", "1s")
+	service.RegisterChecker(&stubChecker{name: "fast"})
+	service.RegisterChecker(&stubChecker{name: "slow", delay: 2 * time.Second})
+
+	start := time.Now()
+	report := service.Check(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusHealthy, report.Checks["fast"].Status)
+	assert.Equal(t, StatusUnhealthy, report.Checks["slow"].Status)
+	assert.Contains(t, report.Checks["slow"].Message, "timed out")
+	assert.Equal(t, StatusUnhealthy, report.Status)
+}
+
+func TestCheckerSpecificTimeout(t *testing.T) {
+	service := newTestHealthService("50ms", "1s")
+	service.RegisterCheckerWithTimeout(&stubChecker{name: "external", delay: 100 * time.Millisecond}, 500*time.Millisecond)
+
+	result := service.CheckSingle(context.Background(), "external")
+
+	assert.Equal(t, StatusHealthy, result.Status)
+}
+
+func TestCheckOverallDeadline(t *testing.T) {
+	service := newTestHealthService("5s", "100ms")
+	service.RegisterChecker(&stubChecker{name: "slow", delay: 2 * time.Second})
+
+	start := time.Now()
+	report := service.Check(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusUnhealthy, report.Checks["slow"].Status)
+}
@@ -0,0 +1,176 @@
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+)
+
+// State is where a circuit breaker is in its cycle
+type State string
+
+const (
+	// StateClosed lets every request through while counting failures
+	StateClosed State = "closed"
+	// StateOpen fails every request fast until the cooldown has passed
+	StateOpen State = "open"
+	// StateHalfOpen lets a single probe through to decide whether to close again
+	StateHalfOpen State = "half_open"
+)
+
+// ErrCircuitOpen is returned without contacting the dependency while its breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerSettings control when a breaker trips and how it recovers
+type BreakerSettings struct {
+	FailureRate float64
+	MinRequests int
+	Window      time.Duration
+	Cooldown    time.Duration
+}
+
+// Breaker stops calls to a dependency that keeps failing, so callers fail fast instead of
+// waiting on timeouts. It is safe for concurrent use; a nil Breaker allows everything.
+type Breaker struct {
+	settings BreakerSettings
+	clock    clock.Clock
+	onChange func(from, to State)
+
+	mu          sync.Mutex
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+}
+
+// NewBreaker creates a closed breaker. It returns nil, which never trips, when the failure rate is
+// zero. onChange is optional and called with the breaker locked, so it must not call back into it.
+func NewBreaker(settings BreakerSettings, clk clock.Clock, onChange func(from, to State)) *Breaker {
+	if settings.FailureRate <= 0 {
+		return nil
+	}
+	if settings.MinRequests < 1 {
+		settings.MinRequests = 1
+	}
+
+	return &Breaker{
+		settings:    settings,
+		clock:       clk,
+		onChange:    onChange,
+		state:       StateClosed,
+		windowStart: clk.Now(),
+	}
+}
+
+// State returns the breaker's current state
+func (b *Breaker) State() State {
+	if b == nil {
+		return StateClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a request may be sent, returning ErrCircuitOpen when it may not. Every
+// allowed request must be followed by Record or Release.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		now := b.clock.Now()
+		if now.Sub(b.openedAt) < b.settings.Cooldown {
+			return ErrCircuitOpen
+		}
+		b.setState(StateHalfOpen, now)
+		b.probing = true
+	case StateHalfOpen:
+		// Only one probe at a time; everything else keeps failing fast until it reports back
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record reports whether an allowed request succeeded. A failed probe reopens the breaker and a
+// successful one closes it; while closed, the breaker opens once the window's failure rate reaches
+// the threshold.
+func (b *Breaker) Record(success bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	switch b.state {
+	case StateHalfOpen:
+		b.probing = false
+		if success {
+			b.setState(StateClosed, now)
+		} else {
+			b.setState(StateOpen, now)
+		}
+		return
+	case StateOpen:
+		// A request allowed before the breaker tripped; it says nothing new
+		return
+	}
+
+	if now.Sub(b.windowStart) >= b.settings.Window {
+		b.windowStart = now
+		b.requests = 0
+		b.failures = 0
+	}
+
+	b.requests++
+	if !success {
+		b.failures++
+	}
+	if b.requests >= b.settings.MinRequests && float64(b.failures)/float64(b.requests) >= b.settings.FailureRate {
+		b.setState(StateOpen, now)
+	}
+}
+
+// Release gives back an allowed request whose outcome says nothing about the dependency, such as
+// one the caller cancelled, so a half-open breaker can send another probe
+func (b *Breaker) Release() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateHalfOpen {
+		b.probing = false
+	}
+}
+
+// setState moves to a new state with a fresh failure window
+func (b *Breaker) setState(to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+	if to == StateOpen {
+		b.openedAt = now
+	}
+	if b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
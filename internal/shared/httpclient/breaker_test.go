@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+)
+
+func newTestBreaker(clk clock.Clock, transitions *[]State) *Breaker {
+	return NewBreaker(BreakerSettings{
+		FailureRate: 0.5,
+		MinRequests: 4,
+		Window:      time.Minute,
+		Cooldown:    30 * time.Second,
+	}, clk, func(from, to State) {
+		*transitions = append(*transitions, to)
+	})
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	clk := clock.NewMock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	var transitions []State
+	breaker := newTestBreaker(clk, &transitions)
+
+	// Failures below the minimum request count don't trip it, however high the rate
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, breaker.Allow())
+		breaker.Record(false)
+	}
+	assert.Equal(t, StateClosed, breaker.State())
+
+	assert.NoError(t, breaker.Allow())
+	breaker.Record(true)
+	assert.Equal(t, StateOpen, breaker.State(), "3 of 4 failed")
+
+	// Open fails fast until the cooldown passes
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+	clk.Advance(29 * time.Second)
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	// Then a single probe goes through
+	clk.Advance(time.Second)
+	assert.NoError(t, breaker.Allow())
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen, "only one probe at a time")
+
+	// A failed probe reopens it for another cooldown
+	breaker.Record(false)
+	assert.Equal(t, StateOpen, breaker.State())
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	// A successful probe closes it with a fresh window
+	clk.Advance(30 * time.Second)
+	assert.NoError(t, breaker.Allow())
+	breaker.Record(true)
+	assert.Equal(t, StateClosed, breaker.State())
+	assert.NoError(t, breaker.Allow())
+	breaker.Record(false)
+	assert.Equal(t, StateClosed, breaker.State())
+
+	assert.Equal(t, []State{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}, transitions)
+}
+
+func TestBreakerWindowResets(t *testing.T) {
+	clk := clock.NewMock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	var transitions []State
+	breaker := newTestBreaker(clk, &transitions)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, breaker.Allow())
+		breaker.Record(false)
+	}
+
+	// The earlier failures fall out with the window, so one more doesn't trip it
+	clk.Advance(time.Minute)
+	assert.NoError(t, breaker.Allow())
+	breaker.Record(false)
+	assert.Equal(t, StateClosed, breaker.State())
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, breaker.Allow())
+		breaker.Record(i == 0)
+	}
+	assert.Equal(t, StateOpen, breaker.State(), "3 of 4 failed in the new window")
+}
+
+func TestBreakerReleaseFreesProbe(t *testing.T) {
+	clk := clock.NewMock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	var transitions []State
+	breaker := newTestBreaker(clk, &transitions)
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, breaker.Allow())
+		breaker.Record(false)
+	}
+	clk.Advance(30 * time.Second)
+
+	assert.NoError(t, breaker.Allow())
+	breaker.Release()
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	assert.NoError(t, breaker.Allow(), "a cancelled probe doesn't block the next one")
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	breaker := NewBreaker(BreakerSettings{FailureRate: 0}, clock.New(), nil)
+	assert.Nil(t, breaker)
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, breaker.Allow())
+		breaker.Record(false)
+	}
+	assert.Equal(t, StateClosed, breaker.State())
+}
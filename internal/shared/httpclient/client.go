@@ -0,0 +1,274 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/monitoring/metrics"
+)
+
+const (
+	// RequestIDHeader carries the originating request ID to downstream services
+	RequestIDHeader = "X-Request-ID"
+
+	// Metric names recorded for every outbound request
+	MetricRequestsTotal   = "http_client_requests_total"
+	MetricRequestDuration = "http_client_request_duration_seconds"
+	MetricRetriesTotal    = "http_client_retries_total"
+
+	// statusCircuitOpen labels requests the breaker failed without sending
+	statusCircuitOpen = "circuit_open"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose outbound requests carry the given request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// Client is an outbound HTTP client for one integration, with bounded timeouts, a pooled
+// transport, retries for idempotent requests and a circuit breaker
+type Client struct {
+	name         string
+	httpClient   *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+	breaker      *Breaker
+	metrics      metrics.MetricsCollector
+	logger       *slog.Logger
+}
+
+// New creates a client for the named integration. The collector is optional.
+func New(cfg *config.Config, logger *slog.Logger, name string, collector metrics.MetricsCollector) *Client {
+	dialer := &net.Dialer{
+		Timeout:   cfg.HTTPClientDialTimeoutDuration(),
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.HTTPClientMaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.HTTPClientMaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.HTTPClientIdleConnTimeoutDuration(),
+		TLSHandshakeTimeout:   cfg.HTTPClientDialTimeoutDuration(),
+		ExpectContinueTimeout: time.Second,
+	}
+
+	client := &Client{
+		name: name,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.HTTPClientTimeoutDuration(),
+		},
+		maxRetries:   cfg.HTTPClientMaxRetries,
+		retryBackoff: cfg.HTTPClientRetryBackoffDuration(),
+		metrics:      collector,
+		logger:       logger,
+	}
+	client.breaker = NewBreaker(BreakerSettings{
+		FailureRate: cfg.HTTPClientBreakerFailureRate,
+		MinRequests: cfg.HTTPClientBreakerMinRequests,
+		Window:      cfg.HTTPClientBreakerWindowDuration(),
+		Cooldown:    cfg.HTTPClientBreakerCooldownDuration(),
+	}, clock.New(), client.logBreakerChange)
+	return client
+}
+
+// Name returns the integration the client calls
+func (c *Client) Name() string {
+	return c.name
+}
+
+// BreakerState returns the state of the client's circuit breaker, closed when it has none
+func (c *Client) BreakerState() State {
+	return c.breaker.State()
+}
+
+// HTTPClient returns the underlying client for libraries that need a *http.Client; requests
+// made through it are not retried, instrumented or guarded by the breaker
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// Do sends a request, retrying idempotent requests that fail with a network error or a
+// retryable status. While the breaker is open it fails fast with ErrCircuitOpen. The caller must
+// close the returned response body.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if requestID, ok := ctx.Value(requestIDKey{}).(string); ok && requestID != "" && req.Header.Get(RequestIDHeader) == "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+
+	if err := c.breaker.Allow(); err != nil {
+		c.count(MetricRequestsTotal, req, statusCircuitOpen)
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	resp, err := c.doWithRetries(ctx, req)
+
+	// The caller giving up is not the dependency failing
+	if ctx.Err() != nil {
+		c.breaker.Release()
+	} else {
+		c.breaker.Record(!isFailure(resp, err))
+	}
+	return resp, err
+}
+
+// doWithRetries sends the request, resending it while attempts remain and the failure is retryable
+func (c *Client) doWithRetries(ctx context.Context, req *http.Request) (*http.Response, error) {
+	// A body can only be resent when it can be recreated
+	attempts := 1
+	if isIdempotent(req.Method) && (req.Body == nil || req.GetBody != nil) {
+		attempts += c.maxRetries
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			c.count(MetricRetriesTotal, req, "")
+			if waitErr := c.wait(ctx, attempt); waitErr != nil {
+				return nil, waitErr
+			}
+			if req.GetBody != nil {
+				body, bodyErr := req.GetBody()
+				if bodyErr != nil {
+					return nil, bodyErr
+				}
+				req.Body = body
+			}
+		}
+
+		resp, err = c.send(req, attempt)
+		if attempt == attempts || !shouldRetry(resp, err) {
+			break
+		}
+
+		if resp != nil {
+			// Drain the body so the connection can be reused for the next attempt
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+	}
+
+	return resp, err
+}
+
+// send performs a single attempt and records its outcome
+func (c *Client) send(req *http.Request, attempt int) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	duration := time.Since(start)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+
+	c.count(MetricRequestsTotal, req, status)
+	if c.metrics != nil {
+		_ = c.metrics.RecordDuration(MetricRequestDuration, duration, map[string]string{
+			"client": c.name,
+			"method": req.Method,
+		})
+	}
+
+	c.logger.Debug("outbound http request",
+		"client", c.name,
+		"method", req.Method,
+		"host", req.URL.Host,
+		"path", req.URL.Path,
+		"status", status,
+		"attempt", attempt,
+		"duration", duration,
+		"request_id", req.Header.Get(RequestIDHeader),
+		"error", err,
+	)
+
+	return resp, err
+}
+
+// logBreakerChange logs every breaker transition, as warnings while the dependency is unhealthy
+func (c *Client) logBreakerChange(from, to State) {
+	level := slog.LevelWarn
+	if to == StateClosed {
+		level = slog.LevelInfo
+	}
+	c.logger.Log(context.Background(), level, "outbound circuit breaker state changed",
+		"client", c.name,
+		"from", from,
+		"to", to,
+	)
+}
+
+// count increments a counter labelled with the client, method and optional status
+func (c *Client) count(name string, req *http.Request, status string) {
+	if c.metrics == nil {
+		return
+	}
+
+	labels := map[string]string{
+		"client": c.name,
+		"method": req.Method,
+	}
+	if status != "" {
+		labels["status"] = status
+	}
+	_ = c.metrics.IncrementCounter(name, labels)
+}
+
+// wait sleeps before a retry, doubling the backoff for every attempt after the second
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	delay := c.retryBackoff << (attempt - 2)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isIdempotent reports whether a request may safely be sent more than once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isFailure reports whether a request's outcome counts against the dependency's health. Client
+// errors are the caller's problem, not the dependency's.
+func isFailure(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// shouldRetry reports whether an attempt failed in a way another attempt may fix
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,141 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/monitoring/metrics"
+)
+
+func newTestClient(collector metrics.MetricsCollector) *Client {
+	cfg := &config.Config{
+		HTTPClientTimeout:      "200ms",
+		HTTPClientMaxRetries:   2,
+		HTTPClientRetryBackoff: "1ms",
+	}
+	return New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), "test", collector)
+}
+
+func TestClientRetriesIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	collector := metrics.NewInMemoryCollector(&config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client := newTestClient(collector)
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "payload", string(body))
+	assert.Equal(t, int32(3), calls.Load())
+
+	retries, _ := collector.GetCurrentValue(MetricRetriesTotal, map[string]string{"client": "test", "method": "PUT"})
+	assert.Equal(t, float64(2), retries)
+	failures, _ := collector.GetCurrentValue(MetricRequestsTotal, map[string]string{"client": "test", "method": "PUT", "status": "503"})
+	assert.Equal(t, float64(2), failures)
+}
+
+func TestClientDoesNotRetryPost(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	resp, err := newTestClient(nil).Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	start := time.Now()
+	_, err := newTestClient(nil).Do(req)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClientPropagatesRequestID(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(RequestIDHeader)
+	}))
+	defer server.Close()
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := newTestClient(nil).Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "req-123", received)
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		HTTPClientTimeout:            "200ms",
+		HTTPClientBreakerFailureRate: 0.5,
+		HTTPClientBreakerMinRequests: 2,
+		HTTPClientBreakerCooldown:    "1h",
+	}
+	collector := metrics.NewInMemoryCollector(&config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), "test", collector)
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, StateOpen, client.BreakerState())
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	_, err := client.Do(req)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load(), "an open breaker doesn't contact the dependency")
+
+	shortCircuited, _ := collector.GetCurrentValue(MetricRequestsTotal, map[string]string{"client": "test", "method": "POST", "status": "circuit_open"})
+	assert.Equal(t, float64(1), shortCircuited)
+}
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// SecurityEvent identifies a security-relevant event written to the security log
+type SecurityEvent string
+
+const (
+	SecurityEventLoginSuccess  SecurityEvent = "login_success"
+	SecurityEventLoginFailure  SecurityEvent = "login_failure"
+	SecurityEventLockout       SecurityEvent = "lockout"
+	SecurityEventRoleChange    SecurityEvent = "role_change"
+	SecurityEventAlert         SecurityEvent = "security_alert"
+	SecurityEventAuthzDecision SecurityEvent = "authz_decision"
+)
+
+// Security log sinks
+const (
+	SecuritySinkStdout = "stdout"
+	SecuritySinkFile   = "file"
+	SecuritySinkNone   = "none"
+)
+
+// sensitiveKeys are attribute keys whose values are never written to the security log
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie", "api_key"}
+
+// SecurityLogger writes security events as structured JSON to a dedicated sink, independent of
+// the application log level. A nil SecurityLogger discards all events.
+type SecurityLogger struct {
+	logger *slog.Logger
+	events map[SecurityEvent]bool
+	closer io.Closer
+}
+
+// NewSecurityLogger creates a security logger for the given sink. An empty events list enables every event.
+func NewSecurityLogger(sink, filePath string, events []string) (*SecurityLogger, error) {
+	var writer io.Writer
+	var closer io.Closer
+
+	switch strings.ToLower(sink) {
+	case "", SecuritySinkStdout:
+		writer = os.Stdout
+	case SecuritySinkFile:
+		if filePath == "" {
+			return nil, fmt.Errorf("security log file path is required for the file sink")
+		}
+		file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open security log file: %w", err)
+		}
+		writer = file
+		closer = file
+	case SecuritySinkNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported security log sink: %s", sink)
+	}
+
+	return NewSecurityLoggerWithWriter(writer, events, closer), nil
+}
+
+// NewSecurityLoggerWithWriter creates a security logger that writes to w
+func NewSecurityLoggerWithWriter(w io.Writer, events []string, closer io.Closer) *SecurityLogger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		// Security events are always emitted regardless of the application log level
+		Level:       slog.LevelDebug,
+		ReplaceAttr: redactSensitive,
+	})
+
+	enabled := make(map[SecurityEvent]bool, len(events))
+	for _, event := range events {
+		if event = strings.TrimSpace(event); event != "" {
+			enabled[SecurityEvent(event)] = true
+		}
+	}
+
+	return &SecurityLogger{
+		logger: slog.New(handler).With("log_type", "security"),
+		events: enabled,
+		closer: closer,
+	}
+}
+
+// Enabled reports whether an event is written to the security log
+func (l *SecurityLogger) Enabled(event SecurityEvent) bool {
+	if l == nil {
+		return false
+	}
+	return len(l.events) == 0 || l.events[event]
+}
+
+// Log writes a security event with the given attributes as key/value pairs
+func (l *SecurityLogger) Log(event SecurityEvent, message string, args ...any) {
+	if !l.Enabled(event) {
+		return
+	}
+
+	level := slog.LevelInfo
+	switch event {
+	case SecurityEventLoginFailure, SecurityEventLockout, SecurityEventRoleChange:
+		level = slog.LevelWarn
+	case SecurityEventAlert:
+		level = slog.LevelError
+	}
+
+	l.logger.Log(context.Background(), level, message, append([]any{"event", event}, args...)...)
+}
+
+// Close releases the underlying sink if it is a file
+func (l *SecurityLogger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// redactSensitive masks attributes that could carry secrets
+func redactSensitive(_ []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(attr.Key, "[REDACTED]")
+		}
+	}
+	return attr
+}
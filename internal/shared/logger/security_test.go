@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityLoggerWritesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	securityLogger := NewSecurityLoggerWithWriter(&buf, nil, nil)
+
+	securityLogger.Log(SecurityEventLoginFailure, "failed login", "email", "user@example.com", "ip_address", "10.0.0.1")
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "security", entry["log_type"])
+	assert.Equal(t, "login_failure", entry["event"])
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "10.0.0.1", entry["ip_address"])
+}
+
+func TestSecurityLoggerEventFilter(t *testing.T) {
+	var buf bytes.Buffer
+	securityLogger := NewSecurityLoggerWithWriter(&buf, []string{"lockout", " role_change "}, nil)
+
+	securityLogger.Log(SecurityEventLoginSuccess, "login")
+	securityLogger.Log(SecurityEventLockout, "locked out")
+	securityLogger.Log(SecurityEventRoleChange, "role changed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"event":"lockout"`)
+	assert.Contains(t, lines[1], `"event":"role_change"`)
+}
+
+func TestSecurityLoggerRedactsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	securityLogger := NewSecurityLoggerWithWriter(&buf, nil, nil)
+
+	securityLogger.Log(SecurityEventAlert, "alert", "password", "hunter2", "refresh_token", "abc123", "email", "user@example.com")
+
+	assert.NotContains(t, buf.String(), "hunter2")
+	assert.NotContains(t, buf.String(), "abc123")
+	assert.Contains(t, buf.String(), "[REDACTED]")
+	assert.Contains(t, buf.String(), "user@example.com")
+}
+
+func TestNilSecurityLoggerIsNoop(t *testing.T) {
+	var securityLogger *SecurityLogger
+
+	assert.False(t, securityLogger.Enabled(SecurityEventAlert))
+	securityLogger.Log(SecurityEventAlert, "ignored")
+	assert.NoError(t, securityLogger.Close())
+
+	none, err := NewSecurityLogger(SecuritySinkNone, "", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, none)
+}
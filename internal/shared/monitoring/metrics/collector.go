@@ -2,32 +2,69 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"math"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/acheevo/tfa/internal/shared/config"
 )
 
-// InMemoryCollector implements MetricsCollector using in-memory storage
+// ErrInvalidMetricDefinition is returned when registering a definition without a name or type
+var ErrInvalidMetricDefinition = errors.New("metric definition requires a name and type")
+
+// DefaultBuckets are used for histograms observed without a registered definition
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// defaultMaxSamples bounds the samples kept per summary series when no limit is configured
+const defaultMaxSamples = 1000
+
+// InMemoryCollector implements MetricsCollector using in-memory storage. It is safe for
+// concurrent use and is the default collector, so metrics work without an external backend.
 type InMemoryCollector struct {
-	metrics map[string]*inMemoryMetric
-	mu      sync.RWMutex
-	logger  *slog.Logger
+	metrics     map[string]*inMemoryMetric
+	definitions map[string]*MetricDefinition
+	maxSamples  int
+	mu          sync.RWMutex
+	logger      *slog.Logger
 }
 
-// inMemoryMetric represents a metric stored in memory
+// inMemoryMetric is a single series: one metric name with one set of label values
 type inMemoryMetric struct {
-	definition *MetricDefinition
-	value      float64
+	name       string
+	metricType MetricType
 	labels     map[string]string
+	value      float64
 	timestamp  time.Time
-	// For histograms
-	buckets map[float64]uint64
-	sum     float64
-	count   uint64
-	// For summaries
+	// For histograms and summaries
+	sum   float64
+	count uint64
+	// For histograms: upper bounds in ascending order and the cumulative count for each
+	bounds       []float64
+	bucketCounts []uint64
+	// For summaries: the most recent samples, used to estimate quantiles
 	samples []float64
 }
 
+// HistogramSnapshot is a point-in-time copy of a histogram series
+type HistogramSnapshot struct {
+	// Buckets maps each upper bound to the number of observations less than or equal to it
+	Buckets map[float64]uint64
+	Sum     float64
+	Count   uint64
+}
+
+// SummarySnapshot is a point-in-time copy of a summary series
+type SummarySnapshot struct {
+	// Quantiles maps each objective (e.g. 0.99) to its estimate over the retained samples
+	Quantiles map[float64]float64
+	Sum       float64
+	Count     uint64
+}
+
 // timer implements the Timer interface
 type timer struct {
 	name      string
@@ -37,10 +74,17 @@ type timer struct {
 }
 
 // NewInMemoryCollector creates a new in-memory metrics collector
-func NewInMemoryCollector(logger *slog.Logger) *InMemoryCollector {
+func NewInMemoryCollector(cfg *config.Config, logger *slog.Logger) *InMemoryCollector {
+	maxSamples := cfg.MetricsSummaryMaxSamples
+	if maxSamples <= 0 {
+		maxSamples = defaultMaxSamples
+	}
+
 	return &InMemoryCollector{
-		metrics: make(map[string]*inMemoryMetric),
-		logger:  logger,
+		metrics:     make(map[string]*inMemoryMetric),
+		definitions: make(map[string]*MetricDefinition),
+		maxSamples:  maxSamples,
+		logger:      logger,
 	}
 }
 
@@ -54,18 +98,7 @@ func (c *InMemoryCollector) IncrementCounterBy(name string, value float64, label
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	key := c.getMetricKey(name, labels)
-	metric, exists := c.metrics[key]
-
-	if !exists {
-		metric = &inMemoryMetric{
-			value:     0,
-			labels:    labels,
-			timestamp: time.Now(),
-		}
-		c.metrics[key] = metric
-	}
-
+	metric := c.series(name, MetricTypeCounter, labels)
 	metric.value += value
 	metric.timestamp = time.Now()
 
@@ -77,16 +110,7 @@ func (c *InMemoryCollector) SetGauge(name string, value float64, labels map[stri
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	key := c.getMetricKey(name, labels)
-	metric, exists := c.metrics[key]
-
-	if !exists {
-		metric = &inMemoryMetric{
-			labels: labels,
-		}
-		c.metrics[key] = metric
-	}
-
+	metric := c.series(name, MetricTypeGauge, labels)
 	metric.value = value
 	metric.timestamp = time.Now()
 
@@ -98,17 +122,7 @@ func (c *InMemoryCollector) IncrementGauge(name string, labels map[string]string
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	key := c.getMetricKey(name, labels)
-	metric, exists := c.metrics[key]
-
-	if !exists {
-		metric = &inMemoryMetric{
-			value:  0,
-			labels: labels,
-		}
-		c.metrics[key] = metric
-	}
-
+	metric := c.series(name, MetricTypeGauge, labels)
 	metric.value++
 	metric.timestamp = time.Now()
 
@@ -120,47 +134,23 @@ func (c *InMemoryCollector) DecrementGauge(name string, labels map[string]string
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	key := c.getMetricKey(name, labels)
-	metric, exists := c.metrics[key]
-
-	if !exists {
-		metric = &inMemoryMetric{
-			value:  0,
-			labels: labels,
-		}
-		c.metrics[key] = metric
-	}
-
+	metric := c.series(name, MetricTypeGauge, labels)
 	metric.value--
 	metric.timestamp = time.Now()
 
 	return nil
 }
 
-// ObserveHistogram observes a value for a histogram metric
+// ObserveHistogram observes a value for a histogram metric. Buckets come from the registered
+// definition, falling back to DefaultBuckets.
 func (c *InMemoryCollector) ObserveHistogram(name string, value float64, labels map[string]string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	key := c.getMetricKey(name, labels)
-	metric, exists := c.metrics[key]
-
-	if !exists {
-		metric = &inMemoryMetric{
-			labels:  labels,
-			buckets: make(map[float64]uint64),
-			sum:     0,
-			count:   0,
-		}
-		c.metrics[key] = metric
-	}
-
-	// Update histogram buckets
-	if metric.definition != nil && metric.definition.Buckets != nil {
-		for _, bucket := range metric.definition.Buckets {
-			if value <= bucket {
-				metric.buckets[bucket]++
-			}
+	metric := c.series(name, MetricTypeHistogram, labels)
+	for i, bound := range metric.bounds {
+		if value <= bound {
+			metric.bucketCounts[i]++
 		}
 	}
 
@@ -171,28 +161,20 @@ func (c *InMemoryCollector) ObserveHistogram(name string, value float64, labels
 	return nil
 }
 
-// ObserveSummary observes a value for a summary metric
+// ObserveSummary observes a value for a summary metric. Sum and count cover every observation,
+// while quantiles are estimated from the most recent samples only.
 func (c *InMemoryCollector) ObserveSummary(name string, value float64, labels map[string]string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	key := c.getMetricKey(name, labels)
-	metric, exists := c.metrics[key]
-
-	if !exists {
-		metric = &inMemoryMetric{
-			labels:  labels,
-			samples: make([]float64, 0),
-		}
-		c.metrics[key] = metric
-	}
-
-	// Add sample (keep only last 1000 samples for memory efficiency)
+	metric := c.series(name, MetricTypeSummary, labels)
 	metric.samples = append(metric.samples, value)
-	if len(metric.samples) > 1000 {
-		metric.samples = metric.samples[1:]
+	if len(metric.samples) > c.maxSamples {
+		metric.samples = metric.samples[len(metric.samples)-c.maxSamples:]
 	}
 
+	metric.sum += value
+	metric.count++
 	metric.timestamp = time.Now()
 
 	return nil
@@ -202,74 +184,96 @@ func (c *InMemoryCollector) ObserveSummary(name string, value float64, labels ma
 func (c *InMemoryCollector) StartTimer(name string, labels map[string]string) Timer {
 	return &timer{
 		name:      name,
-		labels:    labels,
+		labels:    copyLabels(labels),
 		startTime: time.Now(),
 		collector: c,
 	}
 }
 
-// RecordDuration records a duration measurement
+// RecordDuration records a duration in seconds on a histogram metric
 func (c *InMemoryCollector) RecordDuration(name string, duration time.Duration, labels map[string]string) error {
 	return c.ObserveHistogram(name, duration.Seconds(), labels)
 }
 
-// RegisterMetric registers a metric definition
+// RegisterMetric registers a metric definition. Series created afterwards use its buckets and
+// objectives, and Collect reports its type and help text.
 func (c *InMemoryCollector) RegisterMetric(metric *MetricDefinition) error {
+	if metric == nil || metric.Name == "" || metric.Type == "" {
+		return ErrInvalidMetricDefinition
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Initialize metric if needed
-	key := metric.Name
-	if _, exists := c.metrics[key]; !exists {
-		inMemMetric := &inMemoryMetric{
-			definition: metric,
-			timestamp:  time.Now(),
-		}
-
-		// Initialize type-specific fields
-		switch metric.Type {
-		case MetricTypeHistogram:
-			inMemMetric.buckets = make(map[float64]uint64)
-			if metric.Buckets != nil {
-				for _, bucket := range metric.Buckets {
-					inMemMetric.buckets[bucket] = 0
-				}
-			}
-		case MetricTypeSummary:
-			inMemMetric.samples = make([]float64, 0)
-		}
-
-		c.metrics[key] = inMemMetric
-	}
+	c.definitions[metric.Name] = metric
 
 	c.logger.Debug("Metric registered", "name", metric.Name, "type", metric.Type)
 	return nil
 }
 
-// Collect returns all current metrics
+// Collect returns all current metrics. Histograms are expanded into _bucket (with an "le" label),
+// _sum and _count entries, and summaries into one entry per quantile plus _sum and _count.
 func (c *InMemoryCollector) Collect(ctx context.Context) ([]*Metric, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	var metrics []*Metric
+	keys := make([]string, 0, len(c.metrics))
+	for key := range c.metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	for key, metric := range c.metrics {
-		// Extract metric name from key (remove label hash)
-		name := c.extractMetricName(key)
+	var metrics []*Metric
+	for _, key := range keys {
+		metric := c.metrics[key]
 
-		m := &Metric{
-			Name:      name,
-			Value:     metric.value,
-			Labels:    metric.labels,
-			Timestamp: metric.timestamp,
+		help := ""
+		if definition, ok := c.definitions[metric.name]; ok {
+			help = definition.Help
 		}
 
-		if metric.definition != nil {
-			m.Type = metric.definition.Type
-			m.Help = metric.definition.Help
+		entry := func(name string, value float64, extra map[string]string) *Metric {
+			labels := copyLabels(metric.labels)
+			for k, v := range extra {
+				if labels == nil {
+					labels = make(map[string]string, len(extra))
+				}
+				labels[k] = v
+			}
+			return &Metric{
+				Name:      name,
+				Type:      metric.metricType,
+				Value:     value,
+				Labels:    labels,
+				Timestamp: metric.timestamp,
+				Help:      help,
+			}
 		}
 
-		metrics = append(metrics, m)
+		switch metric.metricType {
+		case MetricTypeHistogram:
+			for i, bound := range metric.bounds {
+				le := strconv.FormatFloat(bound, 'g', -1, 64)
+				metrics = append(metrics, entry(metric.name+"_bucket", float64(metric.bucketCounts[i]), map[string]string{"le": le}))
+			}
+			metrics = append(metrics,
+				entry(metric.name+"_bucket", float64(metric.count), map[string]string{"le": "+Inf"}),
+				entry(metric.name+"_sum", metric.sum, nil),
+				entry(metric.name+"_count", float64(metric.count), nil),
+			)
+		case MetricTypeSummary:
+			quantiles := c.quantiles(metric)
+			for _, q := range sortedKeys(quantiles) {
+				quantile := strconv.FormatFloat(q, 'g', -1, 64)
+				metrics = append(metrics, entry(metric.name, quantiles[q], map[string]string{"quantile": quantile}))
+			}
+			metrics = append(metrics,
+				entry(metric.name+"_sum", metric.sum, nil),
+				entry(metric.name+"_count", float64(metric.count), nil),
+			)
+		default:
+			metrics = append(metrics, entry(metric.name, metric.value, nil))
+		}
 	}
 
 	return metrics, nil
@@ -288,29 +292,97 @@ func (t *timer) StopAndRecord() error {
 
 // Helper methods
 
-// getMetricKey generates a unique key for a metric with labels
-func (c *InMemoryCollector) getMetricKey(name string, labels map[string]string) string {
-	key := name
-	if len(labels) > 0 {
-		for k, v := range labels {
-			key += ";" + k + "=" + v
+// series returns the series for name and labels, creating it on first use. Labels are copied so
+// callers may reuse or modify their map afterwards. The caller must hold the write lock.
+func (c *InMemoryCollector) series(name string, metricType MetricType, labels map[string]string) *inMemoryMetric {
+	key := c.getMetricKey(name, labels)
+	if metric, exists := c.metrics[key]; exists {
+		return metric
+	}
+
+	metric := &inMemoryMetric{
+		name:       name,
+		metricType: metricType,
+		labels:     copyLabels(labels),
+		timestamp:  time.Now(),
+	}
+
+	if metricType == MetricTypeHistogram {
+		bounds := DefaultBuckets
+		if definition, ok := c.definitions[name]; ok && len(definition.Buckets) > 0 {
+			bounds = definition.Buckets
 		}
+		metric.bounds = append([]float64(nil), bounds...)
+		sort.Float64s(metric.bounds)
+		metric.bucketCounts = make([]uint64, len(metric.bounds))
 	}
-	return key
+
+	c.metrics[key] = metric
+	return metric
 }
 
-// extractMetricName extracts the metric name from a metric key
-func (c *InMemoryCollector) extractMetricName(key string) string {
-	// Find the first semicolon which separates name from labels
-	for i, char := range key {
-		if char == ';' {
-			return key[:i]
+// quantiles estimates each of the summary's objectives from its retained samples using the
+// nearest-rank method. The caller must hold the lock.
+func (c *InMemoryCollector) quantiles(metric *inMemoryMetric) map[float64]float64 {
+	objectives := DefaultSummaryObjectives
+	if definition, ok := c.definitions[metric.name]; ok && len(definition.Objectives) > 0 {
+		objectives = definition.Objectives
+	}
+
+	samples := append([]float64(nil), metric.samples...)
+	sort.Float64s(samples)
+
+	quantiles := make(map[float64]float64, len(objectives))
+	for q := range objectives {
+		if len(samples) == 0 {
+			quantiles[q] = math.NaN()
+			continue
 		}
+		rank := int(math.Ceil(q*float64(len(samples)))) - 1
+		rank = max(0, min(rank, len(samples)-1))
+		quantiles[q] = samples[rank]
+	}
+	return quantiles
+}
+
+// getMetricKey generates a unique key for a metric with labels, independent of label order
+func (c *InMemoryCollector) getMetricKey(name string, labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	key := name
+	for _, k := range names {
+		key += ";" + k + "=" + labels[k]
 	}
 	return key
 }
 
-// GetCurrentValue returns the current value of a metric
+// copyLabels returns a copy of labels, or nil when there are none
+func copyLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
+
+// sortedKeys returns the keys of m in ascending order
+func sortedKeys(m map[float64]float64) []float64 {
+	keys := make([]float64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Float64s(keys)
+	return keys
+}
+
+// GetCurrentValue returns the current value of a counter or gauge
 func (c *InMemoryCollector) GetCurrentValue(name string, labels map[string]string) (float64, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -324,7 +396,38 @@ func (c *InMemoryCollector) GetCurrentValue(name string, labels map[string]strin
 	return metric.value, true
 }
 
-// GetMetricCount returns the number of registered metrics
+// GetHistogram returns a snapshot of a histogram series
+func (c *InMemoryCollector) GetHistogram(name string, labels map[string]string) (HistogramSnapshot, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	metric, exists := c.metrics[c.getMetricKey(name, labels)]
+	if !exists || metric.metricType != MetricTypeHistogram {
+		return HistogramSnapshot{}, false
+	}
+
+	buckets := make(map[float64]uint64, len(metric.bounds))
+	for i, bound := range metric.bounds {
+		buckets[bound] = metric.bucketCounts[i]
+	}
+
+	return HistogramSnapshot{Buckets: buckets, Sum: metric.sum, Count: metric.count}, true
+}
+
+// GetSummary returns a snapshot of a summary series
+func (c *InMemoryCollector) GetSummary(name string, labels map[string]string) (SummarySnapshot, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	metric, exists := c.metrics[c.getMetricKey(name, labels)]
+	if !exists || metric.metricType != MetricTypeSummary {
+		return SummarySnapshot{}, false
+	}
+
+	return SummarySnapshot{Quantiles: c.quantiles(metric), Sum: metric.sum, Count: metric.count}, true
+}
+
+// GetMetricCount returns the number of series recorded so far
 func (c *InMemoryCollector) GetMetricCount() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -341,10 +444,8 @@ func (c *InMemoryCollector) Reset() {
 		metric.value = 0
 		metric.sum = 0
 		metric.count = 0
-		if metric.buckets != nil {
-			for bucket := range metric.buckets {
-				metric.buckets[bucket] = 0
-			}
+		for i := range metric.bucketCounts {
+			metric.bucketCounts[i] = 0
 		}
 		if metric.samples != nil {
 			metric.samples = metric.samples[:0]
@@ -359,16 +460,15 @@ func (c *InMemoryCollector) GetStats() map[string]interface{} {
 	defer c.mu.RUnlock()
 
 	stats := map[string]interface{}{
-		"total_metrics": len(c.metrics),
-		"memory_usage":  "estimated", // Could calculate actual memory usage
+		"total_metrics":      len(c.metrics),
+		"registered_metrics": len(c.definitions),
+		"memory_usage":       "estimated", // Could calculate actual memory usage
 	}
 
-	// Count metrics by type
+	// Count series by type
 	typeCount := make(map[MetricType]int)
 	for _, metric := range c.metrics {
-		if metric.definition != nil {
-			typeCount[metric.definition.Type]++
-		}
+		typeCount[metric.metricType]++
 	}
 
 	stats["metrics_by_type"] = typeCount
@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func newTestCollector(maxSamples int) *InMemoryCollector {
+	return NewInMemoryCollector(
+		&config.Config{MetricsSummaryMaxSamples: maxSamples},
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+}
+
+func TestCounterAccumulates(t *testing.T) {
+	c := newTestCollector(0)
+	labels := map[string]string{"method": "GET", "status": "200"}
+
+	assert.NoError(t, c.IncrementCounter("requests_total", labels))
+	assert.NoError(t, c.IncrementCounterBy("requests_total", 2.5, map[string]string{"status": "200", "method": "GET"}))
+	assert.NoError(t, c.IncrementCounter("requests_total", map[string]string{"method": "POST", "status": "200"}))
+
+	value, ok := c.GetCurrentValue("requests_total", labels)
+	assert.True(t, ok)
+	assert.Equal(t, 3.5, value)
+
+	value, _ = c.GetCurrentValue("requests_total", map[string]string{"method": "POST", "status": "200"})
+	assert.Equal(t, 1.0, value)
+
+	_, ok = c.GetCurrentValue("requests_total", nil)
+	assert.False(t, ok)
+}
+
+func TestGaugeSetIncrementDecrement(t *testing.T) {
+	c := newTestCollector(0)
+
+	assert.NoError(t, c.SetGauge("queue_size", 10, nil))
+	assert.NoError(t, c.IncrementGauge("queue_size", nil))
+	assert.NoError(t, c.IncrementGauge("queue_size", nil))
+	assert.NoError(t, c.DecrementGauge("queue_size", nil))
+
+	value, ok := c.GetCurrentValue("queue_size", nil)
+	assert.True(t, ok)
+	assert.Equal(t, 11.0, value)
+
+	assert.NoError(t, c.SetGauge("queue_size", 3, nil))
+	value, _ = c.GetCurrentValue("queue_size", nil)
+	assert.Equal(t, 3.0, value)
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	c := newTestCollector(0)
+	assert.NoError(t, c.RegisterMetric(&MetricDefinition{
+		Name:    "latency_seconds",
+		Type:    MetricTypeHistogram,
+		Buckets: []float64{1, 0.1, 0.5},
+	}))
+
+	for _, v := range []float64{0.05, 0.1, 0.3, 0.7, 2} {
+		assert.NoError(t, c.ObserveHistogram("latency_seconds", v, map[string]string{"route": "/a"}))
+	}
+
+	snapshot, ok := c.GetHistogram("latency_seconds", map[string]string{"route": "/a"})
+	assert.True(t, ok)
+	assert.Equal(t, map[float64]uint64{0.1: 2, 0.5: 3, 1: 4}, snapshot.Buckets)
+	assert.Equal(t, uint64(5), snapshot.Count)
+	assert.InDelta(t, 3.15, snapshot.Sum, 1e-9)
+
+	// Unregistered histograms fall back to the default buckets
+	assert.NoError(t, c.ObserveHistogram("other", 0.2, nil))
+	snapshot, _ = c.GetHistogram("other", nil)
+	assert.Len(t, snapshot.Buckets, len(DefaultBuckets))
+	assert.Equal(t, uint64(1), snapshot.Buckets[0.25])
+	assert.Equal(t, uint64(0), snapshot.Buckets[0.1])
+}
+
+func TestSummaryQuantiles(t *testing.T) {
+	c := newTestCollector(10)
+	assert.NoError(t, c.RegisterMetric(&MetricDefinition{
+		Name:       "payload_bytes",
+		Type:       MetricTypeSummary,
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01},
+	}))
+
+	for i := 1; i <= 20; i++ {
+		assert.NoError(t, c.ObserveSummary("payload_bytes", float64(i), nil))
+	}
+
+	snapshot, ok := c.GetSummary("payload_bytes", nil)
+	assert.True(t, ok)
+	// Sum and count cover every observation; quantiles only the last 10 samples (11..20)
+	assert.Equal(t, uint64(20), snapshot.Count)
+	assert.Equal(t, 210.0, snapshot.Sum)
+	assert.Equal(t, map[float64]float64{0.5: 15, 0.9: 19}, snapshot.Quantiles)
+}
+
+func TestTimerAndRecordDuration(t *testing.T) {
+	c := newTestCollector(0)
+
+	assert.NoError(t, c.RecordDuration("job_seconds", 1500*time.Millisecond, nil))
+
+	timer := c.StartTimer("job_seconds", nil)
+	assert.True(t, timer.Stop() >= 0)
+	assert.NoError(t, timer.StopAndRecord())
+
+	snapshot, ok := c.GetHistogram("job_seconds", nil)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), snapshot.Count)
+	assert.True(t, snapshot.Sum >= 1.5)
+}
+
+func TestLabelsAreCopied(t *testing.T) {
+	c := newTestCollector(0)
+	labels := map[string]string{"endpoint": "/a"}
+
+	assert.NoError(t, c.IncrementGauge("in_flight", labels))
+	labels["status"] = "200"
+
+	value, ok := c.GetCurrentValue("in_flight", map[string]string{"endpoint": "/a"})
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, value)
+}
+
+func TestCollect(t *testing.T) {
+	c := newTestCollector(0)
+	assert.NoError(t, c.RegisterMetric(&MetricDefinition{Name: "hits", Type: MetricTypeCounter, Help: "Hits"}))
+	assert.NoError(t, c.RegisterMetric(&MetricDefinition{Name: "size", Type: MetricTypeHistogram, Buckets: []float64{10}}))
+	assert.Equal(t, ErrInvalidMetricDefinition, c.RegisterMetric(&MetricDefinition{Type: MetricTypeGauge}))
+
+	assert.NoError(t, c.IncrementCounter("hits", map[string]string{"page": "home"}))
+	assert.NoError(t, c.ObserveHistogram("size", 5, nil))
+	assert.NoError(t, c.ObserveHistogram("size", 50, nil))
+
+	collected, err := c.Collect(context.Background())
+	assert.NoError(t, err)
+
+	byName := make(map[string][]*Metric)
+	for _, m := range collected {
+		byName[m.Name] = append(byName[m.Name], m)
+	}
+
+	assert.Len(t, byName["hits"], 1)
+	assert.Equal(t, MetricTypeCounter, byName["hits"][0].Type)
+	assert.Equal(t, "Hits", byName["hits"][0].Help)
+	assert.Equal(t, map[string]string{"page": "home"}, byName["hits"][0].Labels)
+
+	assert.Len(t, byName["size_bucket"], 2)
+	assert.Equal(t, map[string]string{"le": "10"}, byName["size_bucket"][0].Labels)
+	assert.Equal(t, 1.0, byName["size_bucket"][0].Value)
+	assert.Equal(t, map[string]string{"le": "+Inf"}, byName["size_bucket"][1].Labels)
+	assert.Equal(t, 2.0, byName["size_bucket"][1].Value)
+	assert.Equal(t, 55.0, byName["size_sum"][0].Value)
+	assert.Equal(t, 2.0, byName["size_count"][0].Value)
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	c := newTestCollector(0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = c.IncrementCounter("concurrent_total", nil)
+				_ = c.ObserveHistogram("concurrent_seconds", 0.01, nil)
+				_, _ = c.Collect(context.Background())
+			}
+		}()
+	}
+	wg.Wait()
+
+	value, _ := c.GetCurrentValue("concurrent_total", nil)
+	assert.Equal(t, 5000.0, value)
+	snapshot, _ := c.GetHistogram("concurrent_seconds", nil)
+	assert.Equal(t, uint64(5000), snapshot.Count)
+}
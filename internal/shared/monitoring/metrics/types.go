@@ -93,9 +93,12 @@ type DatabaseMetrics struct {
 type EmailMetrics struct {
 	EmailsSent         string
 	EmailsFailed       string
+	EmailsRetried      string
 	EmailsQueued       string
 	EmailDeliveryTime  string
 	EmailTemplatesUsed string
+	QueueDepth         string
+	QueueOldestPending string
 }
 
 // AuthMetrics represents authentication-specific metrics
@@ -123,6 +126,7 @@ type SystemMetrics struct {
 type BusinessMetrics struct {
 	UsersRegistered string
 	UsersActive     string
+	UsersLoggedIn   string
 	UserSessions    string
 	FeatureUsage    string
 	ErrorsTotal     string
@@ -161,9 +165,12 @@ func GetDefaultMetrics() *DefaultMetrics {
 		Email: EmailMetrics{
 			EmailsSent:         "emails_sent_total",
 			EmailsFailed:       "emails_failed_total",
+			EmailsRetried:      "emails_retried_total",
 			EmailsQueued:       "emails_queued",
 			EmailDeliveryTime:  "email_delivery_duration_seconds",
 			EmailTemplatesUsed: "email_templates_used_total",
+			QueueDepth:         "email_queue_depth",
+			QueueOldestPending: "email_queue_oldest_pending_seconds",
 		},
 		Auth: AuthMetrics{
 			LoginAttempts:   "auth_login_attempts_total",
@@ -185,6 +192,7 @@ func GetDefaultMetrics() *DefaultMetrics {
 		Business: BusinessMetrics{
 			UsersRegistered: "business_users_registered_total",
 			UsersActive:     "business_users_active",
+			UsersLoggedIn:   "business_users_logged_in",
 			UserSessions:    "business_user_sessions_total",
 			FeatureUsage:    "business_feature_usage_total",
 			ErrorsTotal:     "business_errors_total",
@@ -248,6 +256,22 @@ func (r *MetricsRegistry) RegisterDefaultMetrics() {
 		Buckets: DefaultHistogramBuckets["http_duration"],
 	})
 
+	_ = r.RegisterMetric(&MetricDefinition{
+		Name:    metrics.HTTP.RequestSize,
+		Type:    MetricTypeHistogram,
+		Help:    "HTTP request body size in bytes",
+		Labels:  []string{"method", "status", "endpoint"},
+		Buckets: DefaultHistogramBuckets["file_size"],
+	})
+
+	_ = r.RegisterMetric(&MetricDefinition{
+		Name:    metrics.HTTP.ResponseSize,
+		Type:    MetricTypeHistogram,
+		Help:    "HTTP response body size in bytes",
+		Labels:  []string{"method", "status", "endpoint"},
+		Buckets: DefaultHistogramBuckets["file_size"],
+	})
+
 	_ = r.RegisterMetric(&MetricDefinition{
 		Name:   metrics.HTTP.RequestsInFlight,
 		Type:   MetricTypeGauge,
@@ -278,6 +302,12 @@ func (r *MetricsRegistry) RegisterDefaultMetrics() {
 		Labels: []string{"provider", "template"},
 	})
 
+	_ = r.RegisterMetric(&MetricDefinition{
+		Name: metrics.Email.EmailsRetried,
+		Type: MetricTypeCounter,
+		Help: "Total number of failed emails requeued by the retry sweep",
+	})
+
 	_ = r.RegisterMetric(&MetricDefinition{
 		Name:   metrics.Email.EmailsQueued,
 		Type:   MetricTypeGauge,
@@ -285,6 +315,19 @@ func (r *MetricsRegistry) RegisterDefaultMetrics() {
 		Labels: []string{"priority"},
 	})
 
+	_ = r.RegisterMetric(&MetricDefinition{
+		Name:   metrics.Email.QueueDepth,
+		Type:   MetricTypeGauge,
+		Help:   "Number of emails in the queue by status, as of the last queue check",
+		Labels: []string{"status"},
+	})
+
+	_ = r.RegisterMetric(&MetricDefinition{
+		Name: metrics.Email.QueueOldestPending,
+		Type: MetricTypeGauge,
+		Help: "Seconds the oldest due pending email has waited, as of the last queue check",
+	})
+
 	// Auth metrics
 	_ = r.RegisterMetric(&MetricDefinition{
 		Name:   metrics.Auth.LoginAttempts,
@@ -314,6 +357,19 @@ func (r *MetricsRegistry) RegisterDefaultMetrics() {
 		Labels: []string{"source"},
 	})
 
+	_ = r.RegisterMetric(&MetricDefinition{
+		Name: metrics.Business.UsersActive,
+		Type: MetricTypeGauge,
+		Help: "Number of active accounts, as of the last user activity count",
+	})
+
+	_ = r.RegisterMetric(&MetricDefinition{
+		Name:   metrics.Business.UsersLoggedIn,
+		Type:   MetricTypeGauge,
+		Help:   "Number of users who logged in within the window (1d or 30d), as of the last user activity count",
+		Labels: []string{"window"},
+	})
+
 	_ = r.RegisterMetric(&MetricDefinition{
 		Name:   metrics.Business.ErrorsTotal,
 		Type:   MetricTypeCounter,
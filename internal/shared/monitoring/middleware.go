@@ -9,14 +9,15 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/acheevo/tfa/internal/shared/config"
+	emaildomain "github.com/acheevo/tfa/internal/shared/email/domain"
 	"github.com/acheevo/tfa/internal/shared/monitoring/metrics"
 )
 
-// MonitoringMiddleware provides comprehensive monitoring for HTTP requests
+// MonitoringMiddleware records request counts, durations, sizes and in-flight requests.
+// Requests are logged by the request logger, so this only records metrics.
 func MonitoringMiddleware(
 	config *config.Config,
 	metricsCollector metrics.MetricsCollector,
-	logger *slog.Logger,
 ) gin.HandlerFunc {
 	if !config.MetricsEnabled {
 		return func(c *gin.Context) { c.Next() }
@@ -26,7 +27,11 @@ func MonitoringMiddleware(
 
 	return gin.HandlerFunc(func(c *gin.Context) {
 		start := time.Now()
-		path := c.Request.URL.Path
+		// Label by route template rather than raw path so IDs don't create a series per request
+		path := c.FullPath()
+		if path == "" {
+			path = "unmatched"
+		}
 		method := c.Request.Method
 
 		// Increment in-flight requests
@@ -65,28 +70,6 @@ func MonitoringMiddleware(
 			"method":   method,
 			"endpoint": path,
 		})
-
-		// Enhanced logging for monitoring
-		logLevel := slog.LevelInfo
-		if c.Writer.Status() >= 400 {
-			logLevel = slog.LevelWarn
-		}
-		if c.Writer.Status() >= 500 {
-			logLevel = slog.LevelError
-		}
-
-		logger.Log(c.Request.Context(), logLevel, "HTTP request completed",
-			"method", method,
-			"path", path,
-			"status", status,
-			"duration", duration.String(),
-			"request_size", c.Request.ContentLength,
-			"response_size", responseSize,
-			"ip", c.ClientIP(),
-			"user_agent", c.Request.UserAgent(),
-			"trace_id", c.GetString("trace_id"),
-			"request_id", c.GetString("request_id"),
-		)
 	})
 }
 
@@ -146,6 +129,37 @@ func (e *EmailMetricsRecorder) RecordEmailFailed(provider, template, reason stri
 	_ = e.metricsCollector.IncrementCounter(e.defaultMetrics.Email.EmailsFailed, labels)
 }
 
+// RecordEmailsRetried records failed emails requeued by the retry sweep
+func (e *EmailMetricsRecorder) RecordEmailsRetried(count int64) {
+	_ = e.metricsCollector.IncrementCounterBy(e.defaultMetrics.Email.EmailsRetried, float64(count), nil)
+}
+
+// RecordQueueStats publishes a snapshot of the email queue: the depth of each status and how long
+// the oldest due pending email has waited, zero when none is waiting
+func (e *EmailMetricsRecorder) RecordQueueStats(stats *emaildomain.QueueStats, now time.Time) {
+	depths := map[emaildomain.EmailStatus]int64{
+		emaildomain.StatusPending:  stats.Pending,
+		emaildomain.StatusSending:  stats.Sending,
+		emaildomain.StatusSent:     stats.Sent,
+		emaildomain.StatusFailed:   stats.Failed,
+		emaildomain.StatusRetrying: stats.Retrying,
+	}
+	for status, depth := range depths {
+		_ = e.metricsCollector.SetGauge(e.defaultMetrics.Email.QueueDepth, float64(depth), map[string]string{
+			"status": string(status),
+		})
+	}
+	_ = e.metricsCollector.SetGauge(e.defaultMetrics.Email.QueueDepth, float64(stats.Scheduled), map[string]string{
+		"status": "scheduled",
+	})
+
+	var oldest float64
+	if stats.OldestPendingAt != nil && now.After(*stats.OldestPendingAt) {
+		oldest = now.Sub(*stats.OldestPendingAt).Seconds()
+	}
+	_ = e.metricsCollector.SetGauge(e.defaultMetrics.Email.QueueOldestPending, oldest, nil)
+}
+
 // RecordEmailQueued records an email being queued
 func (e *EmailMetricsRecorder) RecordEmailQueued(priority string) {
 	labels := map[string]string{
@@ -251,6 +265,15 @@ func (b *BusinessMetricsRecorder) RecordActiveUsers(count float64) {
 	_ = b.metricsCollector.SetGauge(b.defaultMetrics.Business.UsersActive, count, nil)
 }
 
+// RecordLoggedInUsers records how many users logged in within a window, such as "1d" for daily actives
+func (b *BusinessMetricsRecorder) RecordLoggedInUsers(window string, count float64) {
+	labels := map[string]string{
+		"window": window,
+	}
+
+	_ = b.metricsCollector.SetGauge(b.defaultMetrics.Business.UsersLoggedIn, count, labels)
+}
+
 // RecordUserSession records a user session
 func (b *BusinessMetricsRecorder) RecordUserSession(sessionType string) {
 	labels := map[string]string{
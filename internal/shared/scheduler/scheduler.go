@@ -0,0 +1,96 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job is a unit of background work run at a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs registered jobs periodically until stopped
+type Scheduler struct {
+	logger *slog.Logger
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a new scheduler
+func New(logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		logger: logger,
+	}
+}
+
+// Register adds a job. Jobs with a non-positive interval are disabled and ignored.
+func (s *Scheduler) Register(job Job) {
+	if job.Interval <= 0 {
+		s.logger.Info("scheduled job disabled", "job", job.Name)
+		return
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every registered job in its own goroutine. Each job runs once immediately
+// and then on every tick of its interval.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+
+	s.logger.Info("scheduler started", "jobs", len(s.jobs))
+}
+
+// Stop cancels all jobs and waits for running ones to return
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// loop runs a job until the context is canceled
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.runOnce(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce runs a job, logging failures and recovering from panics so one bad run
+// does not stop the schedule
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("scheduled job panicked", "job", job.Name, "panic", r)
+		}
+	}()
+
+	start := time.Now()
+	if err := job.Run(ctx); err != nil {
+		s.logger.Error("scheduled job failed", "job", job.Name, "error", err)
+		return
+	}
+	s.logger.Debug("scheduled job completed", "job", job.Name, "duration", time.Since(start))
+}
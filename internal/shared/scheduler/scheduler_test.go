@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerRunsJobsUntilStopped(t *testing.T) {
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var runs atomic.Int32
+	s.Register(Job{
+		Name:     "counter",
+		Interval: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+
+	s.Start(context.Background())
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	s.Stop()
+
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load())
+}
+
+func TestSchedulerSurvivesFailingJobs(t *testing.T) {
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var runs atomic.Int32
+	s.Register(Job{
+		Name:     "flaky",
+		Interval: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			if runs.Add(1) == 1 {
+				panic("boom")
+			}
+			return errors.New("still failing")
+		},
+	})
+
+	s.Start(context.Background())
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	s.Stop()
+}
+
+func TestSchedulerIgnoresDisabledJobs(t *testing.T) {
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.Register(Job{Name: "disabled", Interval: 0, Run: func(ctx context.Context) error { return nil }})
+
+	assert.Empty(t, s.jobs)
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+// Storage errors
+var (
+	ErrInvalidKey = errors.New("invalid storage key")
+	ErrKeyTooLong = errors.New("storage key is too long")
+	ErrNotFound   = errors.New("stored file not found")
+)
+
+// keySegment is one "/"-separated part of a key. Segments start with a letter or digit, so "."
+// and ".." are never valid, and backslashes, NUL and other separators are rejected outright.
+var keySegment = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// extension matches the file extensions kept from client filenames
+var extension = regexp.MustCompile(`^\.[a-z0-9]{1,10}$`)
+
+// Local stores files on disk under a single base directory. Keys are generated server-side,
+// validated, and resolved so that no key can reach outside the base directory.
+type Local struct {
+	basePath     string
+	maxKeyLength int
+}
+
+// NewLocal creates local storage rooted at the configured LocalStoragePath, creating it if needed
+func NewLocal(cfg *config.Config) (*Local, error) {
+	basePath, err := filepath.Abs(cfg.LocalStoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve storage path: %w", err)
+	}
+	if err := os.MkdirAll(basePath, 0o750); err != nil {
+		return nil, fmt.Errorf("create storage path: %w", err)
+	}
+	// Resolve symlinks once so confinement checks compare real paths
+	basePath, err = filepath.EvalSymlinks(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve storage path: %w", err)
+	}
+
+	maxKeyLength := cfg.StorageMaxKeyLength
+	if maxKeyLength <= 0 {
+		maxKeyLength = 255
+	}
+
+	return &Local{
+		basePath:     basePath,
+		maxKeyLength: maxKeyLength,
+	}, nil
+}
+
+// GenerateKey returns a new random key under prefix (e.g. "avatars/42"). Only a sanitized extension
+// is kept from the client's filename; the rest of the name is never used.
+func (l *Local) GenerateKey(prefix, filename string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("generate storage key: %w", err)
+	}
+
+	name := hex.EncodeToString(random)
+	if ext := strings.ToLower(filepath.Ext(filename)); extension.MatchString(ext) {
+		name += ext
+	}
+
+	key := name
+	if prefix != "" {
+		key = strings.Trim(prefix, "/") + "/" + name
+	}
+	if err := l.ValidateKey(key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// ValidateKey checks a key is short enough and made only of safe, relative path segments
+func (l *Local) ValidateKey(key string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	if len(key) > l.maxKeyLength {
+		return ErrKeyTooLong
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if !keySegment.MatchString(segment) {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// Put writes the contents of r to key, replacing any existing file atomically
+func (l *Local) Put(key string, r io.Reader) error {
+	path, err := l.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	// Check before creating anything, so a symlinked directory can't get new directories made through it
+	dir := filepath.Dir(path)
+	if err := l.checkConfined(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("create storage directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Open opens the file stored at key
+func (l *Local) Open(key string) (io.ReadCloser, error) {
+	path, err := l.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.checkConfined(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes the file stored at key; deleting a missing file is not an error
+func (l *Local) Delete(key string) error {
+	path, err := l.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := l.checkConfined(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// resolve validates key and returns its absolute path inside the base directory
+func (l *Local) resolve(key string) (string, error) {
+	if err := l.ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(l.basePath, filepath.FromSlash(key))
+	if !l.contains(path) {
+		return "", ErrInvalidKey
+	}
+	return path, nil
+}
+
+// checkConfined resolves symlinks in dir, or its nearest existing ancestor, and rejects it if the
+// real path lies outside the base directory
+func (l *Local) checkConfined(dir string) error {
+	for {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if errors.Is(err, os.ErrNotExist) && l.contains(filepath.Dir(dir)) {
+			dir = filepath.Dir(dir)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !l.contains(resolved) {
+			return ErrInvalidKey
+		}
+		return nil
+	}
+}
+
+// contains reports whether path is inside the base directory
+func (l *Local) contains(path string) bool {
+	rel, err := filepath.Rel(l.basePath, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+)
+
+func newTestStorage(t *testing.T) (*Local, string) {
+	dir := t.TempDir()
+	local, err := NewLocal(&config.Config{LocalStoragePath: filepath.Join(dir, "uploads"), StorageMaxKeyLength: 64})
+	assert.NoError(t, err)
+	return local, dir
+}
+
+func TestGenerateKey(t *testing.T) {
+	local, _ := newTestStorage(t)
+
+	key, err := local.GenerateKey("avatars/42", "Me At The Beach.JPG")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "avatars/42/"))
+	assert.True(t, strings.HasSuffix(key, ".jpg"))
+	assert.NotContains(t, key, "Beach")
+
+	// Nothing from a hostile filename survives except a clean extension
+	key, err = local.GenerateKey("avatars", "../../etc/passwd")
+	assert.NoError(t, err)
+	assert.NotContains(t, key, "..")
+	assert.NotContains(t, key, "passwd")
+
+	key, err = local.GenerateKey("avatars", "shell.php%00.png")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(key, ".png"))
+
+	other, err := local.GenerateKey("avatars", "shell.php%00.png")
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	_, err = local.GenerateKey("../outside", "a.png")
+	assert.Equal(t, ErrInvalidKey, err)
+}
+
+func TestValidateKeyRejectsTraversal(t *testing.T) {
+	local, _ := newTestStorage(t)
+
+	for _, key := range []string{
+		"",
+		"../secret",
+		"avatars/../../secret",
+		"avatars/./file",
+		"/etc/passwd",
+		"avatars//file",
+		"avatars\\..\\secret",
+		"..",
+		".hidden",
+		"avatars/file\x00.png",
+		"avatars/file name.png",
+	} {
+		assert.Equal(t, ErrInvalidKey, local.ValidateKey(key), "key %q", key)
+	}
+
+	assert.NoError(t, local.ValidateKey("avatars/42/3f2a.png"))
+	assert.Equal(t, ErrKeyTooLong, local.ValidateKey(strings.Repeat("a", 65)))
+}
+
+func TestPutOpenDelete(t *testing.T) {
+	local, _ := newTestStorage(t)
+
+	key, err := local.GenerateKey("avatars/1", "me.png")
+	assert.NoError(t, err)
+	assert.NoError(t, local.Put(key, strings.NewReader("image")))
+
+	file, err := local.Open(key)
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, "image", string(content))
+
+	assert.NoError(t, local.Delete(key))
+	_, err = local.Open(key)
+	assert.Equal(t, ErrNotFound, err)
+	assert.NoError(t, local.Delete(key))
+}
+
+func TestPutRejectsTraversal(t *testing.T) {
+	local, dir := newTestStorage(t)
+
+	for _, key := range []string{"../escaped", "avatars/../../escaped"} {
+		assert.Equal(t, ErrInvalidKey, local.Put(key, strings.NewReader("x")))
+		_, err := local.Open(key)
+		assert.Equal(t, ErrInvalidKey, err)
+		assert.Equal(t, ErrInvalidKey, local.Delete(key))
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "escaped"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPutRejectsSymlinkEscape(t *testing.T) {
+	local, dir := newTestStorage(t)
+
+	outside := filepath.Join(dir, "outside")
+	assert.NoError(t, os.MkdirAll(outside, 0o750))
+	assert.NoError(t, os.Symlink(outside, filepath.Join(dir, "uploads", "linked")))
+
+	assert.Equal(t, ErrInvalidKey, local.Put("linked/file.png", strings.NewReader("x")))
+	assert.Equal(t, ErrInvalidKey, local.Put("linked/nested/file.png", strings.NewReader("x")))
+	entries, _ := os.ReadDir(outside)
+	assert.Empty(t, entries)
+}
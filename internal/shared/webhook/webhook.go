@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/acheevo/tfa/internal/shared/httpclient"
+)
+
+// Headers sent with every delivery
+const (
+	EventHeader     = "X-Webhook-Event"
+	SignatureHeader = "X-Webhook-Signature" // "sha256=" + hex HMAC of the body, when a secret is set
+)
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	Event  string      `json:"event"`
+	SentAt time.Time   `json:"sent_at"`
+	Data   interface{} `json:"data"`
+}
+
+// Sender posts events as JSON to a single endpoint
+type Sender struct {
+	url    string
+	secret string
+	client *httpclient.Client
+}
+
+// NewSender creates a sender for url. With a secret, receivers can check the body with the signature header.
+func NewSender(url, secret string, client *httpclient.Client) *Sender {
+	return &Sender{
+		url:    url,
+		secret: secret,
+		client: client,
+	}
+}
+
+// Send delivers one event. Any 2xx response counts as delivered; deliveries are not retried.
+func (s *Sender) Send(ctx context.Context, event string, data interface{}) error {
+	body, err := json.Marshal(Payload{Event: event, SentAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	if s.secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(s.secret, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook request: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
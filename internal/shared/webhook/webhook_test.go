@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/httpclient"
+)
+
+func newTestSender(url, secret string) *Sender {
+	cfg := &config.Config{HTTPClientTimeout: "1s"}
+	return NewSender(url, secret, httpclient.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), "webhook", nil))
+}
+
+func TestSenderSignsPayload(t *testing.T) {
+	var received Payload
+	var signature, event string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		event = r.Header.Get(EventHeader)
+		signature = r.Header.Get(SignatureHeader)
+		assert.Equal(t, "sha256="+Sign("s3cret", body), signature)
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := newTestSender(server.URL, "s3cret").Send(context.Background(), "security_alert", map[string]string{"type": "mass_deletion"})
+	assert.NoError(t, err)
+	assert.Equal(t, "security_alert", event)
+	assert.Equal(t, "security_alert", received.Event)
+	assert.Equal(t, map[string]interface{}{"type": "mass_deletion"}, received.Data)
+	assert.False(t, received.SentAt.IsZero())
+}
+
+func TestSenderWithoutSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(SignatureHeader))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, newTestSender(server.URL, "").Send(context.Background(), "security_alert", nil))
+}
+
+func TestSenderRejectedDelivery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := newTestSender(server.URL, "").Send(context.Background(), "security_alert", nil)
+	assert.ErrorContains(t, err, "unexpected status 502")
+}
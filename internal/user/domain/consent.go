@@ -0,0 +1,109 @@
+package domain
+
+import "time"
+
+// ConsentType identifies what a user consented to
+type ConsentType string
+
+const (
+	ConsentTermsOfService  ConsentType = "terms_of_service"
+	ConsentPrivacyPolicy   ConsentType = "privacy_policy"
+	ConsentMarketingEmails ConsentType = "marketing_emails"
+)
+
+// ConsentTypes lists every consent type in the order they are reported
+var ConsentTypes = []ConsentType{ConsentTermsOfService, ConsentPrivacyPolicy, ConsentMarketingEmails}
+
+// Where a consent decision was made
+const (
+	ConsentSourceRegistration = "registration"
+	ConsentSourcePreferences  = "preferences"
+	ConsentSourceAPI          = "api"
+)
+
+// IsRequired reports whether the consent is a condition of having an account. Required consents
+// can be accepted again for a newer policy version but not withdrawn; deleting the account is the
+// way out.
+func (t ConsentType) IsRequired() bool {
+	return t == ConsentTermsOfService || t == ConsentPrivacyPolicy
+}
+
+// UserConsent is one recorded consent decision. Records are append-only, so the table is the full
+// history of what a user agreed to, which policy version and when.
+type UserConsent struct {
+	ID        uint        `json:"id" gorm:"primarykey"`
+	UserID    uint        `json:"-" gorm:"not null;index"`
+	Type      ConsentType `json:"type" gorm:"not null"`
+	Granted   bool        `json:"granted" gorm:"not null"`
+	Version   string      `json:"version" gorm:"not null"`
+	Source    string      `json:"source" gorm:"not null"`
+	IPAddress string      `json:"ip_address,omitempty"`
+	UserAgent string      `json:"user_agent,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ConsentPolicy holds the current version of each policy a consent refers to
+type ConsentPolicy struct {
+	TermsVersion   string
+	PrivacyVersion string
+}
+
+// Version returns the current policy version for a consent type. Marketing consent is governed by
+// the privacy policy.
+func (p ConsentPolicy) Version(t ConsentType) string {
+	if t == ConsentTermsOfService {
+		return p.TermsVersion
+	}
+	return p.PrivacyVersion
+}
+
+// UpdateConsentRequest represents a consent being granted or withdrawn by the user
+type UpdateConsentRequest struct {
+	Type    ConsentType `json:"type" binding:"required,oneof=terms_of_service privacy_policy marketing_emails"`
+	Granted *bool       `json:"granted" binding:"required"`
+}
+
+// ConsentStatus is the current state of one consent type
+type ConsentStatus struct {
+	Type           ConsentType `json:"type"`
+	Granted        bool        `json:"granted"`
+	Version        string      `json:"version,omitempty"`
+	CurrentVersion string      `json:"current_version"`
+	UpToDate       bool        `json:"up_to_date"` // granted for the current policy version
+	RecordedAt     *time.Time  `json:"recorded_at,omitempty"`
+}
+
+// ConsentRecordResponse is a user's consent record: the current state of every consent and the
+// full history behind it, newest first
+type ConsentRecordResponse struct {
+	Consents []ConsentStatus `json:"consents"`
+	History  []*UserConsent  `json:"history"`
+}
+
+// NewConsentRecord builds the consent record from a user's history, which must be ordered newest first
+func NewConsentRecord(history []*UserConsent, policy ConsentPolicy) *ConsentRecordResponse {
+	latest := make(map[ConsentType]*UserConsent, len(ConsentTypes))
+	for _, consent := range history {
+		if _, ok := latest[consent.Type]; !ok {
+			latest[consent.Type] = consent
+		}
+	}
+
+	consents := make([]ConsentStatus, 0, len(ConsentTypes))
+	for _, consentType := range ConsentTypes {
+		status := ConsentStatus{Type: consentType, CurrentVersion: policy.Version(consentType)}
+		if consent, ok := latest[consentType]; ok {
+			status.Granted = consent.Granted
+			status.Version = consent.Version
+			status.UpToDate = consent.Granted && consent.Version == status.CurrentVersion
+			recordedAt := consent.CreatedAt
+			status.RecordedAt = &recordedAt
+		}
+		consents = append(consents, status)
+	}
+
+	if history == nil {
+		history = []*UserConsent{}
+	}
+	return &ConsentRecordResponse{Consents: consents, History: history}
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsentPolicyVersion(t *testing.T) {
+	policy := ConsentPolicy{TermsVersion: "2024-06", PrivacyVersion: "3"}
+
+	assert.Equal(t, "2024-06", policy.Version(ConsentTermsOfService))
+	assert.Equal(t, "3", policy.Version(ConsentPrivacyPolicy))
+	assert.Equal(t, "3", policy.Version(ConsentMarketingEmails))
+
+	assert.True(t, ConsentTermsOfService.IsRequired())
+	assert.True(t, ConsentPrivacyPolicy.IsRequired())
+	assert.False(t, ConsentMarketingEmails.IsRequired())
+}
+
+func TestNewConsentRecord(t *testing.T) {
+	policy := ConsentPolicy{TermsVersion: "2", PrivacyVersion: "1"}
+	registered := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	optedOut := registered.Add(48 * time.Hour)
+
+	// Newest first, as the repository returns it
+	history := []*UserConsent{
+		{ID: 4, Type: ConsentMarketingEmails, Granted: false, Version: "1", CreatedAt: optedOut},
+		{ID: 3, Type: ConsentMarketingEmails, Granted: true, Version: "1", CreatedAt: registered},
+		{ID: 2, Type: ConsentPrivacyPolicy, Granted: true, Version: "1", CreatedAt: registered},
+		{ID: 1, Type: ConsentTermsOfService, Granted: true, Version: "1", CreatedAt: registered},
+	}
+
+	record := NewConsentRecord(history, policy)
+	assert.Len(t, record.History, 4)
+	assert.Len(t, record.Consents, len(ConsentTypes))
+
+	terms := record.Consents[0]
+	assert.Equal(t, ConsentTermsOfService, terms.Type)
+	assert.True(t, terms.Granted)
+	assert.Equal(t, "1", terms.Version)
+	assert.Equal(t, "2", terms.CurrentVersion)
+	// Accepted, but for an older version of the terms
+	assert.False(t, terms.UpToDate)
+
+	privacy := record.Consents[1]
+	assert.True(t, privacy.UpToDate)
+	assert.Equal(t, registered, *privacy.RecordedAt)
+
+	marketing := record.Consents[2]
+	assert.False(t, marketing.Granted)
+	assert.False(t, marketing.UpToDate)
+	assert.Equal(t, optedOut, *marketing.RecordedAt)
+}
+
+func TestNewConsentRecordWithoutHistory(t *testing.T) {
+	record := NewConsentRecord(nil, ConsentPolicy{TermsVersion: "1", PrivacyVersion: "1"})
+
+	assert.NotNil(t, record.History)
+	assert.Empty(t, record.History)
+	for _, status := range record.Consents {
+		assert.False(t, status.Granted)
+		assert.Nil(t, status.RecordedAt)
+		assert.Equal(t, "1", status.CurrentVersion)
+	}
+}
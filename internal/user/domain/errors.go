@@ -14,6 +14,11 @@ var (
 	ErrInvalidPreferences    = errors.New("invalid preferences")
 	ErrPreferencesNotFound   = errors.New("preferences not found")
 	ErrProfileUpdateFailed   = errors.New("profile update failed")
+	ErrProfileModified       = errors.New("profile was modified since it was read")
+	ErrFieldNotUpdatable     = errors.New("field cannot be updated through this endpoint")
+	ErrNoProfileChanges      = errors.New("no profile changes provided")
+	ErrInvalidSort           = errors.New("invalid sort")
+	ErrRequiredConsent       = errors.New("required consent cannot be withdrawn")
 )
 
 // IsUserError checks if the error is a user management error
@@ -27,5 +32,10 @@ func IsUserError(err error) bool {
 		err == ErrCannotUpdateOwnStatus ||
 		err == ErrInvalidPreferences ||
 		err == ErrPreferencesNotFound ||
-		err == ErrProfileUpdateFailed
+		err == ErrProfileUpdateFailed ||
+		err == ErrProfileModified ||
+		err == ErrFieldNotUpdatable ||
+		err == ErrNoProfileChanges ||
+		err == ErrInvalidSort ||
+		err == ErrRequiredConsent
 }
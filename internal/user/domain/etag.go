@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProfileETag returns the entity tag for a user profile, derived from its last update time
+func ProfileETag(userID uint, updatedAt time.Time) string {
+	return fmt.Sprintf(`"%d-%d"`, userID, updatedAt.UnixMicro())
+}
+
+// ETagMatches reports whether an If-Match header value matches the current entity tag.
+// Weak validators are compared by their opaque value.
+func ETagMatches(ifMatch, current string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(current, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// SameUpdateTime compares update timestamps at the database's microsecond precision
+func SameUpdateTime(a, b time.Time) bool {
+	return a.Truncate(time.Microsecond).Equal(b.Truncate(time.Microsecond))
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileETag(t *testing.T) {
+	updatedAt := time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.UTC)
+
+	etag := ProfileETag(42, updatedAt)
+	assert.Equal(t, `"42-1704110400123456"`, etag)
+
+	// Sub-microsecond differences are not visible after a database round trip
+	assert.Equal(t, etag, ProfileETag(42, updatedAt.Truncate(time.Microsecond)))
+	assert.NotEqual(t, etag, ProfileETag(42, updatedAt.Add(time.Microsecond)))
+}
+
+func TestETagMatches(t *testing.T) {
+	current := `"42-1704110400123456"`
+
+	assert.True(t, ETagMatches(current, current))
+	assert.True(t, ETagMatches(`W/"42-1704110400123456"`, current))
+	assert.True(t, ETagMatches(`"1-1", "42-1704110400123456"`, current))
+	assert.True(t, ETagMatches("*", current))
+	assert.False(t, ETagMatches(`"42-1704110400000000"`, current))
+	assert.False(t, ETagMatches(`42-1704110400123456`, current))
+}
+
+func TestUpdateSelfRequest(t *testing.T) {
+	name := "Jane"
+	email := "jane@example.com"
+
+	assert.False(t, (&UpdateSelfRequest{}).HasChanges())
+	assert.True(t, (&UpdateSelfRequest{FirstName: &name}).HasChanges())
+	assert.False(t, (&UpdateSelfRequest{FirstName: &name}).HasRestrictedFields())
+	assert.True(t, (&UpdateSelfRequest{Email: &email}).HasRestrictedFields())
+}
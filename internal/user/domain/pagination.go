@@ -0,0 +1,34 @@
+package domain
+
+// CountMode is how a listing computes its total
+type CountMode string
+
+const (
+	// CountExact counts every matching row
+	CountExact CountMode = "exact"
+	// CountEstimated takes the planner's row estimate for unfiltered listings of large tables; filtered
+	// listings are still counted exactly
+	CountEstimated CountMode = "estimated"
+	// CountNone skips the total, for clients that only page forward
+	CountNone CountMode = "none"
+)
+
+// PageCount is what a listing query learned about the rows around the page it returned
+type PageCount struct {
+	Mode    CountMode
+	Total   int  // exact, or the estimate under CountEstimated; zero under CountNone
+	HasNext bool // whether another page follows; taken from an extra row whenever Total isn't exact
+}
+
+// ExactPageCount is the count of a listing whose total was counted, so whether a page follows is known
+func ExactPageCount(page, pageSize, total int) PageCount {
+	return PageCount{Mode: CountExact, Total: total, HasNext: page*pageSize < total}
+}
+
+// TrimPage drops the extra row a query fetched past a page of pageSize, reporting whether there was one
+func TrimPage[T any](rows []T, pageSize int) ([]T, bool) {
+	if len(rows) > pageSize {
+		return rows[:pageSize], true
+	}
+	return rows, false
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimPage(t *testing.T) {
+	// A full page plus the extra row: another page follows
+	rows, hasNext := TrimPage([]int{1, 2, 3, 4}, 3)
+	assert.Equal(t, []int{1, 2, 3}, rows)
+	assert.True(t, hasNext)
+
+	// Exactly a page: the extra row wasn't there, so this is the last page
+	rows, hasNext = TrimPage([]int{1, 2, 3}, 3)
+	assert.Equal(t, []int{1, 2, 3}, rows)
+	assert.False(t, hasNext)
+
+	rows, hasNext = TrimPage([]int{1}, 3)
+	assert.Equal(t, []int{1}, rows)
+	assert.False(t, hasNext)
+
+	rows, hasNext = TrimPage([]int{}, 3)
+	assert.Empty(t, rows)
+	assert.False(t, hasNext)
+}
+
+func TestExactPageCount(t *testing.T) {
+	assert.Equal(t, PageCount{Mode: CountExact, Total: 45, HasNext: true}, ExactPageCount(2, 20, 45))
+	assert.False(t, ExactPageCount(3, 20, 45).HasNext)
+	assert.False(t, ExactPageCount(2, 20, 40).HasNext)
+}
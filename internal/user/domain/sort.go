@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// Sort is a sort field checked against an endpoint's allowlist, so it is safe to use in ORDER BY
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Sortable fields and defaults for the user listing
+var (
+	UserSortFields  = []string{"created_at", "email", "last_login_at"}
+	DefaultUserSort = Sort{Field: "created_at", Desc: true}
+)
+
+// ParseSort parses a "field:asc|desc" sort spec, returning fallback for an empty spec and
+// ErrInvalidSort for a malformed spec or a field outside the allowlist. The direction defaults to asc.
+func ParseSort(spec string, allowed []string, fallback Sort) (Sort, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return fallback, nil
+	}
+
+	field, direction, _ := strings.Cut(spec, ":")
+	if !slices.Contains(allowed, field) {
+		return Sort{}, ErrInvalidSort
+	}
+
+	switch strings.ToLower(direction) {
+	case "", "asc":
+		return Sort{Field: field}, nil
+	case "desc":
+		return Sort{Field: field, Desc: true}, nil
+	default:
+		return Sort{}, ErrInvalidSort
+	}
+}
+
+// OrderClause returns the ORDER BY clause for the sort, breaking ties by id so pages are stable
+func (s Sort) OrderClause() string {
+	direction := "ASC"
+	if s.Desc {
+		direction = "DESC"
+	}
+	return s.Field + " " + direction + ", id " + direction
+}
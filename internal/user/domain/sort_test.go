@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected Sort
+		err      error
+	}{
+		{"", DefaultUserSort, nil},
+		{"email", Sort{Field: "email"}, nil},
+		{"email:asc", Sort{Field: "email"}, nil},
+		{"last_login_at:DESC", Sort{Field: "last_login_at", Desc: true}, nil},
+		{"password_hash:asc", Sort{}, ErrInvalidSort},
+		{"email:sideways", Sort{}, ErrInvalidSort},
+		{"email; DROP TABLE users", Sort{}, ErrInvalidSort},
+		{"email:asc:desc", Sort{}, ErrInvalidSort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sort, err := ParseSort(tt.spec, UserSortFields, DefaultUserSort)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.expected, sort)
+		})
+	}
+}
+
+func TestSortOrderClause(t *testing.T) {
+	assert.Equal(t, "created_at DESC, id DESC", DefaultUserSort.OrderClause())
+	assert.Equal(t, "email ASC, id ASC", Sort{Field: "email"}.OrderClause())
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/geoip"
 )
 
 // User management DTOs
@@ -15,6 +16,32 @@ type UpdateProfileRequest struct {
 	Avatar    string `json:"avatar" binding:"omitempty,url"`
 }
 
+// UpdateSelfRequest represents a partial self-service profile update. Nil fields are left unchanged.
+// Email, role and status are accepted only so that attempts to change them are rejected explicitly;
+// email changes go through the verified change-email flow. A username is checked against the username
+// policy.
+type UpdateSelfRequest struct {
+	FirstName *string    `json:"first_name" binding:"omitempty,min=1,max=50"`
+	LastName  *string    `json:"last_name" binding:"omitempty,min=1,max=50"`
+	Avatar    *string    `json:"avatar" binding:"omitempty,url"`
+	Username  *string    `json:"username"`
+	UpdatedAt *time.Time `json:"updated_at"`
+
+	Email  *string `json:"email"`
+	Role   *string `json:"role"`
+	Status *string `json:"status"`
+}
+
+// HasChanges reports whether the request updates any field
+func (r *UpdateSelfRequest) HasChanges() bool {
+	return r.FirstName != nil || r.LastName != nil || r.Avatar != nil || r.Username != nil
+}
+
+// HasRestrictedFields reports whether the request tries to change a field that is not self-updatable
+func (r *UpdateSelfRequest) HasRestrictedFields() bool {
+	return r.Email != nil || r.Role != nil || r.Status != nil
+}
+
 // UpdatePreferencesRequest represents a user preferences update request
 type UpdatePreferencesRequest struct {
 	Theme         string                       `json:"theme" binding:"omitempty,oneof=light dark system"`
@@ -33,13 +60,18 @@ type ChangeEmailRequest struct {
 
 // UserListRequest represents a request to list users with filtering and pagination
 type UserListRequest struct {
-	Page      int                   `form:"page,default=1" binding:"min=1"`
-	PageSize  int                   `form:"page_size,default=20" binding:"min=1,max=100"`
-	Search    string                `form:"search"`
-	Role      authdomain.UserRole   `form:"role" binding:"omitempty,oneof=user admin"`
-	Status    authdomain.UserStatus `form:"status" binding:"omitempty,oneof=active inactive suspended"`
-	SortBy    string                `form:"sort_by,default=created_at" binding:"omitempty"`
-	SortOrder string                `form:"sort_order,default=desc" binding:"omitempty,oneof=asc desc"`
+	Page     int                   `form:"page,default=1" binding:"min=1"`
+	PageSize int                   `form:"page_size,default=20" binding:"min=1,max=100"`
+	Search   string                `form:"search"`
+	Role     authdomain.UserRole   `form:"role" binding:"omitempty,oneof=user admin super_admin"`
+	Status   authdomain.UserStatus `form:"status" binding:"omitempty,oneof=active inactive suspended pending"`
+	Sort     string                `form:"sort"` // "field:asc|desc", see UserSortFields
+	Count    CountMode             `form:"count" binding:"omitempty,oneof=exact estimated none"`
+}
+
+// Filtered reports whether the listing is narrowed by any filter, so can't use an estimated total
+func (r *UserListRequest) Filtered() bool {
+	return r.Search != "" || r.Role != "" || r.Status != ""
 }
 
 // UserListResponse represents the response for user list requests
@@ -87,6 +119,7 @@ type AuditLogEntry struct {
 	Resource    string                 `json:"resource"`
 	Description string                 `json:"description"`
 	IPAddress   string                 `json:"ip_address"`
+	Location    *geoip.Location        `json:"location,omitempty"`
 	UserAgent   string                 `json:"user_agent"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 	CreatedAt   time.Time              `json:"created_at"`
@@ -100,6 +133,9 @@ type Pagination struct {
 	TotalPages int  `json:"total_pages"`
 	HasNext    bool `json:"has_next"`
 	HasPrev    bool `json:"has_prev"`
+
+	// How Total was computed, on listings that can skip the exact count
+	Count CountMode `json:"count,omitempty"`
 }
 
 // Dashboard response types
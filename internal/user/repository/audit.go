@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"log/slog"
 	"strings"
 	"time"
 
@@ -8,17 +9,23 @@ import (
 
 	admindomain "github.com/acheevo/tfa/internal/admin/domain"
 	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/user/domain"
 )
 
 // AuditRepository handles audit log database operations
 type AuditRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	policy *authdomain.AuditPolicy
+	logger *slog.Logger
 }
 
-// NewAuditRepository creates a new audit repository
-func NewAuditRepository(db *gorm.DB) *AuditRepository {
+// NewAuditRepository creates a new audit repository. A nil policy records every action with its
+// full metadata. The logger, when not nil, reports metadata truncated by the policy.
+func NewAuditRepository(db *gorm.DB, policy *authdomain.AuditPolicy, logger *slog.Logger) *AuditRepository {
 	return &AuditRepository{
-		db: db,
+		db:     db,
+		policy: policy,
+		logger: logger,
 	}
 }
 
@@ -39,6 +46,15 @@ func (r *AuditRepository) CreateAuditEntry(
 	userAgent string,
 	metadata map[string]interface{},
 ) error {
+	if !r.policy.ShouldRecord(action, level) {
+		return nil
+	}
+
+	metadata, size, truncated := r.policy.FitMetadata(metadata)
+	if truncated && r.logger != nil {
+		r.logger.Warn("audit metadata truncated", "action", action, "original_bytes", size)
+	}
+
 	log := &authdomain.AuditLog{
 		UserID:      userID,
 		TargetID:    targetID,
@@ -55,10 +71,12 @@ func (r *AuditRepository) CreateAuditEntry(
 	return r.Create(log)
 }
 
-// List retrieves audit logs with filtering and pagination
-func (r *AuditRepository) List(req *admindomain.AdminAuditLogRequest) ([]*authdomain.AuditLog, int, error) {
-	var logs []*authdomain.AuditLog
-	var total int64
+// List retrieves audit logs with filtering and pagination, totalled as req.Count asks
+func (r *AuditRepository) List(req *admindomain.AdminAuditLogRequest) ([]*authdomain.AuditLog, domain.PageCount, error) {
+	sort, err := domain.ParseSort(req.Sort, admindomain.AuditSortFields, admindomain.DefaultAuditSort)
+	if err != nil {
+		return nil, domain.PageCount{}, err
+	}
 
 	query := r.db.Model(&authdomain.AuditLog{}).Preload("User").Preload("Target")
 
@@ -97,18 +115,17 @@ func (r *AuditRepository) List(req *admindomain.AdminAuditLogRequest) ([]*authdo
 		query = query.Where("created_at <= ?", endOfDay)
 	}
 
-	// Count total records
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	// A cursor resumes the newest-first listing past its position, without counting or offsets
+	if req.After != nil {
+		query = query.Where("(created_at, id) < (?, ?)", req.After.CreatedAt, req.After.ID)
+		return findPage[*authdomain.AuditLog](
+			r.db, query, "audit_logs", true, domain.CountNone, 1, req.PageSize, sort.OrderClause(),
+		)
 	}
 
-	// Apply pagination and sorting
-	offset := (req.Page - 1) * req.PageSize
-	if err := query.Order("created_at DESC").Offset(offset).Limit(req.PageSize).Find(&logs).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return logs, int(total), nil
+	return findPage[*authdomain.AuditLog](
+		r.db, query, "audit_logs", req.Filtered(), req.Count, req.Page, req.PageSize, sort.OrderClause(),
+	)
 }
 
 // GetByID retrieves an audit log by ID
@@ -140,6 +157,51 @@ func (r *AuditRepository) GetUserAuditHistory(userID uint, limit int) ([]*authdo
 	return logs, err
 }
 
+// ListUserHistory retrieves a page of the audit entries where the user is the actor or the target,
+// newest first
+func (r *AuditRepository) ListUserHistory(
+	userID uint,
+	req *admindomain.UserAuditLogRequest,
+) ([]*authdomain.AuditLog, int, error) {
+	var logs []*authdomain.AuditLog
+	var total int64
+
+	query := r.db.Model(&authdomain.AuditLog{}).Where("user_id = ? OR target_id = ?", userID, userID)
+
+	if req.Action != "" {
+		query = query.Where("action = ?", req.Action)
+	}
+
+	if req.Level != "" {
+		query = query.Where("level = ?", req.Level)
+	}
+
+	if req.Search != "" {
+		query = query.Where("LOWER(description) LIKE ?", "%"+strings.ToLower(req.Search)+"%")
+	}
+
+	if req.DateFrom != nil {
+		query = query.Where("created_at >= ?", *req.DateFrom)
+	}
+
+	if req.DateTo != nil {
+		// Include the whole end day
+		query = query.Where("created_at < ?", req.DateTo.AddDate(0, 0, 1))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(req.PageSize).Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, int(total), nil
+}
+
 // GetRecentLogs retrieves recent audit logs
 func (r *AuditRepository) GetRecentLogs(limit int) ([]*authdomain.AuditLog, error) {
 	var logs []*authdomain.AuditLog
@@ -173,6 +235,132 @@ func (r *AuditRepository) GetLogsByAction(action authdomain.AuditAction, limit i
 	return logs, err
 }
 
+// SummarizeFailedLogins aggregates the failed logins recorded since the given time in the database:
+// totals over every attempt, and the limit IP addresses and accounts with the most failures, most
+// recent first on ties
+func (r *AuditRepository) SummarizeFailedLogins(since time.Time, limit int) (*admindomain.LoginFailureSummary, error) {
+	failures := func() *gorm.DB {
+		return r.db.Model(&authdomain.AuditLog{}).
+			Where("action = ? AND created_at >= ?", authdomain.AuditActionLoginFailed, since)
+	}
+
+	var totals struct {
+		TotalFailures  int
+		UniqueIPs      int
+		UniqueAccounts int
+	}
+	err := failures().
+		Select(`COUNT(*) AS total_failures,
+			COUNT(DISTINCT NULLIF(ip_address, '')) AS unique_ips,
+			COUNT(DISTINCT NULLIF(metadata->>'email', '')) AS unique_accounts`).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &admindomain.LoginFailureSummary{
+		TotalFailures:  totals.TotalFailures,
+		UniqueIPs:      totals.UniqueIPs,
+		UniqueAccounts: totals.UniqueAccounts,
+		TopIPs:         []admindomain.LoginFailureCount{},
+		TopAccounts:    []admindomain.LoginFailureCount{},
+	}
+
+	for column, top := range map[string]*[]admindomain.LoginFailureCount{
+		"ip_address":         &summary.TopIPs,
+		"metadata->>'email'": &summary.TopAccounts,
+	} {
+		var rows []struct {
+			Key         string
+			Failures    int
+			LastAttempt time.Time
+		}
+		err := failures().
+			Select(column + ` AS key, COUNT(*) AS failures, MAX(created_at) AS last_attempt`).
+			Where(column + " <> ''").
+			Group(column).
+			Order("failures DESC, last_attempt DESC, key").
+			Limit(limit).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			*top = append(*top, admindomain.LoginFailureCount{
+				Key:         row.Key,
+				Failures:    row.Failures,
+				LastAttempt: row.LastAttempt,
+			})
+		}
+	}
+	return summary, nil
+}
+
+// GetSecurityActivity counts the admin promotions, failed logins and user deletions audited since the
+// given time. Promotions made through a general user update count as well as dedicated role changes.
+func (r *AuditRepository) GetSecurityActivity(since time.Time) (*admindomain.SecurityActivity, error) {
+	var activity admindomain.SecurityActivity
+	err := r.db.Model(&authdomain.AuditLog{}).
+		Select(`
+			COUNT(*) FILTER (WHERE action IN ?
+				AND metadata->>'new_role' IN ?) AS admin_promotions,
+			COUNT(*) FILTER (WHERE action = ?) AS failed_logins,
+			COUNT(*) FILTER (WHERE action = ?) AS deletions`,
+			[]authdomain.AuditAction{authdomain.AuditActionUserRoleChanged, authdomain.AuditActionUserUpdated},
+			[]authdomain.UserRole{authdomain.RoleAdmin, authdomain.RoleSuperAdmin},
+			authdomain.AuditActionLoginFailed,
+			authdomain.AuditActionUserDeleted,
+		).
+		Where("created_at >= ?", since).
+		Scan(&activity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+// LastAdminActivity returns when each of the users last used admin functions, or was granted an admin
+// role, going by the audit log. Users with neither are left out.
+func (r *AuditRepository) LastAdminActivity(userIDs []uint) (map[uint]time.Time, error) {
+	last := make(map[uint]time.Time, len(userIDs))
+	if len(userIDs) == 0 {
+		return last, nil
+	}
+
+	type activity struct {
+		UserID uint
+		At     time.Time
+	}
+	var used, granted []activity
+
+	// Admin service actions and the admin access audit are both recorded against the admin resource
+	if err := r.db.Model(&authdomain.AuditLog{}).
+		Select("user_id, MAX(created_at) AS at").
+		Where("user_id IN ? AND resource = ?", userIDs, "admin").
+		Group("user_id").
+		Scan(&used).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Model(&authdomain.AuditLog{}).
+		Select("target_id AS user_id, MAX(created_at) AS at").
+		Where("target_id IN ? AND action IN ? AND metadata->>'new_role' IN ?",
+			userIDs,
+			[]authdomain.AuditAction{authdomain.AuditActionUserRoleChanged, authdomain.AuditActionUserUpdated},
+			[]authdomain.UserRole{authdomain.RoleAdmin, authdomain.RoleSuperAdmin}).
+		Group("target_id").
+		Scan(&granted).Error; err != nil {
+		return nil, err
+	}
+
+	for _, a := range append(used, granted...) {
+		if a.At.After(last[a.UserID]) {
+			last[a.UserID] = a.At
+		}
+	}
+	return last, nil
+}
+
 // GetLogsByLevel retrieves logs by severity level
 func (r *AuditRepository) GetLogsByLevel(level authdomain.AuditLevel, limit int) ([]*authdomain.AuditLog, error) {
 	var logs []*authdomain.AuditLog
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/acheevo/tfa/internal/user/domain"
+)
+
+// ConsentRepository stores the append-only history of user consent decisions
+type ConsentRepository struct {
+	db *gorm.DB
+}
+
+// NewConsentRepository creates a new consent repository
+func NewConsentRepository(db *gorm.DB) *ConsentRepository {
+	return &ConsentRepository{
+		db: db,
+	}
+}
+
+// Create records consent decisions
+func (r *ConsentRepository) Create(consents ...*domain.UserConsent) error {
+	if len(consents) == 0 {
+		return nil
+	}
+	return r.db.Create(consents).Error
+}
+
+// ListByUser retrieves a user's consent history, newest first
+func (r *ConsentRepository) ListByUser(userID uint) ([]*domain.UserConsent, error) {
+	var consents []*domain.UserConsent
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&consents).Error
+	return consents, err
+}
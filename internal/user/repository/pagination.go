@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/acheevo/tfa/internal/user/domain"
+)
+
+// estimatedCountMinRows is the planner estimate below which an estimated total is counted exactly
+// anyway; small tables count quickly and their estimates are the least reliable
+const estimatedCountMinRows = 10000
+
+// findPage loads one page of the ordered query and totals it as mode asks. Only an unfiltered
+// listing is estimated, from the planner's row estimate for table, which also counts soft-deleted
+// rows. When the total isn't exact, one extra row is fetched to tell whether another page follows.
+func findPage[T any](
+	db, query *gorm.DB,
+	table string,
+	filtered bool,
+	mode domain.CountMode,
+	page, pageSize int,
+	order string,
+) ([]T, domain.PageCount, error) {
+	if mode == domain.CountEstimated && filtered {
+		mode = domain.CountExact
+	}
+
+	total := 0
+	switch mode {
+	case domain.CountNone:
+	case domain.CountEstimated:
+		var estimate int64
+		if err := db.Raw("SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass(?)", table).
+			Scan(&estimate).Error; err != nil {
+			return nil, domain.PageCount{}, err
+		}
+		// A table never analyzed has no estimate (-1), and a small one is cheap to count
+		if estimate < estimatedCountMinRows {
+			mode = domain.CountExact
+		} else {
+			total = int(estimate)
+		}
+	default:
+		mode = domain.CountExact
+	}
+
+	if mode == domain.CountExact {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, domain.PageCount{}, err
+		}
+		total = int(count)
+	}
+
+	limit := pageSize
+	if mode != domain.CountExact {
+		limit++
+	}
+
+	var rows []T
+	offset := (page - 1) * pageSize
+	if err := query.Order(order).Offset(offset).Limit(limit).Find(&rows).Error; err != nil {
+		return nil, domain.PageCount{}, err
+	}
+
+	if mode == domain.CountExact {
+		return rows, domain.ExactPageCount(page, pageSize, total), nil
+	}
+	rows, hasNext := domain.TrimPage(rows, pageSize)
+	return rows, domain.PageCount{Mode: mode, Total: total, HasNext: hasNext}, nil
+}
@@ -1,12 +1,12 @@
 package repository
 
 import (
-	"fmt"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
 
+	admindomain "github.com/acheevo/tfa/internal/admin/domain"
 	authdomain "github.com/acheevo/tfa/internal/auth/domain"
 	"github.com/acheevo/tfa/internal/user/domain"
 )
@@ -56,6 +56,43 @@ func (r *UserRepository) UpdateProfile(userID uint, req *domain.UpdateProfileReq
 	return r.db.Model(&authdomain.User{}).Where("id = ?", userID).Updates(updates).Error
 }
 
+// UpdateSelf applies a partial self-service profile update. When expectedUpdatedAt is set the
+// update only succeeds if the row has not changed since then; the returned bool reports whether a
+// row was updated.
+func (r *UserRepository) UpdateSelf(
+	userID uint,
+	req *domain.UpdateSelfRequest,
+	expectedUpdatedAt *time.Time,
+) (bool, error) {
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+	}
+
+	if req.FirstName != nil {
+		updates["first_name"] = strings.TrimSpace(*req.FirstName)
+	}
+	if req.LastName != nil {
+		updates["last_name"] = strings.TrimSpace(*req.LastName)
+	}
+	if req.Avatar != nil {
+		updates["avatar"] = strings.TrimSpace(*req.Avatar)
+	}
+	if req.Username != nil {
+		updates["username"] = authdomain.NormalizeUsername(*req.Username)
+	}
+
+	query := r.db.Model(&authdomain.User{}).Where("id = ?", userID)
+	if expectedUpdatedAt != nil {
+		query = query.Where("updated_at = ?", *expectedUpdatedAt)
+	}
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // UpdatePreferences updates a user's preferences
 func (r *UserRepository) UpdatePreferences(userID uint, preferences authdomain.UserPreferences) error {
 	return r.db.Model(&authdomain.User{}).
@@ -76,10 +113,12 @@ func (r *UserRepository) GetPreferences(userID uint) (*authdomain.UserPreference
 	return &user.Preferences, nil
 }
 
-// List retrieves users with filtering and pagination
-func (r *UserRepository) List(req *domain.UserListRequest) ([]*authdomain.User, int, error) {
-	var users []*authdomain.User
-	var total int64
+// List retrieves users with filtering and pagination, totalled as req.Count asks
+func (r *UserRepository) List(req *domain.UserListRequest) ([]*authdomain.User, domain.PageCount, error) {
+	sort, err := domain.ParseSort(req.Sort, domain.UserSortFields, domain.DefaultUserSort)
+	if err != nil {
+		return nil, domain.PageCount{}, err
+	}
 
 	query := r.db.Model(&authdomain.User{})
 
@@ -100,22 +139,9 @@ func (r *UserRepository) List(req *domain.UserListRequest) ([]*authdomain.User,
 		query = query.Where("status = ?", req.Status)
 	}
 
-	// Count total records
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Apply sorting
-	orderClause := fmt.Sprintf("%s %s", req.SortBy, strings.ToUpper(req.SortOrder))
-	query = query.Order(orderClause)
-
-	// Apply pagination
-	offset := (req.Page - 1) * req.PageSize
-	if err := query.Offset(offset).Limit(req.PageSize).Find(&users).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return users, int(total), nil
+	return findPage[*authdomain.User](
+		r.db, query, "users", req.Filtered(), req.Count, req.Page, req.PageSize, sort.OrderClause(),
+	)
 }
 
 // GetUserStats retrieves user statistics for dashboard
@@ -153,7 +179,7 @@ func (r *UserRepository) GetUserStats(userID uint) (*domain.UserStats, error) {
 // UpdateEmail updates a user's email address
 func (r *UserRepository) UpdateEmail(userID uint, newEmail string) error {
 	updates := map[string]interface{}{
-		"email":          strings.ToLower(strings.TrimSpace(newEmail)),
+		"email":          authdomain.NormalizeEmail(newEmail),
 		"email_verified": false, // Reset email verification when email changes
 		"updated_at":     time.Now(),
 	}
@@ -161,10 +187,29 @@ func (r *UserRepository) UpdateEmail(userID uint, newEmail string) error {
 	return r.db.Model(&authdomain.User{}).Where("id = ?", userID).Updates(updates).Error
 }
 
-// CheckEmailExists checks if an email already exists (excluding a specific user ID)
-func (r *UserRepository) CheckEmailExists(email string, excludeUserID uint) (bool, error) {
+// CheckEmailExists checks if an email already exists (excluding a specific user ID), counting
+// soft-deleted users when includeDeleted is set
+func (r *UserRepository) CheckEmailExists(email string, excludeUserID uint, includeDeleted bool) (bool, error) {
+	query := r.db
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+
 	var count int64
-	query := r.db.Model(&authdomain.User{}).Where("email = ?", strings.ToLower(strings.TrimSpace(email)))
+	query = query.Model(&authdomain.User{}).Where("LOWER(email) = ?", authdomain.NormalizeEmail(email))
+	if excludeUserID > 0 {
+		query = query.Where("id != ?", excludeUserID)
+	}
+
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// CheckUsernameExists checks if a username is taken by another user. Deleted accounts count, as the
+// unique index keeps their usernames too.
+func (r *UserRepository) CheckUsernameExists(username string, excludeUserID uint) (bool, error) {
+	var count int64
+	query := r.db.Unscoped().Model(&authdomain.User{}).Where("username = ?", authdomain.NormalizeUsername(username))
 	if excludeUserID > 0 {
 		query = query.Where("id != ?", excludeUserID)
 	}
@@ -187,6 +232,16 @@ func (r *UserRepository) UpdateUserRole(userID uint, role authdomain.UserRole) e
 		Update("role", role).Error
 }
 
+// UpdateEmailVerification sets a user's email verification flag, clearing any pending verification token
+func (r *UserRepository) UpdateEmailVerification(userID uint, verified bool) error {
+	return r.db.Model(&authdomain.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"email_verified":     verified,
+			"email_verify_token": "",
+		}).Error
+}
+
 // UpdateUserStatus updates a user's status
 func (r *UserRepository) UpdateUserStatus(userID uint, status authdomain.UserStatus) error {
 	return r.db.Model(&authdomain.User{}).
@@ -194,6 +249,15 @@ func (r *UserRepository) UpdateUserStatus(userID uint, status authdomain.UserSta
 		Update("status", status).Error
 }
 
+// ActivatePending activates a user still pending approval, reporting false when the user was
+// no longer pending so concurrent approvals only succeed once
+func (r *UserRepository) ActivatePending(userID uint) (bool, error) {
+	result := r.db.Model(&authdomain.User{}).
+		Where("id = ? AND status = ?", userID, authdomain.StatusPending).
+		Update("status", authdomain.StatusActive)
+	return result.RowsAffected > 0, result.Error
+}
+
 // BulkUpdateStatus updates status for multiple users
 func (r *UserRepository) BulkUpdateStatus(userIDs []uint, status authdomain.UserStatus) error {
 	return r.db.Model(&authdomain.User{}).
@@ -213,9 +277,86 @@ func (r *UserRepository) SoftDelete(userIDs []uint) error {
 	return r.db.Delete(&authdomain.User{}, userIDs).Error
 }
 
-// HardDelete permanently deletes users
+// HardDelete permanently deletes users. Audit entries that reference them keep an email snapshot
+// and drop the reference so the audit trail survives; refresh tokens cascade.
 func (r *UserRepository) HardDelete(userIDs []uint) error {
-	return r.db.Unscoped().Delete(&authdomain.User{}, userIDs).Error
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`UPDATE audit_logs SET user_email = users.email, user_id = NULL
+			FROM users WHERE audit_logs.user_id = users.id AND users.id IN ?`, userIDs).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`UPDATE audit_logs SET target_email = users.email, target_id = NULL
+			FROM users WHERE audit_logs.target_id = users.id AND users.id IN ?`, userIDs).Error
+		if err != nil {
+			return err
+		}
+
+		// Consent records are personal data and go with the account
+		if err := tx.Where("user_id IN ?", userIDs).Delete(&domain.UserConsent{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&authdomain.User{}, userIDs).Error
+	})
+}
+
+// GetSoftDeletedBefore gets users soft-deleted before the cutoff, oldest first
+func (r *UserRepository) GetSoftDeletedBefore(cutoff time.Time, limit int) ([]*authdomain.User, error) {
+	var users []*authdomain.User
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// ListByRole retrieves every user with the role that is not deleted, in ID order
+func (r *UserRepository) ListByRole(role authdomain.UserRole) ([]*authdomain.User, error) {
+	var users []*authdomain.User
+	err := r.db.Where("role = ?", role).Order("id ASC").Find(&users).Error
+	return users, err
+}
+
+// CountAdminsExcluding counts admins and super admins that are not deleted, ignoring the given user IDs
+func (r *UserRepository) CountAdminsExcluding(userIDs []uint) (int64, error) {
+	var count int64
+	query := r.db.Model(&authdomain.User{}).Where("role IN ?", []authdomain.UserRole{authdomain.RoleAdmin, authdomain.RoleSuperAdmin})
+	if len(userIDs) > 0 {
+		query = query.Where("id NOT IN ?", userIDs)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// CountActiveAdminsExcluding counts active admins and super admins that are not deleted, ignoring the given user IDs
+func (r *UserRepository) CountActiveAdminsExcluding(userIDs []uint) (int64, error) {
+	var count int64
+	query := r.db.Model(&authdomain.User{}).
+		Where("role IN ? AND status = ?", []authdomain.UserRole{authdomain.RoleAdmin, authdomain.RoleSuperAdmin}, authdomain.StatusActive)
+	if len(userIDs) > 0 {
+		query = query.Where("id NOT IN ?", userIDs)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// CountActiveSuperAdminsExcluding counts active super admins that are not deleted, ignoring the given user IDs
+func (r *UserRepository) CountActiveSuperAdminsExcluding(userIDs []uint) (int64, error) {
+	var count int64
+	query := r.db.Model(&authdomain.User{}).
+		Where("role = ? AND status = ?", authdomain.RoleSuperAdmin, authdomain.StatusActive)
+	if len(userIDs) > 0 {
+		query = query.Where("id NOT IN ?", userIDs)
+	}
+	err := query.Count(&count).Error
+	return count, err
 }
 
 // GetAdminStats retrieves admin dashboard statistics
@@ -244,6 +385,22 @@ func (r *UserRepository) GetAdminStats() (*AdminStats, error) {
 	return stats, nil
 }
 
+// CountUserActivity counts active accounts and the users whose last login is at or after each
+// cutoff in a single pass over the users table
+func (r *UserRepository) CountUserActivity(dailySince, monthlySince time.Time) (*admindomain.UserActivity, error) {
+	var activity admindomain.UserActivity
+	err := r.db.Model(&authdomain.User{}).
+		Select(`COUNT(*) FILTER (WHERE status = ?) AS active,
+			COUNT(*) FILTER (WHERE last_login_at >= ?) AS daily_active,
+			COUNT(*) FILTER (WHERE last_login_at >= ?) AS monthly_active`,
+			authdomain.StatusActive, dailySince, monthlySince).
+		Scan(&activity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
 // GetUserGrowthData retrieves user growth data for the last 30 days
 func (r *UserRepository) GetUserGrowthData(days int) ([]UserGrowthDataPoint, error) {
 	var results []UserGrowthDataPoint
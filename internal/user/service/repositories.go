@@ -0,0 +1,50 @@
+package service
+
+import (
+	"time"
+
+	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/user/domain"
+	"github.com/acheevo/tfa/internal/user/repository"
+)
+
+// UserRepo is the user storage the user service needs
+type UserRepo interface {
+	GetByID(id uint) (*authdomain.User, error)
+	UpdateProfile(userID uint, req *domain.UpdateProfileRequest) error
+	UpdateSelf(userID uint, req *domain.UpdateSelfRequest, expectedUpdatedAt *time.Time) (bool, error)
+	UpdatePreferences(userID uint, preferences authdomain.UserPreferences) error
+	GetPreferences(userID uint) (*authdomain.UserPreferences, error)
+	GetUserStats(userID uint) (*domain.UserStats, error)
+	UpdateEmail(userID uint, newEmail string) error
+	CheckEmailExists(email string, excludeUserID uint, includeDeleted bool) (bool, error)
+	CheckUsernameExists(username string, excludeUserID uint) (bool, error)
+}
+
+// AuditRepo records audit log entries
+type AuditRepo interface {
+	CreateAuditEntry(
+		userID *uint,
+		targetID *uint,
+		action authdomain.AuditAction,
+		level authdomain.AuditLevel,
+		resource string,
+		description string,
+		ipAddress string,
+		userAgent string,
+		metadata map[string]interface{},
+	) error
+}
+
+// ConsentRepo stores the consents a user has given or withdrawn
+type ConsentRepo interface {
+	Create(consents ...*domain.UserConsent) error
+	ListByUser(userID uint) ([]*domain.UserConsent, error)
+}
+
+// The repositories used in production satisfy the interfaces
+var (
+	_ UserRepo    = (*repository.UserRepository)(nil)
+	_ AuditRepo   = (*repository.AuditRepository)(nil)
+	_ ConsentRepo = (*repository.ConsentRepository)(nil)
+)
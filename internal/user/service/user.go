@@ -12,25 +12,26 @@ import (
 	authrepo "github.com/acheevo/tfa/internal/auth/repository"
 	"github.com/acheevo/tfa/internal/shared/config"
 	"github.com/acheevo/tfa/internal/user/domain"
-	"github.com/acheevo/tfa/internal/user/repository"
 )
 
 // UserService handles user management operations
 type UserService struct {
 	config       *config.Config
 	logger       *slog.Logger
-	userRepo     *repository.UserRepository
-	auditRepo    *repository.AuditRepository
+	userRepo     UserRepo
+	auditRepo    AuditRepo
 	authUserRepo *authrepo.UserRepository
+	consentRepo  ConsentRepo
 }
 
 // NewUserService creates a new user service
 func NewUserService(
 	config *config.Config,
 	logger *slog.Logger,
-	userRepo *repository.UserRepository,
-	auditRepo *repository.AuditRepository,
+	userRepo UserRepo,
+	auditRepo AuditRepo,
 	authUserRepo *authrepo.UserRepository,
+	consentRepo ConsentRepo,
 ) *UserService {
 	return &UserService{
 		config:       config,
@@ -38,6 +39,7 @@ func NewUserService(
 		userRepo:     userRepo,
 		auditRepo:    auditRepo,
 		authUserRepo: authUserRepo,
+		consentRepo:  consentRepo,
 	}
 }
 
@@ -94,6 +96,82 @@ func (s *UserService) UpdateProfile(
 	return s.GetProfile(userID)
 }
 
+// UpdateSelf applies a partial update to the caller's own profile. If ifMatch or req.UpdatedAt is
+// given, the update is rejected with ErrProfileModified when the profile changed since it was read.
+func (s *UserService) UpdateSelf(
+	userID uint,
+	req *domain.UpdateSelfRequest,
+	ifMatch string,
+	ipAddress, userAgent string,
+) (*authdomain.UserResponse, error) {
+	if req.HasRestrictedFields() {
+		return nil, domain.ErrFieldNotUpdatable
+	}
+	if !req.HasChanges() {
+		return nil, domain.ErrNoProfileChanges
+	}
+
+	currentUser, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		s.logger.Error("failed to get user for self update", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	// Check preconditions against the version the client read
+	var expectedUpdatedAt *time.Time
+	if ifMatch != "" || req.UpdatedAt != nil {
+		if ifMatch != "" && !domain.ETagMatches(ifMatch, domain.ProfileETag(currentUser.ID, currentUser.UpdatedAt)) {
+			return nil, domain.ErrProfileModified
+		}
+		if req.UpdatedAt != nil && !domain.SameUpdateTime(*req.UpdatedAt, currentUser.UpdatedAt) {
+			return nil, domain.ErrProfileModified
+		}
+		expectedUpdatedAt = &currentUser.UpdatedAt
+	}
+
+	// A new username follows the same rules as one chosen at registration
+	if req.Username != nil {
+		username := authdomain.NormalizeUsername(*req.Username)
+		if username != currentUser.GetUsername() {
+			if err := s.checkUsername(userID, username); err != nil {
+				return nil, err
+			}
+		}
+		req.Username = &username
+	}
+
+	updated, err := s.userRepo.UpdateSelf(userID, req, expectedUpdatedAt)
+	if err != nil {
+		s.logger.Error("failed to update own profile", "user_id", userID, "error", err)
+		return nil, domain.ErrProfileUpdateFailed
+	}
+	if !updated {
+		// Another write landed between our read and the conditional update
+		return nil, domain.ErrProfileModified
+	}
+
+	// Create audit log
+	changes := s.buildSelfChanges(currentUser, req)
+	if err := s.auditRepo.CreateAuditEntry(
+		&userID,
+		&userID,
+		authdomain.AuditActionUserUpdated,
+		authdomain.AuditLevelInfo,
+		"user",
+		fmt.Sprintf("Profile updated: %s", changes),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"changes":     changes,
+			"conditional": expectedUpdatedAt != nil,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for self update", "user_id", userID, "error", err)
+	}
+
+	return s.GetProfile(userID)
+}
+
 // UpdatePreferences updates a user's preferences
 func (s *UserService) UpdatePreferences(
 	userID uint,
@@ -117,6 +195,11 @@ func (s *UserService) UpdatePreferences(
 		Custom:        req.Custom,
 	}
 
+	// The backup email is only changed through its own password-checked, confirmed flow
+	if currentPrefs != nil {
+		newPrefs.BackupEmail = currentPrefs.BackupEmail
+	}
+
 	// Validate timezone if provided
 	if newPrefs.Timezone != "" {
 		if _, err := time.LoadLocation(newPrefs.Timezone); err != nil {
@@ -124,6 +207,17 @@ func (s *UserService) UpdatePreferences(
 		}
 	}
 
+	// A changed marketing opt-in is a consent decision and is recorded before it takes effect
+	previousMarketing := currentPrefs != nil && currentPrefs.Notifications.Marketing
+	if newPrefs.Notifications.Marketing != previousMarketing {
+		consent := s.newConsent(userID, domain.ConsentMarketingEmails, newPrefs.Notifications.Marketing,
+			domain.ConsentSourcePreferences, ipAddress, userAgent)
+		if err := s.consentRepo.Create(consent); err != nil {
+			s.logger.Error("failed to record marketing consent", "user_id", userID, "error", err)
+			return nil, err
+		}
+	}
+
 	// Update preferences
 	err = s.userRepo.UpdatePreferences(userID, newPrefs)
 	if err != nil {
@@ -157,6 +251,105 @@ func (s *UserService) GetPreferences(userID uint) (*authdomain.UserPreferences,
 	return s.userRepo.GetPreferences(userID)
 }
 
+// GetConsents retrieves a user's consent record
+func (s *UserService) GetConsents(userID uint) (*domain.ConsentRecordResponse, error) {
+	history, err := s.consentRepo.ListByUser(userID)
+	if err != nil {
+		s.logger.Error("failed to get user consents", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	return domain.NewConsentRecord(history, s.consentPolicy()), nil
+}
+
+// UpdateConsent records a consent being granted or withdrawn against the current policy version.
+// The marketing preference follows the marketing consent so both always agree.
+func (s *UserService) UpdateConsent(
+	userID uint,
+	req *domain.UpdateConsentRequest,
+	ipAddress, userAgent string,
+) (*domain.ConsentRecordResponse, error) {
+	granted := *req.Granted
+	if req.Type.IsRequired() && !granted {
+		return nil, domain.ErrRequiredConsent
+	}
+
+	prefs, err := s.userRepo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	consent := s.newConsent(userID, req.Type, granted, domain.ConsentSourceAPI, ipAddress, userAgent)
+	if err := s.consentRepo.Create(consent); err != nil {
+		s.logger.Error("failed to record consent", "user_id", userID, "type", req.Type, "error", err)
+		return nil, err
+	}
+
+	if req.Type == domain.ConsentMarketingEmails {
+		if prefs.Notifications.Marketing != granted {
+			prefs.Notifications.Marketing = granted
+			if err := s.userRepo.UpdatePreferences(userID, *prefs); err != nil {
+				s.logger.Error("failed to sync marketing preference", "user_id", userID, "error", err)
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.auditRepo.CreateAuditEntry(
+		&userID,
+		&userID,
+		authdomain.AuditActionConsentUpdated,
+		authdomain.AuditLevelInfo,
+		"user",
+		fmt.Sprintf("Consent %s %s for version %s", req.Type, consentDecision(granted), consent.Version),
+		ipAddress,
+		userAgent,
+		map[string]interface{}{
+			"type":    req.Type,
+			"granted": granted,
+			"version": consent.Version,
+		},
+	); err != nil {
+		s.logger.Error("failed to create audit log for consent update", "user_id", userID, "error", err)
+	}
+
+	return s.GetConsents(userID)
+}
+
+// consentPolicy returns the current policy versions consents are recorded against
+func (s *UserService) consentPolicy() domain.ConsentPolicy {
+	return domain.ConsentPolicy{
+		TermsVersion:   s.config.TermsVersion,
+		PrivacyVersion: s.config.PrivacyPolicyVersion,
+	}
+}
+
+// newConsent builds a consent record for the current policy version
+func (s *UserService) newConsent(
+	userID uint,
+	consentType domain.ConsentType,
+	granted bool,
+	source, ipAddress, userAgent string,
+) *domain.UserConsent {
+	return &domain.UserConsent{
+		UserID:    userID,
+		Type:      consentType,
+		Granted:   granted,
+		Version:   s.consentPolicy().Version(consentType),
+		Source:    source,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	}
+}
+
+// consentDecision describes a consent decision for audit descriptions
+func consentDecision(granted bool) string {
+	if granted {
+		return "granted"
+	}
+	return "withdrawn"
+}
+
 // ChangeEmail initiates an email change process
 func (s *UserService) ChangeEmail(userID uint, req *domain.ChangeEmailRequest, ipAddress, userAgent string) error {
 	// Get current user
@@ -170,10 +363,23 @@ func (s *UserService) ChangeEmail(userID uint, req *domain.ChangeEmailRequest, i
 		return authdomain.ErrInvalidCredentials
 	}
 
+	// Stored emails are normalized, so compare and record the new one the same way
+	newEmail := authdomain.NormalizeEmail(req.NewEmail)
+
+	// The registration domain rules apply to changed emails too, or they'd be trivial to get around
+	policy := &authdomain.EmailDomainPolicy{
+		Allowed:         s.config.GetRegistrationAllowedDomains(),
+		Blocked:         s.config.GetRegistrationBlockedDomains(),
+		BlockDisposable: s.config.RegistrationBlockDisposable,
+	}
+	if err := policy.Check(newEmail); err != nil {
+		return err
+	}
+
 	// Check if new email already exists
-	exists, err := s.userRepo.CheckEmailExists(req.NewEmail, userID)
+	exists, err := s.userRepo.CheckEmailExists(newEmail, userID, !s.config.UserDeletedEmailReuse)
 	if err != nil {
-		s.logger.Error("failed to check email exists", "email", req.NewEmail, "error", err)
+		s.logger.Error("failed to check email exists", "email", newEmail, "error", err)
 		return err
 	}
 	if exists {
@@ -182,7 +388,7 @@ func (s *UserService) ChangeEmail(userID uint, req *domain.ChangeEmailRequest, i
 
 	// Update email
 	oldEmail := user.Email
-	err = s.userRepo.UpdateEmail(userID, req.NewEmail)
+	err = s.userRepo.UpdateEmail(userID, newEmail)
 	if err != nil {
 		s.logger.Error("failed to update user email", "user_id", userID, "error", err)
 		return err
@@ -195,12 +401,12 @@ func (s *UserService) ChangeEmail(userID uint, req *domain.ChangeEmailRequest, i
 		authdomain.AuditActionUserUpdated,
 		authdomain.AuditLevelInfo,
 		"user",
-		fmt.Sprintf("Email changed from %s to %s", oldEmail, req.NewEmail),
+		fmt.Sprintf("Email changed from %s to %s", oldEmail, newEmail),
 		ipAddress,
 		userAgent,
 		map[string]interface{}{
 			"old_email": oldEmail,
-			"new_email": req.NewEmail,
+			"new_email": newEmail,
 		},
 	); err != nil {
 		s.logger.Error("failed to create audit log for email change", "user_id", userID, "error", err)
@@ -281,6 +487,52 @@ func (s *UserService) buildProfileChanges(current *authdomain.User, req *domain.
 	return strings.Join(changes, ", ")
 }
 
+// buildSelfChanges builds a human-readable string of partial profile changes
+func (s *UserService) buildSelfChanges(current *authdomain.User, req *domain.UpdateSelfRequest) string {
+	var changes []string
+
+	if req.FirstName != nil && current.FirstName != *req.FirstName {
+		changes = append(changes, fmt.Sprintf("first name: '%s' -> '%s'", current.FirstName, *req.FirstName))
+	}
+	if req.LastName != nil && current.LastName != *req.LastName {
+		changes = append(changes, fmt.Sprintf("last name: '%s' -> '%s'", current.LastName, *req.LastName))
+	}
+	if req.Avatar != nil && current.Avatar != *req.Avatar {
+		changes = append(changes, "avatar updated")
+	}
+	if req.Username != nil && current.GetUsername() != *req.Username {
+		changes = append(changes, fmt.Sprintf("username: '%s' -> '%s'", current.GetUsername(), *req.Username))
+	}
+
+	if len(changes) == 0 {
+		return "no changes"
+	}
+
+	return strings.Join(changes, ", ")
+}
+
+// checkUsername checks a new username against the username policy and the usernames of other users
+func (s *UserService) checkUsername(userID uint, username string) error {
+	policy := &authdomain.UsernamePolicy{
+		MinLength: s.config.UsernameMinLength,
+		MaxLength: s.config.UsernameMaxLength,
+		Reserved:  s.config.GetUsernameReserved(),
+	}
+	if err := policy.Check(username); err != nil {
+		return err
+	}
+
+	taken, err := s.userRepo.CheckUsernameExists(username, userID)
+	if err != nil {
+		s.logger.Error("failed to check username exists", "username", username, "error", err)
+		return err
+	}
+	if taken {
+		return authdomain.ErrUsernameTaken
+	}
+	return nil
+}
+
 // buildPreferencesChanges builds a human-readable string of preferences changes
 func (s *UserService) buildPreferencesChanges(current, new *authdomain.UserPreferences) string {
 	var changes []string
@@ -7,7 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 
 	authdomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/middleware"
 	"github.com/acheevo/tfa/internal/shared/config"
+	apperrors "github.com/acheevo/tfa/internal/shared/errors"
 	"github.com/acheevo/tfa/internal/user/domain"
 	"github.com/acheevo/tfa/internal/user/service"
 )
@@ -42,6 +44,7 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", domain.ProfileETag(profile.ID, profile.UpdatedAt))
 	c.JSON(http.StatusOK, profile)
 }
 
@@ -71,6 +74,33 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, profile)
 }
 
+// UpdateSelf handles PATCH /api/auth/me
+func (h *UserHandler) UpdateSelf(c *gin.Context) {
+	userID := h.getUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.UpdateSelfRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	ipAddress := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	profile, err := h.userService.UpdateSelf(userID, &req, c.GetHeader("If-Match"), ipAddress, userAgent)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", domain.ProfileETag(profile.ID, profile.UpdatedAt))
+	c.JSON(http.StatusOK, profile)
+}
+
 // GetPreferences handles GET /api/user/preferences
 func (h *UserHandler) GetPreferences(c *gin.Context) {
 	userID := h.getUserID(c)
@@ -114,6 +144,49 @@ func (h *UserHandler) UpdatePreferences(c *gin.Context) {
 	c.JSON(http.StatusOK, preferences)
 }
 
+// GetConsents handles GET /api/auth/me/consents; ?download=true serves it as a file attachment
+func (h *UserHandler) GetConsents(c *gin.Context) {
+	userID := h.getUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	record, err := h.userService.GetConsents(userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if c.Query("download") == "true" {
+		c.Header("Content-Disposition", `attachment; filename="consent-record.json"`)
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// UpdateConsent handles POST /api/auth/me/consents
+func (h *UserHandler) UpdateConsent(c *gin.Context) {
+	userID := h.getUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req domain.UpdateConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleValidationError(c, err)
+		return
+	}
+
+	record, err := h.userService.UpdateConsent(userID, &req, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
 // ChangeEmail handles POST /api/user/change-email
 func (h *UserHandler) ChangeEmail(c *gin.Context) {
 	userID := h.getUserID(c)
@@ -186,34 +259,70 @@ func (h *UserHandler) getUserID(c *gin.Context) uint {
 
 // handleError handles service errors and returns appropriate HTTP responses
 func (h *UserHandler) handleError(c *gin.Context, err error) {
+	// Username rejections name the rule that failed, so they are reported on the field as they are
+	if authdomain.IsUsernameError(err) {
+		status := http.StatusBadRequest
+		if err == authdomain.ErrUsernameTaken {
+			status = http.StatusConflict
+		}
+		c.JSON(status, middleware.CodedError(c, apperrors.CodeValidationFailed, authdomain.ErrorResponse{
+			Error:   "validation failed",
+			Details: map[string]string{"username": err.Error()},
+		}))
+		return
+	}
+
 	switch err {
 	case domain.ErrUserNotFound:
-		c.JSON(http.StatusNotFound, authdomain.ErrorResponse{Error: "user not found"})
+		c.JSON(http.StatusNotFound, middleware.CodedError(c, apperrors.CodeUserNotFound, authdomain.ErrorResponse{Error: "user not found"}))
 	case domain.ErrUnauthorized:
-		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"})
+		c.JSON(http.StatusUnauthorized, middleware.CodedError(c, apperrors.CodeUnauthorized, authdomain.ErrorResponse{Error: "unauthorized"}))
 	case domain.ErrForbidden:
-		c.JSON(http.StatusForbidden, authdomain.ErrorResponse{Error: "forbidden"})
+		c.JSON(http.StatusForbidden, middleware.CodedError(c, apperrors.CodeForbidden, authdomain.ErrorResponse{Error: "forbidden"}))
 	case domain.ErrEmailAlreadyExists:
-		c.JSON(http.StatusConflict, authdomain.ErrorResponse{Error: "email already exists"})
+		c.JSON(http.StatusConflict, middleware.CodedError(c, apperrors.CodeEmailAlreadyExists, authdomain.ErrorResponse{
+			Error: "email already exists",
+		}))
 	case domain.ErrInvalidPreferences:
 		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "invalid preferences"})
+	case domain.ErrProfileModified:
+		c.JSON(http.StatusConflict, authdomain.ErrorResponse{Error: "profile was modified since it was read"})
+	case domain.ErrFieldNotUpdatable:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+			Error: "only first_name, last_name, avatar and username can be updated here; use change-email for email changes",
+		})
+	case domain.ErrNoProfileChanges:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{Error: "no profile changes provided"})
+	case domain.ErrRequiredConsent:
+		c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+			Error: "the terms of service and privacy policy cannot be withdrawn; delete the account instead",
+		})
 	case domain.ErrProfileUpdateFailed:
 		c.JSON(http.StatusInternalServerError, authdomain.ErrorResponse{Error: "profile update failed"})
 	case authdomain.ErrInvalidCredentials:
-		c.JSON(http.StatusUnauthorized, authdomain.ErrorResponse{Error: "invalid credentials"})
+		c.JSON(http.StatusUnauthorized, middleware.CodedError(c, apperrors.CodeInvalidCredentials, authdomain.ErrorResponse{
+			Error: "invalid credentials",
+		}))
+	case authdomain.ErrEmailDomainNotAllowed, authdomain.ErrEmailDomainBlocked, authdomain.ErrDisposableEmail:
+		c.JSON(http.StatusBadRequest, middleware.CodedError(c, apperrors.CodeValidationFailed, authdomain.ErrorResponse{
+			Error:   "validation failed",
+			Details: map[string]string{"new_email": err.Error()},
+		}))
 	default:
 		h.logger.Error("unhandled user service error", "error", err)
-		c.JSON(http.StatusInternalServerError, authdomain.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, middleware.CodedError(c, apperrors.CodeInternalError, authdomain.ErrorResponse{
+			Error: "internal server error",
+		}))
 	}
 }
 
 // handleValidationError handles validation errors from request binding
 func (h *UserHandler) handleValidationError(c *gin.Context, err error) {
 	h.logger.Error("validation error", "error", err)
-	c.JSON(http.StatusBadRequest, authdomain.ErrorResponse{
+	c.JSON(http.StatusBadRequest, middleware.CodedError(c, apperrors.CodeValidationFailed, authdomain.ErrorResponse{
 		Error:   "validation failed",
 		Details: extractValidationErrors(err),
-	})
+	}))
 }
 
 // extractValidationErrors extracts field-specific validation errors
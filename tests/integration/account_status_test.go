@@ -0,0 +1,163 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	authTransport "github.com/acheevo/tfa/internal/auth/transport"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_LoginAccountStatus(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev with password "password"
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	cfg := &config.Config{
+		Environment: "test",
+		JWTSecret:   "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+	}
+
+	jwtSvc := authService.NewJWTService(cfg, clock.New(), nil)
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		jwtSvc,
+		authService.NewEmailService(cfg, logger),
+		userRepository.NewAuditRepository(db.DB, nil, nil),
+		clock.New(),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	authHandler := authTransport.NewAuthHandler(cfg, logger, authSvc)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/auth/login", authHandler.Login)
+
+	login := func(password string) (int, authDomain.ErrorResponse) {
+		body, _ := json.Marshal(authDomain.LoginRequest{Email: "admin@fullstack.dev", Password: password})
+		req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response authDomain.ErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	setStatus := func(t *testing.T, status authDomain.UserStatus) {
+		if _, err := sqlDB.Exec(`UPDATE users SET status = $1 WHERE email = 'admin@fullstack.dev'`, string(status)); err != nil {
+			t.Fatalf("Failed to update user status: %v", err)
+		}
+	}
+
+	tests := []struct {
+		status authDomain.UserStatus
+		reason string
+	}{
+		{authDomain.StatusInactive, "account_inactive"},
+		{authDomain.StatusSuspended, "account_suspended"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			setStatus(t, tt.status)
+			defer setStatus(t, authDomain.StatusActive)
+
+			code, response := login("password")
+			if code != http.StatusForbidden {
+				t.Errorf("expected status %d, got %d", http.StatusForbidden, code)
+			}
+			if response.Details["reason"] != tt.reason {
+				t.Errorf("expected reason %q, got %q", tt.reason, response.Details["reason"])
+			}
+
+			// Without the right password the status stays hidden
+			code, response = login("wrong-password")
+			if code != http.StatusUnauthorized {
+				t.Errorf("expected status %d, got %d", http.StatusUnauthorized, code)
+			}
+			if response.Details["reason"] != "" {
+				t.Errorf("expected no status reason, got %q", response.Details["reason"])
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"golang.org/x/crypto/bcrypt"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	adminTransport "github.com/acheevo/tfa/internal/admin/transport"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/middleware"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_AdminAccessAudit(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev (user 1) with password "password"
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	seedUser := func(email string, role authDomain.UserRole) uint {
+		var id uint
+		err := sqlDB.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, created_at, updated_at)
+		VALUES ($1, $2, 'Test', 'User', $3, $4, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING id`, email, string(hashedPassword), string(role), string(authDomain.StatusActive)).Scan(&id)
+		if err != nil {
+			t.Fatalf("Failed to seed user: %v", err)
+		}
+		return id
+	}
+	superAdminID := seedUser("root@fullstack.dev", authDomain.RoleSuperAdmin)
+	memberID := seedUser("member@fullstack.dev", authDomain.RoleUser)
+
+	cfg := &config.Config{
+		Environment:         "test",
+		JWTSecret:           "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		StepUpTokenTTL:      "5m",
+		LastAdminProtection: true,
+	}
+
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	jwtSvc := authService.NewJWTService(cfg, clock.New(), nil)
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		jwtSvc,
+		authService.NewEmailService(cfg, logger),
+		auditRepo,
+		clock.New(),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		auditRepo,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+
+	authMiddleware := middleware.NewAuthMiddleware(logger, authSvc)
+	adminHandler := adminTransport.NewAdminHandler(cfg, logger, adminSvc)
+
+	gin.SetMode(gin.TestMode)
+	newRouter := func(mode string) *gin.Engine {
+		router := gin.New()
+		admin := router.Group("/api/admin")
+		admin.Use(authMiddleware.RequireAuth(), authMiddleware.AuditAdminAccess(mode), authMiddleware.RequireSuperAdminStepUp())
+		admin.GET("/users/:id", adminHandler.GetUserDetails)
+		admin.PUT("/users/:id/role", adminHandler.UpdateUserRole)
+		return router
+	}
+	router := newRouter(middleware.AdminAccessAuditAll)
+
+	accessToken := func(id uint) string {
+		var user authDomain.User
+		if err := db.DB.First(&user, id).Error; err != nil {
+			t.Fatalf("Failed to load user: %v", err)
+		}
+		token, err := jwtSvc.GenerateAccessToken(&user)
+		if err != nil {
+			t.Fatalf("Failed to generate access token: %v", err)
+		}
+		return token
+	}
+
+	send := func(router *gin.Engine, method, path string, userID uint, stepUp string, payload any) *httptest.ResponseRecorder {
+		var body []byte
+		if payload != nil {
+			body, _ = json.Marshal(payload)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+accessToken(userID))
+		if stepUp != "" {
+			req.Header.Set(middleware.StepUpTokenHeader, stepUp)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	countEntries := func(action authDomain.AuditAction, userID uint) int64 {
+		var count int64
+		db.DB.Model(&authDomain.AuditLog{}).Where("action = ? AND user_id = ?", action, userID).Count(&count)
+		return count
+	}
+
+	t.Run("UserDetailsReadIsAudited", func(t *testing.T) {
+		w := send(router, http.MethodGet, fmt.Sprintf("/api/admin/users/%d?include=sessions", memberID), 1, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var entries []authDomain.AuditLog
+		db.DB.Where("action = ? AND user_id = ?", authDomain.AuditActionAdminAccess, 1).Find(&entries)
+		if len(entries) != 1 {
+			t.Fatalf("Expected 1 admin access entry, got %d", len(entries))
+		}
+		entry := entries[0]
+		if entry.TargetID == nil || *entry.TargetID != memberID {
+			t.Errorf("Expected target %d, got %v", memberID, entry.TargetID)
+		}
+		if entry.Level != authDomain.AuditLevelInfo {
+			t.Errorf("Expected info level, got %s", entry.Level)
+		}
+
+		var route, query string
+		if err := sqlDB.QueryRow(`SELECT metadata->>'route', metadata->>'query' FROM audit_logs WHERE id = $1`, entry.ID).
+			Scan(&route, &query); err != nil {
+			t.Fatalf("Failed to read metadata: %v", err)
+		}
+		if route != "/api/admin/users/:id" || query != "include=sessions" {
+			t.Errorf("Expected route and query in metadata, got %q and %q", route, query)
+		}
+	})
+
+	t.Run("MutationKeepsServiceEntry", func(t *testing.T) {
+		before := countEntries(authDomain.AuditActionAdminAccess, 1)
+		w := send(router, http.MethodPut, fmt.Sprintf("/api/admin/users/%d/role", memberID), 1, "",
+			adminDomain.UpdateUserRoleRequest{Role: authDomain.RoleAdmin, Reason: "grant admin access for on-call rotation"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		if got := countEntries(authDomain.AuditActionAdminAccess, 1) - before; got != 1 {
+			t.Errorf("Expected 1 admin access entry for the change, got %d", got)
+		}
+		if got := countEntries(authDomain.AuditActionUserRoleChanged, 1); got != 1 {
+			t.Errorf("Expected the detailed role change entry to remain, got %d", got)
+		}
+	})
+
+	t.Run("SuperAdminActionNotCountedTwice", func(t *testing.T) {
+		stepUp, err := authSvc.StepUp(superAdminID, &authDomain.StepUpRequest{Password: "password"}, "127.0.0.1", "test")
+		if err != nil {
+			t.Fatalf("Failed to step up: %v", err)
+		}
+		w := send(router, http.MethodPut, fmt.Sprintf("/api/admin/users/%d/role", memberID), superAdminID, stepUp.StepUpToken,
+			adminDomain.UpdateUserRoleRequest{Role: authDomain.RoleUser, Reason: "on-call rotation has ended"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		if got := countEntries(authDomain.AuditActionSuperAdminAction, superAdminID); got != 1 {
+			t.Errorf("Expected 1 super admin action entry, got %d", got)
+		}
+		if got := countEntries(authDomain.AuditActionAdminAccess, superAdminID); got != 0 {
+			t.Errorf("Expected no admin access entry on top of the super admin entry, got %d", got)
+		}
+	})
+
+	t.Run("RefusedCallAuditedAsWarning", func(t *testing.T) {
+		w := send(router, http.MethodPut, fmt.Sprintf("/api/admin/users/%d/role", memberID), superAdminID, "",
+			adminDomain.UpdateUserRoleRequest{Role: authDomain.RoleAdmin, Reason: "grant admin access again"})
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusForbidden, w.Code, w.Body.String())
+		}
+
+		var entry authDomain.AuditLog
+		if err := db.DB.Where("action = ? AND user_id = ?", authDomain.AuditActionAdminAccess, superAdminID).First(&entry).Error; err != nil {
+			t.Fatalf("Expected the refused call to be audited: %v", err)
+		}
+		if entry.Level != authDomain.AuditLevelWarning {
+			t.Errorf("Expected warning level, got %s", entry.Level)
+		}
+	})
+
+	t.Run("MutationsModeSkipsReads", func(t *testing.T) {
+		before := countEntries(authDomain.AuditActionAdminAccess, 1)
+		w := send(newRouter(middleware.AdminAccessAuditMutations), http.MethodGet, fmt.Sprintf("/api/admin/users/%d", memberID), 1, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if got := countEntries(authDomain.AuditActionAdminAccess, 1) - before; got != 0 {
+			t.Errorf("Expected reads not to be audited in mutations mode, got %d entries", got)
+		}
+	})
+}
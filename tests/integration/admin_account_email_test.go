@@ -0,0 +1,240 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_AdminAccountEmails(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	// Email is on but no SMTP credentials are set, so sends are skipped without dialing
+	cfg := &config.Config{
+		Environment:  "test",
+		JWTSecret:    "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		EmailEnabled: true,
+	}
+
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		authService.NewJWTService(cfg, clock.New(), nil),
+		authService.NewEmailService(cfg, logger),
+		auditRepo,
+		clock.New(),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		auditRepo,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		authSvc,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+
+	register := func(email string) uint {
+		response, err := authSvc.Register(&authDomain.RegisterRequest{
+			Email: email, Password: "password123", FirstName: "Support", LastName: "Case",
+		}, "127.0.0.1", "test")
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		return response.User.ID
+	}
+
+	countAudits := func(action authDomain.AuditAction, targetID uint) int64 {
+		var count int64
+		db.DB.Model(&authDomain.AuditLog{}).
+			Where("action = ? AND user_id = ? AND target_id = ?", action, 1, targetID).
+			Count(&count)
+		return count
+	}
+
+	t.Run("ResendVerification", func(t *testing.T) {
+		userID := register("unverified@fullstack.dev")
+
+		if err := adminSvc.ResendVerificationEmail(1, userID, "127.0.0.1", "test"); err != nil {
+			t.Fatalf("Failed to resend verification: %v", err)
+		}
+
+		var user authDomain.User
+		db.DB.First(&user, userID)
+		if user.EmailVerifyToken == "" {
+			t.Error("Expected the user to keep a verification token")
+		}
+		if count := countAudits(authDomain.AuditActionVerificationResent, userID); count != 1 {
+			t.Errorf("Expected 1 verification resent audit entry, got %d", count)
+		}
+	})
+
+	t.Run("ResendVerificationAlreadyVerified", func(t *testing.T) {
+		userID := register("verified@fullstack.dev")
+		db.DB.Model(&authDomain.User{}).Where("id = ?", userID).Update("email_verified", true)
+
+		err := adminSvc.ResendVerificationEmail(1, userID, "127.0.0.1", "test")
+		if err != authDomain.ErrEmailAlreadyVerified {
+			t.Errorf("Expected ErrEmailAlreadyVerified, got %v", err)
+		}
+	})
+
+	t.Run("SendReset", func(t *testing.T) {
+		userID := register("forgetful@fullstack.dev")
+
+		if err := adminSvc.SendPasswordReset(1, userID, "127.0.0.1", "test"); err != nil {
+			t.Fatalf("Failed to send password reset: %v", err)
+		}
+
+		var resets int64
+		db.DB.Model(&authDomain.PasswordReset{}).Where("email = ?", "forgetful@fullstack.dev").Count(&resets)
+		if resets != 1 {
+			t.Errorf("Expected 1 password reset token, got %d", resets)
+		}
+		if count := countAudits(authDomain.AuditActionAdminPasswordReset, userID); count != 1 {
+			t.Errorf("Expected 1 password reset audit entry, got %d", count)
+		}
+	})
+
+	t.Run("SendResetLimited", func(t *testing.T) {
+		userID := register("flooded@fullstack.dev")
+
+		for i := 0; i < 3; i++ {
+			if err := adminSvc.SendPasswordReset(1, userID, "127.0.0.1", "test"); err != nil {
+				t.Fatalf("Failed to send password reset: %v", err)
+			}
+		}
+
+		err := adminSvc.SendPasswordReset(1, userID, "127.0.0.1", "test")
+		if err != authDomain.ErrTooManyResetRequests {
+			t.Errorf("Expected ErrTooManyResetRequests, got %v", err)
+		}
+	})
+
+	t.Run("EmailDisabled", func(t *testing.T) {
+		userID := register("offline@fullstack.dev")
+		cfg.EmailEnabled = false
+		defer func() { cfg.EmailEnabled = true }()
+
+		if err := adminSvc.SendPasswordReset(1, userID, "127.0.0.1", "test"); err != authDomain.ErrEmailDisabled {
+			t.Errorf("Expected ErrEmailDisabled for reset, got %v", err)
+		}
+		if err := adminSvc.ResendVerificationEmail(1, userID, "127.0.0.1", "test"); err != authDomain.ErrEmailDisabled {
+			t.Errorf("Expected ErrEmailDisabled for verification, got %v", err)
+		}
+
+		var resets int64
+		db.DB.Model(&authDomain.PasswordReset{}).Where("email = ?", "offline@fullstack.dev").Count(&resets)
+		if resets != 0 {
+			t.Errorf("Expected no password reset token, got %d", resets)
+		}
+		if count := countAudits(authDomain.AuditActionAdminPasswordReset, userID); count != 0 {
+			t.Errorf("Expected no password reset audit entry, got %d", count)
+		}
+	})
+
+	t.Run("RequiresUserManagement", func(t *testing.T) {
+		userID := register("regular@fullstack.dev")
+
+		if err := adminSvc.SendPasswordReset(userID, 1, "127.0.0.1", "test"); err != adminDomain.ErrNotAuthorized {
+			t.Errorf("Expected ErrNotAuthorized, got %v", err)
+		}
+		if err := adminSvc.ResendVerificationEmail(userID, 1, "127.0.0.1", "test"); err != adminDomain.ErrNotAuthorized {
+			t.Errorf("Expected ErrNotAuthorized, got %v", err)
+		}
+	})
+}
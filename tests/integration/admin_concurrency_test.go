@@ -0,0 +1,210 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"gorm.io/gorm"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	adminTransport "github.com/acheevo/tfa/internal/admin/transport"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_AdminOperationConcurrencyLimit(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev (user 1) with password "password"
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	var memberID uint
+	err = sqlDB.QueryRow(`
+	INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, created_at, updated_at)
+	VALUES ('member@fullstack.dev', 'unused', 'Test', 'Member', $1, $2, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	RETURNING id`, string(authDomain.RoleUser), string(authDomain.StatusActive)).Scan(&memberID)
+	if err != nil {
+		t.Fatalf("Failed to seed member: %v", err)
+	}
+
+	// Once armed, the next bulk target lookup parks until released, so the bulk action holding the
+	// only slot stays in flight while the second request arrives
+	var holdBulk atomic.Bool
+	held := make(chan struct{})
+	resume := make(chan struct{})
+	err = db.DB.Callback().Query().Before("gorm:query").Register("test:hold_bulk", func(tx *gorm.DB) {
+		if _, isTargets := tx.Statement.Dest.(*[]*authDomain.User); isTargets && holdBulk.CompareAndSwap(true, false) {
+			close(held)
+			<-resume
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to register query callback: %v", err)
+	}
+
+	cfg := &config.Config{
+		Environment:              "test",
+		JWTSecret:                "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		AdminBulkConcurrency:     1,
+		AdminOperationRetryAfter: "15s",
+	}
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		userRepository.NewAuditRepository(db.DB, nil, nil),
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+	adminHandler := adminTransport.NewAdminHandler(cfg, logger, adminSvc)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/admin/users/bulk", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	}, adminHandler.BulkUpdateUsers)
+
+	bulk := func(action adminDomain.BulkActionType) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		_ = json.NewEncoder(&body).Encode(map[string]any{
+			"user_ids": []uint{memberID}, "action": action, "reason": "concurrency test",
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/users/bulk", &body)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	holdBulk.Store(true)
+	var wg sync.WaitGroup
+	var first *httptest.ResponseRecorder
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		first = bulk(adminDomain.BulkActionSuspend)
+	}()
+
+	select {
+	case <-held:
+	case <-time.After(10 * time.Second):
+		t.Fatal("First bulk action never reached the target lookup")
+	}
+
+	t.Run("RejectsOverLimit", func(t *testing.T) {
+		w := bulk(adminDomain.BulkActionActivate)
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusTooManyRequests, w.Code, w.Body.String())
+		}
+		if retryAfter := w.Header().Get("Retry-After"); retryAfter != "15" {
+			t.Errorf("Expected Retry-After 15, got %q", retryAfter)
+		}
+	})
+
+	close(resume)
+	wg.Wait()
+
+	t.Run("FirstActionCompletes", func(t *testing.T) {
+		if first.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, first.Code, first.Body.String())
+		}
+		var status string
+		if err := sqlDB.QueryRow(`SELECT status FROM users WHERE id = $1`, memberID).Scan(&status); err != nil {
+			t.Fatalf("Failed to read member: %v", err)
+		}
+		if status != string(authDomain.StatusSuspended) {
+			t.Errorf("Expected the member to be suspended, got %q", status)
+		}
+	})
+
+	t.Run("SlotFreedAfterCompletion", func(t *testing.T) {
+		if w := bulk(adminDomain.BulkActionActivate); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,115 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_AuditMetadataTruncation(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	policy := authDomain.NewAuditPolicy(nil, nil, 1024)
+	auditRepo := userRepository.NewAuditRepository(db.DB, policy, logger)
+	adminID := uint(1)
+
+	err = auditRepo.CreateAuditEntry(&adminID, &adminID, authDomain.AuditActionUserRoleChanged, authDomain.AuditLevelInfo,
+		"admin", "Role changed", "127.0.0.1", "test",
+		map[string]interface{}{
+			"old_role":          authDomain.RoleUser,
+			"new_role":          authDomain.RoleAdmin,
+			"reason":            "quarterly access review",
+			"validation_result": map[string]interface{}{"warnings": []string{strings.Repeat("w", 4096)}},
+		})
+	if err != nil {
+		t.Fatalf("Failed to create audit entry: %v", err)
+	}
+
+	logs, err := auditRepo.GetUserAuditHistory(adminID, 10)
+	if err != nil {
+		t.Fatalf("Failed to load audit history: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(logs))
+	}
+
+	metadata := logs[0].Metadata
+	if metadata[authDomain.MetadataTruncatedKey] != true {
+		t.Errorf("Expected metadata to be marked truncated, got %v", metadata)
+	}
+	if _, ok := metadata["validation_result"]; ok {
+		t.Error("Expected nested validation result to be dropped")
+	}
+	if metadata["old_role"] != "user" || metadata["new_role"] != "admin" || metadata["reason"] != "quarterly access review" {
+		t.Errorf("Expected key fields to survive truncation, got %v", metadata)
+	}
+	if original, ok := metadata[authDomain.MetadataOriginalBytesKey].(float64); !ok || original <= 1024 {
+		t.Errorf("Expected original size above the limit, got %v", metadata[authDomain.MetadataOriginalBytesKey])
+	}
+}
@@ -26,8 +26,10 @@ import (
 	authService "github.com/acheevo/tfa/internal/auth/service"
 	authTransport "github.com/acheevo/tfa/internal/auth/transport"
 	"github.com/acheevo/tfa/internal/middleware"
+	"github.com/acheevo/tfa/internal/shared/clock"
 	"github.com/acheevo/tfa/internal/shared/config"
 	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
 )
 
 func TestAuthEndpoints_E2E(t *testing.T) {
@@ -549,9 +551,13 @@ func createTestAuthHandler(t *testing.T, db *database.DB) http.Handler {
 	userRepo := authRepo.NewUserRepository(db.DB)
 	refreshTokenRepo := authRepo.NewRefreshTokenRepository(db.DB)
 	passwordResetRepo := authRepo.NewPasswordResetRepository(db.DB)
-	jwtSvc := authService.NewJWTService(cfg)
+	jwtSvc := authService.NewJWTService(cfg, clock.New(), nil)
 	emailSvc := authService.NewEmailService(cfg, logger)
-	authSvc := authService.NewAuthService(cfg, logger, userRepo, refreshTokenRepo, passwordResetRepo, jwtSvc, emailSvc)
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	authSvc := authService.NewAuthService(
+		cfg, logger, userRepo, refreshTokenRepo, passwordResetRepo, jwtSvc, emailSvc, auditRepo, clock.New(), nil, nil, nil,
+		nil,
+	)
 
 	// Initialize handler
 	authHandler := authTransport.NewAuthHandler(cfg, logger, authSvc)
@@ -595,9 +601,13 @@ func createProtectedAuthHandler(t *testing.T, db *database.DB) http.Handler {
 	userRepo := authRepo.NewUserRepository(db.DB)
 	refreshTokenRepo := authRepo.NewRefreshTokenRepository(db.DB)
 	passwordResetRepo := authRepo.NewPasswordResetRepository(db.DB)
-	jwtSvc := authService.NewJWTService(cfg)
+	jwtSvc := authService.NewJWTService(cfg, clock.New(), nil)
 	emailSvc := authService.NewEmailService(cfg, logger)
-	authSvc := authService.NewAuthService(cfg, logger, userRepo, refreshTokenRepo, passwordResetRepo, jwtSvc, emailSvc)
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	authSvc := authService.NewAuthService(
+		cfg, logger, userRepo, refreshTokenRepo, passwordResetRepo, jwtSvc, emailSvc, auditRepo, clock.New(), nil, nil, nil,
+		nil,
+	)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(logger, authSvc)
@@ -0,0 +1,292 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userDomain "github.com/acheevo/tfa/internal/user/domain"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+	userService "github.com/acheevo/tfa/internal/user/service"
+	userTransport "github.com/acheevo/tfa/internal/user/transport"
+)
+
+func TestIntegration_UserConsents(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	cfg := &config.Config{
+		Environment:          "test",
+		JWTSecret:            "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		TermsVersion:         "2024-01",
+		PrivacyPolicyVersion: "3",
+	}
+
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	consentRepo := userRepository.NewConsentRepository(db.DB)
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		authService.NewJWTService(cfg, clock.New(), nil),
+		authService.NewEmailService(cfg, logger),
+		auditRepo,
+		clock.New(),
+		nil,
+		consentRepo,
+		nil,
+		nil,
+	)
+	userSvc := userService.NewUserService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		auditRepo,
+		authRepo.NewUserRepository(db.DB),
+		consentRepo,
+	)
+	userHandler := userTransport.NewUserHandler(cfg, logger, userSvc)
+
+	registered, err := authSvc.Register(&authDomain.RegisterRequest{
+		Email: "consent@fullstack.dev", Password: "password123", FirstName: "Consent", LastName: "Test",
+		MarketingOptIn: true,
+	}, "203.0.113.7", "test")
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	userID := registered.User.ID
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api")
+	api.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	api.GET("/auth/me/consents", userHandler.GetConsents)
+	api.POST("/auth/me/consents", userHandler.UpdateConsent)
+	api.PUT("/user/preferences", userHandler.UpdatePreferences)
+
+	send := func(method, path string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&body).Encode(payload)
+		}
+		req := httptest.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	getRecord := func(t *testing.T) userDomain.ConsentRecordResponse {
+		w := send(http.MethodGet, "/api/auth/me/consents", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var record userDomain.ConsentRecordResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &record); err != nil {
+			t.Fatalf("Failed to decode consent record: %v", err)
+		}
+		return record
+	}
+
+	status := func(record userDomain.ConsentRecordResponse, consentType userDomain.ConsentType) userDomain.ConsentStatus {
+		for _, s := range record.Consents {
+			if s.Type == consentType {
+				return s
+			}
+		}
+		t.Fatalf("Consent %s missing from record", consentType)
+		return userDomain.ConsentStatus{}
+	}
+
+	marketingPreference := func(t *testing.T) bool {
+		var marketing bool
+		err := sqlDB.QueryRow(`SELECT COALESCE((preferences->'notifications'->>'marketing')::boolean, false)
+			FROM users WHERE id = $1`, userID).Scan(&marketing)
+		if err != nil {
+			t.Fatalf("Failed to read preferences: %v", err)
+		}
+		return marketing
+	}
+
+	t.Run("RegistrationRecordsConsents", func(t *testing.T) {
+		record := getRecord(t)
+		if len(record.History) != 3 {
+			t.Fatalf("Expected 3 registration consents, got %d", len(record.History))
+		}
+		for _, consent := range record.History {
+			if consent.Source != userDomain.ConsentSourceRegistration || !consent.Granted || consent.IPAddress != "203.0.113.7" {
+				t.Errorf("Unexpected registration consent: %+v", consent)
+			}
+		}
+
+		terms := status(record, userDomain.ConsentTermsOfService)
+		if terms.Version != "2024-01" || !terms.UpToDate || terms.RecordedAt == nil {
+			t.Errorf("Unexpected terms consent: %+v", terms)
+		}
+		if marketing := status(record, userDomain.ConsentMarketingEmails); marketing.Version != "3" {
+			t.Errorf("Expected marketing consent against privacy policy version 3, got %q", marketing.Version)
+		}
+		if !marketingPreference(t) {
+			t.Error("Expected the marketing preference to follow the registration opt-in")
+		}
+	})
+
+	t.Run("WithdrawMarketing", func(t *testing.T) {
+		w := send(http.MethodPost, "/api/auth/me/consents", map[string]any{"type": "marketing_emails", "granted": false})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		record := getRecord(t)
+		if status(record, userDomain.ConsentMarketingEmails).Granted {
+			t.Error("Expected marketing consent to be withdrawn")
+		}
+		if len(record.History) != 4 || record.History[0].Source != userDomain.ConsentSourceAPI {
+			t.Errorf("Expected the withdrawal to be appended to the history, got %+v", record.History)
+		}
+		if marketingPreference(t) {
+			t.Error("Expected the marketing preference to be turned off")
+		}
+	})
+
+	t.Run("PreferenceChangeRecordsConsent", func(t *testing.T) {
+		w := send(http.MethodPut, "/api/user/preferences", map[string]any{
+			"theme":         "dark",
+			"notifications": map[string]bool{"email": true, "marketing": true},
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		record := getRecord(t)
+		latest := record.History[0]
+		if latest.Type != userDomain.ConsentMarketingEmails || !latest.Granted || latest.Source != userDomain.ConsentSourcePreferences {
+			t.Errorf("Expected a marketing opt-in from preferences, got %+v", latest)
+		}
+
+		// Saving preferences without changing the opt-in records nothing new
+		send(http.MethodPut, "/api/user/preferences", map[string]any{
+			"theme":         "light",
+			"notifications": map[string]bool{"email": true, "marketing": true},
+		})
+		if after := getRecord(t); len(after.History) != len(record.History) {
+			t.Errorf("Expected %d consents, got %d", len(record.History), len(after.History))
+		}
+	})
+
+	t.Run("RequiredConsentCannotBeWithdrawn", func(t *testing.T) {
+		w := send(http.MethodPost, "/api/auth/me/consents", map[string]any{"type": "terms_of_service", "granted": false})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		w = send(http.MethodPost, "/api/auth/me/consents", map[string]any{"type": "cookies", "granted": true})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an unknown consent type, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("NewPolicyVersionNeedsAcceptance", func(t *testing.T) {
+		cfg.TermsVersion = "2024-06"
+		defer func() { cfg.TermsVersion = "2024-01" }()
+
+		if status(getRecord(t), userDomain.ConsentTermsOfService).UpToDate {
+			t.Error("Expected consent to older terms to be out of date")
+		}
+
+		w := send(http.MethodPost, "/api/auth/me/consents", map[string]any{"type": "terms_of_service", "granted": true})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		terms := status(getRecord(t), userDomain.ConsentTermsOfService)
+		if terms.Version != "2024-06" || !terms.UpToDate {
+			t.Errorf("Expected the new terms to be accepted, got %+v", terms)
+		}
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		w := send(http.MethodGet, "/api/auth/me/consents?download=true", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename="consent-record.json"` {
+			t.Errorf("Unexpected Content-Disposition %q", disposition)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_RefreshTokenPerDevice(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	newAuthService := func(perDevice bool) *authService.AuthService {
+		cfg := &config.Config{
+			Environment:           "test",
+			JWTSecret:             "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+			RefreshTokenPerDevice: perDevice,
+		}
+		return authService.NewAuthService(
+			cfg,
+			logger,
+			authRepo.NewUserRepository(db.DB),
+			authRepo.NewRefreshTokenRepository(db.DB),
+			authRepo.NewPasswordResetRepository(db.DB),
+			authService.NewJWTService(cfg, clock.New(), nil),
+			authService.NewEmailService(cfg, logger),
+			userRepository.NewAuditRepository(db.DB, nil, nil),
+			clock.New(),
+			nil,
+			nil,
+			nil,
+			nil,
+		)
+	}
+
+	login := func(svc *authService.AuthService, deviceID, ipAddress, userAgent string) *authDomain.AuthResponse {
+		response, err := svc.Login(&authDomain.LoginRequest{
+			Email: "admin@fullstack.dev", Password: "password", DeviceID: deviceID,
+		}, ipAddress, userAgent)
+		if err != nil {
+			t.Fatalf("Failed to log in: %v", err)
+		}
+		return response
+	}
+
+	activeTokens := func() int64 {
+		var count int64
+		db.DB.Model(&authDomain.RefreshToken{}).Where("user_id = ?", 1).Count(&count)
+		return count
+	}
+
+	reset := func() {
+		db.DB.Unscoped().Where("user_id = ?", 1).Delete(&authDomain.RefreshToken{})
+	}
+
+	t.Run("SameDeviceReplacesToken", func(t *testing.T) {
+		reset()
+		svc := newAuthService(true)
+
+		first := login(svc, "laptop-1", "10.0.0.1", "Firefox")
+		// The device ID identifies the device even when the network changes
+		second := login(svc, "laptop-1", "10.0.0.2", "Firefox")
+
+		if count := activeTokens(); count != 1 {
+			t.Errorf("Expected 1 refresh token for the device, got %d", count)
+		}
+		if _, err := svc.RefreshToken(&authDomain.RefreshTokenRequest{RefreshToken: first.RefreshToken}); err == nil {
+			t.Error("Expected the replaced refresh token to be rejected")
+		}
+		if _, err := svc.RefreshToken(&authDomain.RefreshTokenRequest{RefreshToken: second.RefreshToken}); err != nil {
+			t.Errorf("Expected the latest refresh token to work, got %v", err)
+		}
+	})
+
+	t.Run("FingerprintWithoutDeviceID", func(t *testing.T) {
+		reset()
+		svc := newAuthService(true)
+
+		login(svc, "", "10.0.0.1", "Firefox")
+		login(svc, "", "10.0.0.1", "Firefox")
+		if count := activeTokens(); count != 1 {
+			t.Errorf("Expected 1 refresh token for the fingerprinted device, got %d", count)
+		}
+
+		login(svc, "", "10.0.0.1", "Safari")
+		if count := activeTokens(); count != 2 {
+			t.Errorf("Expected a second refresh token for a different user agent, got %d", count)
+		}
+	})
+
+	t.Run("NewDeviceAddsToken", func(t *testing.T) {
+		reset()
+		svc := newAuthService(true)
+
+		login(svc, "laptop-1", "10.0.0.1", "Firefox")
+		login(svc, "phone-1", "10.0.0.1", "Firefox")
+		if count := activeTokens(); count != 2 {
+			t.Errorf("Expected 2 refresh tokens for 2 devices, got %d", count)
+		}
+	})
+
+	t.Run("PolicyDisabled", func(t *testing.T) {
+		reset()
+		svc := newAuthService(false)
+
+		login(svc, "laptop-1", "10.0.0.1", "Firefox")
+		login(svc, "laptop-1", "10.0.0.1", "Firefox")
+		if count := activeTokens(); count != 2 {
+			t.Errorf("Expected refresh tokens to accumulate with the policy disabled, got %d", count)
+		}
+	})
+}
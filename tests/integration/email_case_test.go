@@ -0,0 +1,318 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userDomain "github.com/acheevo/tfa/internal/user/domain"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+	userService "github.com/acheevo/tfa/internal/user/service"
+)
+
+func TestIntegration_CaseInsensitiveEmail(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	cfg := &config.Config{
+		Environment: "test",
+		JWTSecret:   "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+	}
+
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		authService.NewJWTService(cfg, clock.New(), nil),
+		authService.NewEmailService(cfg, logger),
+		auditRepo,
+		clock.New(),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		auditRepo,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+
+	userRepo := userRepository.NewUserRepository(db.DB)
+	userSvc := userService.NewUserService(
+		cfg,
+		logger,
+		userRepo,
+		auditRepo,
+		authRepo.NewUserRepository(db.DB),
+		userRepository.NewConsentRepository(db.DB),
+	)
+
+	register := func(email string) (*authDomain.AuthResponse, error) {
+		return authSvc.Register(&authDomain.RegisterRequest{
+			Email: email, Password: "password123", FirstName: "Case", LastName: "Test",
+		}, "127.0.0.1", "test")
+	}
+
+	t.Run("RegisterStoresLowercase", func(t *testing.T) {
+		response, err := register("User@x.com")
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		if response.User.Email != "user@x.com" {
+			t.Errorf("Expected stored email user@x.com, got %s", response.User.Email)
+		}
+	})
+
+	t.Run("RegisterRejectsDifferentCase", func(t *testing.T) {
+		if _, err := register("user@x.com"); err != authDomain.ErrUserAlreadyExists {
+			t.Errorf("Expected ErrUserAlreadyExists, got %v", err)
+		}
+		if _, err := register("USER@X.COM"); err != authDomain.ErrUserAlreadyExists {
+			t.Errorf("Expected ErrUserAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("LoginWithDifferentCase", func(t *testing.T) {
+		_, err := authSvc.Login(&authDomain.LoginRequest{Email: "USER@x.com", Password: "password123"}, "127.0.0.1", "test")
+		if err != nil {
+			t.Errorf("Expected login with a differently cased email to succeed, got %v", err)
+		}
+	})
+
+	t.Run("RegisterDomainPolicy", func(t *testing.T) {
+		cfg.RegistrationAllowedDomains = "x.com,*.corp.dev"
+		cfg.RegistrationBlockedDomains = "blocked.x.com"
+		cfg.RegistrationBlockDisposable = true
+		defer func() {
+			cfg.RegistrationAllowedDomains = ""
+			cfg.RegistrationBlockedDomains = ""
+			cfg.RegistrationBlockDisposable = false
+		}()
+
+		if _, err := register("allowed@eu.corp.dev"); err != nil {
+			t.Errorf("Expected an allowlisted subdomain to register, got %v", err)
+		}
+		if _, err := register("someone@gmail.com"); err != authDomain.ErrEmailDomainNotAllowed {
+			t.Errorf("Expected ErrEmailDomainNotAllowed, got %v", err)
+		}
+		if _, err := register("someone@blocked.x.com"); err != authDomain.ErrEmailDomainBlocked {
+			t.Errorf("Expected ErrEmailDomainBlocked, got %v", err)
+		}
+
+		cfg.RegistrationAllowedDomains = ""
+		if _, err := register("someone@mailinator.com"); err != authDomain.ErrDisposableEmail {
+			t.Errorf("Expected ErrDisposableEmail, got %v", err)
+		}
+	})
+
+	t.Run("DirectInsertRejected", func(t *testing.T) {
+		_, err := sqlDB.Exec(`
+		INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, created_at, updated_at)
+		VALUES ('USER@x.com', 'hash', 'Direct', 'Insert', 'user', 'active', true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`)
+		if err == nil {
+			t.Error("Expected the case-insensitive unique index to reject a mixed-case duplicate")
+		}
+	})
+
+	t.Run("AdminUpdateRejectsDifferentCase", func(t *testing.T) {
+		other, err := register("other@x.com")
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+
+		err = adminSvc.UpdateUser(1, other.User.ID, &adminDomain.AdminUpdateUserRequest{Email: "User@X.com", Reason: "support ticket"}, "127.0.0.1", "test")
+		if err != userDomain.ErrEmailAlreadyExists {
+			t.Errorf("Expected ErrEmailAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("AdminUpdateNormalizes", func(t *testing.T) {
+		other, err := register("mixed@x.com")
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+
+		err = adminSvc.UpdateUser(1, other.User.ID, &adminDomain.AdminUpdateUserRequest{Email: " Renamed@X.com ", Reason: "support ticket"}, "127.0.0.1", "test")
+		if err != nil {
+			t.Fatalf("Failed to update user: %v", err)
+		}
+
+		var stored authDomain.User
+		db.DB.First(&stored, other.User.ID)
+		if stored.Email != "renamed@x.com" {
+			t.Errorf("Expected stored email renamed@x.com, got %s", stored.Email)
+		}
+	})
+
+	t.Run("EntryPointsNormalizeIdentically", func(t *testing.T) {
+		entryPoints := map[string]func(email string) (uint, error){
+			"register": func(email string) (uint, error) {
+				response, err := register(email)
+				if err != nil {
+					return 0, err
+				}
+				return response.User.ID, nil
+			},
+			"admin update": func(email string) (uint, error) {
+				response, err := register("before-admin-update@x.com")
+				if err != nil {
+					return 0, err
+				}
+				return response.User.ID, adminSvc.UpdateUser(1, response.User.ID,
+					&adminDomain.AdminUpdateUserRequest{Email: email, Reason: "support ticket"}, "127.0.0.1", "test")
+			},
+			"email change": func(email string) (uint, error) {
+				response, err := register("before-email-change@x.com")
+				if err != nil {
+					return 0, err
+				}
+				return response.User.ID, userSvc.ChangeEmail(response.User.ID,
+					&userDomain.ChangeEmailRequest{NewEmail: email, Password: "password123"}, "127.0.0.1", "test")
+			},
+		}
+
+		for name, write := range entryPoints {
+			userID, err := write(" Entry@X.com ")
+			if err != nil {
+				t.Fatalf("%s: failed to write email: %v", name, err)
+			}
+
+			var stored authDomain.User
+			db.DB.First(&stored, userID)
+			if stored.Email != "entry@x.com" {
+				t.Errorf("%s: expected stored email entry@x.com, got %q", name, stored.Email)
+			}
+
+			// CheckEmailExists matches the stored form however the lookup is cased or padded
+			for _, lookup := range []string{"entry@x.com", " Entry@X.com ", "ENTRY@X.COM"} {
+				exists, err := userRepo.CheckEmailExists(lookup, 0, true)
+				if err != nil || !exists {
+					t.Errorf("%s: expected %q to match the stored email, got %v, %v", name, lookup, exists, err)
+				}
+			}
+
+			// Free the address for the next entry point
+			if err := db.DB.Unscoped().Delete(&authDomain.User{}, userID).Error; err != nil {
+				t.Fatalf("Failed to delete user: %v", err)
+			}
+		}
+	})
+
+	t.Run("SoftDeletedEmail", func(t *testing.T) {
+		deleted, err := register("deleted@x.com")
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		if err := db.DB.Delete(&authDomain.User{}, deleted.User.ID).Error; err != nil {
+			t.Fatalf("Failed to soft delete user: %v", err)
+		}
+
+		if _, err := register("Deleted@x.com"); err != authDomain.ErrUserAlreadyExists {
+			t.Errorf("Expected a soft-deleted user's email to stay taken, got %v", err)
+		}
+
+		cfg.UserDeletedEmailReuse = true
+		defer func() { cfg.UserDeletedEmailReuse = false }()
+
+		reused, err := register("Deleted@x.com")
+		if err != nil {
+			t.Fatalf("Expected the active email index to allow reusing a soft-deleted user's email, got %v", err)
+		}
+		if reused.User.ID == deleted.User.ID {
+			t.Error("Expected a new account for the reused email")
+		}
+		if _, err := register("deleted@x.com"); err != authDomain.ErrUserAlreadyExists {
+			t.Errorf("Expected ErrUserAlreadyExists for the active account, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	authTransport "github.com/acheevo/tfa/internal/auth/transport"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_EmailDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	// Email is switched per subtest; with no SMTP credentials an enabled service skips sending without dialing
+	cfg := &config.Config{
+		Environment: "test",
+		JWTSecret:   "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+	}
+
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		authService.NewJWTService(cfg, clock.New(), nil),
+		authService.NewEmailService(cfg, logger),
+		userRepository.NewAuditRepository(db.DB, nil, nil),
+		clock.New(),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	authHandler := authTransport.NewAuthHandler(cfg, logger, authSvc)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	auth := router.Group("/api/auth")
+	auth.POST("/register", authHandler.Register)
+	auth.POST("/forgot-password", authHandler.ForgotPassword)
+
+	post := func(path string, payload interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	countResets := func(email string) int64 {
+		var count int64
+		db.DB.Model(&authDomain.PasswordReset{}).Where("email = ?", email).Count(&count)
+		return count
+	}
+
+	for _, enabled := range []bool{false, true} {
+		cfg.EmailEnabled = enabled
+		name := "Disabled"
+		if enabled {
+			name = "Enabled"
+		}
+
+		t.Run("Register_"+name, func(t *testing.T) {
+			email := fmt.Sprintf("register-%t@fullstack.dev", enabled)
+			w := post("/api/auth/register", authDomain.RegisterRequest{
+				Email: email, Password: "password123", FirstName: "Mail", LastName: "Less",
+			})
+
+			// Registration never fails because of email
+			if w.Code != http.StatusCreated && w.Code != http.StatusOK {
+				t.Fatalf("Expected registration to succeed, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+
+		t.Run("ForgotPassword_"+name, func(t *testing.T) {
+			w := post("/api/auth/forgot-password", authDomain.ForgotPasswordRequest{Email: "admin@fullstack.dev"})
+
+			if !enabled {
+				if w.Code != http.StatusServiceUnavailable {
+					t.Fatalf("Expected 503 while email is disabled, got %d: %s", w.Code, w.Body.String())
+				}
+				var response authDomain.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if response.Details["reason"] != "email_disabled" {
+					t.Errorf("Expected email_disabled reason, got %v", response.Details)
+				}
+				if count := countResets("admin@fullstack.dev"); count != 0 {
+					t.Errorf("Expected no reset token while email is disabled, got %d", count)
+				}
+				return
+			}
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200 while email is enabled, got %d: %s", w.Code, w.Body.String())
+			}
+			if count := countResets("admin@fullstack.dev"); count != 1 {
+				t.Errorf("Expected 1 reset token, got %d", count)
+			}
+		})
+	}
+}
@@ -0,0 +1,486 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminRepository "github.com/acheevo/tfa/internal/admin/repository"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	adminTransport "github.com/acheevo/tfa/internal/admin/transport"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	"github.com/acheevo/tfa/internal/shared/email"
+	emailDomain "github.com/acheevo/tfa/internal/shared/email/domain"
+	"github.com/acheevo/tfa/internal/shared/email/queue"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_AdminEmailQueue(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	seeded := []emailDomain.EmailStatus{
+		emailDomain.StatusPending,
+		emailDomain.StatusPending,
+		emailDomain.StatusSent,
+		emailDomain.StatusFailed,
+	}
+	for i, status := range seeded {
+		queued := &emailDomain.QueuedEmail{
+			ID:         fmt.Sprintf("queued-%d", i),
+			MessageID:  fmt.Sprintf("message-%d", i),
+			From:       "noreply@fullstack.dev",
+			To:         `["user@fullstack.dev"]`,
+			Subject:    "Queued email",
+			TextBody:   "Hello",
+			Status:     status,
+			MaxRetries: 3,
+		}
+		if err := db.DB.Create(queued).Error; err != nil {
+			t.Fatalf("Failed to seed queued email: %v", err)
+		}
+	}
+
+	// Nothing listens on this port, so processed emails fail and are scheduled for retry
+	cfg := &config.Config{
+		Environment:   "test",
+		JWTSecret:     "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		EmailProvider: "smtp",
+		SMTPHost:      "127.0.0.1",
+		SMTPPort:      1,
+		EmailFrom:     "noreply@fullstack.dev",
+		EmailEnabled:  true,
+
+		EmailSuppressionList: "@blocked.dev",
+	}
+
+	queueSvc, err := email.NewService(cfg, logger, db.DB, nil, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create email service: %v", err)
+	}
+
+	newRouter := func(emailQueue adminDomain.EmailQueue) *gin.Engine {
+		adminSvc := adminService.NewAdminService(
+			cfg,
+			logger,
+			userRepository.NewUserRepository(db.DB),
+			userRepository.NewAuditRepository(db.DB, nil, nil),
+			nil,
+			nil,
+			nil,
+			emailQueue,
+			adminRepository.NewBroadcastRepository(db.DB),
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			clock.New(),
+		)
+		adminHandler := adminTransport.NewAdminHandler(cfg, logger, adminSvc)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		admin := router.Group("/api/admin")
+		admin.Use(func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Next()
+		})
+		admin.GET("/email/queue", adminHandler.GetEmailQueueStats)
+		admin.GET("/email/messages", adminHandler.ListQueuedEmails)
+		admin.GET("/email/events", adminHandler.ListEmailEvents)
+		admin.POST("/email/process", adminHandler.ProcessEmailQueue)
+		admin.DELETE("/email/scheduled/:message_id", adminHandler.CancelScheduledEmail)
+		admin.POST("/email/broadcast", adminHandler.StartBroadcast)
+		admin.GET("/email/broadcast/:id", adminHandler.GetBroadcast)
+		return router
+	}
+
+	router := newRouter(queueSvc)
+
+	t.Run("QueueStats", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/admin/email/queue", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var stats emailDomain.QueueStats
+		if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if stats.Pending != 2 || stats.Sent != 1 || stats.Failed != 1 {
+			t.Errorf("Unexpected queue stats: %+v", stats)
+		}
+	})
+
+	listEmails := func(query string) adminDomain.EmailListResponse {
+		req := httptest.NewRequest("GET", "/api/admin/email/messages"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response adminDomain.EmailListResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return response
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+
+	t.Run("ListEmails_StatusFilter", func(t *testing.T) {
+		response := listEmails("?status=pending&page_size=1")
+		if response.Pagination.Total != 2 || response.Pagination.TotalPages != 2 || !response.Pagination.HasNext {
+			t.Errorf("Unexpected pagination: %+v", response.Pagination)
+		}
+		if len(response.Emails) != 1 || response.Emails[0].Status != emailDomain.StatusPending {
+			t.Errorf("Expected one pending email on the first page, got %+v", response.Emails)
+		}
+
+		response = listEmails("?status=failed")
+		if response.Pagination.Total != 1 || response.Emails[0].MessageID != "message-3" {
+			t.Errorf("Expected only message-3 to have failed, got %+v", response.Emails)
+		}
+	})
+
+	t.Run("ListEmails_DateFilter", func(t *testing.T) {
+		if response := listEmails("?date_from=" + today); response.Pagination.Total != 4 {
+			t.Errorf("Expected 4 emails from today, got %d", response.Pagination.Total)
+		}
+		if response := listEmails("?date_to=" + yesterday); response.Pagination.Total != 0 {
+			t.Errorf("Expected no emails up to yesterday, got %d", response.Pagination.Total)
+		}
+
+		req := httptest.NewRequest("GET", "/api/admin/email/messages?date_from="+today+"&date_to="+yesterday, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("ListEvents_Filters", func(t *testing.T) {
+		events := []*emailDomain.EmailDeliveryEvent{
+			{ID: "event-1", EmailID: "queued-2", Event: "delivered", Timestamp: time.Now()},
+			{ID: "event-2", EmailID: "queued-3", Event: "bounced", Timestamp: time.Now()},
+			{ID: "event-3", EmailID: "queued-3", Event: "bounced", Timestamp: time.Now().AddDate(0, 0, -3)},
+		}
+		for _, event := range events {
+			if err := db.DB.Create(event).Error; err != nil {
+				t.Fatalf("Failed to seed delivery event: %v", err)
+			}
+		}
+
+		req := httptest.NewRequest("GET", "/api/admin/email/events?event=bounced&date_from="+today, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response adminDomain.EmailEventListResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response.Pagination.Total != 1 || response.Events[0].ID != "event-2" {
+			t.Errorf("Expected only event-2, got %+v", response.Events)
+		}
+	})
+
+	t.Run("ProcessQueue_DrainsPending", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/admin/email/process", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response adminDomain.EmailQueueProcessResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response.Before.Pending != 2 {
+			t.Errorf("Expected 2 pending before processing, got %d", response.Before.Pending)
+		}
+		if response.After.Pending != 0 || response.After.Retrying != 2 {
+			t.Errorf("Expected pending emails to move to retrying, got %+v", response.After)
+		}
+	})
+
+	cancel := func(messageID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", "/api/admin/email/scheduled/"+messageID, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("CancelScheduled_BeforeSend", func(t *testing.T) {
+		scheduledAt := time.Now().Add(time.Hour)
+		if err := queueSvc.Schedule(ctx, &emailDomain.EmailMessage{
+			ID:       "scheduled-reminder",
+			From:     "noreply@fullstack.dev",
+			To:       []string{"user@fullstack.dev"},
+			Subject:  "Reminder",
+			TextBody: "Don't forget",
+		}, scheduledAt); err != nil {
+			t.Fatalf("Failed to schedule email: %v", err)
+		}
+
+		if w := cancel("scheduled-reminder"); w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var status string
+		err := sqlDB.QueryRow(`SELECT status FROM queued_emails WHERE message_id = $1`, "scheduled-reminder").Scan(&status)
+		if err != nil {
+			t.Fatalf("Failed to read email status: %v", err)
+		}
+		if status != string(emailDomain.StatusCancelled) {
+			t.Errorf("Expected status %s, got %s", emailDomain.StatusCancelled, status)
+		}
+
+		// A canceled email cannot be canceled again
+		if w := cancel("scheduled-reminder"); w.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("CancelScheduled_TooLate", func(t *testing.T) {
+		// message-2 was seeded as already sent
+		if w := cancel("message-2"); w.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("CancelScheduled_NotFound", func(t *testing.T) {
+		if w := cancel("does-not-exist"); w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusNotFound, w.Code, w.Body.String())
+		}
+	})
+
+	broadcast := func(query, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/admin/email/broadcast"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Broadcast_RequiresConfirmWithoutFilter", func(t *testing.T) {
+		w := broadcast("", `{"template_id":"welcome","reason":"launch"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Broadcast_UnknownTemplate", func(t *testing.T) {
+		w := broadcast("?role=user", `{"template_id":"missing","reason":"launch"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Broadcast_MissingVariables", func(t *testing.T) {
+		w := broadcast("?role=user", `{"template_id":"announcement","reason":"launch"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Broadcast_QueuesOptedInRecipients", func(t *testing.T) {
+		recipients := []struct {
+			email       string
+			preferences string
+		}{
+			{"opted-in@fullstack.dev", `{"notifications":{"email":true}}`},
+			{"opted-out@fullstack.dev", `{"notifications":{"email":false}}`},
+			{"opted-in@blocked.dev", `{"notifications":{"email":true}}`},
+		}
+		for _, recipient := range recipients {
+			_, err := sqlDB.Exec(`
+				INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, preferences, created_at, updated_at)
+				VALUES ($1, 'hash', 'Test', 'User', 'user', 'active', true, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+				recipient.email, recipient.preferences)
+			if err != nil {
+				t.Fatalf("Failed to seed user: %v", err)
+			}
+		}
+
+		w := broadcast("?role=user",
+			`{"template_id":"announcement","reason":"launch","variables":{"subject":"News","message":"We launched"}}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+
+		var job adminDomain.BroadcastJob
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		deadline := time.Now().Add(10 * time.Second)
+		for job.Status == adminDomain.BroadcastStatusRunning && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+			req := httptest.NewRequest("GET", "/api/admin/email/broadcast/"+job.ID, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+		}
+
+		if job.Status != adminDomain.BroadcastStatusCompleted {
+			t.Fatalf("Expected broadcast to complete, got %+v", job)
+		}
+		if job.Matched != 3 || job.Queued != 1 || job.SkippedPreferences != 1 || job.SkippedSuppressed != 1 {
+			t.Errorf("Unexpected broadcast counts: %+v", job)
+		}
+
+		var queued int
+		err := sqlDB.QueryRow(`SELECT COUNT(*) FROM queued_emails WHERE metadata LIKE $1`, "%"+job.ID+"%").Scan(&queued)
+		if err != nil {
+			t.Fatalf("Failed to count broadcast emails: %v", err)
+		}
+		if queued != 1 {
+			t.Errorf("Expected 1 queued broadcast email, got %d", queued)
+		}
+	})
+
+	t.Run("Broadcast_NotFound", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/admin/email/broadcast/does-not-exist", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusNotFound, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("MarkFailed_HonorsRetryability", func(t *testing.T) {
+		failures := map[string]error{
+			"hard-bounce": fmt.Errorf("%w: 550 5.1.1 No such user", emailDomain.ErrProviderPermanentFailure),
+			"greylisted":  fmt.Errorf("%w: 451 4.7.1 Greylisted", emailDomain.ErrProviderTemporaryFailure),
+		}
+		for id := range failures {
+			queued := &emailDomain.QueuedEmail{
+				ID:         id,
+				MessageID:  "message-" + id,
+				From:       "noreply@fullstack.dev",
+				To:         `["user@fullstack.dev"]`,
+				Subject:    "Queued email",
+				TextBody:   "Hello",
+				Status:     emailDomain.StatusSending,
+				MaxRetries: 3,
+			}
+			if err := db.DB.Create(queued).Error; err != nil {
+				t.Fatalf("Failed to seed queued email: %v", err)
+			}
+		}
+
+		emailQueue := queue.NewDatabaseQueue(db.DB, logger, clock.New())
+		for id, failure := range failures {
+			if err := emailQueue.MarkFailed(ctx, id, failure); err != nil {
+				t.Fatalf("Failed to mark %s as failed: %v", id, err)
+			}
+		}
+
+		var hardBounce, greylisted emailDomain.QueuedEmail
+		db.DB.First(&hardBounce, "id = ?", "hard-bounce")
+		db.DB.First(&greylisted, "id = ?", "greylisted")
+		if hardBounce.Status != emailDomain.StatusFailed || hardBounce.AttemptCount != 1 {
+			t.Errorf("Expected permanent failure to fail after 1 attempt, got %s after %d", hardBounce.Status, hardBounce.AttemptCount)
+		}
+		if greylisted.Status != emailDomain.StatusRetrying || greylisted.ScheduledAt == nil {
+			t.Errorf("Expected temporary failure to be scheduled for retry, got %s", greylisted.Status)
+		}
+	})
+
+	t.Run("QueueUnavailable", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/admin/email/queue", nil)
+		w := httptest.NewRecorder()
+		newRouter(nil).ServeHTTP(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusServiceUnavailable, w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,137 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/database"
+	emailDomain "github.com/acheevo/tfa/internal/shared/email/domain"
+	"github.com/acheevo/tfa/internal/shared/email/queue"
+)
+
+func TestIntegration_EmailRetrySweep(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	emailQueue := queue.NewDatabaseQueue(db.DB, logger, clock.NewMock(now))
+
+	seed := func(id string, status emailDomain.EmailStatus, attempts int, permanent bool, age time.Duration) {
+		queued := &emailDomain.QueuedEmail{
+			ID:           id,
+			MessageID:    "message-" + id,
+			From:         "noreply@fullstack.dev",
+			To:           `["user@fullstack.dev"]`,
+			Subject:      "Queued email",
+			TextBody:     "Hello",
+			Status:       status,
+			AttemptCount: attempts,
+			MaxRetries:   3,
+			Permanent:    permanent,
+			CreatedAt:    now.Add(-age),
+		}
+		if err := db.DB.Create(queued).Error; err != nil {
+			t.Fatalf("Failed to seed queued email: %v", err)
+		}
+	}
+
+	seed("eligible", emailDomain.StatusFailed, 3, false, time.Hour)
+	seed("hard-bounce", emailDomain.StatusFailed, 1, true, time.Hour)
+	seed("exhausted", emailDomain.StatusFailed, 6, false, time.Hour)
+	seed("stale", emailDomain.StatusFailed, 3, false, 48*time.Hour)
+	seed("sent", emailDomain.StatusSent, 1, false, time.Hour)
+	seed("bounced", emailDomain.StatusPending, 0, false, time.Hour)
+
+	// A permanent provider failure marks the email as not worth retrying
+	bounce := fmt.Errorf("%w: 550 no such user", emailDomain.ErrProviderPermanentFailure)
+	if err := emailQueue.MarkFailed(ctx, "bounced", bounce); err != nil {
+		t.Fatalf("Failed to mark email failed: %v", err)
+	}
+
+	retried, err := emailQueue.RetryFailed(ctx, 6, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to retry failed emails: %v", err)
+	}
+	if retried != 1 {
+		t.Errorf("Expected 1 email requeued, got %d", retried)
+	}
+
+	expected := map[string]emailDomain.EmailStatus{
+		"eligible":    emailDomain.StatusPending,
+		"hard-bounce": emailDomain.StatusFailed,
+		"exhausted":   emailDomain.StatusFailed,
+		"stale":       emailDomain.StatusFailed,
+		"sent":        emailDomain.StatusSent,
+		"bounced":     emailDomain.StatusFailed,
+	}
+	for id, status := range expected {
+		var queued emailDomain.QueuedEmail
+		if err := db.DB.First(&queued, "id = ?", id).Error; err != nil {
+			t.Fatalf("Failed to load queued email %s: %v", id, err)
+		}
+		if queued.Status != status {
+			t.Errorf("Expected %s to be %q, got %q", id, status, queued.Status)
+		}
+	}
+
+	// Without an age limit stale emails are retried too
+	retried, err = emailQueue.RetryFailed(ctx, 6, 0)
+	if err != nil {
+		t.Fatalf("Failed to retry failed emails: %v", err)
+	}
+	if retried != 1 {
+		t.Errorf("Expected the stale email to be requeued, got %d", retried)
+	}
+}
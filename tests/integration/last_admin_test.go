@@ -0,0 +1,255 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_LastActiveAdminProtection(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev (user 1) with password "password"
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	var otherAdminID uint
+	err = sqlDB.QueryRow(`
+	INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, created_at, updated_at)
+	VALUES ('ops@fullstack.dev', $1, 'Ops', 'Admin', $2, $3, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	RETURNING id`, string(hashedPassword), string(authDomain.RoleAdmin), string(authDomain.StatusActive)).Scan(&otherAdminID)
+	if err != nil {
+		t.Fatalf("Failed to seed admin: %v", err)
+	}
+
+	// The acting admin always counts as a remaining admin, so the last admin can only be lost when
+	// the actor loses access while its request is in flight. Once armed, the next count query
+	// simulates a concurrent request suspending the acting admin right before the check.
+	var suspendActor atomic.Bool
+	err = db.DB.Callback().Query().Before("gorm:query").Register("test:suspend_actor", func(tx *gorm.DB) {
+		if _, isCount := tx.Statement.Dest.(*int64); isCount && suspendActor.CompareAndSwap(true, false) {
+			if _, err := sqlDB.Exec(`UPDATE users SET status = $1 WHERE id = 1`, string(authDomain.StatusSuspended)); err != nil {
+				t.Errorf("Failed to suspend acting admin: %v", err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to register query callback: %v", err)
+	}
+
+	cfg := &config.Config{
+		Environment:         "test",
+		JWTSecret:           "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		LastAdminProtection: true,
+	}
+	newAdminService := func(cfg *config.Config) *adminService.AdminService {
+		return adminService.NewAdminService(
+			cfg,
+			logger,
+			userRepository.NewUserRepository(db.DB),
+			userRepository.NewAuditRepository(db.DB, nil, nil),
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			clock.New(),
+		)
+	}
+	adminSvc := newAdminService(cfg)
+
+	reset := func(t *testing.T) {
+		_, err := sqlDB.Exec(`UPDATE users SET role = $1, status = $2, deleted_at = NULL WHERE id IN (1, $3)`,
+			string(authDomain.RoleAdmin), string(authDomain.StatusActive), otherAdminID)
+		if err != nil {
+			t.Fatalf("Failed to reset admins: %v", err)
+		}
+	}
+	expectUnchanged := func(t *testing.T) {
+		var role, status string
+		err := sqlDB.QueryRow(`SELECT role, status FROM users WHERE id = $1 AND deleted_at IS NULL`, otherAdminID).
+			Scan(&role, &status)
+		if err != nil {
+			t.Fatalf("Expected the admin to still exist: %v", err)
+		}
+		if role != string(authDomain.RoleAdmin) || status != string(authDomain.StatusActive) {
+			t.Errorf("Expected the last admin to be unchanged, got role %q status %q", role, status)
+		}
+	}
+
+	paths := map[string]func(svc *adminService.AdminService) error{
+		"RoleChange": func(svc *adminService.AdminService) error {
+			_, err := svc.UpdateUserRole(1, otherAdminID, &adminDomain.UpdateUserRoleRequest{
+				Role: authDomain.RoleUser, Reason: "admin rotation",
+			}, "127.0.0.1", "test")
+			return err
+		},
+		"Suspend": func(svc *adminService.AdminService) error {
+			_, err := svc.UpdateUserStatus(1, otherAdminID, &adminDomain.UpdateUserStatusRequest{
+				Status: authDomain.StatusSuspended, Reason: "suspicious activity",
+			}, "127.0.0.1", "test")
+			return err
+		},
+		"Deactivate": func(svc *adminService.AdminService) error {
+			return svc.UpdateUser(1, otherAdminID, &adminDomain.AdminUpdateUserRequest{
+				Status: authDomain.StatusInactive, Reason: "left the team",
+			}, "127.0.0.1", "test")
+		},
+		"Demote": func(svc *adminService.AdminService) error {
+			return svc.UpdateUser(1, otherAdminID, &adminDomain.AdminUpdateUserRequest{
+				Role: authDomain.RoleUser, Reason: "left the team",
+			}, "127.0.0.1", "test")
+		},
+		"Delete": func(svc *adminService.AdminService) error {
+			return svc.DeleteUsers(1, &adminDomain.DeleteUserRequest{Reason: "cleanup"},
+				[]uint{otherAdminID}, "127.0.0.1", "test")
+		},
+		"BulkSuspend": func(svc *adminService.AdminService) error {
+			return bulkError(svc.BulkUpdateUsers(1, &adminDomain.BulkUserActionRequest{
+				UserIDs: []uint{otherAdminID}, Action: adminDomain.BulkActionSuspend, Reason: "cleanup",
+			}, "127.0.0.1", "test"))
+		},
+		"BulkRoleChange": func(svc *adminService.AdminService) error {
+			role := authDomain.RoleUser
+			return bulkError(svc.BulkUpdateUsers(1, &adminDomain.BulkUserActionRequest{
+				UserIDs: []uint{otherAdminID}, Action: adminDomain.BulkActionRoleChange, Role: &role, Reason: "cleanup",
+			}, "127.0.0.1", "test"))
+		},
+		"BulkDelete": func(svc *adminService.AdminService) error {
+			return bulkError(svc.BulkUpdateUsers(1, &adminDomain.BulkUserActionRequest{
+				UserIDs: []uint{otherAdminID}, Action: adminDomain.BulkActionDelete, Reason: "cleanup",
+			}, "127.0.0.1", "test"))
+		},
+	}
+
+	for name, run := range paths {
+		t.Run(name+"BlockedForLastAdmin", func(t *testing.T) {
+			reset(t)
+			suspendActor.Store(true)
+			defer suspendActor.Store(false)
+
+			if err := run(adminSvc); err != adminDomain.ErrLastActiveAdmin {
+				t.Errorf("Expected ErrLastActiveAdmin, got %v", err)
+			}
+			expectUnchanged(t)
+		})
+
+		t.Run(name+"AllowedWhileAnotherAdminRemains", func(t *testing.T) {
+			reset(t)
+			if err := run(adminSvc); err != nil {
+				t.Errorf("Expected the change to succeed, got %v", err)
+			}
+		})
+	}
+
+	t.Run("ProtectionDisabled", func(t *testing.T) {
+		reset(t)
+		suspendActor.Store(true)
+		defer suspendActor.Store(false)
+
+		unprotected := *cfg
+		unprotected.LastAdminProtection = false
+		if err := paths["Suspend"](newAdminService(&unprotected)); err != nil {
+			t.Errorf("Expected the change to succeed with protection disabled, got %v", err)
+		}
+	})
+}
+
+// bulkError returns the error of the first failed item of a bulk action
+func bulkError(result *adminDomain.BulkActionResult, err error) error {
+	if err != nil {
+		return err
+	}
+	for _, item := range result.Results {
+		if !item.Success {
+			if item.Error == adminDomain.ErrLastActiveAdmin.Error() {
+				return adminDomain.ErrLastActiveAdmin
+			}
+			return fmt.Errorf("bulk action failed: %s", item.Error)
+		}
+	}
+	return nil
+}
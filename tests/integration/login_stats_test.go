@@ -0,0 +1,124 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_SummarizeFailedLogins(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	since := time.Now().Add(-time.Hour)
+
+	fail := func(ip, email string) {
+		err := auditRepo.CreateAuditEntry(nil, nil, authDomain.AuditActionLoginFailed, authDomain.AuditLevelWarning,
+			"auth", "Failed login attempt", ip, "test-agent", map[string]interface{}{"email": email})
+		if err != nil {
+			t.Fatalf("Failed to seed audit entry: %v", err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		fail("10.0.0.1", "victim@example.com")
+	}
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		fail("10.0.0.2", email)
+	}
+	fail("10.0.0.3", "victim@example.com")
+
+	t.Run("counts and ranks failures in the window", func(t *testing.T) {
+		summary, err := auditRepo.SummarizeFailedLogins(since, 10)
+		if err != nil {
+			t.Fatalf("Failed to summarize failed logins: %v", err)
+		}
+		if summary.TotalFailures != 9 || summary.UniqueIPs != 3 || summary.UniqueAccounts != 4 {
+			t.Errorf("Expected 9 failures from 3 IPs against 4 accounts, got %+v", summary)
+		}
+		if len(summary.TopIPs) != 3 || summary.TopIPs[0].Key != "10.0.0.1" || summary.TopIPs[0].Failures != 5 {
+			t.Errorf("Expected 10.0.0.1 to lead 3 IPs with 5 failures, got %+v", summary.TopIPs)
+		}
+		if len(summary.TopAccounts) != 4 || summary.TopAccounts[0].Key != "victim@example.com" ||
+			summary.TopAccounts[0].Failures != 6 {
+			t.Errorf("Expected victim@example.com to lead 4 accounts with 6 failures, got %+v", summary.TopAccounts)
+		}
+	})
+
+	t.Run("truncates the top lists to the limit", func(t *testing.T) {
+		summary, err := auditRepo.SummarizeFailedLogins(since, 2)
+		if err != nil {
+			t.Fatalf("Failed to summarize failed logins: %v", err)
+		}
+		if summary.TotalFailures != 9 {
+			t.Errorf("Expected the totals to ignore the limit, got %d failures", summary.TotalFailures)
+		}
+		if len(summary.TopIPs) != 2 || len(summary.TopAccounts) != 2 {
+			t.Errorf("Expected 2 IPs and 2 accounts, got %+v and %+v", summary.TopIPs, summary.TopAccounts)
+		}
+	})
+
+	t.Run("ignores failures before the window", func(t *testing.T) {
+		summary, err := auditRepo.SummarizeFailedLogins(time.Now().Add(time.Hour), 10)
+		if err != nil {
+			t.Fatalf("Failed to summarize failed logins: %v", err)
+		}
+		if summary.TotalFailures != 0 || len(summary.TopIPs) != 0 {
+			t.Errorf("Expected no failures, got %+v", summary)
+		}
+	})
+}
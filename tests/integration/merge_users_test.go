@@ -0,0 +1,260 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"golang.org/x/crypto/bcrypt"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminRepository "github.com/acheevo/tfa/internal/admin/repository"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	featureDomain "github.com/acheevo/tfa/internal/features/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_MergeUsers(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev (user 1) with password "password"; it becomes the super admin
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+	if _, err := sqlDB.Exec(`UPDATE users SET role = $1 WHERE id = 1`, string(authDomain.RoleSuperAdmin)); err != nil {
+		t.Fatalf("Failed to promote super admin: %v", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	seedUser := func(email string, role authDomain.UserRole) uint {
+		var id uint
+		err := sqlDB.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, created_at, updated_at)
+		VALUES ($1, $2, 'Test', 'User', $3, $4, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING id`, email, string(hashedPassword), string(role), string(authDomain.StatusActive)).Scan(&id)
+		if err != nil {
+			t.Fatalf("Failed to seed user: %v", err)
+		}
+		return id
+	}
+	adminID := seedUser("ops@fullstack.dev", authDomain.RoleAdmin)
+
+	cfg := &config.Config{
+		Environment: "test",
+		JWTSecret:   "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+	}
+
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		authService.NewJWTService(cfg, clock.New(), nil),
+		authService.NewEmailService(cfg, logger),
+		auditRepo,
+		clock.New(),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		auditRepo,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		authSvc,
+		nil,
+		adminRepository.NewMergeRepository(db.DB),
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+
+	merge := func(actorID, sourceID, targetID uint) (*adminDomain.MergeUsersResponse, error) {
+		return adminSvc.MergeUsers(actorID, &adminDomain.MergeUsersRequest{
+			SourceID: sourceID, TargetID: targetID, Reason: "duplicate account",
+		}, "127.0.0.1", "test")
+	}
+
+	t.Run("ReassignsAndSoftDeletesSource", func(t *testing.T) {
+		sourceID := seedUser("dupe@fullstack.dev", authDomain.RoleUser)
+		targetID := seedUser("keeper@fullstack.dev", authDomain.RoleUser)
+
+		// History on both sides of the source account
+		auditRepo.CreateAuditEntry(&sourceID, nil, authDomain.AuditActionLoginSuccess, authDomain.AuditLevelInfo,
+			"auth", "login", "127.0.0.1", "test", nil)
+		auditRepo.CreateAuditEntry(&adminID, &sourceID, authDomain.AuditActionUserUpdated, authDomain.AuditLevelInfo,
+			"admin", "update", "127.0.0.1", "test", nil)
+
+		// The target's override wins on a shared flag; the source's other override moves over
+		overrides := []featureDomain.FeatureOverride{
+			{Flag: featureDomain.FlagFileUploads, UserID: &sourceID, Enabled: true, CreatedBy: 1},
+			{Flag: featureDomain.FlagFileUploads, UserID: &targetID, Enabled: false, CreatedBy: 1},
+			{Flag: featureDomain.FlagSocialLogin, UserID: &sourceID, Enabled: true, CreatedBy: 1},
+		}
+		if err := db.DB.Create(&overrides).Error; err != nil {
+			t.Fatalf("Failed to seed overrides: %v", err)
+		}
+
+		loginReq := &authDomain.LoginRequest{Email: "dupe@fullstack.dev", Password: "password"}
+		if _, err := authSvc.Login(loginReq, "127.0.0.1", "test"); err != nil {
+			t.Fatalf("Failed to log in source: %v", err)
+		}
+
+		response, err := merge(1, sourceID, targetID)
+		if err != nil {
+			t.Fatalf("Failed to merge: %v", err)
+		}
+
+		// Login success, the admin update, and the source's login during this test
+		if response.AuditEntriesMoved < 3 {
+			t.Errorf("Expected at least 3 audit references moved, got %d", response.AuditEntriesMoved)
+		}
+		if response.FeatureOverridesMoved != 1 || response.FeatureOverridesDropped != 1 {
+			t.Errorf("Expected 1 override moved and 1 dropped, got %d and %d",
+				response.FeatureOverridesMoved, response.FeatureOverridesDropped)
+		}
+		if response.SessionsRevoked != 1 {
+			t.Errorf("Expected 1 session revoked, got %d", response.SessionsRevoked)
+		}
+
+		var remaining int64
+		db.DB.Model(&authDomain.AuditLog{}).Where("user_id = ? OR target_id = ?", sourceID, sourceID).Count(&remaining)
+		if remaining != 0 {
+			t.Errorf("Expected no audit entries left on the source, got %d", remaining)
+		}
+
+		var uploads featureDomain.FeatureOverride
+		db.DB.Where("user_id = ? AND flag = ?", targetID, featureDomain.FlagFileUploads).First(&uploads)
+		if uploads.Enabled {
+			t.Error("Expected the target's own override to win")
+		}
+
+		var source authDomain.User
+		if err := db.DB.First(&source, sourceID).Error; err == nil {
+			t.Error("Expected the source to be soft-deleted")
+		}
+		if err := db.DB.Unscoped().First(&source, sourceID).Error; err != nil {
+			t.Errorf("Expected the source row to be kept, got %v", err)
+		}
+
+		var merged int64
+		db.DB.Model(&authDomain.AuditLog{}).
+			Where("action = ? AND target_id = ?", authDomain.AuditActionUsersMerged, targetID).
+			Count(&merged)
+		if merged != 1 {
+			t.Errorf("Expected 1 merge audit entry, got %d", merged)
+		}
+	})
+
+	t.Run("RequiresSuperAdmin", func(t *testing.T) {
+		sourceID := seedUser("dupe2@fullstack.dev", authDomain.RoleUser)
+		targetID := seedUser("keeper2@fullstack.dev", authDomain.RoleUser)
+
+		if _, err := merge(adminID, sourceID, targetID); err != adminDomain.ErrSuperAdminRequired {
+			t.Errorf("Expected ErrSuperAdminRequired, got %v", err)
+		}
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		userID := seedUser("plain@fullstack.dev", authDomain.RoleUser)
+		elevatedID := seedUser("elevated@fullstack.dev", authDomain.RoleAdmin)
+		suspendedID := seedUser("suspended@fullstack.dev", authDomain.RoleUser)
+		db.DB.Model(&authDomain.User{}).Where("id = ?", suspendedID).Update("status", authDomain.StatusSuspended)
+
+		if _, err := merge(1, userID, userID); err != adminDomain.ErrMergeSameUser {
+			t.Errorf("Expected ErrMergeSameUser, got %v", err)
+		}
+		if _, err := merge(1, elevatedID, userID); err != adminDomain.ErrMergeRoleConflict {
+			t.Errorf("Expected ErrMergeRoleConflict, got %v", err)
+		}
+		if _, err := merge(1, userID, suspendedID); err != adminDomain.ErrMergeTargetInactive {
+			t.Errorf("Expected ErrMergeTargetInactive, got %v", err)
+		}
+
+		// A rejected merge leaves both accounts in place
+		var count int64
+		db.DB.Model(&authDomain.User{}).Where("id IN ?", []uint{userID, elevatedID}).Count(&count)
+		if count != 2 {
+			t.Errorf("Expected both accounts to remain, got %d", count)
+		}
+	})
+}
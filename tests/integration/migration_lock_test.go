@@ -0,0 +1,161 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/acheevo/tfa/internal/shared/database"
+)
+
+func TestIntegration_MigrationLock(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	t.Run("ConcurrentStartup", func(t *testing.T) {
+		const replicas = 4
+
+		var wg sync.WaitGroup
+		dbs := make([]*database.DB, replicas)
+		errs := make([]error, replicas)
+		for i := 0; i < replicas; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				dbs[i], errs[i] = database.NewWithMigrationLockTimeout(dsn, false, logger, "test", 30*time.Second)
+			}(i)
+		}
+		wg.Wait()
+
+		for i := 0; i < replicas; i++ {
+			if errs[i] != nil {
+				t.Errorf("Replica %d failed to start: %v", i, errs[i])
+				continue
+			}
+			if !dbs[i].MigrationsComplete() {
+				t.Errorf("Replica %d should report migrations complete", i)
+			}
+			dbs[i].Close()
+		}
+	})
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	t.Run("LockIsAcquiredSerially", func(t *testing.T) {
+		var active, maxActive, runs int32
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := db.WithMigrationLock(30*time.Second, func() error {
+					current := atomic.AddInt32(&active, 1)
+					for {
+						seen := atomic.LoadInt32(&maxActive)
+						if current <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, current) {
+							break
+						}
+					}
+					time.Sleep(300 * time.Millisecond)
+					atomic.AddInt32(&active, -1)
+					atomic.AddInt32(&runs, 1)
+					return nil
+				})
+				if err != nil {
+					t.Errorf("Failed to run under the migration lock: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if runs != 3 {
+			t.Errorf("Expected 3 runs under the lock, got %d", runs)
+		}
+		if maxActive != 1 {
+			t.Errorf("Expected migrations to run one at a time, saw %d at once", maxActive)
+		}
+	})
+
+	t.Run("TimesOutWhileHeld", func(t *testing.T) {
+		other, err := database.New(dsn, false, logger, "test")
+		if err != nil {
+			t.Fatalf("Failed to connect second instance: %v", err)
+		}
+		defer other.Close()
+
+		held := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			done <- db.WithMigrationLock(30*time.Second, func() error {
+				close(held)
+				<-release
+				return nil
+			})
+		}()
+		<-held
+
+		err = other.WithMigrationLock(500*time.Millisecond, func() error { return nil })
+		if !errors.Is(err, database.ErrMigrationLockTimeout) {
+			t.Errorf("Expected ErrMigrationLockTimeout while the lock is held, got %v", err)
+		}
+
+		close(release)
+		if err := <-done; err != nil {
+			t.Errorf("Lock holder failed: %v", err)
+		}
+
+		// Once released, the lock is available again
+		if err := other.WithMigrationLock(5*time.Second, func() error { return nil }); err != nil {
+			t.Errorf("Expected the released lock to be acquired, got %v", err)
+		}
+	})
+}
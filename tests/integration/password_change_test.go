@@ -0,0 +1,231 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	authTransport "github.com/acheevo/tfa/internal/auth/transport"
+	"github.com/acheevo/tfa/internal/middleware"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_ChangePasswordRevokesSessions(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev with password "password"
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	newRouter := func(sessionPolicy string) *gin.Engine {
+		cfg := &config.Config{
+			Environment:            "test",
+			JWTSecret:              "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+			PasswordChangeSessions: sessionPolicy,
+		}
+
+		jwtSvc := authService.NewJWTService(cfg, clock.New(), nil)
+		authSvc := authService.NewAuthService(
+			cfg,
+			logger,
+			authRepo.NewUserRepository(db.DB),
+			authRepo.NewRefreshTokenRepository(db.DB),
+			authRepo.NewPasswordResetRepository(db.DB),
+			jwtSvc,
+			authService.NewEmailService(cfg, logger),
+			userRepository.NewAuditRepository(db.DB, nil, nil),
+			clock.New(),
+			nil,
+			nil,
+			nil,
+			nil,
+		)
+		authHandler := authTransport.NewAuthHandler(cfg, logger, authSvc)
+		authMiddleware := middleware.NewAuthMiddleware(logger, authSvc)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		auth := router.Group("/api/auth")
+		auth.POST("/login", authHandler.Login)
+		auth.POST("/refresh", authHandler.RefreshToken)
+		auth.POST("/change-password", authMiddleware.RequireAuth(), authHandler.ChangePassword)
+		return router
+	}
+
+	login := func(t *testing.T, router *gin.Engine, password string) authDomain.AuthResponse {
+		body, _ := json.Marshal(authDomain.LoginRequest{Email: "admin@fullstack.dev", Password: password})
+		req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response authDomain.AuthResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return response
+	}
+
+	refresh := func(router *gin.Engine, refreshToken string) int {
+		req := httptest.NewRequest("POST", "/api/auth/refresh", nil)
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	changePassword := func(
+		t *testing.T, router *gin.Engine, session authDomain.AuthResponse, current, next string,
+	) authDomain.ChangePasswordResponse {
+		body, _ := json.Marshal(authDomain.ChangePasswordRequest{
+			CurrentPassword: current,
+			NewPassword:     next,
+			ConfirmPassword: next,
+		})
+		req := httptest.NewRequest("POST", "/api/auth/change-password", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+session.AccessToken)
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: session.RefreshToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response authDomain.ChangePasswordResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return response
+	}
+
+	t.Run("KeepCurrent_RevokesOtherSessions", func(t *testing.T) {
+		router := newRouter("keep_current")
+
+		current := login(t, router, "password")
+		others := []authDomain.AuthResponse{login(t, router, "password"), login(t, router, "password")}
+
+		response := changePassword(t, router, current, "password", "NewPassword123")
+
+		if response.SessionsRevoked != int64(len(others)) {
+			t.Errorf("Expected %d sessions revoked, got %d", len(others), response.SessionsRevoked)
+		}
+		if response.RefreshToken == "" || response.AccessToken == "" {
+			t.Fatal("Expected a fresh token pair for the current session")
+		}
+
+		for _, other := range others {
+			if code := refresh(router, other.RefreshToken); code != http.StatusUnauthorized {
+				t.Errorf("Expected other session to be revoked, refresh returned %d", code)
+			}
+		}
+
+		// The pre-change token of the current session is rotated out
+		if code := refresh(router, current.RefreshToken); code != http.StatusUnauthorized {
+			t.Errorf("Expected old current-session token to be rotated, refresh returned %d", code)
+		}
+		if code := refresh(router, response.RefreshToken); code != http.StatusOK {
+			t.Errorf("Expected fresh token to refresh, got %d", code)
+		}
+
+		var auditCount int
+		err := sqlDB.QueryRow(`SELECT COUNT(*) FROM audit_logs WHERE action = $1`,
+			string(authDomain.AuditActionSessionsRevoked)).Scan(&auditCount)
+		if err != nil {
+			t.Fatalf("Failed to count audit entries: %v", err)
+		}
+		if auditCount != 1 {
+			t.Errorf("Expected 1 sessions_revoked audit entry, got %d", auditCount)
+		}
+	})
+
+	t.Run("RevokeAll_EndsEverySession", func(t *testing.T) {
+		router := newRouter("revoke_all")
+
+		current := login(t, router, "NewPassword123")
+		other := login(t, router, "NewPassword123")
+
+		response := changePassword(t, router, current, "NewPassword123", "AnotherPassword123")
+
+		if response.RefreshToken != "" || response.AccessToken != "" {
+			t.Error("Expected no tokens when every session is revoked")
+		}
+
+		for _, session := range []authDomain.AuthResponse{current, other} {
+			if code := refresh(router, session.RefreshToken); code != http.StatusUnauthorized {
+				t.Errorf("Expected session to be revoked, refresh returned %d", code)
+			}
+		}
+	})
+}
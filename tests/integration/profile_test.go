@@ -0,0 +1,202 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+	userService "github.com/acheevo/tfa/internal/user/service"
+	userTransport "github.com/acheevo/tfa/internal/user/transport"
+)
+
+func TestIntegration_UpdateSelfConcurrency(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	cfg := &config.Config{
+		Environment: "test",
+		JWTSecret:   "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+	}
+
+	userSvc := userService.NewUserService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		userRepository.NewAuditRepository(db.DB, nil, nil),
+		authRepo.NewUserRepository(db.DB),
+		userRepository.NewConsentRepository(db.DB),
+	)
+	userHandler := userTransport.NewUserHandler(cfg, logger, userSvc)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api")
+	api.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	})
+	api.GET("/user/profile", userHandler.GetProfile)
+	api.PATCH("/auth/me", userHandler.UpdateSelf)
+
+	getETag := func(t *testing.T) string {
+		req := httptest.NewRequest("GET", "/api/user/profile", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			t.Fatal("Expected ETag header on profile response")
+		}
+		return etag
+	}
+
+	patch := func(body map[string]interface{}, ifMatch string) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest("PATCH", "/api/auth/me", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("PartialUpdate_KeepsOtherFields", func(t *testing.T) {
+		w := patch(map[string]interface{}{"first_name": "Patched"}, getETag(t))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var profile map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &profile); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if profile["first_name"] != "Patched" || profile["last_name"] != "User" {
+			t.Errorf("Expected only first_name to change, got %v %v", profile["first_name"], profile["last_name"])
+		}
+	})
+
+	t.Run("StaleETag_Conflict", func(t *testing.T) {
+		etag := getETag(t)
+
+		if w := patch(map[string]interface{}{"last_name": "First"}, etag); w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		if w := patch(map[string]interface{}{"last_name": "Second"}, etag); w.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("ConcurrentWrites_OnlyOneWins", func(t *testing.T) {
+		etag := getETag(t)
+
+		const writers = 8
+		codes := make([]int, writers)
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				codes[i] = patch(map[string]interface{}{"first_name": fmt.Sprintf("Writer%d", i)}, etag).Code
+			}(i)
+		}
+		wg.Wait()
+
+		succeeded, conflicted := 0, 0
+		for _, code := range codes {
+			switch code {
+			case http.StatusOK:
+				succeeded++
+			case http.StatusConflict:
+				conflicted++
+			default:
+				t.Errorf("unexpected status %d", code)
+			}
+		}
+
+		if succeeded != 1 || conflicted != writers-1 {
+			t.Errorf("expected 1 success and %d conflicts, got %d and %d", writers-1, succeeded, conflicted)
+		}
+	})
+
+	t.Run("RestrictedField_Rejected", func(t *testing.T) {
+		w := patch(map[string]interface{}{"email": "other@fullstack.dev"}, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,208 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_RegistrationApproval(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	cfg := &config.Config{
+		Environment: "test",
+		JWTSecret:   "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+	}
+
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	emailSvc := authService.NewEmailService(cfg, logger)
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		authService.NewJWTService(cfg, clock.New(), nil),
+		emailSvc,
+		auditRepo,
+		clock.New(),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		auditRepo,
+		emailSvc,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+
+	register := func(t *testing.T, email string) *authDomain.AuthResponse {
+		response, err := authSvc.Register(&authDomain.RegisterRequest{
+			Email: email, Password: "password123", FirstName: "New", LastName: "Signup",
+		}, "127.0.0.1", "test")
+		if err != nil {
+			t.Fatalf("Failed to register: %v", err)
+		}
+		return response
+	}
+
+	login := func(email string) error {
+		_, err := authSvc.Login(&authDomain.LoginRequest{Email: email, Password: "password123"}, "127.0.0.1", "test")
+		return err
+	}
+
+	t.Run("ActiveByDefault", func(t *testing.T) {
+		response := register(t, "open@fullstack.dev")
+
+		if response.User.Status != authDomain.StatusActive || response.User.Role != authDomain.RoleUser {
+			t.Errorf("Expected an active user, got status %q role %q", response.User.Status, response.User.Role)
+		}
+		if response.AccessToken == "" || response.PendingApproval {
+			t.Error("Expected tokens and no pending approval")
+		}
+		if err := login("open@fullstack.dev"); err != nil {
+			t.Errorf("Expected login to succeed, got %v", err)
+		}
+	})
+
+	t.Run("ApprovalRequired", func(t *testing.T) {
+		cfg.RegistrationDefaultStatus = string(authDomain.StatusPending)
+		defer func() { cfg.RegistrationDefaultStatus = "" }()
+
+		response := register(t, "waiting@fullstack.dev")
+		userID := response.User.ID
+
+		if response.User.Status != authDomain.StatusPending {
+			t.Errorf("Expected a pending user, got %q", response.User.Status)
+		}
+		if response.AccessToken != "" || response.RefreshToken != "" {
+			t.Error("Expected no tokens before approval")
+		}
+		if !response.PendingApproval {
+			t.Error("Expected the response to report pending approval")
+		}
+		if err := login("waiting@fullstack.dev"); err != authDomain.ErrUserPendingApproval {
+			t.Errorf("Expected ErrUserPendingApproval, got %v", err)
+		}
+
+		if err := adminSvc.ApproveUser(1, userID, &adminDomain.ApproveUserRequest{Reason: "known customer"}, "127.0.0.1", "test"); err != nil {
+			t.Fatalf("Failed to approve user: %v", err)
+		}
+		if err := login("waiting@fullstack.dev"); err != nil {
+			t.Errorf("Expected login to succeed after approval, got %v", err)
+		}
+
+		var audits int64
+		db.DB.Model(&authDomain.AuditLog{}).
+			Where("action = ? AND user_id = ? AND target_id = ?", authDomain.AuditActionUserApproved, 1, userID).
+			Count(&audits)
+		if audits != 1 {
+			t.Errorf("Expected 1 approval audit entry, got %d", audits)
+		}
+
+		// A second approval finds nothing to approve
+		err := adminSvc.ApproveUser(1, userID, &adminDomain.ApproveUserRequest{}, "127.0.0.1", "test")
+		if err != adminDomain.ErrUserNotPending {
+			t.Errorf("Expected ErrUserNotPending, got %v", err)
+		}
+	})
+
+	t.Run("ApproveActiveUser", func(t *testing.T) {
+		response := register(t, "already@fullstack.dev")
+
+		err := adminSvc.ApproveUser(1, response.User.ID, &adminDomain.ApproveUserRequest{}, "127.0.0.1", "test")
+		if err != adminDomain.ErrUserNotPending {
+			t.Errorf("Expected ErrUserNotPending, got %v", err)
+		}
+	})
+}
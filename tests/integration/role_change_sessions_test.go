@@ -0,0 +1,225 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"golang.org/x/crypto/bcrypt"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/middleware"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_RoleChangeRevokesSessions(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	seedAdmin := func(email string) uint {
+		var id uint
+		err := sqlDB.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, created_at, updated_at)
+		VALUES ($1, $2, 'Test', 'User', $3, $4, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING id`, email, string(hashedPassword), string(authDomain.RoleAdmin), string(authDomain.StatusActive)).Scan(&id)
+		if err != nil {
+			t.Fatalf("Failed to seed user: %v", err)
+		}
+		return id
+	}
+
+	cfg := &config.Config{
+		Environment:               "test",
+		JWTSecret:                 "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		RoleChangeRevokesSessions: true,
+	}
+
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		authService.NewJWTService(cfg, clock.New(), nil),
+		authService.NewEmailService(cfg, logger),
+		auditRepo,
+		clock.New(),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		auditRepo,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		authSvc,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+
+	authMiddleware := middleware.NewAuthMiddleware(logger, authSvc)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/admin/ping", authMiddleware.RequireAuth(), authMiddleware.RequireRole(authDomain.RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	ping := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/ping", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	login := func(email string) *authDomain.AuthResponse {
+		response, err := authSvc.Login(&authDomain.LoginRequest{Email: email, Password: "password"}, "127.0.0.1", "test")
+		if err != nil {
+			t.Fatalf("Failed to log in: %v", err)
+		}
+		return response
+	}
+
+	demote := func(userID uint) {
+		_, err := adminSvc.UpdateUserRole(1, userID, &adminDomain.UpdateUserRoleRequest{
+			Role: authDomain.RoleUser, Reason: "no longer on the operations team",
+		}, "127.0.0.1", "test")
+		if err != nil {
+			t.Fatalf("Failed to change role: %v", err)
+		}
+	}
+
+	t.Run("RevokedImmediately", func(t *testing.T) {
+		userID := seedAdmin("revoked@fullstack.dev")
+		session := login("revoked@fullstack.dev")
+		if code := ping(session.AccessToken); code != http.StatusOK {
+			t.Fatalf("Expected status %d before the role change, got %d", http.StatusOK, code)
+		}
+
+		demote(userID)
+
+		if code := ping(session.AccessToken); code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d after the role change, got %d", http.StatusUnauthorized, code)
+		}
+		if _, err := authSvc.RefreshToken(&authDomain.RefreshTokenRequest{RefreshToken: session.RefreshToken}); err == nil {
+			t.Error("Expected the refresh token to be revoked")
+		}
+
+		var revocations int64
+		db.DB.Model(&authDomain.AuditLog{}).
+			Where("action = ? AND target_id = ?", authDomain.AuditActionSessionsRevoked, userID).
+			Count(&revocations)
+		if revocations != 1 {
+			t.Errorf("Expected 1 session revocation audit entry, got %d", revocations)
+		}
+	})
+
+	t.Run("SoftTransition", func(t *testing.T) {
+		cfg.RoleChangeRevokesSessions = false
+		defer func() { cfg.RoleChangeRevokesSessions = true }()
+
+		userID := seedAdmin("soft@fullstack.dev")
+		session := login("soft@fullstack.dev")
+
+		demote(userID)
+
+		// The old token keeps its role until it expires
+		if code := ping(session.AccessToken); code != http.StatusOK {
+			t.Errorf("Expected status %d with revocation disabled, got %d", http.StatusOK, code)
+		}
+		if _, err := authSvc.RefreshToken(&authDomain.RefreshTokenRequest{RefreshToken: session.RefreshToken}); err != nil {
+			t.Errorf("Expected the refresh token to stay valid, got %v", err)
+		}
+	})
+}
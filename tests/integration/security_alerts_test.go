@@ -0,0 +1,323 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminRepository "github.com/acheevo/tfa/internal/admin/repository"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	adminTransport "github.com/acheevo/tfa/internal/admin/transport"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	"github.com/acheevo/tfa/internal/middleware"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	"github.com/acheevo/tfa/internal/shared/httpclient"
+	"github.com/acheevo/tfa/internal/shared/webhook"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_SecurityThresholdAlerts(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev (user 1) with password "password"
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	var memberID uint
+	err = sqlDB.QueryRow(`
+	INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, created_at, updated_at)
+	VALUES ('member@fullstack.dev', 'unused', 'Test', 'Member', $1, $2, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	RETURNING id`, string(authDomain.RoleUser), string(authDomain.StatusActive)).Scan(&memberID)
+	if err != nil {
+		t.Fatalf("Failed to seed member: %v", err)
+	}
+
+	// A burst of promotions through both role change paths and a handful of failed logins, all recent.
+	// Deletions stay below their threshold.
+	insertLog := func(action authDomain.AuditAction, metadata string, age time.Duration) {
+		_, err := sqlDB.Exec(`
+		INSERT INTO audit_logs (user_id, action, level, resource, description, metadata, created_at)
+		VALUES (1, $1, 'info', 'admin', 'seeded', $2::jsonb, $3)`, string(action), metadata, time.Now().Add(-age))
+		if err != nil {
+			t.Fatalf("Failed to seed audit log: %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		insertLog(authDomain.AuditActionUserRoleChanged, `{"new_role":"admin"}`, time.Duration(i)*time.Minute)
+		insertLog(authDomain.AuditActionUserUpdated, `{"new_role":"super_admin"}`, time.Duration(i)*time.Minute)
+	}
+	// Outside the window, and a demotion, neither count
+	insertLog(authDomain.AuditActionUserRoleChanged, `{"new_role":"admin"}`, 3*time.Hour)
+	insertLog(authDomain.AuditActionUserRoleChanged, `{"new_role":"user"}`, time.Minute)
+	for i := 0; i < 3; i++ {
+		insertLog(authDomain.AuditActionLoginFailed, `{"email":"member@fullstack.dev"}`, time.Minute)
+	}
+	insertLog(authDomain.AuditActionUserDeleted, `{}`, time.Minute)
+
+	deliveries := make(chan webhook.Payload, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(webhook.SignatureHeader) != "sha256="+webhook.Sign("hook-secret", body) {
+			t.Errorf("Unexpected webhook signature")
+		}
+		var payload webhook.Payload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("Failed to decode webhook payload: %v", err)
+		}
+		deliveries <- payload
+	}))
+	defer receiver.Close()
+
+	cfg := &config.Config{
+		Environment:                  "test",
+		JWTSecret:                    "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		StepUpTokenTTL:               "5m",
+		HTTPClientTimeout:            "2s",
+		SecurityAlertWindow:          "1h",
+		SecurityAlertAdminPromotions: 4,
+		SecurityAlertFailedLogins:    3,
+		SecurityAlertDeletions:       5,
+		SecurityAlertResponses:       "require_step_up,tighten_login_limits",
+		SecurityAlertResponseTTL:     "30m",
+	}
+
+	rateLimiter := middleware.NewRateLimiter(logger, nil, clock.New(), 10, time.Minute)
+	alertRepo := adminRepository.NewSecurityAlertRepository(db.DB)
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	sender := webhook.NewSender(receiver.URL, "hook-secret", httpclient.New(cfg, logger, "security_alert_webhook", nil))
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		auditRepo,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		alertRepo,
+		sender,
+		rateLimiter,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		authService.NewJWTService(cfg, clock.New(), nil),
+		authService.NewEmailService(cfg, logger),
+		auditRepo,
+		clock.New(),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	authMiddleware := middleware.NewAuthMiddleware(logger, authSvc)
+	adminHandler := adminTransport.NewAdminHandler(cfg, logger, adminSvc)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PUT("/api/admin/users/:id/role", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	}, authMiddleware.RequireStepUpWhen(adminHandler.StepUpRequiredForChanges), adminHandler.UpdateUserRole)
+
+	changeRole := func(stepUpToken string) int {
+		body, _ := json.Marshal(map[string]string{"role": "admin", "reason": "grant admin access for on-call rotation"})
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/admin/users/%d/role", memberID), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		if stepUpToken != "" {
+			req.Header.Set(middleware.StepUpTokenHeader, stepUpToken)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	alertsByType := map[string]string{}
+
+	t.Run("BreachRaisesAlerts", func(t *testing.T) {
+		raised, err := adminSvc.CheckSecurityThresholds(ctx)
+		if err != nil {
+			t.Fatalf("Failed to check thresholds: %v", err)
+		}
+		if len(raised) != 2 {
+			t.Fatalf("Expected 2 alerts, got %d", len(raised))
+		}
+		for _, alert := range raised {
+			alertsByType[alert.Type] = alert.ID
+		}
+
+		promotion := raised[0]
+		if promotion.Type != adminDomain.AlertTypeAdminPromotions || promotion.Response != adminDomain.ResponseRequireStepUp {
+			t.Errorf("Unexpected promotion alert: %+v", promotion)
+		}
+		if count, _ := promotion.Data["count"].(int64); count != 4 {
+			t.Errorf("Expected 4 promotions in the window, got %v", promotion.Data["count"])
+		}
+		if raised[1].Type != adminDomain.AlertTypeFailedLogins || raised[1].Response != adminDomain.ResponseTightenLoginLimits {
+			t.Errorf("Unexpected failed login alert: %+v", raised[1])
+		}
+
+		stored, err := alertRepo.GetByID(promotion.ID)
+		if err != nil {
+			t.Fatalf("Expected the alert to be stored: %v", err)
+		}
+		if stored.ResponseUntil == nil || time.Until(*stored.ResponseUntil) < 25*time.Minute {
+			t.Errorf("Expected the response to last 30 minutes, got %v", stored.ResponseUntil)
+		}
+	})
+
+	t.Run("AlertsDeliveredToWebhook", func(t *testing.T) {
+		received := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			select {
+			case payload := <-deliveries:
+				if payload.Event != "security_alert" {
+					t.Errorf("Unexpected webhook event %q", payload.Event)
+				}
+				if data, ok := payload.Data.(map[string]any); ok {
+					received[fmt.Sprint(data["type"])] = true
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Expected 2 webhook deliveries, got %d", i)
+			}
+		}
+		if !received[adminDomain.AlertTypeAdminPromotions] || !received[adminDomain.AlertTypeFailedLogins] {
+			t.Errorf("Unexpected deliveries: %v", received)
+		}
+	})
+
+	t.Run("SustainedBreachNotRepeated", func(t *testing.T) {
+		raised, err := adminSvc.CheckSecurityThresholds(ctx)
+		if err != nil {
+			t.Fatalf("Failed to check thresholds: %v", err)
+		}
+		if len(raised) != 0 {
+			t.Errorf("Expected no new alerts within the same window, got %d", len(raised))
+		}
+	})
+
+	t.Run("ResponsesApplied", func(t *testing.T) {
+		if remaining := rateLimiter.GetRemainingRequests("login:203.0.113.9"); remaining != 5 {
+			t.Errorf("Expected login attempts halved to 5, got %d", remaining)
+		}
+
+		if code := changeRole(""); code != http.StatusForbidden {
+			t.Errorf("Expected status %d without step-up, got %d", http.StatusForbidden, code)
+		}
+
+		stepUp, err := authSvc.StepUp(1, &authDomain.StepUpRequest{Password: "password"}, "127.0.0.1", "test")
+		if err != nil {
+			t.Fatalf("Failed to step up: %v", err)
+		}
+		if code := changeRole(stepUp.StepUpToken); code != http.StatusOK {
+			t.Errorf("Expected status %d with step-up, got %d", http.StatusOK, code)
+		}
+	})
+
+	t.Run("ResolvingLiftsResponses", func(t *testing.T) {
+		for _, alertType := range []string{adminDomain.AlertTypeAdminPromotions, adminDomain.AlertTypeFailedLogins} {
+			_, err := adminSvc.ResolveSecurityAlert(1, alertsByType[alertType],
+				&adminDomain.ResolveSecurityAlertRequest{Notes: "Planned onboarding of the new ops team"}, "127.0.0.1", "test")
+			if err != nil {
+				t.Fatalf("Failed to resolve %s alert: %v", alertType, err)
+			}
+		}
+
+		if code := changeRole(""); code != http.StatusOK {
+			t.Errorf("Expected status %d once resolved, got %d", http.StatusOK, code)
+		}
+		if remaining := rateLimiter.GetRemainingRequests("login:203.0.113.9"); remaining != 10 {
+			t.Errorf("Expected login attempts restored to 10, got %d", remaining)
+		}
+
+		resolved := true
+		list, err := adminSvc.ListSecurityAlerts(1, &adminDomain.SecurityAlertListRequest{Page: 1, PageSize: 20, Resolved: &resolved})
+		if err != nil {
+			t.Fatalf("Failed to list alerts: %v", err)
+		}
+		if list.Pagination.Total != 2 || list.Alerts[0].ResolvedBy == nil {
+			t.Errorf("Expected 2 resolved alerts, got %+v", list.Pagination)
+		}
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,8 +26,10 @@ import (
 	authRepo "github.com/acheevo/tfa/internal/auth/repository"
 	authService "github.com/acheevo/tfa/internal/auth/service"
 	authTransport "github.com/acheevo/tfa/internal/auth/transport"
+	"github.com/acheevo/tfa/internal/shared/clock"
 	"github.com/acheevo/tfa/internal/shared/config"
 	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
 )
 
 func TestIntegration_SimpleAuth(t *testing.T) {
@@ -92,19 +95,27 @@ func TestIntegration_SimpleAuth(t *testing.T) {
 
 	// Create test config
 	cfg := &config.Config{
-		JWTSecret: "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
-		SMTPHost:  "localhost",
-		SMTPPort:  587,
-		EmailFrom: "test@example.com",
+		JWTSecret:                      "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		SMTPHost:                       "localhost",
+		SMTPPort:                       587,
+		EmailFrom:                      "test@example.com",
+		LoginVerificationNudge:         true,
+		LoginVerificationNudgeInterval: "24h",
 	}
 
 	// Initialize services
 	userRepo := authRepo.NewUserRepository(db.DB)
 	refreshTokenRepo := authRepo.NewRefreshTokenRepository(db.DB)
 	passwordResetRepo := authRepo.NewPasswordResetRepository(db.DB)
-	jwtSvc := authService.NewJWTService(cfg)
+	jwtSvc := authService.NewJWTService(cfg, clock.New(), nil)
 	emailSvc := authService.NewEmailService(cfg, logger)
-	authSvc := authService.NewAuthService(cfg, logger, userRepo, refreshTokenRepo, passwordResetRepo, jwtSvc, emailSvc)
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	authSvc := authService.NewAuthService(
+		cfg, logger, userRepo, refreshTokenRepo, passwordResetRepo, jwtSvc, emailSvc, auditRepo, clock.New(), nil,
+		nil,
+		nil,
+		nil,
+	)
 
 	// Initialize handler
 	authHandler := authTransport.NewAuthHandler(cfg, logger, authSvc)
@@ -186,6 +197,101 @@ func TestIntegration_SimpleAuth(t *testing.T) {
 			t.Errorf("Expected user email newuser@fullstack.dev, got %s", response.User.Email)
 		}
 	})
+
+	login := func(t *testing.T, email, password string) authDomain.AuthResponse {
+		body, _ := json.Marshal(authDomain.LoginRequest{Email: email, Password: password})
+		req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response authDomain.AuthResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return response
+	}
+
+	t.Run("Login_VerifiedUser_NoVerificationReminder", func(t *testing.T) {
+		response := login(t, "admin@fullstack.dev", "password")
+		if response.VerificationReminder != nil {
+			t.Errorf("Expected no verification reminder for verified user, got %+v", response.VerificationReminder)
+		}
+	})
+
+	t.Run("Login_UnverifiedUser_VerificationReminder", func(t *testing.T) {
+		response := login(t, "newuser@fullstack.dev", "newpassword123")
+		if response.VerificationReminder == nil {
+			t.Fatal("Expected verification reminder for unverified user")
+		}
+		if !response.VerificationReminder.EmailSent {
+			t.Error("Expected verification reminder email on first unverified login")
+		}
+
+		// A second login within the interval surfaces the reminder without emailing again
+		response = login(t, "newuser@fullstack.dev", "newpassword123")
+		if response.VerificationReminder == nil {
+			t.Fatal("Expected verification reminder on repeated login")
+		}
+		if response.VerificationReminder.EmailSent {
+			t.Error("Expected repeated login not to send another reminder email")
+		}
+	})
+
+	postLogin := func(email, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(authDomain.LoginRequest{Email: email, Password: password})
+		req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Login_RequireVerified_Disabled", func(t *testing.T) {
+		if w := postLogin("newuser@fullstack.dev", "newpassword123"); w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Login_RequireVerified_Enabled", func(t *testing.T) {
+		cfg.RequireVerifiedEmailForLogin = true
+		defer func() { cfg.RequireVerifiedEmailForLogin = false }()
+
+		w := postLogin("newuser@fullstack.dev", "newpassword123")
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusForbidden, w.Code, w.Body.String())
+		}
+
+		var response authDomain.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response.Details["reason"] != "email_not_verified" {
+			t.Errorf("Expected reason email_not_verified, got %+v", response.Details)
+		}
+		if response.Details["hint"] == "" {
+			t.Error("Expected a hint about resending the verification email")
+		}
+		if strings.Contains(w.Body.String(), "access_token") {
+			t.Error("Expected no tokens for an unverified user")
+		}
+
+		// A wrong password must not reveal that the account is unverified
+		if w := postLogin("newuser@fullstack.dev", "wrongpassword"); w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d for a wrong password, got %d", http.StatusUnauthorized, w.Code)
+		}
+
+		// Verified users are unaffected
+		if w := postLogin("admin@fullstack.dev", "password"); w.Code != http.StatusOK {
+			t.Errorf("expected status %d for a verified user, got %d", http.StatusOK, w.Code)
+		}
+	})
 }
 
 func seedSimpleTestData(db *sql.DB) error {
@@ -0,0 +1,289 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"golang.org/x/crypto/bcrypt"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	adminTransport "github.com/acheevo/tfa/internal/admin/transport"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	authRepo "github.com/acheevo/tfa/internal/auth/repository"
+	authService "github.com/acheevo/tfa/internal/auth/service"
+	authTransport "github.com/acheevo/tfa/internal/auth/transport"
+	"github.com/acheevo/tfa/internal/middleware"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_SuperAdminGuards(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev (user 1) with password "password"; it becomes the super admin
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+	if _, err := sqlDB.Exec(`UPDATE users SET role = $1 WHERE id = 1`, string(authDomain.RoleSuperAdmin)); err != nil {
+		t.Fatalf("Failed to promote super admin: %v", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	seedUser := func(email string, role authDomain.UserRole) uint {
+		var id uint
+		err := sqlDB.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, created_at, updated_at)
+		VALUES ($1, $2, 'Test', 'User', $3, $4, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING id`, email, string(hashedPassword), string(role), string(authDomain.StatusActive)).Scan(&id)
+		if err != nil {
+			t.Fatalf("Failed to seed user: %v", err)
+		}
+		return id
+	}
+	adminID := seedUser("ops@fullstack.dev", authDomain.RoleAdmin)
+	userID := seedUser("member@fullstack.dev", authDomain.RoleUser)
+
+	cfg := &config.Config{
+		Environment:    "test",
+		JWTSecret:      "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+		StepUpTokenTTL: "5m",
+	}
+
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	jwtSvc := authService.NewJWTService(cfg, clock.New(), nil)
+	authSvc := authService.NewAuthService(
+		cfg,
+		logger,
+		authRepo.NewUserRepository(db.DB),
+		authRepo.NewRefreshTokenRepository(db.DB),
+		authRepo.NewPasswordResetRepository(db.DB),
+		jwtSvc,
+		authService.NewEmailService(cfg, logger),
+		auditRepo,
+		clock.New(),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		auditRepo,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+
+	authMiddleware := middleware.NewAuthMiddleware(logger, authSvc)
+	authHandler := authTransport.NewAuthHandler(cfg, logger, authSvc)
+	adminHandler := adminTransport.NewAdminHandler(cfg, logger, adminSvc)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/auth/step-up", authMiddleware.RequireAuth(), authHandler.StepUp)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware.RequireAuth(), authMiddleware.RequireSuperAdminStepUp())
+	admin.PUT("/users/:id/role", adminHandler.UpdateUserRole)
+
+	accessToken := func(id uint) string {
+		var user authDomain.User
+		if err := db.DB.First(&user, id).Error; err != nil {
+			t.Fatalf("Failed to load user: %v", err)
+		}
+		token, err := jwtSvc.GenerateAccessToken(&user)
+		if err != nil {
+			t.Fatalf("Failed to generate access token: %v", err)
+		}
+		return token
+	}
+
+	send := func(method, path, token, stepUp string, payload any) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if stepUp != "" {
+			req.Header.Set(middleware.StepUpTokenHeader, stepUp)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	roleChange := func(role authDomain.UserRole) adminDomain.UpdateUserRoleRequest {
+		return adminDomain.UpdateUserRoleRequest{Role: role, Reason: "on-call administrator rotation"}
+	}
+
+	t.Run("AdminCannotTouchSuperAdmin", func(t *testing.T) {
+		_, err := adminSvc.UpdateUserRole(adminID, 1, &adminDomain.UpdateUserRoleRequest{
+			Role: authDomain.RoleAdmin, Reason: "demote the super admin",
+		}, "127.0.0.1", "test")
+		if err != adminDomain.ErrSuperAdminRequired {
+			t.Errorf("Expected ErrSuperAdminRequired, got %v", err)
+		}
+
+		_, err = adminSvc.UpdateUserRole(adminID, userID, &adminDomain.UpdateUserRoleRequest{
+			Role: authDomain.RoleSuperAdmin, Reason: "grant super admin",
+		}, "127.0.0.1", "test")
+		if err != adminDomain.ErrSuperAdminRequired {
+			t.Errorf("Expected ErrSuperAdminRequired, got %v", err)
+		}
+
+		force := true
+		err = adminSvc.DeleteUsers(adminID, &adminDomain.DeleteUserRequest{Reason: "cleanup", Force: &force},
+			[]uint{1}, "127.0.0.1", "test")
+		if err != adminDomain.ErrSuperAdminRequired {
+			t.Errorf("Expected ErrSuperAdminRequired, got %v", err)
+		}
+	})
+
+	t.Run("SuperAdminChangesRequireStepUp", func(t *testing.T) {
+		token := accessToken(1)
+		path := fmt.Sprintf("/api/admin/users/%d/role", adminID)
+
+		w := send(http.MethodPut, path, token, "", roleChange(authDomain.RoleSuperAdmin))
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("Expected status %d without step-up, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
+		}
+
+		w = send(http.MethodPost, "/api/auth/step-up", token, "", authDomain.StepUpRequest{Password: "wrong-password"})
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected status %d for a wrong password, got %d", http.StatusUnauthorized, w.Code)
+		}
+
+		w = send(http.MethodPost, "/api/auth/step-up", token, "", authDomain.StepUpRequest{Password: "password"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d from step-up, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var stepUp authDomain.StepUpResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &stepUp); err != nil {
+			t.Fatalf("Failed to decode step-up response: %v", err)
+		}
+
+		// A step-up token is bound to the user it was issued to
+		otherStepUp, _, err := jwtSvc.GenerateStepUpToken(&authDomain.User{ID: adminID, Role: authDomain.RoleAdmin})
+		if err != nil {
+			t.Fatalf("Failed to generate step-up token: %v", err)
+		}
+		w = send(http.MethodPut, path, token, otherStepUp, roleChange(authDomain.RoleSuperAdmin))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected another user's step-up token to be rejected, got %d", w.Code)
+		}
+
+		w = send(http.MethodPut, path, token, stepUp.StepUpToken, roleChange(authDomain.RoleSuperAdmin))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with step-up, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		// The escalation is allowed, but the admin is told it is unusual
+		var response authDomain.MessageResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode role change response: %v", err)
+		}
+		if !slices.Contains(response.Warnings, "role change involves privilege escalation") {
+			t.Errorf("Expected a privilege escalation warning, got %v", response.Warnings)
+		}
+
+		var role string
+		if err := sqlDB.QueryRow(`SELECT role FROM users WHERE id = $1`, adminID).Scan(&role); err != nil {
+			t.Fatalf("Failed to read role: %v", err)
+		}
+		if role != string(authDomain.RoleSuperAdmin) {
+			t.Errorf("Expected role %q, got %q", authDomain.RoleSuperAdmin, role)
+		}
+
+		var level string
+		err = sqlDB.QueryRow(`SELECT level FROM audit_logs WHERE action = $1 AND user_id = 1`,
+			string(authDomain.AuditActionSuperAdminAction)).Scan(&level)
+		if err != nil {
+			t.Fatalf("Failed to read super admin audit entry: %v", err)
+		}
+		if level != string(authDomain.AuditLevelWarning) {
+			t.Errorf("Expected warning level audit entry, got %q", level)
+		}
+	})
+}
@@ -0,0 +1,240 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	adminDomain "github.com/acheevo/tfa/internal/admin/domain"
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	adminTransport "github.com/acheevo/tfa/internal/admin/transport"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_UserAuditHistory(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev (user 1) with password "password"
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	var memberID uint
+	err = sqlDB.QueryRow(`
+	INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, created_at, updated_at)
+	VALUES ('member@fullstack.dev', 'unused', 'Test', 'Member', $1, $2, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	RETURNING id`, string(authDomain.RoleUser), string(authDomain.StatusActive)).Scan(&memberID)
+	if err != nil {
+		t.Fatalf("Failed to seed member: %v", err)
+	}
+
+	// 30 logins by the member, one per day, then 5 admin actions against them on the last day
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	insertLog := func(userID, targetID *uint, action authDomain.AuditAction, level authDomain.AuditLevel, description string, at time.Time) {
+		_, err := sqlDB.Exec(`
+		INSERT INTO audit_logs (user_id, target_id, action, level, resource, description, created_at)
+		VALUES ($1, $2, $3, $4, 'user', $5, $6)`, userID, targetID, string(action), string(level), description, at)
+		if err != nil {
+			t.Fatalf("Failed to seed audit log: %v", err)
+		}
+	}
+	adminID := uint(1)
+	for i := 0; i < 30; i++ {
+		insertLog(&memberID, nil, authDomain.AuditActionLoginSuccess, authDomain.AuditLevelInfo,
+			fmt.Sprintf("Login %d", i), base.AddDate(0, 0, i))
+	}
+	for i := 0; i < 5; i++ {
+		insertLog(&adminID, &memberID, authDomain.AuditActionUserStatusChanged, authDomain.AuditLevelWarning,
+			fmt.Sprintf("Status changed: Suspicious Activity %d", i), base.AddDate(0, 0, 29).Add(time.Duration(i+1)*time.Minute))
+	}
+	// Entries about someone else never show up in the member's history
+	insertLog(&adminID, nil, authDomain.AuditActionLoginSuccess, authDomain.AuditLevelInfo, "Admin login", base)
+
+	cfg := &config.Config{
+		Environment: "test",
+		JWTSecret:   "test-jwt-secret-key-for-testing-only-and-this-is-long-enough",
+	}
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		userRepository.NewAuditRepository(db.DB, nil, nil),
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+	adminHandler := adminTransport.NewAdminHandler(cfg, logger, adminSvc)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/admin/users/:id/audit", func(c *gin.Context) {
+		c.Set("user_id", adminID)
+		c.Next()
+	}, adminHandler.GetUserAuditLogs)
+
+	list := func(t *testing.T, query string) (int, adminDomain.UserAuditLogResponse) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/admin/users/%d/audit?%s", memberID, query), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response adminDomain.UserAuditLogResponse
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return w.Code, response
+	}
+
+	t.Run("Paginates", func(t *testing.T) {
+		code, first := list(t, "page_size=10")
+		if code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+		}
+		if first.Pagination.Total != 35 || first.Pagination.TotalPages != 4 || !first.Pagination.HasNext {
+			t.Errorf("Unexpected pagination: %+v", first.Pagination)
+		}
+		if len(first.Logs) != 10 || first.Logs[0].Description != "Status changed: Suspicious Activity 4" {
+			t.Errorf("Expected the newest entry first, got %d logs", len(first.Logs))
+		}
+
+		_, last := list(t, "page_size=10&page=4")
+		if len(last.Logs) != 5 || last.Pagination.HasNext {
+			t.Errorf("Expected 5 entries on the last page, got %d", len(last.Logs))
+		}
+		if len(last.Logs) > 0 && last.Logs[len(last.Logs)-1].Description != "Login 0" {
+			t.Errorf("Expected the oldest entry last, got %q", last.Logs[len(last.Logs)-1].Description)
+		}
+	})
+
+	t.Run("FiltersByActionAndLevel", func(t *testing.T) {
+		_, byAction := list(t, "action="+string(authDomain.AuditActionUserStatusChanged))
+		if byAction.Pagination.Total != 5 {
+			t.Errorf("Expected 5 status changes, got %d", byAction.Pagination.Total)
+		}
+
+		_, byLevel := list(t, "level=info")
+		if byLevel.Pagination.Total != 30 {
+			t.Errorf("Expected 30 info entries, got %d", byLevel.Pagination.Total)
+		}
+
+		if code, _ := list(t, "level=debug"); code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an unknown level, got %d", http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("FiltersByDateRange", func(t *testing.T) {
+		_, response := list(t, "date_from=2024-03-10&date_to=2024-03-12")
+		if response.Pagination.Total != 3 {
+			t.Errorf("Expected 3 entries in the range, got %d", response.Pagination.Total)
+		}
+
+		if code, _ := list(t, "date_from=2024-03-12&date_to=2024-03-10"); code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an inverted range, got %d", http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("SearchesDescriptions", func(t *testing.T) {
+		_, response := list(t, "search=suspicious")
+		if response.Pagination.Total != 5 {
+			t.Errorf("Expected 5 matching entries, got %d", response.Pagination.Total)
+		}
+	})
+
+	t.Run("UnknownUser", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/users/9999/audit", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("DetailKeepsRecentSummary", func(t *testing.T) {
+		details, err := adminSvc.GetUserDetails(adminID, memberID)
+		if err != nil {
+			t.Fatalf("Failed to get user details: %v", err)
+		}
+		if len(details.AuditTrail) != 10 {
+			t.Errorf("Expected a 10 entry audit summary, got %d", len(details.AuditTrail))
+		}
+	})
+}
@@ -0,0 +1,210 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	adminService "github.com/acheevo/tfa/internal/admin/service"
+	authDomain "github.com/acheevo/tfa/internal/auth/domain"
+	"github.com/acheevo/tfa/internal/shared/clock"
+	"github.com/acheevo/tfa/internal/shared/config"
+	"github.com/acheevo/tfa/internal/shared/database"
+	userRepository "github.com/acheevo/tfa/internal/user/repository"
+)
+
+func TestIntegration_DeletedUserRetentionPurge(t *testing.T) {
+	ctx := context.Background()
+
+	// Create PostgreSQL container
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("fullstack_template_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres container: %v", err)
+	}
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get container host: %v", err)
+	}
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("Failed to get container port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://test:test@%s:%s/fullstack_template_test?sslmode=disable", host, port.Port())
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelError,
+	}))
+
+	db, err := database.New(dsn, false, logger, "test")
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+
+	// Seeds admin@fullstack.dev as user 1
+	if err := seedSimpleTestData(sqlDB); err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	cfg := &config.Config{
+		Environment:          "test",
+		UserDeletedRetention: "720h",
+	}
+
+	auditRepo := userRepository.NewAuditRepository(db.DB, nil, nil)
+	adminSvc := adminService.NewAdminService(
+		cfg,
+		logger,
+		userRepository.NewUserRepository(db.DB),
+		auditRepo,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		clock.New(),
+	)
+
+	expiredID := seedDeletedUser(t, sqlDB, "expired@fullstack.dev", authDomain.RoleUser, 45*24*time.Hour)
+	recentID := seedDeletedUser(t, sqlDB, "recent@fullstack.dev", authDomain.RoleUser, 24*time.Hour)
+
+	// The expired user has a session and appears in the audit trail as actor and target
+	if _, err := sqlDB.Exec(`INSERT INTO refresh_tokens (user_id, token, expires_at, created_at, updated_at)
+		VALUES ($1, 'expired-session', $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+		expiredID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to seed refresh token: %v", err)
+	}
+	if err := auditRepo.CreateAuditEntry(&expiredID, &expiredID, authDomain.AuditActionUserUpdated,
+		authDomain.AuditLevelInfo, "user", "Profile updated", "", "", nil); err != nil {
+		t.Fatalf("Failed to seed audit entry: %v", err)
+	}
+
+	t.Run("PurgesOnlyExpiredUsers", func(t *testing.T) {
+		purged, err := adminSvc.PurgeDeletedUsers(ctx)
+		if err != nil {
+			t.Fatalf("Purge failed: %v", err)
+		}
+		if purged != 1 {
+			t.Errorf("Expected 1 user purged, got %d", purged)
+		}
+
+		if userRowExists(t, sqlDB, expiredID) {
+			t.Error("Expected expired user to be permanently deleted")
+		}
+		if !userRowExists(t, sqlDB, recentID) {
+			t.Error("Expected recently deleted user to be kept")
+		}
+
+		var tokens int
+		if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM refresh_tokens WHERE user_id = $1`, expiredID).Scan(&tokens); err != nil {
+			t.Fatalf("Failed to count refresh tokens: %v", err)
+		}
+		if tokens != 0 {
+			t.Errorf("Expected refresh tokens to be removed, found %d", tokens)
+		}
+
+		var userEmail, targetEmail string
+		var userRef, targetRef sql.NullInt64
+		err = sqlDB.QueryRow(`SELECT user_email, target_email, user_id, target_id FROM audit_logs
+			WHERE action = $1`, string(authDomain.AuditActionUserUpdated)).Scan(&userEmail, &targetEmail, &userRef, &targetRef)
+		if err != nil {
+			t.Fatalf("Failed to read audit entry: %v", err)
+		}
+		if userEmail != "expired@fullstack.dev" || targetEmail != "expired@fullstack.dev" {
+			t.Errorf("Expected email snapshot on audit entry, got %q / %q", userEmail, targetEmail)
+		}
+		if userRef.Valid || targetRef.Valid {
+			t.Error("Expected audit references to the purged user to be cleared")
+		}
+	})
+
+	t.Run("KeepsLastAdmin", func(t *testing.T) {
+		// Soft-delete the only admin well beyond the retention period
+		if _, err := sqlDB.Exec(`UPDATE users SET deleted_at = $1 WHERE email = 'admin@fullstack.dev'`,
+			time.Now().Add(-60*24*time.Hour)); err != nil {
+			t.Fatalf("Failed to soft-delete admin: %v", err)
+		}
+
+		if _, err := adminSvc.PurgeDeletedUsers(ctx); err != nil {
+			t.Fatalf("Purge failed: %v", err)
+		}
+
+		var admins int
+		if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM users WHERE role = $1`, string(authDomain.RoleAdmin)).Scan(&admins); err != nil {
+			t.Fatalf("Failed to count admins: %v", err)
+		}
+		if admins != 1 {
+			t.Errorf("Expected the last admin to be kept, found %d admins", admins)
+		}
+	})
+}
+
+// seedDeletedUser inserts a user that was soft-deleted deletedAgo in the past
+func seedDeletedUser(t *testing.T, db *sql.DB, email string, role authDomain.UserRole, deletedAgo time.Duration) uint {
+	t.Helper()
+
+	var id uint
+	err := db.QueryRow(`
+	INSERT INTO users (email, password_hash, first_name, last_name, role, status, email_verified, created_at, updated_at, deleted_at)
+	VALUES ($1, 'hash', 'Deleted', 'User', $2, $3, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $4)
+	RETURNING id`,
+		email, string(role), string(authDomain.StatusActive), time.Now().Add(-deletedAgo)).Scan(&id)
+	if err != nil {
+		t.Fatalf("Failed to seed deleted user: %v", err)
+	}
+	return id
+}
+
+// userRowExists reports whether a user row exists, including soft-deleted rows
+func userRowExists(t *testing.T, db *sql.DB, id uint) bool {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM users WHERE id = $1`, id).Scan(&count); err != nil {
+		t.Fatalf("Failed to check user: %v", err)
+	}
+	return count > 0
+}